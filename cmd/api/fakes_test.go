@@ -0,0 +1,1132 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// stub is shared by the fake services. Each method returns a fixture along
+// with err, so setting err makes every service fail with it; handlers ignore
+// the fixture when the error is set.
+type stub struct {
+	err error
+}
+
+func newFakeServices(s *stub) services {
+	return services{
+		Users:              fakeUsers{stub: s},
+		APIKeys:            fakeAPIKeys{stub: s},
+		Wallets:            fakeWallets{stub: s},
+		Transactions:       fakeTransactions{stub: s},
+		Fees:               fakeFees{stub: s},
+		ScheduledTransfers: fakeScheduledTransfers{stub: s},
+		PaymentRequests:    fakePaymentRequests{stub: s},
+		Holds:              fakeHolds{stub: s},
+		Webhooks:           fakeWebhooks{stub: s},
+		Privacy:            fakePrivacy{stub: s},
+		Merchants:          fakeMerchants{stub: s},
+		Checkout:           fakeCheckout{stub: s},
+		Deposits:           fakeDeposits{stub: s},
+		DepositSimulator:   fakeSimulator{},
+		Withdrawals:        fakeWithdrawals{stub: s},
+		PayoutBatches:      fakePayoutBatches{stub: s},
+		SplitPayments:      fakeSplitPayments{stub: s},
+		Escrows:            fakeEscrows{stub: s},
+		Disputes:           fakeDisputes{stub: s},
+		Vouchers:           fakeVouchers{stub: s},
+		Stream:             fakeStream{stub: s},
+	}
+}
+
+var fixtureTime = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func amount(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+type fakeUsers struct {
+	domain.UserService
+	*stub
+}
+
+func testUser() *domain.User {
+	return &domain.User{
+		ID:               1,
+		Name:             "Ada Lovelace",
+		Email:            "ada@example.com",
+		EmailVerifiedAt:  ptr(fixtureTime),
+		Phone:            "+14155550100",
+		Timezone:         "Europe/London",
+		TwoFactorEnabled: true,
+		CreatedAt:        fixtureTime,
+		UpdatedAt:        fixtureTime,
+	}
+}
+
+func testEmailChange() *domain.EmailChange {
+	return &domain.EmailChange{
+		ID:             1,
+		OldEmail:       "ada@example.com",
+		NewEmail:       "ada@king.example",
+		Status:         domain.EmailChangeStatusPending,
+		OldConfirmedAt: ptr(fixtureTime),
+		ExpiresAt:      fixtureTime.Add(time.Hour),
+		CreatedAt:      fixtureTime,
+		UpdatedAt:      fixtureTime,
+	}
+}
+
+func testLoginResult() *domain.LoginResult {
+	return &domain.LoginResult{Token: "token"}
+}
+
+func (f fakeUsers) Signup(ctx context.Context, name, email, password string) (*domain.User, error) {
+	return testUser(), f.err
+}
+
+func (f fakeUsers) Login(ctx context.Context, email, password string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	return &domain.LoginResult{TwoFactorRequired: true, TwoFactorToken: "two-factor-token"}, f.err
+}
+
+func (f fakeUsers) CompleteTwoFactorLogin(ctx context.Context, twoFactorToken, code string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	return testLoginResult(), f.err
+}
+
+func (f fakeUsers) GetProfile(ctx context.Context, userID int64) (*domain.User, error) {
+	return testUser(), f.err
+}
+
+func (f fakeUsers) UpdateProfile(ctx context.Context, userID int64, update domain.ProfileUpdate, ifUnmodifiedSince *time.Time) (*domain.User, error) {
+	return testUser(), f.err
+}
+
+func (f fakeUsers) LoginHistory(ctx context.Context, userID int64) ([]*domain.LoginEvent, error) {
+	return []*domain.LoginEvent{{
+		ID:        1,
+		IP:        "203.0.113.7",
+		UserAgent: "curl/8.0",
+		Success:   true,
+		NewDevice: true,
+		CreatedAt: fixtureTime,
+	}}, f.err
+}
+
+func (f fakeUsers) VerifyPassword(ctx context.Context, userID int64, password string, client domain.ClientInfo) error {
+	return f.err
+}
+
+func (f fakeUsers) EnrollTOTP(ctx context.Context, userID int64) (*domain.TOTPEnrollment, error) {
+	return &domain.TOTPEnrollment{
+		Secret:          "GEZDGNBVGY3TQOJQ",
+		ProvisioningURI: "otpauth://totp/Wallet:ada@example.com?secret=GEZDGNBVGY3TQOJQ&issuer=Wallet",
+	}, f.err
+}
+
+func (f fakeUsers) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	return []string{"abcd-efgh", "ijkl-mnop"}, f.err
+}
+
+func (f fakeUsers) DisableTOTP(ctx context.Context, userID int64, code string, client domain.ClientInfo) error {
+	return f.err
+}
+
+func (f fakeUsers) StepUp(ctx context.Context, userID int64, code, password string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	return testLoginResult(), f.err
+}
+
+// VerifySession always admits the test token; the routes behind it are what
+// is under test.
+func (f fakeUsers) VerifySession(ctx context.Context, userID int64, issuedAt time.Time) error {
+	return nil
+}
+
+func (f fakeUsers) SendEmailVerification(ctx context.Context, userID int64) error {
+	return f.err
+}
+
+func (f fakeUsers) VerifyEmail(ctx context.Context, token string) error {
+	return f.err
+}
+
+func (f fakeUsers) ForgotPassword(ctx context.Context, email string) error {
+	return f.err
+}
+
+func (f fakeUsers) ResetPassword(ctx context.Context, token, newPassword string) error {
+	return f.err
+}
+
+func (f fakeUsers) ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	return testLoginResult(), f.err
+}
+
+func (f fakeUsers) RequestEmailChange(ctx context.Context, userID int64, newEmail, password string, client domain.ClientInfo) (*domain.EmailChange, error) {
+	return testEmailChange(), f.err
+}
+
+func (f fakeUsers) ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, error) {
+	return testEmailChange(), f.err
+}
+
+func (f fakeUsers) GetEmailChange(ctx context.Context, userID int64) (*domain.EmailChange, error) {
+	return testEmailChange(), f.err
+}
+
+func (f fakeUsers) CancelEmailChange(ctx context.Context, userID int64) error {
+	return f.err
+}
+
+type fakeAPIKeys struct {
+	domain.APIKeyService
+	*stub
+}
+
+func testAPIKey() *domain.APIKey {
+	return &domain.APIKey{
+		ID:               1,
+		UserID:           1,
+		Name:             "CI",
+		Prefix:           "wk_live_abcd",
+		Scopes:           []string{string(domain.ScopeWalletsRead)},
+		AllowedIPs:       []string{"203.0.113.0/24"},
+		RequireSignature: true,
+		ExpiresAt:        ptr(fixtureTime.Add(24 * time.Hour)),
+		CreatedAt:        fixtureTime,
+	}
+}
+
+func (f fakeAPIKeys) CreateAPIKey(ctx context.Context, userID int64, name string, scopes, allowedIPs []string, requireSignature bool, expiresAt *time.Time) (*domain.APIKey, error) {
+	key := testAPIKey()
+	key.Key = "wk_live_abcd_secret"
+	key.SigningSecret = "signing-secret"
+	return key, f.err
+}
+
+func (f fakeAPIKeys) ListAPIKeys(ctx context.Context, userID int64) ([]*domain.APIKey, error) {
+	return []*domain.APIKey{testAPIKey()}, f.err
+}
+
+func (f fakeAPIKeys) GetAPIKey(ctx context.Context, userID, keyID int64) (*domain.APIKey, error) {
+	return testAPIKey(), f.err
+}
+
+func (f fakeAPIKeys) RevokeAPIKey(ctx context.Context, userID, keyID int64) error {
+	return f.err
+}
+
+func (f fakeAPIKeys) RollSigningSecret(ctx context.Context, userID, keyID int64) (*domain.APIKey, error) {
+	key := testAPIKey()
+	key.SigningSecret = "signing-secret"
+	return key, f.err
+}
+
+type fakeWallets struct {
+	domain.WalletService
+	*stub
+}
+
+func (f fakeWallets) GetWalletByUserID(ctx context.Context, userID int64) (*domain.Wallet, error) {
+	return &domain.Wallet{
+		ID:               1,
+		UserID:           userID,
+		Balance:          amount("100.00"),
+		HeldBalance:      amount("10.00"),
+		AvailableBalance: amount("90.00"),
+		Currency:         "USD",
+		CreatedAt:        fixtureTime,
+		UpdatedAt:        fixtureTime,
+	}, f.err
+}
+
+type fakeTransactions struct {
+	domain.TransactionService
+	*stub
+}
+
+func testTransaction() *domain.Transaction {
+	return &domain.Transaction{
+		ID:               1,
+		SenderWalletID:   1,
+		ReceiverWalletID: 2,
+		Amount:           amount("10.00"),
+		Fee:              amount("0.25"),
+		FeeWalletID:      ptr(int64(9)),
+		Status:           domain.TransactionStatusPending,
+		CreatedAt:        fixtureTime.Format(time.RFC3339),
+		UpdatedAt:        fixtureTime.Format(time.RFC3339),
+	}
+}
+
+func (f fakeTransactions) CreateTransfer(ctx context.Context, senderUserID, recieverUserID int64, amount decimal.Decimal) (*domain.Transaction, error) {
+	return testTransaction(), f.err
+}
+
+func (f fakeTransactions) ListHistory(ctx context.Context, userID int64) ([]*domain.HistoryEntry, error) {
+	return []*domain.HistoryEntry{
+		{Type: domain.HistoryEntryTypeTransfer, Transaction: testTransaction()},
+		{Type: domain.HistoryEntryTypeSplitPayment, SplitPayment: testSplitPayment()},
+	}, f.err
+}
+
+type fakeFees struct {
+	domain.FeeService
+	*stub
+}
+
+func (f fakeFees) PreviewTransferFee(ctx context.Context, senderUserID, receiverUserID int64, amount decimal.Decimal) (*domain.FeeQuote, error) {
+	return &domain.FeeQuote{
+		Amount:        amount,
+		Fee:           decimal.RequireFromString("0.25"),
+		NetAmount:     amount.Sub(decimal.RequireFromString("0.25")),
+		FeePaidBy:     domain.FeePayerReceiver,
+		Currency:      "USD",
+		FeeScheduleID: ptr(int64(1)),
+	}, f.err
+}
+
+func testFeeSchedule() *domain.FeeSchedule {
+	return &domain.FeeSchedule{
+		ID:         1,
+		Plan:       ptr(domain.FeePlanPersonal),
+		Currency:   ptr("USD"),
+		Type:       domain.FeeTypePercentage,
+		FlatAmount: decimal.RequireFromString("0.30"),
+		Percentage: decimal.RequireFromString("2.9"),
+		MinFee:     decimal.NewNullDecimal(decimal.RequireFromString("0.50")),
+		Active:     true,
+		CreatedAt:  fixtureTime,
+		UpdatedAt:  fixtureTime,
+	}
+}
+
+func (f fakeFees) CreateFeeSchedule(ctx context.Context, schedule *domain.FeeSchedule) (*domain.FeeSchedule, error) {
+	return testFeeSchedule(), f.err
+}
+
+func (f fakeFees) ListFeeSchedules(ctx context.Context) ([]*domain.FeeSchedule, error) {
+	return []*domain.FeeSchedule{testFeeSchedule()}, f.err
+}
+
+func (f fakeFees) GetFeeSchedule(ctx context.Context, id int64) (*domain.FeeSchedule, error) {
+	return testFeeSchedule(), f.err
+}
+
+func (f fakeFees) DeactivateFeeSchedule(ctx context.Context, id int64) (*domain.FeeSchedule, error) {
+	schedule := testFeeSchedule()
+	schedule.Active = false
+	return schedule, f.err
+}
+
+func (f fakeFees) ListRevenueWallets(ctx context.Context) ([]*domain.RevenueWallet, error) {
+	return []*domain.RevenueWallet{{Currency: "USD", WalletID: 9}}, f.err
+}
+
+func (f fakeFees) SetRevenueWallet(ctx context.Context, currency string, walletID int64) (*domain.RevenueWallet, error) {
+	return &domain.RevenueWallet{Currency: currency, WalletID: walletID}, f.err
+}
+
+type fakeScheduledTransfers struct {
+	domain.ScheduledTransferService
+	*stub
+}
+
+func testScheduledTransfer() *domain.ScheduledTransfer {
+	return &domain.ScheduledTransfer{
+		ID:             1,
+		SenderUserID:   1,
+		ReceiverUserID: 2,
+		Amount:         amount("10.00"),
+		Memo:           "Rent",
+		ScheduleType:   domain.ScheduleTypeMonthly,
+		DayOfMonth:     1,
+		NextRunAt:      ptr(fixtureTime.AddDate(0, 1, 0)),
+		LastRunAt:      ptr(fixtureTime),
+		Status:         domain.ScheduledTransferStatusActive,
+		CreatedAt:      fixtureTime,
+		UpdatedAt:      fixtureTime,
+	}
+}
+
+func (f fakeScheduledTransfers) CreateScheduledTransfer(ctx context.Context, st *domain.ScheduledTransfer) (*domain.ScheduledTransfer, error) {
+	return testScheduledTransfer(), f.err
+}
+
+func (f fakeScheduledTransfers) ListScheduledTransfers(ctx context.Context, userID int64) ([]*domain.ScheduledTransfer, error) {
+	return []*domain.ScheduledTransfer{testScheduledTransfer()}, f.err
+}
+
+func (f fakeScheduledTransfers) GetScheduledTransfer(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, []*domain.ScheduledTransferRun, error) {
+	runs := []*domain.ScheduledTransferRun{{
+		ID:                  1,
+		ScheduledTransferID: id,
+		ScheduledFor:        fixtureTime,
+		TransactionID:       ptr(int64(1)),
+		Status:              domain.ScheduledTransferRunStatusSucceeded,
+		CreatedAt:           fixtureTime,
+	}}
+	return testScheduledTransfer(), runs, f.err
+}
+
+func (f fakeScheduledTransfers) PauseScheduledTransfer(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, error) {
+	return testScheduledTransfer(), f.err
+}
+
+func (f fakeScheduledTransfers) ResumeScheduledTransfer(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, error) {
+	return testScheduledTransfer(), f.err
+}
+
+func (f fakeScheduledTransfers) CancelScheduledTransfer(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, error) {
+	return testScheduledTransfer(), f.err
+}
+
+type fakePaymentRequests struct {
+	domain.PaymentRequestService
+	*stub
+}
+
+func testPaymentRequest() *domain.PaymentRequest {
+	return &domain.PaymentRequest{
+		ID:              1,
+		RequesterUserID: 1,
+		PayerUserID:     2,
+		Amount:          amount("10.00"),
+		Memo:            "Dinner",
+		Status:          domain.PaymentRequestStatusPending,
+		ExpiresAt:       fixtureTime.Add(7 * 24 * time.Hour),
+		CreatedAt:       fixtureTime,
+		UpdatedAt:       fixtureTime,
+	}
+}
+
+func (f fakePaymentRequests) CreatePaymentRequest(ctx context.Context, requesterUserID, payerUserID int64, amount decimal.Decimal, memo string, ttl time.Duration) (*domain.PaymentRequest, error) {
+	return testPaymentRequest(), f.err
+}
+
+func (f fakePaymentRequests) ListIncomingPaymentRequests(ctx context.Context, userID int64) ([]*domain.PaymentRequest, error) {
+	return []*domain.PaymentRequest{testPaymentRequest()}, f.err
+}
+
+func (f fakePaymentRequests) ListOutgoingPaymentRequests(ctx context.Context, userID int64) ([]*domain.PaymentRequest, error) {
+	return []*domain.PaymentRequest{testPaymentRequest()}, f.err
+}
+
+func (f fakePaymentRequests) GetPaymentRequestForPayer(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+	return testPaymentRequest(), f.err
+}
+
+func (f fakePaymentRequests) AcceptPaymentRequest(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+	pr := testPaymentRequest()
+	pr.Status = domain.PaymentRequestStatusAccepted
+	pr.TransactionID = ptr(int64(1))
+	return pr, f.err
+}
+
+func (f fakePaymentRequests) DeclinePaymentRequest(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+	return testPaymentRequest(), f.err
+}
+
+func (f fakePaymentRequests) CancelPaymentRequest(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+	return testPaymentRequest(), f.err
+}
+
+type fakeHolds struct {
+	domain.HoldService
+	*stub
+}
+
+func testHold() *domain.Hold {
+	return &domain.Hold{
+		ID:             1,
+		WalletID:       1,
+		PayeeWalletID:  2,
+		Amount:         amount("10.00"),
+		CapturedAmount: decimal.Zero,
+		Reference:      "order-1",
+		Status:         domain.HoldStatusAuthorized,
+		ExpiresAt:      fixtureTime.Add(7 * 24 * time.Hour),
+		CreatedAt:      fixtureTime,
+		UpdatedAt:      fixtureTime,
+	}
+}
+
+func (f fakeHolds) AuthorizeHold(ctx context.Context, payerUserID, payeeUserID int64, amount decimal.Decimal, reference string, ttl time.Duration) (*domain.Hold, error) {
+	return testHold(), f.err
+}
+
+func (f fakeHolds) CaptureHold(ctx context.Context, payeeUserID, holdID int64, amount decimal.Decimal) (*domain.Hold, error) {
+	hold := testHold()
+	hold.Status = domain.HoldStatusCaptured
+	hold.CapturedAmount = hold.Amount
+	hold.TransactionID = ptr(int64(1))
+	return hold, f.err
+}
+
+func (f fakeHolds) VoidHold(ctx context.Context, payeeUserID, holdID int64) (*domain.Hold, error) {
+	return testHold(), f.err
+}
+
+func (f fakeHolds) GetHold(ctx context.Context, userID, holdID int64) (*domain.Hold, error) {
+	return testHold(), f.err
+}
+
+func (f fakeHolds) ListHolds(ctx context.Context, userID int64) ([]*domain.Hold, error) {
+	return []*domain.Hold{testHold()}, f.err
+}
+
+type fakeWebhooks struct {
+	domain.WebhookService
+	*stub
+}
+
+func testEndpoint() *domain.WebhookEndpoint {
+	return &domain.WebhookEndpoint{
+		ID:          1,
+		UserID:      1,
+		URL:         "https://example.com/hooks",
+		EventTypes:  []string{"transfer.*"},
+		Description: "Transfers",
+		Status:      domain.WebhookEndpointStatusActive,
+		CreatedAt:   fixtureTime,
+		UpdatedAt:   fixtureTime,
+	}
+}
+
+func testDelivery() *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:            2,
+		EndpointID:    1,
+		EventID:       3,
+		EventType:     "transfer.completed",
+		Status:        domain.WebhookDeliveryStatusFailed,
+		Attempts:      1,
+		LastAttemptAt: ptr(fixtureTime),
+		CreatedAt:     fixtureTime,
+		UpdatedAt:     fixtureTime,
+	}
+}
+
+func (f fakeWebhooks) CreateEndpoint(ctx context.Context, userID int64, url string, eventTypes []string, description string) (*domain.WebhookEndpoint, error) {
+	endpoint := testEndpoint()
+	endpoint.Secret = "whsec_secret"
+	return endpoint, f.err
+}
+
+func (f fakeWebhooks) ListEndpoints(ctx context.Context, userID int64) ([]*domain.WebhookEndpoint, error) {
+	return []*domain.WebhookEndpoint{testEndpoint()}, f.err
+}
+
+func (f fakeWebhooks) GetEndpoint(ctx context.Context, userID, endpointID int64) (*domain.WebhookEndpoint, error) {
+	return testEndpoint(), f.err
+}
+
+func (f fakeWebhooks) DisableEndpoint(ctx context.Context, userID, endpointID int64) error {
+	return f.err
+}
+
+func (f fakeWebhooks) EnableEndpoint(ctx context.Context, userID, endpointID int64) (*domain.WebhookEndpoint, error) {
+	return testEndpoint(), f.err
+}
+
+func (f fakeWebhooks) ListDeliveries(ctx context.Context, userID, endpointID int64) ([]*domain.WebhookDelivery, error) {
+	return []*domain.WebhookDelivery{testDelivery()}, f.err
+}
+
+func (f fakeWebhooks) GetDelivery(ctx context.Context, userID, endpointID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	delivery := testDelivery()
+	delivery.AttemptLog = []*domain.WebhookDeliveryAttempt{{
+		ID:             1,
+		DeliveryID:     delivery.ID,
+		ResponseStatus: http.StatusServiceUnavailable,
+		ResponseBody:   "unavailable",
+		DurationMS:     120,
+		CreatedAt:      fixtureTime,
+	}}
+	return delivery, f.err
+}
+
+func (f fakeWebhooks) Redeliver(ctx context.Context, userID, endpointID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	delivery := testDelivery()
+	delivery.Status = domain.WebhookDeliveryStatusPending
+	return delivery, f.err
+}
+
+type fakePrivacy struct {
+	domain.PrivacyService
+	*stub
+}
+
+const exportBody = `{"profile":{"name":"Ada Lovelace"}}`
+
+func testExport() *domain.DataExport {
+	return &domain.DataExport{
+		ID:          1,
+		Format:      domain.DataExportFormatJSON,
+		Status:      domain.DataExportStatusReady,
+		SizeBytes:   int64(len(exportBody)),
+		CompletedAt: ptr(fixtureTime),
+		ExpiresAt:   ptr(fixtureTime.Add(7 * 24 * time.Hour)),
+		CreatedAt:   fixtureTime,
+		UpdatedAt:   fixtureTime,
+	}
+}
+
+func (f fakePrivacy) RequestDataExport(ctx context.Context, userID int64, format domain.DataExportFormat) (*domain.DataExport, error) {
+	export := testExport()
+	export.Status = domain.DataExportStatusPending
+	export.SizeBytes = 0
+	export.CompletedAt = nil
+	export.ExpiresAt = nil
+	return export, f.err
+}
+
+func (f fakePrivacy) ListDataExports(ctx context.Context, userID int64) ([]*domain.DataExport, error) {
+	return []*domain.DataExport{testExport()}, f.err
+}
+
+func (f fakePrivacy) GetDataExport(ctx context.Context, userID, id int64) (*domain.DataExport, error) {
+	return testExport(), f.err
+}
+
+func (f fakePrivacy) OpenDataExport(ctx context.Context, userID, id int64) (*domain.DataExport, io.ReadCloser, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	return testExport(), io.NopCloser(strings.NewReader(exportBody)), nil
+}
+
+func (f fakePrivacy) EraseUser(ctx context.Context, userID int64, password string, client domain.ClientInfo) error {
+	return f.err
+}
+
+type fakeMerchants struct {
+	domain.MerchantService
+	*stub
+}
+
+func testMerchant() *domain.Merchant {
+	return &domain.Merchant{
+		ID:                 1,
+		UserID:             1,
+		BusinessName:       "Acme",
+		LegalName:          "Acme Ltd",
+		Website:            "https://acme.example",
+		SupportEmail:       "help@acme.example",
+		Country:            "GB",
+		SettlementWalletID: 1,
+		FeePlan:            "standard",
+		Status:             domain.MerchantStatusActive,
+		CreatedAt:          fixtureTime,
+		UpdatedAt:          fixtureTime,
+	}
+}
+
+func (f fakeMerchants) CreateMerchant(ctx context.Context, userID int64, profile domain.MerchantProfile) (*domain.Merchant, error) {
+	return testMerchant(), f.err
+}
+
+func (f fakeMerchants) GetMerchant(ctx context.Context, userID int64) (*domain.Merchant, error) {
+	return testMerchant(), f.err
+}
+
+func (f fakeMerchants) UpdateMerchant(ctx context.Context, userID int64, profile domain.MerchantProfile) (*domain.Merchant, error) {
+	return testMerchant(), f.err
+}
+
+type fakeCheckout struct {
+	domain.CheckoutService
+	*stub
+}
+
+func testCheckoutSession() *domain.CheckoutSession {
+	return &domain.CheckoutSession{
+		ID:          1,
+		MerchantID:  1,
+		Token:       "cs_test",
+		Amount:      amount("10.00"),
+		Currency:    "USD",
+		Reference:   "order-1",
+		Description: "One widget",
+		SuccessURL:  "https://acme.example/ok",
+		CancelURL:   "https://acme.example/cancel",
+		Status:      domain.CheckoutSessionStatusOpen,
+		ExpiresAt:   fixtureTime.Add(time.Hour),
+		CreatedAt:   fixtureTime,
+		UpdatedAt:   fixtureTime,
+	}
+}
+
+func (f fakeCheckout) CreateCheckoutSession(ctx context.Context, userID int64, params domain.CheckoutSessionParams) (*domain.CheckoutSession, error) {
+	return testCheckoutSession(), f.err
+}
+
+func (f fakeCheckout) ListCheckoutSessions(ctx context.Context, userID int64) ([]*domain.CheckoutSession, error) {
+	return []*domain.CheckoutSession{testCheckoutSession()}, f.err
+}
+
+func (f fakeCheckout) GetCheckoutSession(ctx context.Context, userID, id int64) (*domain.CheckoutSession, error) {
+	return testCheckoutSession(), f.err
+}
+
+func (f fakeCheckout) CancelCheckoutSession(ctx context.Context, userID, id int64) (*domain.CheckoutSession, error) {
+	session := testCheckoutSession()
+	session.Status = domain.CheckoutSessionStatusCancelled
+	return session, f.err
+}
+
+func (f fakeCheckout) GetCheckoutSessionForPayer(ctx context.Context, token string) (*domain.CheckoutSession, error) {
+	session := testCheckoutSession()
+	session.MerchantName = "Acme"
+	return session, f.err
+}
+
+func (f fakeCheckout) ConfirmCheckoutSession(ctx context.Context, payerUserID int64, token string) (*domain.CheckoutSession, error) {
+	session := testCheckoutSession()
+	session.Status = domain.CheckoutSessionStatusProcessing
+	session.PayerUserID = ptr(payerUserID)
+	session.TransactionID = ptr(int64(1))
+	return session, f.err
+}
+
+type fakeDeposits struct {
+	domain.DepositService
+	*stub
+}
+
+func testDeposit() *domain.Deposit {
+	return &domain.Deposit{
+		ID:               1,
+		UserID:           1,
+		WalletID:         1,
+		Amount:           amount("10.00"),
+		Currency:         "USD",
+		Gateway:          "simulated",
+		GatewayReference: ptr("pi_1"),
+		PaymentURL:       "https://pay.example/pi_1",
+		Status:           domain.DepositStatusPending,
+		ExpiresAt:        fixtureTime.Add(time.Hour),
+		CreatedAt:        fixtureTime,
+		UpdatedAt:        fixtureTime,
+	}
+}
+
+func (f fakeDeposits) CreateDeposit(ctx context.Context, userID int64, amount decimal.Decimal) (*domain.Deposit, error) {
+	return testDeposit(), f.err
+}
+
+func (f fakeDeposits) ListDeposits(ctx context.Context, userID int64) ([]*domain.Deposit, error) {
+	return []*domain.Deposit{testDeposit()}, f.err
+}
+
+func (f fakeDeposits) GetDeposit(ctx context.Context, userID, id int64) (*domain.Deposit, error) {
+	return testDeposit(), f.err
+}
+
+func (f fakeDeposits) HandleGatewayCallback(ctx context.Context, gateway string, header http.Header, body []byte) (*domain.Deposit, error) {
+	deposit := testDeposit()
+	deposit.Status = domain.DepositStatusSucceeded
+	deposit.CompletedAt = ptr(fixtureTime)
+	return deposit, f.err
+}
+
+type fakeSimulator struct{}
+
+func (fakeSimulator) Callback(deposit *domain.Deposit, succeeded bool) (http.Header, []byte, error) {
+	return http.Header{}, []byte(`{}`), nil
+}
+
+type fakeWithdrawals struct {
+	domain.WithdrawalService
+	*stub
+}
+
+func testBeneficiary() *domain.BankBeneficiary {
+	return &domain.BankBeneficiary{
+		ID:                1,
+		UserID:            1,
+		Nickname:          "Savings",
+		AccountHolderName: "Ada Lovelace",
+		Country:           "DE",
+		Currency:          "EUR",
+		AccountType:       domain.BankAccountTypeIBAN,
+		AccountLast4:      "3000",
+		RoutingCode:       "COBADEFFXXX",
+		CreatedAt:         fixtureTime,
+		UpdatedAt:         fixtureTime,
+	}
+}
+
+func testWithdrawal() *domain.Withdrawal {
+	return &domain.Withdrawal{
+		ID:                1,
+		UserID:            1,
+		WalletID:          1,
+		BeneficiaryID:     1,
+		Amount:            amount("10.00"),
+		Currency:          "EUR",
+		Provider:          "simulated",
+		ProviderReference: ptr("po_1"),
+		Status:            domain.WithdrawalStatusPending,
+		CreatedAt:         fixtureTime,
+		UpdatedAt:         fixtureTime,
+	}
+}
+
+func (f fakeWithdrawals) CreateBeneficiary(ctx context.Context, userID int64, params domain.BankBeneficiaryParams) (*domain.BankBeneficiary, error) {
+	return testBeneficiary(), f.err
+}
+
+func (f fakeWithdrawals) ListBeneficiaries(ctx context.Context, userID int64) ([]*domain.BankBeneficiary, error) {
+	return []*domain.BankBeneficiary{testBeneficiary()}, f.err
+}
+
+func (f fakeWithdrawals) GetBeneficiary(ctx context.Context, userID, id int64) (*domain.BankBeneficiary, error) {
+	return testBeneficiary(), f.err
+}
+
+func (f fakeWithdrawals) DeleteBeneficiary(ctx context.Context, userID, id int64) error {
+	return f.err
+}
+
+func (f fakeWithdrawals) CreateWithdrawal(ctx context.Context, userID, beneficiaryID int64, amount decimal.Decimal) (*domain.Withdrawal, error) {
+	return testWithdrawal(), f.err
+}
+
+func (f fakeWithdrawals) ListWithdrawals(ctx context.Context, userID int64) ([]*domain.Withdrawal, error) {
+	return []*domain.Withdrawal{testWithdrawal()}, f.err
+}
+
+func (f fakeWithdrawals) GetWithdrawal(ctx context.Context, userID, id int64) (*domain.Withdrawal, error) {
+	withdrawal := testWithdrawal()
+	withdrawal.Status = domain.WithdrawalStatusCompleted
+	withdrawal.CompletedAt = ptr(fixtureTime)
+	return withdrawal, f.err
+}
+
+func (f fakeWithdrawals) CancelWithdrawal(ctx context.Context, userID, id int64) (*domain.Withdrawal, error) {
+	withdrawal := testWithdrawal()
+	withdrawal.Status = domain.WithdrawalStatusCancelled
+	return withdrawal, f.err
+}
+
+type fakePayoutBatches struct {
+	domain.PayoutBatchService
+	*stub
+}
+
+func testPayoutBatch() *domain.PayoutBatch {
+	return &domain.PayoutBatch{
+		ID:             1,
+		UserID:         1,
+		WalletID:       1,
+		Currency:       "USD",
+		TotalAmount:    amount("10.00"),
+		ReservedAmount: amount("10.00"),
+		ItemCount:      1,
+		Status:         domain.PayoutBatchStatusProcessing,
+		CreatedAt:      fixtureTime,
+		UpdatedAt:      fixtureTime,
+		Items: []*domain.PayoutBatchItem{{
+			ID:              1,
+			BatchID:         1,
+			LineNumber:      2,
+			RecipientUserID: 2,
+			Amount:          amount("10.00"),
+			Reference:       "inv-1",
+			Status:          domain.PayoutBatchItemStatusPending,
+			CreatedAt:       fixtureTime,
+			UpdatedAt:       fixtureTime,
+		}},
+	}
+}
+
+func (f fakePayoutBatches) CreatePayoutBatch(ctx context.Context, userID int64, rows []domain.PayoutBatchRow) (*domain.PayoutBatch, error) {
+	return testPayoutBatch(), f.err
+}
+
+func (f fakePayoutBatches) ListPayoutBatches(ctx context.Context, userID int64) ([]*domain.PayoutBatch, error) {
+	batch := testPayoutBatch()
+	batch.Items = nil
+	return []*domain.PayoutBatch{batch}, f.err
+}
+
+func (f fakePayoutBatches) GetPayoutBatch(ctx context.Context, userID, id int64) (*domain.PayoutBatch, error) {
+	return testPayoutBatch(), f.err
+}
+
+type fakeSplitPayments struct {
+	domain.SplitPaymentService
+	*stub
+}
+
+func testSplitPayment() *domain.SplitPayment {
+	leg := testTransaction()
+	leg.SplitPaymentID = ptr(int64(1))
+
+	return &domain.SplitPayment{
+		ID:             1,
+		SenderWalletID: 1,
+		Amount:         amount("10.00"),
+		Currency:       "USD",
+		Description:    "Dinner",
+		Status:         domain.SplitPaymentStatusPending,
+		CreatedAt:      fixtureTime,
+		UpdatedAt:      fixtureTime,
+		Legs:           []*domain.Transaction{leg},
+	}
+}
+
+func (f fakeSplitPayments) CreateSplitPayment(ctx context.Context, senderUserID int64, amount decimal.Decimal, recipients []domain.SplitRecipient, description string) (*domain.SplitPayment, error) {
+	return testSplitPayment(), f.err
+}
+
+func (f fakeSplitPayments) ListSplitPayments(ctx context.Context, userID int64) ([]*domain.SplitPayment, error) {
+	return []*domain.SplitPayment{testSplitPayment()}, f.err
+}
+
+func (f fakeSplitPayments) GetSplitPayment(ctx context.Context, userID, id int64) (*domain.SplitPayment, error) {
+	return testSplitPayment(), f.err
+}
+
+type fakeEscrows struct {
+	domain.EscrowService
+	*stub
+}
+
+func testEscrow() *domain.Escrow {
+	return &domain.Escrow{
+		ID:            1,
+		PayerWalletID: 1,
+		PayeeWalletID: 2,
+		ArbiterUserID: ptr(int64(3)),
+		Amount:        amount("10.00"),
+		Currency:      "USD",
+		Description:   "Laptop",
+		Status:        domain.EscrowStatusFunded,
+		ReleaseAfter:  fixtureTime.Add(14 * 24 * time.Hour),
+		CreatedAt:     fixtureTime,
+		UpdatedAt:     fixtureTime,
+	}
+}
+
+func (f fakeEscrows) CreateEscrow(ctx context.Context, payerUserID, payeeUserID int64, arbiterUserID *int64, amount decimal.Decimal, description string, releaseAfter time.Duration) (*domain.Escrow, error) {
+	return testEscrow(), f.err
+}
+
+func (f fakeEscrows) ReleaseEscrow(ctx context.Context, userID, id int64) (*domain.Escrow, error) {
+	escrow := testEscrow()
+	escrow.Status = domain.EscrowStatusReleased
+	escrow.SettledByUserID = ptr(userID)
+	escrow.SettledAt = ptr(fixtureTime)
+	return escrow, f.err
+}
+
+func (f fakeEscrows) RefundEscrow(ctx context.Context, userID, id int64) (*domain.Escrow, error) {
+	escrow := testEscrow()
+	escrow.Status = domain.EscrowStatusRefunded
+	escrow.SettledByUserID = ptr(userID)
+	escrow.SettledAt = ptr(fixtureTime)
+	return escrow, f.err
+}
+
+func (f fakeEscrows) DisputeEscrow(ctx context.Context, userID, id int64, reason string) (*domain.Escrow, error) {
+	escrow := testEscrow()
+	escrow.Status = domain.EscrowStatusDisputed
+	escrow.DisputedByUserID = ptr(userID)
+	escrow.DisputeReason = reason
+	escrow.DisputedAt = ptr(fixtureTime)
+	return escrow, f.err
+}
+
+func (f fakeEscrows) GetEscrow(ctx context.Context, userID, id int64) (*domain.Escrow, error) {
+	return testEscrow(), f.err
+}
+
+func (f fakeEscrows) ListEscrows(ctx context.Context, userID int64) ([]*domain.Escrow, error) {
+	return []*domain.Escrow{testEscrow()}, f.err
+}
+
+type fakeDisputes struct {
+	domain.DisputeService
+	*stub
+}
+
+func testDispute() *domain.Dispute {
+	return &domain.Dispute{
+		ID:               1,
+		TransactionID:    1,
+		PayerWalletID:    1,
+		MerchantWalletID: 2,
+		Amount:           amount("10.00"),
+		Currency:         "USD",
+		Reason:           "Not delivered",
+		Status:           domain.DisputeStatusOpen,
+		RespondBy:        fixtureTime.Add(7 * 24 * time.Hour),
+		CreatedAt:        fixtureTime,
+		UpdatedAt:        fixtureTime,
+		Evidence: []*domain.DisputeEvidence{{
+			ID:        1,
+			DisputeID: 1,
+			UserID:    1,
+			Party:     domain.DisputePartyPayer,
+			Text:      "Tracking shows no delivery",
+			FileRefs:  []string{"doc-1"},
+			CreatedAt: fixtureTime,
+		}},
+	}
+}
+
+func testResolvedDispute(adminUserID int64, status domain.DisputeStatus) *domain.Dispute {
+	dispute := testDispute()
+	dispute.Status = status
+	dispute.ResolutionNote = "Refunded"
+	dispute.ResolvedByUserID = ptr(adminUserID)
+	dispute.ResolvedAt = ptr(fixtureTime)
+	return dispute
+}
+
+func (f fakeDisputes) OpenDispute(ctx context.Context, userID, transactionID int64, amount decimal.Decimal, reason string) (*domain.Dispute, error) {
+	return testDispute(), f.err
+}
+
+func (f fakeDisputes) SubmitDisputeEvidence(ctx context.Context, userID, id int64, text string, fileRefs []string) (*domain.Dispute, error) {
+	dispute := testDispute()
+	dispute.Status = domain.DisputeStatusUnderReview
+	dispute.RespondedAt = ptr(fixtureTime)
+	return dispute, f.err
+}
+
+func (f fakeDisputes) AcceptDispute(ctx context.Context, userID, id int64) (*domain.Dispute, error) {
+	return testResolvedDispute(userID, domain.DisputeStatusPayerWon), f.err
+}
+
+func (f fakeDisputes) WithdrawDispute(ctx context.Context, userID, id int64) (*domain.Dispute, error) {
+	dispute := testDispute()
+	dispute.Status = domain.DisputeStatusWithdrawn
+	return dispute, f.err
+}
+
+func (f fakeDisputes) GetDispute(ctx context.Context, userID, id int64) (*domain.Dispute, error) {
+	return testDispute(), f.err
+}
+
+func (f fakeDisputes) ListDisputes(ctx context.Context, userID int64) ([]*domain.Dispute, error) {
+	return []*domain.Dispute{testDispute()}, f.err
+}
+
+func (f fakeDisputes) AdminListDisputes(ctx context.Context, status domain.DisputeStatus) ([]*domain.Dispute, error) {
+	return []*domain.Dispute{testDispute()}, f.err
+}
+
+func (f fakeDisputes) AdminGetDispute(ctx context.Context, id int64) (*domain.Dispute, error) {
+	return testDispute(), f.err
+}
+
+func (f fakeDisputes) ResolveDispute(ctx context.Context, adminUserID, id int64, status domain.DisputeStatus, note string) (*domain.Dispute, error) {
+	return testResolvedDispute(adminUserID, status), f.err
+}
+
+type fakeVouchers struct {
+	domain.VoucherService
+	*stub
+}
+
+func testVoucherBatch() *domain.VoucherBatch {
+	return &domain.VoucherBatch{
+		ID:              1,
+		Name:            "Launch",
+		Amount:          amount("5.00"),
+		Currency:        "USD",
+		ExpiresAt:       fixtureTime.Add(30 * 24 * time.Hour),
+		MaxRedemptions:  1,
+		PerUserLimit:    1,
+		CodeCount:       2,
+		RedemptionCount: 1,
+		RedeemedAmount:  amount("5.00"),
+		Status:          domain.VoucherBatchStatusActive,
+		CreatedByUserID: 1,
+		CreatedAt:       fixtureTime,
+		UpdatedAt:       fixtureTime,
+	}
+}
+
+func testRedemption() *domain.VoucherRedemption {
+	return &domain.VoucherRedemption{
+		ID:        1,
+		VoucherID: 1,
+		BatchID:   1,
+		UserID:    1,
+		WalletID:  1,
+		Amount:    amount("5.00"),
+		Currency:  "USD",
+		CreatedAt: fixtureTime,
+	}
+}
+
+func (f fakeVouchers) CreateVoucherBatch(ctx context.Context, adminUserID int64, name string, amount decimal.Decimal, currency string, expiresAt time.Time, maxRedemptions, perUserLimit, codeCount int) (*domain.VoucherBatch, error) {
+	batch := testVoucherBatch()
+	batch.RedemptionCount = 0
+	batch.RedeemedAmount = decimal.Zero
+	batch.Codes = []string{"ABCD-EFGH", "IJKL-MNOP"}
+	return batch, f.err
+}
+
+func (f fakeVouchers) ListVoucherBatches(ctx context.Context) ([]*domain.VoucherBatch, error) {
+	return []*domain.VoucherBatch{testVoucherBatch()}, f.err
+}
+
+func (f fakeVouchers) GetVoucherBatch(ctx context.Context, id int64) (*domain.VoucherBatch, error) {
+	return testVoucherBatch(), f.err
+}
+
+func (f fakeVouchers) DisableVoucherBatch(ctx context.Context, id int64) (*domain.VoucherBatch, error) {
+	batch := testVoucherBatch()
+	batch.Status = domain.VoucherBatchStatusDisabled
+	return batch, f.err
+}
+
+func (f fakeVouchers) GetVoucherBatchReport(ctx context.Context, id int64) (*domain.VoucherBatchReport, error) {
+	return &domain.VoucherBatchReport{
+		Batch:           testVoucherBatch(),
+		CodesRedeemed:   1,
+		UniqueRedeemers: 1,
+		Days: []*domain.VoucherRedemptionDay{{
+			Date:        fixtureTime.Format(time.DateOnly),
+			Redemptions: 1,
+			Amount:      amount("5.00"),
+		}},
+	}, f.err
+}
+
+func (f fakeVouchers) RedeemVoucher(ctx context.Context, userID int64, code string) (*domain.VoucherRedemption, error) {
+	return testRedemption(), f.err
+}
+
+func (f fakeVouchers) ListVoucherRedemptions(ctx context.Context, userID int64) ([]*domain.VoucherRedemption, error) {
+	return []*domain.VoucherRedemption{testRedemption()}, f.err
+}
+
+type fakeStream struct {
+	domain.StreamService
+	*stub
+}
+
+// Subscribe returns a closed channel, so the stream ends after the snapshot.
+func (f fakeStream) Subscribe(ctx context.Context, userID int64, lastEventID string) (<-chan domain.StreamEvent, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	events := make(chan domain.StreamEvent)
+	close(events)
+	return events, nil
+}
@@ -1,19 +1,29 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	_ "time/tzdata"
 
+	grpcapi "github.com/amankp-zop/wallet/internal/api/grpc"
 	"github.com/amankp-zop/wallet/internal/api/handler"
 	authenticationMiddleware "github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/api/openapi"
+	"github.com/amankp-zop/wallet/internal/auth"
 	"github.com/amankp-zop/wallet/internal/config"
 	"github.com/amankp-zop/wallet/internal/database"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+	"github.com/amankp-zop/wallet/internal/gateway"
+	"github.com/amankp-zop/wallet/internal/mailer"
+	"github.com/amankp-zop/wallet/internal/nonce"
+	"github.com/amankp-zop/wallet/internal/payout"
+	"github.com/amankp-zop/wallet/internal/pubsub"
+	"github.com/amankp-zop/wallet/internal/ratelimit"
 	"github.com/amankp-zop/wallet/internal/repository"
 	"github.com/amankp-zop/wallet/internal/service"
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"	
+	"github.com/shopspring/decimal"
 )
 
 func main() {
@@ -35,48 +45,142 @@ func main() {
 	fmt.Println("Database connected Successfully.")
 	defer db.Close()
 
-	store := repository.NewStore(db)
+	cipher, err := fieldcrypt.New(fieldcrypt.Config(cfg.Encryption))
+	if err != nil {
+		log.Fatalf("Error loading encryption keys: %v", err)
+	}
+
+	store := repository.NewStore(db, cipher)
 	// taskProducer := tasks.NewTaskProducer(redisOpt)
-	userService := service.NewUserService(store, cfg.Auth.JWTSecret)
-	userHandler := handler.NewUserHandler(userService)
+	mail, err := mailer.NewMailer(cfg.Mail.Driver, cfg.Mail.From, cfg.Mail.Path, mailer.SMTPConfig(cfg.Mail.SMTP))
+	if err != nil {
+		log.Fatalf("Error creating mailer: %v", err)
+	}
+	userService := service.NewUserService(store, cfg.Auth.JWTSecret, mail, cfg.Mail.AppURL)
 
 	walletService := service.NewWalletService(store)
-	// transactionService := service.NewTransactionService(store)
+	transactionService := service.NewTransactionService(store)
+	feeService := service.NewFeeService(store)
 	
-	walletHandler := handler.NewWalletHandler(walletService)
+	stepUp := auth.StepUpPolicy{MaxAge: cfg.Auth.StepUpMaxAge}
+	if cfg.Auth.StepUpThreshold != "" {
+		threshold, err := decimal.NewFromString(cfg.Auth.StepUpThreshold)
+		if err != nil {
+			log.Fatalf("Error parsing step-up threshold: %v", err)
+		}
+		stepUp.Threshold = decimal.NewNullDecimal(threshold)
+	}
 
-	router := chi.NewRouter()
+	scheduledTransferService := service.NewScheduledTransferService(store)
+	paymentRequestService := service.NewPaymentRequestService(store)
+	holdService := service.NewHoldService(store)
+	webhookService := service.NewWebhookService(store)
+	privacyService := service.NewPrivacyService(store, cfg.Privacy.ExportDir, cfg.Privacy.ExportTTL)
+	apiKeyService := service.NewAPIKeyService(store)
+	merchantService := service.NewMerchantService(store)
+	checkoutService := service.NewCheckoutService(store)
+
+	paymentGateway, err := gateway.NewGateway(cfg.Gateway.Driver, gateway.Config{
+		CallbackSecret: cfg.Gateway.CallbackSecret,
+		BaseURL:        cfg.Gateway.BaseURL,
+	})
+	if err != nil {
+		log.Fatalf("Error creating payment gateway: %v", err)
+	}
 
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
+	// Payments at the simulated gateway are completed through the API.
+	var depositSimulator handler.DepositSimulator
+	if simulated, ok := paymentGateway.(*gateway.Simulated); ok {
+		depositSimulator = simulated
+	}
 
-	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		response := struct {
-			Status string `json:"status"`
-		}{
-			Status: "ok",
-		}
+	depositService := service.NewDepositService(store, paymentGateway)
+
+	payoutProvider, err := payout.NewProvider(cfg.Payouts.Driver)
+	if err != nil {
+		log.Fatalf("Error creating payout provider: %v", err)
+	}
+	withdrawalService := service.NewWithdrawalService(store, payoutProvider)
+
+	payoutBatchService := service.NewPayoutBatchService(store)
+	splitPaymentService := service.NewSplitPaymentService(store)
+	escrowService := service.NewEscrowService(store)
+	disputeService := service.NewDisputeService(store, cfg.Disputes.ResponseWindow, cfg.Disputes.FilingWindow)
+	voucherService := service.NewVoucherService(store)
 
-		w.Header().Set("Content-Type", "application/json")
+	broker, err := pubsub.NewBroker(cfg.PubSub.Driver, cfg.Redis.Addr)
+	if err != nil {
+		log.Fatalf("Error creating event broker: %v", err)
+	}
+	streamService := service.NewStreamService(store, broker)
 
-		err := json.NewEncoder(w).Encode(response)
+	limiter, err := ratelimit.NewStore(cfg.RateLimit.Driver, cfg.Redis.Addr)
+	if err != nil {
+		log.Fatalf("Error creating rate limit store: %v", err)
+	}
+	nonces, err := nonce.NewStore(cfg.Signing.Driver, cfg.Redis.Addr)
+	if err != nil {
+		log.Fatalf("Error creating nonce store: %v", err)
+	}
+
+	var validator func(http.Handler) http.Handler
+	if cfg.Server.ValidateOpenAPI {
+		doc, err := openapi.Load()
 		if err != nil {
-			log.Printf("Error encoding health check response: %v", err)
+			log.Fatalf("Error loading OpenAPI document: %v", err)
 		}
-	})
 
-	router.Route("/users", func(r chi.Router) {
-		r.Post("/signup", userHandler.Signup)
-		r.Post("/login", userHandler.Login)
+		validator, err = authenticationMiddleware.OpenAPIValidator(doc, func(r *http.Request, err error) {
+			log.Printf("OpenAPI drift on %s %s: %v", r.Method, r.URL.Path, err)
+		})
+		if err != nil {
+			log.Fatalf("Error creating OpenAPI validator: %v", err)
+		}
+	}
 
-		router.Group(func(r chi.Router) {
-			r.Use(authenticationMiddleware.AuthMiddleware(cfg.Auth.JWTSecret))
+	router := newRouter(cfg, services{
+		Users:              userService,
+		APIKeys:            apiKeyService,
+		Wallets:            walletService,
+		Transactions:       transactionService,
+		Fees:               feeService,
+		ScheduledTransfers: scheduledTransferService,
+		PaymentRequests:    paymentRequestService,
+		Holds:              holdService,
+		Webhooks:           webhookService,
+		Privacy:            privacyService,
+		Merchants:          merchantService,
+		Checkout:           checkoutService,
+		Deposits:           depositService,
+		DepositSimulator:   depositSimulator,
+		Withdrawals:        withdrawalService,
+		PayoutBatches:      payoutBatchService,
+		SplitPayments:      splitPaymentService,
+		Escrows:            escrowService,
+		Disputes:           disputeService,
+		Vouchers:           voucherService,
+		Stream:             streamService,
+		StepUp:             stepUp,
+	}, limiter, nonces, validator)
+
+	grpcServer := grpcapi.NewServer(grpcapi.Services{
+		Users:        userService,
+		Wallets:      walletService,
+		Transactions: transactionService,
+		StepUp:       stepUp,
+	}, cfg.Auth.JWTSecret)
+
+	go func() {
+		lis, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
+		if err != nil {
+			log.Fatalf("Error listening for gRPC: %v", err)
+		}
 
-			// Protected routes
-			r.Get("/users/profile", userHandler.GetProfile)
-			r.Get("/users/wallets", walletHandler.GetWallet)
-		})
-	})
+		log.Printf("Starting gRPC server on port %s", cfg.Server.GRPCPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Error starting gRPC server: %v", err)
+		}
+	}()
 
 	log.Printf("Starting server on port %s", cfg.Server.Port)
 
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/amankp-zop/wallet/internal/api/handler"
+	authenticationMiddleware "github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/api/openapi"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/config"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/nonce"
+	"github.com/amankp-zop/wallet/internal/ratelimit"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+// services are the domain services behind the REST API. DepositSimulator is
+// only set for the simulated gateway; with cfg.Gateway.SimulateRoute it adds
+// the route that completes its payments. StepUp is the policy applied to transfers and other payments.
+type services struct {
+	Users              domain.UserService
+	APIKeys            domain.APIKeyService
+	Wallets            domain.WalletService
+	Transactions       domain.TransactionService
+	Fees               domain.FeeService
+	ScheduledTransfers domain.ScheduledTransferService
+	PaymentRequests    domain.PaymentRequestService
+	Holds              domain.HoldService
+	Webhooks           domain.WebhookService
+	Privacy            domain.PrivacyService
+	Merchants          domain.MerchantService
+	Checkout           domain.CheckoutService
+	Deposits           domain.DepositService
+	DepositSimulator   handler.DepositSimulator
+	Withdrawals        domain.WithdrawalService
+	PayoutBatches      domain.PayoutBatchService
+	SplitPayments      domain.SplitPaymentService
+	Escrows            domain.EscrowService
+	Disputes           domain.DisputeService
+	Vouchers           domain.VoucherService
+	Stream             domain.StreamService
+	StepUp             auth.StepUpPolicy
+}
+
+// newRouter returns the REST API. validator, when not nil, runs in front of
+// every route; it is the OpenAPI validator in development and in tests.
+func newRouter(cfg config.Config, svc services, limiter ratelimit.Store, nonces nonce.Store, validator func(http.Handler) http.Handler) http.Handler {
+	userHandler := handler.NewUserHandler(svc.Users, svc.StepUp)
+	walletHandler := handler.NewWalletHandler(svc.Wallets)
+	transactionHandler := handler.NewTransactionHandler(svc.Transactions, svc.Fees, svc.StepUp)
+	scheduledTransferHandler := handler.NewScheduledTransferHandler(svc.ScheduledTransfers, svc.StepUp)
+	paymentRequestHandler := handler.NewPaymentRequestHandler(svc.PaymentRequests, svc.StepUp)
+	holdHandler := handler.NewHoldHandler(svc.Holds, svc.StepUp)
+	webhookHandler := handler.NewWebhookHandler(svc.Webhooks)
+	privacyHandler := handler.NewPrivacyHandler(svc.Privacy)
+	apiKeyHandler := handler.NewAPIKeyHandler(svc.APIKeys)
+	merchantHandler := handler.NewMerchantHandler(svc.Merchants)
+	checkoutHandler := handler.NewCheckoutHandler(svc.Checkout, svc.StepUp)
+	depositHandler := handler.NewDepositHandler(svc.Deposits, svc.DepositSimulator)
+	withdrawalHandler := handler.NewWithdrawalHandler(svc.Withdrawals, svc.StepUp)
+	payoutBatchHandler := handler.NewPayoutBatchHandler(svc.PayoutBatches, svc.StepUp)
+	splitPaymentHandler := handler.NewSplitPaymentHandler(svc.SplitPayments, svc.StepUp)
+	escrowHandler := handler.NewEscrowHandler(svc.Escrows, svc.StepUp)
+	disputeHandler := handler.NewDisputeHandler(svc.Disputes)
+	voucherHandler := handler.NewVoucherHandler(svc.Vouchers)
+	feeHandler := handler.NewFeeHandler(svc.Fees)
+	streamHandler := handler.NewStreamHandler(svc.Stream, svc.Wallets)
+
+	rateLimit := func(group string) func(http.Handler) http.Handler {
+		limits := cfg.RateLimit.Groups[group]
+		return authenticationMiddleware.RateLimit(limiter, group, ratelimit.Limit(limits.PerIP), ratelimit.Limit(limits.PerUser))
+	}
+
+	router := chi.NewRouter()
+
+	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
+
+	if validator != nil {
+		router.Use(validator)
+	}
+
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		response := struct {
+			Status string `json:"status"`
+		}{
+			Status: "ok",
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err := json.NewEncoder(w).Encode(response)
+		if err != nil {
+			log.Printf("Error encoding health check response: %v", err)
+		}
+	})
+
+	router.Get("/openapi.json", openapi.Handler)
+
+	// Gateways authenticate their callbacks by signing them.
+	router.Post("/gateways/{gateway}/callback", depositHandler.Callback)
+
+	router.Route("/users", func(r chi.Router) {
+		r.Post("/signup", userHandler.Signup)
+		r.With(rateLimit("login")).Post("/login", userHandler.Login)
+		r.With(rateLimit("login")).Post("/login/2fa", userHandler.CompleteTwoFactorLogin)
+		r.Post("/verify-email", userHandler.VerifyEmail)
+		r.Post("/email-change/confirm", userHandler.ConfirmEmailChange)
+		r.With(rateLimit("login")).Post("/forgot-password", userHandler.ForgotPassword)
+		r.With(rateLimit("login")).Post("/reset-password", userHandler.ResetPassword)
+
+		router.Group(func(r chi.Router) {
+			r.Use(authenticationMiddleware.AuthMiddleware(cfg.Auth.JWTSecret, svc.Users, svc.APIKeys))
+			r.Use(authenticationMiddleware.VerifySignedRequests(nonces, cfg.Signing.MaxSkew))
+
+			// Protected routes. Only routes with a scope are open to API
+			// keys; the rest need a signed-in user.
+			scope := authenticationMiddleware.RequireScope
+
+			r.Get("/users/profile", userHandler.GetProfile)
+			r.Patch("/users/profile", userHandler.UpdateProfile)
+			r.Get("/users/login-history", userHandler.LoginHistory)
+			r.Post("/users/2fa/enroll", userHandler.EnrollTOTP)
+			r.Post("/users/2fa/confirm", userHandler.ConfirmTOTP)
+			r.With(rateLimit("login")).Post("/users/2fa/disable", userHandler.DisableTOTP)
+			r.With(rateLimit("login")).Post("/users/step-up", userHandler.StepUp)
+			r.With(rateLimit("login")).Post("/users/change-password", userHandler.ChangePassword)
+			r.Post("/users/verify-email/resend", userHandler.ResendEmailVerification)
+			r.With(rateLimit("login")).Post("/users/email-change", userHandler.RequestEmailChange)
+			r.Get("/users/email-change", userHandler.GetEmailChange)
+			r.Delete("/users/email-change", userHandler.CancelEmailChange)
+			r.With(rateLimit("login")).Post("/users/erase", privacyHandler.Erase)
+			r.With(scope(domain.ScopeWalletsRead)).Get("/users/wallets", walletHandler.GetWallet)
+			r.With(scope(domain.ScopeWalletsRead)).Get("/users/wallets/stream", streamHandler.Stream)
+
+			r.With(scope(domain.ScopeTransfersWrite), rateLimit("transfers")).Post("/transfers", transactionHandler.CreateTransfer)
+			r.With(scope(domain.ScopeTransfersRead)).Post("/transfers/preview", transactionHandler.PreviewTransfer)
+			r.With(scope(domain.ScopeTransfersRead)).Get("/transactions", transactionHandler.ListHistory)
+
+			r.Route("/split-payments", func(r chi.Router) {
+				r.With(scope(domain.ScopeTransfersWrite), rateLimit("transfers")).Post("/", splitPaymentHandler.CreateSplitPayment)
+				r.With(scope(domain.ScopeTransfersRead)).Get("/", splitPaymentHandler.ListSplitPayments)
+				r.With(scope(domain.ScopeTransfersRead)).Get("/{id}", splitPaymentHandler.GetSplitPayment)
+			})
+
+			r.Route("/scheduled-transfers", func(r chi.Router) {
+				r.With(scope(domain.ScopeTransfersWrite)).Post("/", scheduledTransferHandler.Create)
+				r.With(scope(domain.ScopeTransfersRead)).Get("/", scheduledTransferHandler.List)
+				r.With(scope(domain.ScopeTransfersRead)).Get("/{id}", scheduledTransferHandler.Get)
+				r.With(scope(domain.ScopeTransfersWrite)).Post("/{id}/pause", scheduledTransferHandler.Pause)
+				r.With(scope(domain.ScopeTransfersWrite)).Post("/{id}/resume", scheduledTransferHandler.Resume)
+				r.With(scope(domain.ScopeTransfersWrite)).Post("/{id}/cancel", scheduledTransferHandler.Cancel)
+			})
+
+			r.Route("/payment-requests", func(r chi.Router) {
+				r.With(scope(domain.ScopePaymentRequestsWrite)).Post("/", paymentRequestHandler.Create)
+				r.With(scope(domain.ScopePaymentRequestsRead)).Get("/incoming", paymentRequestHandler.ListIncoming)
+				r.With(scope(domain.ScopePaymentRequestsRead)).Get("/outgoing", paymentRequestHandler.ListOutgoing)
+				r.With(scope(domain.ScopePaymentRequestsWrite)).Post("/{id}/accept", paymentRequestHandler.Accept)
+				r.With(scope(domain.ScopePaymentRequestsWrite)).Post("/{id}/decline", paymentRequestHandler.Decline)
+				r.With(scope(domain.ScopePaymentRequestsWrite)).Post("/{id}/cancel", paymentRequestHandler.Cancel)
+			})
+
+			r.Route("/holds", func(r chi.Router) {
+				r.With(scope(domain.ScopeHoldsWrite)).Post("/", holdHandler.Authorize)
+				r.With(scope(domain.ScopeHoldsRead)).Get("/", holdHandler.List)
+				r.With(scope(domain.ScopeHoldsRead)).Get("/{id}", holdHandler.Get)
+				r.With(scope(domain.ScopeHoldsWrite)).Post("/{id}/capture", holdHandler.Capture)
+				r.With(scope(domain.ScopeHoldsWrite)).Post("/{id}/void", holdHandler.Void)
+			})
+
+			r.Route("/webhooks", func(r chi.Router) {
+				r.With(scope(domain.ScopeWebhooksWrite)).Post("/", webhookHandler.Create)
+				r.With(scope(domain.ScopeWebhooksRead)).Get("/", webhookHandler.List)
+				r.With(scope(domain.ScopeWebhooksRead)).Get("/{id}", webhookHandler.Get)
+				r.With(scope(domain.ScopeWebhooksWrite)).Delete("/{id}", webhookHandler.Delete)
+				r.With(scope(domain.ScopeWebhooksWrite)).Post("/{id}/enable", webhookHandler.Enable)
+				r.With(scope(domain.ScopeWebhooksRead)).Get("/{id}/deliveries", webhookHandler.ListDeliveries)
+				r.With(scope(domain.ScopeWebhooksRead)).Get("/{id}/deliveries/{deliveryID}", webhookHandler.GetDelivery)
+				r.With(scope(domain.ScopeWebhooksWrite)).Post("/{id}/deliveries/{deliveryID}/redeliver", webhookHandler.Redeliver)
+			})
+
+			r.Route("/data-exports", func(r chi.Router) {
+				r.Post("/", privacyHandler.CreateExport)
+				r.Get("/", privacyHandler.ListExports)
+				r.Get("/{id}", privacyHandler.GetExport)
+				r.Get("/{id}/download", privacyHandler.DownloadExport)
+			})
+
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Post("/", apiKeyHandler.Create)
+				r.Get("/", apiKeyHandler.List)
+				r.Get("/{id}", apiKeyHandler.Get)
+				r.Delete("/{id}", apiKeyHandler.Revoke)
+				r.Post("/{id}/signing-secret", apiKeyHandler.RollSigningSecret)
+			})
+
+			r.Post("/merchant", merchantHandler.Create)
+			r.Get("/merchant", merchantHandler.Get)
+			r.Put("/merchant", merchantHandler.Update)
+
+			r.Route("/checkout-sessions", func(r chi.Router) {
+				r.With(scope(domain.ScopeCheckoutWrite)).Post("/", checkoutHandler.Create)
+				r.With(scope(domain.ScopeCheckoutRead)).Get("/", checkoutHandler.List)
+				r.With(scope(domain.ScopeCheckoutRead)).Get("/{id}", checkoutHandler.Get)
+				r.With(scope(domain.ScopeCheckoutWrite)).Post("/{id}/cancel", checkoutHandler.Cancel)
+			})
+
+			r.Route("/deposits", func(r chi.Router) {
+				r.With(scope(domain.ScopeDepositsWrite)).Post("/", depositHandler.Create)
+				r.With(scope(domain.ScopeDepositsRead)).Get("/", depositHandler.List)
+				r.With(scope(domain.ScopeDepositsRead)).Get("/{id}", depositHandler.Get)
+				if cfg.Gateway.SimulateRoute && svc.DepositSimulator != nil {
+					r.Post("/{id}/simulate", depositHandler.Simulate)
+				}
+			})
+
+			// Beneficiaries are managed by the signed-in user only, so that an
+			// API key cannot add an account to withdraw to.
+			r.Route("/beneficiaries", func(r chi.Router) {
+				r.Post("/", withdrawalHandler.CreateBeneficiary)
+				r.Get("/", withdrawalHandler.ListBeneficiaries)
+				r.Get("/{id}", withdrawalHandler.GetBeneficiary)
+				r.Delete("/{id}", withdrawalHandler.DeleteBeneficiary)
+			})
+
+			r.Route("/withdrawals", func(r chi.Router) {
+				r.With(scope(domain.ScopeWithdrawalsWrite), rateLimit("transfers")).Post("/", withdrawalHandler.CreateWithdrawal)
+				r.With(scope(domain.ScopeWithdrawalsRead)).Get("/", withdrawalHandler.ListWithdrawals)
+				r.With(scope(domain.ScopeWithdrawalsRead)).Get("/{id}", withdrawalHandler.GetWithdrawal)
+				r.With(scope(domain.ScopeWithdrawalsWrite)).Post("/{id}/cancel", withdrawalHandler.CancelWithdrawal)
+			})
+
+			r.Route("/payout-batches", func(r chi.Router) {
+				r.With(scope(domain.ScopePayoutsWrite), rateLimit("transfers")).Post("/", payoutBatchHandler.CreateBatch)
+				r.With(scope(domain.ScopePayoutsRead)).Get("/", payoutBatchHandler.ListBatches)
+				r.With(scope(domain.ScopePayoutsRead)).Get("/{id}", payoutBatchHandler.GetBatch)
+				r.With(scope(domain.ScopePayoutsRead)).Get("/{id}/results", payoutBatchHandler.DownloadResults)
+			})
+
+			r.Route("/escrows", func(r chi.Router) {
+				r.With(scope(domain.ScopeEscrowsWrite), rateLimit("transfers")).Post("/", escrowHandler.Create)
+				r.With(scope(domain.ScopeEscrowsRead)).Get("/", escrowHandler.List)
+				r.With(scope(domain.ScopeEscrowsRead)).Get("/{id}", escrowHandler.Get)
+				r.With(scope(domain.ScopeEscrowsWrite)).Post("/{id}/release", escrowHandler.Release)
+				r.With(scope(domain.ScopeEscrowsWrite)).Post("/{id}/refund", escrowHandler.Refund)
+				r.With(scope(domain.ScopeEscrowsWrite)).Post("/{id}/dispute", escrowHandler.Dispute)
+			})
+
+			r.Route("/disputes", func(r chi.Router) {
+				r.With(scope(domain.ScopeDisputesWrite), rateLimit("transfers")).Post("/", disputeHandler.Open)
+				r.With(scope(domain.ScopeDisputesRead)).Get("/", disputeHandler.List)
+				r.With(scope(domain.ScopeDisputesRead)).Get("/{id}", disputeHandler.Get)
+				r.With(scope(domain.ScopeDisputesWrite)).Post("/{id}/evidence", disputeHandler.SubmitEvidence)
+				r.With(scope(domain.ScopeDisputesWrite)).Post("/{id}/accept", disputeHandler.Accept)
+				r.With(scope(domain.ScopeDisputesWrite)).Post("/{id}/withdraw", disputeHandler.Withdraw)
+			})
+
+			// Vouchers are redeemed by the signed-in user only. The rate limit
+			// also slows down anyone guessing codes.
+			r.With(rateLimit("vouchers")).Post("/vouchers/redeem", voucherHandler.Redeem)
+			r.Get("/vouchers/redemptions", voucherHandler.ListRedemptions)
+
+			// The admin API is for signed-in admins only.
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(authenticationMiddleware.RequireAdmin(cfg.Admin.UserIDs))
+
+				r.Get("/disputes", disputeHandler.AdminList)
+				r.Get("/disputes/{id}", disputeHandler.AdminGet)
+				r.Post("/disputes/{id}/resolve", disputeHandler.Resolve)
+
+				r.Post("/voucher-batches", voucherHandler.CreateBatch)
+				r.Get("/voucher-batches", voucherHandler.ListBatches)
+				r.Get("/voucher-batches/{id}", voucherHandler.GetBatch)
+				r.Post("/voucher-batches/{id}/disable", voucherHandler.DisableBatch)
+				r.Get("/voucher-batches/{id}/report", voucherHandler.BatchReport)
+
+				r.Post("/fee-schedules", feeHandler.CreateSchedule)
+				r.Get("/fee-schedules", feeHandler.ListSchedules)
+				r.Get("/fee-schedules/{id}", feeHandler.GetSchedule)
+				r.Post("/fee-schedules/{id}/deactivate", feeHandler.DeactivateSchedule)
+				r.Get("/revenue-wallets", feeHandler.ListRevenueWallets)
+				r.Put("/revenue-wallets/{currency}", feeHandler.SetRevenueWallet)
+			})
+
+			// Payers confirm checkout sessions as themselves, never with an
+			// API key.
+			r.Get("/checkout/{token}", checkoutHandler.GetForPayer)
+			r.With(rateLimit("transfers")).Post("/checkout/{token}/confirm", checkoutHandler.Confirm)
+		})
+	})
+
+	return router
+}
@@ -0,0 +1,225 @@
+// Command payoutbatch submits payout batch files to the wallet API and
+// fetches their status and results. It authenticates with the API key in
+// WALLET_API_KEY and signs requests when WALLET_SIGNING_SECRET is set, which
+// batches above the step-up threshold need. Submitting needs the
+// payouts:write scope and the other commands payouts:read.
+//
+//	payoutbatch submit -wait payroll.csv
+//	payoutbatch status 12
+//	payoutbatch results -format json -o results.json 12
+//
+// Files ending in .json are sent as JSON and any other file as CSV.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/amankp-zop/wallet/pkg/walletclient"
+)
+
+// pollInterval is how often submit -wait checks on the batch.
+const pollInterval = 2 * time.Second
+
+type batchItem struct {
+	LineNumber      int    `json:"line_number"`
+	RecipientUserID int64  `json:"recipient_user_id"`
+	Amount          string `json:"amount"`
+	Reference       string `json:"reference"`
+	Status          string `json:"status"`
+	FailureReason   string `json:"failure_reason"`
+}
+
+type batch struct {
+	ID             int64       `json:"id"`
+	Currency       string      `json:"currency"`
+	TotalAmount    string      `json:"total_amount"`
+	ItemCount      int         `json:"item_count"`
+	SucceededCount int         `json:"succeeded_count"`
+	FailedCount    int         `json:"failed_count"`
+	Status         string      `json:"status"`
+	Items          []batchItem `json:"items"`
+}
+
+func main() {
+	log.SetFlags(0)
+
+	baseURL := flag.String("url", envOr("WALLET_API_URL", "http://localhost:8080"), "base URL of the wallet API")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: payoutbatch [-url URL] submit [-wait] FILE | status ID | results [-format csv|json] [-o FILE] ID")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	apiKey := os.Getenv("WALLET_API_KEY")
+	if apiKey == "" {
+		log.Fatal("WALLET_API_KEY is not set")
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	client := walletclient.New(*baseURL, apiKey, os.Getenv("WALLET_SIGNING_SECRET"))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "submit":
+		err = submit(ctx, client, args)
+	case "status":
+		err = status(ctx, client, args)
+	case "results":
+		err = results(ctx, client, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func submit(ctx context.Context, client *walletclient.Client, args []string) error {
+	flags := flag.NewFlagSet("submit", flag.ExitOnError)
+	wait := flags.Bool("wait", false, "wait until every item has been paid or has failed")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("submit takes one batch file")
+	}
+
+	path := flags.Arg(0)
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	contentType := "text/csv"
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		contentType = "application/json"
+	}
+
+	var b batch
+	if err := client.Upload(ctx, http.MethodPost, "/payout-batches", contentType, body, &b); err != nil {
+		return err
+	}
+
+	fmt.Printf("Batch %d accepted: %d items, %s %s held\n", b.ID, b.ItemCount, b.TotalAmount, b.Currency)
+
+	if !*wait {
+		return nil
+	}
+
+	for b.Status != "COMPLETED" {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+
+		if err := client.Do(ctx, http.MethodGet, batchPath(b.ID), nil, &b); err != nil {
+			return err
+		}
+	}
+
+	printBatch(&b)
+	return nil
+}
+
+func status(ctx context.Context, client *walletclient.Client, args []string) error {
+	id, err := batchID(args)
+	if err != nil {
+		return err
+	}
+
+	var b batch
+	if err := client.Do(ctx, http.MethodGet, batchPath(id), nil, &b); err != nil {
+		return err
+	}
+
+	printBatch(&b)
+	return nil
+}
+
+func results(ctx context.Context, client *walletclient.Client, args []string) error {
+	flags := flag.NewFlagSet("results", flag.ExitOnError)
+	format := flags.String("format", "csv", "result file format, csv or json")
+	output := flags.String("o", "", "write the results to this file instead of stdout")
+	flags.Parse(args)
+
+	id, err := batchID(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	accept := "text/csv"
+	if *format == "json" {
+		accept = "application/json"
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return client.Download(ctx, batchPath(id)+"/results?format="+url.QueryEscape(*format), accept, w)
+}
+
+func printBatch(b *batch) {
+	fmt.Printf("Batch %d %s: %d of %d items paid, %d failed, total %s %s\n",
+		b.ID, b.Status, b.SucceededCount, b.ItemCount, b.FailedCount, b.TotalAmount, b.Currency)
+
+	for _, item := range b.Items {
+		if item.Status == "FAILED" {
+			fmt.Printf("  line %d (%s): %s\n", item.LineNumber, item.Reference, item.FailureReason)
+		}
+	}
+}
+
+func batchID(args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, errors.New("expected one batch ID")
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid batch ID %q", args[0])
+	}
+
+	return id, nil
+}
+
+func batchPath(id int64) string {
+	return "/payout-batches/" + strconv.FormatInt(id, 10)
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+
+	return fallback
+}
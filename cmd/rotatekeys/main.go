@@ -0,0 +1,70 @@
+// Command rotatekeys re-encrypts the personal data columns under the current
+// key-encryption key. Run it after changing encryption.current_kek (keeping
+// the old KEK configured until it finishes), and once after enabling
+// encryption to encrypt existing rows and fill in the email blind index.
+// With -decrypt it writes the plaintext back instead, which is required
+// before rolling back the encryption migration.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/amankp-zop/wallet/internal/config"
+	"github.com/amankp-zop/wallet/internal/database"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 500, "rows re-encrypted per transaction")
+	decrypt := flag.Bool("decrypt", false, "write plaintext instead of re-encrypting")
+	table := flag.String("table", "", "only process this table")
+	flag.Parse()
+
+	if *batchSize <= 0 {
+		log.Fatal("-batch must be positive")
+	}
+
+	tables := repository.EncryptedTables()
+	if *table != "" {
+		if !slices.Contains(tables, *table) {
+			log.Fatalf("Unknown table %q, expected one of %v", *table, tables)
+		}
+		tables = []string{*table}
+	}
+
+	cfg, err := config.LoadConfig("./configs")
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	cipher, err := fieldcrypt.New(fieldcrypt.Config(cfg.Encryption))
+	if err != nil {
+		log.Fatalf("Error loading encryption keys: %v", err)
+	}
+
+	db, err := database.NewDatabase(cfg.Database.DSN)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	rotator := repository.NewKeyRotator(db, cipher)
+	rotator.Decrypt = *decrypt
+
+	for _, name := range tables {
+		result, err := rotator.RotateTable(ctx, name, *batchSize)
+		log.Printf("%s: scanned %d rows, rewrote %d", name, result.Scanned, result.Rewritten)
+		if err != nil {
+			log.Fatalf("Error rotating %s: %v", name, err)
+		}
+	}
+}
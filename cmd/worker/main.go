@@ -2,19 +2,99 @@ package main
 
 import (
 	"log"
-	"time"
+
+	"github.com/amankp-zop/wallet/internal/config"
+	"github.com/amankp-zop/wallet/internal/database"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+	"github.com/amankp-zop/wallet/internal/gateway"
+	"github.com/amankp-zop/wallet/internal/payout"
+	"github.com/amankp-zop/wallet/internal/pubsub"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/amankp-zop/wallet/internal/worker"
+	"github.com/hibiken/asynq"
 )
 
 func main() {
+	cfg, err := config.LoadConfig("./configs")
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	db, err := database.NewDatabase(cfg.Database.DSN)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer db.Close()
+
+	redisOpt := asynq.RedisClientOpt{Addr: cfg.Redis.Addr}
+
+	cipher, err := fieldcrypt.New(fieldcrypt.Config(cfg.Encryption))
+	if err != nil {
+		log.Fatalf("Error loading encryption keys: %v", err)
+	}
+
+	store := repository.NewStore(db, cipher)
+	taskProducer := tasks.NewTaskProducer(redisOpt)
+
+	broker, err := pubsub.NewBroker(cfg.PubSub.Driver, cfg.Redis.Addr)
+	if err != nil {
+		log.Fatalf("Error creating event broker: %v", err)
+	}
+
+	paymentGateway, err := gateway.NewGateway(cfg.Gateway.Driver, gateway.Config{
+		CallbackSecret: cfg.Gateway.CallbackSecret,
+		BaseURL:        cfg.Gateway.BaseURL,
+	})
+	if err != nil {
+		log.Fatalf("Error creating payment gateway: %v", err)
+	}
+
+	payoutProvider, err := payout.NewProvider(cfg.Payouts.Driver)
+	if err != nil {
+		log.Fatalf("Error creating payout provider: %v", err)
+	}
+
+	processor := worker.NewTaskProcessor(worker.Services{
+		Transactions:       service.NewTransactionService(store),
+		ScheduledTransfers: service.NewScheduledTransferService(store),
+		PaymentRequests:    service.NewPaymentRequestService(store),
+		Holds:              service.NewHoldService(store),
+		Outbox:             service.NewOutboxService(store, taskProducer),
+		Webhooks:           service.NewWebhookService(store),
+		Stream:             service.NewStreamService(store, broker),
+		Privacy:            service.NewPrivacyService(store, cfg.Privacy.ExportDir, cfg.Privacy.ExportTTL),
+		Checkout:           service.NewCheckoutService(store),
+		Deposits:           service.NewDepositService(store, paymentGateway),
+		Withdrawals:        service.NewWithdrawalService(store, payoutProvider),
+		PayoutBatches:      service.NewPayoutBatchService(store),
+		SplitPayments:      service.NewSplitPaymentService(store),
+		Escrows:            service.NewEscrowService(store),
+		Disputes:           service.NewDisputeService(store, cfg.Disputes.ResponseWindow, cfg.Disputes.FilingWindow),
+	})
+
+	scheduler := asynq.NewScheduler(redisOpt, nil)
+	if err := worker.RegisterPeriodicTasks(scheduler); err != nil {
+		log.Fatalf("Error registering periodic tasks: %v", err)
+	}
+
+	if err := scheduler.Start(); err != nil {
+		log.Fatalf("Error starting scheduler: %v", err)
+	}
+	defer scheduler.Shutdown()
+
+	server := asynq.NewServer(redisOpt, asynq.Config{
+		Concurrency:    10,
+		RetryDelayFunc: worker.RetryDelay,
+	})
+	mux := asynq.NewServeMux()
+	processor.Register(mux)
+
 	log.Println("Worker started")
 
-	// Example worker loop
-	for {
-		log.Println("Worker processing tasks...")
-		
-		// Add your worker logic here
-		// Example: process jobs from queue, handle background tasks, etc.
-		
-		time.Sleep(10 * time.Second)
+	// Run blocks until the process receives a termination signal.
+	if err := server.Run(mux); err != nil {
+		log.Fatalf("Error running worker: %v", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,93 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/amankp-zop/wallet/internal/api/grpc/walletv1"
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods can be called without an authorization header.
+var publicMethods = map[string]bool{
+	walletv1.UserService_Signup_FullMethodName:                 true,
+	walletv1.UserService_Login_FullMethodName:                  true,
+	walletv1.UserService_CompleteTwoFactorLogin_FullMethodName: true,
+}
+
+// UnaryAuthInterceptor verifies the "authorization" metadata with the same
+// check as middleware.AuthMiddleware and stores the user ID under
+// middleware.UserIDContextKey and its claims under middleware.ClaimsContextKey.
+func UnaryAuthInterceptor(jwtSecret string, sessions middleware.SessionVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, jwtSecret, sessions)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamAuthInterceptor(jwtSecret string, sessions middleware.SessionVerifier) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if publicMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), jwtSecret, sessions)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, jwtSecret string, sessions middleware.SessionVerifier) (context.Context, error) {
+	var authHeader string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authHeader = values[0]
+		}
+	}
+
+	claims, err := middleware.Authenticate(ctx, authHeader, jwtSecret, sessions)
+	if err != nil {
+		if errors.Is(err, middleware.ErrMissingAuthorization) {
+			return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+		}
+		if !middleware.IsAuthError(err) {
+			return nil, toStatus(err)
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return middleware.WithClaims(ctx, claims), nil
+}
+
+func currentUserID(ctx context.Context) (int64, error) {
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "user ID not found in context")
+	}
+
+	return userID, nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
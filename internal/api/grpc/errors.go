@@ -0,0 +1,47 @@
+package grpcapi
+
+import (
+	"errors"
+	"log"
+
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps domain errors to gRPC status codes the same way the HTTP
+// handlers map them to status codes. Unknown errors are logged and hidden
+// behind codes.Internal.
+func toStatus(err error) error {
+	var code codes.Code
+
+	switch {
+	case errors.Is(err, service.ErrUserAlreadyExists):
+		code = codes.AlreadyExists
+	case errors.Is(err, service.ErrInvalidCredentials),
+		errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrInvalidTwoFactorToken):
+		code = codes.Unauthenticated
+	case errors.Is(err, auth.ErrStepUpRequired):
+		code = codes.PermissionDenied
+	case errors.Is(err, service.ErrLoginLocked):
+		code = codes.ResourceExhausted
+	case errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrWalletNotFound),
+		errors.Is(err, service.ErrTransactionNotFound):
+		code = codes.NotFound
+	case errors.Is(err, service.ErrInvalidAmount),
+		errors.Is(err, service.ErrSelfTransfer),
+		errors.Is(err, service.ErrFeeExceedsAmount):
+		code = codes.InvalidArgument
+	case errors.Is(err, service.ErrInsufficientFunds),
+		errors.Is(err, service.ErrCurrencyMismatch):
+		code = codes.FailedPrecondition
+	default:
+		log.Printf("Internal error in gRPC handler: %v", err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return status.Error(code, err.Error())
+}
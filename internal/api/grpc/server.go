@@ -0,0 +1,41 @@
+// Package grpcapi serves the wallet API over gRPC alongside the chi router.
+// The protobuf definitions live in proto/wallet/v1 and the generated code in
+// the walletv1 package.
+package grpcapi
+
+import (
+	"github.com/amankp-zop/wallet/internal/api/grpc/walletv1"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+//go:generate protoc -I ../../../proto --go_out=../../.. --go_opt=module=github.com/amankp-zop/wallet --go-grpc_out=../../.. --go-grpc_opt=module=github.com/amankp-zop/wallet wallet/v1/user.proto wallet/v1/wallet.proto wallet/v1/transaction.proto
+
+// Services are the domain services behind the gRPC API. StepUp is the
+// policy CreateTransfer applies, as the REST handler does.
+type Services struct {
+	Users        domain.UserService
+	Wallets      domain.WalletService
+	Transactions domain.TransactionService
+	StepUp       auth.StepUpPolicy
+}
+
+// NewServer returns a gRPC server with every service registered behind the
+// JWT auth interceptor.
+func NewServer(services Services, jwtSecret string) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryAuthInterceptor(jwtSecret, services.Users)),
+		grpc.ChainStreamInterceptor(StreamAuthInterceptor(jwtSecret, services.Users)),
+	)
+
+	validate := validator.New()
+	walletv1.RegisterUserServiceServer(server, &userServer{users: services.Users, validate: validate})
+	walletv1.RegisterWalletServiceServer(server, &walletServer{wallets: services.Wallets})
+	walletv1.RegisterTransactionServiceServer(server, &transactionServer{transactions: services.Transactions, stepUp: services.StepUp})
+	reflection.Register(server)
+
+	return server
+}
@@ -0,0 +1,217 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/grpc/walletv1"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testJWTSecret = "test-secret"
+
+type fakeUsers struct {
+	domain.UserService
+	revokedBefore time.Time
+}
+
+func (f fakeUsers) VerifySession(ctx context.Context, userID int64, issuedAt time.Time) error {
+	if issuedAt.Before(f.revokedBefore) {
+		return auth.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+func (f fakeUsers) Signup(ctx context.Context, name, email, password string) (*domain.User, error) {
+	return &domain.User{ID: 1, Name: name, Email: email}, nil
+}
+
+type fakeWallets struct {
+	domain.WalletService
+}
+
+func (f fakeWallets) GetWalletByUserID(ctx context.Context, userID int64) (*domain.Wallet, error) {
+	return &domain.Wallet{
+		ID:               100 + userID,
+		UserID:           userID,
+		Balance:          decimal.RequireFromString("25.00"),
+		AvailableBalance: decimal.RequireFromString("25.00"),
+		Currency:         "USD",
+	}, nil
+}
+
+type fakeTransactions struct {
+	domain.TransactionService
+	err error
+}
+
+func (f fakeTransactions) CreateTransfer(ctx context.Context, senderUserID, receiverUserID int64, amount decimal.Decimal) (*domain.Transaction, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &domain.Transaction{
+		ID:               9,
+		SenderWalletID:   100 + senderUserID,
+		ReceiverWalletID: 100 + receiverUserID,
+		Amount:           amount,
+		Status:           domain.TransactionStatusPending,
+	}, nil
+}
+
+// dial serves services on an in-memory listener and returns a connection to
+// it.
+func dial(t *testing.T, services Services) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(services, testJWTSecret)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func testServices() Services {
+	return Services{
+		Users:        fakeUsers{},
+		Wallets:      fakeWallets{},
+		Transactions: fakeTransactions{},
+		StepUp: auth.StepUpPolicy{
+			Threshold: decimal.NewNullDecimal(decimal.RequireFromString("1000")),
+			MaxAge:    5 * time.Minute,
+		},
+	}
+}
+
+// withToken returns a context whose calls carry an access token for user 7.
+func withToken(t *testing.T, claims auth.Claims) context.Context {
+	t.Helper()
+
+	claims.UserID = 7
+	if claims.Type == "" {
+		claims.Type = auth.TypeAccess
+	}
+
+	token, err := auth.Sign(testJWTSecret, claims, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	services := testServices()
+	services.Users = fakeUsers{revokedBefore: time.Now().Add(time.Hour)}
+	wallets := walletv1.NewWalletServiceClient(dial(t, services))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "no metadata", ctx: context.Background()},
+		{name: "not a bearer token", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic abc")},
+		{name: "bad signature", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer abc.def.ghi")},
+		{name: "two-factor token", ctx: withToken(t, auth.Claims{Type: auth.TypeTwoFactor})},
+		{name: "revoked session", ctx: withToken(t, auth.Claims{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := wallets.GetWallet(tt.ctx, &walletv1.GetWalletRequest{})
+			if got := status.Code(err); got != codes.Unauthenticated {
+				t.Errorf("GetWallet() code = %s, want %s", got, codes.Unauthenticated)
+			}
+		})
+	}
+}
+
+func TestPublicMethodsSkipAuth(t *testing.T) {
+	users := walletv1.NewUserServiceClient(dial(t, testServices()))
+
+	resp, err := users.Signup(context.Background(), &walletv1.SignupRequest{Name: "Ada", Email: "ada@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatalf("Signup() error = %v", err)
+	}
+
+	if resp.GetUser().GetEmail() != "ada@example.com" {
+		t.Errorf("Signup() user = %v", resp.GetUser())
+	}
+
+	_, err = users.Signup(context.Background(), &walletv1.SignupRequest{Name: "Ada", Email: "not-an-email", Password: "secret1"})
+	if got := status.Code(err); got != codes.InvalidArgument {
+		t.Errorf("Signup() with a bad email code = %s, want %s", got, codes.InvalidArgument)
+	}
+}
+
+func TestGetWallet(t *testing.T) {
+	wallets := walletv1.NewWalletServiceClient(dial(t, testServices()))
+
+	wallet, err := wallets.GetWallet(withToken(t, auth.Claims{}), &walletv1.GetWalletRequest{})
+	if err != nil {
+		t.Fatalf("GetWallet() error = %v", err)
+	}
+
+	if wallet.GetUserId() != 7 || wallet.GetBalance() != "25" || wallet.GetCurrency() != "USD" {
+		t.Errorf("GetWallet() = %v, want user 7's wallet", wallet)
+	}
+}
+
+func TestCreateTransfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		claims   auth.Claims
+		amount   string
+		wantCode codes.Code
+	}{
+		{name: "created", amount: "10.00", wantCode: codes.OK},
+		{name: "malformed amount", amount: "ten", wantCode: codes.InvalidArgument},
+		{name: "insufficient funds", err: service.ErrInsufficientFunds, amount: "10.00", wantCode: codes.FailedPrecondition},
+		{name: "unknown receiver", err: service.ErrUserNotFound, amount: "10.00", wantCode: codes.NotFound},
+		{name: "internal error hidden", err: context.DeadlineExceeded, amount: "10.00", wantCode: codes.Internal},
+		{name: "large amount without step-up", amount: "5000", wantCode: codes.PermissionDenied},
+		{name: "large amount with step-up", claims: auth.Claims{StepUpAt: time.Now()}, amount: "5000", wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services := testServices()
+			services.Transactions = fakeTransactions{err: tt.err}
+			transactions := walletv1.NewTransactionServiceClient(dial(t, services))
+
+			tx, err := transactions.CreateTransfer(withToken(t, tt.claims), &walletv1.CreateTransferRequest{ReceiverUserId: 8, Amount: tt.amount})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("CreateTransfer() code = %s (%v), want %s", got, err, tt.wantCode)
+			}
+
+			if tt.wantCode == codes.OK && (tx.GetSenderWalletId() != 107 || tx.GetStatus() != walletv1.TransactionStatus_TRANSACTION_STATUS_PENDING) {
+				t.Errorf("CreateTransfer() = %v, want a pending transfer from user 7", tx)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/grpc/walletv1"
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type transactionServer struct {
+	walletv1.UnimplementedTransactionServiceServer
+	transactions domain.TransactionService
+	stepUp       auth.StepUpPolicy
+}
+
+func (s *transactionServer) CreateTransfer(ctx context.Context, req *walletv1.CreateTransferRequest) (*walletv1.Transaction, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetReceiverUserId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "receiver_user_id is required")
+	}
+
+	amount, err := decimal.NewFromString(req.GetAmount())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "amount must be a decimal string")
+	}
+
+	if err := s.stepUp.Check(amount, middleware.StepUpAt(ctx), time.Now()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	tx, err := s.transactions.CreateTransfer(ctx, userID, req.GetReceiverUserId(), amount)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return transactionToProto(tx), nil
+}
+
+var transactionStatuses = map[domain.TransactionStatus]walletv1.TransactionStatus{
+	domain.TransactionStatusPending:   walletv1.TransactionStatus_TRANSACTION_STATUS_PENDING,
+	domain.TransactionStatusCompleted: walletv1.TransactionStatus_TRANSACTION_STATUS_COMPLETED,
+	domain.TransactionStatusFailed:    walletv1.TransactionStatus_TRANSACTION_STATUS_FAILED,
+}
+
+func transactionToProto(tx *domain.Transaction) *walletv1.Transaction {
+	return &walletv1.Transaction{
+		Id:               tx.ID,
+		SenderWalletId:   tx.SenderWalletID,
+		ReceiverWalletId: tx.ReceiverWalletID,
+		Amount:           tx.Amount.String(),
+		Status:           transactionStatuses[tx.Status],
+	}
+}
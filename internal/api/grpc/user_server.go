@@ -0,0 +1,134 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+
+	"github.com/amankp-zop/wallet/internal/api/grpc/walletv1"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type userServer struct {
+	walletv1.UnimplementedUserServiceServer
+	users    domain.UserService
+	validate *validator.Validate
+}
+
+// signupInput and loginInput carry the same rules as the REST request bodies.
+type signupInput struct {
+	Name     string `validate:"required,min=2"`
+	Email    string `validate:"required,email"`
+	Password string `validate:"required,min=6"`
+}
+
+type loginInput struct {
+	Email    string `validate:"required,email"`
+	Password string `validate:"required,min=6"`
+}
+
+type twoFactorLoginInput struct {
+	TwoFactorToken string `validate:"required"`
+	Code           string `validate:"required"`
+}
+
+func (s *userServer) Signup(ctx context.Context, req *walletv1.SignupRequest) (*walletv1.SignupResponse, error) {
+	input := signupInput{Name: req.GetName(), Email: req.GetEmail(), Password: req.GetPassword()}
+	if err := s.validate.Struct(input); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	user, err := s.users.Signup(ctx, input.Name, input.Email, input.Password)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &walletv1.SignupResponse{User: userToProto(user)}, nil
+}
+
+func (s *userServer) Login(ctx context.Context, req *walletv1.LoginRequest) (*walletv1.LoginResponse, error) {
+	input := loginInput{Email: req.GetEmail(), Password: req.GetPassword()}
+	if err := s.validate.Struct(input); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := s.users.Login(ctx, input.Email, input.Password, clientInfo(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return loginResultToProto(result), nil
+}
+
+func (s *userServer) CompleteTwoFactorLogin(ctx context.Context, req *walletv1.CompleteTwoFactorLoginRequest) (*walletv1.LoginResponse, error) {
+	input := twoFactorLoginInput{TwoFactorToken: req.GetTwoFactorToken(), Code: req.GetCode()}
+	if err := s.validate.Struct(input); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := s.users.CompleteTwoFactorLogin(ctx, input.TwoFactorToken, input.Code, clientInfo(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return loginResultToProto(result), nil
+}
+
+func (s *userServer) GetProfile(ctx context.Context, _ *walletv1.GetProfileRequest) (*walletv1.User, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return userToProto(user), nil
+}
+
+// clientInfo describes the caller for login tracking from the connection peer
+// and the user-agent metadata.
+func clientInfo(ctx context.Context) domain.ClientInfo {
+	var client domain.ClientInfo
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client.IP); err == nil {
+			client.IP = host
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			client.UserAgent = values[0]
+		}
+	}
+
+	return client
+}
+
+func loginResultToProto(result *domain.LoginResult) *walletv1.LoginResponse {
+	return &walletv1.LoginResponse{
+		Token:             result.Token,
+		TwoFactorRequired: result.TwoFactorRequired,
+		TwoFactorToken:    result.TwoFactorToken,
+	}
+}
+
+func userToProto(user *domain.User) *walletv1.User {
+	return &walletv1.User{
+		Id:               user.ID,
+		Name:             user.Name,
+		Email:            user.Email,
+		CreatedAt:        timestamppb.New(user.CreatedAt),
+		UpdatedAt:        timestamppb.New(user.UpdatedAt),
+		TwoFactorEnabled: user.TwoFactorEnabled,
+	}
+}
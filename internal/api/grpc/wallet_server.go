@@ -0,0 +1,41 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/amankp-zop/wallet/internal/api/grpc/walletv1"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type walletServer struct {
+	walletv1.UnimplementedWalletServiceServer
+	wallets domain.WalletService
+}
+
+func (s *walletServer) GetWallet(ctx context.Context, _ *walletv1.GetWalletRequest) (*walletv1.Wallet, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.wallets.GetWalletByUserID(ctx, userID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return walletToProto(wallet), nil
+}
+
+func walletToProto(wallet *domain.Wallet) *walletv1.Wallet {
+	return &walletv1.Wallet{
+		Id:               wallet.ID,
+		UserId:           wallet.UserID,
+		Balance:          wallet.Balance.String(),
+		HeldBalance:      wallet.HeldBalance.String(),
+		AvailableBalance: wallet.AvailableBalance.String(),
+		Currency:         wallet.Currency,
+		CreatedAt:        timestamppb.New(wallet.CreatedAt),
+		UpdatedAt:        timestamppb.New(wallet.UpdatedAt),
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+type APIKeyHandler struct {
+	apiKeyService domain.APIKeyService
+	validate      *validator.Validate
+}
+
+func NewAPIKeyHandler(apiKeyService domain.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		validate:      validator.New(),
+	}
+}
+
+// CreateAPIKeyRequest names the key and lists its scopes. AllowedIPs takes IP
+// addresses and CIDR ranges; leave it empty to allow any address.
+// RequireSignature refuses requests made with the key that are not signed.
+type CreateAPIKeyRequest struct {
+	Name             string     `json:"name" validate:"required,max=100"`
+	Scopes           []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	AllowedIPs       []string   `json:"allowed_ips" validate:"max=50,dive,required"`
+	RequireSignature bool       `json:"require_signature"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+}
+
+// Create returns the new key including the key itself and its signing
+// secret, which are shown only once.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(r.Context(), userID, req.Name, req.Scopes, req.AllowedIPs, req.RequireSignature, req.ExpiresAt)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, key)
+}
+
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if keys == nil {
+		keys = []*domain.APIKey{}
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.apiKeyService.GetAPIKey(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, key)
+}
+
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(r.Context(), userID, id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RollSigningSecret returns the key with a new signing secret, shown only
+// once.
+func (h *APIKeyHandler) RollSigningSecret(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.apiKeyService.RollSigningSecret(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, key)
+}
+
+func (h *APIKeyHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidAPIKeyScope), errors.Is(err, service.ErrInvalidAllowedIP),
+		errors.Is(err, service.ErrInvalidAPIKeyExpiry):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrTooManyAPIKeys), errors.Is(err, service.ErrAPIKeyRevoked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
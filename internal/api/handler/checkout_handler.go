@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type CheckoutHandler struct {
+	checkoutService domain.CheckoutService
+	stepUp          auth.StepUpPolicy
+	validate        *validator.Validate
+}
+
+func NewCheckoutHandler(checkoutService domain.CheckoutService, stepUp auth.StepUpPolicy) *CheckoutHandler {
+	return &CheckoutHandler{
+		checkoutService: checkoutService,
+		stepUp:          stepUp,
+		validate:        validator.New(),
+	}
+}
+
+type CreateCheckoutSessionRequest struct {
+	Amount           decimal.Decimal `json:"amount"`
+	Currency         string          `json:"currency" validate:"required,len=3,uppercase"`
+	Reference        string          `json:"reference" validate:"required,max=255"`
+	Description      string          `json:"description" validate:"max=255"`
+	SuccessURL       string          `json:"success_url" validate:"required,max=2048"`
+	CancelURL        string          `json:"cancel_url" validate:"required,max=2048"`
+	ExpiresInMinutes int             `json:"expires_in_minutes" validate:"omitempty,min=1,max=1440"`
+}
+
+// Create opens a checkout session for the caller's merchant. The payer is
+// sent the session's token.
+func (h *CheckoutHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateCheckoutSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.checkoutService.CreateCheckoutSession(r.Context(), userID, domain.CheckoutSessionParams{
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Reference:   req.Reference,
+		Description: req.Description,
+		SuccessURL:  req.SuccessURL,
+		CancelURL:   req.CancelURL,
+		TTL:         time.Duration(req.ExpiresInMinutes) * time.Minute,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, session)
+}
+
+func (h *CheckoutHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.checkoutService.ListCheckoutSessions(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if sessions == nil {
+		sessions = []*domain.CheckoutSession{}
+	}
+
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (h *CheckoutHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.byID(w, r, h.checkoutService.GetCheckoutSession)
+}
+
+func (h *CheckoutHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.byID(w, r, h.checkoutService.CancelCheckoutSession)
+}
+
+func (h *CheckoutHandler) byID(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, userID, id int64) (*domain.CheckoutSession, error)) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid checkout session ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := fn(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, session)
+}
+
+// GetForPayer shows a payer the session they were sent.
+func (h *CheckoutHandler) GetForPayer(w http.ResponseWriter, r *http.Request) {
+	if _, ok := currentUserID(r); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	session, err := h.checkoutService.GetCheckoutSessionForPayer(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, session)
+}
+
+// Confirm pays the session. It responds 202 with the PROCESSING session; the
+// payer is redirected to its success or cancel URL. Amounts above the step-up
+// threshold need a token from a recent step-up, as for transfers.
+func (h *CheckoutHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	token := chi.URLParam(r, "token")
+
+	// The amount of a session never changes, so it can be checked before
+	// the session is locked.
+	session, err := h.checkoutService.GetCheckoutSessionForPayer(r.Context(), token)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if err := h.stepUp.Check(session.Amount, middleware.StepUpAt(r.Context()), time.Now()); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	session, err = h.checkoutService.ConfirmCheckoutSession(r.Context(), userID, token)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, session)
+}
+
+func (h *CheckoutHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrCheckoutSessionNotFound), errors.Is(err, service.ErrMerchantNotFound),
+		errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrCheckoutSessionNotOpen), errors.Is(err, service.ErrDuplicateCheckoutReference),
+		errors.Is(err, service.ErrMerchantDisabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrCheckoutSessionExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrSelfTransfer),
+		errors.Is(err, service.ErrCurrencyMismatch), errors.Is(err, service.ErrInvalidCheckoutSessionTTL),
+		errors.Is(err, service.ErrInvalidRedirectURL), errors.Is(err, service.ErrFeeExceedsAmount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+// maxCallbackBody bounds the gateway callback body read into memory.
+const maxCallbackBody = 64 << 10
+
+// DepositSimulator builds the signed callback a gateway would send once a
+// deposit's payment succeeds or fails. Only the simulated gateway has one.
+type DepositSimulator interface {
+	Callback(deposit *domain.Deposit, succeeded bool) (http.Header, []byte, error)
+}
+
+type DepositHandler struct {
+	depositService domain.DepositService
+	simulator      DepositSimulator
+	validate       *validator.Validate
+}
+
+// NewDepositHandler returns the deposit handler. simulator may be nil when
+// the gateway is a real one; Simulate then responds 404.
+func NewDepositHandler(depositService domain.DepositService, simulator DepositSimulator) *DepositHandler {
+	return &DepositHandler{
+		depositService: depositService,
+		simulator:      simulator,
+		validate:       validator.New(),
+	}
+}
+
+type CreateDepositRequest struct {
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// Create starts a deposit into the caller's wallet. The response carries the
+// gateway's payment URL; the wallet is credited once the gateway reports the
+// payment succeeded.
+func (h *DepositHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateDepositRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	deposit, err := h.depositService.CreateDeposit(r.Context(), userID, req.Amount)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, deposit)
+}
+
+func (h *DepositHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	deposits, err := h.depositService.ListDeposits(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if deposits == nil {
+		deposits = []*domain.Deposit{}
+	}
+
+	writeJSON(w, http.StatusOK, deposits)
+}
+
+func (h *DepositHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid deposit ID", http.StatusBadRequest)
+		return
+	}
+
+	deposit, err := h.depositService.GetDeposit(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deposit)
+}
+
+type SimulateDepositRequest struct {
+	Outcome string `json:"outcome" validate:"required,oneof=succeeded failed"`
+}
+
+// Simulate completes a deposit's payment at the simulated gateway. The signed
+// callback the gateway would send is applied exactly like a real one.
+func (h *DepositHandler) Simulate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if h.simulator == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid deposit ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SimulateDepositRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deposit, err := h.depositService.GetDeposit(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	header, body, err := h.simulator.Callback(deposit, req.Outcome == "succeeded")
+	if err != nil {
+		log.Printf("Error simulating callback for deposit %d: %v", deposit.ID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	deposit, err = h.depositService.HandleGatewayCallback(r.Context(), deposit.Gateway, header, body)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deposit)
+}
+
+// Callback receives a payment gateway's report on a deposit. It is public:
+// the gateway authenticates itself by signing the callback. Retries of a
+// callback that was already applied are acknowledged again.
+func (h *DepositHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBody+1))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(body) > maxCallbackBody {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	deposit, err := h.depositService.HandleGatewayCallback(r.Context(), chi.URLParam(r, "gateway"), r.Header, body)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		DepositID int64                `json:"deposit_id"`
+		Status    domain.DepositStatus `json:"status"`
+	}{deposit.ID, deposit.Status})
+}
+
+func (h *DepositHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrDepositNotFound), errors.Is(err, service.ErrWalletNotFound),
+		errors.Is(err, service.ErrUnknownGateway):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrInvalidGatewayCallback):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrGatewayUnavailable):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
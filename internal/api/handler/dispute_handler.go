@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type DisputeHandler struct {
+	disputeService domain.DisputeService
+	validate       *validator.Validate
+}
+
+func NewDisputeHandler(disputeService domain.DisputeService) *DisputeHandler {
+	return &DisputeHandler{
+		disputeService: disputeService,
+		validate:       validator.New(),
+	}
+}
+
+type OpenDisputeRequest struct {
+	TransactionID int64           `json:"transaction_id" validate:"required,gt=0"`
+	Amount        decimal.Decimal `json:"amount"`
+	Reason        string          `json:"reason" validate:"required,max=1000"`
+}
+
+type SubmitDisputeEvidenceRequest struct {
+	Text     string   `json:"text" validate:"required,max=5000"`
+	FileRefs []string `json:"file_refs" validate:"max=10,dive,required,max=500"`
+}
+
+type ResolveDisputeRequest struct {
+	Status domain.DisputeStatus `json:"status" validate:"required,oneof=PAYER_WON MERCHANT_WON"`
+	Note   string               `json:"note" validate:"max=1000"`
+}
+
+// Open disputes a completed checkout payment the caller made. An amount of
+// zero disputes the whole payment.
+func (h *DisputeHandler) Open(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req OpenDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.disputeService.OpenDispute(r.Context(), userID, req.TransactionID, req.Amount, req.Reason)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, dispute)
+}
+
+func (h *DisputeHandler) SubmitEvidence(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SubmitDisputeEvidenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.disputeService.SubmitDisputeEvidence(r.Context(), userID, id, req.Text, req.FileRefs)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dispute)
+}
+
+func (h *DisputeHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.disputeService.AcceptDispute)
+}
+
+func (h *DisputeHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.disputeService.WithdrawDispute)
+}
+
+func (h *DisputeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.disputeService.GetDispute)
+}
+
+func (h *DisputeHandler) act(w http.ResponseWriter, r *http.Request, act func(ctx context.Context, userID, id int64) (*domain.Dispute, error)) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := act(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dispute)
+}
+
+func (h *DisputeHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	disputes, err := h.disputeService.ListDisputes(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if disputes == nil {
+		disputes = []*domain.Dispute{}
+	}
+
+	writeJSON(w, http.StatusOK, disputes)
+}
+
+// AdminList lists the newest disputes, optionally only those with the status
+// given in the query string.
+func (h *DisputeHandler) AdminList(w http.ResponseWriter, r *http.Request) {
+	status := domain.DisputeStatus(r.URL.Query().Get("status"))
+
+	switch status {
+	case "", domain.DisputeStatusOpen, domain.DisputeStatusUnderReview, domain.DisputeStatusPayerWon,
+		domain.DisputeStatusMerchantWon, domain.DisputeStatusWithdrawn:
+	default:
+		http.Error(w, "Invalid dispute status", http.StatusBadRequest)
+		return
+	}
+
+	disputes, err := h.disputeService.AdminListDisputes(r.Context(), status)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if disputes == nil {
+		disputes = []*domain.Dispute{}
+	}
+
+	writeJSON(w, http.StatusOK, disputes)
+}
+
+func (h *DisputeHandler) AdminGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.disputeService.AdminGetDispute(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dispute)
+}
+
+func (h *DisputeHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ResolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.disputeService.ResolveDispute(r.Context(), userID, id, req.Status, req.Note)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dispute)
+}
+
+func (h *DisputeHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrDisputeNotAllowed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrDisputeNotFound), errors.Is(err, service.ErrTransactionNotFound),
+		errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrDisputeExists), errors.Is(err, service.ErrDisputeResolved),
+		errors.Is(err, service.ErrDisputeResponseClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrInvalidDispute):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type EscrowHandler struct {
+	escrowService domain.EscrowService
+	stepUp        auth.StepUpPolicy
+	validate      *validator.Validate
+}
+
+func NewEscrowHandler(escrowService domain.EscrowService, stepUp auth.StepUpPolicy) *EscrowHandler {
+	return &EscrowHandler{
+		escrowService: escrowService,
+		stepUp:        stepUp,
+		validate:      validator.New(),
+	}
+}
+
+type CreateEscrowRequest struct {
+	PayeeUserID        int64           `json:"payee_user_id" validate:"required,gt=0"`
+	ArbiterUserID      *int64          `json:"arbiter_user_id" validate:"omitempty,gt=0"`
+	Amount             decimal.Decimal `json:"amount"`
+	Description        string          `json:"description" validate:"max=255"`
+	AutoReleaseInHours int             `json:"auto_release_in_hours" validate:"omitempty,min=1,max=8760"`
+}
+
+type DisputeEscrowRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// Create funds an escrow from the caller's wallet. Amounts above the step-up
+// threshold need a token from a recent step-up.
+func (h *EscrowHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateEscrowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.stepUp.Check(req.Amount, middleware.StepUpAt(r.Context()), time.Now()); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	releaseAfter := time.Duration(req.AutoReleaseInHours) * time.Hour
+
+	escrow, err := h.escrowService.CreateEscrow(r.Context(), userID, req.PayeeUserID, req.ArbiterUserID, req.Amount, req.Description, releaseAfter)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, escrow)
+}
+
+func (h *EscrowHandler) Release(w http.ResponseWriter, r *http.Request) {
+	h.settle(w, r, h.escrowService.ReleaseEscrow)
+}
+
+func (h *EscrowHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	h.settle(w, r, h.escrowService.RefundEscrow)
+}
+
+func (h *EscrowHandler) settle(w http.ResponseWriter, r *http.Request, settle func(ctx context.Context, userID, id int64) (*domain.Escrow, error)) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid escrow ID", http.StatusBadRequest)
+		return
+	}
+
+	escrow, err := settle(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, escrow)
+}
+
+func (h *EscrowHandler) Dispute(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid escrow ID", http.StatusBadRequest)
+		return
+	}
+
+	var req DisputeEscrowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	escrow, err := h.escrowService.DisputeEscrow(r.Context(), userID, id, req.Reason)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, escrow)
+}
+
+func (h *EscrowHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid escrow ID", http.StatusBadRequest)
+		return
+	}
+
+	escrow, err := h.escrowService.GetEscrow(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, escrow)
+}
+
+func (h *EscrowHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	escrows, err := h.escrowService.ListEscrows(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if escrows == nil {
+		escrows = []*domain.Escrow{}
+	}
+
+	writeJSON(w, http.StatusOK, escrows)
+}
+
+func (h *EscrowHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrEscrowNotAllowed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrEscrowNotFound), errors.Is(err, service.ErrWalletNotFound), errors.Is(err, service.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrEscrowSettled), errors.Is(err, service.ErrEscrowDisputed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInsufficientFunds):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrSelfTransfer),
+		errors.Is(err, service.ErrCurrencyMismatch), errors.Is(err, service.ErrInvalidEscrowArbiter),
+		errors.Is(err, service.ErrInvalidEscrowDeadline), errors.Is(err, service.ErrEscrowWalletNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+// FeeHandler serves the admin routes that price transfers: fee schedules and
+// the revenue wallets fees are posted to.
+type FeeHandler struct {
+	feeService domain.FeeService
+	validate   *validator.Validate
+}
+
+func NewFeeHandler(feeService domain.FeeService) *FeeHandler {
+	return &FeeHandler{
+		feeService: feeService,
+		validate:   validator.New(),
+	}
+}
+
+type CreateFeeScheduleRequest struct {
+	Plan       *string             `json:"plan" validate:"omitempty,min=1,max=64"`
+	MerchantID *int64              `json:"merchant_id" validate:"omitempty,min=1"`
+	Currency   *string             `json:"currency" validate:"omitempty,len=3,uppercase"`
+	Type       domain.FeeType      `json:"type" validate:"required,oneof=FLAT PERCENTAGE TIERED"`
+	FlatAmount decimal.Decimal     `json:"flat_amount"`
+	Percentage decimal.Decimal     `json:"percentage"`
+	Tiers      []domain.FeeTier    `json:"tiers" validate:"max=20"`
+	MinFee     decimal.NullDecimal `json:"min_fee"`
+	MaxFee     decimal.NullDecimal `json:"max_fee"`
+}
+
+type SetRevenueWalletRequest struct {
+	WalletID int64 `json:"wallet_id" validate:"required,min=1"`
+}
+
+// CreateSchedule adds an active fee schedule. Schedules cannot be edited;
+// they are replaced by creating a new one and deactivating the old.
+func (h *FeeHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req CreateFeeScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.feeService.CreateFeeSchedule(r.Context(), &domain.FeeSchedule{
+		Plan:       req.Plan,
+		MerchantID: req.MerchantID,
+		Currency:   req.Currency,
+		Type:       req.Type,
+		FlatAmount: req.FlatAmount,
+		Percentage: req.Percentage,
+		Tiers:      req.Tiers,
+		MinFee:     req.MinFee,
+		MaxFee:     req.MaxFee,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, schedule)
+}
+
+func (h *FeeHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.feeService.ListFeeSchedules(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if schedules == nil {
+		schedules = []*domain.FeeSchedule{}
+	}
+
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+func (h *FeeHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid fee schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.feeService.GetFeeSchedule(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (h *FeeHandler) DeactivateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid fee schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.feeService.DeactivateFeeSchedule(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (h *FeeHandler) ListRevenueWallets(w http.ResponseWriter, r *http.Request) {
+	revenueWallets, err := h.feeService.ListRevenueWallets(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if revenueWallets == nil {
+		revenueWallets = []*domain.RevenueWallet{}
+	}
+
+	writeJSON(w, http.StatusOK, revenueWallets)
+}
+
+// SetRevenueWallet points the fees charged in the currency at a wallet.
+func (h *FeeHandler) SetRevenueWallet(w http.ResponseWriter, r *http.Request) {
+	currency := chi.URLParam(r, "currency")
+	if err := h.validate.Var(currency, "len=3,uppercase"); err != nil {
+		http.Error(w, "Invalid currency", http.StatusBadRequest)
+		return
+	}
+
+	var req SetRevenueWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	revenueWallet, err := h.feeService.SetRevenueWallet(r.Context(), currency, req.WalletID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revenueWallet)
+}
+
+func (h *FeeHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrFeeScheduleNotFound), errors.Is(err, service.ErrMerchantNotFound),
+		errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidFeeSchedule), errors.Is(err, service.ErrCurrencyMismatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
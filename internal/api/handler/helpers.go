@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/go-chi/chi"
+)
+
+// currentUserID returns the authenticated user's ID set by AuthMiddleware.
+func currentUserID(r *http.Request) (int64, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+
+	return userID, ok
+}
+
+// idParam parses the named chi URL parameter as a positive int64.
+func idParam(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+
+	return id, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type HoldHandler struct {
+	holdService domain.HoldService
+	stepUp      auth.StepUpPolicy
+	validate    *validator.Validate
+}
+
+func NewHoldHandler(holdService domain.HoldService, stepUp auth.StepUpPolicy) *HoldHandler {
+	return &HoldHandler{
+		holdService: holdService,
+		stepUp:      stepUp,
+		validate:    validator.New(),
+	}
+}
+
+type AuthorizeHoldRequest struct {
+	PayeeUserID      int64           `json:"payee_user_id" validate:"required,gt=0"`
+	Amount           decimal.Decimal `json:"amount"`
+	Reference        string          `json:"reference" validate:"max=255"`
+	ExpiresInMinutes int             `json:"expires_in_minutes" validate:"omitempty,min=1,max=43200"`
+}
+
+// CaptureHoldRequest captures Amount, or the full hold when Amount is omitted.
+type CaptureHoldRequest struct {
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// Authorize reserves funds the payee can capture without the payer present,
+// so amounts above the step-up threshold need a token from a recent step-up.
+func (h *HoldHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req AuthorizeHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.stepUp.Check(req.Amount, middleware.StepUpAt(r.Context()), time.Now()); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInMinutes) * time.Minute
+
+	hold, err := h.holdService.AuthorizeHold(r.Context(), userID, req.PayeeUserID, req.Amount, req.Reference, ttl)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, hold)
+}
+
+func (h *HoldHandler) Capture(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CaptureHoldRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	hold, err := h.holdService.CaptureHold(r.Context(), userID, id, req.Amount)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hold)
+}
+
+func (h *HoldHandler) Void(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.holdService.VoidHold(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hold)
+}
+
+func (h *HoldHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.holdService.GetHold(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hold)
+}
+
+func (h *HoldHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	holds, err := h.holdService.ListHolds(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if holds == nil {
+		holds = []*domain.Hold{}
+	}
+
+	writeJSON(w, http.StatusOK, holds)
+}
+
+func (h *HoldHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrHoldNotFound), errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrHoldNotAuthorized):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrHoldExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrInsufficientFunds):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrSelfTransfer),
+		errors.Is(err, service.ErrCaptureExceedsHold), errors.Is(err, service.ErrInvalidHoldDuration),
+		errors.Is(err, service.ErrCurrencyMismatch), errors.Is(err, service.ErrFeeExceedsAmount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+type MerchantHandler struct {
+	merchantService domain.MerchantService
+	validate        *validator.Validate
+}
+
+func NewMerchantHandler(merchantService domain.MerchantService) *MerchantHandler {
+	return &MerchantHandler{
+		merchantService: merchantService,
+		validate:        validator.New(),
+	}
+}
+
+// MerchantProfileRequest is the full business profile; updates replace every
+// field.
+type MerchantProfileRequest struct {
+	BusinessName string `json:"business_name" validate:"required,max=255"`
+	LegalName    string `json:"legal_name" validate:"max=255"`
+	Website      string `json:"website" validate:"omitempty,url,max=2048"`
+	SupportEmail string `json:"support_email" validate:"omitempty,email,max=255"`
+	Country      string `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+}
+
+func (req MerchantProfileRequest) profile() domain.MerchantProfile {
+	return domain.MerchantProfile{
+		BusinessName: req.BusinessName,
+		LegalName:    req.LegalName,
+		Website:      req.Website,
+		SupportEmail: req.SupportEmail,
+		Country:      req.Country,
+	}
+}
+
+func (h *MerchantHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := h.decodeProfile(w, r)
+	if !ok {
+		return
+	}
+
+	merchant, err := h.merchantService.CreateMerchant(r.Context(), userID, req.profile())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, merchant)
+}
+
+func (h *MerchantHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	merchant, err := h.merchantService.GetMerchant(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, merchant)
+}
+
+func (h *MerchantHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := h.decodeProfile(w, r)
+	if !ok {
+		return
+	}
+
+	merchant, err := h.merchantService.UpdateMerchant(r.Context(), userID, req.profile())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, merchant)
+}
+
+func (h *MerchantHandler) decodeProfile(w http.ResponseWriter, r *http.Request) (MerchantProfileRequest, bool) {
+	var req MerchantProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return req, false
+	}
+
+	return req, true
+}
+
+func (h *MerchantHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrMerchantNotFound), errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrMerchantExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type PaymentRequestHandler struct {
+	paymentRequestService domain.PaymentRequestService
+	stepUp                auth.StepUpPolicy
+	validate              *validator.Validate
+}
+
+func NewPaymentRequestHandler(paymentRequestService domain.PaymentRequestService, stepUp auth.StepUpPolicy) *PaymentRequestHandler {
+	return &PaymentRequestHandler{
+		paymentRequestService: paymentRequestService,
+		stepUp:                stepUp,
+		validate:              validator.New(),
+	}
+}
+
+type CreatePaymentRequestRequest struct {
+	PayerUserID    int64           `json:"payer_user_id" validate:"required,gt=0"`
+	Amount         decimal.Decimal `json:"amount"`
+	Memo           string          `json:"memo" validate:"max=255"`
+	ExpiresInHours int             `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+}
+
+func (h *PaymentRequestHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreatePaymentRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInHours) * time.Hour
+
+	pr, err := h.paymentRequestService.CreatePaymentRequest(r.Context(), userID, req.PayerUserID, req.Amount, req.Memo, ttl)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+// ListIncoming returns pending requests the caller has been asked to pay.
+func (h *PaymentRequestHandler) ListIncoming(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.paymentRequestService.ListIncomingPaymentRequests)
+}
+
+// ListOutgoing returns pending requests the caller has sent.
+func (h *PaymentRequestHandler) ListOutgoing(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, h.paymentRequestService.ListOutgoingPaymentRequests)
+}
+
+func (h *PaymentRequestHandler) list(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, userID int64) ([]*domain.PaymentRequest, error)) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	requests, err := fn(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if requests == nil {
+		requests = []*domain.PaymentRequest{}
+	}
+
+	writeJSON(w, http.StatusOK, requests)
+}
+
+// Accept pays the request. Amounts above the step-up threshold need a token
+// from a recent step-up, as for transfers.
+func (h *PaymentRequestHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, func(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+		// The amount of a request never changes, so it can be checked
+		// before the request is locked.
+		pr, err := h.paymentRequestService.GetPaymentRequestForPayer(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := h.stepUp.Check(pr.Amount, middleware.StepUpAt(ctx), time.Now()); err != nil {
+			return nil, err
+		}
+
+		return h.paymentRequestService.AcceptPaymentRequest(ctx, userID, id)
+	})
+}
+
+func (h *PaymentRequestHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.paymentRequestService.DeclinePaymentRequest)
+}
+
+func (h *PaymentRequestHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.paymentRequestService.CancelPaymentRequest)
+}
+
+func (h *PaymentRequestHandler) transition(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error)) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid payment request ID", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := fn(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (h *PaymentRequestHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrPaymentRequestNotFound), errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrPaymentRequestNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrPaymentRequestExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrSelfTransfer), errors.Is(err, service.ErrInvalidPaymentRequestTTL),
+		errors.Is(err, service.ErrFeeExceedsAmount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
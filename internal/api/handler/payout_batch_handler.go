@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/payoutfile"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/shopspring/decimal"
+)
+
+// maxPayoutFileBody bounds the size of an uploaded batch file. It matches
+// what the signing middleware reads, so batch files can be signed.
+const maxPayoutFileBody = 1 << 20
+
+type PayoutBatchHandler struct {
+	payoutBatchService domain.PayoutBatchService
+	stepUp             auth.StepUpPolicy
+}
+
+func NewPayoutBatchHandler(payoutBatchService domain.PayoutBatchService, stepUp auth.StepUpPolicy) *PayoutBatchHandler {
+	return &PayoutBatchHandler{
+		payoutBatchService: payoutBatchService,
+		stepUp:             stepUp,
+	}
+}
+
+// CreateBatch accepts a batch file sent as the request body, as text/csv or
+// application/json. It responds 202 with the batch once every row has been
+// validated and the total held; the worker pays the items. Batches whose
+// total is above the step-up threshold need a token from a recent step-up.
+func (h *PayoutBatchHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	format, err := payoutfile.FormatFromContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayoutFileBody+1))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(body) > maxPayoutFileBody {
+		http.Error(w, "Payout file too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	rows, err := payoutfile.Parse(format, bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, "Invalid payout file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total := decimal.Zero
+	for _, row := range rows {
+		total = total.Add(row.Amount)
+	}
+
+	if err := h.stepUp.Check(total, middleware.StepUpAt(r.Context()), time.Now()); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	batch, err := h.payoutBatchService.CreatePayoutBatch(r.Context(), userID, rows)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, batch)
+}
+
+func (h *PayoutBatchHandler) ListBatches(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	batches, err := h.payoutBatchService.ListPayoutBatches(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if batches == nil {
+		batches = []*domain.PayoutBatch{}
+	}
+
+	writeJSON(w, http.StatusOK, batches)
+}
+
+// GetBatch returns the batch's progress and the outcome of each item.
+func (h *PayoutBatchHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	batch, ok := h.batch(w, r)
+	if !ok {
+		return
+	}
+
+	if batch.Items == nil {
+		batch.Items = []*domain.PayoutBatchItem{}
+	}
+
+	writeJSON(w, http.StatusOK, batch)
+}
+
+// DownloadResults sends the outcome of each item as an attachment, as CSV
+// unless ?format=json is given. Items still PENDING are included as such.
+func (h *PayoutBatchHandler) DownloadResults(w http.ResponseWriter, r *http.Request) {
+	format := payoutfile.FormatCSV
+	if name := r.URL.Query().Get("format"); name != "" {
+		var err error
+		if format, err = payoutfile.ParseFormat(name); err != nil {
+			http.Error(w, "format must be csv or json", http.StatusBadRequest)
+			return
+		}
+	}
+
+	batch, ok := h.batch(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := payoutfile.WriteResults(&buf, format, batch.Items); err != nil {
+		log.Printf("Error writing results of payout batch %d: %v", batch.ID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payout-batch-%d-results.%s"`, batch.ID, format))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("Error sending results of payout batch %d: %v", batch.ID, err)
+	}
+}
+
+func (h *PayoutBatchHandler) batch(w http.ResponseWriter, r *http.Request) (*domain.PayoutBatch, bool) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid payout batch ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	batch, err := h.payoutBatchService.GetPayoutBatch(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return nil, false
+	}
+
+	return batch, true
+}
+
+func (h *PayoutBatchHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrPayoutBatchNotFound), errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInsufficientFunds):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrInvalidPayoutBatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+type PrivacyHandler struct {
+	privacyService domain.PrivacyService
+	validate       *validator.Validate
+}
+
+func NewPrivacyHandler(privacyService domain.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+		validate:       validator.New(),
+	}
+}
+
+// CreateDataExportRequest picks the archive format; it defaults to JSON.
+type CreateDataExportRequest struct {
+	Format string `json:"format" validate:"omitempty,oneof=JSON ZIP json zip"`
+}
+
+type EraseAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// CreateExport queues an archive of the caller's data. Poll the returned
+// export until it is READY, then download it.
+func (h *PrivacyHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateDataExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := domain.DataExportFormatJSON
+	if req.Format != "" {
+		format = domain.DataExportFormat(strings.ToUpper(req.Format))
+	}
+
+	export, err := h.privacyService.RequestDataExport(r.Context(), userID, format)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, export)
+}
+
+func (h *PrivacyHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	exports, err := h.privacyService.ListDataExports(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if exports == nil {
+		exports = []*domain.DataExport{}
+	}
+
+	writeJSON(w, http.StatusOK, exports)
+}
+
+func (h *PrivacyHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid export ID", http.StatusBadRequest)
+		return
+	}
+
+	export, err := h.privacyService.GetDataExport(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, export)
+}
+
+// DownloadExport streams the archive of a READY export as an attachment.
+func (h *PrivacyHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid export ID", http.StatusBadRequest)
+		return
+	}
+
+	export, file, err := h.privacyService.OpenDataExport(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	defer file.Close()
+
+	contentType, ext := "application/json", "json"
+	if export.Format == domain.DataExportFormatZIP {
+		contentType, ext = "application/zip", "zip"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(export.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="wallet-data-export-%d.%s"`, export.ID, ext))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Error streaming data export %d: %v", export.ID, err)
+	}
+}
+
+// Erase pseudonymises the caller's account and signs them out everywhere.
+// Financial records are kept.
+func (h *PrivacyHandler) Erase(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req EraseAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client := domain.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+
+	if err := h.privacyService.EraseUser(r.Context(), userID, req.Password, client); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PrivacyHandler) writeError(w http.ResponseWriter, err error) {
+	var locked *service.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, service.ErrInvalidCredentials):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, service.ErrInvalidExportFormat):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrDataExportNotFound), errors.Is(err, service.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrDataExportNotReady), errors.Is(err, service.ErrDataExportInProgress),
+		errors.Is(err, service.ErrErasureBlocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrDataExportExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
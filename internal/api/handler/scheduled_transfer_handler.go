@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type ScheduledTransferHandler struct {
+	scheduledTransferService domain.ScheduledTransferService
+	stepUp                   auth.StepUpPolicy
+	validate                 *validator.Validate
+}
+
+func NewScheduledTransferHandler(scheduledTransferService domain.ScheduledTransferService, stepUp auth.StepUpPolicy) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{
+		scheduledTransferService: scheduledTransferService,
+		stepUp:                   stepUp,
+		validate:                 validator.New(),
+	}
+}
+
+// CreateScheduledTransferRequest describes a one-off (run_at) or recurring
+// (cron_expr or day_of_month, optionally starting at start_at) transfer.
+type CreateScheduledTransferRequest struct {
+	ReceiverUserID int64           `json:"receiver_user_id" validate:"required,gt=0"`
+	Amount         decimal.Decimal `json:"amount"`
+	Memo           string          `json:"memo" validate:"max=255"`
+	ScheduleType   string          `json:"schedule_type" validate:"required,oneof=ONCE CRON MONTHLY"`
+	RunAt          *time.Time      `json:"run_at"`
+	CronExpr       string          `json:"cron_expr" validate:"max=100"`
+	DayOfMonth     int             `json:"day_of_month" validate:"omitempty,min=1,max=31"`
+	StartAt        *time.Time      `json:"start_at"`
+}
+
+type scheduledTransferDetail struct {
+	*domain.ScheduledTransfer
+	Runs []*domain.ScheduledTransferRun `json:"runs"`
+}
+
+// Create schedules transfers that later run without the sender present, so
+// amounts above the step-up threshold need a token from a recent step-up when
+// the schedule is created.
+func (h *ScheduledTransferHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateScheduledTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.stepUp.Check(req.Amount, middleware.StepUpAt(r.Context()), time.Now()); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	st := &domain.ScheduledTransfer{
+		SenderUserID:   userID,
+		ReceiverUserID: req.ReceiverUserID,
+		Amount:         req.Amount,
+		Memo:           req.Memo,
+		ScheduleType:   domain.ScheduleType(req.ScheduleType),
+		CronExpr:       req.CronExpr,
+		DayOfMonth:     req.DayOfMonth,
+		NextRunAt:      req.StartAt,
+	}
+	if st.ScheduleType == domain.ScheduleTypeOnce {
+		st.NextRunAt = req.RunAt
+	}
+
+	created, err := h.scheduledTransferService.CreateScheduledTransfer(r.Context(), st)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *ScheduledTransferHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	transfers, err := h.scheduledTransferService.ListScheduledTransfers(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if transfers == nil {
+		transfers = []*domain.ScheduledTransfer{}
+	}
+
+	writeJSON(w, http.StatusOK, transfers)
+}
+
+func (h *ScheduledTransferHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid scheduled transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	st, runs, err := h.scheduledTransferService.GetScheduledTransfer(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if runs == nil {
+		runs = []*domain.ScheduledTransferRun{}
+	}
+
+	writeJSON(w, http.StatusOK, scheduledTransferDetail{ScheduledTransfer: st, Runs: runs})
+}
+
+func (h *ScheduledTransferHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.scheduledTransferService.PauseScheduledTransfer)
+}
+
+func (h *ScheduledTransferHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.scheduledTransferService.ResumeScheduledTransfer)
+}
+
+func (h *ScheduledTransferHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.scheduledTransferService.CancelScheduledTransfer)
+}
+
+func (h *ScheduledTransferHandler) transition(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, error)) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid scheduled transfer ID", http.StatusBadRequest)
+		return
+	}
+
+	st, err := fn(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, st)
+}
+
+func (h *ScheduledTransferHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrScheduledTransferNotFound), errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidScheduleState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInvalidSchedule), errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrSelfTransfer):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type SplitPaymentHandler struct {
+	splitPaymentService domain.SplitPaymentService
+	stepUp              auth.StepUpPolicy
+	validate            *validator.Validate
+}
+
+func NewSplitPaymentHandler(splitPaymentService domain.SplitPaymentService, stepUp auth.StepUpPolicy) *SplitPaymentHandler {
+	return &SplitPaymentHandler{
+		splitPaymentService: splitPaymentService,
+		stepUp:              stepUp,
+		validate:            validator.New(),
+	}
+}
+
+type SplitRecipientRequest struct {
+	UserID     int64           `json:"user_id" validate:"required,gt=0"`
+	Amount     decimal.Decimal `json:"amount"`
+	Percentage decimal.Decimal `json:"percentage"`
+}
+
+type CreateSplitPaymentRequest struct {
+	Amount      decimal.Decimal         `json:"amount"`
+	Description string                  `json:"description" validate:"max=255"`
+	Recipients  []SplitRecipientRequest `json:"recipients" validate:"required,min=2,max=50,dive"`
+}
+
+// CreateSplitPayment queues a payment split between several recipients. It
+// responds 202 with the PENDING split payment and its legs; the worker settles
+// all of them or none. Totals above the step-up threshold need a token from a
+// recent step-up.
+func (h *SplitPaymentHandler) CreateSplitPayment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateSplitPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total := req.Amount
+	recipients := make([]domain.SplitRecipient, 0, len(req.Recipients))
+	for _, recipient := range req.Recipients {
+		recipients = append(recipients, domain.SplitRecipient{
+			UserID:     recipient.UserID,
+			Amount:     recipient.Amount,
+			Percentage: recipient.Percentage,
+		})
+
+		if req.Amount.IsZero() {
+			total = total.Add(recipient.Amount)
+		}
+	}
+
+	if err := h.stepUp.Check(total, middleware.StepUpAt(r.Context()), time.Now()); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	payment, err := h.splitPaymentService.CreateSplitPayment(r.Context(), userID, req.Amount, recipients, req.Description)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, payment)
+}
+
+func (h *SplitPaymentHandler) ListSplitPayments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	payments, err := h.splitPaymentService.ListSplitPayments(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if payments == nil {
+		payments = []*domain.SplitPayment{}
+	}
+
+	writeJSON(w, http.StatusOK, payments)
+}
+
+// GetSplitPayment returns a split payment the user sent and the state of each
+// leg.
+func (h *SplitPaymentHandler) GetSplitPayment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid split payment ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.splitPaymentService.GetSplitPayment(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, payment)
+}
+
+func (h *SplitPaymentHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrSplitPaymentNotFound), errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidSplitPayment), errors.Is(err, service.ErrInvalidAmount),
+		errors.Is(err, service.ErrSelfTransfer), errors.Is(err, service.ErrCurrencyMismatch),
+		errors.Is(err, service.ErrFeeExceedsAmount), errors.Is(err, service.ErrRevenueWalletNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+type StreamHandler struct {
+	streamService domain.StreamService
+	walletService domain.WalletService
+}
+
+func NewStreamHandler(streamService domain.StreamService, walletService domain.WalletService) *StreamHandler {
+	return &StreamHandler{
+		streamService: streamService,
+		walletService: walletService,
+	}
+}
+
+// Stream serves the caller's balance and transfer updates as Server-Sent
+// Events. Clients resume with the Last-Event-ID header, or the last_event_id
+// query parameter for the first connection of an EventSource.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	ctx := r.Context()
+	events, err := h.streamService.Subscribe(ctx, userID, lastEventID)
+	if err != nil {
+		http.Error(w, "Failed to subscribe", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 5000\n\n")
+
+	// A fresh connection starts with the current wallet so clients need not
+	// poll GET /users/wallets for the initial state.
+	if lastEventID == "" {
+		if wallet, err := h.walletService.GetWalletByUserID(ctx, userID); err == nil {
+			if data, err := json.Marshal(wallet); err == nil {
+				writeEvent(w, domain.StreamEvent{Type: "wallet.snapshot", Data: data})
+			}
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := writeEvent(w, event); err != nil {
+				log.Printf("Error writing stream event for user %d: %v", userID, err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event domain.StreamEvent) error {
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+
+	return err
+}
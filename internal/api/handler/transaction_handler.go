@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type TransactionHandler struct {
+	transactionService domain.TransactionService
+	feeService         domain.FeeService
+	stepUp             auth.StepUpPolicy
+	validate           *validator.Validate
+}
+
+func NewTransactionHandler(transactionService domain.TransactionService, feeService domain.FeeService, stepUp auth.StepUpPolicy) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService: transactionService,
+		feeService:         feeService,
+		stepUp:             stepUp,
+		validate:           validator.New(),
+	}
+}
+
+type CreateTransferRequest struct {
+	ReceiverUserID int64           `json:"receiver_user_id" validate:"required,gt=0"`
+	Amount         decimal.Decimal `json:"amount"`
+}
+
+// CreateTransfer queues a transfer to another user. It responds 202 with the
+// PENDING transaction; the worker settles or fails it. Amounts above the
+// step-up threshold need a token from a recent step-up.
+func (h *TransactionHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.stepUp.Check(req.Amount, middleware.StepUpAt(r.Context()), time.Now()); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	tx, err := h.transactionService.CreateTransfer(r.Context(), userID, req.ReceiverUserID, req.Amount)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, tx)
+}
+
+// PreviewTransfer returns the fee and the net amount the receiver would be
+// credited by the transfer, without creating it.
+func (h *TransactionHandler) PreviewTransfer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.feeService.PreviewTransferFee(r.Context(), userID, req.ReceiverUserID, req.Amount)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// ListHistory returns the user's payments, newest first, with each split
+// payment they sent as one entry holding its legs.
+func (h *TransactionHandler) ListHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entries, err := h.transactionService.ListHistory(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if entries == nil {
+		entries = []*domain.HistoryEntry{}
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (h *TransactionHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrSelfTransfer), errors.Is(err, service.ErrFeeExceedsAmount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -3,9 +3,14 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
 	"github.com/amankp-zop/wallet/internal/domain"
 	"github.com/amankp-zop/wallet/internal/service"
 	"github.com/go-playground/validator/v10"
@@ -13,12 +18,14 @@ import (
 
 type UserHandler struct {
 	userService domain.UserService
+	stepUp auth.StepUpPolicy
 	validate *validator.Validate
 }
 
-func NewUserHandler(userService domain.UserService) *UserHandler {
+func NewUserHandler(userService domain.UserService, stepUp auth.StepUpPolicy) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		stepUp: stepUp,
 		validate: validator.New(),
 	}
 }
@@ -34,6 +41,59 @@ type SigninRequest struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string `json:"two_factor_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+// UpdateProfileRequest is a partial update: omitted fields are left as they
+// are and an empty string clears phone or timezone.
+type UpdateProfileRequest struct {
+	Name     *string `json:"name" validate:"omitempty,min=2,max=255"`
+	Phone    *string `json:"phone" validate:"omitempty,e164"`
+	Timezone *string `json:"timezone" validate:"omitempty,max=64"`
+}
+
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required"`
+}
+
+// StepUpRequest carries a TOTP or recovery code for users with two-factor
+// authentication and the password for everyone else.
+type StepUpRequest struct {
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+// EnrollTOTPRequest carries the password of a caller without a recent
+// step-up.
+type EnrollTOTPRequest struct {
+	Password string `json:"password"`
+}
+
 func (h *UserHandler)Signup(w http.ResponseWriter, r *http.Request){
 	var req SignupRequest
 
@@ -81,47 +141,503 @@ func (h *UserHandler)Login(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	token, err := h.userService.Login(r.Context(), req.Email, req.Password)
+	client := domain.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+
+	result, err := h.userService.Login(r.Context(), req.Email, req.Password, client)
+	if err!=nil{
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *UserHandler)GetProfile(w http.ResponseWriter, r *http.Request){
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.userService.GetProfile(r.Context(),userID)
 	if err!=nil{
 		if errors.Is(err, service.ErrUserNotFound){
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
 
-		if errors.Is(err, service.ErrInvalidCredentials){
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", profileETag(user))
+	w.Header().Set("Content-Type","application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// LoginHistory lists the caller's recent login attempts.
+func (h *UserHandler) LoginHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	events, err := h.userService.LoginHistory(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if events == nil {
+		events = []*domain.LoginEvent{}
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+// CompleteTwoFactorLogin exchanges the two-factor token from Login and a TOTP
+// or recovery code for an access token.
+func (h *UserHandler) CompleteTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client := domain.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+
+	result, err := h.userService.CompleteTwoFactorLogin(r.Context(), req.TwoFactorToken, req.Code, client)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// EnrollTOTP starts two-factor enrolment and returns the secret and the
+// provisioning URI to show as a QR code. The caller must have stepped up
+// recently or send their password, so that a stolen access token cannot bind
+// the attacker's authenticator.
+func (h *UserHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req EnrollTOTPRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
+	}
 
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	if !h.stepUp.Recent(middleware.StepUpAt(r.Context()), time.Now()) {
+		if req.Password == "" {
+			h.writeError(w, auth.ErrStepUpRequired)
+			return
+		}
+
+		client := domain.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+		if err := h.userService.VerifyPassword(r.Context(), userID, req.Password, client); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+
+	enrollment, err := h.userService.EnrollTOTP(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, http.StatusOK, enrollment)
+}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"token": token,
+// ConfirmTOTP enables two-factor authentication and returns the recovery
+// codes, which are only shown this once.
+func (h *UserHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.userService.ConfirmTOTP(r.Context(), userID, req.Code)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]string{
+		"recovery_codes": codes,
 	})
 }
 
-func (h *UserHandler)GetProfile(w http.ResponseWriter, r *http.Request){
-	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+func (h *UserHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	user, err := h.userService.GetProfile(r.Context(),userID)
-	if err!=nil{
-		if errors.Is(err, service.ErrUserNotFound){
-			http.Error(w, err.Error(), http.StatusNotFound)
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client := domain.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+
+	if err := h.userService.DisableTOTP(r.Context(), userID, req.Code, client); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StepUp re-authenticates the caller and returns an access token that
+// satisfies the step-up requirement of large transfers for a short while.
+func (h *UserHandler) StepUp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req StepUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	client := domain.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+
+	result, err := h.userService.StepUp(r.Context(), userID, req.Code, req.Password, client)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// ResendEmailVerification mails the caller a new verification link.
+func (h *UserHandler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.userService.SendEmailVerification(r.Context(), userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.VerifyEmail(r.Context(), req.Token); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword always responds 202 so that it cannot be used to find out
+// which emails have accounts.
+func (h *UserHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.ForgotPassword(r.Context(), req.Email); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If the email belongs to an account, a reset link has been sent",
+	})
+}
+
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePassword sets a new password and signs out the caller's other
+// sessions. The response carries a new token for this one.
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client := domain.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+
+	result, err := h.userService.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword, client)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// UpdateProfile changes the caller's name, phone or timezone. The If-Match
+// header must carry the ETag of the profile the change is based on, or "*" to
+// skip the check; a stale ETag gets 412.
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return
+	}
+
+	var ifUnmodifiedSince *time.Time
+	if ifMatch != "*" {
+		updatedAt, ok := parseProfileETag(ifMatch)
+		if !ok {
+			h.writeError(w, service.ErrProfileModified)
 			return
 		}
+		ifUnmodifiedSince = &updatedAt
+	}
 
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type","application/json")
-	json.NewEncoder(w).Encode(user)
-}
\ No newline at end of file
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	update := domain.ProfileUpdate{Name: req.Name, Phone: req.Phone, Timezone: req.Timezone}
+
+	user, err := h.userService.UpdateProfile(r.Context(), userID, update, ifUnmodifiedSince)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", profileETag(user))
+	writeJSON(w, http.StatusOK, user)
+}
+
+// RequestEmailChange starts moving the caller to a new email address. Both
+// the current and the new address get a confirmation link.
+func (h *UserHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req EmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client := domain.ClientInfo{IP: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+
+	change, err := h.userService.RequestEmailChange(r.Context(), userID, req.NewEmail, req.Password, client)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, change)
+}
+
+// GetEmailChange returns the caller's pending email change.
+func (h *UserHandler) GetEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	change, err := h.userService.GetEmailChange(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, change)
+}
+
+// CancelEmailChange cancels the caller's pending email change.
+func (h *UserHandler) CancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.userService.CancelEmailChange(r.Context(), userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ConfirmEmailChange redeems a link from either address of a pending email
+// change. The response shows whether the change has completed.
+func (h *UserHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	change, err := h.userService.ConfirmEmailChange(r.Context(), req.Token)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, change)
+}
+
+// profileETag is a strong ETag built from the profile's updated_at, which is
+// stored with microsecond precision.
+func profileETag(user *domain.User) string {
+	return `"` + strconv.FormatInt(user.UpdatedAt.UnixMicro(), 10) + `"`
+}
+
+func parseProfileETag(etag string) (time.Time, bool) {
+	unquoted, err := strconv.Unquote(etag)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	micros, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMicro(micros).UTC(), true
+}
+
+func (h *UserHandler) writeError(w http.ResponseWriter, err error) {
+	var locked *service.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrInvalidTwoFactorToken):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, service.ErrInvalidUserToken), errors.Is(err, service.ErrInvalidTimezone),
+		errors.Is(err, service.ErrSameEmail):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrEmailChangeNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled),
+		errors.Is(err, service.ErrTwoFactorNotEnrolled), errors.Is(err, service.ErrEmailAlreadyVerified),
+		errors.Is(err, service.ErrUserAlreadyExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrProfileModified):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type VoucherHandler struct {
+	voucherService domain.VoucherService
+	validate       *validator.Validate
+}
+
+func NewVoucherHandler(voucherService domain.VoucherService) *VoucherHandler {
+	return &VoucherHandler{
+		voucherService: voucherService,
+		validate:       validator.New(),
+	}
+}
+
+type CreateVoucherBatchRequest struct {
+	Name           string          `json:"name" validate:"required,max=255"`
+	Amount         decimal.Decimal `json:"amount"`
+	Currency       string          `json:"currency" validate:"required,len=3,uppercase"`
+	ExpiresAt      time.Time       `json:"expires_at" validate:"required"`
+	MaxRedemptions int             `json:"max_redemptions" validate:"omitempty,min=1,max=1000000"`
+	PerUserLimit   int             `json:"per_user_limit" validate:"omitempty,min=1"`
+	CodeCount      int             `json:"code_count" validate:"required,min=1,max=10000"`
+}
+
+type RedeemVoucherRequest struct {
+	Code string `json:"code" validate:"required,max=64"`
+}
+
+// Redeem credits the caller's wallet with a voucher's amount.
+func (h *VoucherHandler) Redeem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req RedeemVoucherRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	redemption, err := h.voucherService.RedeemVoucher(r.Context(), userID, req.Code)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, redemption)
+}
+
+func (h *VoucherHandler) ListRedemptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	redemptions, err := h.voucherService.ListVoucherRedemptions(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if redemptions == nil {
+		redemptions = []*domain.VoucherRedemption{}
+	}
+
+	writeJSON(w, http.StatusOK, redemptions)
+}
+
+// CreateBatch generates a batch of codes. Each code may be redeemed once by
+// default and each user may redeem one code of the batch; the codes are in
+// the response and cannot be retrieved later.
+func (h *VoucherHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateVoucherBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.MaxRedemptions == 0 {
+		req.MaxRedemptions = 1
+	}
+
+	if req.PerUserLimit == 0 {
+		req.PerUserLimit = 1
+	}
+
+	batch, err := h.voucherService.CreateVoucherBatch(r.Context(), userID, req.Name, req.Amount, req.Currency, req.ExpiresAt,
+		req.MaxRedemptions, req.PerUserLimit, req.CodeCount)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, batch)
+}
+
+func (h *VoucherHandler) ListBatches(w http.ResponseWriter, r *http.Request) {
+	batches, err := h.voucherService.ListVoucherBatches(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if batches == nil {
+		batches = []*domain.VoucherBatch{}
+	}
+
+	writeJSON(w, http.StatusOK, batches)
+}
+
+func (h *VoucherHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid voucher batch ID", http.StatusBadRequest)
+		return
+	}
+
+	batch, err := h.voucherService.GetVoucherBatch(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, batch)
+}
+
+func (h *VoucherHandler) DisableBatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid voucher batch ID", http.StatusBadRequest)
+		return
+	}
+
+	batch, err := h.voucherService.DisableVoucherBatch(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, batch)
+}
+
+func (h *VoucherHandler) BatchReport(w http.ResponseWriter, r *http.Request) {
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid voucher batch ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.voucherService.GetVoucherBatchReport(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *VoucherHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrVoucherNotFound), errors.Is(err, service.ErrVoucherBatchNotFound),
+		errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrVoucherInactive):
+		http.Error(w, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrVoucherUsedUp), errors.Is(err, service.ErrVoucherAlreadyRedeemed),
+		errors.Is(err, service.ErrVoucherLimitReached), errors.Is(err, service.ErrPromoFundsExhausted):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInvalidVoucherBatch), errors.Is(err, service.ErrPromoWalletNotFound),
+		errors.Is(err, service.ErrCurrencyMismatch), errors.Is(err, service.ErrSelfTransfer):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/api/middleware"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+type WithdrawalHandler struct {
+	withdrawalService domain.WithdrawalService
+	stepUp            auth.StepUpPolicy
+	validate          *validator.Validate
+}
+
+func NewWithdrawalHandler(withdrawalService domain.WithdrawalService, stepUp auth.StepUpPolicy) *WithdrawalHandler {
+	return &WithdrawalHandler{
+		withdrawalService: withdrawalService,
+		stepUp:            stepUp,
+		validate:          validator.New(),
+	}
+}
+
+type CreateBeneficiaryRequest struct {
+	Nickname          string `json:"nickname" validate:"max=100"`
+	AccountHolderName string `json:"account_holder_name" validate:"required,max=255"`
+	Currency          string `json:"currency" validate:"required,len=3,uppercase"`
+	AccountType       string `json:"account_type" validate:"required,oneof=IBAN ACCOUNT_NUMBER"`
+	AccountNumber     string `json:"account_number" validate:"required,max=64"`
+	Country           string `json:"country" validate:"omitempty,len=2,uppercase"`
+	RoutingCode       string `json:"routing_code" validate:"max=32"`
+}
+
+// CreateBeneficiary saves a bank account the caller can withdraw to. IBANs,
+// account numbers and routing codes are checked before the account is saved.
+func (h *WithdrawalHandler) CreateBeneficiary(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateBeneficiaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	beneficiary, err := h.withdrawalService.CreateBeneficiary(r.Context(), userID, domain.BankBeneficiaryParams{
+		Nickname:          req.Nickname,
+		AccountHolderName: req.AccountHolderName,
+		Currency:          req.Currency,
+		AccountType:       domain.BankAccountType(req.AccountType),
+		AccountNumber:     req.AccountNumber,
+		Country:           req.Country,
+		RoutingCode:       req.RoutingCode,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, beneficiary)
+}
+
+func (h *WithdrawalHandler) ListBeneficiaries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	beneficiaries, err := h.withdrawalService.ListBeneficiaries(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if beneficiaries == nil {
+		beneficiaries = []*domain.BankBeneficiary{}
+	}
+
+	writeJSON(w, http.StatusOK, beneficiaries)
+}
+
+func (h *WithdrawalHandler) GetBeneficiary(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid beneficiary ID", http.StatusBadRequest)
+		return
+	}
+
+	beneficiary, err := h.withdrawalService.GetBeneficiary(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, beneficiary)
+}
+
+func (h *WithdrawalHandler) DeleteBeneficiary(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid beneficiary ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.withdrawalService.DeleteBeneficiary(r.Context(), userID, id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type CreateWithdrawalRequest struct {
+	BeneficiaryID int64           `json:"beneficiary_id" validate:"required,gt=0"`
+	Amount        decimal.Decimal `json:"amount"`
+}
+
+// CreateWithdrawal holds the amount and queues the payout. It responds 202
+// with the PENDING withdrawal; the worker pays it out. Amounts above the
+// step-up threshold need a token from a recent step-up, as for transfers.
+func (h *WithdrawalHandler) CreateWithdrawal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateWithdrawalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.stepUp.Check(req.Amount, middleware.StepUpAt(r.Context()), time.Now()); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	withdrawal, err := h.withdrawalService.CreateWithdrawal(r.Context(), userID, req.BeneficiaryID, req.Amount)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, withdrawal)
+}
+
+func (h *WithdrawalHandler) ListWithdrawals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	withdrawals, err := h.withdrawalService.ListWithdrawals(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if withdrawals == nil {
+		withdrawals = []*domain.Withdrawal{}
+	}
+
+	writeJSON(w, http.StatusOK, withdrawals)
+}
+
+func (h *WithdrawalHandler) GetWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.withdrawalByID(w, r, h.withdrawalService.GetWithdrawal)
+}
+
+func (h *WithdrawalHandler) CancelWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.withdrawalByID(w, r, h.withdrawalService.CancelWithdrawal)
+}
+
+func (h *WithdrawalHandler) withdrawalByID(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, userID, id int64) (*domain.Withdrawal, error)) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := idParam(r, "id")
+	if !ok {
+		http.Error(w, "Invalid withdrawal ID", http.StatusBadRequest)
+		return
+	}
+
+	withdrawal, err := fn(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, withdrawal)
+}
+
+func (h *WithdrawalHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrBeneficiaryNotFound), errors.Is(err, service.ErrWithdrawalNotFound),
+		errors.Is(err, service.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrWithdrawalNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInsufficientFunds):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrInvalidBankAccount),
+		errors.Is(err, service.ErrCurrencyMismatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -2,65 +2,241 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
 )
 
 type contextKey string
 
-const UserIDContextKey = contextKey("userID")
+const (
+	UserIDContextKey    = contextKey("userID")
+	ClaimsContextKey    = contextKey("claims")
+	PrincipalContextKey = contextKey("principal")
+)
+
+var (
+	ErrMissingAuthorization = errors.New("missing authorization header")
+	ErrInvalidAuthorization = errors.New("invalid authorization header format")
+	ErrInvalidToken         = auth.ErrInvalidToken
+	ErrInvalidTokenClaims   = auth.ErrInvalidTokenClaims
+	ErrSessionRevoked       = auth.ErrSessionRevoked
+	ErrInvalidAPIKey        = auth.ErrInvalidAPIKey
+	ErrAPIKeyIPNotAllowed   = auth.ErrAPIKeyIPNotAllowed
+)
+
+// SessionVerifier rejects tokens that are validly signed but have been
+// revoked, for example by a password change.
+type SessionVerifier interface {
+	VerifySession(ctx context.Context, userID int64, issuedAt time.Time) error
+}
+
+// APIKeyVerifier looks up an API key and checks that it may be used from
+// clientIP.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key, clientIP string) (*domain.APIKey, error)
+}
+
+// Principal is who a request is authenticated as: a user signed in with a
+// JWT, in which case Claims is set, or a user's API key. SignedAt is set when
+// an API key request carried a valid signature.
+type Principal struct {
+	UserID   int64
+	Claims   *auth.Claims
+	APIKey   *domain.APIKey
+	SignedAt time.Time
+}
+
+// Allows reports whether the principal may act within scope. Signed-in users
+// hold every scope.
+func (p *Principal) Allows(scope domain.APIKeyScope) bool {
+	return p.APIKey == nil || p.APIKey.HasScope(scope)
+}
 
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+// WithPrincipal returns ctx carrying principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, PrincipalContextKey, principal)
+}
+
+// PrincipalFrom returns the principal of an authenticated request.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(PrincipalContextKey).(*Principal)
+	return principal, ok
+}
+
+// Authenticate verifies a "Bearer <jwt>" authorization value and returns the
+// claims of the access token. It is shared by the HTTP and gRPC APIs. Errors
+// other than the Err* values of this package come from sessions and are not
+// the caller's fault.
+func Authenticate(ctx context.Context, authHeader, jwtSecret string, sessions SessionVerifier) (*auth.Claims, error) {
+	if authHeader == "" {
+		return nil, ErrMissingAuthorization
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) < 2 || parts[0] != "Bearer" {
+		return nil, ErrInvalidAuthorization
+	}
+
+	claims, err := auth.Parse(jwtSecret, parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != auth.TypeAccess {
+		return nil, ErrInvalidToken
+	}
+
+	if err := sessions.VerifySession(ctx, claims.UserID, claims.IssuedAt); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// WithClaims returns ctx carrying the authenticated user's claims.
+func WithClaims(ctx context.Context, claims *auth.Claims) context.Context {
+	ctx = context.WithValue(ctx, UserIDContextKey, claims.UserID)
+	ctx = WithPrincipal(ctx, &Principal{UserID: claims.UserID, Claims: claims})
+	return context.WithValue(ctx, ClaimsContextKey, claims)
+}
+
+// StepUpAt returns when the caller last completed a step-up, or the zero
+// time if their token carries none. A signed API key request counts as a
+// step-up at the time it was verified.
+func StepUpAt(ctx context.Context) time.Time {
+	if principal, ok := PrincipalFrom(ctx); ok && principal.APIKey != nil {
+		return principal.SignedAt
+	}
+
+	claims, ok := ctx.Value(ClaimsContextKey).(*auth.Claims)
+	if !ok {
+		return time.Time{}
+	}
+
+	return claims.StepUpAt
+}
+
+// AuthMiddleware authenticates requests carrying either a JWT or an API key
+// as a bearer token and stores the Principal in the context.
+//
+// Only JWTs set the user ID read by handlers. An API key principal gets it
+// from RequireScope, so routes without a scope check stay closed to API keys.
+func AuthMiddleware(jwtSecret string, sessions SessionVerifier, apiKeys APIKeyVerifier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
-			if authHeader == ""{
-				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
-				
+
+			if token, ok := strings.CutPrefix(authHeader, "Bearer "); ok && auth.IsAPIKey(token) {
+				key, err := apiKeys.VerifyAPIKey(r.Context(), token, ClientIP(r))
+				if err != nil {
+					writeAuthError(w, err)
+					return
+				}
+
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), &Principal{UserID: key.UserID, APIKey: key})))
 				return
 			}
 
-			parts := strings.Split(authHeader, " ")
-			if len(parts)<2 || parts[0]!="Bearer"{
-				http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-				
+			claims, err := Authenticate(r.Context(), authHeader, jwtSecret, sessions)
+			if err != nil {
+				writeAuthError(w, err)
 				return
 			}
 
-			tokenString := parts[1]
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
+}
 
-			token,err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-				if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok{
-					return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-				}
-				
-				return []byte(jwtSecret), nil
-			})
-			if err!=nil{
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
-				
+// RequireScope admits signed-in users and API keys granted scope. API keys
+// without it are refused with 403.
+func RequireScope(scope domain.APIKeyScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFrom(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
-			if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid{
-				userIDFloat, ok:= claims["sub"].(float64)
-				if !ok{
-					http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-					
-					return
-				}
+			if !principal.Allows(scope) {
+				http.Error(w, "API key lacks the "+string(scope)+" scope", http.StatusForbidden)
+				return
+			}
 
-				userID := int64(userIDFloat)
+			ctx := context.WithValue(r.Context(), UserIDContextKey, principal.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-				ctx := context.WithValue(r.Context(), UserIDContextKey, userID)
-				next.ServeHTTP(w, r.WithContext(ctx))
-			}else{
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+// RequireAdmin admits only signed-in users listed in adminUserIDs. API keys
+// are refused even when they belong to an admin.
+func RequireAdmin(adminUserIDs []int64) func(http.Handler) http.Handler {
+	admins := make(map[int64]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFrom(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
 			}
 
+			if principal.APIKey != nil || !admins[principal.UserID] {
+				http.Error(w, "Admin access required", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func writeAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrAPIKeyIPNotAllowed):
+		http.Error(w, "API key is not allowed from this address", http.StatusForbidden)
+	case IsAuthError(err):
+		http.Error(w, authErrorMessage(err), http.StatusUnauthorized)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// IsAuthError reports whether err from Authenticate means the credentials
+// were rejected.
+func IsAuthError(err error) bool {
+	for _, target := range []error{ErrMissingAuthorization, ErrInvalidAuthorization, ErrInvalidToken, ErrInvalidTokenClaims, ErrSessionRevoked, ErrInvalidAPIKey} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func authErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrMissingAuthorization):
+		return "Missing Authorization header"
+	case errors.Is(err, ErrInvalidAuthorization):
+		return "Invalid Authorization header format"
+	case errors.Is(err, ErrInvalidTokenClaims):
+		return "Invalid token claims"
+	case errors.Is(err, ErrSessionRevoked):
+		return "Session has been revoked"
+	case errors.Is(err, ErrInvalidAPIKey):
+		return "Invalid API key"
+	default:
+		return "Invalid token"
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+// maxValidatedResponseBody caps how much of a response body is kept for
+// validation; larger bodies are only checked for status and headers.
+const maxValidatedResponseBody = 1 << 20
+
+var ErrRouteNotDocumented = errors.New("route is not described by the OpenAPI document")
+
+// OpenAPIValidator checks every request and response against doc and passes
+// any mismatch to report. Responses are still written to the client unchanged,
+// so the middleware can run in development to log drift, and in tests with a
+// report func that fails the test:
+//
+//	validator, _ := middleware.OpenAPIValidator(doc, func(r *http.Request, err error) {
+//		t.Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+//	})
+//	router.Use(validator)
+//
+// Authentication is left to AuthMiddleware; the validator does not check
+// security requirements.
+func OpenAPIValidator(doc *openapi3.T, report func(r *http.Request, err error)) (func(http.Handler) http.Handler, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         true,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				report(r, fmt.Errorf("%w: %v", ErrRouteNotDocumented, err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestInput := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+			if err := openapi3filter.ValidateRequest(r.Context(), requestInput); err != nil {
+				report(r, fmt.Errorf("request: %w", err))
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			responseOptions := *options
+			responseOptions.ExcludeResponseBody = recorder.truncated || isEventStream(recorder.Header())
+
+			responseInput := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: requestInput,
+				Status:                 recorder.status,
+				Header:                 recorder.Header(),
+				Options:                &responseOptions,
+			}
+			responseInput.SetBodyBytes(recorder.body.Bytes())
+
+			if err := openapi3filter.ValidateResponse(r.Context(), responseInput); err != nil {
+				report(r, fmt.Errorf("response %d: %w", recorder.status, err))
+			}
+		})
+	}, nil
+}
+
+func isEventStream(header http.Header) bool {
+	return strings.HasPrefix(header.Get("Content-Type"), "text/event-stream")
+}
+
+// responseRecorder passes the response through while keeping a copy of the
+// status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	truncated   bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+
+	if !r.truncated {
+		if r.body.Len()+len(p) > maxValidatedResponseBody {
+			r.truncated = true
+			r.body.Reset()
+		} else {
+			r.body.Write(p)
+		}
+	}
+
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
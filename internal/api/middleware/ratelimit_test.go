@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/ratelimit"
+)
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("rate limit store unavailable")
+}
+
+// serveLimited sends a request from ip, as userID when it is non-zero,
+// through limiter.
+func serveLimited(limiter func(http.Handler) http.Handler, ip string, userID int64) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/transactions", nil)
+	req.RemoteAddr = ip + ":5000"
+	if userID != 0 {
+		req = req.WithContext(context.WithValue(req.Context(), UserIDContextKey, userID))
+	}
+
+	rec := httptest.NewRecorder()
+	limiter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestRateLimitPerIP(t *testing.T) {
+	limiter := RateLimit(ratelimit.NewMemoryStore(), "login", ratelimit.Limit{Requests: 2, Period: time.Minute}, ratelimit.Limit{})
+
+	for _, want := range []string{"1", "0"} {
+		rec := serveLimited(limiter, "203.0.113.1", 0)
+		if rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Remaining") != want {
+			t.Fatalf("response = %d with %s remaining, want 204 with %s", rec.Code, rec.Header().Get("RateLimit-Remaining"), want)
+		}
+
+		if rec.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("RateLimit-Limit = %q, want 2", rec.Header().Get("RateLimit-Limit"))
+		}
+	}
+
+	rec := serveLimited(limiter, "203.0.113.1", 0)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("third response = %d with Retry-After %q, want 429 after 30", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	if rec := serveLimited(limiter, "203.0.113.2", 0); rec.Code != http.StatusNoContent {
+		t.Errorf("response to another IP = %d, want 204", rec.Code)
+	}
+}
+
+func TestRateLimitPerUser(t *testing.T) {
+	limiter := RateLimit(ratelimit.NewMemoryStore(), "transfers", ratelimit.Limit{Requests: 100, Period: time.Minute}, ratelimit.Limit{Requests: 1, Period: time.Minute})
+
+	if rec := serveLimited(limiter, "203.0.113.1", 7); rec.Code != http.StatusNoContent {
+		t.Fatalf("first response = %d, want 204", rec.Code)
+	}
+
+	// The user's bucket is empty, wherever they call from, and is reported
+	// over the roomier IP bucket.
+	rec := serveLimited(limiter, "203.0.113.2", 7)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("second response = %d with limit %q, want 429 with limit 1", rec.Code, rec.Header().Get("RateLimit-Limit"))
+	}
+
+	if rec := serveLimited(limiter, "203.0.113.1", 8); rec.Code != http.StatusNoContent {
+		t.Errorf("response to another user = %d, want 204", rec.Code)
+	}
+}
+
+func TestRateLimitLetsRequestsThroughWhenStoreFails(t *testing.T) {
+	limiter := RateLimit(failingRateLimitStore{}, "login", ratelimit.Limit{Requests: 1, Period: time.Minute}, ratelimit.Limit{})
+
+	for range 3 {
+		rec := serveLimited(limiter, "203.0.113.1", 0)
+		if rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("response = %d with limit %q, want 204 without rate limit headers", rec.Code, rec.Header().Get("RateLimit-Limit"))
+		}
+	}
+}
+
+func TestClientIPIgnoresForwardingHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.1:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+
+	if got := ClientIP(req); got != "203.0.113.1" {
+		t.Errorf("ClientIP() = %q, want the connection peer", got)
+	}
+}
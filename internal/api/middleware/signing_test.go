@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/nonce"
+	"github.com/amankp-zop/wallet/pkg/reqsign"
+)
+
+const testSigningSecret = "sk_test_secret"
+
+type failingNonceStore struct{}
+
+func (failingNonceStore) Claim(context.Context, string, time.Duration) (bool, error) {
+	return false, errors.New("nonce store unavailable")
+}
+
+// signedRequest builds a request signed at ts and returns it with its nonce.
+func signedRequest(t *testing.T, method, target, body string, ts time.Time) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if err := reqsign.SignRequest(req, testSigningSecret, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	return req
+}
+
+func withAPIKey(req *http.Request, key *domain.APIKey) *http.Request {
+	return req.WithContext(WithPrincipal(req.Context(), &Principal{UserID: 1, APIKey: key}))
+}
+
+// serveSigned runs req through VerifySignedRequests and reports the response
+// and whether the handler saw a signed principal and the original body.
+func serveSigned(store nonce.Store, req *http.Request) (*httptest.ResponseRecorder, bool) {
+	var signed bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := PrincipalFrom(r.Context())
+		signed = principal != nil && !principal.SignedAt.IsZero()
+
+		io.Copy(w, r.Body)
+	})
+
+	rec := httptest.NewRecorder()
+	VerifySignedRequests(store, time.Minute)(next).ServeHTTP(rec, req)
+
+	return rec, signed
+}
+
+func TestVerifySignedRequests(t *testing.T) {
+	key := &domain.APIKey{Prefix: "wk_test", SigningSecret: testSigningSecret}
+	required := &domain.APIKey{Prefix: "wk_req", SigningSecret: testSigningSecret, RequireSignature: true}
+	now := time.Now()
+
+	tamper := func(req *http.Request, f func(*http.Request)) *http.Request {
+		f(req)
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		key        *domain.APIKey
+		req        *http.Request
+		wantStatus int
+		wantSigned bool
+	}{
+		{
+			name:       "valid signature",
+			key:        key,
+			req:        signedRequest(t, "POST", "/api/v1/transactions?dry_run=true", `{"amount":"1"}`, now),
+			wantStatus: http.StatusOK,
+			wantSigned: true,
+		},
+		{
+			name:       "oldest allowed timestamp",
+			key:        key,
+			req:        signedRequest(t, "GET", "/api/v1/wallets/me", "", now.Add(-time.Minute+2*time.Second)),
+			wantStatus: http.StatusOK,
+			wantSigned: true,
+		},
+		{
+			name:       "newest allowed timestamp",
+			key:        key,
+			req:        signedRequest(t, "GET", "/api/v1/wallets/me", "", now.Add(time.Minute-2*time.Second)),
+			wantStatus: http.StatusOK,
+			wantSigned: true,
+		},
+		{
+			name:       "timestamp too old",
+			key:        key,
+			req:        signedRequest(t, "GET", "/api/v1/wallets/me", "", now.Add(-time.Minute-2*time.Second)),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "timestamp too new",
+			key:        key,
+			req:        signedRequest(t, "GET", "/api/v1/wallets/me", "", now.Add(time.Minute+2*time.Second)),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered body",
+			key:  key,
+			req: tamper(signedRequest(t, "POST", "/api/v1/transactions", `{"amount":"1"}`, now), func(r *http.Request) {
+				r.Body = io.NopCloser(strings.NewReader(`{"amount":"1000"}`))
+			}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered path",
+			key:  key,
+			req: tamper(signedRequest(t, "POST", "/api/v1/transactions", "", now), func(r *http.Request) {
+				r.URL.Path = "/api/v1/withdrawals"
+			}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered query",
+			key:  key,
+			req: tamper(signedRequest(t, "GET", "/api/v1/transactions?limit=10", "", now), func(r *http.Request) {
+				r.URL.RawQuery = "limit=1000"
+			}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong secret",
+			key:        &domain.APIKey{Prefix: "wk_other", SigningSecret: "sk_other"},
+			req:        signedRequest(t, "GET", "/api/v1/wallets/me", "", now),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unsigned request",
+			key:        key,
+			req:        httptest.NewRequest("GET", "/api/v1/wallets/me", nil),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unsigned request to a key requiring signatures",
+			key:        required,
+			req:        httptest.NewRequest("GET", "/api/v1/wallets/me", nil),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "missing nonce",
+			key:  key,
+			req: tamper(signedRequest(t, "GET", "/api/v1/wallets/me", "", now), func(r *http.Request) {
+				r.Header.Del(reqsign.NonceHeader)
+			}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "JWT session",
+			req:        signedRequest(t, "GET", "/api/v1/wallets/me", "", now.Add(-time.Hour)),
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			if tt.key != nil {
+				req = withAPIKey(req, tt.key)
+			}
+
+			rec, signed := serveSigned(nonce.NewMemoryStore(), req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			if signed != tt.wantSigned {
+				t.Errorf("signed = %v, want %v", signed, tt.wantSigned)
+			}
+		})
+	}
+}
+
+func TestVerifySignedRequestsPassesBodyThrough(t *testing.T) {
+	key := &domain.APIKey{Prefix: "wk_test", SigningSecret: testSigningSecret}
+	req := withAPIKey(signedRequest(t, "POST", "/api/v1/transactions", `{"amount":"1"}`, time.Now()), key)
+
+	rec, _ := serveSigned(nonce.NewMemoryStore(), req)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"amount":"1"}` {
+		t.Errorf("handler got %d %q, want the signed body", rec.Code, rec.Body)
+	}
+}
+
+func TestVerifySignedRequestsRejectsReplayedNonce(t *testing.T) {
+	key := &domain.APIKey{Prefix: "wk_test", SigningSecret: testSigningSecret}
+	store := nonce.NewMemoryStore()
+	original := signedRequest(t, "POST", "/api/v1/transactions", `{"amount":"1"}`, time.Now())
+
+	replay := func() *http.Request {
+		req := httptest.NewRequest(original.Method, original.URL.RequestURI(), strings.NewReader(`{"amount":"1"}`))
+		req.Header = original.Header.Clone()
+		return withAPIKey(req, key)
+	}
+
+	if rec, _ := serveSigned(store, replay()); rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec, signed := serveSigned(store, replay())
+	if rec.Code != http.StatusUnauthorized || signed {
+		t.Errorf("replayed request status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	// Nonces are scoped to the key, so another key may use the same one.
+	other := &domain.APIKey{Prefix: "wk_other", SigningSecret: testSigningSecret}
+	req := replay().WithContext(WithPrincipal(context.Background(), &Principal{UserID: 2, APIKey: other}))
+	if rec, _ := serveSigned(store, req); rec.Code != http.StatusOK {
+		t.Errorf("same nonce with another key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestVerifySignedRequestsNonceStoreError(t *testing.T) {
+	key := &domain.APIKey{Prefix: "wk_test", SigningSecret: testSigningSecret}
+	req := withAPIKey(signedRequest(t, "GET", "/api/v1/wallets/me", "", time.Now()), key)
+
+	if rec, _ := serveSigned(failingNonceStore{}, req); rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestVerifySignedRequestsRejectsLargeBody(t *testing.T) {
+	key := &domain.APIKey{Prefix: "wk_test", SigningSecret: testSigningSecret}
+	body := strings.Repeat("x", maxSignedBody+1)
+	req := withAPIKey(signedRequest(t, "POST", "/api/v1/transactions", body, time.Now()), key)
+
+	if rec, _ := serveSigned(nonce.NewMemoryStore(), req); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,85 @@
+// Package openapi holds the OpenAPI 3.1 description of the REST API.
+//
+// The document in openapi.json is maintained by hand; nothing generates it
+// from the handlers. Change it in the same commit as the route or handler it
+// describes. The router tests in cmd/api send a successful and a failing
+// request to every operation through the validation middleware, and fail on
+// any request or response the document does not describe, or on any
+// operation without a test.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.json
+var Spec []byte
+
+// Load parses and validates the embedded document.
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+
+	doc, err := loader.LoadFromData(Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	nullableFromTypes(doc)
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Handler serves the document at /openapi.json.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(Spec)
+}
+
+// nullableFromTypes rewrites OpenAPI 3.1 type lists that include "null" into
+// the 3.0 nullable flag, which is what kin-openapi validates against.
+func nullableFromTypes(doc *openapi3.T) {
+	seen := map[*openapi3.Schema]bool{}
+
+	var visit func(ref *openapi3.SchemaRef)
+	visit = func(ref *openapi3.SchemaRef) {
+		if ref == nil || ref.Value == nil || seen[ref.Value] {
+			return
+		}
+		schema := ref.Value
+		seen[schema] = true
+
+		if schema.Type != nil && schema.Type.Includes(openapi3.TypeNull) {
+			var types openapi3.Types
+			for _, t := range schema.Type.Slice() {
+				if t != openapi3.TypeNull {
+					types = append(types, t)
+				}
+			}
+			schema.Type = &types
+			schema.Nullable = true
+		}
+
+		for _, property := range schema.Properties {
+			visit(property)
+		}
+		visit(schema.Items)
+		visit(schema.AdditionalProperties.Schema)
+		for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.AnyOf, schema.OneOf} {
+			for _, ref := range refs {
+				visit(ref)
+			}
+		}
+	}
+
+	for _, ref := range doc.Components.Schemas {
+		visit(ref)
+	}
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StepUpPolicy decides when an operation needs a recent step-up. Transfers
+// above Threshold require the token's step-up to be at most MaxAge old; an
+// invalid Threshold disables the check.
+type StepUpPolicy struct {
+	Threshold decimal.NullDecimal
+	MaxAge    time.Duration
+}
+
+// Check returns ErrStepUpRequired if moving amount needs a step-up more
+// recent than stepUpAt.
+func (p StepUpPolicy) Check(amount decimal.Decimal, stepUpAt, now time.Time) error {
+	if !p.Threshold.Valid || !amount.GreaterThan(p.Threshold.Decimal) {
+		return nil
+	}
+
+	if !p.Recent(stepUpAt, now) {
+		return ErrStepUpRequired
+	}
+
+	return nil
+}
+
+// Recent reports whether a step-up at stepUpAt is at most MaxAge old, for
+// operations that need one whatever the amount.
+func (p StepUpPolicy) Recent(stepUpAt, now time.Time) bool {
+	return !stepUpAt.IsZero() && now.Sub(stepUpAt) <= p.MaxAge
+}
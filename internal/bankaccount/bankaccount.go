@@ -0,0 +1,148 @@
+// Package bankaccount validates the identifiers of external bank accounts:
+// IBANs, BICs and domestic account and routing numbers.
+package bankaccount
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidIBAN          = errors.New("iban is not valid")
+	ErrInvalidBIC           = errors.New("bic is not valid")
+	ErrInvalidAccountNumber = errors.New("account number is not valid")
+	ErrInvalidRoutingCode   = errors.New("routing code is not valid")
+)
+
+// ibanLengths is the length of an IBAN in each country of the IBAN registry.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22, "BH": 22, "BR": 29,
+	"BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22, "DK": 18, "DO": 28, "EE": 20, "EG": 29,
+	"ES": 24, "FI": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27, "GT": 28,
+	"HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20,
+	"LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MD": 24, "ME": 22, "MK": 19,
+	"MR": 27, "MT": 31, "MU": 30, "NL": 18, "NO": 15, "PK": 24, "PL": 28, "PS": 29, "PT": 25, "QA": 29,
+	"RO": 24, "RS": 22, "SA": 24, "SC": 31, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+var (
+	bicPattern         = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	alphanumPattern    = regexp.MustCompile(`^[A-Z0-9]+$`)
+	digitsPattern      = regexp.MustCompile(`^[0-9]+$`)
+	separatorsReplacer = strings.NewReplacer(" ", "", "-", "")
+)
+
+// Normalize removes the spaces and dashes people type into account
+// identifiers and upper-cases them.
+func Normalize(s string) string {
+	return strings.ToUpper(separatorsReplacer.Replace(strings.TrimSpace(s)))
+}
+
+// ValidateIBAN returns iban normalised and its country code, or
+// ErrInvalidIBAN if it has the wrong length for its country or fails the
+// ISO 7064 mod 97 check.
+func ValidateIBAN(iban string) (string, string, error) {
+	iban = Normalize(iban)
+
+	if len(iban) < 15 || !alphanumPattern.MatchString(iban) {
+		return "", "", ErrInvalidIBAN
+	}
+
+	country := iban[:2]
+	if length, ok := ibanLengths[country]; !ok || len(iban) != length {
+		return "", "", ErrInvalidIBAN
+	}
+
+	// Move the country code and check digits to the end and read letters as
+	// 10 to 35; the remainder of the number must be 1.
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, c := range rearranged {
+		var value int
+		if c >= '0' && c <= '9' {
+			value = int(c - '0')
+			remainder = (remainder*10 + value) % 97
+			continue
+		}
+
+		value = int(c-'A') + 10
+		remainder = (remainder*100 + value) % 97
+	}
+
+	if remainder != 1 {
+		return "", "", ErrInvalidIBAN
+	}
+
+	return iban, country, nil
+}
+
+// ValidateBIC returns bic normalised, or ErrInvalidBIC if it is not an 8 or
+// 11 character SWIFT code.
+func ValidateBIC(bic string) (string, error) {
+	bic = Normalize(bic)
+
+	if !bicPattern.MatchString(bic) {
+		return "", ErrInvalidBIC
+	}
+
+	return bic, nil
+}
+
+// ValidateAccount checks a domestic account number and the routing code of
+// its bank in country and returns both normalised. US accounts need a nine
+// digit ABA routing number with a valid checksum and GB accounts an eight
+// digit account number and six digit sort code; elsewhere the routing code is
+// the bank's BIC.
+func ValidateAccount(country, accountNumber, routingCode string) (string, string, error) {
+	accountNumber = Normalize(accountNumber)
+	routingCode = Normalize(routingCode)
+
+	switch country {
+	case "US":
+		if len(accountNumber) < 4 || len(accountNumber) > 17 || !digitsPattern.MatchString(accountNumber) {
+			return "", "", ErrInvalidAccountNumber
+		}
+
+		if !validABA(routingCode) {
+			return "", "", ErrInvalidRoutingCode
+		}
+	case "GB":
+		if len(accountNumber) != 8 || !digitsPattern.MatchString(accountNumber) {
+			return "", "", ErrInvalidAccountNumber
+		}
+
+		if len(routingCode) != 6 || !digitsPattern.MatchString(routingCode) {
+			return "", "", ErrInvalidRoutingCode
+		}
+	default:
+		if len(accountNumber) < 4 || len(accountNumber) > 34 || !alphanumPattern.MatchString(accountNumber) {
+			return "", "", ErrInvalidAccountNumber
+		}
+
+		bic, err := ValidateBIC(routingCode)
+		if err != nil {
+			return "", "", ErrInvalidRoutingCode
+		}
+		routingCode = bic
+	}
+
+	return accountNumber, routingCode, nil
+}
+
+// validABA reports whether routing is a nine digit ABA routing number whose
+// weighted digit sum is a multiple of ten.
+func validABA(routing string) bool {
+	if len(routing) != 9 || !digitsPattern.MatchString(routing) {
+		return false
+	}
+
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i, c := range routing {
+		sum += int(c-'0') * weights[i%3]
+	}
+
+	return sum%10 == 0
+}
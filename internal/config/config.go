@@ -3,19 +3,35 @@ package config
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Auth     AuthConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	Redis      RedisConfig
+	Auth       AuthConfig
+	PubSub     PubSubConfig
+	RateLimit  RateLimitConfig
+	Mail       MailConfig
+	Privacy    PrivacyConfig
+	Encryption EncryptionConfig
+	Signing    SigningConfig
+	Gateway    GatewayConfig
+	Payouts    PayoutsConfig
+	Disputes   DisputesConfig
+	Admin      AdminConfig
 }
 
+// ServerConfig holds the listen ports of the API binary: Port for HTTP and
+// GRPCPort for the gRPC API. ValidateOpenAPI logs requests and responses
+// that do not match the OpenAPI document; it is meant for development.
 type ServerConfig struct {
-	Port string
+	Port            string
+	GRPCPort        string `mapstructure:"grpc_port"`
+	ValidateOpenAPI bool   `mapstructure:"validate_openapi"`
 }
 
 type DatabaseConfig struct {
@@ -26,8 +42,119 @@ type RedisConfig struct {
 	Addr string
 }
 
+// PubSubConfig selects the broker behind the live event stream: "redis"
+// (the default) or "memory" for a single process.
+type PubSubConfig struct {
+	Driver string
+}
+
+// RateLimitConfig selects the rate limit store, "redis" (the default) or
+// "memory", and the limits of each route group. Groups that are not
+// configured are not limited.
+type RateLimitConfig struct {
+	Driver string
+	Groups map[string]RateLimitGroup
+}
+
+// RateLimitGroup limits a route group per client IP and per authenticated
+// user. A rule with zero requests is disabled.
+type RateLimitGroup struct {
+	PerIP   RateLimitRule `mapstructure:"per_ip"`
+	PerUser RateLimitRule `mapstructure:"per_user"`
+}
+
+// RateLimitRule is a token bucket allowing Requests per Period with bursts of
+// up to Burst requests.
+type RateLimitRule struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// MailConfig selects how emails are delivered: "smtp", "file" (appended to
+// Path) or "log" (the default). AppURL is the base URL of the links in
+// verification and password reset emails.
+type MailConfig struct {
+	Driver string
+	From   string
+	AppURL string `mapstructure:"app_url"`
+	Path   string
+	SMTP   MailSMTPConfig
+}
+
+type MailSMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// PrivacyConfig sets where data export archives are written and how long they
+// are kept. ExportDir must be shared by the API and the worker.
+type PrivacyConfig struct {
+	ExportDir string        `mapstructure:"export_dir"`
+	ExportTTL time.Duration `mapstructure:"export_ttl"`
+}
+
+// EncryptionConfig holds the keys protecting personal data at rest, base64
+// encoded, inline or in files. KEKs and KEKFiles are keyed by a lower-case
+// key ID; CurrentKEK wraps new data keys and the others are kept for
+// decryption until cmd/rotatekeys has re-encrypted every row.
+type EncryptionConfig struct {
+	CurrentKEK   string            `mapstructure:"current_kek"`
+	KEKs         map[string]string `mapstructure:"keks"`
+	KEKFiles     map[string]string `mapstructure:"kek_files"`
+	IndexKey     string            `mapstructure:"index_key"`
+	IndexKeyFile string            `mapstructure:"index_key_file"`
+}
+
+// SigningConfig selects where the nonces of signed API key requests are
+// remembered, "redis" (the default) or "memory", and how far a request
+// timestamp may be from the server clock.
+type SigningConfig struct {
+	Driver  string
+	MaxSkew time.Duration `mapstructure:"max_skew"`
+}
+
+// GatewayConfig selects the payment gateway deposits are paid through. The
+// driver is required; only "simulated" exists. It signs its callbacks with
+// CallbackSecret and links to payment pages under BaseURL. SimulateRoute
+// serves POST /deposits/{id}/simulate, which completes simulated payments
+// through the API; it is for development only and off by default.
+type GatewayConfig struct {
+	Driver         string
+	CallbackSecret string `mapstructure:"callback_secret"`
+	BaseURL        string `mapstructure:"base_url"`
+	SimulateRoute  bool   `mapstructure:"simulate_route"`
+}
+
+// PayoutsConfig selects the provider withdrawals are paid out through. The
+// driver is required; only "simulated" exists.
+type PayoutsConfig struct {
+	Driver string
+}
+
+// DisputesConfig sets how long a merchant has to answer a dispute before it
+// is resolved for the payer, and how long after a checkout payment the payer
+// may dispute it. Zero uses the service defaults of seven and ninety days.
+type DisputesConfig struct {
+	ResponseWindow time.Duration `mapstructure:"response_window"`
+	FilingWindow   time.Duration `mapstructure:"filing_window"`
+}
+
+// AdminConfig lists the users allowed to use the admin API, such as dispute
+// resolution. They must sign in; API keys are never admins.
+type AdminConfig struct {
+	UserIDs []int64 `mapstructure:"user_ids"`
+}
+
+// AuthConfig holds the JWT secret and the step-up policy: transfers above
+// StepUpThreshold (a decimal string, empty to disable) need a step-up no
+// older than StepUpMaxAge.
 type AuthConfig struct {
-	JWTSecret string `mapstructure:"jwt_secret"`
+	JWTSecret       string        `mapstructure:"jwt_secret"`
+	StepUpThreshold string        `mapstructure:"step_up_threshold"`
+	StepUpMaxAge    time.Duration `mapstructure:"step_up_max_age"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// APIKeyScope is a permission granted to an API key. Scopes only restrict API
+// keys; a signed-in user can do everything their account allows.
+type APIKeyScope string
+
+const (
+	ScopeWalletsRead          APIKeyScope = "wallets:read"
+	ScopeTransfersRead        APIKeyScope = "transfers:read"
+	ScopeTransfersWrite       APIKeyScope = "transfers:write"
+	ScopePaymentRequestsRead  APIKeyScope = "payment_requests:read"
+	ScopePaymentRequestsWrite APIKeyScope = "payment_requests:write"
+	ScopeHoldsRead            APIKeyScope = "holds:read"
+	ScopeHoldsWrite           APIKeyScope = "holds:write"
+	ScopeWebhooksRead         APIKeyScope = "webhooks:read"
+	ScopeWebhooksWrite        APIKeyScope = "webhooks:write"
+	ScopeCheckoutRead         APIKeyScope = "checkout:read"
+	ScopeCheckoutWrite        APIKeyScope = "checkout:write"
+	ScopeDepositsRead         APIKeyScope = "deposits:read"
+	ScopeDepositsWrite        APIKeyScope = "deposits:write"
+	ScopeWithdrawalsRead      APIKeyScope = "withdrawals:read"
+	ScopeWithdrawalsWrite     APIKeyScope = "withdrawals:write"
+	ScopePayoutsRead          APIKeyScope = "payouts:read"
+	ScopePayoutsWrite         APIKeyScope = "payouts:write"
+	ScopeEscrowsRead          APIKeyScope = "escrows:read"
+	ScopeEscrowsWrite         APIKeyScope = "escrows:write"
+	ScopeDisputesRead         APIKeyScope = "disputes:read"
+	ScopeDisputesWrite        APIKeyScope = "disputes:write"
+)
+
+// APIKeyScopes lists every scope a key can be granted.
+var APIKeyScopes = []APIKeyScope{
+	ScopeWalletsRead,
+	ScopeTransfersRead,
+	ScopeTransfersWrite,
+	ScopePaymentRequestsRead,
+	ScopePaymentRequestsWrite,
+	ScopeHoldsRead,
+	ScopeHoldsWrite,
+	ScopeWebhooksRead,
+	ScopeWebhooksWrite,
+	ScopeCheckoutRead,
+	ScopeCheckoutWrite,
+	ScopeDepositsRead,
+	ScopeDepositsWrite,
+	ScopeWithdrawalsRead,
+	ScopeWithdrawalsWrite,
+	ScopePayoutsRead,
+	ScopePayoutsWrite,
+	ScopeEscrowsRead,
+	ScopeEscrowsWrite,
+	ScopeDisputesRead,
+	ScopeDisputesWrite,
+}
+
+// APIKey lets a user's backend call the API without their password. Key is
+// only set when the key is created; afterwards it is identified by Prefix.
+// AllowedIPs holds IP addresses and CIDR ranges; an empty list allows any
+// address.
+//
+// SigningSecret signs requests made with the key. Like Key, it is only
+// returned when it is generated. Keys with RequireSignature refuse unsigned
+// requests.
+type APIKey struct {
+	ID               int64      `json:"id"`
+	UserID           int64      `json:"user_id"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	Key              string     `json:"key,omitempty"`
+	KeyHash          string     `json:"-"`
+	SigningSecret    string     `json:"signing_secret,omitempty"`
+	Scopes           []string   `json:"scopes"`
+	AllowedIPs       []string   `json:"allowed_ips"`
+	RequireSignature bool       `json:"require_signature"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == string(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	GetAPIKeyByID(ctx context.Context, id int64) (*APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	ListAPIKeysByUser(ctx context.Context, userID int64) ([]*APIKey, error)
+	CountActiveAPIKeys(ctx context.Context, userID int64, now time.Time) (int, error)
+	UpdateAPIKeySigningSecret(ctx context.Context, id int64, secret string) error
+	RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error
+	RevokeUserAPIKeys(ctx context.Context, userID int64, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID int64, name string, scopes, allowedIPs []string, requireSignature bool, expiresAt *time.Time) (*APIKey, error)
+	ListAPIKeys(ctx context.Context, userID int64) ([]*APIKey, error)
+	GetAPIKey(ctx context.Context, userID, keyID int64) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int64) error
+	RollSigningSecret(ctx context.Context, userID, keyID int64) (*APIKey, error)
+	VerifyAPIKey(ctx context.Context, key, clientIP string) (*APIKey, error)
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type DataExportFormat string
+
+const (
+	DataExportFormatJSON DataExportFormat = "JSON"
+	DataExportFormatZIP  DataExportFormat = "ZIP"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending DataExportStatus = "PENDING"
+	DataExportStatusReady   DataExportStatus = "READY"
+	DataExportStatusFailed  DataExportStatus = "FAILED"
+	DataExportStatusExpired DataExportStatus = "EXPIRED"
+)
+
+// DataExport is an archive of everything stored about a user, built in the
+// background. Path locates the file in the export directory while it is
+// READY; the file is deleted when the export expires.
+type DataExport struct {
+	ID          int64            `json:"id"`
+	UserID      int64            `json:"-"`
+	Format      DataExportFormat `json:"format"`
+	Status      DataExportStatus `json:"status"`
+	Path        string           `json:"-"`
+	SizeBytes   int64            `json:"size_bytes"`
+	Error       string           `json:"error,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at"`
+	ExpiresAt   *time.Time       `json:"expires_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type DataExportRepository interface {
+	CreateDataExport(ctx context.Context, export *DataExport) error
+	GetDataExportByID(ctx context.Context, id int64) (*DataExport, error)
+	GetDataExportForUpdate(ctx context.Context, id int64) (*DataExport, error)
+	ListDataExportsByUser(ctx context.Context, userID int64) ([]*DataExport, error)
+	ListExpiredDataExportIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdateDataExport(ctx context.Context, export *DataExport) error
+	DeleteDataExports(ctx context.Context, userID int64) error
+}
+
+type PrivacyService interface {
+	RequestDataExport(ctx context.Context, userID int64, format DataExportFormat) (*DataExport, error)
+	ListDataExports(ctx context.Context, userID int64) ([]*DataExport, error)
+	GetDataExport(ctx context.Context, userID, id int64) (*DataExport, error)
+	OpenDataExport(ctx context.Context, userID, id int64) (*DataExport, io.ReadCloser, error)
+	BuildDataExport(ctx context.Context, id int64, finalAttempt bool) error
+	ExpireDataExports(ctx context.Context, now time.Time) (int, error)
+	EraseUser(ctx context.Context, userID int64, password string, client ClientInfo) error
+}
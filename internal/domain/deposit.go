@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type DepositStatus string
+
+const (
+	DepositStatusPending   DepositStatus = "PENDING"
+	DepositStatusSucceeded DepositStatus = "SUCCEEDED"
+	DepositStatusFailed    DepositStatus = "FAILED"
+)
+
+// Deposit is money a user pays into their wallet through a payment gateway.
+// The user completes the payment at PaymentURL; the wallet is credited when
+// the gateway reports the payment succeeded.
+type Deposit struct {
+	ID               int64           `json:"id"`
+	UserID           int64           `json:"user_id"`
+	WalletID         int64           `json:"wallet_id"`
+	Amount           decimal.Decimal `json:"amount"`
+	Currency         string          `json:"currency"`
+	Gateway          string          `json:"gateway"`
+	GatewayReference *string         `json:"gateway_reference"`
+	PaymentURL       string          `json:"payment_url"`
+	Status           DepositStatus   `json:"status"`
+	FailureReason    string          `json:"failure_reason,omitempty"`
+	ExpiresAt        time.Time       `json:"expires_at"`
+	CompletedAt      *time.Time      `json:"completed_at"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// PaymentIntent is a payment a gateway is ready to take. Reference is the
+// gateway's identifier for it.
+type PaymentIntent struct {
+	Reference  string
+	PaymentURL string
+}
+
+// GatewayCallback is a gateway's report on the outcome of a payment intent.
+type GatewayCallback struct {
+	Reference     string
+	Succeeded     bool
+	Amount        decimal.Decimal
+	Currency      string
+	FailureReason string
+}
+
+// PaymentGateway takes payments for deposits. ParseCallback authenticates a
+// callback request from the gateway and returns what it reports.
+type PaymentGateway interface {
+	Name() string
+	CreatePaymentIntent(ctx context.Context, deposit *Deposit) (*PaymentIntent, error)
+	ParseCallback(header http.Header, body []byte) (*GatewayCallback, error)
+}
+
+type DepositRepository interface {
+	CreateDeposit(ctx context.Context, deposit *Deposit) error
+	GetDepositByID(ctx context.Context, id int64) (*Deposit, error)
+	GetDepositForUpdate(ctx context.Context, id int64) (*Deposit, error)
+	GetDepositByGatewayReferenceForUpdate(ctx context.Context, gateway, reference string) (*Deposit, error)
+	ListDepositsByUser(ctx context.Context, userID int64) ([]*Deposit, error)
+	ListExpiredDepositIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdateDeposit(ctx context.Context, deposit *Deposit) error
+}
+
+type DepositService interface {
+	CreateDeposit(ctx context.Context, userID int64, amount decimal.Decimal) (*Deposit, error)
+	ListDeposits(ctx context.Context, userID int64) ([]*Deposit, error)
+	GetDeposit(ctx context.Context, userID, id int64) (*Deposit, error)
+	HandleGatewayCallback(ctx context.Context, gateway string, header http.Header, body []byte) (*Deposit, error)
+	ExpireDeposits(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen        DisputeStatus = "OPEN"
+	DisputeStatusUnderReview DisputeStatus = "UNDER_REVIEW"
+	DisputeStatusPayerWon    DisputeStatus = "PAYER_WON"
+	DisputeStatusMerchantWon DisputeStatus = "MERCHANT_WON"
+	DisputeStatusWithdrawn   DisputeStatus = "WITHDRAWN"
+)
+
+// Dispute contests Amount of a completed transaction. The payer is its sender
+// and the merchant its receiver. Opening the dispute provisionally credits
+// the payer's wallet, where the amount stays held until the dispute is
+// resolved, and debits the merchant's wallet and, for the share of the
+// transaction's fee, the revenue wallet the fee was paid to. An OPEN dispute waits for the merchant to
+// answer before RespondBy; once they do it is UNDER_REVIEW until an admin
+// decides it. A merchant who does not answer in time loses the dispute.
+type Dispute struct {
+	ID               int64              `json:"id"`
+	TransactionID    int64              `json:"transaction_id"`
+	PayerWalletID    int64              `json:"payer_wallet_id"`
+	MerchantWalletID int64              `json:"merchant_wallet_id"`
+	Amount           decimal.Decimal    `json:"amount"`
+	Currency         string             `json:"currency"`
+	Reason           string             `json:"reason"`
+	Status           DisputeStatus      `json:"status"`
+	RespondBy        time.Time          `json:"respond_by"`
+	RespondedAt      *time.Time         `json:"responded_at"`
+	ResolutionNote   string             `json:"resolution_note,omitempty"`
+	ResolvedByUserID *int64             `json:"resolved_by_user_id"`
+	ResolvedAt       *time.Time         `json:"resolved_at"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	Evidence         []*DisputeEvidence `json:"evidence,omitempty"`
+}
+
+// Open reports whether the dispute is still to be decided.
+func (d *Dispute) Open() bool {
+	return d.Status == DisputeStatusOpen || d.Status == DisputeStatusUnderReview
+}
+
+type DisputeParty string
+
+const (
+	DisputePartyPayer    DisputeParty = "PAYER"
+	DisputePartyMerchant DisputeParty = "MERCHANT"
+)
+
+// DisputeEvidence is a statement submitted by one side of a dispute. FileRefs
+// point to supporting files kept elsewhere, such as URLs or document IDs.
+type DisputeEvidence struct {
+	ID        int64        `json:"id"`
+	DisputeID int64        `json:"dispute_id"`
+	UserID    int64        `json:"user_id"`
+	Party     DisputeParty `json:"party"`
+	Text      string       `json:"text"`
+	FileRefs  []string     `json:"file_refs"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type DisputeRepository interface {
+	CreateDispute(ctx context.Context, dispute *Dispute) error
+	GetDisputeByID(ctx context.Context, id int64) (*Dispute, error)
+	GetDisputeForUpdate(ctx context.Context, id int64) (*Dispute, error)
+	GetDisputeByTransaction(ctx context.Context, transactionID int64) (*Dispute, error)
+	ListDisputesByWallet(ctx context.Context, walletID int64) ([]*Dispute, error)
+	ListDisputesByStatus(ctx context.Context, status DisputeStatus, limit int) ([]*Dispute, error)
+	ListOverdueDisputeIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdateDispute(ctx context.Context, dispute *Dispute) error
+	CreateDisputeEvidence(ctx context.Context, evidence *DisputeEvidence) error
+	ListDisputeEvidence(ctx context.Context, disputeID int64) ([]*DisputeEvidence, error)
+}
+
+type DisputeService interface {
+	OpenDispute(ctx context.Context, userID, transactionID int64, amount decimal.Decimal, reason string) (*Dispute, error)
+	SubmitDisputeEvidence(ctx context.Context, userID, id int64, text string, fileRefs []string) (*Dispute, error)
+	AcceptDispute(ctx context.Context, userID, id int64) (*Dispute, error)
+	WithdrawDispute(ctx context.Context, userID, id int64) (*Dispute, error)
+	GetDispute(ctx context.Context, userID, id int64) (*Dispute, error)
+	ListDisputes(ctx context.Context, userID int64) ([]*Dispute, error)
+	AdminListDisputes(ctx context.Context, status DisputeStatus) ([]*Dispute, error)
+	AdminGetDispute(ctx context.Context, id int64) (*Dispute, error)
+	ResolveDispute(ctx context.Context, adminUserID, id int64, status DisputeStatus, note string) (*Dispute, error)
+	ResolveOverdueDisputes(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type EmailChangeStatus string
+
+const (
+	EmailChangeStatusPending   EmailChangeStatus = "PENDING"
+	EmailChangeStatusCompleted EmailChangeStatus = "COMPLETED"
+	EmailChangeStatusCancelled EmailChangeStatus = "CANCELLED"
+)
+
+// EmailChange is a requested change of a user's email. It completes once
+// both OldEmail and NewEmail have confirmed it through the links mailed to
+// them.
+type EmailChange struct {
+	ID             int64             `json:"id"`
+	UserID         int64             `json:"-"`
+	OldEmail       string            `json:"old_email"`
+	NewEmail       string            `json:"new_email"`
+	Status         EmailChangeStatus `json:"status"`
+	OldConfirmedAt *time.Time        `json:"old_confirmed_at"`
+	NewConfirmedAt *time.Time        `json:"new_confirmed_at"`
+	ExpiresAt      time.Time         `json:"expires_at"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+type EmailChangeRepository interface {
+	CreateEmailChange(ctx context.Context, change *EmailChange) error
+	GetPendingEmailChange(ctx context.Context, userID int64) (*EmailChange, error)
+	GetPendingEmailChangeForUpdate(ctx context.Context, userID int64) (*EmailChange, error)
+	UpdateEmailChange(ctx context.Context, change *EmailChange) error
+	ListEmailChangesByUser(ctx context.Context, userID int64) ([]*EmailChange, error)
+	DeleteEmailChanges(ctx context.Context, userID int64) error
+}
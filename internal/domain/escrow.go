@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type EscrowStatus string
+
+const (
+	EscrowStatusFunded   EscrowStatus = "FUNDED"
+	EscrowStatusDisputed EscrowStatus = "DISPUTED"
+	EscrowStatusReleased EscrowStatus = "RELEASED"
+	EscrowStatusRefunded EscrowStatus = "REFUNDED"
+)
+
+// Escrow keeps Amount out of both the payer's and the payee's reach until the
+// deal it secures is settled. The payer funds it when it is created, moving
+// the amount to the house escrow wallet of its currency. The payer or the
+// arbiter releases it to the payee, and the payee or the arbiter refunds it
+// to the payer. A FUNDED escrow is released automatically once ReleaseAfter
+// has passed; disputing it stops that until it is settled by hand.
+type Escrow struct {
+	ID               int64           `json:"id"`
+	PayerWalletID    int64           `json:"payer_wallet_id"`
+	PayeeWalletID    int64           `json:"payee_wallet_id"`
+	ArbiterUserID    *int64          `json:"arbiter_user_id"`
+	EscrowWalletID   int64           `json:"-"`
+	Amount           decimal.Decimal `json:"amount"`
+	Currency         string          `json:"currency"`
+	Description      string          `json:"description"`
+	Status           EscrowStatus    `json:"status"`
+	ReleaseAfter     time.Time       `json:"release_after"`
+	DisputedByUserID *int64          `json:"disputed_by_user_id"`
+	DisputeReason    string          `json:"dispute_reason,omitempty"`
+	DisputedAt       *time.Time      `json:"disputed_at"`
+	SettledByUserID  *int64          `json:"settled_by_user_id"`
+	SettledAt        *time.Time      `json:"settled_at"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// Open reports whether the escrow still holds its amount.
+func (e *Escrow) Open() bool {
+	return e.Status == EscrowStatusFunded || e.Status == EscrowStatusDisputed
+}
+
+type EscrowRepository interface {
+	GetEscrowWalletID(ctx context.Context, currency string) (int64, bool, error)
+	CreateEscrow(ctx context.Context, escrow *Escrow) error
+	GetEscrowByID(ctx context.Context, id int64) (*Escrow, error)
+	GetEscrowForUpdate(ctx context.Context, id int64) (*Escrow, error)
+	ListEscrowsByParty(ctx context.Context, walletID, userID int64) ([]*Escrow, error)
+	ListDueEscrowIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdateEscrow(ctx context.Context, escrow *Escrow) error
+}
+
+type EscrowService interface {
+	CreateEscrow(ctx context.Context, payerUserID, payeeUserID int64, arbiterUserID *int64, amount decimal.Decimal, description string, releaseAfter time.Duration) (*Escrow, error)
+	ReleaseEscrow(ctx context.Context, userID, id int64) (*Escrow, error)
+	RefundEscrow(ctx context.Context, userID, id int64) (*Escrow, error)
+	DisputeEscrow(ctx context.Context, userID, id int64, reason string) (*Escrow, error)
+	GetEscrow(ctx context.Context, userID, id int64) (*Escrow, error)
+	ListEscrows(ctx context.Context, userID int64) ([]*Escrow, error)
+	ReleaseDueEscrows(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FeePlanPersonal is the fee plan of transfers to users who are not
+// merchants.
+const FeePlanPersonal = "personal"
+
+type FeeType string
+
+const (
+	FeeTypeFlat       FeeType = "FLAT"
+	FeeTypePercentage FeeType = "PERCENTAGE"
+	FeeTypeTiered     FeeType = "TIERED"
+)
+
+// FeeTier is one band of a TIERED schedule. UpTo is the largest amount the
+// tier applies to; the last tier leaves it unset.
+type FeeTier struct {
+	UpTo       decimal.NullDecimal `json:"up_to"`
+	FlatAmount decimal.Decimal     `json:"flat_amount"`
+	Percentage decimal.Decimal     `json:"percentage"`
+}
+
+// FeeSchedule prices the transfers of a fee plan, or of one merchant when
+// MerchantID is set. A schedule with a Currency only applies to transfers in
+// that currency. Percentage is in percent, so 2.5 charges 2.5%.
+type FeeSchedule struct {
+	ID         int64               `json:"id"`
+	Plan       *string             `json:"plan"`
+	MerchantID *int64              `json:"merchant_id"`
+	Currency   *string             `json:"currency"`
+	Type       FeeType             `json:"type"`
+	FlatAmount decimal.Decimal     `json:"flat_amount"`
+	Percentage decimal.Decimal     `json:"percentage"`
+	Tiers      []FeeTier           `json:"tiers"`
+	MinFee     decimal.NullDecimal `json:"min_fee"`
+	MaxFee     decimal.NullDecimal `json:"max_fee"`
+	Active     bool                `json:"active"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// Fee returns the fee the schedule charges on amount, rounded to cents and
+// clamped to MinFee and MaxFee. It never returns a negative fee.
+func (s *FeeSchedule) Fee(amount decimal.Decimal) decimal.Decimal {
+	var fee decimal.Decimal
+
+	switch s.Type {
+	case FeeTypeFlat:
+		fee = s.FlatAmount
+	case FeeTypePercentage:
+		fee = s.FlatAmount.Add(amount.Mul(s.Percentage).Div(hundred))
+	case FeeTypeTiered:
+		for _, tier := range s.Tiers {
+			if !tier.UpTo.Valid || amount.LessThanOrEqual(tier.UpTo.Decimal) {
+				fee = tier.FlatAmount.Add(amount.Mul(tier.Percentage).Div(hundred))
+				break
+			}
+		}
+	}
+
+	if s.MinFee.Valid && fee.LessThan(s.MinFee.Decimal) {
+		fee = s.MinFee.Decimal
+	}
+
+	if s.MaxFee.Valid && fee.GreaterThan(s.MaxFee.Decimal) {
+		fee = s.MaxFee.Decimal
+	}
+
+	if fee.IsNegative() {
+		return decimal.Zero
+	}
+
+	return fee.Round(2)
+}
+
+// FeePayer names the party a fee is deducted from.
+type FeePayer string
+
+// FeePayerReceiver means the sender is debited the full amount and the fee
+// comes out of what the receiver is credited.
+const FeePayerReceiver FeePayer = "RECEIVER"
+
+// FeeQuote is the fee a transfer of Amount would be charged. The sender is
+// debited Amount; the receiver is credited NetAmount and FeeWalletID, the
+// house revenue wallet, the fee.
+type FeeQuote struct {
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	NetAmount     decimal.Decimal `json:"net_amount"`
+	FeePaidBy     FeePayer        `json:"fee_paid_by"`
+	Currency      string          `json:"currency"`
+	FeeScheduleID *int64          `json:"fee_schedule_id"`
+	FeeWalletID   *int64          `json:"-"`
+}
+
+// RevenueWallet is the house wallet collecting the fees charged in Currency.
+type RevenueWallet struct {
+	Currency string `json:"currency"`
+	WalletID int64  `json:"wallet_id"`
+}
+
+type FeeRepository interface {
+	ListActiveFeeSchedules(ctx context.Context, plan string, merchantID *int64) ([]*FeeSchedule, error)
+	GetRevenueWalletID(ctx context.Context, currency string) (int64, bool, error)
+	CreateFeeSchedule(ctx context.Context, schedule *FeeSchedule) error
+	GetFeeScheduleByID(ctx context.Context, id int64) (*FeeSchedule, error)
+	ListFeeSchedules(ctx context.Context) ([]*FeeSchedule, error)
+	SetFeeScheduleActive(ctx context.Context, id int64, active bool) error
+	ListRevenueWallets(ctx context.Context) ([]*RevenueWallet, error)
+	UpsertRevenueWallet(ctx context.Context, revenueWallet *RevenueWallet) error
+}
+
+type LedgerEntryType string
+
+const (
+	LedgerEntryTypePrincipal  LedgerEntryType = "PRINCIPAL"
+	LedgerEntryTypeFee        LedgerEntryType = "FEE"
+	LedgerEntryTypeDeposit    LedgerEntryType = "DEPOSIT"
+	LedgerEntryTypeWithdrawal LedgerEntryType = "WITHDRAWAL"
+	LedgerEntryTypeEscrow     LedgerEntryType = "ESCROW"
+	LedgerEntryTypeDispute    LedgerEntryType = "DISPUTE"
+	LedgerEntryTypeVoucher    LedgerEntryType = "VOUCHER"
+)
+
+// LedgerEntry is a signed change to one wallet's balance: one leg of a
+// settled transaction, the credit of a deposit, the debit of a withdrawal,
+// one leg of a movement into or out of escrow, one leg of a dispute's
+// provisional debit or its reversal, or one leg of a voucher redemption.
+type LedgerEntry struct {
+	ID                  int64           `json:"id"`
+	TransactionID       *int64          `json:"transaction_id"`
+	DepositID           *int64          `json:"deposit_id"`
+	WithdrawalID        *int64          `json:"withdrawal_id"`
+	EscrowID            *int64          `json:"escrow_id"`
+	DisputeID           *int64          `json:"dispute_id"`
+	VoucherRedemptionID *int64          `json:"voucher_redemption_id"`
+	WalletID            int64           `json:"wallet_id"`
+	Type                LedgerEntryType `json:"type"`
+	Amount              decimal.Decimal `json:"amount"`
+	CreatedAt           time.Time       `json:"created_at"`
+}
+
+type LedgerRepository interface {
+	CreateLedgerEntry(ctx context.Context, entry *LedgerEntry) error
+	ListLedgerEntriesByTransaction(ctx context.Context, transactionID int64) ([]*LedgerEntry, error)
+}
+
+type FeeService interface {
+	PreviewTransferFee(ctx context.Context, senderUserID, receiverUserID int64, amount decimal.Decimal) (*FeeQuote, error)
+	CreateFeeSchedule(ctx context.Context, schedule *FeeSchedule) (*FeeSchedule, error)
+	ListFeeSchedules(ctx context.Context) ([]*FeeSchedule, error)
+	GetFeeSchedule(ctx context.Context, id int64) (*FeeSchedule, error)
+	DeactivateFeeSchedule(ctx context.Context, id int64) (*FeeSchedule, error)
+	ListRevenueWallets(ctx context.Context) ([]*RevenueWallet, error)
+	SetRevenueWallet(ctx context.Context, currency string, walletID int64) (*RevenueWallet, error)
+}
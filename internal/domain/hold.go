@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type HoldStatus string
+
+const (
+	HoldStatusAuthorized HoldStatus = "AUTHORIZED"
+	HoldStatusCaptured   HoldStatus = "CAPTURED"
+	HoldStatusVoided     HoldStatus = "VOIDED"
+	HoldStatusExpired    HoldStatus = "EXPIRED"
+)
+
+// Hold reserves Amount on WalletID in favour of PayeeWalletID. While it is
+// AUTHORIZED it reduces the wallet's available balance but not its ledger
+// balance; capturing it moves CapturedAmount to the payee and releases the
+// rest.
+type Hold struct {
+	ID             int64           `json:"id"`
+	WalletID       int64           `json:"wallet_id"`
+	PayeeWalletID  int64           `json:"payee_wallet_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	CapturedAmount decimal.Decimal `json:"captured_amount"`
+	Reference      string          `json:"reference"`
+	Status         HoldStatus      `json:"status"`
+	TransactionID  *int64          `json:"transaction_id"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type HoldRepository interface {
+	CreateHold(ctx context.Context, hold *Hold) error
+	GetHoldByID(ctx context.Context, id int64) (*Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (*Hold, error)
+	ListHoldsByWallet(ctx context.Context, walletID int64) ([]*Hold, error)
+	ListExpiredHoldIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdateHold(ctx context.Context, hold *Hold) error
+}
+
+type HoldService interface {
+	AuthorizeHold(ctx context.Context, payerUserID, payeeUserID int64, amount decimal.Decimal, reference string, ttl time.Duration) (*Hold, error)
+	CaptureHold(ctx context.Context, payeeUserID, holdID int64, amount decimal.Decimal) (*Hold, error)
+	VoidHold(ctx context.Context, payeeUserID, holdID int64) (*Hold, error)
+	GetHold(ctx context.Context, userID, holdID int64) (*Hold, error)
+	ListHolds(ctx context.Context, userID int64) ([]*Hold, error)
+	ExpireHolds(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ClientInfo identifies where a request came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// LoginEvent is one login attempt. Failed attempts against unknown emails are
+// recorded without a UserID and so never show up in anyone's history.
+type LoginEvent struct {
+	ID        int64     `json:"id"`
+	UserID    *int64    `json:"-"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	NewDevice bool      `json:"new_device"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginThrottleScope string
+
+const (
+	LoginThrottleScopeAccount LoginThrottleScope = "ACCOUNT"
+	LoginThrottleScopeIP      LoginThrottleScope = "IP"
+)
+
+// LoginThrottle counts recent failed logins for an account or an IP. Every
+// time Failures reaches the threshold it is reset, Lockouts is incremented and
+// the subject is locked until LockedUntil.
+type LoginThrottle struct {
+	Scope         LoginThrottleScope
+	Subject       string
+	Failures      int
+	Lockouts      int
+	LockedUntil   *time.Time
+	LastFailureAt *time.Time
+}
+
+// UserDevice is a client a user has logged in from, identified by a hash of
+// its user agent.
+type UserDevice struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"-"`
+	Fingerprint string    `json:"-"`
+	UserAgent   string    `json:"user_agent"`
+	LastIP      string    `json:"last_ip"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+type LoginRepository interface {
+	CreateLoginEvent(ctx context.Context, event *LoginEvent) error
+	ListLoginEventsByUser(ctx context.Context, userID int64, limit int) ([]*LoginEvent, error)
+	GetLoginThrottle(ctx context.Context, scope LoginThrottleScope, subject string) (*LoginThrottle, error)
+	GetLoginThrottleForUpdate(ctx context.Context, scope LoginThrottleScope, subject string) (*LoginThrottle, error)
+	UpsertLoginThrottle(ctx context.Context, throttle *LoginThrottle) error
+	CountUserDevices(ctx context.Context, userID int64) (int, error)
+	UpsertUserDevice(ctx context.Context, device *UserDevice) (bool, error)
+	ListUserDevices(ctx context.Context, userID int64) ([]*UserDevice, error)
+	DeleteUserLoginData(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type MerchantStatus string
+
+const (
+	MerchantStatusActive   MerchantStatus = "ACTIVE"
+	MerchantStatusDisabled MerchantStatus = "DISABLED"
+)
+
+// FeePlanStandard is the fee plan merchants are created on.
+const FeePlanStandard = "standard"
+
+// Merchant is the business profile of a user who accepts checkout payments.
+// Payments settle into SettlementWalletID, and FeePlan names the fee schedule
+// applied to them.
+type Merchant struct {
+	ID                 int64          `json:"id"`
+	UserID             int64          `json:"user_id"`
+	BusinessName       string         `json:"business_name"`
+	LegalName          string         `json:"legal_name"`
+	Website            string         `json:"website"`
+	SupportEmail       string         `json:"support_email"`
+	Country            string         `json:"country"`
+	SettlementWalletID int64          `json:"settlement_wallet_id"`
+	FeePlan            string         `json:"fee_plan"`
+	Status             MerchantStatus `json:"status"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+// MerchantProfile is the part of a merchant its owner can edit.
+type MerchantProfile struct {
+	BusinessName string
+	LegalName    string
+	Website      string
+	SupportEmail string
+	Country      string
+}
+
+type CheckoutSessionStatus string
+
+const (
+	CheckoutSessionStatusOpen       CheckoutSessionStatus = "OPEN"
+	CheckoutSessionStatusProcessing CheckoutSessionStatus = "PROCESSING"
+	CheckoutSessionStatusCompleted  CheckoutSessionStatus = "COMPLETED"
+	CheckoutSessionStatusFailed     CheckoutSessionStatus = "FAILED"
+	CheckoutSessionStatusCancelled  CheckoutSessionStatus = "CANCELLED"
+	CheckoutSessionStatusExpired    CheckoutSessionStatus = "EXPIRED"
+)
+
+// CheckoutSession is a merchant's request for a payer to pay Amount. Payers
+// find it by Token; confirming it creates a transfer to the merchant's
+// settlement wallet, and the session is COMPLETED or FAILED once the worker
+// settles that transfer. MerchantName is only set on the payer's view.
+type CheckoutSession struct {
+	ID            int64                 `json:"id"`
+	MerchantID    int64                 `json:"merchant_id"`
+	MerchantName  string                `json:"merchant_name,omitempty"`
+	Token         string                `json:"token"`
+	Amount        decimal.Decimal       `json:"amount"`
+	Currency      string                `json:"currency"`
+	Reference     string                `json:"reference"`
+	Description   string                `json:"description"`
+	SuccessURL    string                `json:"success_url"`
+	CancelURL     string                `json:"cancel_url"`
+	Status        CheckoutSessionStatus `json:"status"`
+	PayerUserID   *int64                `json:"payer_user_id"`
+	TransactionID *int64                `json:"transaction_id"`
+	FailureReason string                `json:"failure_reason,omitempty"`
+	ExpiresAt     time.Time             `json:"expires_at"`
+	CompletedAt   *time.Time            `json:"completed_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+// CheckoutSessionParams describes a session a merchant wants to create. A
+// zero TTL uses the default session lifetime.
+type CheckoutSessionParams struct {
+	Amount      decimal.Decimal
+	Currency    string
+	Reference   string
+	Description string
+	SuccessURL  string
+	CancelURL   string
+	TTL         time.Duration
+}
+
+type MerchantRepository interface {
+	CreateMerchant(ctx context.Context, merchant *Merchant) error
+	GetMerchantByID(ctx context.Context, id int64) (*Merchant, error)
+	GetMerchantByUserID(ctx context.Context, userID int64) (*Merchant, error)
+	UpdateMerchantProfile(ctx context.Context, merchant *Merchant) error
+	UpdateMerchantStatus(ctx context.Context, id int64, status MerchantStatus) error
+}
+
+type CheckoutSessionRepository interface {
+	CreateCheckoutSession(ctx context.Context, session *CheckoutSession) error
+	GetCheckoutSessionByID(ctx context.Context, id int64) (*CheckoutSession, error)
+	GetCheckoutSessionByToken(ctx context.Context, token string) (*CheckoutSession, error)
+	GetCheckoutSessionByReference(ctx context.Context, merchantID int64, reference string) (*CheckoutSession, error)
+	GetCheckoutSessionForUpdate(ctx context.Context, id int64) (*CheckoutSession, error)
+	GetCheckoutSessionByTransactionForUpdate(ctx context.Context, transactionID int64) (*CheckoutSession, error)
+	ListCheckoutSessionsByMerchant(ctx context.Context, merchantID int64) ([]*CheckoutSession, error)
+	ListExpiredCheckoutSessionIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdateCheckoutSession(ctx context.Context, session *CheckoutSession) error
+}
+
+type MerchantService interface {
+	CreateMerchant(ctx context.Context, userID int64, profile MerchantProfile) (*Merchant, error)
+	GetMerchant(ctx context.Context, userID int64) (*Merchant, error)
+	UpdateMerchant(ctx context.Context, userID int64, profile MerchantProfile) (*Merchant, error)
+}
+
+type CheckoutService interface {
+	CreateCheckoutSession(ctx context.Context, userID int64, params CheckoutSessionParams) (*CheckoutSession, error)
+	ListCheckoutSessions(ctx context.Context, userID int64) ([]*CheckoutSession, error)
+	GetCheckoutSession(ctx context.Context, userID, id int64) (*CheckoutSession, error)
+	CancelCheckoutSession(ctx context.Context, userID, id int64) (*CheckoutSession, error)
+	GetCheckoutSessionForPayer(ctx context.Context, token string) (*CheckoutSession, error)
+	ConfirmCheckoutSession(ctx context.Context, payerUserID int64, token string) (*CheckoutSession, error)
+	ExpireCheckoutSessions(ctx context.Context, now time.Time) (int, error)
+}
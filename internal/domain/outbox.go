@@ -1,16 +1,31 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusUnpublished OutboxStatus = "UNPUBLISHED"
+	OutboxStatusPublished   OutboxStatus = "PUBLISHED"
+)
 
 type Outbox struct {
-	ID        int64  `json:"id"`
-	Topic     string `json:"topic"`
-	Payload   []byte `json:"payload"`
-	Status    string `json:"status"`
+	ID        int64        `json:"id"`
+	Topic     string       `json:"topic"`
+	Payload   []byte       `json:"payload"`
+	Status    OutboxStatus `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
 }
 
 type OutboxRepository interface {
 	CreateOutbox(ctx context.Context, event *Outbox) error
+	ListUnpublishedOutboxForUpdate(ctx context.Context, limit int) ([]*Outbox, error)
+	MarkOutboxPublished(ctx context.Context, id int64) error
 }
 
-
+type OutboxService interface {
+	RelayOutbox(ctx context.Context) (int, error)
+}
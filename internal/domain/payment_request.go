@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestStatusPending   PaymentRequestStatus = "PENDING"
+	PaymentRequestStatusAccepted  PaymentRequestStatus = "ACCEPTED"
+	PaymentRequestStatusDeclined  PaymentRequestStatus = "DECLINED"
+	PaymentRequestStatusCancelled PaymentRequestStatus = "CANCELLED"
+	PaymentRequestStatusExpired   PaymentRequestStatus = "EXPIRED"
+)
+
+// PaymentRequest is a request from RequesterUserID asking PayerUserID to send
+// them Amount. Accepting it creates a normal transfer from payer to requester.
+type PaymentRequest struct {
+	ID              int64                `json:"id"`
+	RequesterUserID int64                `json:"requester_user_id"`
+	PayerUserID     int64                `json:"payer_user_id"`
+	Amount          decimal.Decimal      `json:"amount"`
+	Memo            string               `json:"memo"`
+	Status          PaymentRequestStatus `json:"status"`
+	TransactionID   *int64               `json:"transaction_id"`
+	ExpiresAt       time.Time            `json:"expires_at"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+type PaymentRequestRepository interface {
+	CreatePaymentRequest(ctx context.Context, pr *PaymentRequest) error
+	GetPaymentRequestByID(ctx context.Context, id int64) (*PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id int64) (*PaymentRequest, error)
+	ListPendingPaymentRequestsByPayer(ctx context.Context, payerUserID int64) ([]*PaymentRequest, error)
+	ListPendingPaymentRequestsByRequester(ctx context.Context, requesterUserID int64) ([]*PaymentRequest, error)
+	ListPaymentRequestsByUser(ctx context.Context, userID int64) ([]*PaymentRequest, error)
+	ListExpiredPaymentRequestIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdatePaymentRequest(ctx context.Context, pr *PaymentRequest) error
+}
+
+type PaymentRequestService interface {
+	CreatePaymentRequest(ctx context.Context, requesterUserID, payerUserID int64, amount decimal.Decimal, memo string, ttl time.Duration) (*PaymentRequest, error)
+	ListIncomingPaymentRequests(ctx context.Context, userID int64) ([]*PaymentRequest, error)
+	ListOutgoingPaymentRequests(ctx context.Context, userID int64) ([]*PaymentRequest, error)
+	GetPaymentRequestForPayer(ctx context.Context, userID, id int64) (*PaymentRequest, error)
+	AcceptPaymentRequest(ctx context.Context, userID, id int64) (*PaymentRequest, error)
+	DeclinePaymentRequest(ctx context.Context, userID, id int64) (*PaymentRequest, error)
+	CancelPaymentRequest(ctx context.Context, userID, id int64) (*PaymentRequest, error)
+	ExpirePaymentRequests(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PayoutBatchStatus string
+
+const (
+	PayoutBatchStatusProcessing PayoutBatchStatus = "PROCESSING"
+	PayoutBatchStatusCompleted  PayoutBatchStatus = "COMPLETED"
+)
+
+// PayoutBatch pays many recipients out of one wallet. TotalAmount is held on
+// the wallet when the batch is accepted and each item is paid from the hold;
+// ReservedAmount is what is still held for items that have not settled. A
+// batch is COMPLETED once every item has succeeded or failed.
+type PayoutBatch struct {
+	ID             int64              `json:"id"`
+	UserID         int64              `json:"user_id"`
+	WalletID       int64              `json:"wallet_id"`
+	Currency       string             `json:"currency"`
+	TotalAmount    decimal.Decimal    `json:"total_amount"`
+	ReservedAmount decimal.Decimal    `json:"reserved_amount"`
+	ItemCount      int                `json:"item_count"`
+	SucceededCount int                `json:"succeeded_count"`
+	FailedCount    int                `json:"failed_count"`
+	Status         PayoutBatchStatus  `json:"status"`
+	CompletedAt    *time.Time         `json:"completed_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Items          []*PayoutBatchItem `json:"items,omitempty"`
+}
+
+type PayoutBatchItemStatus string
+
+const (
+	PayoutBatchItemStatusPending   PayoutBatchItemStatus = "PENDING"
+	PayoutBatchItemStatusCompleted PayoutBatchItemStatus = "COMPLETED"
+	PayoutBatchItemStatusFailed    PayoutBatchItemStatus = "FAILED"
+)
+
+// PayoutBatchItem is one row of a batch file. LineNumber is the row's line in
+// the file, or its position for JSON files.
+type PayoutBatchItem struct {
+	ID               int64                 `json:"id"`
+	BatchID          int64                 `json:"batch_id"`
+	LineNumber       int                   `json:"line_number"`
+	RecipientUserID  int64                 `json:"recipient_user_id"`
+	ReceiverWalletID int64                 `json:"-"`
+	Amount           decimal.Decimal       `json:"amount"`
+	Reference        string                `json:"reference"`
+	Status           PayoutBatchItemStatus `json:"status"`
+	TransactionID    *int64                `json:"transaction_id"`
+	FailureReason    string                `json:"failure_reason,omitempty"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+}
+
+// PayoutBatchRow is a parsed row of a batch file.
+type PayoutBatchRow struct {
+	LineNumber      int
+	RecipientUserID int64
+	Amount          decimal.Decimal
+	Reference       string
+}
+
+type PayoutBatchRepository interface {
+	CreatePayoutBatch(ctx context.Context, batch *PayoutBatch) error
+	GetPayoutBatchByID(ctx context.Context, id int64) (*PayoutBatch, error)
+	GetPayoutBatchForUpdate(ctx context.Context, id int64) (*PayoutBatch, error)
+	ListPayoutBatchesByUser(ctx context.Context, userID int64) ([]*PayoutBatch, error)
+	UpdatePayoutBatch(ctx context.Context, batch *PayoutBatch) error
+	CreatePayoutBatchItem(ctx context.Context, item *PayoutBatchItem) error
+	GetPayoutBatchItemForUpdate(ctx context.Context, id int64) (*PayoutBatchItem, error)
+	ListPayoutBatchItems(ctx context.Context, batchID int64) ([]*PayoutBatchItem, error)
+	UpdatePayoutBatchItem(ctx context.Context, item *PayoutBatchItem) error
+}
+
+type PayoutBatchService interface {
+	CreatePayoutBatch(ctx context.Context, userID int64, rows []PayoutBatchRow) (*PayoutBatch, error)
+	ListPayoutBatches(ctx context.Context, userID int64) ([]*PayoutBatch, error)
+	GetPayoutBatch(ctx context.Context, userID, id int64) (*PayoutBatch, error)
+	ProcessPayoutBatchItem(ctx context.Context, itemID int64) error
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type ScheduleType string
+
+const (
+	ScheduleTypeOnce    ScheduleType = "ONCE"
+	ScheduleTypeCron    ScheduleType = "CRON"
+	ScheduleTypeMonthly ScheduleType = "MONTHLY"
+)
+
+type ScheduledTransferStatus string
+
+const (
+	ScheduledTransferStatusActive    ScheduledTransferStatus = "ACTIVE"
+	ScheduledTransferStatusPaused    ScheduledTransferStatus = "PAUSED"
+	ScheduledTransferStatusCancelled ScheduledTransferStatus = "CANCELLED"
+	ScheduledTransferStatusCompleted ScheduledTransferStatus = "COMPLETED"
+	ScheduledTransferStatusFailed    ScheduledTransferStatus = "FAILED"
+)
+
+// ScheduledTransfer is a transfer that the worker materialises into a normal
+// Transaction once (ONCE) or repeatedly (CRON, MONTHLY) at NextRunAt.
+type ScheduledTransfer struct {
+	ID             int64                   `json:"id"`
+	SenderUserID   int64                   `json:"sender_user_id"`
+	ReceiverUserID int64                   `json:"receiver_user_id"`
+	Amount         decimal.Decimal         `json:"amount"`
+	Memo           string                  `json:"memo"`
+	ScheduleType   ScheduleType            `json:"schedule_type"`
+	CronExpr       string                  `json:"cron_expr,omitempty"`
+	DayOfMonth     int                     `json:"day_of_month,omitempty"`
+	NextRunAt      *time.Time              `json:"next_run_at"`
+	LastRunAt      *time.Time              `json:"last_run_at"`
+	Status         ScheduledTransferStatus `json:"status"`
+	FailureCount   int                     `json:"failure_count"`
+	LastError      string                  `json:"last_error,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+}
+
+type ScheduledTransferRunStatus string
+
+const (
+	ScheduledTransferRunStatusPending   ScheduledTransferRunStatus = "PENDING"
+	ScheduledTransferRunStatusSucceeded ScheduledTransferRunStatus = "SUCCEEDED"
+	ScheduledTransferRunStatusFailed    ScheduledTransferRunStatus = "FAILED"
+	ScheduledTransferRunStatusSkipped   ScheduledTransferRunStatus = "SKIPPED"
+)
+
+// ScheduledTransferRun records the outcome of a single occurrence of a
+// ScheduledTransfer. A run that created its transaction stays PENDING until
+// the transaction settles.
+type ScheduledTransferRun struct {
+	ID                  int64                      `json:"id"`
+	ScheduledTransferID int64                      `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time                  `json:"scheduled_for"`
+	TransactionID       *int64                     `json:"transaction_id"`
+	Status              ScheduledTransferRunStatus `json:"status"`
+	Error               string                     `json:"error,omitempty"`
+	CreatedAt           time.Time                  `json:"created_at"`
+}
+
+type ScheduledTransferRepository interface {
+	CreateScheduledTransfer(ctx context.Context, st *ScheduledTransfer) error
+	GetScheduledTransferByID(ctx context.Context, id int64) (*ScheduledTransfer, error)
+	GetScheduledTransferForUpdate(ctx context.Context, id int64) (*ScheduledTransfer, error)
+	ListScheduledTransfersBySender(ctx context.Context, senderUserID int64) ([]*ScheduledTransfer, error)
+	ListDueScheduledTransferIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdateScheduledTransfer(ctx context.Context, st *ScheduledTransfer) error
+	CreateScheduledTransferRun(ctx context.Context, run *ScheduledTransferRun) error
+	GetScheduledTransferRunByTransactionForUpdate(ctx context.Context, transactionID int64) (*ScheduledTransferRun, error)
+	UpdateScheduledTransferRun(ctx context.Context, run *ScheduledTransferRun) error
+	ListScheduledTransferRuns(ctx context.Context, scheduledTransferID int64, limit int) ([]*ScheduledTransferRun, error)
+}
+
+type ScheduledTransferService interface {
+	CreateScheduledTransfer(ctx context.Context, st *ScheduledTransfer) (*ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, userID int64) ([]*ScheduledTransfer, error)
+	GetScheduledTransfer(ctx context.Context, userID, id int64) (*ScheduledTransfer, []*ScheduledTransferRun, error)
+	PauseScheduledTransfer(ctx context.Context, userID, id int64) (*ScheduledTransfer, error)
+	ResumeScheduledTransfer(ctx context.Context, userID, id int64) (*ScheduledTransfer, error)
+	CancelScheduledTransfer(ctx context.Context, userID, id int64) (*ScheduledTransfer, error)
+	ProcessDueScheduledTransfers(ctx context.Context, now time.Time) (int, error)
+}
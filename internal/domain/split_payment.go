@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type SplitPaymentStatus string
+
+const (
+	SplitPaymentStatusPending   SplitPaymentStatus = "PENDING"
+	SplitPaymentStatusCompleted SplitPaymentStatus = "COMPLETED"
+	SplitPaymentStatusFailed    SplitPaymentStatus = "FAILED"
+)
+
+// SplitPayment debits Amount from one wallet and credits it to several
+// recipients. Each recipient's share is a leg: a transaction with
+// SplitPaymentID set, charged its own fee like any transfer. The legs settle
+// together or not at all.
+type SplitPayment struct {
+	ID             int64              `json:"id"`
+	SenderWalletID int64              `json:"sender_wallet_id"`
+	Amount         decimal.Decimal    `json:"amount"`
+	Currency       string             `json:"currency"`
+	Description    string             `json:"description"`
+	Status         SplitPaymentStatus `json:"status"`
+	FailureReason  string             `json:"failure_reason,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Legs           []*Transaction     `json:"legs"`
+}
+
+// SplitRecipient is one recipient of a new split payment, given either the
+// Amount they receive or the Percentage of the payment's amount.
+type SplitRecipient struct {
+	UserID     int64
+	Amount     decimal.Decimal
+	Percentage decimal.Decimal
+}
+
+type SplitPaymentRepository interface {
+	CreateSplitPayment(ctx context.Context, payment *SplitPayment) error
+	GetSplitPaymentByID(ctx context.Context, id int64) (*SplitPayment, error)
+	GetSplitPaymentForUpdate(ctx context.Context, id int64) (*SplitPayment, error)
+	ListSplitPaymentsBySender(ctx context.Context, senderWalletID int64) ([]*SplitPayment, error)
+	UpdateSplitPayment(ctx context.Context, payment *SplitPayment) error
+}
+
+type SplitPaymentService interface {
+	CreateSplitPayment(ctx context.Context, senderUserID int64, amount decimal.Decimal, recipients []SplitRecipient, description string) (*SplitPayment, error)
+	ListSplitPayments(ctx context.Context, userID int64) ([]*SplitPayment, error)
+	GetSplitPayment(ctx context.Context, userID, id int64) (*SplitPayment, error)
+	ProcessSplitPayment(ctx context.Context, id int64) error
+}
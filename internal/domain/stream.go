@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// StreamEvent is a message pushed to a user's live event stream. ID orders
+// events within a user's stream so that clients can resume after reconnecting.
+type StreamEvent struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// EventBroker fans stream events out to every subscriber of a user, keeping a
+// short backlog per user for replay.
+type EventBroker interface {
+	Publish(ctx context.Context, userID int64, event StreamEvent) error
+	// Subscribe returns backlog events after lastEventID followed by live
+	// events. The channel is closed when ctx is done.
+	Subscribe(ctx context.Context, userID int64, lastEventID string) (<-chan StreamEvent, error)
+}
+
+type StreamService interface {
+	PublishEvent(ctx context.Context, eventID int64, eventType string, data []byte) error
+	Subscribe(ctx context.Context, userID int64, lastEventID string) (<-chan StreamEvent, error)
+}
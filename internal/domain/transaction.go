@@ -14,20 +14,50 @@ const (
 	TransactionStatusFailed    TransactionStatus = "FAILED"
 )
 
+// Transaction moves Amount out of the sender's wallet. The receiver is
+// credited Amount less Fee, which goes to the house wallet FeeWalletID. A
+// transaction with SplitPaymentID set is one leg of a split payment. Memo is
+// copied from the scheduled transfer or payment request that created it.
 type Transaction struct {
 	ID               int64             `json:"id"`
 	SenderWalletID   int64             `json:"sender_wallet_id"`
 	ReceiverWalletID int64             `json:"receiver_wallet_id"`
 	Amount           decimal.Decimal   `json:"amount"`
+	Fee              decimal.Decimal   `json:"fee"`
+	Memo             string            `json:"memo,omitempty"`
+	FeeWalletID      *int64            `json:"fee_wallet_id,omitempty"`
+	SplitPaymentID   *int64            `json:"split_payment_id,omitempty"`
 	Status           TransactionStatus `json:"status"`
-	CreatedAt        string            `json:"created_at"`
-	UpdatedAt        string            `json:"updated_at"`
+	CreatedAt        string            `json:"created_at,omitempty"`
+	UpdatedAt        string            `json:"updated_at,omitempty"`
 }
 
 type TransactionRepository interface {
 	CreateTransaction(ctx context.Context, tx *Transaction) error
+	GetTransactionForUpdate(ctx context.Context, id int64) (*Transaction, error)
+	ListTransactionsByWallet(ctx context.Context, walletID int64) ([]*Transaction, error)
+	ListTransactionsBySplitPayment(ctx context.Context, splitPaymentID int64) ([]*Transaction, error)
+	UpdateTransactionStatus(ctx context.Context, id int64, status TransactionStatus) error
+}
+
+type HistoryEntryType string
+
+const (
+	HistoryEntryTypeTransfer     HistoryEntryType = "TRANSFER"
+	HistoryEntryTypeSplitPayment HistoryEntryType = "SPLIT_PAYMENT"
+)
+
+// HistoryEntry is one payment in a wallet's history: a single transfer, or a
+// split payment the wallet sent together with its legs. Recipients of a split
+// payment only see their own leg, as a transfer.
+type HistoryEntry struct {
+	Type         HistoryEntryType `json:"type"`
+	Transaction  *Transaction     `json:"transaction,omitempty"`
+	SplitPayment *SplitPayment    `json:"split_payment,omitempty"`
 }
 
 type TransactionService interface {
 	CreateTransfer(ctx context.Context, senderUserID, recieverUserID int64, amount decimal.Decimal) (*Transaction, error)
+	ProcessTransfer(ctx context.Context, transactionID int64) error
+	ListHistory(ctx context.Context, userID int64) ([]*HistoryEntry, error)
 }
\ No newline at end of file
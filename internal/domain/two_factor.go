@@ -0,0 +1,25 @@
+package domain
+
+import "context"
+
+// LoginResult is the outcome of a login step. When TwoFactorRequired is set
+// Token is empty and TwoFactorToken must be exchanged, together with a TOTP
+// or recovery code, for the access token.
+type LoginResult struct {
+	Token             string `json:"token,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
+}
+
+// TOTPEnrollment is returned when a user starts enrolling an authenticator
+// app. ProvisioningURI is the otpauth:// URI to render as a QR code.
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodeRepository interface {
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	DeleteRecoveryCodes(ctx context.Context, userID int64) error
+}
@@ -5,23 +5,71 @@ import (
 	"time"
 )
 
+// User is an account holder. Timezone is an IANA zone name and Phone is in
+// E.164 format; both may be empty. EmailVerifiedAt is nil until the user proves they
+// own Email. TOTPSecret is set by enrolment but only checked at login once
+// TwoFactorEnabled is confirmed. Tokens issued before SessionsValidAfter are
+// rejected. ErasedAt is set once the user's personal data has been
+// pseudonymised; the row itself stays because the ledger refers to it.
 type User struct {
-	ID        int64  `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 int64      `json:"id"`
+	Name               string     `json:"name"`
+	Email              string     `json:"email"`
+	EmailVerifiedAt    *time.Time `json:"email_verified_at"`
+	Phone              string     `json:"phone"`
+	Timezone           string     `json:"timezone"`
+	Password           string     `json:"-"`
+	TOTPSecret         string     `json:"-"`
+	TwoFactorEnabled   bool       `json:"two_factor_enabled"`
+	TOTPLastStep       int64      `json:"-"`
+	SessionsValidAfter *time.Time `json:"-"`
+	ErasedAt           *time.Time `json:"-"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// ProfileUpdate lists the profile fields to change; nil fields are left as
+// they are.
+type ProfileUpdate struct {
+	Name     *string
+	Phone    *string
+	Timezone *string
 }
 
 type UserService interface {
 	Signup(ctx context.Context, name, email, password string) (*User, error)
-	Login(ctx context.Context, email, password string) (string, error)
+	Login(ctx context.Context, email, password string, client ClientInfo) (*LoginResult, error)
+	CompleteTwoFactorLogin(ctx context.Context, twoFactorToken, code string, client ClientInfo) (*LoginResult, error)
 	GetProfile(ctx context.Context, userID int64) (*User, error)
+	UpdateProfile(ctx context.Context, userID int64, update ProfileUpdate, ifUnmodifiedSince *time.Time) (*User, error)
+	LoginHistory(ctx context.Context, userID int64) ([]*LoginEvent, error)
+	EnrollTOTP(ctx context.Context, userID int64) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID int64, code string, client ClientInfo) error
+	StepUp(ctx context.Context, userID int64, code, password string, client ClientInfo) (*LoginResult, error)
+	VerifyPassword(ctx context.Context, userID int64, password string, client ClientInfo) error
+	VerifySession(ctx context.Context, userID int64, issuedAt time.Time) error
+	SendEmailVerification(ctx context.Context, userID int64) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword string, client ClientInfo) (*LoginResult, error)
+	RequestEmailChange(ctx context.Context, userID int64, newEmail, password string, client ClientInfo) (*EmailChange, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*EmailChange, error)
+	GetEmailChange(ctx context.Context, userID int64) (*EmailChange, error)
+	CancelEmailChange(ctx context.Context, userID int64) error
 }
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *User) error
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByID(ctx context.Context, id int64) (*User, error)
-}
\ No newline at end of file
+	UpdateUserTOTP(ctx context.Context, userID int64, secret string, enabled bool) error
+	AdvanceUserTOTPStep(ctx context.Context, userID, step int64) (bool, error)
+	UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error
+	RevokeUserSessions(ctx context.Context, userID int64, validAfter time.Time) error
+	MarkUserEmailVerified(ctx context.Context, userID int64, email string) (bool, error)
+	UpdateUserProfile(ctx context.Context, user *User, ifUnmodifiedSince *time.Time) (bool, error)
+	UpdateUserEmail(ctx context.Context, userID int64, email string) error
+	PseudonymiseUser(ctx context.Context, userID int64, email string, erasedAt time.Time) error
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type UserTokenPurpose string
+
+const (
+	UserTokenPurposeEmailVerification  UserTokenPurpose = "EMAIL_VERIFICATION"
+	UserTokenPurposePasswordReset      UserTokenPurpose = "PASSWORD_RESET"
+	UserTokenPurposeEmailChangeCurrent UserTokenPurpose = "EMAIL_CHANGE_CURRENT"
+	UserTokenPurposeEmailChangeNew     UserTokenPurpose = "EMAIL_CHANGE_NEW"
+)
+
+// UserToken is a single-use token mailed to a user. Only the SHA-256 hash of
+// the token is stored; Email is the address it was sent to.
+type UserToken struct {
+	ID        int64
+	UserID    int64
+	Purpose   UserTokenPurpose
+	TokenHash string
+	Email     string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type UserTokenRepository interface {
+	CreateUserToken(ctx context.Context, token *UserToken) error
+	GetUserTokenByHashForUpdate(ctx context.Context, tokenHash string) (*UserToken, error)
+	MarkUserTokenUsed(ctx context.Context, id int64) error
+	InvalidateUserTokens(ctx context.Context, userID int64, purpose UserTokenPurpose) error
+	DeleteUserTokens(ctx context.Context, userID int64) error
+}
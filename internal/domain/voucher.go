@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type VoucherBatchStatus string
+
+const (
+	VoucherBatchStatusActive   VoucherBatchStatus = "ACTIVE"
+	VoucherBatchStatusDisabled VoucherBatchStatus = "DISABLED"
+)
+
+// VoucherBatch is a set of codes each worth Amount of wallet credit, paid
+// from the promo wallet of Currency when redeemed. Each code can be redeemed
+// by up to MaxRedemptions users, once each, and a user can redeem at most
+// PerUserLimit codes of the batch. Codes stop working once the batch expires
+// or is disabled.
+type VoucherBatch struct {
+	ID              int64              `json:"id"`
+	Name            string             `json:"name"`
+	Amount          decimal.Decimal    `json:"amount"`
+	Currency        string             `json:"currency"`
+	PromoWalletID   int64              `json:"-"`
+	ExpiresAt       time.Time          `json:"expires_at"`
+	MaxRedemptions  int                `json:"max_redemptions"`
+	PerUserLimit    int                `json:"per_user_limit"`
+	CodeCount       int                `json:"code_count"`
+	RedemptionCount int                `json:"redemption_count"`
+	RedeemedAmount  decimal.Decimal    `json:"redeemed_amount"`
+	Status          VoucherBatchStatus `json:"status"`
+	CreatedByUserID int64              `json:"created_by_user_id"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	Codes           []string           `json:"codes,omitempty"`
+}
+
+// Voucher is one code of a batch. Only the hash of the code is kept; Codes on
+// the batch returns the codes themselves once, when they are generated.
+type Voucher struct {
+	ID              int64     `json:"id"`
+	BatchID         int64     `json:"batch_id"`
+	CodeHash        string    `json:"-"`
+	CodeHint        string    `json:"code_hint"`
+	RedemptionCount int       `json:"redemption_count"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// VoucherRedemption is the credit a user received for redeeming a voucher.
+type VoucherRedemption struct {
+	ID        int64           `json:"id"`
+	VoucherID int64           `json:"voucher_id"`
+	BatchID   int64           `json:"batch_id"`
+	UserID    int64           `json:"user_id"`
+	WalletID  int64           `json:"wallet_id"`
+	Amount    decimal.Decimal `json:"amount"`
+	Currency  string          `json:"currency"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// VoucherRedemptionDay totals a batch's redemptions on one day.
+type VoucherRedemptionDay struct {
+	Date        string          `json:"date"`
+	Redemptions int             `json:"redemptions"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+// VoucherBatchReport summarizes how a batch has been redeemed.
+type VoucherBatchReport struct {
+	Batch           *VoucherBatch           `json:"batch"`
+	CodesRedeemed   int                     `json:"codes_redeemed"`
+	UniqueRedeemers int                     `json:"unique_redeemers"`
+	Days            []*VoucherRedemptionDay `json:"days"`
+}
+
+type VoucherRepository interface {
+	GetPromoWalletID(ctx context.Context, currency string) (int64, bool, error)
+	CreateVoucherBatch(ctx context.Context, batch *VoucherBatch) error
+	GetVoucherBatchByID(ctx context.Context, id int64) (*VoucherBatch, error)
+	GetVoucherBatchForUpdate(ctx context.Context, id int64) (*VoucherBatch, error)
+	ListVoucherBatches(ctx context.Context) ([]*VoucherBatch, error)
+	UpdateVoucherBatch(ctx context.Context, batch *VoucherBatch) error
+	CreateVoucher(ctx context.Context, voucher *Voucher) error
+	GetVoucherByCodeHashForUpdate(ctx context.Context, codeHash string) (*Voucher, error)
+	UpdateVoucher(ctx context.Context, voucher *Voucher) error
+	CreateVoucherRedemption(ctx context.Context, redemption *VoucherRedemption) error
+	GetVoucherRedemption(ctx context.Context, voucherID, userID int64) (*VoucherRedemption, error)
+	CountVoucherRedemptionsByUser(ctx context.Context, batchID, userID int64) (int, error)
+	ListVoucherRedemptionsByUser(ctx context.Context, userID int64) ([]*VoucherRedemption, error)
+	GetVoucherBatchRedeemerCounts(ctx context.Context, batchID int64) (codes, users int, err error)
+	ListVoucherRedemptionDays(ctx context.Context, batchID int64) ([]*VoucherRedemptionDay, error)
+}
+
+type VoucherService interface {
+	CreateVoucherBatch(ctx context.Context, adminUserID int64, name string, amount decimal.Decimal, currency string, expiresAt time.Time, maxRedemptions, perUserLimit, codeCount int) (*VoucherBatch, error)
+	ListVoucherBatches(ctx context.Context) ([]*VoucherBatch, error)
+	GetVoucherBatch(ctx context.Context, id int64) (*VoucherBatch, error)
+	DisableVoucherBatch(ctx context.Context, id int64) (*VoucherBatch, error)
+	GetVoucherBatchReport(ctx context.Context, id int64) (*VoucherBatchReport, error)
+	RedeemVoucher(ctx context.Context, userID int64, code string) (*VoucherRedemption, error)
+	ListVoucherRedemptions(ctx context.Context, userID int64) ([]*VoucherRedemption, error)
+}
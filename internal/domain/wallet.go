@@ -7,20 +7,29 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Wallet holds a user's funds. Balance is the ledger balance; HeldBalance is
+// the part of it reserved by authorized holds, and AvailableBalance is what
+// remains spendable.
 type Wallet struct {
-	ID        int64           `json:"id"`
-	UserID    int64           `json:"user_id"`
-	Balance   decimal.Decimal `json:"balance"`
-	Currency  string          `json:"currency"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID               int64           `json:"id"`
+	UserID           int64           `json:"user_id"`
+	Balance          decimal.Decimal `json:"balance"`
+	HeldBalance      decimal.Decimal `json:"held_balance"`
+	AvailableBalance decimal.Decimal `json:"available_balance"`
+	Currency         string          `json:"currency"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
 }
 
 type WalletRepository interface {
 	CreateWallet(ctx context.Context, wallet *Wallet) error
 	GetByUserID(ctx context.Context, userID int64) (*Wallet, error)
+	GetWalletByID(ctx context.Context, id int64) (*Wallet, error)
+	GetWalletForUpdate(ctx context.Context, id int64) (*Wallet, error)
+	AdjustWalletBalance(ctx context.Context, id int64, delta decimal.Decimal) error
+	AdjustWalletHeldBalance(ctx context.Context, id int64, delta decimal.Decimal) error
 }
 
 type WalletService interface {
 	GetWalletByUserID(ctx context.Context, userID int64) (*Wallet, error)
-}
\ No newline at end of file
+}
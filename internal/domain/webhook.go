@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type WebhookEndpointStatus string
+
+const (
+	WebhookEndpointStatusActive   WebhookEndpointStatus = "ACTIVE"
+	WebhookEndpointStatusDisabled WebhookEndpointStatus = "DISABLED"
+)
+
+// WebhookEndpoint is a URL registered by a user to receive events. EventTypes
+// filters which events are sent; an empty list or "*" matches every event and
+// a trailing "*" (for example "transfer:*") matches by prefix.
+type WebhookEndpoint struct {
+	ID          int64                 `json:"id"`
+	UserID      int64                 `json:"user_id"`
+	URL         string                `json:"url"`
+	Secret      string                `json:"secret,omitempty"`
+	EventTypes  []string              `json:"event_types"`
+	Description string                `json:"description"`
+	Status      WebhookEndpointStatus `json:"status"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookDelivery is one event queued for one endpoint.
+type WebhookDelivery struct {
+	ID            int64                     `json:"id"`
+	EndpointID    int64                     `json:"endpoint_id"`
+	EventID       int64                     `json:"event_id"`
+	EventType     string                    `json:"event_type"`
+	Payload       []byte                    `json:"-"`
+	Status        WebhookDeliveryStatus     `json:"status"`
+	Attempts      int                       `json:"attempts"`
+	LastAttemptAt *time.Time                `json:"last_attempt_at"`
+	CreatedAt     time.Time                 `json:"created_at"`
+	UpdatedAt     time.Time                 `json:"updated_at"`
+	AttemptLog    []*WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             int64     `json:"id"`
+	DeliveryID     int64     `json:"delivery_id"`
+	ResponseStatus int       `json:"response_status"`
+	ResponseBody   string    `json:"response_body,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMS     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type WebhookRepository interface {
+	CreateWebhookEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	GetWebhookEndpointByID(ctx context.Context, id int64) (*WebhookEndpoint, error)
+	ListWebhookEndpointsByUser(ctx context.Context, userID int64) ([]*WebhookEndpoint, error)
+	ListActiveWebhookEndpointsByUsers(ctx context.Context, userIDs []int64) ([]*WebhookEndpoint, error)
+	UpdateWebhookEndpointStatus(ctx context.Context, id int64, status WebhookEndpointStatus) error
+	CreateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) (bool, error)
+	GetWebhookDeliveryByID(ctx context.Context, id int64) (*WebhookDelivery, error)
+	ListWebhookDeliveriesByEndpoint(ctx context.Context, endpointID int64, limit int) ([]*WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	CreateWebhookDeliveryAttempt(ctx context.Context, attempt *WebhookDeliveryAttempt) error
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]*WebhookDeliveryAttempt, error)
+}
+
+type WebhookService interface {
+	CreateEndpoint(ctx context.Context, userID int64, url string, eventTypes []string, description string) (*WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, userID int64) ([]*WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, userID, endpointID int64) (*WebhookEndpoint, error)
+	DisableEndpoint(ctx context.Context, userID, endpointID int64) error
+	EnableEndpoint(ctx context.Context, userID, endpointID int64) (*WebhookEndpoint, error)
+	ListDeliveries(ctx context.Context, userID, endpointID int64) ([]*WebhookDelivery, error)
+	GetDelivery(ctx context.Context, userID, endpointID, deliveryID int64) (*WebhookDelivery, error)
+	Redeliver(ctx context.Context, userID, endpointID, deliveryID int64) (*WebhookDelivery, error)
+	DispatchEvent(ctx context.Context, eventID int64, eventType string, data []byte, occurredAt time.Time) (int, error)
+	DeliverWebhook(ctx context.Context, deliveryID int64, finalAttempt bool) error
+}
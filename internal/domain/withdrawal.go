@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type BankAccountType string
+
+const (
+	BankAccountTypeIBAN          BankAccountType = "IBAN"
+	BankAccountTypeAccountNumber BankAccountType = "ACCOUNT_NUMBER"
+)
+
+// BankBeneficiary is an external bank account a user withdraws to. Accounts
+// are identified by an IBAN, or by a domestic account number and the routing
+// code of the bank: an ABA routing number, a sort code or a BIC. An IBAN
+// account may name the bank's BIC as its routing code. Only the last four
+// characters of the account number are shown.
+type BankBeneficiary struct {
+	ID                int64           `json:"id"`
+	UserID            int64           `json:"user_id"`
+	Nickname          string          `json:"nickname"`
+	AccountHolderName string          `json:"account_holder_name"`
+	Country           string          `json:"country"`
+	Currency          string          `json:"currency"`
+	AccountType       BankAccountType `json:"account_type"`
+	AccountNumber     string          `json:"-"`
+	AccountLast4      string          `json:"account_last4"`
+	RoutingCode       string          `json:"routing_code"`
+	DeletedAt         *time.Time      `json:"-"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// BankBeneficiaryParams describes a new beneficiary. Country is required for
+// ACCOUNT_NUMBER accounts; an IBAN carries its own.
+type BankBeneficiaryParams struct {
+	Nickname          string
+	AccountHolderName string
+	Currency          string
+	AccountType       BankAccountType
+	AccountNumber     string
+	Country           string
+	RoutingCode       string
+}
+
+type BankBeneficiaryRepository interface {
+	CreateBankBeneficiary(ctx context.Context, beneficiary *BankBeneficiary) error
+	GetBankBeneficiaryByID(ctx context.Context, id int64) (*BankBeneficiary, error)
+	ListBankBeneficiariesByUser(ctx context.Context, userID int64) ([]*BankBeneficiary, error)
+	DeleteBankBeneficiary(ctx context.Context, id int64, deletedAt time.Time) error
+	EraseBankBeneficiaries(ctx context.Context, userID int64, erasedAt time.Time) error
+}
+
+type WithdrawalStatus string
+
+// A withdrawal is PENDING until the worker submits it to the payout provider,
+// PROCESSING while the provider pays it out, and COMPLETED or FAILED once the
+// provider reports the result. Only PENDING withdrawals can be cancelled.
+const (
+	WithdrawalStatusPending    WithdrawalStatus = "PENDING"
+	WithdrawalStatusProcessing WithdrawalStatus = "PROCESSING"
+	WithdrawalStatusCompleted  WithdrawalStatus = "COMPLETED"
+	WithdrawalStatusFailed     WithdrawalStatus = "FAILED"
+	WithdrawalStatusCancelled  WithdrawalStatus = "CANCELLED"
+)
+
+// Withdrawal pays Amount out of a wallet to a bank beneficiary. The amount is
+// held on the wallet from creation; a completed withdrawal debits it and any
+// other outcome releases it.
+type Withdrawal struct {
+	ID                int64            `json:"id"`
+	UserID            int64            `json:"user_id"`
+	WalletID          int64            `json:"wallet_id"`
+	BeneficiaryID     int64            `json:"beneficiary_id"`
+	Amount            decimal.Decimal  `json:"amount"`
+	Currency          string           `json:"currency"`
+	Provider          string           `json:"provider"`
+	ProviderReference *string          `json:"provider_reference"`
+	Status            WithdrawalStatus `json:"status"`
+	FailureReason     string           `json:"failure_reason,omitempty"`
+	Attempts          int              `json:"-"`
+	NextAttemptAt     *time.Time       `json:"-"`
+	CompletedAt       *time.Time       `json:"completed_at"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+type WithdrawalRepository interface {
+	CreateWithdrawal(ctx context.Context, withdrawal *Withdrawal) error
+	GetWithdrawalByID(ctx context.Context, id int64) (*Withdrawal, error)
+	GetWithdrawalForUpdate(ctx context.Context, id int64) (*Withdrawal, error)
+	ListWithdrawalsByUser(ctx context.Context, userID int64) ([]*Withdrawal, error)
+	ListDueWithdrawalIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	UpdateWithdrawal(ctx context.Context, withdrawal *Withdrawal) error
+}
+
+type PayoutStatus string
+
+const (
+	PayoutStatusPending   PayoutStatus = "PENDING"
+	PayoutStatusSucceeded PayoutStatus = "SUCCEEDED"
+	PayoutStatusFailed    PayoutStatus = "FAILED"
+)
+
+// PayoutResult is a payout provider's view of a payout.
+type PayoutResult struct {
+	Reference     string
+	Status        PayoutStatus
+	FailureReason string
+}
+
+// PayoutProvider pays withdrawals out to bank accounts. SubmitPayout must be
+// idempotent per withdrawal, so that a submission retried after a timeout
+// cannot pay out twice. A FAILED result means the provider did not and will
+// not pay; an error means the outcome is unknown.
+type PayoutProvider interface {
+	Name() string
+	SubmitPayout(ctx context.Context, withdrawal *Withdrawal, beneficiary *BankBeneficiary) (*PayoutResult, error)
+	GetPayout(ctx context.Context, reference string) (*PayoutResult, error)
+}
+
+type WithdrawalService interface {
+	CreateBeneficiary(ctx context.Context, userID int64, params BankBeneficiaryParams) (*BankBeneficiary, error)
+	ListBeneficiaries(ctx context.Context, userID int64) ([]*BankBeneficiary, error)
+	GetBeneficiary(ctx context.Context, userID, id int64) (*BankBeneficiary, error)
+	DeleteBeneficiary(ctx context.Context, userID, id int64) error
+	CreateWithdrawal(ctx context.Context, userID, beneficiaryID int64, amount decimal.Decimal) (*Withdrawal, error)
+	ListWithdrawals(ctx context.Context, userID int64) ([]*Withdrawal, error)
+	GetWithdrawal(ctx context.Context, userID, id int64) (*Withdrawal, error)
+	CancelWithdrawal(ctx context.Context, userID, id int64) (*Withdrawal, error)
+	ProcessWithdrawal(ctx context.Context, id int64) error
+	ProcessDueWithdrawals(ctx context.Context, now time.Time) (int, error)
+}
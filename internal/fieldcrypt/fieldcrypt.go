@@ -0,0 +1,310 @@
+// Package fieldcrypt encrypts individual database fields with envelope
+// encryption. Values are sealed with AES-256-GCM under a random data key, and
+// the data key is itself sealed (wrapped) under a key-encryption key (KEK)
+// that never touches the database. Each value carries the ID of its KEK and
+// its wrapped data key, so KEKs can be rotated by re-encrypting rows while
+// the old KEK is still configured.
+//
+// Equality lookups use a blind index: a keyed HMAC of the normalised value
+// that reveals nothing without the index key.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrNoKEK         = errors.New("fieldcrypt: no key-encryption key configured")
+	ErrNoIndexKey    = errors.New("fieldcrypt: no blind index key configured")
+	ErrInvalidKey    = errors.New("fieldcrypt: keys must be 32 bytes, base64 encoded")
+	ErrUnknownKEK    = errors.New("fieldcrypt: value is wrapped with an unknown key-encryption key")
+	ErrMalformed     = errors.New("fieldcrypt: malformed encrypted value")
+	ErrDecryptFailed = errors.New("fieldcrypt: value could not be decrypted")
+)
+
+// prefix marks encrypted values. Values without it are treated as plaintext
+// written before encryption was enabled.
+const prefix = "enc:v1:"
+
+const (
+	keySize = 32
+	// maxCachedDataKeys bounds the cache of unwrapped data keys. Every
+	// process start creates one data key, so the number in use is small.
+	maxCachedDataKeys = 1024
+)
+
+// Config lists the keys, base64 encoded, either inline or as files holding
+// them. KEKs and KEKFiles are keyed by KEK ID; CurrentKEK wraps new data keys
+// and the others are only used to decrypt.
+type Config struct {
+	CurrentKEK   string
+	KEKs         map[string]string
+	KEKFiles     map[string]string
+	IndexKey     string
+	IndexKeyFile string
+}
+
+// Cipher encrypts and decrypts field values. It is safe for concurrent use.
+type Cipher struct {
+	currentKEK string
+	keks       map[string]cipher.AEAD
+	indexKey   []byte
+
+	// dataKey encrypts new values; wrappedDataKey is its stored form.
+	dataKey        cipher.AEAD
+	wrappedDataKey string
+
+	mu       sync.RWMutex
+	dataKeys map[string]cipher.AEAD
+}
+
+// New loads the keys in cfg and creates a fresh data key wrapped with the
+// current KEK.
+func New(cfg Config) (*Cipher, error) {
+	if cfg.CurrentKEK == "" {
+		return nil, ErrNoKEK
+	}
+
+	c := &Cipher{
+		currentKEK: cfg.CurrentKEK,
+		keks:       make(map[string]cipher.AEAD),
+		dataKeys:   make(map[string]cipher.AEAD),
+	}
+
+	add := func(id, inline, file string) error {
+		if strings.ContainsAny(id, ":") || id == "" {
+			return fmt.Errorf("fieldcrypt: invalid key ID %q", id)
+		}
+
+		key, err := loadKey(inline, file)
+		if err != nil {
+			return fmt.Errorf("fieldcrypt: key %q: %w", id, err)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return err
+		}
+
+		c.keks[id] = aead
+		return nil
+	}
+
+	for id, inline := range cfg.KEKs {
+		if err := add(id, inline, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	for id, file := range cfg.KEKFiles {
+		if err := add(id, "", file); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := c.keks[cfg.CurrentKEK]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNoKEK, cfg.CurrentKEK)
+	}
+
+	if cfg.IndexKey == "" && cfg.IndexKeyFile == "" {
+		return nil, ErrNoIndexKey
+	}
+
+	indexKey, err := loadKey(cfg.IndexKey, cfg.IndexKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("fieldcrypt: index key: %w", err)
+	}
+	c.indexKey = indexKey
+
+	if err := c.newDataKey(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func loadKey(inline, file string) ([]byte, error) {
+	encoded := inline
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(b)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != keySize {
+		return nil, ErrInvalidKey
+	}
+
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// newDataKey generates the data key used for new values and wraps it with the
+// current KEK. The KEK ID is authenticated along with the wrapped key.
+func (c *Cipher) newDataKey() error {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	kek := c.keks[c.currentKEK]
+	nonce := make([]byte, kek.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	wrapped := kek.Seal(nonce, nonce, key, []byte(c.currentKEK))
+
+	c.dataKey = aead
+	c.wrappedDataKey = c.currentKEK + ":" + base64.RawURLEncoding.EncodeToString(wrapped)
+	c.dataKeys[c.wrappedDataKey] = aead
+
+	return nil
+}
+
+// Encrypt seals plaintext under the current data key. The empty string stays
+// empty so that optional fields keep their zero value.
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, c.dataKey.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.dataKey.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return prefix + c.wrappedDataKey + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with any configured KEK. Values
+// without the encryption prefix are returned unchanged, so rows written before
+// encryption was enabled stay readable until they are rotated.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrMalformed
+	}
+
+	dataKey, err := c.unwrapDataKey(parts[0], parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sealed) < dataKey.NonceSize() {
+		return "", ErrMalformed
+	}
+
+	nonce, ciphertext := sealed[:dataKey.NonceSize()], sealed[dataKey.NonceSize():]
+	plaintext, err := dataKey.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrDecryptFailed
+	}
+
+	return string(plaintext), nil
+}
+
+func (c *Cipher) unwrapDataKey(kekID, encodedWrapped string) (cipher.AEAD, error) {
+	cacheKey := kekID + ":" + encodedWrapped
+
+	c.mu.RLock()
+	dataKey, ok := c.dataKeys[cacheKey]
+	c.mu.RUnlock()
+	if ok {
+		return dataKey, nil
+	}
+
+	kek, ok := c.keks[kekID]
+	if !ok {
+		return nil, ErrUnknownKEK
+	}
+
+	wrapped, err := base64.RawURLEncoding.DecodeString(encodedWrapped)
+	if err != nil || len(wrapped) < kek.NonceSize() {
+		return nil, ErrMalformed
+	}
+
+	key, err := kek.Open(nil, wrapped[:kek.NonceSize()], wrapped[kek.NonceSize():], []byte(kekID))
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+
+	dataKey, err = newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if len(c.dataKeys) >= maxCachedDataKeys {
+		clear(c.dataKeys)
+		c.dataKeys[c.wrappedDataKey] = c.dataKey
+	}
+	c.dataKeys[cacheKey] = dataKey
+	c.mu.Unlock()
+
+	return dataKey, nil
+}
+
+// IsEncrypted reports whether value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// NeedsRotation reports whether value is plaintext or wrapped with a KEK other
+// than the current one.
+func (c *Cipher) NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	if !IsEncrypted(value) {
+		return true
+	}
+
+	kekID, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+
+	return kekID != c.currentKEK
+}
+
+// BlindIndex returns the hex HMAC-SHA256 of value, trimmed and lower-cased so
+// that lookups match the case-insensitive comparison of the plaintext column.
+func (c *Cipher) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
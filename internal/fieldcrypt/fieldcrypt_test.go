@@ -0,0 +1,247 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func newTestCipher(t *testing.T, current string, keks ...string) *Cipher {
+	t.Helper()
+
+	cfg := Config{CurrentKEK: current, KEKs: make(map[string]string), IndexKey: testKey(0xff)}
+	for i, id := range keks {
+		cfg.KEKs[id] = testKey(byte(i + 1))
+	}
+
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return c
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	c := newTestCipher(t, "k1", "k1")
+
+	for _, plaintext := range []string{"alice@example.com", "Zoë Ångström", "+44 20 7946 0958", strings.Repeat("x", 4096)} {
+		encrypted, err := c.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt() error = %v", err)
+		}
+
+		if !IsEncrypted(encrypted) || strings.Contains(encrypted, plaintext) {
+			t.Errorf("Encrypt(%q) = %q, want an opaque encrypted value", plaintext, encrypted)
+		}
+
+		again, err := c.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if again == encrypted {
+			t.Errorf("Encrypt(%q) returned the same value twice", plaintext)
+		}
+
+		decrypted, err := c.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+
+		if decrypted != plaintext {
+			t.Errorf("Decrypt() = %q, want %q", decrypted, plaintext)
+		}
+
+		if c.NeedsRotation(encrypted) {
+			t.Errorf("NeedsRotation() = true for a value under the current KEK")
+		}
+	}
+}
+
+func TestEmptyAndPlaintextValues(t *testing.T) {
+	c := newTestCipher(t, "k1", "k1")
+
+	encrypted, err := c.Encrypt("")
+	if err != nil || encrypted != "" {
+		t.Errorf("Encrypt(\"\") = %q, %v, want \"\"", encrypted, err)
+	}
+
+	// Rows written before encryption was enabled are read as they are.
+	decrypted, err := c.Decrypt("alice@example.com")
+	if err != nil || decrypted != "alice@example.com" {
+		t.Errorf("Decrypt(plaintext) = %q, %v, want the value unchanged", decrypted, err)
+	}
+
+	if !c.NeedsRotation("alice@example.com") {
+		t.Error("NeedsRotation(plaintext) = false, want true")
+	}
+
+	if c.NeedsRotation("") {
+		t.Error("NeedsRotation(\"\") = true, want false")
+	}
+}
+
+func TestDecryptWithRetiredKEK(t *testing.T) {
+	before := newTestCipher(t, "k1", "k1")
+	old, err := before.Encrypt("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// k2 becomes current; k1 stays configured to decrypt until rotation
+	// finishes.
+	after := newTestCipher(t, "k2", "k1", "k2")
+
+	decrypted, err := after.Decrypt(old)
+	if err != nil || decrypted != "alice@example.com" {
+		t.Fatalf("Decrypt() with the retired KEK = %q, %v", decrypted, err)
+	}
+
+	if !after.NeedsRotation(old) {
+		t.Error("NeedsRotation() = false for a value under the retired KEK")
+	}
+
+	rotated, err := after.Encrypt(decrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after.NeedsRotation(rotated) || !strings.HasPrefix(rotated, prefix+"k2:") {
+		t.Errorf("rotated value %q is not wrapped with k2", rotated)
+	}
+
+	// Once k1 is removed only rotated values can be read.
+	cfg := Config{CurrentKEK: "k2", KEKs: map[string]string{"k2": testKey(2)}, IndexKey: testKey(0xff)}
+	retired, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := retired.Decrypt(rotated); err != nil || got != "alice@example.com" {
+		t.Errorf("Decrypt(rotated) = %q, %v", got, err)
+	}
+
+	if _, err := retired.Decrypt(old); !errors.Is(err, ErrUnknownKEK) {
+		t.Errorf("Decrypt(old) without k1 error = %v, want %v", err, ErrUnknownKEK)
+	}
+}
+
+// tamper replaces a character in the middle of s with a different one from
+// the base64url alphabet.
+func tamper(s string) string {
+	i := len(s) / 2
+	c := byte('A')
+	if s[i] == 'A' {
+		c = 'B'
+	}
+
+	return s[:i] + string(c) + s[i+1:]
+}
+
+func TestDecryptRejectsTamperedValues(t *testing.T) {
+	c := newTestCipher(t, "k1", "k1", "k2")
+
+	encrypted, err := c.Encrypt("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(encrypted, prefix), ":")
+	kekID, wrapped, sealed := parts[0], parts[1], parts[2]
+
+	tests := []struct {
+		name  string
+		value string
+		want  error
+	}{
+		{"tampered ciphertext", prefix + kekID + ":" + wrapped + ":" + tamper(sealed), ErrDecryptFailed},
+		{"tampered data key", prefix + kekID + ":" + tamper(wrapped) + ":" + sealed, ErrDecryptFailed},
+		{"other configured key ID", prefix + "k2:" + wrapped + ":" + sealed, ErrDecryptFailed},
+		{"unknown key ID", prefix + "k9:" + wrapped + ":" + sealed, ErrUnknownKEK},
+		{"truncated ciphertext", prefix + kekID + ":" + wrapped + ":AAAA", ErrMalformed},
+		{"missing part", prefix + kekID + ":" + sealed, ErrMalformed},
+		{"invalid base64", prefix + kekID + ":" + wrapped + ":!!!", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Decrypt(tt.value)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt() = %q, %v, want error %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlindIndexNormalisesEmails(t *testing.T) {
+	c := newTestCipher(t, "k1", "k1")
+
+	want := c.BlindIndex("alice@example.com")
+	for _, email := range []string{"Alice@Example.com", "  ALICE@EXAMPLE.COM\n", "alice@example.com "} {
+		if got := c.BlindIndex(email); got != want {
+			t.Errorf("BlindIndex(%q) = %s, want %s", email, got, want)
+		}
+	}
+
+	if c.BlindIndex("bob@example.com") == want {
+		t.Error("different emails share a blind index")
+	}
+
+	// The index depends only on the index key, not on the KEKs, so rotating
+	// KEKs keeps lookups working.
+	rotated := newTestCipher(t, "k2", "k1", "k2")
+	if rotated.BlindIndex("Alice@Example.com") != want {
+		t.Error("blind index changed with the KEK")
+	}
+
+	other, err := New(Config{CurrentKEK: "k1", KEKs: map[string]string{"k1": testKey(1)}, IndexKey: testKey(0xee)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.BlindIndex("alice@example.com") == want {
+		t.Error("blind index does not depend on the index key")
+	}
+}
+
+func TestNewValidatesKeys(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "kek")
+	if err := os.WriteFile(keyFile, []byte(testKey(3)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"no current KEK", Config{KEKs: map[string]string{"k1": testKey(1)}, IndexKey: testKey(0xff)}, ErrNoKEK},
+		{"current KEK not configured", Config{CurrentKEK: "k2", KEKs: map[string]string{"k1": testKey(1)}, IndexKey: testKey(0xff)}, ErrNoKEK},
+		{"short key", Config{CurrentKEK: "k1", KEKs: map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte("short"))}, IndexKey: testKey(0xff)}, ErrInvalidKey},
+		{"no index key", Config{CurrentKEK: "k1", KEKs: map[string]string{"k1": testKey(1)}}, ErrNoIndexKey},
+		{"key file", Config{CurrentKEK: "k1", KEKFiles: map[string]string{"k1": keyFile}, IndexKeyFile: keyFile}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(Config{CurrentKEK: "a:b", KEKs: map[string]string{"a:b": testKey(1)}, IndexKey: testKey(0xff)}); err == nil {
+		t.Error("New() accepted a key ID containing a colon")
+	}
+}
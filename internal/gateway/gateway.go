@@ -0,0 +1,34 @@
+// Package gateway provides PaymentGateway implementations. Only a simulated
+// gateway exists so far; it takes no real payments and is meant for local
+// development and testing the deposit flow end to end.
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+// Config configures the payment gateway. CallbackSecret authenticates the
+// gateway's callbacks and BaseURL is where its payment pages are served.
+type Config struct {
+	CallbackSecret string
+	BaseURL        string
+}
+
+// NewGateway returns the payment gateway for driver: "simulated" is the only
+// one available. The driver must be named, so that a missing setting cannot
+// silently route deposits to a gateway that takes no real payments.
+func NewGateway(driver string, cfg Config) (domain.PaymentGateway, error) {
+	switch driver {
+	case "":
+		return nil, fmt.Errorf("payment gateway driver is not set")
+	case SimulatedName:
+		if cfg.CallbackSecret == "" {
+			return nil, fmt.Errorf("simulated gateway needs a callback secret")
+		}
+		return NewSimulated(cfg.CallbackSecret, cfg.BaseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown payment gateway driver %q", driver)
+	}
+}
@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/webhook"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	SimulatedName = "simulated"
+
+	SignatureHeader = "X-Gateway-Signature"
+	TimestampHeader = "X-Gateway-Timestamp"
+
+	statusSucceeded = "succeeded"
+	statusFailed    = "failed"
+)
+
+var ErrMalformedCallback = errors.New("gateway callback body is malformed")
+
+// simulatedCallback is the body of a simulated gateway callback.
+type simulatedCallback struct {
+	Reference     string          `json:"reference"`
+	Status        string          `json:"status"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	FailureReason string          `json:"failure_reason,omitempty"`
+}
+
+// Simulated is a gateway that takes no real payments. Its callbacks are signed
+// like outgoing webhooks, with the callback secret, so the deposit flow
+// authenticates them exactly as it would a real gateway's. Callback builds
+// the callback a payment at the gateway would produce.
+type Simulated struct {
+	secret  string
+	baseURL string
+	now     func() time.Time
+}
+
+func NewSimulated(secret, baseURL string) *Simulated {
+	return &Simulated{
+		secret:  secret,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		now:     time.Now,
+	}
+}
+
+func (g *Simulated) Name() string {
+	return SimulatedName
+}
+
+func (g *Simulated) CreatePaymentIntent(_ context.Context, _ *domain.Deposit) (*domain.PaymentIntent, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	reference := "sim_" + hex.EncodeToString(b)
+
+	return &domain.PaymentIntent{
+		Reference:  reference,
+		PaymentURL: g.baseURL + "/pay/" + reference,
+	}, nil
+}
+
+func (g *Simulated) ParseCallback(header http.Header, body []byte) (*domain.GatewayCallback, error) {
+	err := webhook.Verify(g.secret, header.Get(SignatureHeader), header.Get(TimestampHeader), body,
+		webhook.DefaultTolerance, g.now())
+	if err != nil {
+		return nil, err
+	}
+
+	var payload simulatedCallback
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrMalformedCallback
+	}
+
+	if payload.Reference == "" || (payload.Status != statusSucceeded && payload.Status != statusFailed) {
+		return nil, ErrMalformedCallback
+	}
+
+	return &domain.GatewayCallback{
+		Reference:     payload.Reference,
+		Succeeded:     payload.Status == statusSucceeded,
+		Amount:        payload.Amount,
+		Currency:      payload.Currency,
+		FailureReason: payload.FailureReason,
+	}, nil
+}
+
+// Callback returns the signed callback the gateway sends once the payment of
+// deposit succeeds or, when succeeded is false, is declined.
+func (g *Simulated) Callback(deposit *domain.Deposit, succeeded bool) (http.Header, []byte, error) {
+	if deposit.GatewayReference == nil {
+		return nil, nil, errors.New("deposit has no gateway reference")
+	}
+
+	payload := simulatedCallback{
+		Reference: *deposit.GatewayReference,
+		Status:    statusSucceeded,
+		Amount:    deposit.Amount,
+		Currency:  deposit.Currency,
+	}
+
+	if !succeeded {
+		payload.Status = statusFailed
+		payload.FailureReason = "payment declined"
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := g.now()
+	header := http.Header{}
+	header.Set(SignatureHeader, webhook.Sign(g.secret, now, body))
+	header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+
+	return header, body, nil
+}
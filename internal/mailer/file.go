@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"net/mail"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+// FileMailer appends every message to a file, or writes it to the standard
+// logger when path is empty. It is meant for local development, where the
+// links in verification and reset emails are copied by hand.
+type FileMailer struct {
+	path string
+	from *mail.Address
+	mu   sync.Mutex
+}
+
+func NewFileMailer(path string, from *mail.Address) *FileMailer {
+	return &FileMailer{
+		path: path,
+		from: from,
+	}
+}
+
+func (m *FileMailer) Send(_ context.Context, msg domain.EmailMessage) error {
+	data := formatMessage(m.from, msg, time.Now())
+
+	if m.path == "" {
+		log.Printf("Email to %s:\n%s", msg.To, data)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, "\r\n\r\n"...)); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
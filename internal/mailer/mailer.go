@@ -0,0 +1,77 @@
+// Package mailer provides Mailer implementations: SMTP for real delivery and
+// a file or log mailer for local development.
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+// SMTPConfig is where the SMTP mailer connects. Username and Password are
+// optional; credentials are only sent over TLS.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// NewMailer returns the mailer for driver: "smtp" delivers through smtpConfig,
+// "file" appends messages to path and "log" (or empty) writes them to the
+// standard logger. from is the sender, e.g. "Wallet <no-reply@example.com>".
+func NewMailer(driver, from, path string, smtpConfig SMTPConfig) (domain.Mailer, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail sender %q: %w", from, err)
+	}
+
+	switch driver {
+	case "smtp":
+		if smtpConfig.Host == "" {
+			return nil, fmt.Errorf("smtp mailer needs a host")
+		}
+		return NewSMTPMailer(smtpConfig, sender), nil
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("file mailer needs a path")
+		}
+		return NewFileMailer(path, sender), nil
+	case "", "log":
+		return NewFileMailer("", sender), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", driver)
+	}
+}
+
+// formatMessage renders msg as an RFC 5322 message. The body is sent as
+// 8-bit UTF-8 so that links stay readable in the file and log mailers.
+func formatMessage(from *mail.Address, msg domain.EmailMessage, now time.Time) []byte {
+	var buf bytes.Buffer
+
+	header := func(name, value string) {
+		// Values never come from raw user input, but a stray line break
+		// would still let one header inject others.
+		value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+
+	header("From", from.String())
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "8bit")
+	buf.WriteString("\r\n")
+
+	body := strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n")
+	buf.WriteString(body)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,53 @@
+package nonce
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired nonces are dropped from a MemoryStore.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps nonces in process. Nonces are not shared between
+// instances, so it is meant for development and single-instance setups.
+type MemoryStore struct {
+	mu        sync.Mutex
+	expiries  map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		expiries: make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryStore) Claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	if expiry, ok := s.expiries[key]; ok && now.Before(expiry) {
+		return false, nil
+	}
+
+	s.expiries[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, expiry := range s.expiries {
+		if !now.Before(expiry) {
+			delete(s.expiries, key)
+		}
+	}
+}
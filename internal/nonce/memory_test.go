@@ -0,0 +1,68 @@
+package nonce
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreClaim(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if ok, err := store.Claim(ctx, "reqsign:key:a", time.Minute); err != nil || !ok {
+		t.Fatalf("first Claim() = %v, %v, want true", ok, err)
+	}
+
+	if ok, err := store.Claim(ctx, "reqsign:key:a", time.Minute); err != nil || ok {
+		t.Errorf("reused Claim() = %v, %v, want false", ok, err)
+	}
+
+	if ok, err := store.Claim(ctx, "reqsign:key:b", time.Minute); err != nil || !ok {
+		t.Errorf("Claim() of another nonce = %v, %v, want true", ok, err)
+	}
+}
+
+func TestMemoryStoreClaimAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if ok, _ := store.Claim(ctx, "n", time.Millisecond); !ok {
+		t.Fatal("first Claim() = false")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if ok, _ := store.Claim(ctx, "n", time.Minute); !ok {
+		t.Error("Claim() after the TTL = false, want true")
+	}
+}
+
+func TestMemoryStoreSweepsExpiredNonces(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	store.expiries["expired"] = now.Add(-time.Second)
+	store.expiries["live"] = now.Add(time.Minute)
+	store.sweep(now)
+
+	if _, ok := store.expiries["expired"]; ok {
+		t.Error("expired nonce was kept")
+	}
+
+	if _, ok := store.expiries["live"]; !ok {
+		t.Error("live nonce was dropped")
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	if store, err := NewStore("memory", ""); err != nil {
+		t.Errorf("NewStore(memory) error = %v", err)
+	} else if _, ok := store.(*MemoryStore); !ok {
+		t.Errorf("NewStore(memory) = %T, want *MemoryStore", store)
+	}
+
+	if _, err := NewStore("memcached", ""); err == nil {
+		t.Error("NewStore(memcached) error = nil")
+	}
+}
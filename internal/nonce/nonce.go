@@ -0,0 +1,31 @@
+// Package nonce records single-use values, such as request signing nonces, so
+// that a replayed value can be refused. It has a Redis store for
+// multi-instance deployments and an in-memory store for a single process.
+package nonce
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store claims nonces. Claim reports false if key was already claimed within
+// ttl.
+type Store interface {
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// NewStore returns the store for driver: "redis" (or empty) connects to
+// redisAddr, "memory" keeps nonces in process.
+func NewStore(driver, redisAddr string) (Store, error) {
+	switch driver {
+	case "", "redis":
+		return NewRedisStore(redis.NewClient(&redis.Options{Addr: redisAddr})), nil
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown nonce driver %q", driver)
+	}
+}
@@ -0,0 +1,24 @@
+// Package payout provides PayoutProvider implementations. Only a simulated
+// provider exists so far; it moves no real money and is meant for local
+// development and testing the withdrawal flow end to end.
+package payout
+
+import (
+	"fmt"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+// NewProvider returns the payout provider for driver: "simulated" is the only
+// one available. The driver must be named, so that a missing setting cannot
+// silently send withdrawals to a provider that moves no money.
+func NewProvider(driver string) (domain.PayoutProvider, error) {
+	switch driver {
+	case "":
+		return nil, fmt.Errorf("payout provider driver is not set")
+	case SimulatedName:
+		return NewSimulated(), nil
+	default:
+		return nil, fmt.Errorf("unknown payout provider driver %q", driver)
+	}
+}
@@ -0,0 +1,59 @@
+package payout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+const (
+	SimulatedName = "simulated"
+
+	// SimulatedRejectedSuffix ends the account numbers the simulated provider
+	// rejects, so that failed payouts can be tried out.
+	SimulatedRejectedSuffix = "0000"
+
+	simulatedReferencePrefix = "sim_po_"
+)
+
+// Simulated is a payout provider that moves no money. Payouts are accepted
+// as PENDING and reported SUCCEEDED the first time they are checked on, except
+// to accounts ending in SimulatedRejectedSuffix, which are rejected. It keeps
+// no state: the reference is derived from the withdrawal ID, which makes
+// submissions idempotent.
+type Simulated struct{}
+
+func NewSimulated() *Simulated {
+	return &Simulated{}
+}
+
+func (p *Simulated) Name() string {
+	return SimulatedName
+}
+
+func (p *Simulated) SubmitPayout(_ context.Context, withdrawal *domain.Withdrawal, beneficiary *domain.BankBeneficiary) (*domain.PayoutResult, error) {
+	result := &domain.PayoutResult{
+		Reference: fmt.Sprintf("%s%d", simulatedReferencePrefix, withdrawal.ID),
+		Status:    domain.PayoutStatusPending,
+	}
+
+	if strings.HasSuffix(beneficiary.AccountNumber, SimulatedRejectedSuffix) {
+		result.Status = domain.PayoutStatusFailed
+		result.FailureReason = "beneficiary account is closed"
+	}
+
+	return result, nil
+}
+
+func (p *Simulated) GetPayout(_ context.Context, reference string) (*domain.PayoutResult, error) {
+	if !strings.HasPrefix(reference, simulatedReferencePrefix) {
+		return nil, fmt.Errorf("unknown payout %q", reference)
+	}
+
+	return &domain.PayoutResult{
+		Reference: reference,
+		Status:    domain.PayoutStatusSucceeded,
+	}, nil
+}
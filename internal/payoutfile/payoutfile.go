@@ -0,0 +1,299 @@
+// Package payoutfile reads payout batch files and writes their result files.
+//
+// A CSV file starts with a header row naming the recipient_user_id, amount
+// and reference columns, in any order:
+//
+//	recipient_user_id,amount,reference
+//	42,1500.00,payroll-2026-10-alice
+//
+// A JSON file is an array of objects with the same fields, the amount as a
+// string. Rows are numbered by their line in a CSV file and by their position
+// in a JSON array.
+package payoutfile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// MaxRows is the most rows a batch file may have.
+const MaxRows = 1000
+
+// maxReportedErrors bounds how many row errors Errors lists.
+const maxReportedErrors = 20
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("payout files must be text/csv or application/json")
+	ErrNoRows            = errors.New("payout file has no rows")
+	ErrTooManyRows       = fmt.Errorf("payout file has more than %d rows", MaxRows)
+)
+
+var columns = []string{"recipient_user_id", "amount", "reference"}
+
+// ContentType returns the media type of files in format.
+func (f Format) ContentType() string {
+	if f == FormatJSON {
+		return "application/json"
+	}
+
+	return "text/csv"
+}
+
+// ParseFormat returns the format named by name, "csv" or "json".
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// FormatFromContentType returns the format of a file sent with contentType.
+func FormatFromContentType(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/json":
+		return FormatJSON, nil
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// LineError is a problem with one row of a batch file.
+type LineError struct {
+	Line    int
+	Message string
+}
+
+// Errors lists every row of a batch file that was refused.
+type Errors []LineError
+
+func (e Errors) Error() string {
+	var b strings.Builder
+	for i, lineErr := range e {
+		if i == maxReportedErrors {
+			fmt.Fprintf(&b, "; and %d more", len(e)-maxReportedErrors)
+			break
+		}
+
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "line %d: %s", lineErr.Line, lineErr.Message)
+	}
+
+	return b.String()
+}
+
+// Parse reads the rows of a batch file. It only checks that each row can be
+// read: recipients, amounts and references are validated by the caller. Rows
+// that cannot be read are reported together as Errors.
+func Parse(format Format, r io.Reader) ([]domain.PayoutBatchRow, error) {
+	var rows []domain.PayoutBatchRow
+	var err error
+
+	switch format {
+	case FormatCSV:
+		rows, err = parseCSV(r)
+	case FormatJSON:
+		rows, err = parseJSON(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+
+	return rows, nil
+}
+
+func parseCSV(r io.Reader) ([]domain.PayoutBatchRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets often save CSV with a byte order mark.
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := index[name]; ok {
+			return nil, fmt.Errorf("payout file has a duplicate %q column", name)
+		}
+		index[name] = i
+	}
+
+	for _, name := range columns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("payout file has no %q column", name)
+		}
+	}
+
+	if len(index) != len(columns) {
+		return nil, fmt.Errorf("payout file must only have the columns %s", strings.Join(columns, ", "))
+	}
+
+	var rows []domain.PayoutBatchRow
+	var errs Errors
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rows)+len(errs) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := domain.PayoutBatchRow{
+			LineNumber: line,
+			Reference:  strings.TrimSpace(record[index["reference"]]),
+		}
+
+		recipient := strings.TrimSpace(record[index["recipient_user_id"]])
+		if row.RecipientUserID, err = strconv.ParseInt(recipient, 10, 64); err != nil {
+			errs = append(errs, LineError{Line: line, Message: fmt.Sprintf("recipient_user_id %q is not a user ID", recipient)})
+			continue
+		}
+
+		amount := strings.TrimSpace(record[index["amount"]])
+		if row.Amount, err = decimal.NewFromString(amount); err != nil {
+			errs = append(errs, LineError{Line: line, Message: fmt.Sprintf("amount %q is not a number", amount)})
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return rows, nil
+}
+
+type jsonRow struct {
+	RecipientUserID int64           `json:"recipient_user_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Reference       string          `json:"reference"`
+}
+
+func parseJSON(r io.Reader) ([]domain.PayoutBatchRow, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var parsed []jsonRow
+	if err := decoder.Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("payout file is not a JSON array of rows: %v", err)
+	}
+
+	if len(parsed) > MaxRows {
+		return nil, ErrTooManyRows
+	}
+
+	rows := make([]domain.PayoutBatchRow, len(parsed))
+	for i, row := range parsed {
+		rows[i] = domain.PayoutBatchRow{
+			LineNumber:      i + 1,
+			RecipientUserID: row.RecipientUserID,
+			Amount:          row.Amount,
+			Reference:       strings.TrimSpace(row.Reference),
+		}
+	}
+
+	return rows, nil
+}
+
+// WriteResults writes the outcome of each item of a batch in format. A CSV
+// result file has a header row and the columns line_number,
+// recipient_user_id, amount, reference, status, transaction_id and
+// failure_reason.
+func WriteResults(w io.Writer, format Format, items []*domain.PayoutBatchItem) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, items)
+	case FormatJSON:
+		if items == nil {
+			items = []*domain.PayoutBatchItem{}
+		}
+		return json.NewEncoder(w).Encode(items)
+	}
+
+	return ErrUnsupportedFormat
+}
+
+func writeCSV(w io.Writer, items []*domain.PayoutBatchItem) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"line_number", "recipient_user_id", "amount", "reference", "status", "transaction_id", "failure_reason"})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		transactionID := ""
+		if item.TransactionID != nil {
+			transactionID = strconv.FormatInt(*item.TransactionID, 10)
+		}
+
+		err := writer.Write([]string{
+			strconv.Itoa(item.LineNumber),
+			strconv.FormatInt(item.RecipientUserID, 10),
+			item.Amount.String(),
+			item.Reference,
+			string(item.Status),
+			transactionID,
+			item.FailureReason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,95 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+// MemoryBroker is an in-process EventBroker. Publishers and subscribers must
+// run in the same process, so it only suits single-node setups and local
+// development.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	backlog     map[int64][]domain.StreamEvent
+	subscribers map[int64]map[chan domain.StreamEvent]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		backlog:     make(map[int64][]domain.StreamEvent),
+		subscribers: make(map[int64]map[chan domain.StreamEvent]struct{}),
+	}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, userID int64, event domain.StreamEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	backlog := append(b.backlog[userID], event)
+	if len(backlog) > backlogSize {
+		backlog = backlog[len(backlog)-backlogSize:]
+	}
+	b.backlog[userID] = backlog
+
+	for ch := range b.subscribers[userID] {
+		select {
+		case ch <- event:
+		default:
+			// Drop events for subscribers that are not keeping up; they
+			// can resume from the backlog by reconnecting.
+		}
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, userID int64, lastEventID string) (<-chan domain.StreamEvent, error) {
+	live := make(chan domain.StreamEvent, backlogSize)
+	out := make(chan domain.StreamEvent, backlogSize)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan domain.StreamEvent]struct{})
+	}
+	b.subscribers[userID][live] = struct{}{}
+	last := lastEventID
+	for _, event := range b.backlog[userID] {
+		if last == "" || After(event.ID, last) {
+			out <- event
+			last = event.ID
+		}
+	}
+	b.mu.Unlock()
+
+	go func() {
+		defer close(out)
+		defer func() {
+			b.mu.Lock()
+			delete(b.subscribers[userID], live)
+			b.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-live:
+				// Skip events republished after a retried publish.
+				if last != "" && !After(event.ID, last) {
+					continue
+				}
+				last = event.ID
+
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
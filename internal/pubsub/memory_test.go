@@ -0,0 +1,138 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+func TestAfter(t *testing.T) {
+	tests := []struct {
+		id, last string
+		want     bool
+	}{
+		{"5-0", "", true},
+		{"5-0", "garbage", true},
+		{"5-1", "5-0", true},
+		{"6-0", "5-9", true},
+		{"10-0", "9-0", true},
+		{"5-0", "5-0", false},
+		{"5-0", "5-1", false},
+		{"4-3", "5-0", false},
+		{"5", "4-2", true},
+		{"garbage", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := After(tt.id, tt.last); got != tt.want {
+			t.Errorf("After(%q, %q) = %v, want %v", tt.id, tt.last, got, tt.want)
+		}
+	}
+}
+
+func event(id string) domain.StreamEvent {
+	return domain.StreamEvent{ID: id, Type: "wallet.balance", Data: []byte(`{}`)}
+}
+
+// receive returns the next event on events, failing t if none arrives.
+func receive(t *testing.T, events <-chan domain.StreamEvent) domain.StreamEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("stream closed, want an event")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+
+	return domain.StreamEvent{}
+}
+
+func TestMemoryBrokerReplaysBacklogAfterLastEventID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := NewMemoryBroker()
+	for _, id := range []string{"1-0", "2-0", "2-1", "3-0"} {
+		broker.Publish(ctx, 7, event(id))
+	}
+	broker.Publish(ctx, 8, event("4-0"))
+
+	events, err := broker.Subscribe(ctx, 7, "2-0")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	for _, want := range []string{"2-1", "3-0"} {
+		if got := receive(t, events); got.ID != want {
+			t.Errorf("replayed event %s, want %s", got.ID, want)
+		}
+	}
+
+	broker.Publish(ctx, 7, event("5-0"))
+	if got := receive(t, events); got.ID != "5-0" {
+		t.Errorf("live event %s, want 5-0", got.ID)
+	}
+}
+
+func TestMemoryBrokerSkipsRepublishedEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := NewMemoryBroker()
+	events, err := broker.Subscribe(ctx, 7, "")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	// A retried outbox event is published again with the same IDs.
+	for _, id := range []string{"1-0", "1-1", "1-0", "1-1", "2-0"} {
+		broker.Publish(ctx, 7, event(id))
+	}
+
+	for _, want := range []string{"1-0", "1-1", "2-0"} {
+		if got := receive(t, events); got.ID != want {
+			t.Errorf("event %s, want %s", got.ID, want)
+		}
+	}
+}
+
+func TestMemoryBrokerClosesStreamWhenDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	broker := NewMemoryBroker()
+	events, err := broker.Subscribe(ctx, 7, "")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("received an event, want the stream closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream not closed after the context was cancelled")
+	}
+}
+
+func TestMemoryBrokerKeepsBoundedBacklog(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+
+	for i := 0; i < backlogSize+10; i++ {
+		broker.Publish(ctx, 7, event(fmt.Sprintf("%d-0", i+1)))
+	}
+
+	backlog := broker.backlog[7]
+	if len(backlog) != backlogSize || backlog[0].ID != "11-0" {
+		t.Errorf("backlog = %d events from %s, want the last %d", len(backlog), backlog[0].ID, backlogSize)
+	}
+}
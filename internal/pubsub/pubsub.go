@@ -0,0 +1,68 @@
+// Package pubsub provides EventBroker implementations for the live wallet
+// event stream: Redis for multi-instance deployments and an in-memory broker
+// for a single process.
+package pubsub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// backlogSize is the number of recent events kept per user for replay.
+const backlogSize = 100
+
+// NewBroker returns the broker for driver: "redis" (or empty) connects to
+// redisAddr, "memory" keeps everything in process.
+func NewBroker(driver, redisAddr string) (domain.EventBroker, error) {
+	switch driver {
+	case "", "redis":
+		return NewRedisBroker(redis.NewClient(&redis.Options{Addr: redisAddr})), nil
+	case "memory":
+		return NewMemoryBroker(), nil
+	default:
+		return nil, fmt.Errorf("unknown pubsub driver %q", driver)
+	}
+}
+
+// After reports whether event ID id sorts after last. IDs have the form
+// "<sequence>-<part>"; an empty or malformed last sorts before everything.
+func After(id, last string) bool {
+	idSeq, idPart, ok := parseID(id)
+	if !ok {
+		return false
+	}
+
+	lastSeq, lastPart, ok := parseID(last)
+	if !ok {
+		return true
+	}
+
+	if idSeq != lastSeq {
+		return idSeq > lastSeq
+	}
+
+	return idPart > lastPart
+}
+
+func parseID(id string) (int64, int64, bool) {
+	seqStr, partStr, found := strings.Cut(id, "-")
+	if !found {
+		partStr = "0"
+	}
+
+	seq, err := strconv.ParseInt(seqStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	part, err := strconv.ParseInt(partStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return seq, part, true
+}
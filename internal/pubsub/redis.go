@@ -0,0 +1,115 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const backlogTTL = 24 * time.Hour
+
+// RedisBroker is an EventBroker backed by Redis pub/sub, with each user's
+// backlog kept in a capped Redis list.
+type RedisBroker struct {
+	client *redis.Client
+}
+
+func NewRedisBroker(client *redis.Client) *RedisBroker {
+	return &RedisBroker{
+		client: client,
+	}
+}
+
+func channelKey(userID int64) string {
+	return fmt.Sprintf("wallet:stream:%d", userID)
+}
+
+func backlogKey(userID int64) string {
+	return fmt.Sprintf("wallet:stream:%d:backlog", userID)
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, userID int64, event domain.StreamEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, backlogKey(userID), payload)
+		pipe.LTrim(ctx, backlogKey(userID), -backlogSize, -1)
+		pipe.Expire(ctx, backlogKey(userID), backlogTTL)
+		pipe.Publish(ctx, channelKey(userID), payload)
+		return nil
+	})
+
+	return err
+}
+
+// Subscribe listens on the user's channel before reading the backlog so that
+// no event published in between is lost; duplicates are filtered by ID.
+func (b *RedisBroker) Subscribe(ctx context.Context, userID int64, lastEventID string) (<-chan domain.StreamEvent, error) {
+	sub := b.client.Subscribe(ctx, channelKey(userID))
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	backlog, err := b.client.LRange(ctx, backlogKey(userID), 0, -1).Result()
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	out := make(chan domain.StreamEvent, backlogSize)
+
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		last := lastEventID
+		send := func(raw string) bool {
+			var event domain.StreamEvent
+			if err := json.Unmarshal([]byte(raw), &event); err != nil {
+				log.Printf("Error decoding stream event for user %d: %v", userID, err)
+				return true
+			}
+
+			if last != "" && !After(event.ID, last) {
+				return true
+			}
+			last = event.ID
+
+			select {
+			case out <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, raw := range backlog {
+			if !send(raw) {
+				return
+			}
+		}
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok || !send(msg.Payload) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// rewind moves the bucket for key back by d, as if d had passed since its
+// last request.
+func (s *MemoryStore) rewind(key string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buckets[key].updated = s.buckets[key].updated.Add(-d)
+}
+
+func TestMemoryStoreAllowsBurstThenRejects(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Requests: 1, Period: time.Minute, Burst: 3}
+
+	for want := 2; want >= 0; want-- {
+		res, err := store.Allow(ctx, "k", limit)
+		if err != nil || !res.Allowed {
+			t.Fatalf("Allow() = %+v, %v, want allowed", res, err)
+		}
+
+		if res.Remaining != want || res.Limit != 3 || res.RetryAfter != 0 {
+			t.Errorf("Allow() = %+v, want %d remaining of 3", res, want)
+		}
+	}
+
+	res, err := store.Allow(ctx, "k", limit)
+	if err != nil || res.Allowed {
+		t.Fatalf("Allow() over the burst = %+v, %v, want rejected", res, err)
+	}
+
+	// One token takes a minute to refill, and the bucket three.
+	if res.RetryAfter < 59*time.Second || res.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %v, want about a minute", res.RetryAfter)
+	}
+
+	if res.Reset < 179*time.Second || res.Reset > 3*time.Minute {
+		t.Errorf("Reset = %v, want about three minutes", res.Reset)
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Requests: 2, Period: time.Minute}
+
+	store.Allow(ctx, "k", limit)
+	store.Allow(ctx, "k", limit)
+	if res, _ := store.Allow(ctx, "k", limit); res.Allowed {
+		t.Fatal("Allow() of an empty bucket was allowed")
+	}
+
+	// Two requests a minute refill one token every 30 seconds.
+	store.rewind("k", 30*time.Second)
+	if res, _ := store.Allow(ctx, "k", limit); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("Allow() after 30s = %+v, want allowed with none remaining", res)
+	}
+
+	// Refilling stops at the bucket's capacity.
+	store.rewind("k", time.Hour)
+	if res, _ := store.Allow(ctx, "k", limit); !res.Allowed || res.Remaining != 1 {
+		t.Errorf("Allow() after an hour = %+v, want allowed with 1 remaining", res)
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Requests: 1, Period: time.Minute}
+
+	if res, _ := store.Allow(ctx, "ratelimit:login:ip:10.0.0.1", limit); !res.Allowed {
+		t.Fatal("first Allow() rejected")
+	}
+
+	if res, _ := store.Allow(ctx, "ratelimit:login:ip:10.0.0.2", limit); !res.Allowed {
+		t.Error("Allow() for another key rejected")
+	}
+
+	if res, _ := store.Allow(ctx, "ratelimit:login:ip:10.0.0.1", limit); res.Allowed {
+		t.Error("second Allow() for the same key allowed")
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	store.buckets["full"] = &bucket{full: now.Add(-time.Second)}
+	store.buckets["draining"] = &bucket{full: now.Add(time.Minute)}
+	store.sweep(now)
+
+	if _, ok := store.buckets["full"]; ok {
+		t.Error("full bucket was kept")
+	}
+
+	if _, ok := store.buckets["draining"]; !ok {
+		t.Error("draining bucket was dropped")
+	}
+}
+
+func TestLimitEnabled(t *testing.T) {
+	for _, tt := range []struct {
+		limit Limit
+		want  bool
+	}{
+		{Limit{Requests: 5, Period: time.Minute}, true},
+		{Limit{Period: time.Minute}, false},
+		{Limit{Requests: 5}, false},
+		{Limit{}, false},
+	} {
+		if got := tt.limit.Enabled(); got != tt.want {
+			t.Errorf("%+v.Enabled() = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	if store, err := NewStore("memory", ""); err != nil {
+		t.Errorf("NewStore(memory) error = %v", err)
+	} else if _, ok := store.(*MemoryStore); !ok {
+		t.Errorf("NewStore(memory) = %T, want *MemoryStore", store)
+	}
+
+	if _, err := NewStore("memcached", ""); err == nil {
+		t.Error("NewStore(memcached) error = nil, want unknown driver")
+	}
+}
@@ -0,0 +1,92 @@
+// Package ratelimit implements token-bucket rate limiting with a Redis store
+// for multi-instance deployments and an in-memory store for a single process.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit allows Requests per Period on average, with bursts of up to Burst
+// requests. A zero Burst defaults to Requests; a zero Limit disables limiting.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+
+	return float64(l.Requests)
+}
+
+// ratePerSecond is the bucket's refill rate in tokens per second.
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result describes the bucket after a request was counted against it.
+type Result struct {
+	Allowed bool
+	// Limit is the bucket capacity.
+	Limit int
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed; zero when
+	// Allowed is set.
+	RetryAfter time.Duration
+}
+
+// Store takes one token from the bucket identified by key.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// NewStore returns the store for driver: "redis" (or empty) connects to
+// redisAddr, "memory" keeps buckets in process.
+func NewStore(driver, redisAddr string) (Store, error) {
+	switch driver {
+	case "", "redis":
+		return NewRedisStore(redis.NewClient(&redis.Options{Addr: redisAddr})), nil
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit driver %q", driver)
+	}
+}
+
+// result builds a Result from the tokens left in the bucket.
+func result(limit Limit, allowed bool, tokens float64) Result {
+	capacity := limit.capacity()
+	rate := limit.ratePerSecond()
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     int(capacity),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((capacity - tokens) / rate),
+	}
+
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+)
+
+// apiKeyTouchInterval limits how often last_used_at is written for a key that
+// is in constant use.
+const apiKeyTouchInterval = time.Minute
+
+// mysqlAPIKeyRepository stores signing secrets encrypted with cipher.
+type mysqlAPIKeyRepository struct {
+	db     DBTX
+	cipher *fieldcrypt.Cipher
+}
+
+func NewAPIKeyRepository(db DBTX, cipher *fieldcrypt.Cipher) domain.APIKeyRepository {
+	return &mysqlAPIKeyRepository{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, signing_secret, scopes, allowed_ips, require_signature,
+	expires_at, last_used_at, revoked_at, created_at`
+
+func (r *mysqlAPIKeyRepository) scan(row rowScanner) (*domain.APIKey, error) {
+	key, err := scanAPIKey(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.SigningSecret, err = r.cipher.Decrypt(key.SigningSecret); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var scopes, allowedIPs []byte
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.SigningSecret,
+		&scopes,
+		&allowedIPs,
+		&key.RequireSignature,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(allowedIPs, &key.AllowedIPs); err != nil {
+		return nil, err
+	}
+
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+
+	return &key, nil
+}
+
+func (r *mysqlAPIKeyRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+
+	allowedIPs, err := json.Marshal(key.AllowedIPs)
+	if err != nil {
+		return err
+	}
+
+	secret, err := r.cipher.Encrypt(key.SigningSecret)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, signing_secret, scopes, allowed_ips, require_signature, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, key.UserID, key.Name, key.Prefix, key.KeyHash, secret, scopes, allowedIPs,
+		key.RequireSignature, key.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	key.ID = id
+
+	return nil
+}
+
+func (r *mysqlAPIKeyRepository) GetAPIKeyByID(ctx context.Context, id int64) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+
+	return r.getAPIKey(ctx, query, id)
+}
+
+func (r *mysqlAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = ?`
+
+	return r.getAPIKey(ctx, query, prefix)
+}
+
+func (r *mysqlAPIKeyRepository) getAPIKey(ctx context.Context, query string, args ...any) (*domain.APIKey, error) {
+	key, err := r.scan(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *mysqlAPIKeyRepository) ListAPIKeysByUser(ctx context.Context, userID int64) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// CountActiveAPIKeys counts the user's keys that are neither revoked nor
+// expired at now.
+func (r *mysqlAPIKeyRepository) CountActiveAPIKeys(ctx context.Context, userID int64, now time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM api_keys
+		WHERE user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID, now).Scan(&count)
+
+	return count, err
+}
+
+func (r *mysqlAPIKeyRepository) UpdateAPIKeySigningSecret(ctx context.Context, id int64, secret string) error {
+	encrypted, err := r.cipher.Encrypt(secret)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE api_keys SET signing_secret = ? WHERE id = ?"
+	_, err = r.db.ExecContext(ctx, query, encrypted, id)
+
+	return err
+}
+
+// RevokeAPIKey revokes the key unless it already is.
+func (r *mysqlAPIKeyRepository) RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error {
+	query := "UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL"
+	_, err := r.db.ExecContext(ctx, query, revokedAt, id)
+
+	return err
+}
+
+func (r *mysqlAPIKeyRepository) RevokeUserAPIKeys(ctx context.Context, userID int64, revokedAt time.Time) error {
+	query := "UPDATE api_keys SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL"
+	_, err := r.db.ExecContext(ctx, query, revokedAt, userID)
+
+	return err
+}
+
+// TouchAPIKey records that the key was used at usedAt. The write is skipped
+// if the recorded time is less than apiKeyTouchInterval old.
+func (r *mysqlAPIKeyRepository) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	query := "UPDATE api_keys SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)"
+	_, err := r.db.ExecContext(ctx, query, usedAt, id, usedAt.Add(-apiKeyTouchInterval))
+
+	return err
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+)
+
+// mysqlBankBeneficiaryRepository stores the account holder name and account
+// number encrypted with cipher.
+type mysqlBankBeneficiaryRepository struct {
+	db     DBTX
+	cipher *fieldcrypt.Cipher
+}
+
+func NewBankBeneficiaryRepository(db DBTX, cipher *fieldcrypt.Cipher) domain.BankBeneficiaryRepository {
+	return &mysqlBankBeneficiaryRepository{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+const bankBeneficiaryColumns = `id, user_id, nickname, account_holder_name, country, currency, account_type, account_number,
+	account_last4, routing_code, deleted_at, created_at, updated_at`
+
+func scanBankBeneficiary(row rowScanner) (*domain.BankBeneficiary, error) {
+	var beneficiary domain.BankBeneficiary
+	var deletedAt sql.NullTime
+
+	err := row.Scan(
+		&beneficiary.ID,
+		&beneficiary.UserID,
+		&beneficiary.Nickname,
+		&beneficiary.AccountHolderName,
+		&beneficiary.Country,
+		&beneficiary.Currency,
+		&beneficiary.AccountType,
+		&beneficiary.AccountNumber,
+		&beneficiary.AccountLast4,
+		&beneficiary.RoutingCode,
+		&deletedAt,
+		&beneficiary.CreatedAt,
+		&beneficiary.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	beneficiary.DeletedAt = nullTimePtr(deletedAt)
+
+	return &beneficiary, nil
+}
+
+func (r *mysqlBankBeneficiaryRepository) scan(row rowScanner) (*domain.BankBeneficiary, error) {
+	beneficiary, err := scanBankBeneficiary(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if beneficiary.AccountHolderName, err = r.cipher.Decrypt(beneficiary.AccountHolderName); err != nil {
+		return nil, err
+	}
+
+	if beneficiary.AccountNumber, err = r.cipher.Decrypt(beneficiary.AccountNumber); err != nil {
+		return nil, err
+	}
+
+	return beneficiary, nil
+}
+
+func (r *mysqlBankBeneficiaryRepository) CreateBankBeneficiary(ctx context.Context, beneficiary *domain.BankBeneficiary) error {
+	holderName, err := r.cipher.Encrypt(beneficiary.AccountHolderName)
+	if err != nil {
+		return err
+	}
+
+	accountNumber, err := r.cipher.Encrypt(beneficiary.AccountNumber)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO bank_beneficiaries (user_id, nickname, account_holder_name, country, currency, account_type,
+			account_number, account_last4, routing_code)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, beneficiary.UserID, beneficiary.Nickname, holderName, beneficiary.Country,
+		beneficiary.Currency, beneficiary.AccountType, accountNumber, beneficiary.AccountLast4, beneficiary.RoutingCode)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	beneficiary.ID = id
+
+	return nil
+}
+
+// GetBankBeneficiaryByID returns the beneficiary even if it was deleted, so
+// that withdrawals already made to it can still be paid out.
+func (r *mysqlBankBeneficiaryRepository) GetBankBeneficiaryByID(ctx context.Context, id int64) (*domain.BankBeneficiary, error) {
+	query := `SELECT ` + bankBeneficiaryColumns + ` FROM bank_beneficiaries WHERE id = ?`
+
+	beneficiary, err := r.scan(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return beneficiary, nil
+}
+
+func (r *mysqlBankBeneficiaryRepository) ListBankBeneficiariesByUser(ctx context.Context, userID int64) ([]*domain.BankBeneficiary, error) {
+	query := `SELECT ` + bankBeneficiaryColumns + ` FROM bank_beneficiaries WHERE user_id = ? AND deleted_at IS NULL ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var beneficiaries []*domain.BankBeneficiary
+	for rows.Next() {
+		beneficiary, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		beneficiaries = append(beneficiaries, beneficiary)
+	}
+
+	return beneficiaries, rows.Err()
+}
+
+func (r *mysqlBankBeneficiaryRepository) DeleteBankBeneficiary(ctx context.Context, id int64, deletedAt time.Time) error {
+	query := `UPDATE bank_beneficiaries SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, deletedAt, id)
+
+	return err
+}
+
+// EraseBankBeneficiaries deletes the user's beneficiaries and blanks their
+// holder names and account numbers. The rows stay as the destinations of past
+// withdrawals.
+func (r *mysqlBankBeneficiaryRepository) EraseBankBeneficiaries(ctx context.Context, userID int64, erasedAt time.Time) error {
+	query := `
+		UPDATE bank_beneficiaries
+		SET nickname = '', account_holder_name = '', account_number = '', deleted_at = COALESCE(deleted_at, ?)
+		WHERE user_id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, erasedAt, userID)
+
+	return err
+}
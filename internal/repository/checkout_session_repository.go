@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlCheckoutSessionRepository struct {
+	db DBTX
+}
+
+func NewCheckoutSessionRepository(db DBTX) domain.CheckoutSessionRepository {
+	return &mysqlCheckoutSessionRepository{
+		db: db,
+	}
+}
+
+const checkoutSessionColumns = `id, merchant_id, token, amount, currency, reference, description, success_url, cancel_url,
+	status, payer_user_id, transaction_id, failure_reason, expires_at, completed_at, created_at, updated_at`
+
+func scanCheckoutSession(row rowScanner) (*domain.CheckoutSession, error) {
+	var session domain.CheckoutSession
+	var payerUserID, transactionID sql.NullInt64
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID,
+		&session.MerchantID,
+		&session.Token,
+		&session.Amount,
+		&session.Currency,
+		&session.Reference,
+		&session.Description,
+		&session.SuccessURL,
+		&session.CancelURL,
+		&session.Status,
+		&payerUserID,
+		&transactionID,
+		&session.FailureReason,
+		&session.ExpiresAt,
+		&completedAt,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if payerUserID.Valid {
+		session.PayerUserID = &payerUserID.Int64
+	}
+
+	if transactionID.Valid {
+		session.TransactionID = &transactionID.Int64
+	}
+
+	session.CompletedAt = nullTimePtr(completedAt)
+
+	return &session, nil
+}
+
+func (r *mysqlCheckoutSessionRepository) CreateCheckoutSession(ctx context.Context, session *domain.CheckoutSession) error {
+	query := `
+		INSERT INTO checkout_sessions (merchant_id, token, amount, currency, reference, description, success_url, cancel_url,
+			status, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, session.MerchantID, session.Token, session.Amount, session.Currency,
+		session.Reference, session.Description, session.SuccessURL, session.CancelURL, session.Status, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	session.ID = id
+
+	return nil
+}
+
+func (r *mysqlCheckoutSessionRepository) GetCheckoutSessionByID(ctx context.Context, id int64) (*domain.CheckoutSession, error) {
+	query := `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlCheckoutSessionRepository) GetCheckoutSessionByToken(ctx context.Context, token string) (*domain.CheckoutSession, error) {
+	query := `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE token = ?`
+
+	return r.get(ctx, query, token)
+}
+
+func (r *mysqlCheckoutSessionRepository) GetCheckoutSessionByReference(ctx context.Context, merchantID int64, reference string) (*domain.CheckoutSession, error) {
+	query := `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE merchant_id = ? AND reference = ?`
+
+	return r.get(ctx, query, merchantID, reference)
+}
+
+func (r *mysqlCheckoutSessionRepository) GetCheckoutSessionForUpdate(ctx context.Context, id int64) (*domain.CheckoutSession, error) {
+	query := `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE id = ? FOR UPDATE`
+
+	return r.get(ctx, query, id)
+}
+
+// GetCheckoutSessionByTransactionForUpdate locks the session paid by the
+// transaction, if there is one.
+func (r *mysqlCheckoutSessionRepository) GetCheckoutSessionByTransactionForUpdate(ctx context.Context, transactionID int64) (*domain.CheckoutSession, error) {
+	query := `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE transaction_id = ? FOR UPDATE`
+
+	return r.get(ctx, query, transactionID)
+}
+
+func (r *mysqlCheckoutSessionRepository) get(ctx context.Context, query string, args ...any) (*domain.CheckoutSession, error) {
+	session, err := scanCheckoutSession(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *mysqlCheckoutSessionRepository) ListCheckoutSessionsByMerchant(ctx context.Context, merchantID int64) ([]*domain.CheckoutSession, error) {
+	query := `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE merchant_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*domain.CheckoutSession
+	for rows.Next() {
+		session, err := scanCheckoutSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *mysqlCheckoutSessionRepository) ListExpiredCheckoutSessionIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM checkout_sessions
+		WHERE status = ? AND expires_at <= ?
+		ORDER BY expires_at
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.CheckoutSessionStatusOpen, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlCheckoutSessionRepository) UpdateCheckoutSession(ctx context.Context, session *domain.CheckoutSession) error {
+	query := `
+		UPDATE checkout_sessions
+		SET status = ?, payer_user_id = ?, transaction_id = ?, failure_reason = ?, completed_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, session.Status, session.PayerUserID, session.TransactionID,
+		session.FailureReason, session.CompletedAt, session.ID)
+
+	return err
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlDataExportRepository struct {
+	db DBTX
+}
+
+func NewDataExportRepository(db DBTX) domain.DataExportRepository {
+	return &mysqlDataExportRepository{
+		db: db,
+	}
+}
+
+const dataExportColumns = `id, user_id, format, status, path, size_bytes, error, completed_at, expires_at, created_at, updated_at`
+
+func scanDataExport(row rowScanner) (*domain.DataExport, error) {
+	var export domain.DataExport
+	var completedAt, expiresAt sql.NullTime
+
+	err := row.Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Format,
+		&export.Status,
+		&export.Path,
+		&export.SizeBytes,
+		&export.Error,
+		&completedAt,
+		&expiresAt,
+		&export.CreatedAt,
+		&export.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	export.CompletedAt = nullTimePtr(completedAt)
+	export.ExpiresAt = nullTimePtr(expiresAt)
+
+	return &export, nil
+}
+
+func (r *mysqlDataExportRepository) CreateDataExport(ctx context.Context, export *domain.DataExport) error {
+	query := "INSERT INTO data_exports (user_id, format, status) VALUES (?, ?, ?)"
+	result, err := r.db.ExecContext(ctx, query, export.UserID, export.Format, export.Status)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	export.ID = id
+
+	return nil
+}
+
+func (r *mysqlDataExportRepository) GetDataExportByID(ctx context.Context, id int64) (*domain.DataExport, error) {
+	return r.get(ctx, `SELECT `+dataExportColumns+` FROM data_exports WHERE id = ?`, id)
+}
+
+func (r *mysqlDataExportRepository) GetDataExportForUpdate(ctx context.Context, id int64) (*domain.DataExport, error) {
+	return r.get(ctx, `SELECT `+dataExportColumns+` FROM data_exports WHERE id = ? FOR UPDATE`, id)
+}
+
+func (r *mysqlDataExportRepository) get(ctx context.Context, query string, id int64) (*domain.DataExport, error) {
+	export, err := scanDataExport(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (r *mysqlDataExportRepository) ListDataExportsByUser(ctx context.Context, userID int64) ([]*domain.DataExport, error) {
+	query := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []*domain.DataExport
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *mysqlDataExportRepository) ListExpiredDataExportIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM data_exports
+		WHERE status = ? AND expires_at <= ?
+		ORDER BY expires_at
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.DataExportStatusReady, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlDataExportRepository) UpdateDataExport(ctx context.Context, export *domain.DataExport) error {
+	query := `
+		UPDATE data_exports
+		SET status = ?, path = ?, size_bytes = ?, error = ?, completed_at = ?, expires_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, export.Status, export.Path, export.SizeBytes, export.Error,
+		export.CompletedAt, export.ExpiresAt, export.ID)
+
+	return err
+}
+
+func (r *mysqlDataExportRepository) DeleteDataExports(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM data_exports WHERE user_id = ?", userID)
+
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"github.com/amankp-zop/wallet/internal/domain" // Make sure domain is imported
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
 )
 
 // Store defines all the functions to execute db queries and transactions.
@@ -18,19 +19,43 @@ type Store interface {
 	domain.WalletRepository
 	domain.TransactionRepository
 	domain.OutboxRepository
+	domain.ScheduledTransferRepository
+	domain.PaymentRequestRepository
+	domain.HoldRepository
+	domain.WebhookRepository
+	domain.LoginRepository
+	domain.RecoveryCodeRepository
+	domain.UserTokenRepository
+	domain.EmailChangeRepository
+	domain.DataExportRepository
+	domain.APIKeyRepository
+	domain.MerchantRepository
+	domain.CheckoutSessionRepository
+	domain.FeeRepository
+	domain.LedgerRepository
+	domain.DepositRepository
+	domain.BankBeneficiaryRepository
+	domain.WithdrawalRepository
+	domain.PayoutBatchRepository
+	domain.SplitPaymentRepository
+	domain.EscrowRepository
+	domain.DisputeRepository
+	domain.VoucherRepository
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
 type SQLStore struct {
-	db *sql.DB
+	db     *sql.DB
+	cipher *fieldcrypt.Cipher
 	*Queries
 }
 
 // NewStore creates a new store
-func NewStore(db *sql.DB) Store {
+func NewStore(db *sql.DB, cipher *fieldcrypt.Cipher) Store {
 	return &SQLStore{
 		db:      db,
-		Queries: NewQueries(db),
+		cipher:  cipher,
+		Queries: NewQueries(db, cipher),
 	}
 }
 
@@ -41,7 +66,7 @@ func (s *SQLStore) ExecTx(ctx context.Context, fn func(*Queries) error) error {
 		return err
 	}
 
-	q := NewQueries(tx)
+	q := NewQueries(tx, s.cipher)
 	err = fn(q)
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlDepositRepository struct {
+	db DBTX
+}
+
+func NewDepositRepository(db DBTX) domain.DepositRepository {
+	return &mysqlDepositRepository{
+		db: db,
+	}
+}
+
+const depositColumns = `id, user_id, wallet_id, amount, currency, gateway, gateway_reference, payment_url, status,
+	failure_reason, expires_at, completed_at, created_at, updated_at`
+
+func scanDeposit(row rowScanner) (*domain.Deposit, error) {
+	var deposit domain.Deposit
+	var gatewayReference sql.NullString
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&deposit.ID,
+		&deposit.UserID,
+		&deposit.WalletID,
+		&deposit.Amount,
+		&deposit.Currency,
+		&deposit.Gateway,
+		&gatewayReference,
+		&deposit.PaymentURL,
+		&deposit.Status,
+		&deposit.FailureReason,
+		&deposit.ExpiresAt,
+		&completedAt,
+		&deposit.CreatedAt,
+		&deposit.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if gatewayReference.Valid {
+		deposit.GatewayReference = &gatewayReference.String
+	}
+
+	deposit.CompletedAt = nullTimePtr(completedAt)
+
+	return &deposit, nil
+}
+
+func (r *mysqlDepositRepository) CreateDeposit(ctx context.Context, deposit *domain.Deposit) error {
+	query := `
+		INSERT INTO deposits (user_id, wallet_id, amount, currency, gateway, status, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, deposit.UserID, deposit.WalletID, deposit.Amount, deposit.Currency,
+		deposit.Gateway, deposit.Status, deposit.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	deposit.ID = id
+
+	return nil
+}
+
+func (r *mysqlDepositRepository) GetDepositByID(ctx context.Context, id int64) (*domain.Deposit, error) {
+	query := `SELECT ` + depositColumns + ` FROM deposits WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlDepositRepository) GetDepositForUpdate(ctx context.Context, id int64) (*domain.Deposit, error) {
+	query := `SELECT ` + depositColumns + ` FROM deposits WHERE id = ? FOR UPDATE`
+
+	return r.get(ctx, query, id)
+}
+
+// GetDepositByGatewayReferenceForUpdate locks the deposit the gateway knows
+// by reference, if there is one.
+func (r *mysqlDepositRepository) GetDepositByGatewayReferenceForUpdate(ctx context.Context, gateway, reference string) (*domain.Deposit, error) {
+	query := `SELECT ` + depositColumns + ` FROM deposits WHERE gateway = ? AND gateway_reference = ? FOR UPDATE`
+
+	return r.get(ctx, query, gateway, reference)
+}
+
+func (r *mysqlDepositRepository) get(ctx context.Context, query string, args ...any) (*domain.Deposit, error) {
+	deposit, err := scanDeposit(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+func (r *mysqlDepositRepository) ListDepositsByUser(ctx context.Context, userID int64) ([]*domain.Deposit, error) {
+	query := `SELECT ` + depositColumns + ` FROM deposits WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deposits []*domain.Deposit
+	for rows.Next() {
+		deposit, err := scanDeposit(rows)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, deposit)
+	}
+
+	return deposits, rows.Err()
+}
+
+func (r *mysqlDepositRepository) ListExpiredDepositIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM deposits
+		WHERE status = ? AND expires_at <= ?
+		ORDER BY expires_at
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.DepositStatusPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlDepositRepository) UpdateDeposit(ctx context.Context, deposit *domain.Deposit) error {
+	query := `
+		UPDATE deposits
+		SET gateway_reference = ?, payment_url = ?, status = ?, failure_reason = ?, completed_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, deposit.GatewayReference, deposit.PaymentURL, deposit.Status,
+		deposit.FailureReason, deposit.CompletedAt, deposit.ID)
+
+	return err
+}
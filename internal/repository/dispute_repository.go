@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlDisputeRepository struct {
+	db DBTX
+}
+
+func NewDisputeRepository(db DBTX) domain.DisputeRepository {
+	return &mysqlDisputeRepository{
+		db: db,
+	}
+}
+
+const disputeColumns = `id, transaction_id, payer_wallet_id, merchant_wallet_id, amount, currency, reason, status,
+	respond_by, responded_at, resolution_note, resolved_by_user_id, resolved_at, created_at, updated_at`
+
+func scanDispute(row rowScanner) (*domain.Dispute, error) {
+	var dispute domain.Dispute
+	var resolvedByUserID sql.NullInt64
+	var respondedAt, resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&dispute.ID,
+		&dispute.TransactionID,
+		&dispute.PayerWalletID,
+		&dispute.MerchantWalletID,
+		&dispute.Amount,
+		&dispute.Currency,
+		&dispute.Reason,
+		&dispute.Status,
+		&dispute.RespondBy,
+		&respondedAt,
+		&dispute.ResolutionNote,
+		&resolvedByUserID,
+		&resolvedAt,
+		&dispute.CreatedAt,
+		&dispute.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedByUserID.Valid {
+		dispute.ResolvedByUserID = &resolvedByUserID.Int64
+	}
+
+	dispute.RespondedAt = nullTimePtr(respondedAt)
+	dispute.ResolvedAt = nullTimePtr(resolvedAt)
+
+	return &dispute, nil
+}
+
+func (r *mysqlDisputeRepository) CreateDispute(ctx context.Context, dispute *domain.Dispute) error {
+	query := `
+		INSERT INTO disputes (transaction_id, payer_wallet_id, merchant_wallet_id, amount, currency, reason, status,
+			respond_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, dispute.TransactionID, dispute.PayerWalletID, dispute.MerchantWalletID,
+		dispute.Amount, dispute.Currency, dispute.Reason, dispute.Status, dispute.RespondBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	dispute.ID = id
+
+	return nil
+}
+
+func (r *mysqlDisputeRepository) GetDisputeByID(ctx context.Context, id int64) (*domain.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlDisputeRepository) GetDisputeForUpdate(ctx context.Context, id int64) (*domain.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE id = ? FOR UPDATE`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlDisputeRepository) GetDisputeByTransaction(ctx context.Context, transactionID int64) (*domain.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE transaction_id = ?`
+
+	return r.get(ctx, query, transactionID)
+}
+
+func (r *mysqlDisputeRepository) get(ctx context.Context, query string, args ...any) (*domain.Dispute, error) {
+	dispute, err := scanDispute(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// ListDisputesByWallet returns the disputes walletID raised or must answer.
+func (r *mysqlDisputeRepository) ListDisputesByWallet(ctx context.Context, walletID int64) ([]*domain.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + ` FROM disputes
+		WHERE payer_wallet_id = ? OR merchant_wallet_id = ?
+		ORDER BY id DESC
+	`
+
+	return r.list(ctx, query, walletID, walletID)
+}
+
+// ListDisputesByStatus returns the newest limit disputes in status, or in any
+// status when it is empty.
+func (r *mysqlDisputeRepository) ListDisputesByStatus(ctx context.Context, status domain.DisputeStatus, limit int) ([]*domain.Dispute, error) {
+	if status == "" {
+		query := `SELECT ` + disputeColumns + ` FROM disputes ORDER BY id DESC LIMIT ?`
+		return r.list(ctx, query, limit)
+	}
+
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE status = ? ORDER BY id DESC LIMIT ?`
+	return r.list(ctx, query, status, limit)
+}
+
+func (r *mysqlDisputeRepository) list(ctx context.Context, query string, args ...any) ([]*domain.Dispute, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disputes []*domain.Dispute
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, dispute)
+	}
+
+	return disputes, rows.Err()
+}
+
+// ListOverdueDisputeIDs returns OPEN disputes whose respond_by has passed.
+func (r *mysqlDisputeRepository) ListOverdueDisputeIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM disputes
+		WHERE status = ? AND respond_by <= ?
+		ORDER BY respond_by
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.DisputeStatusOpen, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlDisputeRepository) UpdateDispute(ctx context.Context, dispute *domain.Dispute) error {
+	query := `
+		UPDATE disputes
+		SET status = ?, responded_at = ?, resolution_note = ?, resolved_by_user_id = ?, resolved_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, dispute.Status, dispute.RespondedAt, dispute.ResolutionNote,
+		dispute.ResolvedByUserID, dispute.ResolvedAt, dispute.ID)
+
+	return err
+}
+
+func (r *mysqlDisputeRepository) CreateDisputeEvidence(ctx context.Context, evidence *domain.DisputeEvidence) error {
+	fileRefs, err := json.Marshal(evidence.FileRefs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO dispute_evidence (dispute_id, user_id, party, text, file_refs)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, evidence.DisputeID, evidence.UserID, evidence.Party, evidence.Text, fileRefs)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	evidence.ID = id
+
+	return nil
+}
+
+func (r *mysqlDisputeRepository) ListDisputeEvidence(ctx context.Context, disputeID int64) ([]*domain.DisputeEvidence, error) {
+	query := `
+		SELECT id, dispute_id, user_id, party, text, file_refs, created_at
+		FROM dispute_evidence WHERE dispute_id = ? ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var evidence []*domain.DisputeEvidence
+	for rows.Next() {
+		var item domain.DisputeEvidence
+		var fileRefs []byte
+		err := rows.Scan(&item.ID, &item.DisputeID, &item.UserID, &item.Party, &item.Text, &fileRefs, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(fileRefs, &item.FileRefs); err != nil {
+			return nil, err
+		}
+
+		evidence = append(evidence, &item)
+	}
+
+	return evidence, rows.Err()
+}
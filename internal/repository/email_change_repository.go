@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+)
+
+// mysqlEmailChangeRepository stores both addresses of a change encrypted with
+// cipher.
+type mysqlEmailChangeRepository struct {
+	db     DBTX
+	cipher *fieldcrypt.Cipher
+}
+
+func NewEmailChangeRepository(db DBTX, cipher *fieldcrypt.Cipher) domain.EmailChangeRepository {
+	return &mysqlEmailChangeRepository{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+const emailChangeColumns = `id, user_id, old_email, new_email, status, old_confirmed_at, new_confirmed_at, expires_at, created_at, updated_at`
+
+func scanEmailChange(row rowScanner) (*domain.EmailChange, error) {
+	var change domain.EmailChange
+	var oldConfirmedAt, newConfirmedAt sql.NullTime
+
+	err := row.Scan(
+		&change.ID,
+		&change.UserID,
+		&change.OldEmail,
+		&change.NewEmail,
+		&change.Status,
+		&oldConfirmedAt,
+		&newConfirmedAt,
+		&change.ExpiresAt,
+		&change.CreatedAt,
+		&change.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	change.OldConfirmedAt = nullTimePtr(oldConfirmedAt)
+	change.NewConfirmedAt = nullTimePtr(newConfirmedAt)
+
+	return &change, nil
+}
+
+func (r *mysqlEmailChangeRepository) scan(row rowScanner) (*domain.EmailChange, error) {
+	change, err := scanEmailChange(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.OldEmail, err = r.cipher.Decrypt(change.OldEmail); err != nil {
+		return nil, err
+	}
+
+	if change.NewEmail, err = r.cipher.Decrypt(change.NewEmail); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (r *mysqlEmailChangeRepository) CreateEmailChange(ctx context.Context, change *domain.EmailChange) error {
+	oldEmail, err := r.cipher.Encrypt(change.OldEmail)
+	if err != nil {
+		return err
+	}
+
+	newEmail, err := r.cipher.Encrypt(change.NewEmail)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO email_changes (user_id, old_email, new_email, status, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, change.UserID, oldEmail, newEmail, change.Status, change.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	change.ID = id
+
+	return nil
+}
+
+func (r *mysqlEmailChangeRepository) GetPendingEmailChange(ctx context.Context, userID int64) (*domain.EmailChange, error) {
+	return r.getPendingEmailChange(ctx, userID, "")
+}
+
+func (r *mysqlEmailChangeRepository) GetPendingEmailChangeForUpdate(ctx context.Context, userID int64) (*domain.EmailChange, error) {
+	return r.getPendingEmailChange(ctx, userID, " FOR UPDATE")
+}
+
+func (r *mysqlEmailChangeRepository) getPendingEmailChange(ctx context.Context, userID int64, lock string) (*domain.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE user_id = ? AND status = ? ORDER BY id DESC LIMIT 1` + lock
+
+	change, err := r.scan(r.db.QueryRowContext(ctx, query, userID, domain.EmailChangeStatusPending))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (r *mysqlEmailChangeRepository) UpdateEmailChange(ctx context.Context, change *domain.EmailChange) error {
+	query := `
+		UPDATE email_changes
+		SET status = ?, old_confirmed_at = ?, new_confirmed_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, change.Status, change.OldConfirmedAt, change.NewConfirmedAt, change.ID)
+
+	return err
+}
+
+func (r *mysqlEmailChangeRepository) ListEmailChangesByUser(ctx context.Context, userID int64) ([]*domain.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*domain.EmailChange
+	for rows.Next() {
+		change, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func (r *mysqlEmailChangeRepository) DeleteEmailChanges(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM email_changes WHERE user_id = ?", userID)
+
+	return err
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlEscrowRepository struct {
+	db DBTX
+}
+
+func NewEscrowRepository(db DBTX) domain.EscrowRepository {
+	return &mysqlEscrowRepository{
+		db: db,
+	}
+}
+
+const escrowColumns = `id, payer_wallet_id, payee_wallet_id, arbiter_user_id, escrow_wallet_id, amount, currency, description,
+	status, release_after, disputed_by_user_id, dispute_reason, disputed_at, settled_by_user_id, settled_at, created_at,
+	updated_at`
+
+func scanEscrow(row rowScanner) (*domain.Escrow, error) {
+	var escrow domain.Escrow
+	var arbiterUserID, disputedByUserID, settledByUserID sql.NullInt64
+	var disputedAt, settledAt sql.NullTime
+
+	err := row.Scan(
+		&escrow.ID,
+		&escrow.PayerWalletID,
+		&escrow.PayeeWalletID,
+		&arbiterUserID,
+		&escrow.EscrowWalletID,
+		&escrow.Amount,
+		&escrow.Currency,
+		&escrow.Description,
+		&escrow.Status,
+		&escrow.ReleaseAfter,
+		&disputedByUserID,
+		&escrow.DisputeReason,
+		&disputedAt,
+		&settledByUserID,
+		&settledAt,
+		&escrow.CreatedAt,
+		&escrow.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if arbiterUserID.Valid {
+		escrow.ArbiterUserID = &arbiterUserID.Int64
+	}
+
+	if disputedByUserID.Valid {
+		escrow.DisputedByUserID = &disputedByUserID.Int64
+	}
+
+	if settledByUserID.Valid {
+		escrow.SettledByUserID = &settledByUserID.Int64
+	}
+
+	escrow.DisputedAt = nullTimePtr(disputedAt)
+	escrow.SettledAt = nullTimePtr(settledAt)
+
+	return &escrow, nil
+}
+
+// GetEscrowWalletID returns the house wallet holding escrowed funds in
+// currency, and false if none is configured.
+func (r *mysqlEscrowRepository) GetEscrowWalletID(ctx context.Context, currency string) (int64, bool, error) {
+	query := "SELECT wallet_id FROM escrow_wallets WHERE currency = ?"
+
+	var walletID int64
+	err := r.db.QueryRowContext(ctx, query, currency).Scan(&walletID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return walletID, true, nil
+}
+
+func (r *mysqlEscrowRepository) CreateEscrow(ctx context.Context, escrow *domain.Escrow) error {
+	query := `
+		INSERT INTO escrows (payer_wallet_id, payee_wallet_id, arbiter_user_id, escrow_wallet_id, amount, currency,
+			description, status, release_after)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, escrow.PayerWalletID, escrow.PayeeWalletID, escrow.ArbiterUserID,
+		escrow.EscrowWalletID, escrow.Amount, escrow.Currency, escrow.Description, escrow.Status, escrow.ReleaseAfter)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	escrow.ID = id
+
+	return nil
+}
+
+func (r *mysqlEscrowRepository) GetEscrowByID(ctx context.Context, id int64) (*domain.Escrow, error) {
+	query := `SELECT ` + escrowColumns + ` FROM escrows WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlEscrowRepository) GetEscrowForUpdate(ctx context.Context, id int64) (*domain.Escrow, error) {
+	query := `SELECT ` + escrowColumns + ` FROM escrows WHERE id = ? FOR UPDATE`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlEscrowRepository) get(ctx context.Context, query string, args ...any) (*domain.Escrow, error) {
+	escrow, err := scanEscrow(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return escrow, nil
+}
+
+// ListEscrowsByParty returns the escrows paid from or to walletID and those
+// userID arbitrates.
+func (r *mysqlEscrowRepository) ListEscrowsByParty(ctx context.Context, walletID, userID int64) ([]*domain.Escrow, error) {
+	query := `
+		SELECT ` + escrowColumns + ` FROM escrows
+		WHERE payer_wallet_id = ? OR payee_wallet_id = ? OR arbiter_user_id = ?
+		ORDER BY id DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, walletID, walletID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var escrows []*domain.Escrow
+	for rows.Next() {
+		escrow, err := scanEscrow(rows)
+		if err != nil {
+			return nil, err
+		}
+		escrows = append(escrows, escrow)
+	}
+
+	return escrows, rows.Err()
+}
+
+// ListDueEscrowIDs returns FUNDED escrows whose release_after has passed.
+func (r *mysqlEscrowRepository) ListDueEscrowIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM escrows
+		WHERE status = ? AND release_after <= ?
+		ORDER BY release_after
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.EscrowStatusFunded, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlEscrowRepository) UpdateEscrow(ctx context.Context, escrow *domain.Escrow) error {
+	query := `
+		UPDATE escrows
+		SET status = ?, disputed_by_user_id = ?, dispute_reason = ?, disputed_at = ?, settled_by_user_id = ?, settled_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, escrow.Status, escrow.DisputedByUserID, escrow.DisputeReason, escrow.DisputedAt,
+		escrow.SettledByUserID, escrow.SettledAt, escrow.ID)
+
+	return err
+}
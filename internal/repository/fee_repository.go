@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlFeeRepository struct {
+	db DBTX
+}
+
+func NewFeeRepository(db DBTX) domain.FeeRepository {
+	return &mysqlFeeRepository{
+		db: db,
+	}
+}
+
+const feeScheduleColumns = `id, plan, merchant_id, currency, type, flat_amount, percentage, tiers, min_fee, max_fee,
+	active, created_at, updated_at`
+
+func scanFeeSchedule(row rowScanner) (*domain.FeeSchedule, error) {
+	var schedule domain.FeeSchedule
+	var plan, currency sql.NullString
+	var merchantID sql.NullInt64
+	var tiers []byte
+
+	err := row.Scan(
+		&schedule.ID,
+		&plan,
+		&merchantID,
+		&currency,
+		&schedule.Type,
+		&schedule.FlatAmount,
+		&schedule.Percentage,
+		&tiers,
+		&schedule.MinFee,
+		&schedule.MaxFee,
+		&schedule.Active,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Valid {
+		schedule.Plan = &plan.String
+	}
+
+	if merchantID.Valid {
+		schedule.MerchantID = &merchantID.Int64
+	}
+
+	if currency.Valid {
+		schedule.Currency = &currency.String
+	}
+
+	if tiers != nil {
+		if err := json.Unmarshal(tiers, &schedule.Tiers); err != nil {
+			return nil, err
+		}
+	}
+
+	return &schedule, nil
+}
+
+// ListActiveFeeSchedules returns the active schedules of the plan together
+// with those of the merchant, if merchantID is set.
+func (r *mysqlFeeRepository) ListActiveFeeSchedules(ctx context.Context, plan string, merchantID *int64) ([]*domain.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules
+		WHERE active = TRUE AND ((merchant_id IS NULL AND plan = ?) OR merchant_id = ?)
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, plan, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*domain.FeeSchedule
+	for rows.Next() {
+		schedule, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// GetRevenueWalletID returns the house wallet collecting fees in currency,
+// and false if none is configured.
+func (r *mysqlFeeRepository) GetRevenueWalletID(ctx context.Context, currency string) (int64, bool, error) {
+	query := "SELECT wallet_id FROM revenue_wallets WHERE currency = ?"
+
+	var walletID int64
+	err := r.db.QueryRowContext(ctx, query, currency).Scan(&walletID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return walletID, true, nil
+}
+
+func (r *mysqlFeeRepository) CreateFeeSchedule(ctx context.Context, schedule *domain.FeeSchedule) error {
+	var tiers []byte
+	if schedule.Tiers != nil {
+		encoded, err := json.Marshal(schedule.Tiers)
+		if err != nil {
+			return err
+		}
+		tiers = encoded
+	}
+
+	query := `
+		INSERT INTO fee_schedules (plan, merchant_id, currency, type, flat_amount, percentage, tiers, min_fee, max_fee, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, schedule.Plan, schedule.MerchantID, schedule.Currency, schedule.Type,
+		schedule.FlatAmount, schedule.Percentage, tiers, schedule.MinFee, schedule.MaxFee, schedule.Active)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	schedule.ID = id
+
+	return nil
+}
+
+func (r *mysqlFeeRepository) GetFeeScheduleByID(ctx context.Context, id int64) (*domain.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules WHERE id = ?`
+
+	schedule, err := scanFeeSchedule(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// ListFeeSchedules returns every schedule, active or not, newest first.
+func (r *mysqlFeeRepository) ListFeeSchedules(ctx context.Context) ([]*domain.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*domain.FeeSchedule
+	for rows.Next() {
+		schedule, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (r *mysqlFeeRepository) SetFeeScheduleActive(ctx context.Context, id int64, active bool) error {
+	query := "UPDATE fee_schedules SET active = ? WHERE id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, active, id)
+	return err
+}
+
+func (r *mysqlFeeRepository) ListRevenueWallets(ctx context.Context) ([]*domain.RevenueWallet, error) {
+	query := "SELECT currency, wallet_id FROM revenue_wallets ORDER BY currency"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revenueWallets []*domain.RevenueWallet
+	for rows.Next() {
+		var revenueWallet domain.RevenueWallet
+		if err := rows.Scan(&revenueWallet.Currency, &revenueWallet.WalletID); err != nil {
+			return nil, err
+		}
+		revenueWallets = append(revenueWallets, &revenueWallet)
+	}
+
+	return revenueWallets, rows.Err()
+}
+
+// UpsertRevenueWallet points the currency's fees at a new wallet, or
+// configures the currency if it has none.
+func (r *mysqlFeeRepository) UpsertRevenueWallet(ctx context.Context, revenueWallet *domain.RevenueWallet) error {
+	query := `
+		INSERT INTO revenue_wallets (currency, wallet_id) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE wallet_id = VALUES(wallet_id)
+	`
+	_, err := r.db.ExecContext(ctx, query, revenueWallet.Currency, revenueWallet.WalletID)
+	return err
+}
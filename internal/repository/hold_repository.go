@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlHoldRepository struct {
+	db DBTX
+}
+
+func NewHoldRepository(db DBTX) domain.HoldRepository {
+	return &mysqlHoldRepository{
+		db: db,
+	}
+}
+
+const holdColumns = `id, wallet_id, payee_wallet_id, amount, captured_amount, reference, status, transaction_id,
+	expires_at, created_at, updated_at`
+
+func scanHold(row rowScanner) (*domain.Hold, error) {
+	var hold domain.Hold
+	var transactionID sql.NullInt64
+
+	err := row.Scan(
+		&hold.ID,
+		&hold.WalletID,
+		&hold.PayeeWalletID,
+		&hold.Amount,
+		&hold.CapturedAmount,
+		&hold.Reference,
+		&hold.Status,
+		&transactionID,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if transactionID.Valid {
+		hold.TransactionID = &transactionID.Int64
+	}
+
+	return &hold, nil
+}
+
+func (r *mysqlHoldRepository) CreateHold(ctx context.Context, hold *domain.Hold) error {
+	query := `
+		INSERT INTO holds (wallet_id, payee_wallet_id, amount, reference, status, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, hold.WalletID, hold.PayeeWalletID, hold.Amount, hold.Reference, hold.Status, hold.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	hold.ID = id
+
+	return nil
+}
+
+func (r *mysqlHoldRepository) GetHoldByID(ctx context.Context, id int64) (*domain.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE id = ?`
+
+	hold, err := scanHold(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (r *mysqlHoldRepository) GetHoldForUpdate(ctx context.Context, id int64) (*domain.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE id = ? FOR UPDATE`
+
+	hold, err := scanHold(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// ListHoldsByWallet returns holds placed on the wallet or payable to it.
+func (r *mysqlHoldRepository) ListHoldsByWallet(ctx context.Context, walletID int64) ([]*domain.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE wallet_id = ? OR payee_wallet_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, walletID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []*domain.Hold
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, rows.Err()
+}
+
+func (r *mysqlHoldRepository) ListExpiredHoldIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM holds
+		WHERE status = ? AND expires_at <= ?
+		ORDER BY expires_at
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.HoldStatusAuthorized, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlHoldRepository) UpdateHold(ctx context.Context, hold *domain.Hold) error {
+	query := "UPDATE holds SET captured_amount = ?, status = ?, transaction_id = ? WHERE id = ?"
+	_, err := r.db.ExecContext(ctx, query, hold.CapturedAmount, hold.Status, hold.TransactionID, hold.ID)
+
+	return err
+}
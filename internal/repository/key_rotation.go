@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+)
+
+// encryptedTable lists the encrypted columns of a table. blindIndexes maps a
+// column to the column holding its blind index.
+type encryptedTable struct {
+	name         string
+	columns      []string
+	blindIndexes map[string]string
+	// keepUpdatedAt stops MySQL from bumping an ON UPDATE updated_at column,
+	// which would invalidate the ETags derived from it.
+	keepUpdatedAt bool
+}
+
+var encryptedTables = []encryptedTable{
+	{
+		name:          "users",
+		columns:       []string{"name", "email", "phone"},
+		blindIndexes:  map[string]string{"email": "email_bidx"},
+		keepUpdatedAt: true,
+	},
+	{
+		name:          "email_changes",
+		columns:       []string{"old_email", "new_email"},
+		keepUpdatedAt: true,
+	},
+	{
+		name:    "user_tokens",
+		columns: []string{"email"},
+	},
+	{
+		name:    "api_keys",
+		columns: []string{"signing_secret"},
+	},
+	{
+		name:    "bank_beneficiaries",
+		columns: []string{"account_holder_name", "account_number"},
+	},
+}
+
+// EncryptedTables returns the names of the tables holding encrypted columns.
+func EncryptedTables() []string {
+	names := make([]string, len(encryptedTables))
+	for i, t := range encryptedTables {
+		names[i] = t.name
+	}
+	return names
+}
+
+// RotationResult counts the rows a key rotation looked at and rewrote.
+type RotationResult struct {
+	Scanned   int
+	Rewritten int
+}
+
+// KeyRotator re-encrypts the encrypted columns under the current KEK. Rows
+// are processed in batches, each locked and rewritten in its own transaction,
+// so rotation can run while the API is serving traffic and can be restarted
+// at any point.
+type KeyRotator struct {
+	db     *sql.DB
+	cipher *fieldcrypt.Cipher
+	// Decrypt writes plaintext back instead, to prepare for rolling back the
+	// encryption migration.
+	Decrypt bool
+}
+
+func NewKeyRotator(db *sql.DB, cipher *fieldcrypt.Cipher) *KeyRotator {
+	return &KeyRotator{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+// RotateTable rewrites every row of table whose values are plaintext, wrapped
+// with an old KEK or missing their blind index, batchSize rows at a time.
+func (k *KeyRotator) RotateTable(ctx context.Context, table string, batchSize int) (RotationResult, error) {
+	var result RotationResult
+
+	var spec *encryptedTable
+	for i := range encryptedTables {
+		if encryptedTables[i].name == table {
+			spec = &encryptedTables[i]
+		}
+	}
+	if spec == nil {
+		return result, fmt.Errorf("table %q has no encrypted columns", table)
+	}
+
+	var lastID int64
+	for {
+		scanned, rewritten, nextID, err := k.rotateBatch(ctx, spec, lastID, batchSize)
+		result.Scanned += scanned
+		result.Rewritten += rewritten
+		if err != nil {
+			return result, err
+		}
+
+		if scanned < batchSize {
+			return result, nil
+		}
+		lastID = nextID
+	}
+}
+
+func (k *KeyRotator) rotateBatch(ctx context.Context, spec *encryptedTable, afterID int64, batchSize int) (scanned, rewritten int, lastID int64, err error) {
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer tx.Rollback()
+
+	columns := append([]string{"id"}, spec.columns...)
+	for _, column := range spec.columns {
+		if bidx, ok := spec.blindIndexes[column]; ok {
+			columns = append(columns, bidx)
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id > ? ORDER BY id LIMIT ? FOR UPDATE",
+		strings.Join(columns, ", "), spec.name)
+
+	rows, err := tx.QueryContext(ctx, query, afterID, batchSize)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	type row struct {
+		id     int64
+		values []string
+		bidx   []sql.NullString
+	}
+
+	var batch []row
+	for rows.Next() {
+		r := row{
+			values: make([]string, len(spec.columns)),
+			bidx:   make([]sql.NullString, len(columns)-len(spec.columns)-1),
+		}
+
+		dest := []any{&r.id}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		for i := range r.bidx {
+			dest = append(dest, &r.bidx[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return 0, 0, 0, err
+		}
+		batch = append(batch, r)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, 0, 0, err
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	set := make([]string, 0, len(columns))
+	for _, column := range columns[1:] {
+		set = append(set, column+" = ?")
+	}
+	if spec.keepUpdatedAt {
+		set = append(set, "updated_at = updated_at")
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?", spec.name, strings.Join(set, ", "))
+
+	for _, r := range batch {
+		lastID = r.id
+
+		if !k.needsRewrite(r.values, r.bidx) {
+			continue
+		}
+
+		args := make([]any, 0, len(columns))
+		var indexes []any
+		for i, column := range spec.columns {
+			plaintext, err := k.cipher.Decrypt(r.values[i])
+			if err != nil {
+				return 0, 0, 0, fmt.Errorf("%s %d: %s: %w", spec.name, r.id, column, err)
+			}
+
+			value := plaintext
+			if !k.Decrypt {
+				if value, err = k.cipher.Encrypt(plaintext); err != nil {
+					return 0, 0, 0, err
+				}
+			}
+			args = append(args, value)
+
+			if _, ok := spec.blindIndexes[column]; ok {
+				indexes = append(indexes, k.cipher.BlindIndex(plaintext))
+			}
+		}
+		args = append(args, indexes...)
+		args = append(args, r.id)
+
+		if _, err := tx.ExecContext(ctx, update, args...); err != nil {
+			return 0, 0, 0, fmt.Errorf("%s %d: %w", spec.name, r.id, err)
+		}
+		rewritten++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	return len(batch), rewritten, lastID, nil
+}
+
+func (k *KeyRotator) needsRewrite(values []string, bidx []sql.NullString) bool {
+	for _, value := range values {
+		if k.Decrypt && fieldcrypt.IsEncrypted(value) {
+			return true
+		}
+		if !k.Decrypt && k.cipher.NeedsRotation(value) {
+			return true
+		}
+	}
+
+	for _, index := range bidx {
+		if !index.Valid {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+)
+
+// memTable is a single table served by a database/sql driver that
+// understands just the SELECT and UPDATE statements KeyRotator issues.
+type memTable struct {
+	rows    map[int64]map[string]any
+	updates []string
+}
+
+func (m *memTable) Connect(context.Context) (driver.Conn, error) { return &memConn{m}, nil }
+func (m *memTable) Driver() driver.Driver                        { return nil }
+
+type memConn struct{ table *memTable }
+
+func (c *memConn) Prepare(query string) (driver.Stmt, error) { return &memStmt{c.table, query}, nil }
+func (c *memConn) Close() error                              { return nil }
+func (c *memConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *memConn) Commit() error                             { return nil }
+func (c *memConn) Rollback() error                           { return nil }
+
+type memStmt struct {
+	table *memTable
+	query string
+}
+
+func (s *memStmt) Close() error  { return nil }
+func (s *memStmt) NumInput() int { return -1 }
+
+// Exec applies "UPDATE t SET a = ?, b = ? WHERE id = ?".
+func (s *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.table.updates = append(s.table.updates, s.query)
+
+	set, _, _ := strings.Cut(strings.SplitN(s.query, " SET ", 2)[1], " WHERE ")
+	row := s.table.rows[args[len(args)-1].(int64)]
+
+	i := 0
+	for _, assignment := range strings.Split(set, ", ") {
+		column, value, _ := strings.Cut(assignment, " = ")
+		if value != "?" {
+			continue
+		}
+		row[column] = args[i]
+		i++
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+// Query serves "SELECT cols FROM t WHERE id > ? ORDER BY id LIMIT ?".
+func (s *memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	list, _, _ := strings.Cut(strings.TrimPrefix(s.query, "SELECT "), " FROM ")
+	columns := strings.Split(list, ", ")
+
+	var ids []int64
+	for id := range s.table.rows {
+		if id > args[0].(int64) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	ids = ids[:min(len(ids), int(args[1].(int64)))]
+
+	rows := &memRows{columns: columns}
+	for _, id := range ids {
+		values := make([]driver.Value, len(columns))
+		for i, column := range columns {
+			if column == "id" {
+				values[i] = id
+			} else {
+				values[i] = s.table.rows[id][column]
+			}
+		}
+		rows.values = append(rows.values, values)
+	}
+
+	return rows, nil
+}
+
+type memRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *memRows) Columns() []string { return r.columns }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func testCipher(t *testing.T, current string, keks ...string) *fieldcrypt.Cipher {
+	t.Helper()
+
+	cfg := fieldcrypt.Config{
+		CurrentKEK: current,
+		KEKs:       make(map[string]string),
+		IndexKey:   base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xff}, 32)),
+	}
+	for _, id := range keks {
+		cfg.KEKs[id] = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte(id[1:]), 32))
+	}
+
+	c, err := fieldcrypt.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+type testUser struct {
+	name, email, phone string
+}
+
+func encryptedUser(t *testing.T, c *fieldcrypt.Cipher, u testUser) map[string]any {
+	t.Helper()
+
+	row := map[string]any{"email_bidx": c.BlindIndex(u.email)}
+	for column, value := range map[string]string{"name": u.name, "email": u.email, "phone": u.phone} {
+		encrypted, err := c.Encrypt(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		row[column] = encrypted
+	}
+
+	return row
+}
+
+func TestRotateTableReencryptsUnderCurrentKEK(t *testing.T) {
+	old := testCipher(t, "k1", "k1")
+	current := testCipher(t, "k2", "k1", "k2")
+
+	users := map[int64]testUser{
+		1: {"Alice", "alice@example.com", "+441234"},
+		2: {"Bob", "Bob@Example.com", ""},
+		3: {"Carol", "carol@example.com", "+445678"},
+		4: {"Dan", "dan@example.com", "+449999"},
+	}
+
+	table := &memTable{rows: map[int64]map[string]any{
+		// Encrypted under the retired KEK.
+		1: encryptedUser(t, old, users[1]),
+		// Written before encryption was enabled.
+		2: {"name": "Bob", "email": "Bob@Example.com", "phone": "", "email_bidx": nil},
+		// Already rotated.
+		3: encryptedUser(t, current, users[3]),
+		4: encryptedUser(t, old, users[4]),
+	}}
+	unchanged := fmt.Sprint(table.rows[3])
+
+	rotator := NewKeyRotator(sql.OpenDB(table), current)
+	result, err := rotator.RotateTable(context.Background(), "users", 3)
+	if err != nil {
+		t.Fatalf("RotateTable() error = %v", err)
+	}
+
+	if result != (RotationResult{Scanned: 4, Rewritten: 3}) {
+		t.Errorf("RotateTable() = %+v, want 4 scanned and 3 rewritten", result)
+	}
+
+	if fmt.Sprint(table.rows[3]) != unchanged {
+		t.Error("a row already under the current KEK was rewritten")
+	}
+
+	for _, update := range table.updates {
+		if !strings.Contains(update, "updated_at = updated_at") {
+			t.Errorf("update %q bumps updated_at", update)
+		}
+	}
+
+	// With the retired KEK removed, every row still decrypts and email
+	// lookups still match by blind index.
+	retired := testCipher(t, "k2", "k2")
+	for id, want := range users {
+		row := table.rows[id]
+		for column, plaintext := range map[string]string{"name": want.name, "email": want.email, "phone": want.phone} {
+			value := row[column].(string)
+			if plaintext != "" && !fieldcrypt.IsEncrypted(value) {
+				t.Errorf("user %d: %s was left in plaintext", id, column)
+			}
+
+			got, err := retired.Decrypt(value)
+			if err != nil || got != plaintext {
+				t.Errorf("user %d: %s = %q, %v, want %q", id, column, got, err, plaintext)
+			}
+		}
+
+		if row["email_bidx"] != retired.BlindIndex(strings.ToUpper(want.email)) {
+			t.Errorf("user %d: email_bidx does not match a lookup by %q", id, want.email)
+		}
+	}
+}
+
+func TestRotateTableDecrypt(t *testing.T) {
+	c := testCipher(t, "k1", "k1")
+	user := testUser{"Alice", "alice@example.com", "+441234"}
+	table := &memTable{rows: map[int64]map[string]any{1: encryptedUser(t, c, user)}}
+
+	rotator := NewKeyRotator(sql.OpenDB(table), c)
+	rotator.Decrypt = true
+
+	result, err := rotator.RotateTable(context.Background(), "users", 10)
+	if err != nil {
+		t.Fatalf("RotateTable() error = %v", err)
+	}
+
+	if result.Rewritten != 1 {
+		t.Errorf("rewritten = %d, want 1", result.Rewritten)
+	}
+
+	row := table.rows[1]
+	if row["name"] != user.name || row["email"] != user.email || row["phone"] != user.phone {
+		t.Errorf("row = %v, want plaintext values", row)
+	}
+}
+
+func TestRotateTableUnknownKEK(t *testing.T) {
+	other := testCipher(t, "k3", "k3")
+	table := &memTable{rows: map[int64]map[string]any{1: encryptedUser(t, other, testUser{"Alice", "alice@example.com", ""})}}
+
+	rotator := NewKeyRotator(sql.OpenDB(table), testCipher(t, "k2", "k1", "k2"))
+	if _, err := rotator.RotateTable(context.Background(), "users", 10); !errors.Is(err, fieldcrypt.ErrUnknownKEK) {
+		t.Errorf("RotateTable() error = %v, want %v", err, fieldcrypt.ErrUnknownKEK)
+	}
+
+	if len(table.updates) != 0 {
+		t.Error("rows were rewritten despite the error")
+	}
+}
+
+func TestRotateTableRejectsUnencryptedTable(t *testing.T) {
+	rotator := NewKeyRotator(sql.OpenDB(&memTable{}), testCipher(t, "k1", "k1"))
+	if _, err := rotator.RotateTable(context.Background(), "wallets", 10); err == nil {
+		t.Error("RotateTable(wallets) error = nil")
+	}
+}
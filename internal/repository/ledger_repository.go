@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlLedgerRepository struct {
+	db DBTX
+}
+
+func NewLedgerRepository(db DBTX) domain.LedgerRepository {
+	return &mysqlLedgerRepository{
+		db: db,
+	}
+}
+
+func (r *mysqlLedgerRepository) CreateLedgerEntry(ctx context.Context, entry *domain.LedgerEntry) error {
+	query := `
+		INSERT INTO ledger_entries (transaction_id, deposit_id, withdrawal_id, escrow_id, dispute_id,
+			voucher_redemption_id, wallet_id, type, amount)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, entry.TransactionID, entry.DepositID, entry.WithdrawalID, entry.EscrowID,
+		entry.DisputeID, entry.VoucherRedemptionID, entry.WalletID, entry.Type, entry.Amount)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+
+	return nil
+}
+
+func (r *mysqlLedgerRepository) ListLedgerEntriesByTransaction(ctx context.Context, transactionID int64) ([]*domain.LedgerEntry, error) {
+	query := `
+		SELECT id, transaction_id, deposit_id, withdrawal_id, escrow_id, dispute_id, voucher_redemption_id, wallet_id, type, amount, created_at
+		FROM ledger_entries WHERE transaction_id = ? ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.LedgerEntry
+	for rows.Next() {
+		var entry domain.LedgerEntry
+		var transactionID, depositID, withdrawalID, escrowID, disputeID, voucherRedemptionID sql.NullInt64
+		err := rows.Scan(&entry.ID, &transactionID, &depositID, &withdrawalID, &escrowID, &disputeID, &voucherRedemptionID,
+			&entry.WalletID, &entry.Type, &entry.Amount, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if transactionID.Valid {
+			entry.TransactionID = &transactionID.Int64
+		}
+
+		if depositID.Valid {
+			entry.DepositID = &depositID.Int64
+		}
+
+		if withdrawalID.Valid {
+			entry.WithdrawalID = &withdrawalID.Int64
+		}
+
+		if escrowID.Valid {
+			entry.EscrowID = &escrowID.Int64
+		}
+
+		if disputeID.Valid {
+			entry.DisputeID = &disputeID.Int64
+		}
+
+		if voucherRedemptionID.Valid {
+			entry.VoucherRedemptionID = &voucherRedemptionID.Int64
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
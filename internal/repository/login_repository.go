@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlLoginRepository struct {
+	db DBTX
+}
+
+func NewLoginRepository(db DBTX) domain.LoginRepository {
+	return &mysqlLoginRepository{
+		db: db,
+	}
+}
+
+func (r *mysqlLoginRepository) CreateLoginEvent(ctx context.Context, event *domain.LoginEvent) error {
+	query := `
+		INSERT INTO login_events (user_id, ip, user_agent, success, new_device)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, event.UserID, event.IP, event.UserAgent, event.Success, event.NewDevice)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	event.ID = id
+
+	return nil
+}
+
+func (r *mysqlLoginRepository) ListLoginEventsByUser(ctx context.Context, userID int64, limit int) ([]*domain.LoginEvent, error) {
+	query := `
+		SELECT id, user_id, ip, user_agent, success, new_device, created_at
+		FROM login_events
+		WHERE user_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.LoginEvent
+	for rows.Next() {
+		var event domain.LoginEvent
+		var eventUserID sql.NullInt64
+
+		err := rows.Scan(&event.ID, &eventUserID, &event.IP, &event.UserAgent, &event.Success, &event.NewDevice, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if eventUserID.Valid {
+			event.UserID = &eventUserID.Int64
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
+
+const loginThrottleColumns = `scope, subject, failures, lockouts, locked_until, last_failure_at`
+
+func scanLoginThrottle(row rowScanner) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+	var lockedUntil, lastFailureAt sql.NullTime
+
+	err := row.Scan(&throttle.Scope, &throttle.Subject, &throttle.Failures, &throttle.Lockouts, &lockedUntil, &lastFailureAt)
+	if err != nil {
+		return nil, err
+	}
+
+	throttle.LockedUntil = nullTimePtr(lockedUntil)
+	throttle.LastFailureAt = nullTimePtr(lastFailureAt)
+
+	return &throttle, nil
+}
+
+func (r *mysqlLoginRepository) GetLoginThrottle(ctx context.Context, scope domain.LoginThrottleScope, subject string) (*domain.LoginThrottle, error) {
+	query := `SELECT ` + loginThrottleColumns + ` FROM login_throttles WHERE scope = ? AND subject = ?`
+
+	throttle, err := scanLoginThrottle(r.db.QueryRowContext(ctx, query, scope, subject))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+func (r *mysqlLoginRepository) GetLoginThrottleForUpdate(ctx context.Context, scope domain.LoginThrottleScope, subject string) (*domain.LoginThrottle, error) {
+	query := `SELECT ` + loginThrottleColumns + ` FROM login_throttles WHERE scope = ? AND subject = ? FOR UPDATE`
+
+	throttle, err := scanLoginThrottle(r.db.QueryRowContext(ctx, query, scope, subject))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+func (r *mysqlLoginRepository) UpsertLoginThrottle(ctx context.Context, throttle *domain.LoginThrottle) error {
+	query := `
+		INSERT INTO login_throttles (scope, subject, failures, lockouts, locked_until, last_failure_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			failures = VALUES(failures),
+			lockouts = VALUES(lockouts),
+			locked_until = VALUES(locked_until),
+			last_failure_at = VALUES(last_failure_at)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		throttle.Scope, throttle.Subject, throttle.Failures, throttle.Lockouts, throttle.LockedUntil, throttle.LastFailureAt,
+	)
+
+	return err
+}
+
+func (r *mysqlLoginRepository) CountUserDevices(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_devices WHERE user_id = ?`, userID).Scan(&count)
+
+	return count, err
+}
+
+// UpsertUserDevice records a login from device and reports whether the device
+// had not been seen before.
+func (r *mysqlLoginRepository) UpsertUserDevice(ctx context.Context, device *domain.UserDevice) (bool, error) {
+	query := `
+		INSERT INTO user_devices (user_id, fingerprint, user_agent, last_ip)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE last_ip = VALUES(last_ip), last_seen_at = CURRENT_TIMESTAMP
+	`
+	result, err := r.db.ExecContext(ctx, query, device.UserID, device.Fingerprint, device.UserAgent, device.LastIP)
+	if err != nil {
+		return false, err
+	}
+
+	// MySQL reports one affected row for an insert and two for an update.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *mysqlLoginRepository) ListUserDevices(ctx context.Context, userID int64) ([]*domain.UserDevice, error) {
+	query := `
+		SELECT id, user_id, fingerprint, user_agent, last_ip, first_seen_at, last_seen_at
+		FROM user_devices
+		WHERE user_id = ?
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*domain.UserDevice
+	for rows.Next() {
+		var device domain.UserDevice
+
+		err := rows.Scan(&device.ID, &device.UserID, &device.Fingerprint, &device.UserAgent, &device.LastIP, &device.FirstSeenAt, &device.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, &device)
+	}
+
+	return devices, rows.Err()
+}
+
+// DeleteUserLoginData removes the user's login history and known devices,
+// which record the IPs and user agents they used.
+func (r *mysqlLoginRepository) DeleteUserLoginData(ctx context.Context, userID int64) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM login_events WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM user_devices WHERE user_id = ?", userID)
+
+	return err
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlMerchantRepository struct {
+	db DBTX
+}
+
+func NewMerchantRepository(db DBTX) domain.MerchantRepository {
+	return &mysqlMerchantRepository{
+		db: db,
+	}
+}
+
+const merchantColumns = `id, user_id, business_name, legal_name, website, support_email, country,
+	settlement_wallet_id, fee_plan, status, created_at, updated_at`
+
+func scanMerchant(row rowScanner) (*domain.Merchant, error) {
+	var merchant domain.Merchant
+
+	err := row.Scan(
+		&merchant.ID,
+		&merchant.UserID,
+		&merchant.BusinessName,
+		&merchant.LegalName,
+		&merchant.Website,
+		&merchant.SupportEmail,
+		&merchant.Country,
+		&merchant.SettlementWalletID,
+		&merchant.FeePlan,
+		&merchant.Status,
+		&merchant.CreatedAt,
+		&merchant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &merchant, nil
+}
+
+func (r *mysqlMerchantRepository) CreateMerchant(ctx context.Context, merchant *domain.Merchant) error {
+	query := `
+		INSERT INTO merchants (user_id, business_name, legal_name, website, support_email, country,
+			settlement_wallet_id, fee_plan, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, merchant.UserID, merchant.BusinessName, merchant.LegalName, merchant.Website,
+		merchant.SupportEmail, merchant.Country, merchant.SettlementWalletID, merchant.FeePlan, merchant.Status)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	merchant.ID = id
+
+	return nil
+}
+
+func (r *mysqlMerchantRepository) GetMerchantByID(ctx context.Context, id int64) (*domain.Merchant, error) {
+	query := `SELECT ` + merchantColumns + ` FROM merchants WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlMerchantRepository) GetMerchantByUserID(ctx context.Context, userID int64) (*domain.Merchant, error) {
+	query := `SELECT ` + merchantColumns + ` FROM merchants WHERE user_id = ?`
+
+	return r.get(ctx, query, userID)
+}
+
+func (r *mysqlMerchantRepository) get(ctx context.Context, query string, args ...any) (*domain.Merchant, error) {
+	merchant, err := scanMerchant(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+func (r *mysqlMerchantRepository) UpdateMerchantProfile(ctx context.Context, merchant *domain.Merchant) error {
+	query := `
+		UPDATE merchants SET business_name = ?, legal_name = ?, website = ?, support_email = ?, country = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, merchant.BusinessName, merchant.LegalName, merchant.Website,
+		merchant.SupportEmail, merchant.Country, merchant.ID)
+
+	return err
+}
+
+func (r *mysqlMerchantRepository) UpdateMerchantStatus(ctx context.Context, id int64, status domain.MerchantStatus) error {
+	query := "UPDATE merchants SET status = ? WHERE id = ?"
+	_, err := r.db.ExecContext(ctx, query, status, id)
+
+	return err
+}
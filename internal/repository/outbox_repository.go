@@ -21,4 +21,39 @@ func (r *mysqlOutboxRepository) CreateOutbox(ctx context.Context, event *domain.
 	_, err := r.db.ExecContext(ctx, query, event.Topic, event.Payload)
 	
 	return err
-}
\ No newline at end of file
+}
+
+// ListUnpublishedOutboxForUpdate locks the oldest unpublished events, skipping
+// rows already locked by another relay.
+func (r *mysqlOutboxRepository) ListUnpublishedOutboxForUpdate(ctx context.Context, limit int) ([]*domain.Outbox, error) {
+	query := `
+		SELECT id, topic, payload, status, created_at FROM outbox
+		WHERE status = ?
+		ORDER BY id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.OutboxStatusUnpublished, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.Outbox
+	for rows.Next() {
+		var event domain.Outbox
+		if err := rows.Scan(&event.ID, &event.Topic, &event.Payload, &event.Status, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *mysqlOutboxRepository) MarkOutboxPublished(ctx context.Context, id int64) error {
+	query := "UPDATE outbox SET status = ?, published_at = CURRENT_TIMESTAMP WHERE id = ?"
+	_, err := r.db.ExecContext(ctx, query, domain.OutboxStatusPublished, id)
+
+	return err
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlPaymentRequestRepository struct {
+	db DBTX
+}
+
+func NewPaymentRequestRepository(db DBTX) domain.PaymentRequestRepository {
+	return &mysqlPaymentRequestRepository{
+		db: db,
+	}
+}
+
+const paymentRequestColumns = `id, requester_user_id, payer_user_id, amount, memo, status, transaction_id,
+	expires_at, created_at, updated_at`
+
+func scanPaymentRequest(row rowScanner) (*domain.PaymentRequest, error) {
+	var pr domain.PaymentRequest
+	var transactionID sql.NullInt64
+
+	err := row.Scan(
+		&pr.ID,
+		&pr.RequesterUserID,
+		&pr.PayerUserID,
+		&pr.Amount,
+		&pr.Memo,
+		&pr.Status,
+		&transactionID,
+		&pr.ExpiresAt,
+		&pr.CreatedAt,
+		&pr.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if transactionID.Valid {
+		pr.TransactionID = &transactionID.Int64
+	}
+
+	return &pr, nil
+}
+
+func (r *mysqlPaymentRequestRepository) CreatePaymentRequest(ctx context.Context, pr *domain.PaymentRequest) error {
+	query := `
+		INSERT INTO payment_requests (requester_user_id, payer_user_id, amount, memo, status, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, pr.RequesterUserID, pr.PayerUserID, pr.Amount, pr.Memo, pr.Status, pr.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	pr.ID = id
+
+	return nil
+}
+
+func (r *mysqlPaymentRequestRepository) GetPaymentRequestByID(ctx context.Context, id int64) (*domain.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests WHERE id = ?`
+
+	pr, err := scanPaymentRequest(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+func (r *mysqlPaymentRequestRepository) GetPaymentRequestForUpdate(ctx context.Context, id int64) (*domain.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests WHERE id = ? FOR UPDATE`
+
+	pr, err := scanPaymentRequest(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+func (r *mysqlPaymentRequestRepository) ListPendingPaymentRequestsByPayer(ctx context.Context, payerUserID int64) ([]*domain.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests
+		WHERE payer_user_id = ? AND status = ? ORDER BY id DESC`
+
+	return r.list(ctx, query, payerUserID, domain.PaymentRequestStatusPending)
+}
+
+func (r *mysqlPaymentRequestRepository) ListPendingPaymentRequestsByRequester(ctx context.Context, requesterUserID int64) ([]*domain.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests
+		WHERE requester_user_id = ? AND status = ? ORDER BY id DESC`
+
+	return r.list(ctx, query, requesterUserID, domain.PaymentRequestStatusPending)
+}
+
+// ListPaymentRequestsByUser returns every request the user sent or was asked
+// to pay, in any status.
+func (r *mysqlPaymentRequestRepository) ListPaymentRequestsByUser(ctx context.Context, userID int64) ([]*domain.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests
+		WHERE requester_user_id = ? OR payer_user_id = ? ORDER BY id DESC`
+
+	return r.list(ctx, query, userID, userID)
+}
+
+func (r *mysqlPaymentRequestRepository) list(ctx context.Context, query string, args ...any) ([]*domain.PaymentRequest, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*domain.PaymentRequest
+	for rows.Next() {
+		pr, err := scanPaymentRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, pr)
+	}
+
+	return requests, rows.Err()
+}
+
+func (r *mysqlPaymentRequestRepository) ListExpiredPaymentRequestIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM payment_requests
+		WHERE status = ? AND expires_at <= ?
+		ORDER BY expires_at
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.PaymentRequestStatusPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlPaymentRequestRepository) UpdatePaymentRequest(ctx context.Context, pr *domain.PaymentRequest) error {
+	query := "UPDATE payment_requests SET status = ?, transaction_id = ? WHERE id = ?"
+	_, err := r.db.ExecContext(ctx, query, pr.Status, pr.TransactionID, pr.ID)
+
+	return err
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlPayoutBatchRepository struct {
+	db DBTX
+}
+
+func NewPayoutBatchRepository(db DBTX) domain.PayoutBatchRepository {
+	return &mysqlPayoutBatchRepository{
+		db: db,
+	}
+}
+
+const payoutBatchColumns = `id, user_id, wallet_id, currency, total_amount, reserved_amount, item_count, succeeded_count,
+	failed_count, status, completed_at, created_at, updated_at`
+
+func scanPayoutBatch(row rowScanner) (*domain.PayoutBatch, error) {
+	var batch domain.PayoutBatch
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&batch.ID,
+		&batch.UserID,
+		&batch.WalletID,
+		&batch.Currency,
+		&batch.TotalAmount,
+		&batch.ReservedAmount,
+		&batch.ItemCount,
+		&batch.SucceededCount,
+		&batch.FailedCount,
+		&batch.Status,
+		&completedAt,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	batch.CompletedAt = nullTimePtr(completedAt)
+
+	return &batch, nil
+}
+
+func (r *mysqlPayoutBatchRepository) CreatePayoutBatch(ctx context.Context, batch *domain.PayoutBatch) error {
+	query := `
+		INSERT INTO payout_batches (user_id, wallet_id, currency, total_amount, reserved_amount, item_count, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, batch.UserID, batch.WalletID, batch.Currency, batch.TotalAmount,
+		batch.ReservedAmount, batch.ItemCount, batch.Status)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	batch.ID = id
+
+	return nil
+}
+
+func (r *mysqlPayoutBatchRepository) GetPayoutBatchByID(ctx context.Context, id int64) (*domain.PayoutBatch, error) {
+	query := `SELECT ` + payoutBatchColumns + ` FROM payout_batches WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlPayoutBatchRepository) GetPayoutBatchForUpdate(ctx context.Context, id int64) (*domain.PayoutBatch, error) {
+	query := `SELECT ` + payoutBatchColumns + ` FROM payout_batches WHERE id = ? FOR UPDATE`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlPayoutBatchRepository) get(ctx context.Context, query string, args ...any) (*domain.PayoutBatch, error) {
+	batch, err := scanPayoutBatch(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+func (r *mysqlPayoutBatchRepository) ListPayoutBatchesByUser(ctx context.Context, userID int64) ([]*domain.PayoutBatch, error) {
+	query := `SELECT ` + payoutBatchColumns + ` FROM payout_batches WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []*domain.PayoutBatch
+	for rows.Next() {
+		batch, err := scanPayoutBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+func (r *mysqlPayoutBatchRepository) UpdatePayoutBatch(ctx context.Context, batch *domain.PayoutBatch) error {
+	query := `
+		UPDATE payout_batches
+		SET reserved_amount = ?, succeeded_count = ?, failed_count = ?, status = ?, completed_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, batch.ReservedAmount, batch.SucceededCount, batch.FailedCount,
+		batch.Status, batch.CompletedAt, batch.ID)
+
+	return err
+}
+
+const payoutBatchItemColumns = `id, batch_id, line_number, recipient_user_id, receiver_wallet_id, amount, reference, status,
+	transaction_id, failure_reason, created_at, updated_at`
+
+func scanPayoutBatchItem(row rowScanner) (*domain.PayoutBatchItem, error) {
+	var item domain.PayoutBatchItem
+	var transactionID sql.NullInt64
+
+	err := row.Scan(
+		&item.ID,
+		&item.BatchID,
+		&item.LineNumber,
+		&item.RecipientUserID,
+		&item.ReceiverWalletID,
+		&item.Amount,
+		&item.Reference,
+		&item.Status,
+		&transactionID,
+		&item.FailureReason,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if transactionID.Valid {
+		item.TransactionID = &transactionID.Int64
+	}
+
+	return &item, nil
+}
+
+func (r *mysqlPayoutBatchRepository) CreatePayoutBatchItem(ctx context.Context, item *domain.PayoutBatchItem) error {
+	query := `
+		INSERT INTO payout_batch_items (batch_id, line_number, recipient_user_id, receiver_wallet_id, amount, reference, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, item.BatchID, item.LineNumber, item.RecipientUserID, item.ReceiverWalletID,
+		item.Amount, item.Reference, item.Status)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	item.ID = id
+
+	return nil
+}
+
+func (r *mysqlPayoutBatchRepository) GetPayoutBatchItemForUpdate(ctx context.Context, id int64) (*domain.PayoutBatchItem, error) {
+	query := `SELECT ` + payoutBatchItemColumns + ` FROM payout_batch_items WHERE id = ? FOR UPDATE`
+
+	item, err := scanPayoutBatchItem(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// ListPayoutBatchItems returns the items of a batch in file order.
+func (r *mysqlPayoutBatchRepository) ListPayoutBatchItems(ctx context.Context, batchID int64) ([]*domain.PayoutBatchItem, error) {
+	query := `SELECT ` + payoutBatchItemColumns + ` FROM payout_batch_items WHERE batch_id = ? ORDER BY line_number`
+
+	rows, err := r.db.QueryContext(ctx, query, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*domain.PayoutBatchItem
+	for rows.Next() {
+		item, err := scanPayoutBatchItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *mysqlPayoutBatchRepository) UpdatePayoutBatchItem(ctx context.Context, item *domain.PayoutBatchItem) error {
+	query := `UPDATE payout_batch_items SET status = ?, transaction_id = ?, failure_reason = ? WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, item.Status, item.TransactionID, item.FailureReason, item.ID)
+
+	return err
+}
@@ -1,18 +1,69 @@
 package repository
 
-import "github.com/amankp-zop/wallet/internal/domain"
+import (
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+)
 
 type Queries struct {
 	domain.WalletRepository
 	domain.UserRepository
 	domain.TransactionRepository
 	domain.OutboxRepository
+	domain.ScheduledTransferRepository
+	domain.PaymentRequestRepository
+	domain.HoldRepository
+	domain.WebhookRepository
+	domain.LoginRepository
+	domain.RecoveryCodeRepository
+	domain.UserTokenRepository
+	domain.EmailChangeRepository
+	domain.DataExportRepository
+	domain.APIKeyRepository
+	domain.MerchantRepository
+	domain.CheckoutSessionRepository
+	domain.FeeRepository
+	domain.LedgerRepository
+	domain.DepositRepository
+	domain.BankBeneficiaryRepository
+	domain.WithdrawalRepository
+	domain.PayoutBatchRepository
+	domain.SplitPaymentRepository
+	domain.EscrowRepository
+	domain.DisputeRepository
+	domain.VoucherRepository
 }
 
-func NewQueries(db DBTX) *Queries {
+// NewQueries returns the repositories backed by db. cipher encrypts the PII
+// columns of users, user tokens, email changes and bank beneficiaries, and
+// API key signing secrets.
+func NewQueries(db DBTX, cipher *fieldcrypt.Cipher) *Queries {
 	return &Queries{
-		WalletRepository: NewWalletRepository(db),
-		UserRepository:   NewUserRepository(db),
-		TransactionRepository: NewTransactionRepository(db),
+		WalletRepository:            NewWalletRepository(db),
+		UserRepository:              NewUserRepository(db, cipher),
+		TransactionRepository:       NewTransactionRepository(db),
+		OutboxRepository:            NewOutboxRepository(db),
+		ScheduledTransferRepository: NewScheduledTransferRepository(db),
+		PaymentRequestRepository:    NewPaymentRequestRepository(db),
+		HoldRepository:              NewHoldRepository(db),
+		WebhookRepository:           NewWebhookRepository(db),
+		LoginRepository:             NewLoginRepository(db),
+		RecoveryCodeRepository:      NewRecoveryCodeRepository(db),
+		UserTokenRepository:         NewUserTokenRepository(db, cipher),
+		EmailChangeRepository:       NewEmailChangeRepository(db, cipher),
+		DataExportRepository:        NewDataExportRepository(db),
+		APIKeyRepository:            NewAPIKeyRepository(db, cipher),
+		MerchantRepository:          NewMerchantRepository(db),
+		CheckoutSessionRepository:   NewCheckoutSessionRepository(db),
+		FeeRepository:               NewFeeRepository(db),
+		LedgerRepository:            NewLedgerRepository(db),
+		DepositRepository:           NewDepositRepository(db),
+		BankBeneficiaryRepository:   NewBankBeneficiaryRepository(db, cipher),
+		WithdrawalRepository:        NewWithdrawalRepository(db),
+		PayoutBatchRepository:       NewPayoutBatchRepository(db),
+		SplitPaymentRepository:      NewSplitPaymentRepository(db),
+		EscrowRepository:            NewEscrowRepository(db),
+		DisputeRepository:           NewDisputeRepository(db),
+		VoucherRepository:           NewVoucherRepository(db),
 	}
 }
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlRecoveryCodeRepository struct {
+	db DBTX
+}
+
+func NewRecoveryCodeRepository(db DBTX) domain.RecoveryCodeRepository {
+	return &mysqlRecoveryCodeRepository{
+		db: db,
+	}
+}
+
+// ReplaceRecoveryCodes deletes the user's recovery codes, used or not, and
+// stores codeHashes in their place.
+func (r *mysqlRecoveryCodeRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	if err := r.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		query := "INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)"
+		if _, err := r.db.ExecContext(ctx, query, userID, codeHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused code as used and reports whether there was
+// one to mark.
+func (r *mysqlRecoveryCodeRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	query := `
+		UPDATE recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *mysqlRecoveryCodeRepository) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = ?", userID)
+	return err
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlScheduledTransferRepository struct {
+	db DBTX
+}
+
+func NewScheduledTransferRepository(db DBTX) domain.ScheduledTransferRepository {
+	return &mysqlScheduledTransferRepository{
+		db: db,
+	}
+}
+
+const scheduledTransferColumns = `id, sender_user_id, receiver_user_id, amount, memo, schedule_type, cron_expr,
+	day_of_month, next_run_at, last_run_at, status, failure_count, last_error, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanScheduledTransfer(row rowScanner) (*domain.ScheduledTransfer, error) {
+	var st domain.ScheduledTransfer
+	var nextRunAt, lastRunAt sql.NullTime
+
+	err := row.Scan(
+		&st.ID,
+		&st.SenderUserID,
+		&st.ReceiverUserID,
+		&st.Amount,
+		&st.Memo,
+		&st.ScheduleType,
+		&st.CronExpr,
+		&st.DayOfMonth,
+		&nextRunAt,
+		&lastRunAt,
+		&st.Status,
+		&st.FailureCount,
+		&st.LastError,
+		&st.CreatedAt,
+		&st.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	st.NextRunAt = nullTimePtr(nextRunAt)
+	st.LastRunAt = nullTimePtr(lastRunAt)
+
+	return &st, nil
+}
+
+const scheduledTransferRunColumns = `id, scheduled_transfer_id, scheduled_for, transaction_id, status, error, created_at`
+
+func scanScheduledTransferRun(row rowScanner) (*domain.ScheduledTransferRun, error) {
+	var run domain.ScheduledTransferRun
+	var transactionID sql.NullInt64
+
+	err := row.Scan(&run.ID, &run.ScheduledTransferID, &run.ScheduledFor, &transactionID, &run.Status, &run.Error, &run.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if transactionID.Valid {
+		run.TransactionID = &transactionID.Int64
+	}
+
+	return &run, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+func (r *mysqlScheduledTransferRepository) CreateScheduledTransfer(ctx context.Context, st *domain.ScheduledTransfer) error {
+	query := `
+		INSERT INTO scheduled_transfers
+			(sender_user_id, receiver_user_id, amount, memo, schedule_type, cron_expr, day_of_month, next_run_at, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		st.SenderUserID, st.ReceiverUserID, st.Amount, st.Memo, st.ScheduleType,
+		st.CronExpr, st.DayOfMonth, st.NextRunAt, st.Status,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	st.ID = id
+
+	return nil
+}
+
+func (r *mysqlScheduledTransferRepository) GetScheduledTransferByID(ctx context.Context, id int64) (*domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE id = ?`
+
+	st, err := scanScheduledTransfer(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return st, nil
+}
+
+func (r *mysqlScheduledTransferRepository) GetScheduledTransferForUpdate(ctx context.Context, id int64) (*domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE id = ? FOR UPDATE`
+
+	st, err := scanScheduledTransfer(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return st, nil
+}
+
+func (r *mysqlScheduledTransferRepository) ListScheduledTransfersBySender(ctx context.Context, senderUserID int64) ([]*domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE sender_user_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, senderUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []*domain.ScheduledTransfer
+	for rows.Next() {
+		st, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, st)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (r *mysqlScheduledTransferRepository) ListDueScheduledTransferIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM scheduled_transfers
+		WHERE status = ? AND next_run_at IS NOT NULL AND next_run_at <= ?
+		ORDER BY next_run_at
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.ScheduledTransferStatusActive, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlScheduledTransferRepository) UpdateScheduledTransfer(ctx context.Context, st *domain.ScheduledTransfer) error {
+	query := `
+		UPDATE scheduled_transfers
+		SET next_run_at = ?, last_run_at = ?, status = ?, failure_count = ?, last_error = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, st.NextRunAt, st.LastRunAt, st.Status, st.FailureCount, st.LastError, st.ID)
+
+	return err
+}
+
+func (r *mysqlScheduledTransferRepository) CreateScheduledTransferRun(ctx context.Context, run *domain.ScheduledTransferRun) error {
+	query := `
+		INSERT INTO scheduled_transfer_runs (scheduled_transfer_id, scheduled_for, transaction_id, status, error)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, run.ScheduledTransferID, run.ScheduledFor, run.TransactionID, run.Status, run.Error)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	run.ID = id
+
+	return nil
+}
+
+// GetScheduledTransferRunByTransactionForUpdate locks the run that created
+// the transaction, if there is one.
+func (r *mysqlScheduledTransferRepository) GetScheduledTransferRunByTransactionForUpdate(ctx context.Context, transactionID int64) (*domain.ScheduledTransferRun, error) {
+	query := `
+		SELECT ` + scheduledTransferRunColumns + `
+		FROM scheduled_transfer_runs
+		WHERE transaction_id = ?
+		FOR UPDATE
+	`
+	run, err := scanScheduledTransferRun(r.db.QueryRowContext(ctx, query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return run, nil
+}
+
+func (r *mysqlScheduledTransferRepository) UpdateScheduledTransferRun(ctx context.Context, run *domain.ScheduledTransferRun) error {
+	query := `UPDATE scheduled_transfer_runs SET status = ?, error = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, run.Status, run.Error, run.ID)
+
+	return err
+}
+
+func (r *mysqlScheduledTransferRepository) ListScheduledTransferRuns(ctx context.Context, scheduledTransferID int64, limit int) ([]*domain.ScheduledTransferRun, error) {
+	query := `
+		SELECT ` + scheduledTransferRunColumns + `
+		FROM scheduled_transfer_runs
+		WHERE scheduled_transfer_id = ?
+		ORDER BY scheduled_for DESC
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, scheduledTransferID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*domain.ScheduledTransferRun
+	for rows.Next() {
+		run, err := scanScheduledTransferRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
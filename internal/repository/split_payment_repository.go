@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlSplitPaymentRepository struct {
+	db DBTX
+}
+
+func NewSplitPaymentRepository(db DBTX) domain.SplitPaymentRepository {
+	return &mysqlSplitPaymentRepository{
+		db: db,
+	}
+}
+
+const splitPaymentColumns = `id, sender_wallet_id, amount, currency, description, status, failure_reason, created_at, updated_at`
+
+func scanSplitPayment(row rowScanner) (*domain.SplitPayment, error) {
+	var payment domain.SplitPayment
+
+	err := row.Scan(
+		&payment.ID,
+		&payment.SenderWalletID,
+		&payment.Amount,
+		&payment.Currency,
+		&payment.Description,
+		&payment.Status,
+		&payment.FailureReason,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *mysqlSplitPaymentRepository) CreateSplitPayment(ctx context.Context, payment *domain.SplitPayment) error {
+	query := `
+		INSERT INTO split_payments (sender_wallet_id, amount, currency, description, status)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, payment.SenderWalletID, payment.Amount, payment.Currency,
+		payment.Description, payment.Status)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	payment.ID = id
+
+	return nil
+}
+
+func (r *mysqlSplitPaymentRepository) GetSplitPaymentByID(ctx context.Context, id int64) (*domain.SplitPayment, error) {
+	query := `SELECT ` + splitPaymentColumns + ` FROM split_payments WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlSplitPaymentRepository) GetSplitPaymentForUpdate(ctx context.Context, id int64) (*domain.SplitPayment, error) {
+	query := `SELECT ` + splitPaymentColumns + ` FROM split_payments WHERE id = ? FOR UPDATE`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlSplitPaymentRepository) get(ctx context.Context, query string, args ...any) (*domain.SplitPayment, error) {
+	payment, err := scanSplitPayment(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (r *mysqlSplitPaymentRepository) ListSplitPaymentsBySender(ctx context.Context, senderWalletID int64) ([]*domain.SplitPayment, error) {
+	query := `SELECT ` + splitPaymentColumns + ` FROM split_payments WHERE sender_wallet_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, senderWalletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*domain.SplitPayment
+	for rows.Next() {
+		payment, err := scanSplitPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *mysqlSplitPaymentRepository) UpdateSplitPayment(ctx context.Context, payment *domain.SplitPayment) error {
+	query := `UPDATE split_payments SET status = ?, failure_reason = ? WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, payment.Status, payment.FailureReason, payment.ID)
+
+	return err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/amankp-zop/wallet/internal/domain"
 )
@@ -16,12 +17,32 @@ func NewTransactionRepository(db DBTX) domain.TransactionRepository {
 	}
 }
 
+func scanTransaction(row rowScanner) (*domain.Transaction, error) {
+	var tx domain.Transaction
+	var feeWalletID, splitPaymentID sql.NullInt64
+
+	err := row.Scan(&tx.ID, &tx.SenderWalletID, &tx.ReceiverWalletID, &tx.Amount, &tx.Fee, &tx.Memo, &feeWalletID, &splitPaymentID, &tx.Status, &tx.CreatedAt, &tx.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if feeWalletID.Valid {
+		tx.FeeWalletID = &feeWalletID.Int64
+	}
+
+	if splitPaymentID.Valid {
+		tx.SplitPaymentID = &splitPaymentID.Int64
+	}
+
+	return &tx, nil
+}
+
 func (r *mysqlTransactionRepository)CreateTransaction(ctx context.Context, tx *domain.Transaction) error {
 	query := `
-		INSERT INTO transactions (sender_wallet_id, receiver_wallet_id, amount, status)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO transactions (sender_wallet_id, receiver_wallet_id, amount, fee, memo, fee_wallet_id, split_payment_id, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := r.db.ExecContext(ctx, query, tx.SenderWalletID, tx.ReceiverWalletID, tx.Amount, tx.Status)
+	result, err := r.db.ExecContext(ctx, query, tx.SenderWalletID, tx.ReceiverWalletID, tx.Amount, tx.Fee, tx.Memo, tx.FeeWalletID, tx.SplitPaymentID, tx.Status)
 	if err != nil {
 		return err
 	}
@@ -32,4 +53,81 @@ func (r *mysqlTransactionRepository)CreateTransaction(ctx context.Context, tx *d
 	tx.ID = id
 	
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *mysqlTransactionRepository) GetTransactionForUpdate(ctx context.Context, id int64) (*domain.Transaction, error) {
+	query := `
+		SELECT id, sender_wallet_id, receiver_wallet_id, amount, fee, memo, fee_wallet_id, split_payment_id, status, created_at, updated_at
+		FROM transactions WHERE id = ? FOR UPDATE
+	`
+	row := r.db.QueryRowContext(ctx, query, id)
+
+	tx, err := scanTransaction(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+func (r *mysqlTransactionRepository) UpdateTransactionStatus(ctx context.Context, id int64, status domain.TransactionStatus) error {
+	query := "UPDATE transactions SET status = ? WHERE id = ?"
+	_, err := r.db.ExecContext(ctx, query, status, id)
+
+	return err
+}
+
+// ListTransactionsByWallet returns every transaction the wallet sent or
+// received, newest first.
+func (r *mysqlTransactionRepository) ListTransactionsByWallet(ctx context.Context, walletID int64) ([]*domain.Transaction, error) {
+	query := `
+		SELECT id, sender_wallet_id, receiver_wallet_id, amount, fee, memo, fee_wallet_id, split_payment_id, status, created_at, updated_at
+		FROM transactions WHERE sender_wallet_id = ? OR receiver_wallet_id = ?
+		ORDER BY id DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, walletID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*domain.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, rows.Err()
+}
+
+// ListTransactionsBySplitPayment returns the legs of a split payment in the
+// order they were created.
+func (r *mysqlTransactionRepository) ListTransactionsBySplitPayment(ctx context.Context, splitPaymentID int64) ([]*domain.Transaction, error) {
+	query := `
+		SELECT id, sender_wallet_id, receiver_wallet_id, amount, fee, memo, fee_wallet_id, split_payment_id, status, created_at, updated_at
+		FROM transactions WHERE split_payment_id = ?
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, splitPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*domain.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, rows.Err()
+}
@@ -3,23 +3,39 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
 )
 
+// mysqlUserRepository stores name, email and phone encrypted with cipher and
+// looks users up by the blind index of their email.
 type mysqlUserRepository struct {
-	db DBTX
+	db     DBTX
+	cipher *fieldcrypt.Cipher
 }
 
-func NewUserRepository(db DBTX) domain.UserRepository {
+func NewUserRepository(db DBTX, cipher *fieldcrypt.Cipher) domain.UserRepository {
 	return &mysqlUserRepository{
-		db: db,
+		db:     db,
+		cipher: cipher,
 	}
 }
 
 func (r *mysqlUserRepository) CreateUser(ctx context.Context, user *domain.User) error {
-	query := "INSERT INTO users (name, email, password) VALUES (?, ?, ?)"
-	result, err := r.db.ExecContext(ctx, query, user.Name, user.Email, user.Password)
+	name, err := r.cipher.Encrypt(user.Name)
+	if err != nil {
+		return err
+	}
+
+	email, err := r.cipher.Encrypt(user.Email)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO users (name, email, email_bidx, password) VALUES (?, ?, ?, ?)"
+	result, err := r.db.ExecContext(ctx, query, name, email, r.cipher.BlindIndex(user.Email), user.Password)
 	if err != nil {
 		return err
 	}
@@ -34,28 +50,60 @@ func (r *mysqlUserRepository) CreateUser(ctx context.Context, user *domain.User)
 	return nil
 }
 
-func (r *mysqlUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	query := "SELECT id, name, email, password, created_at, updated_at FROM users WHERE email = ?"
-	row := r.db.QueryRowContext(ctx, query, email)
+const userColumns = `id, name, email, email_verified_at, phone, timezone, password, totp_secret, totp_enabled,
+	totp_last_step, sessions_valid_after, erased_at, created_at, updated_at`
 
+func scanUser(row rowScanner) (*domain.User, error) {
 	var user domain.User
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	var totpSecret sql.NullString
+	var emailVerifiedAt, sessionsValidAfter, erasedAt sql.NullTime
+
+	err := row.Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&emailVerifiedAt,
+		&user.Phone,
+		&user.Timezone,
+		&user.Password,
+		&totpSecret,
+		&user.TwoFactorEnabled,
+		&user.TOTPLastStep,
+		&sessionsValidAfter,
+		&erasedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
 
+	user.TOTPSecret = totpSecret.String
+	user.EmailVerifiedAt = nullTimePtr(emailVerifiedAt)
+	user.SessionsValidAfter = nullTimePtr(sessionsValidAfter)
+	user.ErasedAt = nullTimePtr(erasedAt)
+
 	return &user, nil
 }
 
+// emailMatch matches a user's email by its blind index, falling back to the
+// plaintext column for rows that have not been encrypted yet.
+const emailMatch = "(email_bidx = ? OR (email_bidx IS NULL AND email = ?))"
+
+func (r *mysqlUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE " + emailMatch
+
+	return r.getUser(ctx, query, r.cipher.BlindIndex(email), email)
+}
+
 func (r *mysqlUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
-	query := "SELECT id, name, email, created_at, updated_at FROM users WHERE id = ?"
-	row := r.db.QueryRowContext(ctx, query, id)
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
 
-	var user domain.User
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+	return r.getUser(ctx, query, id)
+}
+
+func (r *mysqlUserRepository) getUser(ctx context.Context, query string, args ...any) (*domain.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -63,5 +111,132 @@ func (r *mysqlUserRepository) GetByID(ctx context.Context, id int64) (*domain.Us
 		return nil, err
 	}
 
-	return &user, nil
-}
\ No newline at end of file
+	for _, field := range []*string{&user.Name, &user.Email, &user.Phone} {
+		if *field, err = r.cipher.Decrypt(*field); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// UpdateUserTOTP stores the user's TOTP secret and whether it is enabled. An
+// empty secret clears it and resets the replay guard.
+func (r *mysqlUserRepository) UpdateUserTOTP(ctx context.Context, userID int64, secret string, enabled bool) error {
+	query := "UPDATE users SET totp_secret = NULLIF(?, ''), totp_enabled = ?, totp_last_step = 0 WHERE id = ?"
+	_, err := r.db.ExecContext(ctx, query, secret, enabled, userID)
+	return err
+}
+
+// AdvanceUserTOTPStep records step as the last accepted TOTP time step. It
+// reports false if step is not newer than the recorded one, which makes a
+// code usable only once even under concurrent requests.
+func (r *mysqlUserRepository) AdvanceUserTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	query := "UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?"
+	result, err := r.db.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *mysqlUserRepository) UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", passwordHash, userID)
+	return err
+}
+
+// RevokeUserSessions invalidates every token issued to the user before
+// validAfter.
+func (r *mysqlUserRepository) RevokeUserSessions(ctx context.Context, userID int64, validAfter time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET sessions_valid_after = ? WHERE id = ?", validAfter, userID)
+	return err
+}
+
+// MarkUserEmailVerified marks email as verified if it is still the user's
+// address, and reports whether it was.
+func (r *mysqlUserRepository) MarkUserEmailVerified(ctx context.Context, userID int64, email string) (bool, error) {
+	query := "UPDATE users SET email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP) WHERE id = ? AND " + emailMatch
+	result, err := r.db.ExecContext(ctx, query, userID, r.cipher.BlindIndex(email), email)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// UpdateUserProfile saves the user's profile fields. With ifUnmodifiedSince
+// set, the row is only updated if its updated_at still equals it; the result
+// reports whether a row was updated. updated_at is set explicitly so that it
+// moves even when no field changes.
+func (r *mysqlUserRepository) UpdateUserProfile(ctx context.Context, user *domain.User, ifUnmodifiedSince *time.Time) (bool, error) {
+	name, err := r.cipher.Encrypt(user.Name)
+	if err != nil {
+		return false, err
+	}
+
+	phone, err := r.cipher.Encrypt(user.Phone)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE users
+		SET name = ?, phone = ?, timezone = ?, updated_at = CURRENT_TIMESTAMP(6)
+		WHERE id = ? AND (? IS NULL OR updated_at = ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, name, phone, user.Timezone, user.ID, ifUnmodifiedSince, ifUnmodifiedSince)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// UpdateUserEmail switches the user to email, which counts as verified.
+func (r *mysqlUserRepository) UpdateUserEmail(ctx context.Context, userID int64, email string) error {
+	encrypted, err := r.cipher.Encrypt(email)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE users SET email = ?, email_bidx = ?, email_verified_at = CURRENT_TIMESTAMP WHERE id = ?"
+	_, err = r.db.ExecContext(ctx, query, encrypted, r.cipher.BlindIndex(email), userID)
+	return err
+}
+
+// PseudonymiseUser replaces the user's personal data with placeholders,
+// leaving email as a unique placeholder address. The empty password hash
+// never matches, so the account can no longer log in, and every session is
+// revoked.
+func (r *mysqlUserRepository) PseudonymiseUser(ctx context.Context, userID int64, email string, erasedAt time.Time) error {
+	encrypted, err := r.cipher.Encrypt(email)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE users
+		SET name = '', email = ?, email_bidx = ?, email_verified_at = NULL, phone = '', timezone = '', password = '',
+			totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0, sessions_valid_after = ?, erased_at = ?
+		WHERE id = ?
+	`
+	_, err = r.db.ExecContext(ctx, query, encrypted, r.cipher.BlindIndex(email), erasedAt, erasedAt, userID)
+
+	return err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/fieldcrypt"
+)
+
+// mysqlUserTokenRepository stores the email a token was sent to encrypted
+// with cipher.
+type mysqlUserTokenRepository struct {
+	db     DBTX
+	cipher *fieldcrypt.Cipher
+}
+
+func NewUserTokenRepository(db DBTX, cipher *fieldcrypt.Cipher) domain.UserTokenRepository {
+	return &mysqlUserTokenRepository{
+		db:     db,
+		cipher: cipher,
+	}
+}
+
+func (r *mysqlUserTokenRepository) CreateUserToken(ctx context.Context, token *domain.UserToken) error {
+	email, err := r.cipher.Encrypt(token.Email)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, email, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, token.UserID, token.Purpose, token.TokenHash, email, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = id
+
+	return nil
+}
+
+func (r *mysqlUserTokenRepository) GetUserTokenByHashForUpdate(ctx context.Context, tokenHash string) (*domain.UserToken, error) {
+	query := `
+		SELECT id, user_id, purpose, token_hash, email, expires_at, used_at, created_at
+		FROM user_tokens
+		WHERE token_hash = ?
+		FOR UPDATE
+	`
+
+	var token domain.UserToken
+	var usedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.Email, &token.ExpiresAt, &usedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	token.UsedAt = nullTimePtr(usedAt)
+
+	if token.Email, err = r.cipher.Decrypt(token.Email); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *mysqlUserTokenRepository) MarkUserTokenUsed(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE user_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = ? AND used_at IS NULL", id)
+	return err
+}
+
+// InvalidateUserTokens marks every unused token of purpose as used, so that
+// only the most recently issued one can be redeemed.
+func (r *mysqlUserTokenRepository) InvalidateUserTokens(ctx context.Context, userID int64, purpose domain.UserTokenPurpose) error {
+	query := "UPDATE user_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = ? AND purpose = ? AND used_at IS NULL"
+	_, err := r.db.ExecContext(ctx, query, userID, purpose)
+	return err
+}
+
+func (r *mysqlUserTokenRepository) DeleteUserTokens(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM user_tokens WHERE user_id = ?", userID)
+
+	return err
+}
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlVoucherRepository struct {
+	db DBTX
+}
+
+func NewVoucherRepository(db DBTX) domain.VoucherRepository {
+	return &mysqlVoucherRepository{
+		db: db,
+	}
+}
+
+const voucherBatchColumns = `id, name, amount, currency, promo_wallet_id, expires_at, max_redemptions, per_user_limit,
+	code_count, redemption_count, redeemed_amount, status, created_by_user_id, created_at, updated_at`
+
+func scanVoucherBatch(row rowScanner) (*domain.VoucherBatch, error) {
+	var batch domain.VoucherBatch
+
+	err := row.Scan(
+		&batch.ID,
+		&batch.Name,
+		&batch.Amount,
+		&batch.Currency,
+		&batch.PromoWalletID,
+		&batch.ExpiresAt,
+		&batch.MaxRedemptions,
+		&batch.PerUserLimit,
+		&batch.CodeCount,
+		&batch.RedemptionCount,
+		&batch.RedeemedAmount,
+		&batch.Status,
+		&batch.CreatedByUserID,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+const voucherRedemptionColumns = `id, voucher_id, batch_id, user_id, wallet_id, amount, currency, created_at`
+
+func scanVoucherRedemption(row rowScanner) (*domain.VoucherRedemption, error) {
+	var redemption domain.VoucherRedemption
+
+	err := row.Scan(
+		&redemption.ID,
+		&redemption.VoucherID,
+		&redemption.BatchID,
+		&redemption.UserID,
+		&redemption.WalletID,
+		&redemption.Amount,
+		&redemption.Currency,
+		&redemption.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redemption, nil
+}
+
+// GetPromoWalletID returns the house wallet funding voucher redemptions in
+// currency, and false if none is configured.
+func (r *mysqlVoucherRepository) GetPromoWalletID(ctx context.Context, currency string) (int64, bool, error) {
+	query := "SELECT wallet_id FROM promo_wallets WHERE currency = ?"
+
+	var walletID int64
+	err := r.db.QueryRowContext(ctx, query, currency).Scan(&walletID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return walletID, true, nil
+}
+
+func (r *mysqlVoucherRepository) CreateVoucherBatch(ctx context.Context, batch *domain.VoucherBatch) error {
+	query := `
+		INSERT INTO voucher_batches (name, amount, currency, promo_wallet_id, expires_at, max_redemptions, per_user_limit,
+			code_count, status, created_by_user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, batch.Name, batch.Amount, batch.Currency, batch.PromoWalletID, batch.ExpiresAt,
+		batch.MaxRedemptions, batch.PerUserLimit, batch.CodeCount, batch.Status, batch.CreatedByUserID)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	batch.ID = id
+
+	return nil
+}
+
+func (r *mysqlVoucherRepository) GetVoucherBatchByID(ctx context.Context, id int64) (*domain.VoucherBatch, error) {
+	query := `SELECT ` + voucherBatchColumns + ` FROM voucher_batches WHERE id = ?`
+
+	return r.getBatch(ctx, query, id)
+}
+
+func (r *mysqlVoucherRepository) GetVoucherBatchForUpdate(ctx context.Context, id int64) (*domain.VoucherBatch, error) {
+	query := `SELECT ` + voucherBatchColumns + ` FROM voucher_batches WHERE id = ? FOR UPDATE`
+
+	return r.getBatch(ctx, query, id)
+}
+
+func (r *mysqlVoucherRepository) getBatch(ctx context.Context, query string, args ...any) (*domain.VoucherBatch, error) {
+	batch, err := scanVoucherBatch(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+func (r *mysqlVoucherRepository) ListVoucherBatches(ctx context.Context) ([]*domain.VoucherBatch, error) {
+	query := `SELECT ` + voucherBatchColumns + ` FROM voucher_batches ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []*domain.VoucherBatch
+	for rows.Next() {
+		batch, err := scanVoucherBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+func (r *mysqlVoucherRepository) UpdateVoucherBatch(ctx context.Context, batch *domain.VoucherBatch) error {
+	query := `
+		UPDATE voucher_batches
+		SET redemption_count = ?, redeemed_amount = ?, status = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, batch.RedemptionCount, batch.RedeemedAmount, batch.Status, batch.ID)
+
+	return err
+}
+
+func (r *mysqlVoucherRepository) CreateVoucher(ctx context.Context, voucher *domain.Voucher) error {
+	query := `INSERT INTO vouchers (batch_id, code_hash, code_hint) VALUES (?, ?, ?)`
+
+	result, err := r.db.ExecContext(ctx, query, voucher.BatchID, voucher.CodeHash, voucher.CodeHint)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	voucher.ID = id
+
+	return nil
+}
+
+func (r *mysqlVoucherRepository) GetVoucherByCodeHashForUpdate(ctx context.Context, codeHash string) (*domain.Voucher, error) {
+	query := `
+		SELECT id, batch_id, code_hash, code_hint, redemption_count, created_at
+		FROM vouchers WHERE code_hash = ? FOR UPDATE
+	`
+
+	var voucher domain.Voucher
+	err := r.db.QueryRowContext(ctx, query, codeHash).Scan(&voucher.ID, &voucher.BatchID, &voucher.CodeHash, &voucher.CodeHint,
+		&voucher.RedemptionCount, &voucher.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &voucher, nil
+}
+
+func (r *mysqlVoucherRepository) UpdateVoucher(ctx context.Context, voucher *domain.Voucher) error {
+	query := `UPDATE vouchers SET redemption_count = ? WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, voucher.RedemptionCount, voucher.ID)
+
+	return err
+}
+
+func (r *mysqlVoucherRepository) CreateVoucherRedemption(ctx context.Context, redemption *domain.VoucherRedemption) error {
+	query := `
+		INSERT INTO voucher_redemptions (voucher_id, batch_id, user_id, wallet_id, amount, currency)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, redemption.VoucherID, redemption.BatchID, redemption.UserID, redemption.WalletID,
+		redemption.Amount, redemption.Currency)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	redemption.ID = id
+
+	return nil
+}
+
+// GetVoucherRedemption returns userID's redemption of a voucher, or nil if
+// they have not redeemed it.
+func (r *mysqlVoucherRepository) GetVoucherRedemption(ctx context.Context, voucherID, userID int64) (*domain.VoucherRedemption, error) {
+	query := `SELECT ` + voucherRedemptionColumns + ` FROM voucher_redemptions WHERE voucher_id = ? AND user_id = ?`
+
+	redemption, err := scanVoucherRedemption(r.db.QueryRowContext(ctx, query, voucherID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return redemption, nil
+}
+
+func (r *mysqlVoucherRepository) CountVoucherRedemptionsByUser(ctx context.Context, batchID, userID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM voucher_redemptions WHERE batch_id = ? AND user_id = ?`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, batchID, userID).Scan(&count)
+
+	return count, err
+}
+
+func (r *mysqlVoucherRepository) ListVoucherRedemptionsByUser(ctx context.Context, userID int64) ([]*domain.VoucherRedemption, error) {
+	query := `SELECT ` + voucherRedemptionColumns + ` FROM voucher_redemptions WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var redemptions []*domain.VoucherRedemption
+	for rows.Next() {
+		redemption, err := scanVoucherRedemption(rows)
+		if err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// GetVoucherBatchRedeemerCounts returns how many of the batch's codes have
+// been redeemed at least once and by how many different users.
+func (r *mysqlVoucherRepository) GetVoucherBatchRedeemerCounts(ctx context.Context, batchID int64) (codes, users int, err error) {
+	query := `SELECT COUNT(DISTINCT voucher_id), COUNT(DISTINCT user_id) FROM voucher_redemptions WHERE batch_id = ?`
+
+	err = r.db.QueryRowContext(ctx, query, batchID).Scan(&codes, &users)
+
+	return codes, users, err
+}
+
+// ListVoucherRedemptionDays totals the batch's redemptions per day, oldest
+// first.
+func (r *mysqlVoucherRepository) ListVoucherRedemptionDays(ctx context.Context, batchID int64) ([]*domain.VoucherRedemptionDay, error) {
+	query := `
+		SELECT DATE_FORMAT(created_at, '%Y-%m-%d') AS day, COUNT(*), SUM(amount)
+		FROM voucher_redemptions WHERE batch_id = ?
+		GROUP BY day
+		ORDER BY day
+	`
+	rows, err := r.db.QueryContext(ctx, query, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []*domain.VoucherRedemptionDay
+	for rows.Next() {
+		var day domain.VoucherRedemptionDay
+		if err := rows.Scan(&day.Date, &day.Redemptions, &day.Amount); err != nil {
+			return nil, err
+		}
+		days = append(days, &day)
+	}
+
+	return days, rows.Err()
+}
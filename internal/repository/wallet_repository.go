@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/shopspring/decimal"
 )
 
 type walletRepository struct {
@@ -17,6 +18,28 @@ func NewWalletRepository(db DBTX) domain.WalletRepository {
 	}
 }
 
+const walletColumns = `id, user_id, balance, held_balance, currency, created_at, updated_at`
+
+func scanWallet(row rowScanner) (*domain.Wallet, error) {
+	var wallet domain.Wallet
+	err := row.Scan(
+		&wallet.ID,
+		&wallet.UserID,
+		&wallet.Balance,
+		&wallet.HeldBalance,
+		&wallet.Currency,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet.AvailableBalance = wallet.Balance.Sub(wallet.HeldBalance)
+
+	return &wallet, nil
+}
+
 func (r *walletRepository) CreateWallet(ctx context.Context, wallet *domain.Wallet) error {
 	query := `INSERT INTO wallets (user_id, balance, currency) VALUES (?, ?, ?)`
 	result, err := r.db.ExecContext(ctx, query, wallet.UserID, wallet.Balance, wallet.Currency)
@@ -33,19 +56,24 @@ func (r *walletRepository) CreateWallet(ctx context.Context, wallet *domain.Wall
 }
 
 func (r *walletRepository) GetByUserID(ctx context.Context, userID int64) (*domain.Wallet, error) {
-	query := `SELECT id, user_id, balance, currency, created_at, updated_at FROM wallets WHERE user_id = ?`
-	row := r.db.QueryRowContext(ctx, query, userID)
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE user_id = ?`
 
-	var wallet domain.Wallet
-	err := row.Scan(
-		&wallet.ID,
-		&wallet.UserID,
-		&wallet.Balance,
-		&wallet.Currency,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
-	)
+	wallet, err := scanWallet(r.db.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+func (r *walletRepository) GetWalletByID(ctx context.Context, id int64) (*domain.Wallet, error) {
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = ?`
 
+	wallet, err := scanWallet(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -54,5 +82,34 @@ func (r *walletRepository) GetByUserID(ctx context.Context, userID int64) (*doma
 		return nil, err
 	}
 
-	return &wallet, nil
-}
\ No newline at end of file
+	return wallet, nil
+}
+
+func (r *walletRepository) GetWalletForUpdate(ctx context.Context, id int64) (*domain.Wallet, error) {
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = ? FOR UPDATE`
+
+	wallet, err := scanWallet(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+func (r *walletRepository) AdjustWalletBalance(ctx context.Context, id int64, delta decimal.Decimal) error {
+	query := `UPDATE wallets SET balance = balance + ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, delta, id)
+
+	return err
+}
+
+func (r *walletRepository) AdjustWalletHeldBalance(ctx context.Context, id int64, delta decimal.Decimal) error {
+	query := `UPDATE wallets SET held_balance = held_balance + ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, delta, id)
+
+	return err
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlWebhookRepository struct {
+	db DBTX
+}
+
+func NewWebhookRepository(db DBTX) domain.WebhookRepository {
+	return &mysqlWebhookRepository{
+		db: db,
+	}
+}
+
+const webhookEndpointColumns = `id, user_id, url, secret, event_types, description, status, created_at, updated_at`
+
+func scanWebhookEndpoint(row rowScanner) (*domain.WebhookEndpoint, error) {
+	var endpoint domain.WebhookEndpoint
+	var eventTypes []byte
+
+	err := row.Scan(
+		&endpoint.ID,
+		&endpoint.UserID,
+		&endpoint.URL,
+		&endpoint.Secret,
+		&eventTypes,
+		&endpoint.Description,
+		&endpoint.Status,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(eventTypes, &endpoint.EventTypes); err != nil {
+		return nil, err
+	}
+
+	return &endpoint, nil
+}
+
+func (r *mysqlWebhookRepository) CreateWebhookEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	eventTypes, err := json.Marshal(endpoint.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO webhook_endpoints (user_id, url, secret, event_types, description, status)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, endpoint.UserID, endpoint.URL, endpoint.Secret, eventTypes, endpoint.Description, endpoint.Status)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	endpoint.ID = id
+
+	return nil
+}
+
+func (r *mysqlWebhookRepository) GetWebhookEndpointByID(ctx context.Context, id int64) (*domain.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE id = ?`
+
+	endpoint, err := scanWebhookEndpoint(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+func (r *mysqlWebhookRepository) ListWebhookEndpointsByUser(ctx context.Context, userID int64) ([]*domain.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE user_id = ? ORDER BY id DESC`
+
+	return r.listEndpoints(ctx, query, userID)
+}
+
+func (r *mysqlWebhookRepository) ListActiveWebhookEndpointsByUsers(ctx context.Context, userIDs []int64) ([]*domain.WebhookEndpoint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, 0, len(userIDs)+1)
+	args = append(args, domain.WebhookEndpointStatusActive)
+	for _, id := range userIDs {
+		args = append(args, id)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(userIDs)), ", ")
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints
+		WHERE status = ? AND user_id IN (` + placeholders + `)`
+
+	return r.listEndpoints(ctx, query, args...)
+}
+
+func (r *mysqlWebhookRepository) listEndpoints(ctx context.Context, query string, args ...any) ([]*domain.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []*domain.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, rows.Err()
+}
+
+func (r *mysqlWebhookRepository) UpdateWebhookEndpointStatus(ctx context.Context, id int64, status domain.WebhookEndpointStatus) error {
+	query := "UPDATE webhook_endpoints SET status = ? WHERE id = ?"
+	_, err := r.db.ExecContext(ctx, query, status, id)
+
+	return err
+}
+
+// CreateWebhookDelivery inserts the delivery unless one already exists for the
+// same endpoint and event, and reports whether a row was created.
+func (r *mysqlWebhookRepository) CreateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error) {
+	query := `
+		INSERT IGNORE INTO webhook_deliveries (endpoint_id, event_id, event_type, payload, status)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, delivery.EndpointID, delivery.EventID, delivery.EventType, delivery.Payload, delivery.Status)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+	delivery.ID = id
+
+	return true, nil
+}
+
+const webhookDeliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, last_attempt_at,
+	created_at, updated_at`
+
+func scanWebhookDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var lastAttemptAt sql.NullTime
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.EndpointID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&lastAttemptAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.LastAttemptAt = nullTimePtr(lastAttemptAt)
+
+	return &delivery, nil
+}
+
+func (r *mysqlWebhookRepository) GetWebhookDeliveryByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = ?`
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *mysqlWebhookRepository) ListWebhookDeliveriesByEndpoint(ctx context.Context, endpointID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE endpoint_id = ? ORDER BY id DESC LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, endpointID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *mysqlWebhookRepository) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := "UPDATE webhook_deliveries SET status = ?, attempts = ?, last_attempt_at = ? WHERE id = ?"
+	_, err := r.db.ExecContext(ctx, query, delivery.Status, delivery.Attempts, delivery.LastAttemptAt, delivery.ID)
+
+	return err
+}
+
+func (r *mysqlWebhookRepository) CreateWebhookDeliveryAttempt(ctx context.Context, attempt *domain.WebhookDeliveryAttempt) error {
+	query := `
+		INSERT INTO webhook_delivery_attempts (delivery_id, response_status, response_body, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, attempt.DeliveryID, attempt.ResponseStatus, attempt.ResponseBody, attempt.Error, attempt.DurationMS)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	attempt.ID = id
+
+	return nil
+}
+
+func (r *mysqlWebhookRepository) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]*domain.WebhookDeliveryAttempt, error) {
+	query := `
+		SELECT id, delivery_id, response_status, response_body, error, duration_ms, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = ?
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*domain.WebhookDeliveryAttempt
+	for rows.Next() {
+		var attempt domain.WebhookDeliveryAttempt
+		err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.ResponseStatus, &attempt.ResponseBody, &attempt.Error, &attempt.DurationMS, &attempt.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	return attempts, rows.Err()
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+type mysqlWithdrawalRepository struct {
+	db DBTX
+}
+
+func NewWithdrawalRepository(db DBTX) domain.WithdrawalRepository {
+	return &mysqlWithdrawalRepository{
+		db: db,
+	}
+}
+
+const withdrawalColumns = `id, user_id, wallet_id, beneficiary_id, amount, currency, provider, provider_reference, status,
+	failure_reason, attempts, next_attempt_at, completed_at, created_at, updated_at`
+
+func scanWithdrawal(row rowScanner) (*domain.Withdrawal, error) {
+	var withdrawal domain.Withdrawal
+	var providerReference sql.NullString
+	var nextAttemptAt, completedAt sql.NullTime
+
+	err := row.Scan(
+		&withdrawal.ID,
+		&withdrawal.UserID,
+		&withdrawal.WalletID,
+		&withdrawal.BeneficiaryID,
+		&withdrawal.Amount,
+		&withdrawal.Currency,
+		&withdrawal.Provider,
+		&providerReference,
+		&withdrawal.Status,
+		&withdrawal.FailureReason,
+		&withdrawal.Attempts,
+		&nextAttemptAt,
+		&completedAt,
+		&withdrawal.CreatedAt,
+		&withdrawal.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if providerReference.Valid {
+		withdrawal.ProviderReference = &providerReference.String
+	}
+
+	withdrawal.NextAttemptAt = nullTimePtr(nextAttemptAt)
+	withdrawal.CompletedAt = nullTimePtr(completedAt)
+
+	return &withdrawal, nil
+}
+
+func (r *mysqlWithdrawalRepository) CreateWithdrawal(ctx context.Context, withdrawal *domain.Withdrawal) error {
+	query := `
+		INSERT INTO withdrawals (user_id, wallet_id, beneficiary_id, amount, currency, provider, status, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, query, withdrawal.UserID, withdrawal.WalletID, withdrawal.BeneficiaryID,
+		withdrawal.Amount, withdrawal.Currency, withdrawal.Provider, withdrawal.Status, withdrawal.NextAttemptAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	withdrawal.ID = id
+
+	return nil
+}
+
+func (r *mysqlWithdrawalRepository) GetWithdrawalByID(ctx context.Context, id int64) (*domain.Withdrawal, error) {
+	query := `SELECT ` + withdrawalColumns + ` FROM withdrawals WHERE id = ?`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlWithdrawalRepository) GetWithdrawalForUpdate(ctx context.Context, id int64) (*domain.Withdrawal, error) {
+	query := `SELECT ` + withdrawalColumns + ` FROM withdrawals WHERE id = ? FOR UPDATE`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *mysqlWithdrawalRepository) get(ctx context.Context, query string, args ...any) (*domain.Withdrawal, error) {
+	withdrawal, err := scanWithdrawal(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
+
+func (r *mysqlWithdrawalRepository) ListWithdrawalsByUser(ctx context.Context, userID int64) ([]*domain.Withdrawal, error) {
+	query := `SELECT ` + withdrawalColumns + ` FROM withdrawals WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var withdrawals []*domain.Withdrawal
+	for rows.Next() {
+		withdrawal, err := scanWithdrawal(rows)
+		if err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+
+	return withdrawals, rows.Err()
+}
+
+// ListDueWithdrawalIDs returns withdrawals that are waiting to be submitted
+// to the payout provider or checked on, and whose next attempt is due.
+func (r *mysqlWithdrawalRepository) ListDueWithdrawalIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM withdrawals
+		WHERE status IN (?, ?) AND next_attempt_at <= ?
+		ORDER BY next_attempt_at
+		LIMIT ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.WithdrawalStatusPending, domain.WithdrawalStatusProcessing, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *mysqlWithdrawalRepository) UpdateWithdrawal(ctx context.Context, withdrawal *domain.Withdrawal) error {
+	query := `
+		UPDATE withdrawals
+		SET provider_reference = ?, status = ?, failure_reason = ?, attempts = ?, next_attempt_at = ?, completed_at = ?
+		WHERE id = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, withdrawal.ProviderReference, withdrawal.Status, withdrawal.FailureReason,
+		withdrawal.Attempts, withdrawal.NextAttemptAt, withdrawal.CompletedAt, withdrawal.ID)
+
+	return err
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidUserToken     = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+)
+
+const (
+	emailVerificationTTL = 48 * time.Hour
+	passwordResetTTL     = time.Hour
+	userTokenSize        = 32
+)
+
+// VerifySession rejects tokens issued before the user's sessions were last
+// revoked, and tokens of users that no longer exist.
+func (s *userService) VerifySession(ctx context.Context, userID int64, issuedAt time.Time) error {
+	user, err := s.store.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user == nil || user.ErasedAt != nil {
+		return auth.ErrSessionRevoked
+	}
+
+	if user.SessionsValidAfter != nil && issuedAt.Before(*user.SessionsValidAfter) {
+		return auth.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// SendEmailVerification mails the user a new verification link. Links sent
+// earlier stop working.
+func (s *userService) SendEmailVerification(ctx context.Context, userID int64) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	return s.sendEmailVerification(ctx, user)
+}
+
+func (s *userService) sendEmailVerification(ctx context.Context, user *domain.User) error {
+	var token string
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		var err error
+		token, err = issueUserToken(ctx, q, user, domain.UserTokenPurposeEmailVerification, emailVerificationTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in 48 hours. If you did not create a wallet account, you can ignore this email.\n",
+			user.Name, s.link("/verify-email", token)),
+	})
+}
+
+// VerifyEmail redeems a verification token. It fails if the user has changed
+// their email since the token was sent.
+func (s *userService) VerifyEmail(ctx context.Context, token string) error {
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		userToken, err := redeemUserToken(ctx, q, token, domain.UserTokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		verified, err := q.MarkUserEmailVerified(ctx, userToken.UserID, userToken.Email)
+		if err != nil {
+			return err
+		}
+
+		if !verified {
+			return ErrInvalidUserToken
+		}
+
+		return nil
+	})
+}
+
+// ForgotPassword mails a password reset link if email belongs to a user. It
+// returns nil either way so callers cannot probe for accounts; delivery
+// failures are only logged for the same reason.
+func (s *userService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.store.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	var token string
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		var err error
+		token, err = issueUserToken(ctx, q, user, domain.UserTokenPurposePasswordReset, passwordResetTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your wallet account. To choose a new password, open this link:\n\n%s\n\n"+
+			"The link expires in 1 hour and works once. If you did not ask for this, you can ignore this email.\n",
+			user.Name, s.link("/reset-password", token)),
+	})
+	if err != nil {
+		log.Printf("Error sending password reset email to user %d: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ResetPassword redeems a reset token, sets the new password and signs out
+// every session.
+func (s *userService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	var userID int64
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		userToken, err := redeemUserToken(ctx, q, token, domain.UserTokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		userID = userToken.UserID
+		return setPassword(ctx, q, userID, string(hashedPassword))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPasswordChanged(ctx, userID)
+	return nil
+}
+
+// ChangePassword replaces the caller's password and signs out their other
+// sessions. The returned token replaces the one used for this request. A wrong
+// current password counts as a failed login.
+func (s *userService) ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	accountSubject := loginSubject(user.Email)
+
+	if err := checkLoginLock(ctx, s.store, now, accountSubject, client.IP); err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			return recordLoginFailure(ctx, q, now, user, accountSubject, client)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidCredentials
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		return setPassword(ctx, q, userID, string(hashedPassword))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyPasswordChanged(ctx, userID)
+
+	token, err := auth.Sign(s.jwtSecret, auth.Claims{UserID: userID, Type: auth.TypeAccess}, s.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginResult{Token: token}, nil
+}
+
+// setPassword stores a new password hash, revokes every token issued so far
+// and voids outstanding reset links. Revocation is truncated to the second
+// because token issue times are.
+func setPassword(ctx context.Context, q *repository.Queries, userID int64, passwordHash string) error {
+	if err := q.UpdateUserPassword(ctx, userID, passwordHash); err != nil {
+		return err
+	}
+
+	if err := q.RevokeUserSessions(ctx, userID, time.Now().UTC().Truncate(time.Second)); err != nil {
+		return err
+	}
+
+	return q.InvalidateUserTokens(ctx, userID, domain.UserTokenPurposePasswordReset)
+}
+
+// notifyPasswordChanged tells the user their password changed, so that an
+// unexpected change does not go unnoticed. Failures are only logged.
+func (s *userService) notifyPasswordChanged(ctx context.Context, userID int64) {
+	user, err := s.store.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		log.Printf("Error loading user %d for password change notice: %v", userID, err)
+		return
+	}
+
+	err = s.mailer.Send(ctx, domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe password of your wallet account was just changed and your other sessions were signed out.\n\n"+
+			"If this was not you, reset your password right away.\n", user.Name),
+	})
+	if err != nil {
+		log.Printf("Error sending password change notice to user %d: %v", userID, err)
+	}
+}
+
+func (s *userService) link(path, token string) string {
+	return s.appURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// issueUserToken stores the hash of a new random token for user, voiding
+// unused tokens of the same purpose, and returns the token.
+func issueUserToken(ctx context.Context, q *repository.Queries, user *domain.User, purpose domain.UserTokenPurpose, ttl time.Duration) (string, error) {
+	b := make([]byte, userTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	if err := q.InvalidateUserTokens(ctx, user.ID, purpose); err != nil {
+		return "", err
+	}
+
+	err := q.CreateUserToken(ctx, &domain.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashUserToken(token),
+		Email:     user.Email,
+		ExpiresAt: time.Now().UTC().Add(ttl).Truncate(time.Second),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// redeemUserToken locks an unused, unexpired token with one of purposes and
+// marks it used. Every other case is ErrInvalidUserToken.
+func redeemUserToken(ctx context.Context, q *repository.Queries, token string, purposes ...domain.UserTokenPurpose) (*domain.UserToken, error) {
+	userToken, err := q.GetUserTokenByHashForUpdate(ctx, hashUserToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if userToken == nil || !slices.Contains(purposes, userToken.Purpose) || userToken.UsedAt != nil || !userToken.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidUserToken
+	}
+
+	if err := q.MarkUserTokenUsed(ctx, userToken.ID); err != nil {
+		return nil, err
+	}
+
+	return userToken, nil
+}
+
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recoveryStore adds user tokens to loginStore.
+type recoveryStore struct {
+	*loginStore
+	tokens []*domain.UserToken
+}
+
+func (s *recoveryStore) CreateUserToken(ctx context.Context, token *domain.UserToken) error {
+	token.ID = int64(len(s.tokens) + 1)
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
+func (s *recoveryStore) GetUserTokenByHashForUpdate(ctx context.Context, tokenHash string) (*domain.UserToken, error) {
+	for _, token := range s.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *recoveryStore) MarkUserTokenUsed(ctx context.Context, id int64) error {
+	now := time.Now()
+	if token := s.tokens[id-1]; token.UsedAt == nil {
+		token.UsedAt = &now
+	}
+
+	return nil
+}
+
+func (s *recoveryStore) InvalidateUserTokens(ctx context.Context, userID int64, purpose domain.UserTokenPurpose) error {
+	for _, token := range s.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			s.MarkUserTokenUsed(ctx, token.ID)
+		}
+	}
+
+	return nil
+}
+
+func (s *recoveryStore) UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error {
+	user, _ := s.GetByID(ctx, userID)
+	user.Password = passwordHash
+	return nil
+}
+
+func (s *recoveryStore) RevokeUserSessions(ctx context.Context, userID int64, validAfter time.Time) error {
+	user, _ := s.GetByID(ctx, userID)
+	user.SessionsValidAfter = &validAfter
+	return nil
+}
+
+func (s *recoveryStore) MarkUserEmailVerified(ctx context.Context, userID int64, email string) (bool, error) {
+	user, _ := s.GetByID(ctx, userID)
+	if !strings.EqualFold(user.Email, email) {
+		return false, nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return true, nil
+}
+
+type sentMail struct {
+	messages []domain.EmailMessage
+}
+
+func (m *sentMail) Send(ctx context.Context, msg domain.EmailMessage) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var mailedToken = regexp.MustCompile(`token=(\S+)`)
+
+// lastToken returns the token in the link of the last message sent.
+func (m *sentMail) lastToken(t *testing.T) string {
+	t.Helper()
+
+	if len(m.messages) == 0 {
+		t.Fatal("no email was sent")
+	}
+
+	match := mailedToken.FindStringSubmatch(m.messages[len(m.messages)-1].Body)
+	if match == nil {
+		t.Fatalf("last email has no link: %q", m.messages[len(m.messages)-1].Body)
+	}
+
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func newRecoveryService(t *testing.T) (*recoveryStore, *sentMail, domain.UserService) {
+	t.Helper()
+
+	store := &recoveryStore{loginStore: newLoginStore(t)}
+	mail := &sentMail{}
+	return store, mail, NewUserService(txStore{store}, "test-secret", mail, "https://wallet.example")
+}
+
+func TestPasswordResetLifecycle(t *testing.T) {
+	store, mail, svc := newRecoveryService(t)
+	ctx := context.Background()
+	issuedAt := time.Now().Add(-time.Minute)
+
+	if err := svc.ForgotPassword(ctx, "ada@example.com"); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	first := mail.lastToken(t)
+
+	// A second request voids the first link.
+	if err := svc.ForgotPassword(ctx, "ada@example.com"); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	second := mail.lastToken(t)
+
+	if err := svc.ResetPassword(ctx, first, "new password"); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("ResetPassword() with a replaced token error = %v, want %v", err, ErrInvalidUserToken)
+	}
+
+	if err := svc.ResetPassword(ctx, second, "new password"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+
+	user := store.users["ada@example.com"]
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new password")) != nil {
+		t.Error("password was not changed")
+	}
+
+	if err := svc.VerifySession(ctx, user.ID, issuedAt); !errors.Is(err, auth.ErrSessionRevoked) {
+		t.Errorf("VerifySession() for an older token error = %v, want %v", err, auth.ErrSessionRevoked)
+	}
+
+	if subject := mail.messages[len(mail.messages)-1].Subject; subject != "Your password was changed" {
+		t.Errorf("last email subject = %q, want the change notice", subject)
+	}
+
+	// Each link works once.
+	if err := svc.ResetPassword(ctx, second, "another password"); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("ResetPassword() with a used token error = %v, want %v", err, ErrInvalidUserToken)
+	}
+}
+
+func TestResetPasswordRejectsInvalidTokens(t *testing.T) {
+	store, mail, svc := newRecoveryService(t)
+	ctx := context.Background()
+
+	if err := svc.ForgotPassword(ctx, "ada@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	expired := mail.lastToken(t)
+	store.tokens[0].ExpiresAt = time.Now().Add(-time.Second)
+
+	if err := svc.SendEmailVerification(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	verification := mail.lastToken(t)
+
+	for name, token := range map[string]string{
+		"expired":      expired,
+		"verification": verification,
+		"unknown":      "not-a-token",
+	} {
+		if err := svc.ResetPassword(ctx, token, "new password"); !errors.Is(err, ErrInvalidUserToken) {
+			t.Errorf("ResetPassword() with %s token error = %v, want %v", name, err, ErrInvalidUserToken)
+		}
+	}
+
+	if user := store.users["grace@example.com"]; user.SessionsValidAfter != nil {
+		t.Error("sessions were revoked by a rejected token")
+	}
+}
+
+func TestForgotPasswordIgnoresUnknownEmail(t *testing.T) {
+	store, mail, svc := newRecoveryService(t)
+
+	if err := svc.ForgotPassword(context.Background(), "nobody@example.com"); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+
+	if len(mail.messages) != 0 || len(store.tokens) != 0 {
+		t.Errorf("sent %d emails and issued %d tokens, want none", len(mail.messages), len(store.tokens))
+	}
+}
+
+func TestChangePasswordVoidsResetLinks(t *testing.T) {
+	_, mail, svc := newRecoveryService(t)
+	ctx := context.Background()
+
+	if err := svc.ForgotPassword(ctx, "ada@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := mail.lastToken(t)
+
+	if _, err := svc.ChangePassword(ctx, 1, testPassword, "new password", domain.ClientInfo{IP: "203.0.113.1"}); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+
+	if err := svc.ResetPassword(ctx, token, "another password"); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("ResetPassword() after a password change error = %v, want %v", err, ErrInvalidUserToken)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	store, mail, svc := newRecoveryService(t)
+	ctx := context.Background()
+
+	if err := svc.SendEmailVerification(ctx, 1); err != nil {
+		t.Fatalf("SendEmailVerification() error = %v", err)
+	}
+	token := mail.lastToken(t)
+
+	if err := svc.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+
+	if store.users["ada@example.com"].EmailVerifiedAt == nil {
+		t.Error("email was not marked verified")
+	}
+
+	if err := svc.SendEmailVerification(ctx, 1); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("SendEmailVerification() when verified error = %v, want %v", err, ErrEmailAlreadyVerified)
+	}
+}
+
+func TestVerifyEmailFailsAfterEmailChange(t *testing.T) {
+	store, mail, svc := newRecoveryService(t)
+	ctx := context.Background()
+
+	if err := svc.SendEmailVerification(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	token := mail.lastToken(t)
+
+	store.users["ada@example.com"].Email = "ada@king.example"
+
+	if err := svc.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("VerifyEmail() for an old address error = %v, want %v", err, ErrInvalidUserToken)
+	}
+}
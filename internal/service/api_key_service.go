@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+var (
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrInvalidAPIKeyScope  = errors.New("unknown api key scope")
+	ErrInvalidAllowedIP    = errors.New("allowed ips must be IP addresses or CIDR ranges")
+	ErrInvalidAPIKeyExpiry = errors.New("api key expiry must be in the future")
+	ErrTooManyAPIKeys      = errors.New("too many active api keys")
+	ErrAPIKeyRevoked       = errors.New("api key has been revoked")
+)
+
+const maxActiveAPIKeys = 25
+
+type apiKeyService struct {
+	store repository.Store
+}
+
+func NewAPIKeyService(store repository.Store) domain.APIKeyService {
+	return &apiKeyService{
+		store: store,
+	}
+}
+
+// CreateAPIKey creates a key for the user and returns it with the key itself
+// and its signing secret, which cannot be retrieved again.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID int64, name string, scopes, allowedIPs []string, requireSignature bool, expiresAt *time.Time) (*domain.APIKey, error) {
+	now := time.Now()
+
+	granted := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(domain.APIKeyScopes, domain.APIKeyScope(scope)) {
+			return nil, ErrInvalidAPIKeyScope
+		}
+		if !slices.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	allowed := make([]string, 0, len(allowedIPs))
+	for _, ip := range allowedIPs {
+		prefix, err := parseAllowedIP(ip)
+		if err != nil {
+			return nil, ErrInvalidAllowedIP
+		}
+		allowed = append(allowed, prefix.String())
+	}
+
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, ErrInvalidAPIKeyExpiry
+		}
+		utc := expiresAt.UTC().Truncate(time.Second)
+		expiresAt = &utc
+	}
+
+	key, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	signingSecret, err := auth.NewSigningSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &domain.APIKey{
+		UserID:           userID,
+		Name:             name,
+		Prefix:           prefix,
+		KeyHash:          auth.HashAPIKey(key),
+		SigningSecret:    signingSecret,
+		Scopes:           granted,
+		AllowedIPs:       allowed,
+		RequireSignature: requireSignature,
+		ExpiresAt:        expiresAt,
+	}
+
+	count, err := s.store.CountActiveAPIKeys(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= maxActiveAPIKeys {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	if err := s.store.CreateAPIKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	created, err := s.store.GetAPIKeyByID(ctx, apiKey.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	created.Key = key
+	return created, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID int64) ([]*domain.APIKey, error) {
+	keys, err := s.store.ListAPIKeysByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		key.SigningSecret = ""
+	}
+
+	return keys, nil
+}
+
+func (s *apiKeyService) GetAPIKey(ctx context.Context, userID, keyID int64) (*domain.APIKey, error) {
+	key, err := s.ownedAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	key.SigningSecret = ""
+	return key, nil
+}
+
+// RevokeAPIKey revokes the key immediately. Revoking a revoked key succeeds.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID, keyID int64) error {
+	if _, err := s.ownedAPIKey(ctx, userID, keyID); err != nil {
+		return err
+	}
+
+	return s.store.RevokeAPIKey(ctx, keyID, time.Now().UTC())
+}
+
+// RollSigningSecret replaces the key's signing secret and returns the key with
+// the new secret. Requests signed with the old secret fail from then on.
+func (s *apiKeyService) RollSigningSecret(ctx context.Context, userID, keyID int64) (*domain.APIKey, error) {
+	key, err := s.ownedAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	secret, err := auth.NewSigningSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UpdateAPIKeySigningSecret(ctx, keyID, secret); err != nil {
+		return nil, err
+	}
+
+	key.SigningSecret = secret
+	return key, nil
+}
+
+func (s *apiKeyService) ownedAPIKey(ctx context.Context, userID, keyID int64) (*domain.APIKey, error) {
+	key, err := s.store.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil || key.UserID != userID {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+// VerifyAPIKey returns the active key matching key. Unknown, revoked and
+// expired keys are all auth.ErrInvalidAPIKey; a key used from an address
+// outside its allowlist is auth.ErrAPIKeyIPNotAllowed.
+func (s *apiKeyService) VerifyAPIKey(ctx context.Context, key, clientIP string) (*domain.APIKey, error) {
+	prefix, ok := auth.APIKeyPrefix(key)
+	if !ok {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.store.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if apiKey == nil || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(auth.HashAPIKey(key))) != 1 ||
+		apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now)) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	if !ipAllowed(apiKey.AllowedIPs, clientIP) {
+		return nil, auth.ErrAPIKeyIPNotAllowed
+	}
+
+	if err := s.store.TouchAPIKey(ctx, apiKey.ID, now.UTC().Truncate(time.Second)); err != nil {
+		log.Printf("Error recording use of api key %d: %v", apiKey.ID, err)
+	}
+
+	return apiKey, nil
+}
+
+// parseAllowedIP parses an allowlist entry, an address or a CIDR range, as a
+// prefix. A bare address is a single-address prefix.
+func parseAllowedIP(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func ipAllowed(allowlist []string, clientIP string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, entry := range allowlist {
+		prefix, err := parseAllowedIP(entry)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
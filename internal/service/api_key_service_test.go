@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+// apiKeyStore keeps API keys in memory.
+type apiKeyStore struct {
+	repository.Store
+	keys []*domain.APIKey
+}
+
+func (s *apiKeyStore) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	key.ID = int64(len(s.keys) + 1)
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func (s *apiKeyStore) GetAPIKeyByID(ctx context.Context, id int64) (*domain.APIKey, error) {
+	copied := *s.keys[id-1]
+	return &copied, nil
+}
+
+func (s *apiKeyStore) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	for _, key := range s.keys {
+		if key.Prefix == prefix {
+			copied := *key
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *apiKeyStore) CountActiveAPIKeys(ctx context.Context, userID int64, now time.Time) (int, error) {
+	return len(s.keys), nil
+}
+
+func (s *apiKeyStore) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	s.keys[id-1].LastUsedAt = &usedAt
+	return nil
+}
+
+func TestCreateAPIKeyNormalisesScopesAndIPs(t *testing.T) {
+	svc := NewAPIKeyService(&apiKeyStore{})
+	scopes := []string{string(domain.ScopeWalletsRead), string(domain.ScopeTransfersWrite), string(domain.ScopeWalletsRead)}
+
+	key, err := svc.CreateAPIKey(context.Background(), 1, "CI", scopes, []string{"203.0.113.9/24", "::ffff:198.51.100.7"}, false, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	if len(key.Scopes) != 2 || !key.HasScope(domain.ScopeTransfersWrite) || key.HasScope(domain.ScopeTransfersRead) {
+		t.Errorf("scopes = %v, want wallets:read and transfers:write", key.Scopes)
+	}
+
+	if len(key.AllowedIPs) != 2 || key.AllowedIPs[0] != "203.0.113.0/24" || key.AllowedIPs[1] != "198.51.100.7/32" {
+		t.Errorf("allowed IPs = %v, want masked prefixes", key.AllowedIPs)
+	}
+
+	if !auth.IsAPIKey(key.Key) || key.SigningSecret == "" {
+		t.Error("the key and signing secret were not returned")
+	}
+}
+
+func TestCreateAPIKeyRejectsInvalidInput(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		scopes    []string
+		allowed   []string
+		expiresAt *time.Time
+		want      error
+	}{
+		{name: "unknown scope", scopes: []string{"admin"}, want: ErrInvalidAPIKeyScope},
+		{name: "bad address", allowed: []string{"example.com"}, want: ErrInvalidAllowedIP},
+		{name: "past expiry", expiresAt: &past, want: ErrInvalidAPIKeyExpiry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &apiKeyStore{}
+			_, err := NewAPIKeyService(store).CreateAPIKey(context.Background(), 1, "CI", tt.scopes, tt.allowed, false, tt.expiresAt)
+			if !errors.Is(err, tt.want) || len(store.keys) != 0 {
+				t.Errorf("CreateAPIKey() error = %v with %d keys stored, want %v", err, len(store.keys), tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAPIKey(t *testing.T) {
+	store := &apiKeyStore{}
+	svc := NewAPIKeyService(store)
+	ctx := context.Background()
+
+	key, err := svc.CreateAPIKey(ctx, 1, "CI", []string{string(domain.ScopeWalletsRead)}, []string{"203.0.113.0/24"}, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err := svc.VerifyAPIKey(ctx, key.Key, "203.0.113.9")
+	if err != nil {
+		t.Fatalf("VerifyAPIKey() error = %v", err)
+	}
+
+	if verified.UserID != 1 || !verified.HasScope(domain.ScopeWalletsRead) || store.keys[0].LastUsedAt == nil {
+		t.Errorf("VerifyAPIKey() = %+v, want user 1's key marked used", verified)
+	}
+
+	if _, err := svc.VerifyAPIKey(ctx, key.Key, "198.51.100.1"); !errors.Is(err, auth.ErrAPIKeyIPNotAllowed) {
+		t.Errorf("VerifyAPIKey() from outside the allowlist error = %v, want %v", err, auth.ErrAPIKeyIPNotAllowed)
+	}
+
+	// A guessed secret for a real prefix is refused like an unknown key.
+	prefix, _ := auth.APIKeyPrefix(key.Key)
+	if _, err := svc.VerifyAPIKey(ctx, auth.APIKeyMarker+prefix+"_guess", "203.0.113.9"); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Errorf("VerifyAPIKey() with a wrong secret error = %v, want %v", err, auth.ErrInvalidAPIKey)
+	}
+
+	expired := time.Now().Add(-time.Second)
+	store.keys[0].ExpiresAt = &expired
+	if _, err := svc.VerifyAPIKey(ctx, key.Key, "203.0.113.9"); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Errorf("VerifyAPIKey() of an expired key error = %v, want %v", err, auth.ErrInvalidAPIKey)
+	}
+
+	store.keys[0].ExpiresAt = nil
+	store.keys[0].RevokedAt = &expired
+	if _, err := svc.VerifyAPIKey(ctx, key.Key, "203.0.113.9"); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Errorf("VerifyAPIKey() of a revoked key error = %v, want %v", err, auth.ErrInvalidAPIKey)
+	}
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+)
+
+var (
+	ErrCheckoutSessionNotFound    = errors.New("checkout session not found")
+	ErrCheckoutSessionNotOpen     = errors.New("checkout session is no longer open")
+	ErrCheckoutSessionExpired     = errors.New("checkout session has expired")
+	ErrInvalidCheckoutSessionTTL  = errors.New("checkout session expiry is out of range")
+	ErrInvalidRedirectURL         = errors.New("redirect urls must be absolute http or https urls")
+	ErrDuplicateCheckoutReference = errors.New("a checkout session with this reference already exists")
+)
+
+const (
+	DefaultCheckoutSessionTTL = time.Hour
+	MaxCheckoutSessionTTL     = 24 * time.Hour
+	checkoutSessionBatchSize  = 100
+	checkoutTokenPrefix       = "cs_"
+)
+
+type checkoutService struct {
+	store repository.Store
+}
+
+func NewCheckoutService(store repository.Store) domain.CheckoutService {
+	return &checkoutService{
+		store: store,
+	}
+}
+
+// CreateCheckoutSession opens a session for the user's merchant. The currency
+// must be that of the merchant's settlement wallet, and references are unique
+// per merchant so that a retried request cannot open a second session.
+func (s *checkoutService) CreateCheckoutSession(ctx context.Context, userID int64, params domain.CheckoutSessionParams) (*domain.CheckoutSession, error) {
+	if !params.Amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	if params.TTL == 0 {
+		params.TTL = DefaultCheckoutSessionTTL
+	}
+
+	if params.TTL < time.Minute || params.TTL > MaxCheckoutSessionTTL {
+		return nil, ErrInvalidCheckoutSessionTTL
+	}
+
+	successURL, err := parseRedirectURL(params.SuccessURL)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelURL, err := parseRedirectURL(params.CancelURL)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newCheckoutToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var created *domain.CheckoutSession
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		merchant, err := q.GetMerchantByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if merchant == nil {
+			return ErrMerchantNotFound
+		}
+
+		if merchant.Status != domain.MerchantStatusActive {
+			return ErrMerchantDisabled
+		}
+
+		wallet, err := q.GetWalletByID(ctx, merchant.SettlementWalletID)
+		if err != nil {
+			return err
+		}
+
+		if wallet == nil {
+			return ErrWalletNotFound
+		}
+
+		if wallet.Currency != params.Currency {
+			return ErrCurrencyMismatch
+		}
+
+		existing, err := q.GetCheckoutSessionByReference(ctx, merchant.ID, params.Reference)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return ErrDuplicateCheckoutReference
+		}
+
+		session := &domain.CheckoutSession{
+			MerchantID:  merchant.ID,
+			Token:       token,
+			Amount:      params.Amount,
+			Currency:    params.Currency,
+			Reference:   params.Reference,
+			Description: params.Description,
+			SuccessURL:  successURL,
+			CancelURL:   cancelURL,
+			Status:      domain.CheckoutSessionStatusOpen,
+			ExpiresAt:   time.Now().UTC().Add(params.TTL).Truncate(time.Second),
+		}
+
+		if err := q.CreateCheckoutSession(ctx, session); err != nil {
+			return err
+		}
+
+		if err := publishCheckoutSessionEvent(ctx, q, tasks.TopicCheckoutSessionCreated, merchant, session); err != nil {
+			return err
+		}
+
+		created = session
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetCheckoutSessionByID(ctx, created.ID)
+}
+
+func (s *checkoutService) ListCheckoutSessions(ctx context.Context, userID int64) ([]*domain.CheckoutSession, error) {
+	merchant, err := s.store.GetMerchantByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant == nil {
+		return nil, ErrMerchantNotFound
+	}
+
+	return s.store.ListCheckoutSessionsByMerchant(ctx, merchant.ID)
+}
+
+func (s *checkoutService) GetCheckoutSession(ctx context.Context, userID, id int64) (*domain.CheckoutSession, error) {
+	merchant, err := s.store.GetMerchantByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.store.GetCheckoutSessionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant == nil || session == nil || session.MerchantID != merchant.ID {
+		return nil, ErrCheckoutSessionNotFound
+	}
+
+	return session, nil
+}
+
+func (s *checkoutService) CancelCheckoutSession(ctx context.Context, userID, id int64) (*domain.CheckoutSession, error) {
+	return s.transition(ctx, id, func(q *repository.Queries, merchant *domain.Merchant, session *domain.CheckoutSession) (string, error) {
+		if merchant.UserID != userID {
+			return "", ErrCheckoutSessionNotFound
+		}
+
+		session.Status = domain.CheckoutSessionStatusCancelled
+		return tasks.TopicCheckoutSessionCancelled, nil
+	})
+}
+
+// GetCheckoutSessionForPayer returns the session with the given token,
+// together with the merchant's business name.
+func (s *checkoutService) GetCheckoutSessionForPayer(ctx context.Context, token string) (*domain.CheckoutSession, error) {
+	session, err := s.store.GetCheckoutSessionByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil {
+		return nil, ErrCheckoutSessionNotFound
+	}
+
+	merchant, err := s.store.GetMerchantByID(ctx, session.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant == nil {
+		return nil, ErrCheckoutSessionNotFound
+	}
+
+	session.MerchantName = merchant.BusinessName
+	return session, nil
+}
+
+// ConfirmCheckoutSession pays the session from the payer's wallet. The
+// transfer to the merchant's settlement wallet is created in the same
+// database transaction that moves the session to PROCESSING; the worker
+// completes or fails the session when it settles the transfer.
+func (s *checkoutService) ConfirmCheckoutSession(ctx context.Context, payerUserID int64, token string) (*domain.CheckoutSession, error) {
+	found, err := s.store.GetCheckoutSessionByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, ErrCheckoutSessionNotFound
+	}
+
+	return s.transition(ctx, found.ID, func(q *repository.Queries, merchant *domain.Merchant, session *domain.CheckoutSession) (string, error) {
+		if merchant.Status != domain.MerchantStatusActive {
+			return "", ErrMerchantDisabled
+		}
+
+		settlementWallet, err := q.GetWalletByID(ctx, merchant.SettlementWalletID)
+		if err != nil {
+			return "", err
+		}
+
+		payerWallet, err := q.GetByUserID(ctx, payerUserID)
+		if err != nil {
+			return "", err
+		}
+
+		if settlementWallet == nil || payerWallet == nil {
+			return "", ErrWalletNotFound
+		}
+
+		if payerWallet.Currency != session.Currency || settlementWallet.Currency != session.Currency {
+			return "", ErrCurrencyMismatch
+		}
+
+		tx, err := createTransfer(ctx, q, payerUserID, settlementWallet.UserID, session.Amount, "")
+		if err != nil {
+			return "", err
+		}
+
+		session.Status = domain.CheckoutSessionStatusProcessing
+		session.PayerUserID = &payerUserID
+		session.TransactionID = &tx.ID
+		return tasks.TopicCheckoutSessionConfirmed, nil
+	})
+}
+
+// transition locks an open, unexpired session, lets apply move it to a new
+// state and persists the result together with the outbox event apply names.
+func (s *checkoutService) transition(ctx context.Context, id int64, apply func(*repository.Queries, *domain.Merchant, *domain.CheckoutSession) (string, error)) (*domain.CheckoutSession, error) {
+	var updated *domain.CheckoutSession
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		session, err := q.GetCheckoutSessionForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if session == nil {
+			return ErrCheckoutSessionNotFound
+		}
+
+		merchant, err := q.GetMerchantByID(ctx, session.MerchantID)
+		if err != nil {
+			return err
+		}
+
+		if merchant == nil {
+			return ErrCheckoutSessionNotFound
+		}
+
+		if session.Status != domain.CheckoutSessionStatusOpen {
+			return ErrCheckoutSessionNotOpen
+		}
+
+		if !session.ExpiresAt.After(time.Now()) {
+			return ErrCheckoutSessionExpired
+		}
+
+		topic, err := apply(q, merchant, session)
+		if err != nil {
+			return err
+		}
+
+		if err := q.UpdateCheckoutSession(ctx, session); err != nil {
+			return err
+		}
+
+		if err := publishCheckoutSessionEvent(ctx, q, topic, merchant, session); err != nil {
+			return err
+		}
+
+		updated = session
+		return nil
+	})
+
+	return updated, err
+}
+
+// ExpireCheckoutSessions marks open sessions whose expiry has passed as
+// EXPIRED and returns how many were expired.
+func (s *checkoutService) ExpireCheckoutSessions(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListExpiredCheckoutSessionIDs(ctx, now, checkoutSessionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		changed := false
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			session, err := q.GetCheckoutSessionForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if session == nil || session.Status != domain.CheckoutSessionStatusOpen || session.ExpiresAt.After(now) {
+				return nil
+			}
+
+			merchant, err := q.GetMerchantByID(ctx, session.MerchantID)
+			if err != nil {
+				return err
+			}
+
+			session.Status = domain.CheckoutSessionStatusExpired
+			if err := q.UpdateCheckoutSession(ctx, session); err != nil {
+				return err
+			}
+
+			changed = true
+			return publishCheckoutSessionEvent(ctx, q, tasks.TopicCheckoutSessionExpired, merchant, session)
+		})
+		if err != nil {
+			log.Printf("Error expiring checkout session %d: %v", id, err)
+			continue
+		}
+
+		if changed {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// settleCheckoutSession completes or fails the session paid by tx, if any,
+// once the worker has settled tx. It runs in the transaction that settles tx.
+func settleCheckoutSession(ctx context.Context, q *repository.Queries, tx *domain.Transaction, reason string) error {
+	session, err := q.GetCheckoutSessionByTransactionForUpdate(ctx, tx.ID)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || session.Status != domain.CheckoutSessionStatusProcessing {
+		return nil
+	}
+
+	merchant, err := q.GetMerchantByID(ctx, session.MerchantID)
+	if err != nil {
+		return err
+	}
+
+	topic := tasks.TopicCheckoutSessionFailed
+	if tx.Status == domain.TransactionStatusCompleted {
+		topic = tasks.TopicCheckoutSessionCompleted
+		completedAt := time.Now().UTC().Truncate(time.Second)
+		session.Status = domain.CheckoutSessionStatusCompleted
+		session.CompletedAt = &completedAt
+	} else {
+		session.Status = domain.CheckoutSessionStatusFailed
+		session.FailureReason = truncate(reason, 255)
+	}
+
+	if err := q.UpdateCheckoutSession(ctx, session); err != nil {
+		return err
+	}
+
+	return publishCheckoutSessionEvent(ctx, q, topic, merchant, session)
+}
+
+func publishCheckoutSessionEvent(ctx context.Context, q *repository.Queries, topic string, merchant *domain.Merchant, session *domain.CheckoutSession) error {
+	payload := tasks.CheckoutSessionEventPayload{
+		CheckoutSessionID: session.ID,
+		MerchantID:        session.MerchantID,
+		Reference:         session.Reference,
+		Amount:            session.Amount,
+		Currency:          session.Currency,
+		Status:            string(session.Status),
+		PayerUserID:       session.PayerUserID,
+		TransactionID:     session.TransactionID,
+		Reason:            session.FailureReason,
+	}
+	if merchant != nil {
+		payload.MerchantUserID = merchant.UserID
+	}
+
+	return publishEvent(ctx, q, topic, payload)
+}
+
+// parseRedirectURL returns rawURL normalised, or ErrInvalidRedirectURL if it
+// is not an absolute http or https URL.
+func parseRedirectURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrInvalidRedirectURL
+	}
+
+	return u.String(), nil
+}
+
+// newCheckoutToken returns a random session token carrying 192 bits.
+func newCheckoutToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return checkoutTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+const revenueUserID = 99
+
+func (s *ledgerStore) GetMerchantByID(ctx context.Context, id int64) (*domain.Merchant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merchant, ok := s.merchants[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *merchant
+	return &copied, nil
+}
+
+func (s *ledgerStore) CreateCheckoutSession(ctx context.Context, session *domain.CheckoutSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.ID = int64(len(s.checkoutSessions) + 1)
+	copied := *session
+	s.checkoutSessions[session.ID] = &copied
+	return nil
+}
+
+// findCheckoutSession returns a copy of the first session matching match.
+func (s *ledgerStore) findCheckoutSession(match func(*domain.CheckoutSession) bool) *domain.CheckoutSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := int64(1); id <= int64(len(s.checkoutSessions)); id++ {
+		if session := s.checkoutSessions[id]; match(session) {
+			copied := *session
+			return &copied
+		}
+	}
+
+	return nil
+}
+
+func (s *ledgerStore) GetCheckoutSessionByID(ctx context.Context, id int64) (*domain.CheckoutSession, error) {
+	return s.findCheckoutSession(func(session *domain.CheckoutSession) bool { return session.ID == id }), nil
+}
+
+func (s *ledgerStore) GetCheckoutSessionForUpdate(ctx context.Context, id int64) (*domain.CheckoutSession, error) {
+	return s.GetCheckoutSessionByID(ctx, id)
+}
+
+func (s *ledgerStore) GetCheckoutSessionByToken(ctx context.Context, token string) (*domain.CheckoutSession, error) {
+	return s.findCheckoutSession(func(session *domain.CheckoutSession) bool { return session.Token == token }), nil
+}
+
+func (s *ledgerStore) GetCheckoutSessionByReference(ctx context.Context, merchantID int64, reference string) (*domain.CheckoutSession, error) {
+	return s.findCheckoutSession(func(session *domain.CheckoutSession) bool {
+		return session.MerchantID == merchantID && session.Reference == reference
+	}), nil
+}
+
+func (s *ledgerStore) ListExpiredCheckoutSessionIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id := int64(1); id <= int64(len(s.checkoutSessions)) && len(ids) < limit; id++ {
+		if session := s.checkoutSessions[id]; session.Status == domain.CheckoutSessionStatusOpen && !session.ExpiresAt.After(now) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *ledgerStore) UpdateCheckoutSession(ctx context.Context, session *domain.CheckoutSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *session
+	s.checkoutSessions[session.ID] = &copied
+	return nil
+}
+
+// addMerchant makes userID an active merchant settling into their wallet on
+// the "standard" plan, which charges 2.9% into user 99's wallet.
+func (s *ledgerStore) addMerchant(userID int64) *domain.Merchant {
+	s.addUser(revenueUserID, "0")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan := "standard"
+	s.feeSchedules = append(s.feeSchedules, &domain.FeeSchedule{
+		ID:         int64(len(s.feeSchedules) + 1),
+		Plan:       &plan,
+		Type:       domain.FeeTypePercentage,
+		Percentage: decimal.RequireFromString("2.9"),
+		Active:     true,
+	})
+	s.revenueWallets["USD"] = walletID(revenueUserID)
+
+	merchant := &domain.Merchant{
+		ID:                 int64(len(s.merchants) + 1),
+		UserID:             userID,
+		BusinessName:       "Grace's Bakery",
+		SettlementWalletID: walletID(userID),
+		FeePlan:            plan,
+		Status:             domain.MerchantStatusActive,
+	}
+	s.merchants[merchant.ID] = merchant
+
+	return merchant
+}
+
+func (s *ledgerStore) outboxTopics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var topics []string
+	for _, event := range s.outbox {
+		if event.Topic != tasks.TaskTypeProcessTransfer {
+			topics = append(topics, event.Topic)
+		}
+	}
+
+	return topics
+}
+
+func checkoutParams(reference string) domain.CheckoutSessionParams {
+	return domain.CheckoutSessionParams{
+		Amount:     decimal.RequireFromString("10.00"),
+		Currency:   "USD",
+		Reference:  reference,
+		SuccessURL: "https://shop.example/done",
+		CancelURL:  "https://shop.example/cart",
+	}
+}
+
+// newCheckoutStore has user 1 with 100.00 pay merchant user 2.
+func newCheckoutStore() *ledgerStore {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	store.addMerchant(2)
+	return store
+}
+
+func TestCheckoutSessionIsPaid(t *testing.T) {
+	store := newCheckoutStore()
+	svc := NewCheckoutService(store)
+	ctx := context.Background()
+
+	session, err := svc.CreateCheckoutSession(ctx, 2, checkoutParams("order-1"))
+	if err != nil {
+		t.Fatalf("CreateCheckoutSession() error = %v", err)
+	}
+
+	if session.Status != domain.CheckoutSessionStatusOpen || session.ExpiresAt.Sub(time.Now()) > DefaultCheckoutSessionTTL {
+		t.Errorf("session = %+v, want OPEN for the default TTL", session)
+	}
+
+	shown, err := svc.GetCheckoutSessionForPayer(ctx, session.Token)
+	if err != nil || shown.MerchantName != "Grace's Bakery" {
+		t.Fatalf("GetCheckoutSessionForPayer() = %+v, %v, want the merchant's name", shown, err)
+	}
+
+	confirmed, err := svc.ConfirmCheckoutSession(ctx, 1, session.Token)
+	if err != nil {
+		t.Fatalf("ConfirmCheckoutSession() error = %v", err)
+	}
+
+	if confirmed.Status != domain.CheckoutSessionStatusProcessing || confirmed.TransactionID == nil || *confirmed.PayerUserID != 1 {
+		t.Fatalf("confirmed session = %+v, want PROCESSING with a transaction", confirmed)
+	}
+
+	// Nothing moves until the worker settles the transfer.
+	store.assertBalance(t, 1, "100.00", "0")
+
+	store.settleTransfers(t)
+
+	completed, _ := svc.GetCheckoutSession(ctx, 2, session.ID)
+	if completed.Status != domain.CheckoutSessionStatusCompleted || completed.CompletedAt == nil {
+		t.Errorf("session after settlement = %+v, want COMPLETED", completed)
+	}
+
+	// The merchant's plan takes 2.9% out of what the merchant receives.
+	store.assertBalance(t, 1, "90.00", "0")
+	store.assertBalance(t, 2, "9.71", "0")
+	store.assertBalance(t, revenueUserID, "0.29", "0")
+
+	want := []string{tasks.TopicCheckoutSessionCreated, tasks.TopicCheckoutSessionConfirmed, tasks.TopicCheckoutSessionCompleted}
+	if topics := store.outboxTopics(); !containsInOrder(topics, want) {
+		t.Errorf("events = %v, want %v in order", topics, want)
+	}
+}
+
+// containsInOrder reports whether want is a subsequence of got.
+func containsInOrder(got, want []string) bool {
+	for _, topic := range got {
+		if len(want) > 0 && topic == want[0] {
+			want = want[1:]
+		}
+	}
+
+	return len(want) == 0
+}
+
+func TestCheckoutSessionIsPaidOnce(t *testing.T) {
+	store := newCheckoutStore()
+	store.addUser(3, "100.00")
+	svc := NewCheckoutService(store)
+	ctx := context.Background()
+
+	session, err := svc.CreateCheckoutSession(ctx, 2, checkoutParams("order-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.ConfirmCheckoutSession(ctx, 1, session.Token); err != nil {
+		t.Fatalf("ConfirmCheckoutSession() error = %v", err)
+	}
+
+	if _, err := svc.ConfirmCheckoutSession(ctx, 3, session.Token); !errors.Is(err, ErrCheckoutSessionNotOpen) {
+		t.Errorf("second ConfirmCheckoutSession() error = %v, want %v", err, ErrCheckoutSessionNotOpen)
+	}
+
+	if _, err := svc.CancelCheckoutSession(ctx, 2, session.ID); !errors.Is(err, ErrCheckoutSessionNotOpen) {
+		t.Errorf("CancelCheckoutSession() of a paid session error = %v, want %v", err, ErrCheckoutSessionNotOpen)
+	}
+
+	store.settleTransfers(t)
+	store.assertBalance(t, 3, "100.00", "0")
+}
+
+func TestCheckoutSessionFailsWithTransfer(t *testing.T) {
+	store := newCheckoutStore()
+	svc := NewCheckoutService(store)
+	ctx := context.Background()
+
+	session, err := svc.CreateCheckoutSession(ctx, 2, checkoutParams("order-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.ConfirmCheckoutSession(ctx, 1, session.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	// The payer spends the money before the worker settles.
+	store.wallets[walletID(1)].Balance = decimal.RequireFromString("5.00")
+	store.settleTransfers(t)
+
+	failed, _ := svc.GetCheckoutSession(ctx, 2, session.ID)
+	if failed.Status != domain.CheckoutSessionStatusFailed || failed.FailureReason != ErrInsufficientFunds.Error() {
+		t.Errorf("session = %+v, want FAILED for insufficient funds", failed)
+	}
+
+	store.assertBalance(t, 2, "0", "0")
+}
+
+func TestConfirmCheckoutSessionRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(store *ledgerStore, session *domain.CheckoutSession)
+		want  error
+	}{
+		{name: "expired", want: ErrCheckoutSessionExpired, setup: func(store *ledgerStore, session *domain.CheckoutSession) {
+			store.checkoutSessions[session.ID].ExpiresAt = time.Now().Add(-time.Second)
+		}},
+		{name: "cancelled", want: ErrCheckoutSessionNotOpen, setup: func(store *ledgerStore, session *domain.CheckoutSession) {
+			store.checkoutSessions[session.ID].Status = domain.CheckoutSessionStatusCancelled
+		}},
+		{name: "merchant disabled", want: ErrMerchantDisabled, setup: func(store *ledgerStore, session *domain.CheckoutSession) {
+			store.merchants[session.MerchantID].Status = domain.MerchantStatusDisabled
+		}},
+		{name: "payer in another currency", want: ErrCurrencyMismatch, setup: func(store *ledgerStore, session *domain.CheckoutSession) {
+			store.wallets[walletID(1)].Currency = "EUR"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newCheckoutStore()
+			svc := NewCheckoutService(store)
+
+			session, err := svc.CreateCheckoutSession(context.Background(), 2, checkoutParams("order-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.setup(store, session)
+
+			if _, err := svc.ConfirmCheckoutSession(context.Background(), 1, session.Token); !errors.Is(err, tt.want) {
+				t.Errorf("ConfirmCheckoutSession() error = %v, want %v", err, tt.want)
+			}
+
+			if len(store.transactions) != 0 {
+				t.Errorf("created %d transactions, want none", len(store.transactions))
+			}
+		})
+	}
+}
+
+func TestCreateCheckoutSessionRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID int64
+		params func(p *domain.CheckoutSessionParams)
+		want   error
+	}{
+		{name: "not a merchant", userID: 1, want: ErrMerchantNotFound},
+		{name: "zero amount", userID: 2, want: ErrInvalidAmount, params: func(p *domain.CheckoutSessionParams) { p.Amount = decimal.Zero }},
+		{name: "short ttl", userID: 2, want: ErrInvalidCheckoutSessionTTL, params: func(p *domain.CheckoutSessionParams) { p.TTL = time.Second }},
+		{name: "long ttl", userID: 2, want: ErrInvalidCheckoutSessionTTL, params: func(p *domain.CheckoutSessionParams) { p.TTL = MaxCheckoutSessionTTL + time.Minute }},
+		{name: "relative url", userID: 2, want: ErrInvalidRedirectURL, params: func(p *domain.CheckoutSessionParams) { p.SuccessURL = "/done" }},
+		{name: "script url", userID: 2, want: ErrInvalidRedirectURL, params: func(p *domain.CheckoutSessionParams) { p.CancelURL = "javascript:alert(1)" }},
+		{name: "other currency", userID: 2, want: ErrCurrencyMismatch, params: func(p *domain.CheckoutSessionParams) { p.Currency = "EUR" }},
+		{name: "duplicate reference", userID: 2, want: ErrDuplicateCheckoutReference, params: func(p *domain.CheckoutSessionParams) { p.Reference = "taken" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newCheckoutStore()
+			svc := NewCheckoutService(store)
+
+			if _, err := svc.CreateCheckoutSession(context.Background(), 2, checkoutParams("taken")); err != nil {
+				t.Fatal(err)
+			}
+
+			params := checkoutParams("order-1")
+			if tt.params != nil {
+				tt.params(&params)
+			}
+
+			if _, err := svc.CreateCheckoutSession(context.Background(), tt.userID, params); !errors.Is(err, tt.want) {
+				t.Errorf("CreateCheckoutSession() error = %v, want %v", err, tt.want)
+			}
+
+			if len(store.checkoutSessions) != 1 {
+				t.Errorf("stored %d sessions, want only the first", len(store.checkoutSessions))
+			}
+		})
+	}
+}
+
+func TestCancelCheckoutSessionChecksOwner(t *testing.T) {
+	store := newCheckoutStore()
+	store.addUser(3, "0")
+	store.addMerchant(3)
+	svc := NewCheckoutService(store)
+	ctx := context.Background()
+
+	session, err := svc.CreateCheckoutSession(ctx, 2, checkoutParams("order-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.CancelCheckoutSession(ctx, 3, session.ID); !errors.Is(err, ErrCheckoutSessionNotFound) {
+		t.Errorf("CancelCheckoutSession() by another merchant error = %v, want %v", err, ErrCheckoutSessionNotFound)
+	}
+
+	if _, err := svc.GetCheckoutSession(ctx, 3, session.ID); !errors.Is(err, ErrCheckoutSessionNotFound) {
+		t.Errorf("GetCheckoutSession() by another merchant error = %v, want %v", err, ErrCheckoutSessionNotFound)
+	}
+
+	cancelled, err := svc.CancelCheckoutSession(ctx, 2, session.ID)
+	if err != nil || cancelled.Status != domain.CheckoutSessionStatusCancelled {
+		t.Fatalf("CancelCheckoutSession() = %+v, %v, want CANCELLED", cancelled, err)
+	}
+
+	if _, err := svc.ConfirmCheckoutSession(ctx, 1, session.Token); !errors.Is(err, ErrCheckoutSessionNotOpen) {
+		t.Errorf("ConfirmCheckoutSession() after cancelling error = %v, want %v", err, ErrCheckoutSessionNotOpen)
+	}
+}
+
+func TestExpireCheckoutSessions(t *testing.T) {
+	store := newCheckoutStore()
+	svc := NewCheckoutService(store)
+	ctx := context.Background()
+
+	for _, reference := range []string{"stale", "paid", "fresh"} {
+		if _, err := svc.CreateCheckoutSession(ctx, 2, checkoutParams(reference)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := svc.ConfirmCheckoutSession(ctx, 1, store.checkoutSessions[2].Token); err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Second)
+	store.checkoutSessions[1].ExpiresAt = past
+	store.checkoutSessions[2].ExpiresAt = past
+
+	expired, err := svc.ExpireCheckoutSessions(ctx, time.Now())
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireCheckoutSessions() = %d, %v, want 1", expired, err)
+	}
+
+	for id, want := range map[int64]domain.CheckoutSessionStatus{
+		1: domain.CheckoutSessionStatusExpired,
+		2: domain.CheckoutSessionStatusProcessing,
+		3: domain.CheckoutSessionStatusOpen,
+	} {
+		if got := store.checkoutSessions[id].Status; got != want {
+			t.Errorf("session %d status = %s, want %s", id, got, want)
+		}
+	}
+}
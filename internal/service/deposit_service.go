@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrDepositNotFound        = errors.New("deposit not found")
+	ErrUnknownGateway         = errors.New("unknown payment gateway")
+	ErrInvalidGatewayCallback = errors.New("gateway callback could not be verified")
+	ErrGatewayUnavailable     = errors.New("payment gateway is unavailable")
+)
+
+// MaxDepositAmount is the largest amount a single deposit may be for.
+var MaxDepositAmount = decimal.NewFromInt(10000)
+
+const (
+	// DepositTTL is how long the user has to complete the payment at the
+	// gateway before the deposit fails.
+	DepositTTL         = 24 * time.Hour
+	depositBatchSize   = 100
+	depositExpiredText = "payment was not completed in time"
+)
+
+type depositService struct {
+	store   repository.Store
+	gateway domain.PaymentGateway
+}
+
+func NewDepositService(store repository.Store, gateway domain.PaymentGateway) domain.DepositService {
+	return &depositService{
+		store:   store,
+		gateway: gateway,
+	}
+}
+
+// CreateDeposit records a PENDING deposit of up to MaxDepositAmount into the
+// user's wallet and creates
+// the payment intent at the gateway. The deposit is committed before the
+// gateway is called, so a callback can always find it; if the gateway cannot
+// be reached the deposit is failed straight away.
+func (s *depositService) CreateDeposit(ctx context.Context, userID int64, amount decimal.Decimal) (*domain.Deposit, error) {
+	switch {
+	case !amount.IsPositive():
+		return nil, ErrInvalidAmount
+	case !amount.Equal(amount.Round(2)):
+		return nil, fmt.Errorf("%w: amount has more than 2 decimal places", ErrInvalidAmount)
+	case amount.GreaterThan(MaxDepositAmount):
+		return nil, fmt.Errorf("%w: a deposit can be at most %s", ErrInvalidAmount, MaxDepositAmount)
+	}
+
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	deposit := &domain.Deposit{
+		UserID:    userID,
+		WalletID:  wallet.ID,
+		Amount:    amount,
+		Currency:  wallet.Currency,
+		Gateway:   s.gateway.Name(),
+		Status:    domain.DepositStatusPending,
+		ExpiresAt: time.Now().Add(DepositTTL),
+	}
+
+	if err := s.store.CreateDeposit(ctx, deposit); err != nil {
+		return nil, err
+	}
+
+	intent, gatewayErr := s.gateway.CreatePaymentIntent(ctx, deposit)
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		locked, err := q.GetDepositForUpdate(ctx, deposit.ID)
+		if err != nil {
+			return err
+		}
+
+		if gatewayErr != nil {
+			return failDeposit(ctx, q, locked, "payment gateway is unavailable")
+		}
+
+		locked.GatewayReference = &intent.Reference
+		locked.PaymentURL = intent.PaymentURL
+		if err := q.UpdateDeposit(ctx, locked); err != nil {
+			return err
+		}
+
+		return publishDepositEvent(ctx, q, tasks.TopicDepositCreated, locked)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if gatewayErr != nil {
+		log.Printf("Error creating payment intent for deposit %d: %v", deposit.ID, gatewayErr)
+		return nil, ErrGatewayUnavailable
+	}
+
+	return s.store.GetDepositByID(ctx, deposit.ID)
+}
+
+func (s *depositService) ListDeposits(ctx context.Context, userID int64) ([]*domain.Deposit, error) {
+	return s.store.ListDepositsByUser(ctx, userID)
+}
+
+func (s *depositService) GetDeposit(ctx context.Context, userID, id int64) (*domain.Deposit, error) {
+	deposit, err := s.store.GetDepositByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if deposit == nil || deposit.UserID != userID {
+		return nil, ErrDepositNotFound
+	}
+
+	return deposit, nil
+}
+
+// HandleGatewayCallback applies a callback from the named gateway. It is
+// idempotent: gateways retry callbacks, and only the first one to find the
+// deposit PENDING credits the wallet or fails the deposit. A callback whose
+// amount or currency disagrees with the deposit is rejected.
+func (s *depositService) HandleGatewayCallback(ctx context.Context, gateway string, header http.Header, body []byte) (*domain.Deposit, error) {
+	if gateway != s.gateway.Name() {
+		return nil, ErrUnknownGateway
+	}
+
+	callback, err := s.gateway.ParseCallback(header, body)
+	if err != nil {
+		log.Printf("Rejected %s gateway callback: %v", gateway, err)
+		return nil, ErrInvalidGatewayCallback
+	}
+
+	var result *domain.Deposit
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		deposit, err := q.GetDepositByGatewayReferenceForUpdate(ctx, gateway, callback.Reference)
+		if err != nil {
+			return err
+		}
+
+		if deposit == nil {
+			return ErrDepositNotFound
+		}
+
+		result = deposit
+
+		if deposit.Status != domain.DepositStatusPending {
+			return nil
+		}
+
+		if !callback.Succeeded {
+			reason := callback.FailureReason
+			if reason == "" {
+				reason = "payment failed"
+			}
+			return failDeposit(ctx, q, deposit, truncate(reason, 255))
+		}
+
+		if !callback.Amount.Equal(deposit.Amount) || callback.Currency != deposit.Currency {
+			return ErrInvalidGatewayCallback
+		}
+
+		if err := q.AdjustWalletBalance(ctx, deposit.WalletID, deposit.Amount); err != nil {
+			return err
+		}
+
+		err = q.CreateLedgerEntry(ctx, &domain.LedgerEntry{
+			DepositID: &deposit.ID,
+			WalletID:  deposit.WalletID,
+			Type:      domain.LedgerEntryTypeDeposit,
+			Amount:    deposit.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		deposit.Status = domain.DepositStatusSucceeded
+		deposit.CompletedAt = &now
+		if err := q.UpdateDeposit(ctx, deposit); err != nil {
+			return err
+		}
+
+		return publishDepositEvent(ctx, q, tasks.TopicDepositSucceeded, deposit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExpireDeposits fails pending deposits whose payment was not completed
+// before they expired and returns how many were failed. A callback arriving
+// afterwards finds the deposit settled and is ignored.
+func (s *depositService) ExpireDeposits(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListExpiredDepositIDs(ctx, now, depositBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		changed := false
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			deposit, err := q.GetDepositForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if deposit == nil || deposit.Status != domain.DepositStatusPending || deposit.ExpiresAt.After(now) {
+				return nil
+			}
+
+			changed = true
+			return failDeposit(ctx, q, deposit, depositExpiredText)
+		})
+		if err != nil {
+			log.Printf("Error expiring deposit %d: %v", id, err)
+			continue
+		}
+
+		if changed {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+func failDeposit(ctx context.Context, q *repository.Queries, deposit *domain.Deposit, reason string) error {
+	now := time.Now()
+	deposit.Status = domain.DepositStatusFailed
+	deposit.FailureReason = reason
+	deposit.CompletedAt = &now
+	if err := q.UpdateDeposit(ctx, deposit); err != nil {
+		return err
+	}
+
+	return publishDepositEvent(ctx, q, tasks.TopicDepositFailed, deposit)
+}
+
+func publishDepositEvent(ctx context.Context, q *repository.Queries, topic string, deposit *domain.Deposit) error {
+	return publishEvent(ctx, q, topic, tasks.DepositEventPayload{
+		DepositID: deposit.ID,
+		UserID:    deposit.UserID,
+		WalletID:  deposit.WalletID,
+		Amount:    deposit.Amount,
+		Currency:  deposit.Currency,
+		Gateway:   deposit.Gateway,
+		Status:    string(deposit.Status),
+		Reason:    deposit.FailureReason,
+	})
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/gateway"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/webhook"
+)
+
+const testGatewaySecret = "gateway-secret"
+
+// depositStore adds deposits to ledgerStore.
+type depositStore struct {
+	*ledgerStore
+	deposits []*domain.Deposit
+}
+
+func (s *depositStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+func (s *depositStore) CreateDeposit(ctx context.Context, deposit *domain.Deposit) error {
+	deposit.ID = int64(len(s.deposits) + 1)
+	copied := *deposit
+	s.deposits = append(s.deposits, &copied)
+	return nil
+}
+
+func (s *depositStore) GetDepositByID(ctx context.Context, id int64) (*domain.Deposit, error) {
+	copied := *s.deposits[id-1]
+	return &copied, nil
+}
+
+func (s *depositStore) GetDepositForUpdate(ctx context.Context, id int64) (*domain.Deposit, error) {
+	return s.GetDepositByID(ctx, id)
+}
+
+func (s *depositStore) GetDepositByGatewayReferenceForUpdate(ctx context.Context, gateway, reference string) (*domain.Deposit, error) {
+	for _, deposit := range s.deposits {
+		if deposit.Gateway == gateway && deposit.GatewayReference != nil && *deposit.GatewayReference == reference {
+			copied := *deposit
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *depositStore) UpdateDeposit(ctx context.Context, deposit *domain.Deposit) error {
+	copied := *deposit
+	s.deposits[deposit.ID-1] = &copied
+	return nil
+}
+
+func newDepositService(t *testing.T) (*depositStore, *gateway.Simulated, domain.DepositService, *domain.Deposit) {
+	t.Helper()
+
+	store := &depositStore{ledgerStore: newLedgerStore()}
+	store.addUser(1, "0")
+	simulated := gateway.NewSimulated(testGatewaySecret, "https://gateway.example")
+	svc := NewDepositService(store, simulated)
+
+	deposit, err := svc.CreateDeposit(context.Background(), 1, dec("25.00"))
+	if err != nil {
+		t.Fatalf("CreateDeposit() error = %v", err)
+	}
+
+	return store, simulated, svc, deposit
+}
+
+// signedCallback signs body as the simulated gateway would at signedAt.
+func signedCallback(secret string, signedAt time.Time, body string) http.Header {
+	header := http.Header{}
+	header.Set(gateway.SignatureHeader, webhook.Sign(secret, signedAt, []byte(body)))
+	header.Set(gateway.TimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+	return header
+}
+
+func TestCreateDepositRejectsInvalidAmounts(t *testing.T) {
+	store, _, svc, _ := newDepositService(t)
+
+	for _, amount := range []string{"0", "-5.00", "10.001", "10000.01"} {
+		if _, err := svc.CreateDeposit(context.Background(), 1, dec(amount)); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("CreateDeposit(%s) error = %v, want %v", amount, err, ErrInvalidAmount)
+		}
+	}
+
+	if len(store.deposits) != 1 {
+		t.Errorf("%d deposits stored, want only the valid one", len(store.deposits))
+	}
+
+	if _, err := svc.CreateDeposit(context.Background(), 1, MaxDepositAmount); err != nil {
+		t.Errorf("CreateDeposit(%s) error = %v", MaxDepositAmount, err)
+	}
+}
+
+func TestGatewayCallbackIsIdempotent(t *testing.T) {
+	store, simulated, svc, deposit := newDepositService(t)
+	ctx := context.Background()
+
+	header, body, err := simulated.Callback(deposit, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Gateways retry callbacks; every delivery after the first is a no-op.
+	for i := 0; i < 3; i++ {
+		got, err := svc.HandleGatewayCallback(ctx, gateway.SimulatedName, header, body)
+		if err != nil {
+			t.Fatalf("HandleGatewayCallback() delivery %d error = %v", i+1, err)
+		}
+
+		if got.Status != domain.DepositStatusSucceeded {
+			t.Errorf("delivery %d status = %s, want %s", i+1, got.Status, domain.DepositStatusSucceeded)
+		}
+	}
+
+	store.assertBalance(t, 1, "25.00", "0")
+
+	if len(store.ledger) != 1 {
+		t.Errorf("%d ledger entries, want 1", len(store.ledger))
+	}
+
+	// A late failure report cannot undo the credit.
+	header, body, err = simulated.Callback(deposit, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.HandleGatewayCallback(ctx, gateway.SimulatedName, header, body)
+	if err != nil || got.Status != domain.DepositStatusSucceeded {
+		t.Errorf("HandleGatewayCallback() of a late failure = %+v, %v, want the deposit still succeeded", got, err)
+	}
+
+	store.assertBalance(t, 1, "25.00", "0")
+}
+
+func TestGatewayCallbackFailure(t *testing.T) {
+	store, simulated, svc, deposit := newDepositService(t)
+
+	header, body, err := simulated.Callback(deposit, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.HandleGatewayCallback(context.Background(), gateway.SimulatedName, header, body)
+	if err != nil {
+		t.Fatalf("HandleGatewayCallback() error = %v", err)
+	}
+
+	if got.Status != domain.DepositStatusFailed || got.FailureReason != "payment declined" {
+		t.Errorf("deposit = %+v, want it failed with the gateway's reason", got)
+	}
+
+	store.assertBalance(t, 1, "0", "0")
+}
+
+func TestGatewayCallbackRejectsBadSignatures(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		header func(body string) http.Header
+		body   func(reference string) string
+	}{
+		{
+			name:   "missing signature",
+			header: func(body string) http.Header { return http.Header{} },
+		},
+		{
+			name:   "wrong secret",
+			header: func(body string) http.Header { return signedCallback("other-secret", now, body) },
+		},
+		{
+			name: "tampered body",
+			header: func(body string) http.Header {
+				return signedCallback(testGatewaySecret, now, `{"reference":"sim_other","status":"succeeded","amount":"25.00","currency":"USD"}`)
+			},
+		},
+		{
+			name:   "stale timestamp",
+			header: func(body string) http.Header { return signedCallback(testGatewaySecret, now.Add(-time.Hour), body) },
+		},
+		{
+			name:   "amount differs from the deposit",
+			header: func(body string) http.Header { return signedCallback(testGatewaySecret, now, body) },
+			body: func(reference string) string {
+				return `{"reference":"` + reference + `","status":"succeeded","amount":"2500.00","currency":"USD"}`
+			},
+		},
+		{
+			name:   "malformed body",
+			header: func(body string) http.Header { return signedCallback(testGatewaySecret, now, body) },
+			body:   func(reference string) string { return `{"reference":"` + reference + `","status":"paid"}` },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _, svc, deposit := newDepositService(t)
+
+			body := `{"reference":"` + *deposit.GatewayReference + `","status":"succeeded","amount":"25.00","currency":"USD"}`
+			if tt.body != nil {
+				body = tt.body(*deposit.GatewayReference)
+			}
+
+			_, err := svc.HandleGatewayCallback(context.Background(), gateway.SimulatedName, tt.header(body), []byte(body))
+			if !errors.Is(err, ErrInvalidGatewayCallback) {
+				t.Fatalf("HandleGatewayCallback() error = %v, want %v", err, ErrInvalidGatewayCallback)
+			}
+
+			if status := store.deposits[0].Status; status != domain.DepositStatusPending {
+				t.Errorf("deposit status = %s, want %s", status, domain.DepositStatusPending)
+			}
+
+			store.assertBalance(t, 1, "0", "0")
+		})
+	}
+}
+
+func TestGatewayCallbackFromUnknownGateway(t *testing.T) {
+	store, simulated, svc, deposit := newDepositService(t)
+
+	header, body, err := simulated.Callback(deposit, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.HandleGatewayCallback(context.Background(), "stripe", header, body); !errors.Is(err, ErrUnknownGateway) {
+		t.Errorf("HandleGatewayCallback() error = %v, want %v", err, ErrUnknownGateway)
+	}
+
+	store.assertBalance(t, 1, "0", "0")
+}
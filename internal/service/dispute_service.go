@@ -0,0 +1,580 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrDisputeNotFound       = errors.New("dispute not found")
+	ErrDisputeExists         = errors.New("transaction is already disputed")
+	ErrDisputeResolved       = errors.New("dispute is already resolved")
+	ErrDisputeNotAllowed     = errors.New("your part in the dispute does not allow this")
+	ErrDisputeResponseClosed = errors.New("merchant's response window has closed")
+	ErrInvalidDispute        = errors.New("dispute is not valid")
+)
+
+const (
+	DefaultDisputeResponseWindow = 7 * 24 * time.Hour
+	DefaultDisputeFilingWindow   = 90 * 24 * time.Hour
+	maxDisputeEvidence           = 20
+	maxDisputeFileRefs           = 10
+	adminDisputeListLimit        = 200
+	disputeBatchSize             = 100
+)
+
+type disputeService struct {
+	store          repository.Store
+	responseWindow time.Duration
+	filingWindow   time.Duration
+}
+
+// NewDisputeService returns a DisputeService giving merchants responseWindow
+// to answer a dispute and payers filingWindow after a checkout payment to
+// dispute it. Zero values use DefaultDisputeResponseWindow and
+// DefaultDisputeFilingWindow.
+func NewDisputeService(store repository.Store, responseWindow, filingWindow time.Duration) domain.DisputeService {
+	if responseWindow <= 0 {
+		responseWindow = DefaultDisputeResponseWindow
+	}
+
+	if filingWindow <= 0 {
+		filingWindow = DefaultDisputeFilingWindow
+	}
+
+	return &disputeService{
+		store:          store,
+		responseWindow: responseWindow,
+		filingWindow:   filingWindow,
+	}
+}
+
+// OpenDispute contests amount of a completed checkout payment the user made,
+// or all of it when amount is zero. Only payments to an active merchant can
+// be disputed, within the filing window after they completed; other
+// transfers are final. The amount is taken back straight away and credited
+// to the payer on hold until the dispute is resolved. The merchant only
+// received the payment net of its fee, so they are debited their share of
+// the amount and the rest is reversed from the revenue wallet the fee went
+// to. Like a card chargeback, the debit is taken even if it overdraws the
+// merchant's wallet.
+func (s *disputeService) OpenDispute(ctx context.Context, userID, transactionID int64, amount decimal.Decimal, reason string) (*domain.Dispute, error) {
+	var opened *domain.Dispute
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		wallet, err := q.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if wallet == nil {
+			return ErrWalletNotFound
+		}
+
+		tx, err := q.GetTransactionForUpdate(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		if tx == nil || tx.SenderWalletID != wallet.ID {
+			return ErrTransactionNotFound
+		}
+
+		if tx.Status != domain.TransactionStatusCompleted {
+			return fmt.Errorf("%w: only completed transactions can be disputed", ErrInvalidDispute)
+		}
+
+		session, err := q.GetCheckoutSessionByTransactionForUpdate(ctx, tx.ID)
+		if err != nil {
+			return err
+		}
+
+		if session == nil || session.Status != domain.CheckoutSessionStatusCompleted || session.CompletedAt == nil {
+			return fmt.Errorf("%w: only checkout payments to a merchant can be disputed", ErrInvalidDispute)
+		}
+
+		merchant, err := q.GetMerchantByID(ctx, session.MerchantID)
+		if err != nil {
+			return err
+		}
+
+		if merchant == nil || merchant.Status != domain.MerchantStatusActive {
+			return fmt.Errorf("%w: the merchant is no longer active", ErrInvalidDispute)
+		}
+
+		if time.Now().After(session.CompletedAt.Add(s.filingWindow)) {
+			return fmt.Errorf("%w: payments can only be disputed within %s of completing", ErrInvalidDispute, s.filingWindow)
+		}
+
+		if amount.IsZero() {
+			amount = tx.Amount
+		}
+
+		switch {
+		case !amount.IsPositive():
+			return ErrInvalidAmount
+		case !amount.Equal(amount.Round(2)):
+			return fmt.Errorf("%w: amount has more than 2 decimal places", ErrInvalidDispute)
+		case amount.GreaterThan(tx.Amount):
+			return fmt.Errorf("%w: amount is more than the transaction's %s", ErrInvalidDispute, tx.Amount)
+		}
+
+		existing, err := q.GetDisputeByTransaction(ctx, tx.ID)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return ErrDisputeExists
+		}
+
+		wallets, err := lockWallets(ctx, q, disputeWalletIDs(tx)...)
+		if err != nil {
+			return err
+		}
+
+		dispute := &domain.Dispute{
+			TransactionID:    tx.ID,
+			PayerWalletID:    tx.SenderWalletID,
+			MerchantWalletID: tx.ReceiverWalletID,
+			Amount:           amount,
+			Currency:         wallets[tx.SenderWalletID].Currency,
+			Reason:           reason,
+			Status:           domain.DisputeStatusOpen,
+			RespondBy:        time.Now().UTC().Add(s.responseWindow).Truncate(time.Second),
+		}
+		if err := q.CreateDispute(ctx, dispute); err != nil {
+			return err
+		}
+
+		if err := moveDisputeFunds(ctx, q, dispute, tx, true); err != nil {
+			return err
+		}
+
+		if err := q.AdjustWalletHeldBalance(ctx, dispute.PayerWalletID, dispute.Amount); err != nil {
+			return err
+		}
+
+		opened = dispute
+		return publishDisputeEvent(ctx, q, tasks.TopicDisputeOpened, dispute, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDispute(ctx, userID, opened.ID)
+}
+
+// SubmitDisputeEvidence adds a statement from the payer or the merchant to an
+// open dispute. The merchant's first statement is their response and puts
+// the dispute under review; it must come before the response window closes.
+func (s *disputeService) SubmitDisputeEvidence(ctx context.Context, userID, id int64, text string, fileRefs []string) (*domain.Dispute, error) {
+	if len(fileRefs) > maxDisputeFileRefs {
+		return nil, fmt.Errorf("%w: at most %d file references are allowed", ErrInvalidDispute, maxDisputeFileRefs)
+	}
+
+	return s.transition(ctx, userID, id, func(q *repository.Queries, dispute *domain.Dispute, party domain.DisputeParty) (string, *domain.DisputeEvidence, error) {
+		evidence, err := q.ListDisputeEvidence(ctx, dispute.ID)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if len(evidence) >= maxDisputeEvidence {
+			return "", nil, fmt.Errorf("%w: at most %d pieces of evidence are allowed", ErrInvalidDispute, maxDisputeEvidence)
+		}
+
+		topic := tasks.TopicDisputeEvidenceSubmitted
+		if party == domain.DisputePartyMerchant && dispute.Status == domain.DisputeStatusOpen {
+			now := time.Now().UTC()
+			if now.After(dispute.RespondBy) {
+				return "", nil, ErrDisputeResponseClosed
+			}
+
+			dispute.Status = domain.DisputeStatusUnderReview
+			dispute.RespondedAt = &now
+			topic = tasks.TopicDisputeResponded
+		}
+
+		if fileRefs == nil {
+			fileRefs = []string{}
+		}
+
+		item := &domain.DisputeEvidence{
+			DisputeID: dispute.ID,
+			UserID:    userID,
+			Party:     party,
+			Text:      text,
+			FileRefs:  fileRefs,
+		}
+		if err := q.CreateDisputeEvidence(ctx, item); err != nil {
+			return "", nil, err
+		}
+
+		return topic, item, nil
+	})
+}
+
+// AcceptDispute lets the merchant concede the dispute, which resolves it for
+// the payer.
+func (s *disputeService) AcceptDispute(ctx context.Context, userID, id int64) (*domain.Dispute, error) {
+	return s.transition(ctx, userID, id, func(q *repository.Queries, dispute *domain.Dispute, party domain.DisputeParty) (string, *domain.DisputeEvidence, error) {
+		if party != domain.DisputePartyMerchant {
+			return "", nil, ErrDisputeNotAllowed
+		}
+
+		if err := resolveDispute(ctx, q, dispute, domain.DisputeStatusPayerWon, &userID, "accepted by the merchant"); err != nil {
+			return "", nil, err
+		}
+
+		return tasks.TopicDisputeResolved, nil, nil
+	})
+}
+
+// WithdrawDispute lets the payer drop the dispute, which moves the amount
+// back to the merchant.
+func (s *disputeService) WithdrawDispute(ctx context.Context, userID, id int64) (*domain.Dispute, error) {
+	return s.transition(ctx, userID, id, func(q *repository.Queries, dispute *domain.Dispute, party domain.DisputeParty) (string, *domain.DisputeEvidence, error) {
+		if party != domain.DisputePartyPayer {
+			return "", nil, ErrDisputeNotAllowed
+		}
+
+		if err := resolveDispute(ctx, q, dispute, domain.DisputeStatusWithdrawn, &userID, "withdrawn by the payer"); err != nil {
+			return "", nil, err
+		}
+
+		return tasks.TopicDisputeWithdrawn, nil, nil
+	})
+}
+
+// ResolveDispute decides an open dispute for the payer (PAYER_WON) or for the
+// merchant (MERCHANT_WON). Callers must have checked that adminUserID is an
+// admin.
+func (s *disputeService) ResolveDispute(ctx context.Context, adminUserID, id int64, status domain.DisputeStatus, note string) (*domain.Dispute, error) {
+	if status != domain.DisputeStatusPayerWon && status != domain.DisputeStatusMerchantWon {
+		return nil, fmt.Errorf("%w: resolution must be %s or %s", ErrInvalidDispute, domain.DisputeStatusPayerWon, domain.DisputeStatusMerchantWon)
+	}
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		dispute, err := q.GetDisputeForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if dispute == nil {
+			return ErrDisputeNotFound
+		}
+
+		if !dispute.Open() {
+			return ErrDisputeResolved
+		}
+
+		if err := resolveDispute(ctx, q, dispute, status, &adminUserID, note); err != nil {
+			return err
+		}
+
+		if err := q.UpdateDispute(ctx, dispute); err != nil {
+			return err
+		}
+
+		return publishDisputeEvent(ctx, q, tasks.TopicDisputeResolved, dispute, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AdminGetDispute(ctx, id)
+}
+
+// disputePartyOf returns the side of the dispute wallet is on, or "" if none.
+func disputePartyOf(wallet *domain.Wallet, dispute *domain.Dispute) domain.DisputeParty {
+	switch {
+	case wallet == nil:
+		return ""
+	case wallet.ID == dispute.PayerWalletID:
+		return domain.DisputePartyPayer
+	case wallet.ID == dispute.MerchantWalletID:
+		return domain.DisputePartyMerchant
+	}
+
+	return ""
+}
+
+// transition locks an open dispute userID is a party to and persists whatever
+// change apply makes along with its outbox event.
+func (s *disputeService) transition(ctx context.Context, userID, id int64, apply func(*repository.Queries, *domain.Dispute, domain.DisputeParty) (string, *domain.DisputeEvidence, error)) (*domain.Dispute, error) {
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		dispute, err := q.GetDisputeForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if dispute == nil {
+			return ErrDisputeNotFound
+		}
+
+		wallet, err := q.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		party := disputePartyOf(wallet, dispute)
+		if party == "" {
+			return ErrDisputeNotFound
+		}
+
+		if !dispute.Open() {
+			return ErrDisputeResolved
+		}
+
+		topic, evidence, err := apply(q, dispute, party)
+		if err != nil {
+			return err
+		}
+
+		if err := q.UpdateDispute(ctx, dispute); err != nil {
+			return err
+		}
+
+		return publishDisputeEvent(ctx, q, topic, dispute, evidence)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDispute(ctx, userID, id)
+}
+
+// resolveDispute settles the provisional movement made when the dispute was
+// opened: the payer's hold is released, and unless the payer won the amount
+// goes back to the merchant and the revenue wallet in the shares it was
+// taken from. resolvedBy is nil when the worker resolves the dispute. The
+// dispute is not saved.
+func resolveDispute(ctx context.Context, q *repository.Queries, dispute *domain.Dispute, status domain.DisputeStatus, resolvedBy *int64, note string) error {
+	tx, err := q.GetTransactionForUpdate(ctx, dispute.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	if tx == nil {
+		return ErrTransactionNotFound
+	}
+
+	if _, err := lockWallets(ctx, q, disputeWalletIDs(tx)...); err != nil {
+		return err
+	}
+
+	if err := q.AdjustWalletHeldBalance(ctx, dispute.PayerWalletID, dispute.Amount.Neg()); err != nil {
+		return err
+	}
+
+	if status != domain.DisputeStatusPayerWon {
+		if err := moveDisputeFunds(ctx, q, dispute, tx, false); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	dispute.Status = status
+	dispute.ResolutionNote = truncate(note, 1000)
+	dispute.ResolvedByUserID = resolvedBy
+	dispute.ResolvedAt = &now
+	return nil
+}
+
+// disputeWalletIDs returns the wallets a dispute of tx moves funds between:
+// the payer's, the merchant's and, if tx was charged a fee, the revenue
+// wallet the fee was posted to.
+func disputeWalletIDs(tx *domain.Transaction) []int64 {
+	ids := []int64{tx.SenderWalletID, tx.ReceiverWalletID}
+	if tx.Fee.IsPositive() && tx.FeeWalletID != nil {
+		ids = append(ids, *tx.FeeWalletID)
+	}
+
+	return ids
+}
+
+// disputeFeeShare returns the part of amount that was paid to the revenue
+// wallet as tx's fee: all of the fee when the whole transaction is disputed,
+// or a proportional share rounded to the cent when only part of it is.
+func disputeFeeShare(tx *domain.Transaction, amount decimal.Decimal) decimal.Decimal {
+	if !tx.Fee.IsPositive() || tx.FeeWalletID == nil {
+		return decimal.Zero
+	}
+
+	if amount.Equal(tx.Amount) {
+		return tx.Fee
+	}
+
+	return tx.Fee.Mul(amount).Div(tx.Amount).Round(2)
+}
+
+// moveDisputeFunds moves the dispute's amount to the payer from the merchant
+// and the revenue wallet when toPayer is set, or back again when it is not,
+// and records every leg in the ledger. The caller must hold locks on the
+// wallets disputeWalletIDs returns.
+func moveDisputeFunds(ctx context.Context, q *repository.Queries, dispute *domain.Dispute, tx *domain.Transaction, toPayer bool) error {
+	feeShare := disputeFeeShare(tx, dispute.Amount)
+
+	legs := []domain.LedgerEntry{
+		{WalletID: dispute.PayerWalletID, Type: domain.LedgerEntryTypeDispute, Amount: dispute.Amount},
+		{WalletID: dispute.MerchantWalletID, Type: domain.LedgerEntryTypeDispute, Amount: dispute.Amount.Sub(feeShare).Neg()},
+	}
+	if feeShare.IsPositive() {
+		legs = append(legs, domain.LedgerEntry{WalletID: *tx.FeeWalletID, Type: domain.LedgerEntryTypeFee, Amount: feeShare.Neg()})
+	}
+
+	for _, leg := range legs {
+		if !toPayer {
+			leg.Amount = leg.Amount.Neg()
+		}
+
+		if err := q.AdjustWalletBalance(ctx, leg.WalletID, leg.Amount); err != nil {
+			return err
+		}
+
+		leg.DisputeID = &dispute.ID
+		if err := q.CreateLedgerEntry(ctx, &leg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetDispute returns a dispute the user raised or must answer, with its
+// evidence.
+func (s *disputeService) GetDispute(ctx context.Context, userID, id int64) (*domain.Dispute, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute, err := s.store.GetDisputeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if dispute == nil || disputePartyOf(wallet, dispute) == "" {
+		return nil, ErrDisputeNotFound
+	}
+
+	if dispute.Evidence, err = s.store.ListDisputeEvidence(ctx, dispute.ID); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// ListDisputes returns the disputes the user raised or must answer, without
+// their evidence.
+func (s *disputeService) ListDisputes(ctx context.Context, userID int64) ([]*domain.Dispute, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	return s.store.ListDisputesByWallet(ctx, wallet.ID)
+}
+
+// AdminListDisputes returns the newest disputes in status, or in any status
+// when it is empty.
+func (s *disputeService) AdminListDisputes(ctx context.Context, status domain.DisputeStatus) ([]*domain.Dispute, error) {
+	return s.store.ListDisputesByStatus(ctx, status, adminDisputeListLimit)
+}
+
+// AdminGetDispute returns any dispute with its evidence.
+func (s *disputeService) AdminGetDispute(ctx context.Context, id int64) (*domain.Dispute, error) {
+	dispute, err := s.store.GetDisputeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if dispute == nil {
+		return nil, ErrDisputeNotFound
+	}
+
+	if dispute.Evidence, err = s.store.ListDisputeEvidence(ctx, dispute.ID); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// ResolveOverdueDisputes resolves for the payer the OPEN disputes whose
+// merchant let the response window close, and returns how many it resolved.
+func (s *disputeService) ResolveOverdueDisputes(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListOverdueDisputeIDs(ctx, now, disputeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, id := range ids {
+		changed := false
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			dispute, err := q.GetDisputeForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if dispute == nil || dispute.Status != domain.DisputeStatusOpen || dispute.RespondBy.After(now) {
+				return nil
+			}
+
+			if err := resolveDispute(ctx, q, dispute, domain.DisputeStatusPayerWon, nil, "the merchant did not respond in time"); err != nil {
+				return err
+			}
+
+			if err := q.UpdateDispute(ctx, dispute); err != nil {
+				return err
+			}
+
+			changed = true
+			return publishDisputeEvent(ctx, q, tasks.TopicDisputeResolved, dispute, nil)
+		})
+		if err != nil {
+			log.Printf("Error resolving dispute %d: %v", id, err)
+			continue
+		}
+
+		if changed {
+			resolved++
+		}
+	}
+
+	return resolved, nil
+}
+
+func publishDisputeEvent(ctx context.Context, q *repository.Queries, topic string, dispute *domain.Dispute, evidence *domain.DisputeEvidence) error {
+	payload := tasks.DisputeEventPayload{
+		DisputeID:        dispute.ID,
+		TransactionID:    dispute.TransactionID,
+		PayerWalletID:    dispute.PayerWalletID,
+		MerchantWalletID: dispute.MerchantWalletID,
+		Amount:           dispute.Amount,
+		Currency:         dispute.Currency,
+		Reason:           dispute.Reason,
+		Status:           string(dispute.Status),
+		ResolvedByUserID: dispute.ResolvedByUserID,
+		ResolutionNote:   dispute.ResolutionNote,
+	}
+
+	if evidence != nil {
+		payload.EvidenceID = &evidence.ID
+		payload.Party = string(evidence.Party)
+	}
+
+	return publishEvent(ctx, q, topic, payload)
+}
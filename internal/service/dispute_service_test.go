@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// disputeStore adds disputes and their evidence to ledgerStore.
+type disputeStore struct {
+	*ledgerStore
+	disputes []*domain.Dispute
+	evidence []*domain.DisputeEvidence
+}
+
+func (s *disputeStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+func (s *disputeStore) CreateDispute(ctx context.Context, dispute *domain.Dispute) error {
+	dispute.ID = int64(len(s.disputes) + 1)
+	copied := *dispute
+	s.disputes = append(s.disputes, &copied)
+	return nil
+}
+
+func (s *disputeStore) GetDisputeByID(ctx context.Context, id int64) (*domain.Dispute, error) {
+	copied := *s.disputes[id-1]
+	return &copied, nil
+}
+
+func (s *disputeStore) GetDisputeForUpdate(ctx context.Context, id int64) (*domain.Dispute, error) {
+	return s.GetDisputeByID(ctx, id)
+}
+
+func (s *disputeStore) GetDisputeByTransaction(ctx context.Context, transactionID int64) (*domain.Dispute, error) {
+	for _, dispute := range s.disputes {
+		if dispute.TransactionID == transactionID {
+			copied := *dispute
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *disputeStore) ListOverdueDisputeIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	for _, dispute := range s.disputes {
+		if dispute.Status == domain.DisputeStatusOpen && !dispute.RespondBy.After(now) {
+			ids = append(ids, dispute.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *disputeStore) UpdateDispute(ctx context.Context, dispute *domain.Dispute) error {
+	copied := *dispute
+	s.disputes[dispute.ID-1] = &copied
+	return nil
+}
+
+func (s *disputeStore) CreateDisputeEvidence(ctx context.Context, evidence *domain.DisputeEvidence) error {
+	evidence.ID = int64(len(s.evidence) + 1)
+	copied := *evidence
+	s.evidence = append(s.evidence, &copied)
+	return nil
+}
+
+func (s *disputeStore) ListDisputeEvidence(ctx context.Context, disputeID int64) ([]*domain.DisputeEvidence, error) {
+	var evidence []*domain.DisputeEvidence
+	for _, item := range s.evidence {
+		if item.DisputeID == disputeID {
+			copied := *item
+			evidence = append(evidence, &copied)
+		}
+	}
+
+	return evidence, nil
+}
+
+// newDisputeService has user 1 pay merchant user 2 10.00 at checkout, of
+// which the merchant receives 9.71 and the revenue wallet 0.29, and returns
+// the payment's transaction ID.
+func newDisputeService(t *testing.T) (*disputeStore, domain.DisputeService, int64) {
+	t.Helper()
+
+	store := &disputeStore{ledgerStore: newCheckoutStore()}
+	checkout := NewCheckoutService(store)
+	ctx := context.Background()
+
+	session, err := checkout.CreateCheckoutSession(ctx, 2, checkoutParams("order-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confirmed, err := checkout.ConfirmCheckoutSession(ctx, 1, session.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.settleTransfers(t)
+	store.assertBalance(t, 2, "9.71", "0")
+	store.assertBalance(t, revenueUserID, "0.29", "0")
+
+	return store, NewDisputeService(store, 0, 0), *confirmed.TransactionID
+}
+
+// assertLedgerBalances fails t unless the ledger entries of every dispute add
+// up to zero, so a dispute never creates or destroys money.
+func (s *disputeStore) assertLedgerBalances(t *testing.T) {
+	t.Helper()
+
+	sum := decimal.Zero
+	for _, entry := range s.ledger {
+		if entry.DisputeID != nil {
+			sum = sum.Add(entry.Amount)
+		}
+	}
+
+	if !sum.IsZero() {
+		t.Errorf("dispute ledger entries add up to %s, want 0", sum)
+	}
+}
+
+func TestOpenDisputeReversesTheFee(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		merchant string
+		revenue  string
+	}{
+		// The merchant only gives back what they received; the fee comes
+		// back from the revenue wallet.
+		{name: "whole payment", amount: "0", merchant: "0", revenue: "0"},
+		// 2.9% of 5.00 is 0.145, which rounds to 0.15 of the fee.
+		{name: "part of the payment", amount: "5.00", merchant: "4.86", revenue: "0.14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, svc, txID := newDisputeService(t)
+
+			dispute, err := svc.OpenDispute(context.Background(), 1, txID, dec(tt.amount), "never delivered")
+			if err != nil {
+				t.Fatalf("OpenDispute() error = %v", err)
+			}
+
+			held := dispute.Amount.StringFixed(2)
+			store.assertBalance(t, 1, dec("90.00").Add(dispute.Amount).StringFixed(2), held)
+			store.assertBalance(t, 2, tt.merchant, "0")
+			store.assertBalance(t, revenueUserID, tt.revenue, "0")
+			store.assertLedgerBalances(t)
+		})
+	}
+}
+
+func TestDisputeResolutionBalances(t *testing.T) {
+	tests := []struct {
+		name    string
+		resolve func(svc domain.DisputeService, id int64) error
+		status  domain.DisputeStatus
+		payer   string
+	}{
+		{
+			name: "merchant accepts",
+			resolve: func(svc domain.DisputeService, id int64) error {
+				_, err := svc.AcceptDispute(context.Background(), 2, id)
+				return err
+			},
+			status: domain.DisputeStatusPayerWon,
+			payer:  "100.00",
+		},
+		{
+			name: "admin decides for the payer",
+			resolve: func(svc domain.DisputeService, id int64) error {
+				_, err := svc.ResolveDispute(context.Background(), 50, id, domain.DisputeStatusPayerWon, "refund")
+				return err
+			},
+			status: domain.DisputeStatusPayerWon,
+			payer:  "100.00",
+		},
+		{
+			name: "merchant misses the response window",
+			resolve: func(svc domain.DisputeService, id int64) error {
+				_, err := svc.ResolveOverdueDisputes(context.Background(), time.Now().Add(DefaultDisputeResponseWindow+time.Hour))
+				return err
+			},
+			status: domain.DisputeStatusPayerWon,
+			payer:  "100.00",
+		},
+		{
+			name: "admin decides for the merchant",
+			resolve: func(svc domain.DisputeService, id int64) error {
+				_, err := svc.ResolveDispute(context.Background(), 50, id, domain.DisputeStatusMerchantWon, "delivered")
+				return err
+			},
+			status: domain.DisputeStatusMerchantWon,
+			payer:  "90.00",
+		},
+		{
+			name: "payer withdraws",
+			resolve: func(svc domain.DisputeService, id int64) error {
+				_, err := svc.WithdrawDispute(context.Background(), 1, id)
+				return err
+			},
+			status: domain.DisputeStatusWithdrawn,
+			payer:  "90.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, svc, txID := newDisputeService(t)
+
+			dispute, err := svc.OpenDispute(context.Background(), 1, txID, decimal.Zero, "never delivered")
+			if err != nil {
+				t.Fatalf("OpenDispute() error = %v", err)
+			}
+
+			if err := tt.resolve(svc, dispute.ID); err != nil {
+				t.Fatalf("resolving error = %v", err)
+			}
+
+			if got := store.disputes[0]; got.Status != tt.status || got.ResolvedAt == nil {
+				t.Errorf("dispute = %s resolved at %v, want %s", got.Status, got.ResolvedAt, tt.status)
+			}
+
+			// The payer's hold is released either way. If the payer lost,
+			// the merchant and the revenue wallet get back exactly what the
+			// dispute took from them.
+			merchant, revenue := "0", "0"
+			if tt.status != domain.DisputeStatusPayerWon {
+				merchant, revenue = "9.71", "0.29"
+			}
+
+			store.assertBalance(t, 1, tt.payer, "0")
+			store.assertBalance(t, 2, merchant, "0")
+			store.assertBalance(t, revenueUserID, revenue, "0")
+			store.assertLedgerBalances(t)
+		})
+	}
+}
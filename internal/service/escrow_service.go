@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrEscrowNotFound        = errors.New("escrow not found")
+	ErrEscrowSettled         = errors.New("escrow is already settled")
+	ErrEscrowDisputed        = errors.New("escrow is already disputed")
+	ErrEscrowNotAllowed      = errors.New("your part in the escrow does not allow this")
+	ErrInvalidEscrowArbiter  = errors.New("arbiter must be a user other than the payer and payee")
+	ErrInvalidEscrowDeadline = errors.New("escrow release deadline is out of range")
+	ErrEscrowWalletNotFound  = errors.New("no escrow wallet is configured for the currency")
+)
+
+const (
+	DefaultEscrowReleaseAfter = 14 * 24 * time.Hour
+	MaxEscrowReleaseAfter     = 365 * 24 * time.Hour
+	escrowBatchSize           = 100
+)
+
+type escrowService struct {
+	store repository.Store
+}
+
+func NewEscrowService(store repository.Store) domain.EscrowService {
+	return &escrowService{
+		store: store,
+	}
+}
+
+// CreateEscrow funds an escrow from the payer's wallet in favour of the payee.
+// The escrow is released to the payee automatically after releaseAfter unless
+// it is settled or disputed first; a zero releaseAfter uses
+// DefaultEscrowReleaseAfter.
+func (s *escrowService) CreateEscrow(ctx context.Context, payerUserID, payeeUserID int64, arbiterUserID *int64, amount decimal.Decimal, description string, releaseAfter time.Duration) (*domain.Escrow, error) {
+	if releaseAfter == 0 {
+		releaseAfter = DefaultEscrowReleaseAfter
+	}
+
+	if releaseAfter < time.Hour || releaseAfter > MaxEscrowReleaseAfter {
+		return nil, ErrInvalidEscrowDeadline
+	}
+
+	if arbiterUserID != nil && (*arbiterUserID == payerUserID || *arbiterUserID == payeeUserID) {
+		return nil, ErrInvalidEscrowArbiter
+	}
+
+	var created *domain.Escrow
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		payerWallet, payeeWallet, err := transferWallets(ctx, q, payerUserID, payeeUserID, amount)
+		if err != nil {
+			return err
+		}
+
+		if payerWallet.Currency != payeeWallet.Currency {
+			return ErrCurrencyMismatch
+		}
+
+		if arbiterUserID != nil {
+			arbiter, err := q.GetByID(ctx, *arbiterUserID)
+			if err != nil {
+				return err
+			}
+
+			if arbiter == nil || arbiter.ErasedAt != nil {
+				return ErrUserNotFound
+			}
+		}
+
+		escrowWalletID, ok, err := q.GetEscrowWalletID(ctx, payerWallet.Currency)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return ErrEscrowWalletNotFound
+		}
+
+		wallets, err := lockWallets(ctx, q, payerWallet.ID, escrowWalletID)
+		if err != nil {
+			return err
+		}
+
+		if wallets[payerWallet.ID].AvailableBalance.LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+
+		escrow := &domain.Escrow{
+			PayerWalletID:  payerWallet.ID,
+			PayeeWalletID:  payeeWallet.ID,
+			ArbiterUserID:  arbiterUserID,
+			EscrowWalletID: escrowWalletID,
+			Amount:         amount,
+			Currency:       payerWallet.Currency,
+			Description:    description,
+			Status:         domain.EscrowStatusFunded,
+			ReleaseAfter:   time.Now().UTC().Add(releaseAfter).Truncate(time.Second),
+		}
+		if err := q.CreateEscrow(ctx, escrow); err != nil {
+			return err
+		}
+
+		if err := moveEscrowFunds(ctx, q, escrow, escrow.PayerWalletID, escrow.EscrowWalletID); err != nil {
+			return err
+		}
+
+		created = escrow
+		return publishEscrowEvent(ctx, q, tasks.TopicEscrowFunded, escrow)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetEscrowByID(ctx, created.ID)
+}
+
+// ReleaseEscrow pays the escrow out to the payee. Only the payer and the
+// arbiter may release it, disputed or not.
+func (s *escrowService) ReleaseEscrow(ctx context.Context, userID, id int64) (*domain.Escrow, error) {
+	return s.transition(ctx, userID, id, func(q *repository.Queries, escrow *domain.Escrow, role escrowRole) (string, error) {
+		if !role.payer && !role.arbiter {
+			return "", ErrEscrowNotAllowed
+		}
+
+		if err := settleEscrow(ctx, q, escrow, domain.EscrowStatusReleased, &userID); err != nil {
+			return "", err
+		}
+
+		return tasks.TopicEscrowReleased, nil
+	})
+}
+
+// RefundEscrow returns the escrow to the payer. Only the payee and the
+// arbiter may refund it, disputed or not.
+func (s *escrowService) RefundEscrow(ctx context.Context, userID, id int64) (*domain.Escrow, error) {
+	return s.transition(ctx, userID, id, func(q *repository.Queries, escrow *domain.Escrow, role escrowRole) (string, error) {
+		if !role.payee && !role.arbiter {
+			return "", ErrEscrowNotAllowed
+		}
+
+		if err := settleEscrow(ctx, q, escrow, domain.EscrowStatusRefunded, &userID); err != nil {
+			return "", err
+		}
+
+		return tasks.TopicEscrowRefunded, nil
+	})
+}
+
+// DisputeEscrow stops the escrow from being released automatically. The payer
+// or the payee raises the dispute; it lasts until the escrow is settled.
+func (s *escrowService) DisputeEscrow(ctx context.Context, userID, id int64, reason string) (*domain.Escrow, error) {
+	return s.transition(ctx, userID, id, func(q *repository.Queries, escrow *domain.Escrow, role escrowRole) (string, error) {
+		if !role.payer && !role.payee {
+			return "", ErrEscrowNotAllowed
+		}
+
+		if escrow.Status == domain.EscrowStatusDisputed {
+			return "", ErrEscrowDisputed
+		}
+
+		now := time.Now().UTC()
+		escrow.Status = domain.EscrowStatusDisputed
+		escrow.DisputedByUserID = &userID
+		escrow.DisputeReason = reason
+		escrow.DisputedAt = &now
+		return tasks.TopicEscrowDisputed, nil
+	})
+}
+
+// escrowRole is the part a user plays in an escrow.
+type escrowRole struct {
+	payer, payee, arbiter bool
+}
+
+func escrowRoleOf(wallet *domain.Wallet, userID int64, escrow *domain.Escrow) escrowRole {
+	return escrowRole{
+		payer:   wallet != nil && wallet.ID == escrow.PayerWalletID,
+		payee:   wallet != nil && wallet.ID == escrow.PayeeWalletID,
+		arbiter: escrow.ArbiterUserID != nil && *escrow.ArbiterUserID == userID,
+	}
+}
+
+func (r escrowRole) party() bool {
+	return r.payer || r.payee || r.arbiter
+}
+
+// transition locks an open escrow userID is a party to and persists whatever
+// change apply makes along with its outbox event.
+func (s *escrowService) transition(ctx context.Context, userID, id int64, apply func(*repository.Queries, *domain.Escrow, escrowRole) (string, error)) (*domain.Escrow, error) {
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		escrow, err := q.GetEscrowForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if escrow == nil {
+			return ErrEscrowNotFound
+		}
+
+		wallet, err := q.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		role := escrowRoleOf(wallet, userID, escrow)
+		if !role.party() {
+			return ErrEscrowNotFound
+		}
+
+		if !escrow.Open() {
+			return ErrEscrowSettled
+		}
+
+		topic, err := apply(q, escrow, role)
+		if err != nil {
+			return err
+		}
+
+		if err := q.UpdateEscrow(ctx, escrow); err != nil {
+			return err
+		}
+
+		return publishEscrowEvent(ctx, q, topic, escrow)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetEscrowByID(ctx, id)
+}
+
+// settleEscrow pays the escrow out to the payee when status is RELEASED, or
+// back to the payer when it is REFUNDED. The full amount is paid out; escrows
+// are not charged a fee. settledBy is nil when the worker releases the
+// escrow. The escrow is not saved.
+func settleEscrow(ctx context.Context, q *repository.Queries, escrow *domain.Escrow, status domain.EscrowStatus, settledBy *int64) error {
+	to := escrow.PayeeWalletID
+	if status == domain.EscrowStatusRefunded {
+		to = escrow.PayerWalletID
+	}
+
+	if _, err := lockWallets(ctx, q, escrow.EscrowWalletID, to); err != nil {
+		return err
+	}
+
+	if err := moveEscrowFunds(ctx, q, escrow, escrow.EscrowWalletID, to); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	escrow.Status = status
+	escrow.SettledByUserID = settledBy
+	escrow.SettledAt = &now
+	return nil
+}
+
+// moveEscrowFunds moves the escrow's amount between two wallets and records
+// both legs in the ledger. The caller must hold locks on both wallets.
+func moveEscrowFunds(ctx context.Context, q *repository.Queries, escrow *domain.Escrow, fromWalletID, toWalletID int64) error {
+	legs := []domain.LedgerEntry{
+		{WalletID: fromWalletID, Type: domain.LedgerEntryTypeEscrow, Amount: escrow.Amount.Neg()},
+		{WalletID: toWalletID, Type: domain.LedgerEntryTypeEscrow, Amount: escrow.Amount},
+	}
+
+	for _, leg := range legs {
+		if err := q.AdjustWalletBalance(ctx, leg.WalletID, leg.Amount); err != nil {
+			return err
+		}
+
+		leg.EscrowID = &escrow.ID
+		if err := q.CreateLedgerEntry(ctx, &leg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetEscrow returns an escrow the user pays, is paid by or arbitrates.
+func (s *escrowService) GetEscrow(ctx context.Context, userID, id int64) (*domain.Escrow, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	escrow, err := s.store.GetEscrowByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if escrow == nil || !escrowRoleOf(wallet, userID, escrow).party() {
+		return nil, ErrEscrowNotFound
+	}
+
+	return escrow, nil
+}
+
+// ListEscrows returns the escrows the user pays, is paid by or arbitrates.
+func (s *escrowService) ListEscrows(ctx context.Context, userID int64) ([]*domain.Escrow, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var walletID int64
+	if wallet != nil {
+		walletID = wallet.ID
+	}
+
+	return s.store.ListEscrowsByParty(ctx, walletID, userID)
+}
+
+// ReleaseDueEscrows releases FUNDED escrows whose deadline has passed and
+// returns how many were released. Disputed escrows are left alone.
+func (s *escrowService) ReleaseDueEscrows(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListDueEscrowIDs(ctx, now, escrowBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, id := range ids {
+		changed := false
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			escrow, err := q.GetEscrowForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if escrow == nil || escrow.Status != domain.EscrowStatusFunded || escrow.ReleaseAfter.After(now) {
+				return nil
+			}
+
+			if err := settleEscrow(ctx, q, escrow, domain.EscrowStatusReleased, nil); err != nil {
+				return err
+			}
+
+			if err := q.UpdateEscrow(ctx, escrow); err != nil {
+				return err
+			}
+
+			changed = true
+			return publishEscrowEvent(ctx, q, tasks.TopicEscrowReleased, escrow)
+		})
+		if err != nil {
+			log.Printf("Error releasing escrow %d: %v", id, err)
+			continue
+		}
+
+		if changed {
+			released++
+		}
+	}
+
+	return released, nil
+}
+
+func publishEscrowEvent(ctx context.Context, q *repository.Queries, topic string, escrow *domain.Escrow) error {
+	return publishEvent(ctx, q, topic, tasks.EscrowEventPayload{
+		EscrowID:        escrow.ID,
+		WalletID:        escrow.PayerWalletID,
+		PayeeWalletID:   escrow.PayeeWalletID,
+		ArbiterUserID:   escrow.ArbiterUserID,
+		Amount:          escrow.Amount,
+		Currency:        escrow.Currency,
+		Status:          string(escrow.Status),
+		SettledByUserID: escrow.SettledByUserID,
+		Reason:          escrow.DisputeReason,
+	})
+}
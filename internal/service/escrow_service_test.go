@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+// escrowUserID owns the house escrow wallet in escrowStore.
+const escrowUserID = 98
+
+// escrowStore adds escrows to ledgerStore.
+type escrowStore struct {
+	*ledgerStore
+	escrows []*domain.Escrow
+}
+
+func (s *escrowStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+func (s *escrowStore) GetEscrowWalletID(ctx context.Context, currency string) (int64, bool, error) {
+	return walletID(escrowUserID), currency == "USD", nil
+}
+
+func (s *escrowStore) CreateEscrow(ctx context.Context, escrow *domain.Escrow) error {
+	escrow.ID = int64(len(s.escrows) + 1)
+	copied := *escrow
+	s.escrows = append(s.escrows, &copied)
+	return nil
+}
+
+func (s *escrowStore) GetEscrowByID(ctx context.Context, id int64) (*domain.Escrow, error) {
+	copied := *s.escrows[id-1]
+	return &copied, nil
+}
+
+func (s *escrowStore) GetEscrowForUpdate(ctx context.Context, id int64) (*domain.Escrow, error) {
+	return s.GetEscrowByID(ctx, id)
+}
+
+// ListDueEscrowIDs lists every open escrow past its deadline, disputed or
+// not, as the real query can when an escrow is disputed after it is listed.
+func (s *escrowStore) ListDueEscrowIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	for _, escrow := range s.escrows {
+		if escrow.Open() && !escrow.ReleaseAfter.After(now) {
+			ids = append(ids, escrow.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *escrowStore) UpdateEscrow(ctx context.Context, escrow *domain.Escrow) error {
+	copied := *escrow
+	s.escrows[escrow.ID-1] = &copied
+	return nil
+}
+
+func TestReleaseDueEscrowsSkipsDisputed(t *testing.T) {
+	store := &escrowStore{ledgerStore: newLedgerStore()}
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	store.addUser(escrowUserID, "0")
+	svc := NewEscrowService(store)
+	ctx := context.Background()
+
+	undisputed, err := svc.CreateEscrow(ctx, 1, 2, nil, dec("30.00"), "laptop", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateEscrow() error = %v", err)
+	}
+
+	disputed, err := svc.CreateEscrow(ctx, 1, 2, nil, dec("20.00"), "phone", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateEscrow() error = %v", err)
+	}
+
+	if _, err := svc.DisputeEscrow(ctx, 1, disputed.ID, "never arrived"); err != nil {
+		t.Fatalf("DisputeEscrow() error = %v", err)
+	}
+
+	// Nothing is due before the deadline.
+	if released, err := svc.ReleaseDueEscrows(ctx, time.Now().UTC()); err != nil || released != 0 {
+		t.Errorf("ReleaseDueEscrows() before the deadline = %d, %v, want 0", released, err)
+	}
+
+	due := time.Now().UTC().Add(2 * time.Hour)
+	released, err := svc.ReleaseDueEscrows(ctx, due)
+	if err != nil {
+		t.Fatalf("ReleaseDueEscrows() error = %v", err)
+	}
+
+	if released != 1 {
+		t.Errorf("ReleaseDueEscrows() released %d escrows, want 1", released)
+	}
+
+	if got := store.escrows[undisputed.ID-1]; got.Status != domain.EscrowStatusReleased || got.SettledByUserID != nil {
+		t.Errorf("undisputed escrow = %s settled by %v, want RELEASED by the worker", got.Status, got.SettledByUserID)
+	}
+
+	if got := store.escrows[disputed.ID-1]; got.Status != domain.EscrowStatusDisputed || got.SettledAt != nil {
+		t.Errorf("disputed escrow = %s settled at %v, want it still DISPUTED", got.Status, got.SettledAt)
+	}
+
+	store.assertBalance(t, 1, "50.00", "0")
+	store.assertBalance(t, 2, "30.00", "0")
+	store.assertBalance(t, escrowUserID, "20.00", "0")
+
+	// Later runs keep leaving it alone; it is settled by hand.
+	if released, err := svc.ReleaseDueEscrows(ctx, due.Add(24*time.Hour)); err != nil || released != 0 {
+		t.Errorf("second ReleaseDueEscrows() = %d, %v, want 0", released, err)
+	}
+
+	if _, err := svc.RefundEscrow(ctx, 2, disputed.ID); err != nil {
+		t.Fatalf("RefundEscrow() error = %v", err)
+	}
+
+	store.assertBalance(t, 1, "70.00", "0")
+	store.assertBalance(t, escrowUserID, "0", "0")
+
+	if _, err := svc.ReleaseEscrow(ctx, 1, disputed.ID); !errors.Is(err, ErrEscrowSettled) {
+		t.Errorf("ReleaseEscrow() of a refunded escrow error = %v, want %v", err, ErrEscrowSettled)
+	}
+}
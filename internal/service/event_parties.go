@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+// eventParties lists the payload fields that identify who an outbox event is
+// about. Event payloads name the users or wallets involved using these fields.
+type eventParties struct {
+	UserID           int64 `json:"user_id"`
+	RequesterUserID  int64 `json:"requester_user_id"`
+	PayerUserID      int64 `json:"payer_user_id"`
+	MerchantUserID   int64 `json:"merchant_user_id"`
+	ArbiterUserID    int64 `json:"arbiter_user_id"`
+	WalletID         int64 `json:"wallet_id"`
+	PayeeWalletID    int64 `json:"payee_wallet_id"`
+	SenderWalletID   int64 `json:"sender_wallet_id"`
+	ReceiverWalletID int64 `json:"receiver_wallet_id"`
+	PayerWalletID    int64 `json:"payer_wallet_id"`
+	MerchantWalletID int64 `json:"merchant_wallet_id"`
+}
+
+// resolveEventUserIDs returns the distinct users an event payload concerns.
+func resolveEventUserIDs(ctx context.Context, store repository.Store, data []byte) ([]int64, error) {
+	var parties eventParties
+	if err := json.Unmarshal(data, &parties); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool)
+	var userIDs []int64
+	add := func(id int64) {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	add(parties.UserID)
+	add(parties.RequesterUserID)
+	add(parties.PayerUserID)
+	add(parties.MerchantUserID)
+	add(parties.ArbiterUserID)
+
+	for _, walletID := range []int64{parties.WalletID, parties.PayeeWalletID, parties.SenderWalletID, parties.ReceiverWalletID,
+		parties.PayerWalletID, parties.MerchantWalletID} {
+		if walletID == 0 {
+			continue
+		}
+
+		wallet, err := store.GetWalletByID(ctx, walletID)
+		if err != nil {
+			return nil, err
+		}
+
+		if wallet != nil {
+			add(wallet.UserID)
+		}
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrFeeExceedsAmount      = errors.New("amount does not cover the transfer fee")
+	ErrRevenueWalletNotFound = errors.New("no revenue wallet is configured for the currency")
+	ErrFeeScheduleNotFound   = errors.New("fee schedule not found")
+	ErrInvalidFeeSchedule    = errors.New("fee schedule is not valid")
+)
+
+type feeService struct {
+	store repository.Store
+}
+
+func NewFeeService(store repository.Store) domain.FeeService {
+	return &feeService{
+		store: store,
+	}
+}
+
+// PreviewTransferFee returns the fee a transfer of amount to receiverUserID
+// would be charged if it were created now.
+func (s *feeService) PreviewTransferFee(ctx context.Context, senderUserID, receiverUserID int64, amount decimal.Decimal) (*domain.FeeQuote, error) {
+	var quote *domain.FeeQuote
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		_, receiverWallet, err := transferWallets(ctx, q, senderUserID, receiverUserID, amount)
+		if err != nil {
+			return err
+		}
+
+		quote, err = quoteTransferFee(ctx, q, receiverUserID, receiverWallet, amount)
+		return err
+	})
+
+	return quote, err
+}
+
+// CreateFeeSchedule validates and stores a new active schedule. Schedules are
+// not edited: to change a price, create its replacement and deactivate the
+// old schedule, so the schedule that priced a past transfer keeps its terms.
+func (s *feeService) CreateFeeSchedule(ctx context.Context, schedule *domain.FeeSchedule) (*domain.FeeSchedule, error) {
+	if err := validateFeeSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	if schedule.MerchantID != nil {
+		merchant, err := s.store.GetMerchantByID(ctx, *schedule.MerchantID)
+		if err != nil {
+			return nil, err
+		}
+
+		if merchant == nil {
+			return nil, ErrMerchantNotFound
+		}
+	}
+
+	created := *schedule
+	created.ID = 0
+	created.Active = true
+	if err := s.store.CreateFeeSchedule(ctx, &created); err != nil {
+		return nil, err
+	}
+
+	return s.GetFeeSchedule(ctx, created.ID)
+}
+
+// feeAmountScale and feePercentageScale are the decimal places the
+// fee_schedules columns keep for amounts and for percentages.
+const (
+	feeAmountScale     = 4
+	feePercentageScale = 6
+)
+
+// validateFeeSchedule checks that schedule applies to exactly one plan or
+// merchant, that its amounts and percentages fit their columns, and that its
+// fields match its type: a FLAT schedule has no percentage, and only a
+// TIERED one has tiers, which must rise strictly and end with an unbounded
+// tier so that every amount is priced.
+func validateFeeSchedule(schedule *domain.FeeSchedule) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidFeeSchedule, fmt.Sprintf(format, args...))
+	}
+
+	validAmount := func(d decimal.Decimal) bool {
+		return !d.IsNegative() && d.Equal(d.Round(feeAmountScale))
+	}
+
+	validPercentage := func(d decimal.Decimal) bool {
+		return !d.IsNegative() && d.LessThanOrEqual(decimal.NewFromInt(100)) && d.Equal(d.Round(feePercentageScale))
+	}
+
+	switch {
+	case (schedule.Plan == nil) == (schedule.MerchantID == nil):
+		return invalid("exactly one of plan and merchant_id must be set")
+	case schedule.Plan != nil && (*schedule.Plan == "" || len(*schedule.Plan) > 64):
+		return invalid("plan must be between 1 and 64 characters")
+	case schedule.Currency != nil && !validCurrency(*schedule.Currency):
+		return invalid("currency must be a 3-letter code")
+	case !validAmount(schedule.FlatAmount):
+		return invalid("flat_amount must not be negative and has at most %d decimal places", feeAmountScale)
+	case !validPercentage(schedule.Percentage):
+		return invalid("percentage must be between 0 and 100 with at most %d decimal places", feePercentageScale)
+	case schedule.MinFee.Valid && !validAmount(schedule.MinFee.Decimal):
+		return invalid("min_fee must not be negative and has at most %d decimal places", feeAmountScale)
+	case schedule.MaxFee.Valid && !validAmount(schedule.MaxFee.Decimal):
+		return invalid("max_fee must not be negative and has at most %d decimal places", feeAmountScale)
+	case schedule.MinFee.Valid && schedule.MaxFee.Valid && schedule.MinFee.Decimal.GreaterThan(schedule.MaxFee.Decimal):
+		return invalid("min_fee is more than max_fee")
+	}
+
+	switch schedule.Type {
+	case domain.FeeTypeFlat:
+		if !schedule.Percentage.IsZero() || len(schedule.Tiers) > 0 {
+			return invalid("a FLAT schedule only has a flat_amount")
+		}
+	case domain.FeeTypePercentage:
+		if len(schedule.Tiers) > 0 {
+			return invalid("only a TIERED schedule has tiers")
+		}
+	case domain.FeeTypeTiered:
+		if !schedule.FlatAmount.IsZero() || !schedule.Percentage.IsZero() {
+			return invalid("a TIERED schedule sets flat_amount and percentage on its tiers")
+		}
+
+		if len(schedule.Tiers) == 0 {
+			return invalid("a TIERED schedule needs at least one tier")
+		}
+
+		last := len(schedule.Tiers) - 1
+		for i, tier := range schedule.Tiers {
+			switch {
+			case !validAmount(tier.FlatAmount) || !validPercentage(tier.Percentage):
+				return invalid("tier %d has an invalid flat_amount or percentage", i+1)
+			case i == last && tier.UpTo.Valid:
+				return invalid("the last tier must not have an up_to")
+			case i < last && !tier.UpTo.Valid:
+				return invalid("only the last tier may leave up_to unset")
+			case i < last && !tier.UpTo.Decimal.IsPositive():
+				return invalid("tier %d must have a positive up_to", i+1)
+			case i > 0 && i < last && !tier.UpTo.Decimal.GreaterThan(schedule.Tiers[i-1].UpTo.Decimal):
+				return invalid("tier %d must have a larger up_to than the tier before it", i+1)
+			}
+		}
+	default:
+		return invalid("type must be FLAT, PERCENTAGE or TIERED")
+	}
+
+	return nil
+}
+
+func validCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ListFeeSchedules returns every schedule, active or not, newest first.
+func (s *feeService) ListFeeSchedules(ctx context.Context) ([]*domain.FeeSchedule, error) {
+	return s.store.ListFeeSchedules(ctx)
+}
+
+func (s *feeService) GetFeeSchedule(ctx context.Context, id int64) (*domain.FeeSchedule, error) {
+	schedule, err := s.store.GetFeeScheduleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule == nil {
+		return nil, ErrFeeScheduleNotFound
+	}
+
+	return schedule, nil
+}
+
+// DeactivateFeeSchedule stops the schedule from pricing new transfers.
+// Transfers already created keep the fee they were quoted. Deactivating an
+// inactive schedule does nothing.
+func (s *feeService) DeactivateFeeSchedule(ctx context.Context, id int64) (*domain.FeeSchedule, error) {
+	if _, err := s.GetFeeSchedule(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.SetFeeScheduleActive(ctx, id, false); err != nil {
+		return nil, err
+	}
+
+	return s.GetFeeSchedule(ctx, id)
+}
+
+func (s *feeService) ListRevenueWallets(ctx context.Context) ([]*domain.RevenueWallet, error) {
+	return s.store.ListRevenueWallets(ctx)
+}
+
+// SetRevenueWallet makes walletID collect the fees charged in currency from
+// now on. The wallet must hold that currency. Fees already posted stay where
+// they are.
+func (s *feeService) SetRevenueWallet(ctx context.Context, currency string, walletID int64) (*domain.RevenueWallet, error) {
+	wallet, err := s.store.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	if wallet.Currency != currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	revenueWallet := &domain.RevenueWallet{Currency: currency, WalletID: walletID}
+	if err := s.store.UpsertRevenueWallet(ctx, revenueWallet); err != nil {
+		return nil, err
+	}
+
+	return revenueWallet, nil
+}
+
+// quoteTransferFee prices a transfer of amount into receiverWallet. Transfers
+// into an active merchant's settlement wallet use the merchant's fee plan and
+// overrides; every other transfer uses the personal plan. A transfer without
+// a matching schedule is free. The fee is always deducted from what the
+// receiver is credited.
+//
+// Only transfers, including checkout payments, split payment legs and
+// accepted payment requests, are priced here. Escrow funding and settlement,
+// dispute postings and voucher redemptions are never charged a fee of their
+// own; a dispute reverses its share of the disputed payment's fee.
+func quoteTransferFee(ctx context.Context, q *repository.Queries, receiverUserID int64, receiverWallet *domain.Wallet, amount decimal.Decimal) (*domain.FeeQuote, error) {
+	plan := domain.FeePlanPersonal
+	var merchantID *int64
+
+	merchant, err := q.GetMerchantByUserID(ctx, receiverUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant != nil && merchant.Status == domain.MerchantStatusActive && merchant.SettlementWalletID == receiverWallet.ID {
+		plan = merchant.FeePlan
+		merchantID = &merchant.ID
+	}
+
+	schedules, err := q.ListActiveFeeSchedules(ctx, plan, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &domain.FeeQuote{
+		Amount:    amount,
+		Fee:       decimal.Zero,
+		NetAmount: amount,
+		FeePaidBy: domain.FeePayerReceiver,
+		Currency:  receiverWallet.Currency,
+	}
+
+	schedule := selectFeeSchedule(schedules, receiverWallet.Currency)
+	if schedule == nil {
+		return quote, nil
+	}
+
+	quote.FeeScheduleID = &schedule.ID
+	quote.Fee = schedule.Fee(amount)
+	quote.NetAmount = amount.Sub(quote.Fee)
+
+	if !quote.NetAmount.IsPositive() {
+		return nil, ErrFeeExceedsAmount
+	}
+
+	if quote.Fee.IsZero() {
+		return quote, nil
+	}
+
+	walletID, ok, err := q.GetRevenueWalletID(ctx, receiverWallet.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrRevenueWalletNotFound
+	}
+
+	quote.FeeWalletID = &walletID
+	return quote, nil
+}
+
+// selectFeeSchedule picks the most specific schedule for currency: a
+// merchant override before a plan schedule, then a schedule for the currency
+// before one for any currency. Among equals the newest wins.
+func selectFeeSchedule(schedules []*domain.FeeSchedule, currency string) *domain.FeeSchedule {
+	var best *domain.FeeSchedule
+	bestRank := -1
+
+	for _, schedule := range schedules {
+		rank := 0
+
+		if schedule.Currency != nil {
+			if *schedule.Currency != currency {
+				continue
+			}
+			rank++
+		}
+
+		if schedule.MerchantID != nil {
+			rank += 2
+		}
+
+		if rank > bestRank || (rank == bestRank && schedule.ID > best.ID) {
+			best, bestRank = schedule, rank
+		}
+	}
+
+	return best
+}
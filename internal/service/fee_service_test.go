@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func (s *ledgerStore) CreateFeeSchedule(ctx context.Context, schedule *domain.FeeSchedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule.ID = int64(len(s.feeSchedules) + 1)
+	copied := *schedule
+	s.feeSchedules = append(s.feeSchedules, &copied)
+	return nil
+}
+
+func (s *ledgerStore) GetFeeScheduleByID(ctx context.Context, id int64) (*domain.FeeSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > int64(len(s.feeSchedules)) {
+		return nil, nil
+	}
+
+	copied := *s.feeSchedules[id-1]
+	return &copied, nil
+}
+
+func (s *ledgerStore) SetFeeScheduleActive(ctx context.Context, id int64, active bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.feeSchedules[id-1].Active = active
+	return nil
+}
+
+func (s *ledgerStore) UpsertRevenueWallet(ctx context.Context, revenueWallet *domain.RevenueWallet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revenueWallets[revenueWallet.Currency] = revenueWallet.WalletID
+	return nil
+}
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func nullDec(s string) decimal.NullDecimal {
+	return decimal.NewNullDecimal(dec(s))
+}
+
+func TestFeeScheduleFee(t *testing.T) {
+	tiered := []domain.FeeTier{
+		{UpTo: nullDec("100"), FlatAmount: dec("0.25")},
+		{UpTo: nullDec("1000"), Percentage: dec("1")},
+		{Percentage: dec("0.5")},
+	}
+
+	tests := []struct {
+		name     string
+		schedule domain.FeeSchedule
+		amount   string
+		want     string
+	}{
+		{name: "flat", schedule: domain.FeeSchedule{Type: domain.FeeTypeFlat, FlatAmount: dec("0.50")}, amount: "10.00", want: "0.50"},
+		{name: "flat clamped to max", schedule: domain.FeeSchedule{Type: domain.FeeTypeFlat, FlatAmount: dec("5.00"), MaxFee: nullDec("2.00")}, amount: "10.00", want: "2.00"},
+		{name: "percentage plus flat", schedule: domain.FeeSchedule{Type: domain.FeeTypePercentage, FlatAmount: dec("0.30"), Percentage: dec("2.9")}, amount: "100.00", want: "3.20"},
+		{name: "percentage rounded to cents", schedule: domain.FeeSchedule{Type: domain.FeeTypePercentage, Percentage: dec("1")}, amount: "10.55", want: "0.11"},
+		{name: "percentage clamped to min", schedule: domain.FeeSchedule{Type: domain.FeeTypePercentage, Percentage: dec("2.9"), MinFee: nullDec("0.50")}, amount: "1.00", want: "0.50"},
+		{name: "percentage clamped to max", schedule: domain.FeeSchedule{Type: domain.FeeTypePercentage, Percentage: dec("2.9"), MaxFee: nullDec("25.00")}, amount: "10000.00", want: "25.00"},
+		{name: "percentage between min and max", schedule: domain.FeeSchedule{Type: domain.FeeTypePercentage, Percentage: dec("2"), MinFee: nullDec("0.50"), MaxFee: nullDec("25.00")}, amount: "100.00", want: "2.00"},
+		{name: "first tier", schedule: domain.FeeSchedule{Type: domain.FeeTypeTiered, Tiers: tiered}, amount: "50.00", want: "0.25"},
+		{name: "tier boundary is inclusive", schedule: domain.FeeSchedule{Type: domain.FeeTypeTiered, Tiers: tiered}, amount: "100.00", want: "0.25"},
+		{name: "second tier", schedule: domain.FeeSchedule{Type: domain.FeeTypeTiered, Tiers: tiered}, amount: "100.01", want: "1.00"},
+		{name: "unbounded tier", schedule: domain.FeeSchedule{Type: domain.FeeTypeTiered, Tiers: tiered}, amount: "2000.00", want: "10.00"},
+		{name: "tier clamped to max", schedule: domain.FeeSchedule{Type: domain.FeeTypeTiered, Tiers: tiered, MaxFee: nullDec("20.00")}, amount: "5000.00", want: "20.00"},
+		{name: "free tier clamped to min", schedule: domain.FeeSchedule{Type: domain.FeeTypeTiered, Tiers: []domain.FeeTier{{}}, MinFee: nullDec("0.10")}, amount: "1.00", want: "0.10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Fee(dec(tt.amount)); !got.Equal(dec(tt.want)) {
+				t.Errorf("Fee(%s) = %s, want %s", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectFeeSchedule(t *testing.T) {
+	plan, usd, eur := domain.FeePlanPersonal, "USD", "EUR"
+	merchantID := int64(7)
+
+	anyCurrency := &domain.FeeSchedule{ID: 1, Plan: &plan}
+	planUSD := &domain.FeeSchedule{ID: 2, Plan: &plan, Currency: &usd}
+	newerPlanUSD := &domain.FeeSchedule{ID: 3, Plan: &plan, Currency: &usd}
+	planEUR := &domain.FeeSchedule{ID: 4, Plan: &plan, Currency: &eur}
+	merchantAny := &domain.FeeSchedule{ID: 5, MerchantID: &merchantID}
+
+	tests := []struct {
+		name      string
+		schedules []*domain.FeeSchedule
+		want      *domain.FeeSchedule
+	}{
+		{name: "none", want: nil},
+		{name: "other currency only", schedules: []*domain.FeeSchedule{planEUR}, want: nil},
+		{name: "any currency", schedules: []*domain.FeeSchedule{anyCurrency, planEUR}, want: anyCurrency},
+		{name: "currency before any currency", schedules: []*domain.FeeSchedule{planUSD, anyCurrency}, want: planUSD},
+		{name: "newest of equals", schedules: []*domain.FeeSchedule{planUSD, newerPlanUSD}, want: newerPlanUSD},
+		{name: "merchant override before plan", schedules: []*domain.FeeSchedule{newerPlanUSD, merchantAny}, want: merchantAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectFeeSchedule(tt.schedules, usd); got != tt.want {
+				t.Errorf("selectFeeSchedule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewTransferFee(t *testing.T) {
+	plan := domain.FeePlanPersonal
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	store.addUser(revenueUserID, "0")
+	store.revenueWallets["USD"] = walletID(revenueUserID)
+	store.feeSchedules = []*domain.FeeSchedule{{ID: 1, Plan: &plan, Type: domain.FeeTypePercentage, Percentage: dec("2"), MinFee: nullDec("0.50"), Active: true}}
+
+	svc := NewFeeService(store)
+	ctx := context.Background()
+
+	quote, err := svc.PreviewTransferFee(ctx, 1, 2, dec("10.00"))
+	if err != nil {
+		t.Fatalf("PreviewTransferFee() error = %v", err)
+	}
+
+	if !quote.Fee.Equal(dec("0.50")) || !quote.NetAmount.Equal(dec("9.50")) || !quote.Amount.Equal(dec("10.00")) {
+		t.Errorf("quote = %+v, want a 0.50 fee taken from the 10.00 credited", quote)
+	}
+
+	if quote.FeePaidBy != domain.FeePayerReceiver || quote.FeeWalletID == nil || *quote.FeeWalletID != walletID(revenueUserID) {
+		t.Errorf("quote = %+v, want the receiver to pay the revenue wallet", quote)
+	}
+
+	if _, err := svc.PreviewTransferFee(ctx, 1, 2, dec("0.50")); !errors.Is(err, ErrFeeExceedsAmount) {
+		t.Errorf("PreviewTransferFee() of the fee alone error = %v, want %v", err, ErrFeeExceedsAmount)
+	}
+
+	delete(store.revenueWallets, "USD")
+	if _, err := svc.PreviewTransferFee(ctx, 1, 2, dec("10.00")); !errors.Is(err, ErrRevenueWalletNotFound) {
+		t.Errorf("PreviewTransferFee() without a revenue wallet error = %v, want %v", err, ErrRevenueWalletNotFound)
+	}
+
+	store.feeSchedules[0].Active = false
+	quote, err = svc.PreviewTransferFee(ctx, 1, 2, dec("10.00"))
+	if err != nil || !quote.Fee.IsZero() || quote.FeeScheduleID != nil {
+		t.Errorf("PreviewTransferFee() without a schedule = %+v, %v, want free", quote, err)
+	}
+}
+
+func TestCreateFeeSchedule(t *testing.T) {
+	plan, empty, usd, lower := domain.FeePlanPersonal, "", "USD", "usd"
+	merchantID := int64(7)
+
+	tests := []struct {
+		name     string
+		schedule domain.FeeSchedule
+	}{
+		{name: "no plan or merchant", schedule: domain.FeeSchedule{Type: domain.FeeTypeFlat}},
+		{name: "plan and merchant", schedule: domain.FeeSchedule{Plan: &plan, MerchantID: &merchantID, Type: domain.FeeTypeFlat}},
+		{name: "empty plan", schedule: domain.FeeSchedule{Plan: &empty, Type: domain.FeeTypeFlat}},
+		{name: "bad currency", schedule: domain.FeeSchedule{Plan: &plan, Currency: &lower, Type: domain.FeeTypeFlat}},
+		{name: "unknown type", schedule: domain.FeeSchedule{Plan: &plan, Type: "STEPPED"}},
+		{name: "negative flat amount", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeFlat, FlatAmount: dec("-1")}},
+		{name: "too precise flat amount", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeFlat, FlatAmount: dec("0.00001")}},
+		{name: "percentage over 100", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypePercentage, Percentage: dec("100.5")}},
+		{name: "flat with percentage", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeFlat, Percentage: dec("1")}},
+		{name: "min above max", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeFlat, MinFee: nullDec("2"), MaxFee: nullDec("1")}},
+		{name: "negative min", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeFlat, MinFee: nullDec("-1")}},
+		{name: "percentage with tiers", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypePercentage, Tiers: []domain.FeeTier{{}}}},
+		{name: "tiered without tiers", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeTiered}},
+		{name: "tiered with top-level percentage", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeTiered, Percentage: dec("1"), Tiers: []domain.FeeTier{{}}}},
+		{name: "bounded last tier", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeTiered, Tiers: []domain.FeeTier{{UpTo: nullDec("100")}}}},
+		{name: "unbounded middle tier", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeTiered, Tiers: []domain.FeeTier{{}, {}}}},
+		{name: "falling tiers", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeTiered,
+			Tiers: []domain.FeeTier{{UpTo: nullDec("100")}, {UpTo: nullDec("50")}, {}}}},
+		{name: "negative tier percentage", schedule: domain.FeeSchedule{Plan: &plan, Type: domain.FeeTypeTiered, Tiers: []domain.FeeTier{{Percentage: dec("-1")}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newLedgerStore()
+			_, err := NewFeeService(store).CreateFeeSchedule(context.Background(), &tt.schedule)
+			if !errors.Is(err, ErrInvalidFeeSchedule) || len(store.feeSchedules) != 0 {
+				t.Errorf("CreateFeeSchedule() error = %v with %d schedules stored, want %v", err, len(store.feeSchedules), ErrInvalidFeeSchedule)
+			}
+		})
+	}
+
+	store := newLedgerStore()
+	svc := NewFeeService(store)
+	ctx := context.Background()
+
+	if _, err := svc.CreateFeeSchedule(ctx, &domain.FeeSchedule{MerchantID: &merchantID, Type: domain.FeeTypeFlat}); !errors.Is(err, ErrMerchantNotFound) {
+		t.Errorf("CreateFeeSchedule() for an unknown merchant error = %v, want %v", err, ErrMerchantNotFound)
+	}
+
+	created, err := svc.CreateFeeSchedule(ctx, &domain.FeeSchedule{Plan: &plan, Currency: &usd, Type: domain.FeeTypeTiered,
+		Tiers: []domain.FeeTier{{UpTo: nullDec("100"), FlatAmount: dec("0.25")}, {Percentage: dec("0.5")}}, MaxFee: nullDec("20")})
+	if err != nil {
+		t.Fatalf("CreateFeeSchedule() error = %v", err)
+	}
+
+	if !created.Active || created.ID != 1 {
+		t.Errorf("CreateFeeSchedule() = %+v, want an active schedule", created)
+	}
+
+	deactivated, err := svc.DeactivateFeeSchedule(ctx, created.ID)
+	if err != nil || deactivated.Active {
+		t.Errorf("DeactivateFeeSchedule() = %+v, %v, want an inactive schedule", deactivated, err)
+	}
+
+	if _, err := svc.DeactivateFeeSchedule(ctx, 2); !errors.Is(err, ErrFeeScheduleNotFound) {
+		t.Errorf("DeactivateFeeSchedule() of an unknown schedule error = %v, want %v", err, ErrFeeScheduleNotFound)
+	}
+}
+
+func TestSetRevenueWallet(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(revenueUserID, "0")
+	svc := NewFeeService(store)
+	ctx := context.Background()
+
+	if _, err := svc.SetRevenueWallet(ctx, "EUR", walletID(revenueUserID)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("SetRevenueWallet() with a USD wallet for EUR error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	if _, err := svc.SetRevenueWallet(ctx, "USD", 1); !errors.Is(err, ErrWalletNotFound) {
+		t.Errorf("SetRevenueWallet() with an unknown wallet error = %v, want %v", err, ErrWalletNotFound)
+	}
+
+	if _, err := svc.SetRevenueWallet(ctx, "USD", walletID(revenueUserID)); err != nil {
+		t.Fatalf("SetRevenueWallet() error = %v", err)
+	}
+
+	if id, ok, _ := store.GetRevenueWalletID(ctx, "USD"); !ok || id != walletID(revenueUserID) {
+		t.Errorf("revenue wallet = %d, want %d", id, walletID(revenueUserID))
+	}
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrHoldNotFound        = errors.New("hold not found")
+	ErrHoldNotAuthorized   = errors.New("hold is no longer authorized")
+	ErrHoldExpired         = errors.New("hold has expired")
+	ErrCaptureExceedsHold  = errors.New("capture amount exceeds the authorized amount")
+	ErrInvalidHoldDuration = errors.New("hold expiry is out of range")
+)
+
+const (
+	DefaultHoldTTL = 7 * 24 * time.Hour
+	MaxHoldTTL     = 30 * 24 * time.Hour
+	holdBatchSize  = 100
+)
+
+type holdService struct {
+	store repository.Store
+}
+
+func NewHoldService(store repository.Store) domain.HoldService {
+	return &holdService{
+		store: store,
+	}
+}
+
+// AuthorizeHold reserves amount on the payer's wallet in favour of the payee.
+// A zero ttl uses DefaultHoldTTL.
+func (s *holdService) AuthorizeHold(ctx context.Context, payerUserID, payeeUserID int64, amount decimal.Decimal, reference string, ttl time.Duration) (*domain.Hold, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	if payerUserID == payeeUserID {
+		return nil, ErrSelfTransfer
+	}
+
+	if ttl == 0 {
+		ttl = DefaultHoldTTL
+	}
+
+	if ttl < time.Minute || ttl > MaxHoldTTL {
+		return nil, ErrInvalidHoldDuration
+	}
+
+	var created *domain.Hold
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		payerWallet, err := q.GetByUserID(ctx, payerUserID)
+		if err != nil {
+			return err
+		}
+
+		payeeWallet, err := q.GetByUserID(ctx, payeeUserID)
+		if err != nil {
+			return err
+		}
+
+		if payerWallet == nil || payeeWallet == nil {
+			return ErrWalletNotFound
+		}
+
+		payerWallet, payeeWallet, err = lockWalletPair(ctx, q, payerWallet.ID, payeeWallet.ID)
+		if err != nil {
+			return err
+		}
+
+		if payerWallet.Currency != payeeWallet.Currency {
+			return ErrCurrencyMismatch
+		}
+
+		if payerWallet.AvailableBalance.LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+
+		if err := q.AdjustWalletHeldBalance(ctx, payerWallet.ID, amount); err != nil {
+			return err
+		}
+
+		hold := &domain.Hold{
+			WalletID:       payerWallet.ID,
+			PayeeWalletID:  payeeWallet.ID,
+			Amount:         amount,
+			CapturedAmount: decimal.Zero,
+			Reference:      reference,
+			Status:         domain.HoldStatusAuthorized,
+			ExpiresAt:      time.Now().UTC().Add(ttl).Truncate(time.Second),
+		}
+
+		if err := q.CreateHold(ctx, hold); err != nil {
+			return err
+		}
+
+		created = hold
+		return publishHoldEvent(ctx, q, tasks.TopicHoldAuthorized, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetHoldByID(ctx, created.ID)
+}
+
+// CaptureHold settles amount of the hold to the payee immediately and releases
+// whatever remains. A zero amount captures the full authorized amount. The
+// payee's transfer fee is deducted from the captured amount.
+func (s *holdService) CaptureHold(ctx context.Context, payeeUserID, holdID int64, amount decimal.Decimal) (*domain.Hold, error) {
+	if amount.IsNegative() {
+		return nil, ErrInvalidAmount
+	}
+
+	return s.transition(ctx, payeeUserID, holdID, func(q *repository.Queries, hold *domain.Hold) (string, error) {
+		if amount.IsZero() {
+			amount = hold.Amount
+		}
+
+		if amount.GreaterThan(hold.Amount) {
+			return "", ErrCaptureExceedsHold
+		}
+
+		_, payee, err := lockWalletPair(ctx, q, hold.WalletID, hold.PayeeWalletID)
+		if err != nil {
+			return "", err
+		}
+
+		// Captures into a merchant wallet are priced like any other
+		// transfer; a fee the amount cannot cover rejects the capture.
+		quote, err := quoteTransferFee(ctx, q, payeeUserID, payee, amount)
+		if err != nil {
+			return "", err
+		}
+
+		if err := q.AdjustWalletHeldBalance(ctx, hold.WalletID, hold.Amount.Neg()); err != nil {
+			return "", err
+		}
+
+		tx := &domain.Transaction{
+			SenderWalletID:   hold.WalletID,
+			ReceiverWalletID: hold.PayeeWalletID,
+			Amount:           amount,
+			Fee:              quote.Fee,
+			FeeWalletID:      quote.FeeWalletID,
+			Status:           domain.TransactionStatusPending,
+		}
+		if err := q.CreateTransaction(ctx, tx); err != nil {
+			return "", err
+		}
+
+		// The released hold guarantees the funds, so the capture settles in
+		// this transaction instead of going through the worker.
+		if err := completeTransfer(ctx, q, tx); err != nil {
+			return "", err
+		}
+
+		hold.Status = domain.HoldStatusCaptured
+		hold.CapturedAmount = amount
+		hold.TransactionID = &tx.ID
+		return tasks.TopicHoldCaptured, nil
+	})
+}
+
+func (s *holdService) VoidHold(ctx context.Context, payeeUserID, holdID int64) (*domain.Hold, error) {
+	return s.transition(ctx, payeeUserID, holdID, func(q *repository.Queries, hold *domain.Hold) (string, error) {
+		if err := q.AdjustWalletHeldBalance(ctx, hold.WalletID, hold.Amount.Neg()); err != nil {
+			return "", err
+		}
+
+		hold.Status = domain.HoldStatusVoided
+		return tasks.TopicHoldVoided, nil
+	})
+}
+
+// transition locks an authorized, unexpired hold payable to payeeUserID and
+// persists whatever change apply makes along with its outbox event.
+func (s *holdService) transition(ctx context.Context, payeeUserID, holdID int64, apply func(*repository.Queries, *domain.Hold) (string, error)) (*domain.Hold, error) {
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		hold, err := q.GetHoldForUpdate(ctx, holdID)
+		if err != nil {
+			return err
+		}
+
+		if hold == nil {
+			return ErrHoldNotFound
+		}
+
+		payeeWallet, err := q.GetByUserID(ctx, payeeUserID)
+		if err != nil {
+			return err
+		}
+
+		if payeeWallet == nil || payeeWallet.ID != hold.PayeeWalletID {
+			return ErrHoldNotFound
+		}
+
+		if hold.Status != domain.HoldStatusAuthorized {
+			return ErrHoldNotAuthorized
+		}
+
+		if !hold.ExpiresAt.After(time.Now()) {
+			return ErrHoldExpired
+		}
+
+		topic, err := apply(q, hold)
+		if err != nil {
+			return err
+		}
+
+		if err := q.UpdateHold(ctx, hold); err != nil {
+			return err
+		}
+
+		return publishHoldEvent(ctx, q, topic, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetHoldByID(ctx, holdID)
+}
+
+func (s *holdService) GetHold(ctx context.Context, userID, holdID int64) (*domain.Hold, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := s.store.GetHoldByID(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet == nil || hold == nil || (hold.WalletID != wallet.ID && hold.PayeeWalletID != wallet.ID) {
+		return nil, ErrHoldNotFound
+	}
+
+	return hold, nil
+}
+
+// ListHolds returns holds placed on the user's wallet as well as those payable
+// to it.
+func (s *holdService) ListHolds(ctx context.Context, userID int64) ([]*domain.Hold, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	return s.store.ListHoldsByWallet(ctx, wallet.ID)
+}
+
+// ExpireHolds releases authorized holds whose expiry has passed and returns
+// how many were released.
+func (s *holdService) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListExpiredHoldIDs(ctx, now, holdBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		changed := false
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			hold, err := q.GetHoldForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if hold == nil || hold.Status != domain.HoldStatusAuthorized || hold.ExpiresAt.After(now) {
+				return nil
+			}
+
+			if _, err := q.GetWalletForUpdate(ctx, hold.WalletID); err != nil {
+				return err
+			}
+
+			if err := q.AdjustWalletHeldBalance(ctx, hold.WalletID, hold.Amount.Neg()); err != nil {
+				return err
+			}
+
+			hold.Status = domain.HoldStatusExpired
+			if err := q.UpdateHold(ctx, hold); err != nil {
+				return err
+			}
+
+			changed = true
+			return publishHoldEvent(ctx, q, tasks.TopicHoldExpired, hold)
+		})
+		if err != nil {
+			log.Printf("Error expiring hold %d: %v", id, err)
+			continue
+		}
+
+		if changed {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+func publishHoldEvent(ctx context.Context, q *repository.Queries, topic string, hold *domain.Hold) error {
+	return publishEvent(ctx, q, topic, tasks.HoldEventPayload{
+		HoldID:         hold.ID,
+		WalletID:       hold.WalletID,
+		PayeeWalletID:  hold.PayeeWalletID,
+		Amount:         hold.Amount,
+		CapturedAmount: hold.CapturedAmount,
+		Status:         string(hold.Status),
+		TransactionID:  hold.TransactionID,
+	})
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func (s *ledgerStore) CreateHold(ctx context.Context, hold *domain.Hold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hold.ID = int64(len(s.holds) + 1)
+	copied := *hold
+	s.holds[hold.ID] = &copied
+	return nil
+}
+
+func (s *ledgerStore) GetHoldByID(ctx context.Context, id int64) (*domain.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hold, ok := s.holds[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *hold
+	return &copied, nil
+}
+
+func (s *ledgerStore) GetHoldForUpdate(ctx context.Context, id int64) (*domain.Hold, error) {
+	return s.GetHoldByID(ctx, id)
+}
+
+func (s *ledgerStore) ListExpiredHoldIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, hold := range s.holds {
+		if hold.Status == domain.HoldStatusAuthorized && !hold.ExpiresAt.After(now) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *ledgerStore) UpdateHold(ctx context.Context, hold *domain.Hold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *hold
+	s.holds[hold.ID] = &copied
+	return nil
+}
+
+// authorizeHold has user 1 hold 40.00 for user 2.
+func authorizeHold(t *testing.T, svc domain.HoldService) *domain.Hold {
+	t.Helper()
+
+	hold, err := svc.AuthorizeHold(context.Background(), 1, 2, decimal.RequireFromString("40.00"), "order-1", 0)
+	if err != nil {
+		t.Fatalf("AuthorizeHold() error = %v", err)
+	}
+
+	return hold
+}
+
+func TestAuthorizeHoldReservesFunds(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	svc := NewHoldService(store)
+
+	hold := authorizeHold(t, svc)
+	if hold.Status != domain.HoldStatusAuthorized {
+		t.Errorf("hold status = %s, want AUTHORIZED", hold.Status)
+	}
+
+	store.assertBalance(t, 1, "100.00", "40.00")
+
+	_, err := svc.AuthorizeHold(context.Background(), 1, 2, decimal.RequireFromString("60.01"), "", 0)
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("AuthorizeHold() beyond the available balance error = %v, want %v", err, ErrInsufficientFunds)
+	}
+
+	// Held funds cannot be spent by a transfer either.
+	if _, err := NewTransactionService(store).CreateTransfer(context.Background(), 1, 2, decimal.RequireFromString("70.00")); err != nil {
+		t.Fatalf("CreateTransfer() error = %v", err)
+	}
+
+	store.settleTransfers(t)
+	if tx := store.transactions[1]; tx.Status != domain.TransactionStatusFailed {
+		t.Errorf("transfer of held funds = %s, want FAILED", tx.Status)
+	}
+
+	store.assertBalance(t, 1, "100.00", "40.00")
+}
+
+func TestCaptureHoldPaysPayeeAndReleasesRemainder(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	svc := NewHoldService(store)
+
+	hold := authorizeHold(t, svc)
+
+	if _, err := svc.CaptureHold(context.Background(), 1, hold.ID, decimal.Zero); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("CaptureHold() by the payer error = %v, want %v", err, ErrHoldNotFound)
+	}
+
+	if _, err := svc.CaptureHold(context.Background(), 2, hold.ID, decimal.RequireFromString("40.01")); !errors.Is(err, ErrCaptureExceedsHold) {
+		t.Errorf("CaptureHold() above the hold error = %v, want %v", err, ErrCaptureExceedsHold)
+	}
+
+	captured, err := svc.CaptureHold(context.Background(), 2, hold.ID, decimal.RequireFromString("30.00"))
+	if err != nil {
+		t.Fatalf("CaptureHold() error = %v", err)
+	}
+
+	if captured.Status != domain.HoldStatusCaptured || !captured.CapturedAmount.Equal(decimal.RequireFromString("30.00")) || captured.TransactionID == nil {
+		t.Errorf("hold = %s captured %s, want CAPTURED 30.00 with a transaction", captured.Status, captured.CapturedAmount)
+	}
+
+	if tx := store.transactions[*captured.TransactionID]; tx.Status != domain.TransactionStatusCompleted {
+		t.Errorf("capture transaction = %s, want COMPLETED", tx.Status)
+	}
+
+	store.assertBalance(t, 1, "70.00", "0")
+	store.assertBalance(t, 2, "30.00", "0")
+
+	if _, err := svc.VoidHold(context.Background(), 2, hold.ID); !errors.Is(err, ErrHoldNotAuthorized) {
+		t.Errorf("VoidHold() after capture error = %v, want %v", err, ErrHoldNotAuthorized)
+	}
+}
+
+func TestVoidHoldReleasesFunds(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	svc := NewHoldService(store)
+
+	hold := authorizeHold(t, svc)
+
+	voided, err := svc.VoidHold(context.Background(), 2, hold.ID)
+	if err != nil {
+		t.Fatalf("VoidHold() error = %v", err)
+	}
+
+	if voided.Status != domain.HoldStatusVoided {
+		t.Errorf("hold status = %s, want VOIDED", voided.Status)
+	}
+
+	store.assertBalance(t, 1, "100.00", "0")
+	store.assertBalance(t, 2, "0", "0")
+
+	if _, err := svc.CaptureHold(context.Background(), 2, hold.ID, decimal.Zero); !errors.Is(err, ErrHoldNotAuthorized) {
+		t.Errorf("CaptureHold() after void error = %v, want %v", err, ErrHoldNotAuthorized)
+	}
+}
+
+func TestExpireHoldsReleasesFunds(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	svc := NewHoldService(store)
+
+	hold := authorizeHold(t, svc)
+	store.holds[hold.ID].ExpiresAt = time.Now().Add(-time.Second)
+
+	if _, err := svc.CaptureHold(context.Background(), 2, hold.ID, decimal.Zero); !errors.Is(err, ErrHoldExpired) {
+		t.Errorf("CaptureHold() of an expired hold error = %v, want %v", err, ErrHoldExpired)
+	}
+
+	expired, err := svc.ExpireHolds(context.Background(), time.Now())
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireHolds() = %d, %v, want 1", expired, err)
+	}
+
+	if got := store.holds[hold.ID].Status; got != domain.HoldStatusExpired {
+		t.Errorf("hold status = %s, want EXPIRED", got)
+	}
+
+	store.assertBalance(t, 1, "100.00", "0")
+
+	// Expiring again releases nothing twice.
+	if expired, err := svc.ExpireHolds(context.Background(), time.Now()); err != nil || expired != 0 {
+		t.Errorf("ExpireHolds() again = %d, %v, want 0", expired, err)
+	}
+
+	store.assertBalance(t, 1, "100.00", "0")
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// ledgerStore is an in-memory store of users, wallets, transactions and their
+// ledger entries, enough to create and settle transfers. ExecTx runs one
+// callback at a time, standing in for the row locks of the real store. Tests
+// of other features add their own tables to it.
+type ledgerStore struct {
+	repository.Store
+
+	txMu sync.Mutex
+	mu   sync.Mutex
+
+	users          map[int64]*domain.User
+	wallets        map[int64]*domain.Wallet
+	transactions   map[int64]*domain.Transaction
+	ledger         []*domain.LedgerEntry
+	outbox         []*domain.Outbox
+	feeSchedules   []*domain.FeeSchedule
+	revenueWallets map[string]int64
+
+	scheduledTransfers map[int64]*domain.ScheduledTransfer
+	scheduledRuns      []*domain.ScheduledTransferRun
+	paymentRequests    map[int64]*domain.PaymentRequest
+	holds              map[int64]*domain.Hold
+	merchants          map[int64]*domain.Merchant
+	checkoutSessions   map[int64]*domain.CheckoutSession
+}
+
+func newLedgerStore() *ledgerStore {
+	return &ledgerStore{
+		users:              make(map[int64]*domain.User),
+		wallets:            make(map[int64]*domain.Wallet),
+		transactions:       make(map[int64]*domain.Transaction),
+		revenueWallets:     make(map[string]int64),
+		scheduledTransfers: make(map[int64]*domain.ScheduledTransfer),
+		paymentRequests:    make(map[int64]*domain.PaymentRequest),
+		holds:              make(map[int64]*domain.Hold),
+		merchants:          make(map[int64]*domain.Merchant),
+		checkoutSessions:   make(map[int64]*domain.CheckoutSession),
+	}
+}
+
+func (s *ledgerStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+// walletID is the ID of the wallet addUser creates for userID.
+func walletID(userID int64) int64 {
+	return 100 + userID
+}
+
+// addUser adds a user with a USD wallet holding balance.
+func (s *ledgerStore) addUser(userID int64, balance string) *domain.Wallet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[userID] = &domain.User{ID: userID, Name: "User", Email: "user@example.com"}
+
+	wallet := &domain.Wallet{
+		ID:       walletID(userID),
+		UserID:   userID,
+		Balance:  decimal.RequireFromString(balance),
+		Currency: "USD",
+	}
+	s.wallets[wallet.ID] = wallet
+
+	return wallet
+}
+
+// balances returns the ledger and held balance of the user's wallet.
+func (s *ledgerStore) balances(userID int64) (decimal.Decimal, decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet := s.wallets[walletID(userID)]
+	return wallet.Balance, wallet.HeldBalance
+}
+
+// assertBalance fails t unless the user's wallet holds balance, of which held
+// is reserved.
+func (s *ledgerStore) assertBalance(t *testing.T, userID int64, balance, held string) {
+	t.Helper()
+
+	gotBalance, gotHeld := s.balances(userID)
+	if !gotBalance.Equal(decimal.RequireFromString(balance)) || !gotHeld.Equal(decimal.RequireFromString(held)) {
+		t.Errorf("user %d balance = %s (held %s), want %s (held %s)", userID, gotBalance, gotHeld, balance, held)
+	}
+}
+
+// settleTransfers processes every PENDING transaction the way the worker
+// does.
+func (s *ledgerStore) settleTransfers(t *testing.T) {
+	t.Helper()
+
+	svc := NewTransactionService(s)
+
+	for _, id := range s.pendingTransactionIDs() {
+		if err := svc.ProcessTransfer(context.Background(), id); err != nil {
+			t.Fatalf("ProcessTransfer(%d) error = %v", id, err)
+		}
+	}
+}
+
+func (s *ledgerStore) pendingTransactionIDs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id := int64(1); id <= int64(len(s.transactions)); id++ {
+		if tx, ok := s.transactions[id]; ok && tx.Status == domain.TransactionStatusPending {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func (s *ledgerStore) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (s *ledgerStore) GetByUserID(ctx context.Context, userID int64) (*domain.Wallet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, wallet := range s.wallets {
+		if wallet.UserID == userID {
+			return copyWallet(wallet), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *ledgerStore) GetWalletByID(ctx context.Context, id int64) (*domain.Wallet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, ok := s.wallets[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return copyWallet(wallet), nil
+}
+
+func (s *ledgerStore) GetWalletForUpdate(ctx context.Context, id int64) (*domain.Wallet, error) {
+	return s.GetWalletByID(ctx, id)
+}
+
+// copyWallet returns a copy of wallet with its available balance computed as
+// the repository does.
+func copyWallet(wallet *domain.Wallet) *domain.Wallet {
+	copied := *wallet
+	copied.AvailableBalance = wallet.Balance.Sub(wallet.HeldBalance)
+	return &copied
+}
+
+func (s *ledgerStore) AdjustWalletBalance(ctx context.Context, id int64, delta decimal.Decimal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wallets[id].Balance = s.wallets[id].Balance.Add(delta)
+	return nil
+}
+
+func (s *ledgerStore) AdjustWalletHeldBalance(ctx context.Context, id int64, delta decimal.Decimal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wallets[id].HeldBalance = s.wallets[id].HeldBalance.Add(delta)
+	return nil
+}
+
+func (s *ledgerStore) CreateTransaction(ctx context.Context, tx *domain.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx.ID = int64(len(s.transactions) + 1)
+	copied := *tx
+	s.transactions[tx.ID] = &copied
+	return nil
+}
+
+func (s *ledgerStore) GetTransactionForUpdate(ctx context.Context, id int64) (*domain.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, ok := s.transactions[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *tx
+	return &copied, nil
+}
+
+func (s *ledgerStore) UpdateTransactionStatus(ctx context.Context, id int64, status domain.TransactionStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.transactions[id].Status = status
+	return nil
+}
+
+func (s *ledgerStore) CreateLedgerEntry(ctx context.Context, entry *domain.LedgerEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = int64(len(s.ledger) + 1)
+	copied := *entry
+	s.ledger = append(s.ledger, &copied)
+	return nil
+}
+
+func (s *ledgerStore) CreateOutbox(ctx context.Context, event *domain.Outbox) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = int64(len(s.outbox) + 1)
+	s.outbox = append(s.outbox, event)
+	return nil
+}
+
+func (s *ledgerStore) GetMerchantByUserID(ctx context.Context, userID int64) (*domain.Merchant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, merchant := range s.merchants {
+		if merchant.UserID == userID {
+			copied := *merchant
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *ledgerStore) ListActiveFeeSchedules(ctx context.Context, plan string, merchantID *int64) ([]*domain.FeeSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var schedules []*domain.FeeSchedule
+	for _, schedule := range s.feeSchedules {
+		if schedule.Active && schedule.Plan != nil && *schedule.Plan == plan {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	return schedules, nil
+}
+
+func (s *ledgerStore) GetRevenueWalletID(ctx context.Context, currency string) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.revenueWallets[currency]
+	return id, ok, nil
+}
+
+func (s *ledgerStore) GetCheckoutSessionByTransactionForUpdate(ctx context.Context, transactionID int64) (*domain.CheckoutSession, error) {
+	return s.findCheckoutSession(func(session *domain.CheckoutSession) bool {
+		return session.TransactionID != nil && *session.TransactionID == transactionID
+	}), nil
+}
+
+func (s *ledgerStore) GetScheduledTransferForUpdate(ctx context.Context, id int64) (*domain.ScheduledTransfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.scheduledTransfers[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *st
+	return &copied, nil
+}
+
+func (s *ledgerStore) ListDueScheduledTransferIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, st := range s.scheduledTransfers {
+		if st.Status == domain.ScheduledTransferStatusActive && st.NextRunAt != nil && !st.NextRunAt.After(now) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *ledgerStore) UpdateScheduledTransfer(ctx context.Context, st *domain.ScheduledTransfer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *st
+	s.scheduledTransfers[st.ID] = &copied
+	return nil
+}
+
+func (s *ledgerStore) CreateScheduledTransferRun(ctx context.Context, run *domain.ScheduledTransferRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run.ID = int64(len(s.scheduledRuns) + 1)
+	copied := *run
+	s.scheduledRuns = append(s.scheduledRuns, &copied)
+	return nil
+}
+
+func (s *ledgerStore) GetScheduledTransferRunByTransactionForUpdate(ctx context.Context, transactionID int64) (*domain.ScheduledTransferRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, run := range s.scheduledRuns {
+		if run.TransactionID != nil && *run.TransactionID == transactionID {
+			copied := *run
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *ledgerStore) UpdateScheduledTransferRun(ctx context.Context, run *domain.ScheduledTransferRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *run
+	s.scheduledRuns[run.ID-1] = &copied
+	return nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrLoginLocked = errors.New("too many failed login attempts, try again later")
+
+// LoginLockedError is returned while an account or IP is locked out. It
+// matches ErrLoginLocked with errors.Is.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+const (
+	// loginFailureWindow is how long a failed attempt counts towards a
+	// lockout.
+	loginFailureWindow = 15 * time.Minute
+	// lockoutDecay is how long a subject must go without failures before its
+	// lockouts stop escalating.
+	lockoutDecay           = 24 * time.Hour
+	accountLockoutFailures = 5
+	ipLockoutFailures      = 20
+	baseLockout            = time.Minute
+	maxLockout             = 24 * time.Hour
+	loginHistoryLimit      = 50
+	maxUserAgentLength     = 512
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so that
+// the response takes as long as for a wrong password.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// loginSubject keys the account throttle by the submitted email rather than
+// the user ID, so unknown emails lock out just like registered ones.
+func loginSubject(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkLoginLock returns a LoginLockedError if the account or the client IP is
+// locked out at now.
+func checkLoginLock(ctx context.Context, store repository.Store, now time.Time, accountSubject, ip string) error {
+	var lockedUntil time.Time
+
+	for scope, subject := range map[domain.LoginThrottleScope]string{
+		domain.LoginThrottleScopeAccount: accountSubject,
+		domain.LoginThrottleScopeIP:      ip,
+	} {
+		throttle, err := store.GetLoginThrottle(ctx, scope, subject)
+		if err != nil {
+			return err
+		}
+
+		if throttle != nil && throttle.LockedUntil != nil && throttle.LockedUntil.After(lockedUntil) {
+			lockedUntil = *throttle.LockedUntil
+		}
+	}
+
+	if lockedUntil.After(now) {
+		return &LoginLockedError{RetryAfter: lockedUntil.Sub(now)}
+	}
+
+	return nil
+}
+
+// recordLoginFailure logs the attempt and counts it against both the account
+// and the IP.
+func recordLoginFailure(ctx context.Context, q *repository.Queries, now time.Time, user *domain.User, accountSubject string, client domain.ClientInfo) error {
+	event := &domain.LoginEvent{
+		IP:        client.IP,
+		UserAgent: truncate(client.UserAgent, maxUserAgentLength),
+	}
+	if user != nil {
+		event.UserID = &user.ID
+	}
+
+	if err := q.CreateLoginEvent(ctx, event); err != nil {
+		return err
+	}
+
+	if err := countLoginFailure(ctx, q, now, domain.LoginThrottleScopeAccount, accountSubject, accountLockoutFailures); err != nil {
+		return err
+	}
+
+	return countLoginFailure(ctx, q, now, domain.LoginThrottleScopeIP, client.IP, ipLockoutFailures)
+}
+
+// countLoginFailure adds a failure to a throttle and locks it once threshold
+// failures fall within loginFailureWindow. Each lockout lasts twice as long as
+// the previous one, up to maxLockout.
+func countLoginFailure(ctx context.Context, q *repository.Queries, now time.Time, scope domain.LoginThrottleScope, subject string, threshold int) error {
+	throttle, err := q.GetLoginThrottleForUpdate(ctx, scope, subject)
+	if err != nil {
+		return err
+	}
+
+	if throttle == nil {
+		throttle = &domain.LoginThrottle{Scope: scope, Subject: subject}
+	}
+
+	if throttle.LastFailureAt != nil {
+		idle := now.Sub(*throttle.LastFailureAt)
+		if idle > loginFailureWindow {
+			throttle.Failures = 0
+		}
+		if idle > lockoutDecay {
+			throttle.Lockouts = 0
+		}
+	}
+
+	throttle.Failures++
+	throttle.LastFailureAt = &now
+
+	if throttle.Failures >= threshold {
+		throttle.Failures = 0
+		throttle.Lockouts++
+		lockedUntil := now.Add(lockoutDuration(throttle.Lockouts))
+		throttle.LockedUntil = &lockedUntil
+	}
+
+	return q.UpsertLoginThrottle(ctx, throttle)
+}
+
+func lockoutDuration(lockouts int) time.Duration {
+	d := baseLockout
+	for i := 1; i < lockouts && d < maxLockout; i++ {
+		d *= 2
+	}
+
+	return min(d, maxLockout)
+}
+
+// recordLoginSuccess clears the account's failures, logs the attempt and
+// emits TopicUserNewDeviceLogin when the user agent has not been seen for
+// this user before. The first device a user logs in from is not reported. IP
+// failures are left alone so one valid account cannot reset an IP lockout.
+func recordLoginSuccess(ctx context.Context, q *repository.Queries, user *domain.User, accountSubject string, client domain.ClientInfo) error {
+	throttle, err := q.GetLoginThrottleForUpdate(ctx, domain.LoginThrottleScopeAccount, accountSubject)
+	if err != nil {
+		return err
+	}
+
+	if throttle != nil && (throttle.Failures > 0 || throttle.Lockouts > 0 || throttle.LockedUntil != nil) {
+		throttle.Failures = 0
+		throttle.Lockouts = 0
+		throttle.LockedUntil = nil
+		if err := q.UpsertLoginThrottle(ctx, throttle); err != nil {
+			return err
+		}
+	}
+
+	knownDevices, err := q.CountUserDevices(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	userAgent := truncate(client.UserAgent, maxUserAgentLength)
+	fingerprint := sha256.Sum256([]byte(userAgent))
+
+	created, err := q.UpsertUserDevice(ctx, &domain.UserDevice{
+		UserID:      user.ID,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		UserAgent:   userAgent,
+		LastIP:      client.IP,
+	})
+	if err != nil {
+		return err
+	}
+
+	event := &domain.LoginEvent{
+		UserID:    &user.ID,
+		IP:        client.IP,
+		UserAgent: userAgent,
+		Success:   true,
+		NewDevice: created && knownDevices > 0,
+	}
+	if err := q.CreateLoginEvent(ctx, event); err != nil {
+		return err
+	}
+
+	if !event.NewDevice {
+		return nil
+	}
+
+	return publishEvent(ctx, q, tasks.TopicUserNewDeviceLogin, tasks.UserLoginEventPayload{
+		UserID:       user.ID,
+		LoginEventID: event.ID,
+		IP:           event.IP,
+		UserAgent:    event.UserAgent,
+	})
+}
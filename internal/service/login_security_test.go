@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "correct horse"
+
+type throttleKey struct {
+	scope   domain.LoginThrottleScope
+	subject string
+}
+
+// loginStore keeps users, login throttles, devices and login events in
+// memory.
+type loginStore struct {
+	repository.Store
+	users     map[string]*domain.User
+	throttles map[throttleKey]*domain.LoginThrottle
+	devices   map[int64]map[string]bool
+	events    []*domain.LoginEvent
+	outbox    []*domain.Outbox
+}
+
+func newLoginStore(t *testing.T) *loginStore {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &loginStore{
+		users: map[string]*domain.User{
+			"ada@example.com":   {ID: 1, Email: "ada@example.com", Password: string(hash)},
+			"grace@example.com": {ID: 2, Email: "grace@example.com", Password: string(hash)},
+		},
+		throttles: make(map[throttleKey]*domain.LoginThrottle),
+		devices:   make(map[int64]map[string]bool),
+	}
+}
+
+func (s *loginStore) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return s.users[email], nil
+}
+
+func (s *loginStore) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *loginStore) GetLoginThrottle(ctx context.Context, scope domain.LoginThrottleScope, subject string) (*domain.LoginThrottle, error) {
+	throttle, ok := s.throttles[throttleKey{scope, subject}]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *throttle
+	return &copied, nil
+}
+
+func (s *loginStore) GetLoginThrottleForUpdate(ctx context.Context, scope domain.LoginThrottleScope, subject string) (*domain.LoginThrottle, error) {
+	return s.GetLoginThrottle(ctx, scope, subject)
+}
+
+func (s *loginStore) UpsertLoginThrottle(ctx context.Context, throttle *domain.LoginThrottle) error {
+	copied := *throttle
+	s.throttles[throttleKey{throttle.Scope, throttle.Subject}] = &copied
+	return nil
+}
+
+func (s *loginStore) CreateLoginEvent(ctx context.Context, event *domain.LoginEvent) error {
+	event.ID = int64(len(s.events) + 1)
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *loginStore) CountUserDevices(ctx context.Context, userID int64) (int, error) {
+	return len(s.devices[userID]), nil
+}
+
+func (s *loginStore) UpsertUserDevice(ctx context.Context, device *domain.UserDevice) (bool, error) {
+	if s.devices[device.UserID] == nil {
+		s.devices[device.UserID] = make(map[string]bool)
+	}
+
+	if s.devices[device.UserID][device.Fingerprint] {
+		return false, nil
+	}
+
+	s.devices[device.UserID][device.Fingerprint] = true
+	return true, nil
+}
+
+func (s *loginStore) CreateOutbox(ctx context.Context, event *domain.Outbox) error {
+	event.ID = int64(len(s.outbox) + 1)
+	s.outbox = append(s.outbox, event)
+	return nil
+}
+
+// unlock moves every lockout into the past.
+func (s *loginStore) unlock() {
+	past := time.Now().Add(-time.Second)
+	for _, throttle := range s.throttles {
+		if throttle.LockedUntil != nil {
+			throttle.LockedUntil = &past
+		}
+	}
+}
+
+func newLoginService(store *loginStore) domain.UserService {
+	return NewUserService(txStore{store}, "test-secret", nil, "")
+}
+
+func TestLoginLocksAccountAfterRepeatedFailures(t *testing.T) {
+	store := newLoginStore(t)
+	svc := newLoginService(store)
+	client := domain.ClientInfo{IP: "203.0.113.1", UserAgent: "test"}
+
+	for i := 0; i < accountLockoutFailures; i++ {
+		if _, err := svc.Login(context.Background(), "ada@example.com", "wrong", client); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: Login() error = %v, want %v", i+1, err, ErrInvalidCredentials)
+		}
+	}
+
+	// Even the right password is refused while the account is locked.
+	_, err := svc.Login(context.Background(), "ada@example.com", testPassword, client)
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("Login() while locked error = %v, want %v", err, ErrLoginLocked)
+	}
+
+	if locked.RetryAfter <= 0 || locked.RetryAfter > baseLockout {
+		t.Errorf("RetryAfter = %v, want at most %v", locked.RetryAfter, baseLockout)
+	}
+
+	// Other accounts from the same IP are not affected.
+	if _, err := svc.Login(context.Background(), "grace@example.com", testPassword, client); err != nil {
+		t.Errorf("Login() to another account error = %v", err)
+	}
+
+	store.unlock()
+
+	if _, err := svc.Login(context.Background(), "ada@example.com", testPassword, client); err != nil {
+		t.Fatalf("Login() after the lockout error = %v", err)
+	}
+
+	account := store.throttles[throttleKey{domain.LoginThrottleScopeAccount, loginSubject("ada@example.com")}]
+	if account.Failures != 0 || account.Lockouts != 0 || account.LockedUntil != nil {
+		t.Errorf("account throttle after login = %+v, want cleared", account)
+	}
+}
+
+func TestLoginLocksUnknownEmails(t *testing.T) {
+	store := newLoginStore(t)
+	svc := newLoginService(store)
+	client := domain.ClientInfo{IP: "203.0.113.1"}
+
+	for i := 0; i < accountLockoutFailures; i++ {
+		svc.Login(context.Background(), "nobody@example.com", "wrong", client)
+	}
+
+	// The response is the same as for a registered account, and the account
+	// subject ignores case.
+	if _, err := svc.Login(context.Background(), "Nobody@Example.com", "wrong", client); !errors.Is(err, ErrLoginLocked) {
+		t.Errorf("Login() error = %v, want %v", err, ErrLoginLocked)
+	}
+}
+
+func TestLoginLocksIP(t *testing.T) {
+	store := newLoginStore(t)
+	svc := newLoginService(store)
+	attacker := domain.ClientInfo{IP: "203.0.113.1"}
+
+	for i := 0; i < ipLockoutFailures; i++ {
+		// Forget the account's failures so that only the IP can lock.
+		delete(store.throttles, throttleKey{domain.LoginThrottleScopeAccount, loginSubject("ada@example.com")})
+		svc.Login(context.Background(), "ada@example.com", "wrong", attacker)
+	}
+	delete(store.throttles, throttleKey{domain.LoginThrottleScopeAccount, loginSubject("ada@example.com")})
+
+	if _, err := svc.Login(context.Background(), "ada@example.com", testPassword, attacker); !errors.Is(err, ErrLoginLocked) {
+		t.Errorf("Login() from the locked IP error = %v, want %v", err, ErrLoginLocked)
+	}
+
+	if _, err := svc.Login(context.Background(), "ada@example.com", testPassword, domain.ClientInfo{IP: "203.0.113.2"}); err != nil {
+		t.Errorf("Login() from another IP error = %v", err)
+	}
+
+	// A successful login elsewhere does not lift the IP lockout.
+	if ip := store.throttles[throttleKey{domain.LoginThrottleScopeIP, attacker.IP}]; ip.LockedUntil == nil {
+		t.Error("IP lockout was cleared")
+	}
+}
+
+func TestVerifyPasswordCountsFailures(t *testing.T) {
+	store := newLoginStore(t)
+	svc := newLoginService(store)
+	client := domain.ClientInfo{IP: "203.0.113.1"}
+
+	if err := svc.VerifyPassword(context.Background(), 1, testPassword, client); err != nil {
+		t.Fatalf("VerifyPassword() error = %v", err)
+	}
+
+	for i := 0; i < accountLockoutFailures; i++ {
+		if err := svc.VerifyPassword(context.Background(), 1, "wrong", client); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: VerifyPassword() error = %v, want %v", i+1, err, ErrInvalidCredentials)
+		}
+	}
+
+	// Guessing a password this way locks the account for logins too.
+	if err := svc.VerifyPassword(context.Background(), 1, testPassword, client); !errors.Is(err, ErrLoginLocked) {
+		t.Errorf("VerifyPassword() while locked error = %v, want %v", err, ErrLoginLocked)
+	}
+
+	if _, err := svc.Login(context.Background(), "ada@example.com", testPassword, client); !errors.Is(err, ErrLoginLocked) {
+		t.Errorf("Login() while locked error = %v, want %v", err, ErrLoginLocked)
+	}
+}
+
+func TestCountLoginFailure(t *testing.T) {
+	store := newLoginStore(t)
+	q := queriesFor(store)
+	key := throttleKey{domain.LoginThrottleScopeAccount, "subject"}
+	now := time.Date(2025, time.June, 2, 9, 0, 0, 0, time.UTC)
+
+	fail := func(at time.Time, times int) *domain.LoginThrottle {
+		t.Helper()
+
+		for i := 0; i < times; i++ {
+			if err := countLoginFailure(context.Background(), q, at, key.scope, key.subject, 3); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return store.throttles[key]
+	}
+
+	if got := fail(now, 2); got.Failures != 2 || got.LockedUntil != nil {
+		t.Fatalf("throttle after 2 failures = %+v, want unlocked", got)
+	}
+
+	// Failures older than the window are forgotten.
+	now = now.Add(loginFailureWindow + time.Second)
+	if got := fail(now, 1); got.Failures != 1 || got.LockedUntil != nil {
+		t.Fatalf("throttle after an idle window = %+v, want 1 failure", got)
+	}
+
+	got := fail(now, 2)
+	if got.Lockouts != 1 || got.Failures != 0 || !got.LockedUntil.Equal(now.Add(time.Minute)) {
+		t.Fatalf("throttle after 3 failures = %+v, want locked for a minute", got)
+	}
+
+	// The next lockout lasts twice as long.
+	now = now.Add(2 * time.Minute)
+	if got := fail(now, 3); got.Lockouts != 2 || !got.LockedUntil.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("second lockout = %+v, want two minutes", got)
+	}
+
+	// A day without failures stops the escalation.
+	now = now.Add(lockoutDecay + time.Second)
+	if got := fail(now, 3); got.Lockouts != 1 || !got.LockedUntil.Equal(now.Add(time.Minute)) {
+		t.Errorf("lockout after a quiet day = %+v, want one minute", got)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	for _, tt := range []struct {
+		lockouts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{11, 1024 * time.Minute},
+		{12, maxLockout},
+		{50, maxLockout},
+	} {
+		if got := lockoutDuration(tt.lockouts); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", tt.lockouts, got, tt.want)
+		}
+	}
+}
+
+func TestLoginReportsNewDevices(t *testing.T) {
+	store := newLoginStore(t)
+	svc := newLoginService(store)
+
+	for _, userAgent := range []string{"laptop", "laptop", "phone"} {
+		if _, err := svc.Login(context.Background(), "ada@example.com", testPassword, domain.ClientInfo{IP: "203.0.113.1", UserAgent: userAgent}); err != nil {
+			t.Fatalf("Login() from %s error = %v", userAgent, err)
+		}
+	}
+
+	// The first device is not reported, nor is a device seen before.
+	if len(store.outbox) != 1 || store.outbox[0].Topic != tasks.TopicUserNewDeviceLogin {
+		t.Fatalf("outbox = %+v, want one %s event", store.outbox, tasks.TopicUserNewDeviceLogin)
+	}
+
+	var newDevices []string
+	for _, event := range store.events {
+		if event.NewDevice {
+			newDevices = append(newDevices, event.UserAgent)
+		}
+	}
+
+	if len(newDevices) != 1 || newDevices[0] != "phone" {
+		t.Errorf("new device logins = %v, want [phone]", newDevices)
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+var (
+	ErrMerchantNotFound = errors.New("merchant not found")
+	ErrMerchantExists   = errors.New("user already has a merchant profile")
+	ErrMerchantDisabled = errors.New("merchant is disabled")
+)
+
+type merchantService struct {
+	store repository.Store
+}
+
+func NewMerchantService(store repository.Store) domain.MerchantService {
+	return &merchantService{
+		store: store,
+	}
+}
+
+// CreateMerchant registers the user as a merchant on the standard fee plan.
+// Checkout payments settle into the user's wallet.
+func (s *merchantService) CreateMerchant(ctx context.Context, userID int64, profile domain.MerchantProfile) (*domain.Merchant, error) {
+	var created *domain.Merchant
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		existing, err := q.GetMerchantByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return ErrMerchantExists
+		}
+
+		wallet, err := q.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if wallet == nil {
+			return ErrWalletNotFound
+		}
+
+		merchant := &domain.Merchant{
+			UserID:             userID,
+			SettlementWalletID: wallet.ID,
+			FeePlan:            domain.FeePlanStandard,
+			Status:             domain.MerchantStatusActive,
+		}
+		applyMerchantProfile(merchant, profile)
+
+		if err := q.CreateMerchant(ctx, merchant); err != nil {
+			return err
+		}
+
+		created = merchant
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetMerchantByID(ctx, created.ID)
+}
+
+func (s *merchantService) GetMerchant(ctx context.Context, userID int64) (*domain.Merchant, error) {
+	merchant, err := s.store.GetMerchantByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant == nil {
+		return nil, ErrMerchantNotFound
+	}
+
+	return merchant, nil
+}
+
+// UpdateMerchant replaces the merchant's profile. The settlement wallet and
+// fee plan cannot be changed by the merchant.
+func (s *merchantService) UpdateMerchant(ctx context.Context, userID int64, profile domain.MerchantProfile) (*domain.Merchant, error) {
+	merchant, err := s.GetMerchant(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyMerchantProfile(merchant, profile)
+
+	if err := s.store.UpdateMerchantProfile(ctx, merchant); err != nil {
+		return nil, err
+	}
+
+	return s.store.GetMerchantByID(ctx, merchant.ID)
+}
+
+func applyMerchantProfile(merchant *domain.Merchant, profile domain.MerchantProfile) {
+	merchant.BusinessName = profile.BusinessName
+	merchant.LegalName = profile.LegalName
+	merchant.Website = profile.Website
+	merchant.SupportEmail = profile.SupportEmail
+	merchant.Country = profile.Country
+}
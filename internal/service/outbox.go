@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+// publishEvent writes payload to the outbox under topic using q, so the event
+// is committed together with the state change that produced it.
+func publishEvent(ctx context.Context, q *repository.Queries, topic string, payload any) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return q.CreateOutbox(ctx, &domain.Outbox{
+		Topic:   topic,
+		Payload: payloadBytes,
+	})
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+)
+
+const outboxBatchSize = 100
+
+type outboxService struct {
+	store    repository.Store
+	producer tasks.TaskProducer
+}
+
+func NewOutboxService(store repository.Store, producer tasks.TaskProducer) domain.OutboxService {
+	return &outboxService{
+		store:    store,
+		producer: producer,
+	}
+}
+
+// RelayOutbox enqueues unpublished outbox events onto asynq and marks them
+// published, draining the outbox in batches. It returns the number of events
+// relayed.
+func (s *outboxService) RelayOutbox(ctx context.Context) (int, error) {
+	relayed := 0
+
+	for {
+		batch := 0
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			events, err := q.ListUnpublishedOutboxForUpdate(ctx, outboxBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, event := range events {
+				if err := s.producer.ProduceOutboxTask(event); err != nil {
+					// Keep what has been enqueued so far; the rest is
+					// retried on the next run.
+					if batch > 0 {
+						return nil
+					}
+					return err
+				}
+
+				if err := q.MarkOutboxPublished(ctx, event.ID); err != nil {
+					return err
+				}
+				batch++
+			}
+
+			return nil
+		})
+		if err != nil {
+			return relayed, err
+		}
+
+		relayed += batch
+		if batch < outboxBatchSize {
+			return relayed, nil
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrPaymentRequestNotFound   = errors.New("payment request not found")
+	ErrPaymentRequestNotPending = errors.New("payment request is no longer pending")
+	ErrPaymentRequestExpired    = errors.New("payment request has expired")
+	ErrInvalidPaymentRequestTTL = errors.New("payment request expiry is out of range")
+)
+
+const (
+	DefaultPaymentRequestTTL = 7 * 24 * time.Hour
+	MaxPaymentRequestTTL     = 30 * 24 * time.Hour
+	paymentRequestBatchSize  = 100
+)
+
+type paymentRequestService struct {
+	store repository.Store
+}
+
+func NewPaymentRequestService(store repository.Store) domain.PaymentRequestService {
+	return &paymentRequestService{
+		store: store,
+	}
+}
+
+// CreatePaymentRequest asks payerUserID to pay requesterUserID. A zero ttl uses
+// DefaultPaymentRequestTTL.
+func (s *paymentRequestService) CreatePaymentRequest(ctx context.Context, requesterUserID, payerUserID int64, amount decimal.Decimal, memo string, ttl time.Duration) (*domain.PaymentRequest, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	if requesterUserID == payerUserID {
+		return nil, ErrSelfTransfer
+	}
+
+	if ttl == 0 {
+		ttl = DefaultPaymentRequestTTL
+	}
+
+	if ttl < time.Minute || ttl > MaxPaymentRequestTTL {
+		return nil, ErrInvalidPaymentRequestTTL
+	}
+
+	var created *domain.PaymentRequest
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		payerWallet, err := q.GetByUserID(ctx, payerUserID)
+		if err != nil {
+			return err
+		}
+
+		if payerWallet == nil {
+			return ErrWalletNotFound
+		}
+
+		pr := &domain.PaymentRequest{
+			RequesterUserID: requesterUserID,
+			PayerUserID:     payerUserID,
+			Amount:          amount,
+			Memo:            memo,
+			Status:          domain.PaymentRequestStatusPending,
+			ExpiresAt:       time.Now().UTC().Add(ttl).Truncate(time.Second),
+		}
+
+		if err := q.CreatePaymentRequest(ctx, pr); err != nil {
+			return err
+		}
+
+		if err := publishPaymentRequestEvent(ctx, q, tasks.TopicPaymentRequestCreated, pr); err != nil {
+			return err
+		}
+
+		created = pr
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetPaymentRequestByID(ctx, created.ID)
+}
+
+func (s *paymentRequestService) ListIncomingPaymentRequests(ctx context.Context, userID int64) ([]*domain.PaymentRequest, error) {
+	return s.store.ListPendingPaymentRequestsByPayer(ctx, userID)
+}
+
+func (s *paymentRequestService) ListOutgoingPaymentRequests(ctx context.Context, userID int64) ([]*domain.PaymentRequest, error) {
+	return s.store.ListPendingPaymentRequestsByRequester(ctx, userID)
+}
+
+// GetPaymentRequestForPayer returns a request addressed to userID.
+func (s *paymentRequestService) GetPaymentRequestForPayer(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+	pr, err := s.store.GetPaymentRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if pr == nil || pr.PayerUserID != userID {
+		return nil, ErrPaymentRequestNotFound
+	}
+
+	return pr, nil
+}
+
+// AcceptPaymentRequest creates a transfer from the payer to the requester in the
+// same database transaction that marks the request ACCEPTED.
+func (s *paymentRequestService) AcceptPaymentRequest(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+	return s.transition(ctx, id, func(q *repository.Queries, pr *domain.PaymentRequest) (string, error) {
+		if pr.PayerUserID != userID {
+			return "", ErrPaymentRequestNotFound
+		}
+
+		tx, err := createTransfer(ctx, q, pr.PayerUserID, pr.RequesterUserID, pr.Amount, pr.Memo)
+		if err != nil {
+			return "", err
+		}
+
+		pr.Status = domain.PaymentRequestStatusAccepted
+		pr.TransactionID = &tx.ID
+		return tasks.TopicPaymentRequestAccepted, nil
+	})
+}
+
+func (s *paymentRequestService) DeclinePaymentRequest(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+	return s.transition(ctx, id, func(q *repository.Queries, pr *domain.PaymentRequest) (string, error) {
+		if pr.PayerUserID != userID {
+			return "", ErrPaymentRequestNotFound
+		}
+
+		pr.Status = domain.PaymentRequestStatusDeclined
+		return tasks.TopicPaymentRequestDeclined, nil
+	})
+}
+
+func (s *paymentRequestService) CancelPaymentRequest(ctx context.Context, userID, id int64) (*domain.PaymentRequest, error) {
+	return s.transition(ctx, id, func(q *repository.Queries, pr *domain.PaymentRequest) (string, error) {
+		if pr.RequesterUserID != userID {
+			return "", ErrPaymentRequestNotFound
+		}
+
+		pr.Status = domain.PaymentRequestStatusCancelled
+		return tasks.TopicPaymentRequestCancelled, nil
+	})
+}
+
+// transition locks a pending, unexpired request, lets apply move it to a new
+// state and persists the result together with the outbox event apply names.
+func (s *paymentRequestService) transition(ctx context.Context, id int64, apply func(*repository.Queries, *domain.PaymentRequest) (string, error)) (*domain.PaymentRequest, error) {
+	var updated *domain.PaymentRequest
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		pr, err := q.GetPaymentRequestForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if pr == nil {
+			return ErrPaymentRequestNotFound
+		}
+
+		if pr.Status != domain.PaymentRequestStatusPending {
+			return ErrPaymentRequestNotPending
+		}
+
+		if !pr.ExpiresAt.After(time.Now()) {
+			return ErrPaymentRequestExpired
+		}
+
+		topic, err := apply(q, pr)
+		if err != nil {
+			return err
+		}
+
+		if err := q.UpdatePaymentRequest(ctx, pr); err != nil {
+			return err
+		}
+
+		if err := publishPaymentRequestEvent(ctx, q, topic, pr); err != nil {
+			return err
+		}
+
+		updated = pr
+		return nil
+	})
+
+	return updated, err
+}
+
+// ExpirePaymentRequests marks pending requests whose expiry has passed as
+// EXPIRED and returns how many were expired.
+func (s *paymentRequestService) ExpirePaymentRequests(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListExpiredPaymentRequestIDs(ctx, now, paymentRequestBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		changed := false
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			pr, err := q.GetPaymentRequestForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if pr == nil || pr.Status != domain.PaymentRequestStatusPending || pr.ExpiresAt.After(now) {
+				return nil
+			}
+
+			pr.Status = domain.PaymentRequestStatusExpired
+			if err := q.UpdatePaymentRequest(ctx, pr); err != nil {
+				return err
+			}
+
+			changed = true
+			return publishPaymentRequestEvent(ctx, q, tasks.TopicPaymentRequestExpired, pr)
+		})
+		if err != nil {
+			log.Printf("Error expiring payment request %d: %v", id, err)
+			continue
+		}
+
+		if changed {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+func publishPaymentRequestEvent(ctx context.Context, q *repository.Queries, topic string, pr *domain.PaymentRequest) error {
+	return publishEvent(ctx, q, topic, tasks.PaymentRequestEventPayload{
+		PaymentRequestID: pr.ID,
+		RequesterUserID:  pr.RequesterUserID,
+		PayerUserID:      pr.PayerUserID,
+		Status:           string(pr.Status),
+		TransactionID:    pr.TransactionID,
+	})
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func (s *ledgerStore) CreatePaymentRequest(ctx context.Context, pr *domain.PaymentRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr.ID = int64(len(s.paymentRequests) + 1)
+	copied := *pr
+	s.paymentRequests[pr.ID] = &copied
+	return nil
+}
+
+func (s *ledgerStore) GetPaymentRequestByID(ctx context.Context, id int64) (*domain.PaymentRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr, ok := s.paymentRequests[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *pr
+	return &copied, nil
+}
+
+func (s *ledgerStore) GetPaymentRequestForUpdate(ctx context.Context, id int64) (*domain.PaymentRequest, error) {
+	return s.GetPaymentRequestByID(ctx, id)
+}
+
+func (s *ledgerStore) ListExpiredPaymentRequestIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, pr := range s.paymentRequests {
+		if pr.Status == domain.PaymentRequestStatusPending && !pr.ExpiresAt.After(now) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *ledgerStore) UpdatePaymentRequest(ctx context.Context, pr *domain.PaymentRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *pr
+	s.paymentRequests[pr.ID] = &copied
+	return nil
+}
+
+// newPaymentRequest has user 2 ask user 1 for 25.00.
+func newPaymentRequest(t *testing.T, svc domain.PaymentRequestService) *domain.PaymentRequest {
+	t.Helper()
+
+	pr, err := svc.CreatePaymentRequest(context.Background(), 2, 1, decimal.RequireFromString("25.00"), "Dinner", 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentRequest() error = %v", err)
+	}
+
+	return pr
+}
+
+func TestAcceptPaymentRequestPaysRequester(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	svc := NewPaymentRequestService(store)
+
+	pr := newPaymentRequest(t, svc)
+
+	if _, err := svc.AcceptPaymentRequest(context.Background(), 2, pr.ID); !errors.Is(err, ErrPaymentRequestNotFound) {
+		t.Errorf("AcceptPaymentRequest() by the requester error = %v, want %v", err, ErrPaymentRequestNotFound)
+	}
+
+	accepted, err := svc.AcceptPaymentRequest(context.Background(), 1, pr.ID)
+	if err != nil {
+		t.Fatalf("AcceptPaymentRequest() error = %v", err)
+	}
+
+	if accepted.Status != domain.PaymentRequestStatusAccepted || accepted.TransactionID == nil {
+		t.Fatalf("request = %s, transaction %v, want ACCEPTED with a transaction", accepted.Status, accepted.TransactionID)
+	}
+
+	if tx := store.transactions[*accepted.TransactionID]; tx.Memo != "Dinner" || !tx.Amount.Equal(pr.Amount) {
+		t.Errorf("transaction = %s %q, want 25.00 \"Dinner\"", tx.Amount, tx.Memo)
+	}
+
+	store.settleTransfers(t)
+	store.assertBalance(t, 1, "75.00", "0")
+	store.assertBalance(t, 2, "25.00", "0")
+
+	if _, err := svc.DeclinePaymentRequest(context.Background(), 1, pr.ID); !errors.Is(err, ErrPaymentRequestNotPending) {
+		t.Errorf("DeclinePaymentRequest() after accepting error = %v, want %v", err, ErrPaymentRequestNotPending)
+	}
+}
+
+func TestDeclineAndCancelPaymentRequest(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	svc := NewPaymentRequestService(store)
+
+	declined := newPaymentRequest(t, svc)
+	if _, err := svc.DeclinePaymentRequest(context.Background(), 2, declined.ID); !errors.Is(err, ErrPaymentRequestNotFound) {
+		t.Errorf("DeclinePaymentRequest() by the requester error = %v, want %v", err, ErrPaymentRequestNotFound)
+	}
+
+	if pr, err := svc.DeclinePaymentRequest(context.Background(), 1, declined.ID); err != nil || pr.Status != domain.PaymentRequestStatusDeclined {
+		t.Errorf("DeclinePaymentRequest() = %v, %v, want DECLINED", pr, err)
+	}
+
+	cancelled := newPaymentRequest(t, svc)
+	if _, err := svc.CancelPaymentRequest(context.Background(), 1, cancelled.ID); !errors.Is(err, ErrPaymentRequestNotFound) {
+		t.Errorf("CancelPaymentRequest() by the payer error = %v, want %v", err, ErrPaymentRequestNotFound)
+	}
+
+	if pr, err := svc.CancelPaymentRequest(context.Background(), 2, cancelled.ID); err != nil || pr.Status != domain.PaymentRequestStatusCancelled {
+		t.Errorf("CancelPaymentRequest() = %v, %v, want CANCELLED", pr, err)
+	}
+
+	if len(store.transactions) != 0 {
+		t.Errorf("transactions = %d, want none", len(store.transactions))
+	}
+}
+
+func TestExpiredPaymentRequestCannotBeAccepted(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	svc := NewPaymentRequestService(store)
+
+	pr := newPaymentRequest(t, svc)
+	store.paymentRequests[pr.ID].ExpiresAt = time.Now().Add(-time.Second)
+
+	if _, err := svc.AcceptPaymentRequest(context.Background(), 1, pr.ID); !errors.Is(err, ErrPaymentRequestExpired) {
+		t.Errorf("AcceptPaymentRequest() error = %v, want %v", err, ErrPaymentRequestExpired)
+	}
+
+	expired, err := svc.ExpirePaymentRequests(context.Background(), time.Now())
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpirePaymentRequests() = %d, %v, want 1", expired, err)
+	}
+
+	if got := store.paymentRequests[pr.ID].Status; got != domain.PaymentRequestStatusExpired {
+		t.Errorf("status = %s, want EXPIRED", got)
+	}
+
+	if _, err := svc.AcceptPaymentRequest(context.Background(), 1, pr.ID); !errors.Is(err, ErrPaymentRequestNotPending) {
+		t.Errorf("AcceptPaymentRequest() after expiry error = %v, want %v", err, ErrPaymentRequestNotPending)
+	}
+
+	store.assertBalance(t, 1, "100.00", "0")
+}
+
+func TestCreatePaymentRequestValidates(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "0")
+	svc := NewPaymentRequestService(store)
+
+	tests := []struct {
+		name   string
+		payer  int64
+		amount string
+		ttl    time.Duration
+		want   error
+	}{
+		{"zero amount", 1, "0", 0, ErrInvalidAmount},
+		{"self", 2, "1", 0, ErrSelfTransfer},
+		{"ttl too short", 1, "1", time.Second, ErrInvalidPaymentRequestTTL},
+		{"ttl too long", 1, "1", MaxPaymentRequestTTL + time.Hour, ErrInvalidPaymentRequestTTL},
+		{"unknown payer", 3, "1", 0, ErrWalletNotFound},
+	}
+
+	for _, tt := range tests {
+		_, err := svc.CreatePaymentRequest(context.Background(), 2, tt.payer, decimal.RequireFromString(tt.amount), "", tt.ttl)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: CreatePaymentRequest() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
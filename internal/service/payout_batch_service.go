@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/payoutfile"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrPayoutBatchNotFound     = errors.New("payout batch not found")
+	ErrPayoutBatchItemNotFound = errors.New("payout batch item not found")
+	ErrInvalidPayoutBatch      = errors.New("payout batch is not valid")
+)
+
+type payoutBatchService struct {
+	store repository.Store
+}
+
+func NewPayoutBatchService(store repository.Store) domain.PayoutBatchService {
+	return &payoutBatchService{
+		store: store,
+	}
+}
+
+// CreatePayoutBatch validates every row, holds the batch total on the user's
+// wallet and queues one transfer per row. A batch with any invalid row is
+// refused as a whole, listing the rows at fault. The receivers' fees are
+// fixed when each item is paid.
+func (s *payoutBatchService) CreatePayoutBatch(ctx context.Context, userID int64, rows []domain.PayoutBatchRow) (*domain.PayoutBatch, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayoutBatch, payoutfile.ErrNoRows)
+	}
+
+	if len(rows) > payoutfile.MaxRows {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayoutBatch, payoutfile.ErrTooManyRows)
+	}
+
+	var created *domain.PayoutBatch
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		wallet, err := q.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if wallet == nil {
+			return ErrWalletNotFound
+		}
+
+		items, err := validatePayoutRows(ctx, q, userID, wallet, rows)
+		if err != nil {
+			return err
+		}
+
+		total := decimal.Zero
+		for _, item := range items {
+			total = total.Add(item.Amount)
+		}
+
+		wallet, err = q.GetWalletForUpdate(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+
+		if wallet.AvailableBalance.LessThan(total) {
+			return ErrInsufficientFunds
+		}
+
+		if err := q.AdjustWalletHeldBalance(ctx, wallet.ID, total); err != nil {
+			return err
+		}
+
+		batch := &domain.PayoutBatch{
+			UserID:         userID,
+			WalletID:       wallet.ID,
+			Currency:       wallet.Currency,
+			TotalAmount:    total,
+			ReservedAmount: total,
+			ItemCount:      len(items),
+			Status:         domain.PayoutBatchStatusProcessing,
+		}
+
+		if err := q.CreatePayoutBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			item.BatchID = batch.ID
+			if err := q.CreatePayoutBatchItem(ctx, item); err != nil {
+				return err
+			}
+
+			if err := publishEvent(ctx, q, tasks.TaskTypeProcessPayoutBatchItem, tasks.ProcessPayoutBatchItemPayload{ItemID: item.ID}); err != nil {
+				return err
+			}
+		}
+
+		created = batch
+		return publishPayoutBatchEvent(ctx, q, tasks.TopicPayoutBatchCreated, batch)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPayoutBatch(ctx, userID, created.ID)
+}
+
+// validatePayoutRows checks each row as a transfer from the user's wallet and
+// returns the rows as PENDING items. Every row at fault is reported.
+func validatePayoutRows(ctx context.Context, q *repository.Queries, userID int64, wallet *domain.Wallet, rows []domain.PayoutBatchRow) ([]*domain.PayoutBatchItem, error) {
+	var errs payoutfile.Errors
+	reject := func(row domain.PayoutBatchRow, format string, args ...any) {
+		errs = append(errs, payoutfile.LineError{Line: row.LineNumber, Message: fmt.Sprintf(format, args...)})
+	}
+
+	references := make(map[string]int, len(rows))
+	items := make([]*domain.PayoutBatchItem, 0, len(rows))
+
+	for _, row := range rows {
+		switch {
+		case row.Reference == "":
+			reject(row, "reference is required")
+			continue
+		case len(row.Reference) > 255:
+			reject(row, "reference is longer than 255 characters")
+			continue
+		}
+
+		if line, ok := references[row.Reference]; ok {
+			reject(row, "reference %q is already used on line %d", row.Reference, line)
+			continue
+		}
+		references[row.Reference] = row.LineNumber
+
+		if !row.Amount.Equal(row.Amount.Round(2)) {
+			reject(row, "amount has more than 2 decimal places")
+			continue
+		}
+
+		_, receiverWallet, err := transferWallets(ctx, q, userID, row.RecipientUserID, row.Amount)
+		switch {
+		case errors.Is(err, ErrWalletNotFound):
+			reject(row, "recipient %d not found", row.RecipientUserID)
+			continue
+		case errors.Is(err, ErrInvalidAmount), errors.Is(err, ErrSelfTransfer):
+			reject(row, "%v", err)
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		if receiverWallet.Currency != wallet.Currency {
+			reject(row, "recipient's wallet is in %s, not %s", receiverWallet.Currency, wallet.Currency)
+			continue
+		}
+
+		_, err = quoteTransferFee(ctx, q, row.RecipientUserID, receiverWallet, row.Amount)
+		switch {
+		case errors.Is(err, ErrFeeExceedsAmount), errors.Is(err, ErrRevenueWalletNotFound):
+			reject(row, "%v", err)
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		items = append(items, &domain.PayoutBatchItem{
+			LineNumber:       row.LineNumber,
+			RecipientUserID:  row.RecipientUserID,
+			ReceiverWalletID: receiverWallet.ID,
+			Amount:           row.Amount,
+			Reference:        row.Reference,
+			Status:           domain.PayoutBatchItemStatusPending,
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayoutBatch, errs)
+	}
+
+	return items, nil
+}
+
+func (s *payoutBatchService) ListPayoutBatches(ctx context.Context, userID int64) ([]*domain.PayoutBatch, error) {
+	return s.store.ListPayoutBatchesByUser(ctx, userID)
+}
+
+// GetPayoutBatch returns the batch with the outcome of each of its items.
+func (s *payoutBatchService) GetPayoutBatch(ctx context.Context, userID, id int64) (*domain.PayoutBatch, error) {
+	batch, err := s.store.GetPayoutBatchByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if batch == nil || batch.UserID != userID {
+		return nil, ErrPayoutBatchNotFound
+	}
+
+	if batch.Items, err = s.store.ListPayoutBatchItems(ctx, batch.ID); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// ProcessPayoutBatchItem pays a PENDING item out of the batch's hold. The
+// recipient is checked again, since they may have been erased or changed
+// currency since the batch was accepted; an item that can no longer be paid
+// fails and its amount is released. The last item to settle completes the
+// batch. Settled items are left untouched, so the task is idempotent.
+func (s *payoutBatchService) ProcessPayoutBatchItem(ctx context.Context, itemID int64) error {
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		item, err := q.GetPayoutBatchItemForUpdate(ctx, itemID)
+		if err != nil {
+			return err
+		}
+
+		if item == nil {
+			return ErrPayoutBatchItemNotFound
+		}
+
+		if item.Status != domain.PayoutBatchItemStatusPending {
+			return nil
+		}
+
+		batch, err := q.GetPayoutBatchForUpdate(ctx, item.BatchID)
+		if err != nil {
+			return err
+		}
+
+		if batch == nil {
+			return ErrPayoutBatchNotFound
+		}
+
+		if err := payPayoutBatchItem(ctx, q, batch, item); err != nil {
+			return err
+		}
+
+		if err := q.UpdatePayoutBatchItem(ctx, item); err != nil {
+			return err
+		}
+
+		batch.ReservedAmount = batch.ReservedAmount.Sub(item.Amount)
+		if item.Status == domain.PayoutBatchItemStatusCompleted {
+			batch.SucceededCount++
+		} else {
+			batch.FailedCount++
+		}
+
+		settled := batch.SucceededCount+batch.FailedCount == batch.ItemCount
+		if settled {
+			now := time.Now().UTC()
+			batch.Status = domain.PayoutBatchStatusCompleted
+			batch.CompletedAt = &now
+		}
+
+		if err := q.UpdatePayoutBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		if !settled {
+			return nil
+		}
+
+		return publishPayoutBatchEvent(ctx, q, tasks.TopicPayoutBatchCompleted, batch)
+	})
+}
+
+// payPayoutBatchItem releases the item's share of the batch hold and, if the
+// recipient can still be paid, settles the transfer to them. It sets the
+// item's outcome but does not save it.
+func payPayoutBatchItem(ctx context.Context, q *repository.Queries, batch *domain.PayoutBatch, item *domain.PayoutBatchItem) error {
+	fail := func(reason string) error {
+		item.Status = domain.PayoutBatchItemStatusFailed
+		item.FailureReason = truncate(reason, 255)
+		return nil
+	}
+
+	_, receiverWallet, err := transferWallets(ctx, q, batch.UserID, item.RecipientUserID, item.Amount)
+	if err != nil && !errors.Is(err, ErrWalletNotFound) {
+		return err
+	}
+
+	sender, receiver, err := lockWalletPair(ctx, q, batch.WalletID, item.ReceiverWalletID)
+	if err != nil {
+		return err
+	}
+
+	if err := q.AdjustWalletHeldBalance(ctx, sender.ID, item.Amount.Neg()); err != nil {
+		return err
+	}
+
+	switch {
+	case receiverWallet == nil || receiverWallet.ID != receiver.ID:
+		return fail("recipient not found")
+	case sender.Currency != receiver.Currency:
+		return fail(ErrCurrencyMismatch.Error())
+	}
+
+	quote, err := quoteTransferFee(ctx, q, item.RecipientUserID, receiver, item.Amount)
+	switch {
+	case errors.Is(err, ErrFeeExceedsAmount), errors.Is(err, ErrRevenueWalletNotFound):
+		return fail(err.Error())
+	case err != nil:
+		return err
+	}
+
+	tx := &domain.Transaction{
+		SenderWalletID:   sender.ID,
+		ReceiverWalletID: receiver.ID,
+		Amount:           item.Amount,
+		Fee:              quote.Fee,
+		FeeWalletID:      quote.FeeWalletID,
+		Status:           domain.TransactionStatusPending,
+	}
+	if err := q.CreateTransaction(ctx, tx); err != nil {
+		return err
+	}
+
+	// The batch hold guarantees the funds, so the item settles in this
+	// transaction instead of going through the transfer task.
+	if err := completeTransfer(ctx, q, tx); err != nil {
+		return err
+	}
+
+	item.Status = domain.PayoutBatchItemStatusCompleted
+	item.TransactionID = &tx.ID
+	return nil
+}
+
+func publishPayoutBatchEvent(ctx context.Context, q *repository.Queries, topic string, batch *domain.PayoutBatch) error {
+	return publishEvent(ctx, q, topic, tasks.PayoutBatchEventPayload{
+		BatchID:        batch.ID,
+		UserID:         batch.UserID,
+		WalletID:       batch.WalletID,
+		TotalAmount:    batch.TotalAmount,
+		Currency:       batch.Currency,
+		ItemCount:      batch.ItemCount,
+		SucceededCount: batch.SucceededCount,
+		FailedCount:    batch.FailedCount,
+		Status:         string(batch.Status),
+	})
+}
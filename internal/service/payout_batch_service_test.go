@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+// payoutBatchStore adds payout batches and their items to ledgerStore.
+type payoutBatchStore struct {
+	*ledgerStore
+	batches []*domain.PayoutBatch
+	items   []*domain.PayoutBatchItem
+}
+
+func (s *payoutBatchStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+func (s *payoutBatchStore) CreatePayoutBatch(ctx context.Context, batch *domain.PayoutBatch) error {
+	batch.ID = int64(len(s.batches) + 1)
+	copied := *batch
+	s.batches = append(s.batches, &copied)
+	return nil
+}
+
+func (s *payoutBatchStore) GetPayoutBatchByID(ctx context.Context, id int64) (*domain.PayoutBatch, error) {
+	copied := *s.batches[id-1]
+	return &copied, nil
+}
+
+func (s *payoutBatchStore) GetPayoutBatchForUpdate(ctx context.Context, id int64) (*domain.PayoutBatch, error) {
+	return s.GetPayoutBatchByID(ctx, id)
+}
+
+func (s *payoutBatchStore) UpdatePayoutBatch(ctx context.Context, batch *domain.PayoutBatch) error {
+	copied := *batch
+	s.batches[batch.ID-1] = &copied
+	return nil
+}
+
+func (s *payoutBatchStore) CreatePayoutBatchItem(ctx context.Context, item *domain.PayoutBatchItem) error {
+	item.ID = int64(len(s.items) + 1)
+	copied := *item
+	s.items = append(s.items, &copied)
+	return nil
+}
+
+func (s *payoutBatchStore) GetPayoutBatchItemForUpdate(ctx context.Context, id int64) (*domain.PayoutBatchItem, error) {
+	copied := *s.items[id-1]
+	return &copied, nil
+}
+
+func (s *payoutBatchStore) ListPayoutBatchItems(ctx context.Context, batchID int64) ([]*domain.PayoutBatchItem, error) {
+	var items []*domain.PayoutBatchItem
+	for _, item := range s.items {
+		if item.BatchID == batchID {
+			copied := *item
+			items = append(items, &copied)
+		}
+	}
+
+	return items, nil
+}
+
+func (s *payoutBatchStore) UpdatePayoutBatchItem(ctx context.Context, item *domain.PayoutBatchItem) error {
+	copied := *item
+	s.items[item.ID-1] = &copied
+	return nil
+}
+
+func newPayoutBatchStore() *payoutBatchStore {
+	store := &payoutBatchStore{ledgerStore: newLedgerStore()}
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	store.addUser(3, "0")
+	store.addUser(4, "0")
+	return store
+}
+
+func payoutRow(line int, recipient int64, amount, reference string) domain.PayoutBatchRow {
+	return domain.PayoutBatchRow{LineNumber: line, RecipientUserID: recipient, Amount: dec(amount), Reference: reference}
+}
+
+func TestPayoutBatchPartialFailure(t *testing.T) {
+	store := newPayoutBatchStore()
+	svc := NewPayoutBatchService(store)
+	ctx := context.Background()
+
+	batch, err := svc.CreatePayoutBatch(ctx, 1, []domain.PayoutBatchRow{
+		payoutRow(2, 2, "10.00", "inv-1"),
+		payoutRow(3, 3, "20.00", "inv-2"),
+		payoutRow(4, 4, "30.00", "inv-3"),
+	})
+	if err != nil {
+		t.Fatalf("CreatePayoutBatch() error = %v", err)
+	}
+
+	store.assertBalance(t, 1, "100.00", "60.00")
+
+	// After the batch was accepted user 3 lost their wallet and user 4's
+	// changed currency, so only user 2 can still be paid.
+	store.wallets[walletID(3)].UserID = 0
+	store.wallets[walletID(4)].Currency = "EUR"
+
+	for _, item := range batch.Items {
+		if err := svc.ProcessPayoutBatchItem(ctx, item.ID); err != nil {
+			t.Fatalf("ProcessPayoutBatchItem(%d) error = %v", item.ID, err)
+		}
+	}
+
+	got, err := svc.GetPayoutBatch(ctx, 1, batch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status != domain.PayoutBatchStatusCompleted || got.SucceededCount != 1 || got.FailedCount != 2 || !got.ReservedAmount.IsZero() {
+		t.Errorf("batch = %+v, want COMPLETED with 1 paid, 2 failed and nothing reserved", got)
+	}
+
+	want := []struct {
+		status domain.PayoutBatchItemStatus
+		reason string
+	}{
+		{domain.PayoutBatchItemStatusCompleted, ""},
+		{domain.PayoutBatchItemStatusFailed, "recipient not found"},
+		{domain.PayoutBatchItemStatusFailed, ErrCurrencyMismatch.Error()},
+	}
+	for i, item := range got.Items {
+		if item.Status != want[i].status || item.FailureReason != want[i].reason {
+			t.Errorf("item %d = %s %q, want %s %q", item.ID, item.Status, item.FailureReason, want[i].status, want[i].reason)
+		}
+	}
+
+	if got.Items[0].TransactionID == nil || got.Items[1].TransactionID != nil {
+		t.Error("only the paid item should have a transaction")
+	}
+
+	// Failed items release their share of the hold; only the paid one is
+	// debited.
+	store.assertBalance(t, 1, "90.00", "0")
+	store.assertBalance(t, 2, "10.00", "0")
+	store.assertBalance(t, 3, "0", "0")
+	store.assertBalance(t, 4, "0", "0")
+
+	// Redelivered tasks do not pay an item twice.
+	for _, item := range batch.Items {
+		if err := svc.ProcessPayoutBatchItem(ctx, item.ID); err != nil {
+			t.Fatalf("second ProcessPayoutBatchItem(%d) error = %v", item.ID, err)
+		}
+	}
+
+	store.assertBalance(t, 1, "90.00", "0")
+	store.assertBalance(t, 2, "10.00", "0")
+
+	if got, _ := svc.GetPayoutBatch(ctx, 1, batch.ID); got.SucceededCount != 1 || got.FailedCount != 2 {
+		t.Errorf("batch counts after redelivery = %d/%d, want 1/2", got.SucceededCount, got.FailedCount)
+	}
+}
+
+func TestPayoutBatchStaysOpenUntilEveryItemSettles(t *testing.T) {
+	store := newPayoutBatchStore()
+	svc := NewPayoutBatchService(store)
+	ctx := context.Background()
+
+	batch, err := svc.CreatePayoutBatch(ctx, 1, []domain.PayoutBatchRow{
+		payoutRow(2, 2, "10.00", "inv-1"),
+		payoutRow(3, 3, "20.00", "inv-2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.ProcessPayoutBatchItem(ctx, batch.Items[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := svc.GetPayoutBatch(ctx, 1, batch.ID)
+	if got.Status != domain.PayoutBatchStatusProcessing || !got.ReservedAmount.Equal(dec("20.00")) {
+		t.Errorf("batch = %+v, want PROCESSING with 20.00 still reserved", got)
+	}
+
+	store.assertBalance(t, 1, "90.00", "20.00")
+}
+
+func TestCreatePayoutBatchRejectsInvalidRows(t *testing.T) {
+	store := newPayoutBatchStore()
+	svc := NewPayoutBatchService(store)
+
+	_, err := svc.CreatePayoutBatch(context.Background(), 1, []domain.PayoutBatchRow{
+		payoutRow(2, 2, "10.00", "inv-1"),
+		payoutRow(3, 9, "10.00", "inv-2"),
+		payoutRow(4, 3, "1.005", "inv-3"),
+		payoutRow(5, 4, "10.00", "inv-1"),
+		payoutRow(6, 1, "10.00", "inv-4"),
+	})
+	if !errors.Is(err, ErrInvalidPayoutBatch) {
+		t.Fatalf("CreatePayoutBatch() error = %v, want %v", err, ErrInvalidPayoutBatch)
+	}
+
+	// Every row at fault is reported, and nothing is held or queued.
+	for _, line := range []string{"line 3", "line 4", "line 5", "line 6"} {
+		if !strings.Contains(err.Error(), line) {
+			t.Errorf("error %q does not report %s", err, line)
+		}
+	}
+
+	if strings.Contains(err.Error(), "line 2:") {
+		t.Errorf("error %q reports the valid line 2", err)
+	}
+
+	if len(store.batches) != 0 || len(store.items) != 0 {
+		t.Errorf("%d batches and %d items stored, want none", len(store.batches), len(store.items))
+	}
+
+	store.assertBalance(t, 1, "100.00", "0")
+}
+
+func TestCreatePayoutBatchNeedsFundsForTheTotal(t *testing.T) {
+	store := newPayoutBatchStore()
+	svc := NewPayoutBatchService(store)
+
+	_, err := svc.CreatePayoutBatch(context.Background(), 1, []domain.PayoutBatchRow{
+		payoutRow(2, 2, "60.00", "inv-1"),
+		payoutRow(3, 3, "40.01", "inv-2"),
+	})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("CreatePayoutBatch() error = %v, want %v", err, ErrInsufficientFunds)
+	}
+
+	store.assertBalance(t, 1, "100.00", "0")
+}
@@ -0,0 +1,843 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrDataExportNotFound   = errors.New("data export not found")
+	ErrDataExportNotReady   = errors.New("data export is not ready")
+	ErrDataExportExpired    = errors.New("data export has expired")
+	ErrDataExportInProgress = errors.New("a data export is already being prepared")
+	ErrInvalidExportFormat  = errors.New("format must be JSON or ZIP")
+	ErrErasureBlocked       = errors.New("account still holds funds or has payments in flight")
+)
+
+const (
+	DefaultDataExportTTL  = 7 * 24 * time.Hour
+	dataExportBatchSize   = 100
+	maxDataExportErrorLen = 1024
+)
+
+type privacyService struct {
+	store     repository.Store
+	exportDir string
+	exportTTL time.Duration
+}
+
+// NewPrivacyService returns the service behind data exports and erasure.
+// Export archives are written to exportDir, which the API and the worker must
+// share, and deleted exportTTL after they are built; zero uses
+// DefaultDataExportTTL.
+func NewPrivacyService(store repository.Store, exportDir string, exportTTL time.Duration) domain.PrivacyService {
+	if exportTTL == 0 {
+		exportTTL = DefaultDataExportTTL
+	}
+
+	return &privacyService{
+		store:     store,
+		exportDir: exportDir,
+		exportTTL: exportTTL,
+	}
+}
+
+// RequestDataExport queues an archive of the user's data for the worker to
+// build. Only one export can be pending at a time.
+func (s *privacyService) RequestDataExport(ctx context.Context, userID int64, format domain.DataExportFormat) (*domain.DataExport, error) {
+	if format != domain.DataExportFormatJSON && format != domain.DataExportFormatZIP {
+		return nil, ErrInvalidExportFormat
+	}
+
+	export := &domain.DataExport{
+		UserID: userID,
+		Format: format,
+		Status: domain.DataExportStatusPending,
+	}
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		exports, err := q.ListDataExportsByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		for _, existing := range exports {
+			if existing.Status == domain.DataExportStatusPending {
+				return ErrDataExportInProgress
+			}
+		}
+
+		if err := q.CreateDataExport(ctx, export); err != nil {
+			return err
+		}
+
+		return publishEvent(ctx, q, tasks.TaskTypeBuildDataExport, tasks.BuildDataExportPayload{DataExportID: export.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetDataExportByID(ctx, export.ID)
+}
+
+func (s *privacyService) ListDataExports(ctx context.Context, userID int64) ([]*domain.DataExport, error) {
+	return s.store.ListDataExportsByUser(ctx, userID)
+}
+
+// GetDataExport returns an export of the user's. Exports of other users are
+// reported as not found.
+func (s *privacyService) GetDataExport(ctx context.Context, userID, id int64) (*domain.DataExport, error) {
+	export, err := s.store.GetDataExportByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if export == nil || export.UserID != userID {
+		return nil, ErrDataExportNotFound
+	}
+
+	return export, nil
+}
+
+// OpenDataExport opens the archive of a READY export for download. The caller
+// must close it.
+func (s *privacyService) OpenDataExport(ctx context.Context, userID, id int64) (*domain.DataExport, io.ReadCloser, error) {
+	export, err := s.GetDataExport(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch export.Status {
+	case domain.DataExportStatusReady:
+	case domain.DataExportStatusExpired:
+		return nil, nil, ErrDataExportExpired
+	default:
+		return nil, nil, ErrDataExportNotReady
+	}
+
+	file, err := os.Open(filepath.Join(s.exportDir, export.Path))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return export, file, nil
+}
+
+// BuildDataExport writes the archive of a PENDING export and marks it READY.
+// Exports that are gone or already built are skipped, so redelivered tasks
+// are harmless. On the final attempt a failure marks the export FAILED.
+func (s *privacyService) BuildDataExport(ctx context.Context, id int64, finalAttempt bool) error {
+	export, err := s.store.GetDataExportByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if export == nil || export.Status != domain.DataExportStatusPending {
+		return nil
+	}
+
+	if err := s.buildDataExport(ctx, export); err != nil {
+		if finalAttempt {
+			export.Status = domain.DataExportStatusFailed
+			export.Error = truncate(err.Error(), maxDataExportErrorLen)
+			if updateErr := s.store.UpdateDataExport(ctx, export); updateErr != nil {
+				log.Printf("Error marking data export %d failed: %v", id, updateErr)
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *privacyService) buildDataExport(ctx context.Context, export *domain.DataExport) error {
+	archive, err := s.collectUserData(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	path, size, err := s.writeArchive(export, archive)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(s.exportTTL)
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		locked, err := q.GetDataExportForUpdate(ctx, export.ID)
+		if err != nil {
+			return err
+		}
+
+		// The user may have been erased, or another run finished first.
+		if locked == nil || locked.Status != domain.DataExportStatusPending {
+			return errDataExportSuperseded
+		}
+
+		locked.Status = domain.DataExportStatusReady
+		locked.Path = path
+		locked.SizeBytes = size
+		locked.Error = ""
+		locked.CompletedAt = &now
+		locked.ExpiresAt = &expiresAt
+
+		return q.UpdateDataExport(ctx, locked)
+	})
+	if err != nil {
+		s.removeExportFile(path)
+
+		if errors.Is(err, errDataExportSuperseded) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+var errDataExportSuperseded = errors.New("data export superseded")
+
+// dataExportArchive is everything stored about a user. A JSON export is this
+// document; a ZIP export has one file per section.
+type dataExportArchive struct {
+	GeneratedAt        time.Time                   `json:"generated_at"`
+	Profile            *domain.User                `json:"profile"`
+	Wallets            []*domain.Wallet            `json:"wallets"`
+	Transactions       []*domain.Transaction       `json:"transactions"`
+	Holds              []*domain.Hold              `json:"holds"`
+	PaymentRequests    []*domain.PaymentRequest    `json:"payment_requests"`
+	ScheduledTransfers []*domain.ScheduledTransfer `json:"scheduled_transfers"`
+	WebhookEndpoints   []*domain.WebhookEndpoint   `json:"webhook_endpoints"`
+	LoginEvents        []*domain.LoginEvent        `json:"login_events"`
+	Devices            []*domain.UserDevice        `json:"devices"`
+	EmailChanges       []*domain.EmailChange       `json:"email_changes"`
+	APIKeys            []*domain.APIKey            `json:"api_keys"`
+	Merchant           *domain.Merchant            `json:"merchant"`
+	Deposits           []*domain.Deposit           `json:"deposits"`
+	BankBeneficiaries  []*domain.BankBeneficiary   `json:"bank_beneficiaries"`
+	Withdrawals        []*domain.Withdrawal        `json:"withdrawals"`
+	PayoutBatches      []*domain.PayoutBatch       `json:"payout_batches"`
+	SplitPayments      []*domain.SplitPayment      `json:"split_payments"`
+	Escrows            []*domain.Escrow            `json:"escrows"`
+	Disputes           []*domain.Dispute           `json:"disputes"`
+	VoucherRedemptions []*domain.VoucherRedemption `json:"voucher_redemptions"`
+}
+
+type archiveSection struct {
+	name string
+	data any
+}
+
+func (a *dataExportArchive) sections() []archiveSection {
+	return []archiveSection{
+		{"profile.json", a.Profile},
+		{"wallets.json", a.Wallets},
+		{"transactions.json", a.Transactions},
+		{"holds.json", a.Holds},
+		{"payment_requests.json", a.PaymentRequests},
+		{"scheduled_transfers.json", a.ScheduledTransfers},
+		{"webhook_endpoints.json", a.WebhookEndpoints},
+		{"login_events.json", a.LoginEvents},
+		{"devices.json", a.Devices},
+		{"email_changes.json", a.EmailChanges},
+		{"api_keys.json", a.APIKeys},
+		{"merchant.json", a.Merchant},
+		{"deposits.json", a.Deposits},
+		{"bank_beneficiaries.json", a.BankBeneficiaries},
+		{"withdrawals.json", a.Withdrawals},
+		{"payout_batches.json", a.PayoutBatches},
+		{"split_payments.json", a.SplitPayments},
+		{"escrows.json", a.Escrows},
+		{"disputes.json", a.Disputes},
+		{"voucher_redemptions.json", a.VoucherRedemptions},
+	}
+}
+
+func (s *privacyService) collectUserData(ctx context.Context, userID int64) (*dataExportArchive, error) {
+	user, err := s.store.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	archive := &dataExportArchive{
+		GeneratedAt:  time.Now().UTC(),
+		Profile:      user,
+		Wallets:      []*domain.Wallet{},
+		Transactions: []*domain.Transaction{},
+		Holds:        []*domain.Hold{},
+	}
+
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet != nil {
+		archive.Wallets = append(archive.Wallets, wallet)
+
+		if archive.Transactions, err = s.store.ListTransactionsByWallet(ctx, wallet.ID); err != nil {
+			return nil, err
+		}
+
+		if archive.Holds, err = s.store.ListHoldsByWallet(ctx, wallet.ID); err != nil {
+			return nil, err
+		}
+
+		if archive.SplitPayments, err = s.store.ListSplitPaymentsBySender(ctx, wallet.ID); err != nil {
+			return nil, err
+		}
+
+		for _, payment := range archive.SplitPayments {
+			if payment.Legs, err = s.store.ListTransactionsBySplitPayment(ctx, payment.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		if archive.Escrows, err = s.store.ListEscrowsByParty(ctx, wallet.ID, userID); err != nil {
+			return nil, err
+		}
+
+		if archive.Disputes, err = s.store.ListDisputesByWallet(ctx, wallet.ID); err != nil {
+			return nil, err
+		}
+
+		// Only the evidence the user submitted is theirs to export.
+		for _, dispute := range archive.Disputes {
+			evidence, err := s.store.ListDisputeEvidence(ctx, dispute.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range evidence {
+				if item.UserID == userID {
+					dispute.Evidence = append(dispute.Evidence, item)
+				}
+			}
+		}
+	}
+
+	if archive.PaymentRequests, err = s.store.ListPaymentRequestsByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.ScheduledTransfers, err = s.store.ListScheduledTransfersBySender(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.WebhookEndpoints, err = s.store.ListWebhookEndpointsByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.VoucherRedemptions, err = s.store.ListVoucherRedemptionsByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// Signing secrets are credentials rather than personal data.
+	for _, endpoint := range archive.WebhookEndpoints {
+		endpoint.Secret = ""
+	}
+
+	if archive.LoginEvents, err = s.store.ListLoginEventsByUser(ctx, userID, math.MaxInt32); err != nil {
+		return nil, err
+	}
+
+	if archive.Devices, err = s.store.ListUserDevices(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.EmailChanges, err = s.store.ListEmailChangesByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.APIKeys, err = s.store.ListAPIKeysByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	for _, key := range archive.APIKeys {
+		key.SigningSecret = ""
+	}
+
+	if archive.Merchant, err = s.store.GetMerchantByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.Deposits, err = s.store.ListDepositsByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.BankBeneficiaries, err = s.store.ListBankBeneficiariesByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.Withdrawals, err = s.store.ListWithdrawalsByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if archive.PayoutBatches, err = s.store.ListPayoutBatchesByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	for _, batch := range archive.PayoutBatches {
+		if batch.Items, err = s.store.ListPayoutBatchItems(ctx, batch.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	archive.Transactions = emptyIfNil(archive.Transactions)
+	archive.Holds = emptyIfNil(archive.Holds)
+	archive.PaymentRequests = emptyIfNil(archive.PaymentRequests)
+	archive.ScheduledTransfers = emptyIfNil(archive.ScheduledTransfers)
+	archive.WebhookEndpoints = emptyIfNil(archive.WebhookEndpoints)
+	archive.LoginEvents = emptyIfNil(archive.LoginEvents)
+	archive.Devices = emptyIfNil(archive.Devices)
+	archive.EmailChanges = emptyIfNil(archive.EmailChanges)
+	archive.APIKeys = emptyIfNil(archive.APIKeys)
+	archive.Deposits = emptyIfNil(archive.Deposits)
+	archive.BankBeneficiaries = emptyIfNil(archive.BankBeneficiaries)
+	archive.Withdrawals = emptyIfNil(archive.Withdrawals)
+	archive.PayoutBatches = emptyIfNil(archive.PayoutBatches)
+	archive.SplitPayments = emptyIfNil(archive.SplitPayments)
+	archive.Escrows = emptyIfNil(archive.Escrows)
+	archive.Disputes = emptyIfNil(archive.Disputes)
+	archive.VoucherRedemptions = emptyIfNil(archive.VoucherRedemptions)
+
+	return archive, nil
+}
+
+func emptyIfNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// writeArchive writes archive to a new file in the export directory and
+// returns its name and size. The name carries a random part so that it cannot
+// be guessed from the export ID.
+func (s *privacyService) writeArchive(export *domain.DataExport, archive *dataExportArchive) (string, int64, error) {
+	if err := os.MkdirAll(s.exportDir, 0o700); err != nil {
+		return "", 0, err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", 0, err
+	}
+
+	ext := ".json"
+	if export.Format == domain.DataExportFormatZIP {
+		ext = ".zip"
+	}
+	path := fmt.Sprintf("export-%d-%s%s", export.ID, hex.EncodeToString(suffix), ext)
+
+	file, err := os.OpenFile(filepath.Join(s.exportDir, path), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if export.Format == domain.DataExportFormatZIP {
+		err = writeZIPArchive(file, archive)
+	} else {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(archive)
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		s.removeExportFile(path)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(filepath.Join(s.exportDir, path))
+	if err != nil {
+		s.removeExportFile(path)
+		return "", 0, err
+	}
+
+	return path, info.Size(), nil
+}
+
+func writeZIPArchive(w io.Writer, archive *dataExportArchive) error {
+	zw := zip.NewWriter(w)
+
+	for _, section := range archive.sections() {
+		entry, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     section.name,
+			Method:   zip.Deflate,
+			Modified: archive.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (s *privacyService) removeExportFile(path string) {
+	if path == "" {
+		return
+	}
+
+	err := os.Remove(filepath.Join(s.exportDir, path))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Error removing data export file %s: %v", path, err)
+	}
+}
+
+// ExpireDataExports deletes the files of READY exports past their expiry and
+// marks them EXPIRED.
+func (s *privacyService) ExpireDataExports(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListExpiredDataExportIDs(ctx, now, dataExportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		var path string
+
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			export, err := q.GetDataExportForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if export == nil || export.Status != domain.DataExportStatusReady || export.ExpiresAt == nil || export.ExpiresAt.After(now) {
+				return nil
+			}
+
+			path = export.Path
+			export.Status = domain.DataExportStatusExpired
+			export.Path = ""
+
+			return q.UpdateDataExport(ctx, export)
+		})
+		if err != nil {
+			log.Printf("Error expiring data export %d: %v", id, err)
+			continue
+		}
+
+		if path != "" {
+			s.removeExportFile(path)
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// EraseUser pseudonymises the user's account. Their name, email, phone,
+// credentials, login history and other personal data are removed, while the
+// user row, wallet and ledger entries are kept as financial records. The
+// wallet must be empty with nothing in flight. Pending payment requests and
+// active schedules are cancelled, webhooks disabled and bank beneficiaries
+// erased. A wrong password counts as a failed login.
+func (s *privacyService) EraseUser(ctx context.Context, userID int64, password string, client domain.ClientInfo) error {
+	user, err := s.store.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user == nil || user.ErasedAt != nil {
+		return ErrUserNotFound
+	}
+
+	now := time.Now().UTC()
+	accountSubject := loginSubject(user.Email)
+
+	if err := checkLoginLock(ctx, s.store, now, accountSubject, client.IP); err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			return recordLoginFailure(ctx, q, now, user, accountSubject, client)
+		})
+		if err != nil {
+			return err
+		}
+
+		return ErrInvalidCredentials
+	}
+
+	exports, err := s.store.ListDataExportsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	erasedAt := now.Truncate(time.Second)
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		if err := checkErasable(ctx, q, userID); err != nil {
+			return err
+		}
+
+		if err := cancelUserActivity(ctx, q, userID); err != nil {
+			return err
+		}
+
+		if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := q.DeleteUserTokens(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := q.DeleteEmailChanges(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := q.DeleteUserLoginData(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := q.DeleteDataExports(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := q.EraseBankBeneficiaries(ctx, userID, erasedAt); err != nil {
+			return err
+		}
+
+		if err := q.PseudonymiseUser(ctx, userID, fmt.Sprintf("erased-%d@erased.invalid", userID), erasedAt); err != nil {
+			return err
+		}
+
+		return publishEvent(ctx, q, tasks.TopicUserErased, tasks.UserErasedEventPayload{
+			UserID:   userID,
+			ErasedAt: erasedAt,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		s.removeExportFile(export.Path)
+	}
+
+	return nil
+}
+
+// checkErasable locks the user's wallet and returns ErrErasureBlocked if it
+// holds funds or takes part in a pending transfer, an authorized hold or an
+// open escrow.
+func checkErasable(ctx context.Context, q *repository.Queries, userID int64) error {
+	wallet, err := q.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if wallet == nil {
+		return nil
+	}
+
+	wallet, err = q.GetWalletForUpdate(ctx, wallet.ID)
+	if err != nil {
+		return err
+	}
+
+	if !wallet.Balance.IsZero() || !wallet.HeldBalance.IsZero() {
+		return ErrErasureBlocked
+	}
+
+	transactions, err := q.ListTransactionsByWallet(ctx, wallet.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, tx := range transactions {
+		if tx.Status == domain.TransactionStatusPending {
+			return ErrErasureBlocked
+		}
+	}
+
+	holds, err := q.ListHoldsByWallet(ctx, wallet.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, hold := range holds {
+		if hold.Status == domain.HoldStatusAuthorized {
+			return ErrErasureBlocked
+		}
+	}
+
+	escrows, err := q.ListEscrowsByParty(ctx, wallet.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, escrow := range escrows {
+		if escrow.Open() {
+			return ErrErasureBlocked
+		}
+	}
+
+	disputes, err := q.ListDisputesByWallet(ctx, wallet.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, dispute := range disputes {
+		if dispute.Open() {
+			return ErrErasureBlocked
+		}
+	}
+
+	return nil
+}
+
+// cancelUserActivity stops everything that could move money or send data
+// on the user's behalf after erasure.
+func cancelUserActivity(ctx context.Context, q *repository.Queries, userID int64) error {
+	requests, err := q.ListPaymentRequestsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, pr := range requests {
+		if pr.Status != domain.PaymentRequestStatusPending {
+			continue
+		}
+
+		pr, err := q.GetPaymentRequestForUpdate(ctx, pr.ID)
+		if err != nil {
+			return err
+		}
+
+		if pr == nil || pr.Status != domain.PaymentRequestStatusPending {
+			continue
+		}
+
+		pr.Status = domain.PaymentRequestStatusCancelled
+		if err := q.UpdatePaymentRequest(ctx, pr); err != nil {
+			return err
+		}
+
+		if err := publishPaymentRequestEvent(ctx, q, tasks.TopicPaymentRequestCancelled, pr); err != nil {
+			return err
+		}
+	}
+
+	schedules, err := q.ListScheduledTransfersBySender(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range schedules {
+		st, err := q.GetScheduledTransferForUpdate(ctx, st.ID)
+		if err != nil {
+			return err
+		}
+
+		if st == nil || (st.Status != domain.ScheduledTransferStatusActive && st.Status != domain.ScheduledTransferStatusPaused) {
+			continue
+		}
+
+		st.Status = domain.ScheduledTransferStatusCancelled
+		st.NextRunAt = nil
+		if err := q.UpdateScheduledTransfer(ctx, st); err != nil {
+			return err
+		}
+	}
+
+	endpoints, err := q.ListWebhookEndpointsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if endpoint.Status != domain.WebhookEndpointStatusActive {
+			continue
+		}
+
+		if err := q.UpdateWebhookEndpointStatus(ctx, endpoint.ID, domain.WebhookEndpointStatusDisabled); err != nil {
+			return err
+		}
+	}
+
+	if err := q.RevokeUserAPIKeys(ctx, userID, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return disableMerchant(ctx, q, userID)
+}
+
+// disableMerchant disables the user's merchant, if any, and cancels its open
+// checkout sessions.
+func disableMerchant(ctx context.Context, q *repository.Queries, userID int64) error {
+	merchant, err := q.GetMerchantByUserID(ctx, userID)
+	if err != nil || merchant == nil {
+		return err
+	}
+
+	if err := q.UpdateMerchantStatus(ctx, merchant.ID, domain.MerchantStatusDisabled); err != nil {
+		return err
+	}
+
+	sessions, err := q.ListCheckoutSessionsByMerchant(ctx, merchant.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.Status != domain.CheckoutSessionStatusOpen {
+			continue
+		}
+
+		session, err := q.GetCheckoutSessionForUpdate(ctx, session.ID)
+		if err != nil {
+			return err
+		}
+
+		if session == nil || session.Status != domain.CheckoutSessionStatusOpen {
+			continue
+		}
+
+		session.Status = domain.CheckoutSessionStatusCancelled
+		if err := q.UpdateCheckoutSession(ctx, session); err != nil {
+			return err
+		}
+
+		if err := publishCheckoutSessionEvent(ctx, q, tasks.TopicCheckoutSessionCancelled, merchant, session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
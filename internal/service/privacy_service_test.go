@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// erasureStore adds the escrows, disputes and personal data erasure touches
+// to ledgerStore. The personal data tables are only counted.
+type erasureStore struct {
+	*ledgerStore
+	escrows    []*domain.Escrow
+	disputes   []*domain.Dispute
+	endpoints  []*domain.WebhookEndpoint
+	erasedData int
+	failures   int
+}
+
+func newErasureStore(t *testing.T) *erasureStore {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &erasureStore{ledgerStore: newLedgerStore()}
+	store.addUser(1, "0")
+	store.addUser(2, "50.00")
+	store.users[1].Password = string(hash)
+
+	return store
+}
+
+func (s *erasureStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+func (s *erasureStore) GetLoginThrottle(ctx context.Context, scope domain.LoginThrottleScope, subject string) (*domain.LoginThrottle, error) {
+	return nil, nil
+}
+
+func (s *erasureStore) GetLoginThrottleForUpdate(ctx context.Context, scope domain.LoginThrottleScope, subject string) (*domain.LoginThrottle, error) {
+	return nil, nil
+}
+
+func (s *erasureStore) UpsertLoginThrottle(ctx context.Context, throttle *domain.LoginThrottle) error {
+	return nil
+}
+
+func (s *erasureStore) CreateLoginEvent(ctx context.Context, event *domain.LoginEvent) error {
+	s.failures++
+	return nil
+}
+
+func (s *erasureStore) ListDataExportsByUser(ctx context.Context, userID int64) ([]*domain.DataExport, error) {
+	return nil, nil
+}
+
+func (s *erasureStore) ListTransactionsByWallet(ctx context.Context, walletID int64) ([]*domain.Transaction, error) {
+	var txs []*domain.Transaction
+	for _, tx := range s.transactions {
+		if tx.SenderWalletID == walletID || tx.ReceiverWalletID == walletID {
+			txs = append(txs, tx)
+		}
+	}
+
+	return txs, nil
+}
+
+func (s *erasureStore) ListHoldsByWallet(ctx context.Context, walletID int64) ([]*domain.Hold, error) {
+	var holds []*domain.Hold
+	for _, hold := range s.holds {
+		if hold.WalletID == walletID || hold.PayeeWalletID == walletID {
+			holds = append(holds, hold)
+		}
+	}
+
+	return holds, nil
+}
+
+func (s *erasureStore) ListEscrowsByParty(ctx context.Context, walletID, userID int64) ([]*domain.Escrow, error) {
+	var escrows []*domain.Escrow
+	for _, escrow := range s.escrows {
+		if escrow.PayerWalletID == walletID || escrow.PayeeWalletID == walletID {
+			escrows = append(escrows, escrow)
+		}
+	}
+
+	return escrows, nil
+}
+
+func (s *erasureStore) ListDisputesByWallet(ctx context.Context, walletID int64) ([]*domain.Dispute, error) {
+	var disputes []*domain.Dispute
+	for _, dispute := range s.disputes {
+		if dispute.PayerWalletID == walletID || dispute.MerchantWalletID == walletID {
+			disputes = append(disputes, dispute)
+		}
+	}
+
+	return disputes, nil
+}
+
+func (s *erasureStore) ListPaymentRequestsByUser(ctx context.Context, userID int64) ([]*domain.PaymentRequest, error) {
+	var requests []*domain.PaymentRequest
+	for _, pr := range s.paymentRequests {
+		if pr.RequesterUserID == userID || pr.PayerUserID == userID {
+			requests = append(requests, pr)
+		}
+	}
+
+	return requests, nil
+}
+
+func (s *erasureStore) ListScheduledTransfersBySender(ctx context.Context, senderUserID int64) ([]*domain.ScheduledTransfer, error) {
+	var schedules []*domain.ScheduledTransfer
+	for _, st := range s.scheduledTransfers {
+		if st.SenderUserID == senderUserID {
+			schedules = append(schedules, st)
+		}
+	}
+
+	return schedules, nil
+}
+
+func (s *erasureStore) ListWebhookEndpointsByUser(ctx context.Context, userID int64) ([]*domain.WebhookEndpoint, error) {
+	return s.endpoints, nil
+}
+
+func (s *erasureStore) UpdateWebhookEndpointStatus(ctx context.Context, id int64, status domain.WebhookEndpointStatus) error {
+	for _, endpoint := range s.endpoints {
+		if endpoint.ID == id {
+			endpoint.Status = status
+		}
+	}
+
+	return nil
+}
+
+func (s *erasureStore) RevokeUserAPIKeys(ctx context.Context, userID int64, revokedAt time.Time) error {
+	s.erasedData++
+	return nil
+}
+
+func (s *erasureStore) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	s.erasedData++
+	return nil
+}
+
+func (s *erasureStore) DeleteUserTokens(ctx context.Context, userID int64) error {
+	s.erasedData++
+	return nil
+}
+
+func (s *erasureStore) DeleteEmailChanges(ctx context.Context, userID int64) error {
+	s.erasedData++
+	return nil
+}
+
+func (s *erasureStore) DeleteUserLoginData(ctx context.Context, userID int64) error {
+	s.erasedData++
+	return nil
+}
+
+func (s *erasureStore) DeleteDataExports(ctx context.Context, userID int64) error {
+	s.erasedData++
+	return nil
+}
+
+func (s *erasureStore) EraseBankBeneficiaries(ctx context.Context, userID int64, erasedAt time.Time) error {
+	s.erasedData++
+	return nil
+}
+
+func (s *erasureStore) PseudonymiseUser(ctx context.Context, userID int64, email string, erasedAt time.Time) error {
+	user := s.users[userID]
+	user.Name = ""
+	user.Email = email
+	user.Password = ""
+	user.ErasedAt = &erasedAt
+	return nil
+}
+
+func eraseUser(store *erasureStore, password string) error {
+	svc := NewPrivacyService(store, "", 0)
+	return svc.EraseUser(context.Background(), 1, password, domain.ClientInfo{IP: "203.0.113.1"})
+}
+
+func TestEraseUserBlocked(t *testing.T) {
+	amount := decimal.RequireFromString("10.00")
+
+	tests := []struct {
+		name  string
+		setup func(s *erasureStore)
+	}{
+		{name: "balance", setup: func(s *erasureStore) {
+			s.wallets[walletID(1)].Balance = decimal.RequireFromString("0.01")
+		}},
+		{name: "held funds", setup: func(s *erasureStore) {
+			s.wallets[walletID(1)].HeldBalance = amount
+		}},
+		{name: "incoming pending transfer", setup: func(s *erasureStore) {
+			s.transactions[1] = &domain.Transaction{ID: 1, SenderWalletID: walletID(2), ReceiverWalletID: walletID(1), Amount: amount, Status: domain.TransactionStatusPending}
+		}},
+		{name: "authorized hold as payee", setup: func(s *erasureStore) {
+			s.holds[1] = &domain.Hold{ID: 1, WalletID: walletID(2), PayeeWalletID: walletID(1), Amount: amount, Status: domain.HoldStatusAuthorized}
+		}},
+		{name: "funded escrow", setup: func(s *erasureStore) {
+			s.escrows = append(s.escrows, &domain.Escrow{ID: 1, PayerWalletID: walletID(2), PayeeWalletID: walletID(1), Amount: amount, Status: domain.EscrowStatusFunded})
+		}},
+		{name: "disputed escrow", setup: func(s *erasureStore) {
+			s.escrows = append(s.escrows, &domain.Escrow{ID: 1, PayerWalletID: walletID(1), PayeeWalletID: walletID(2), Amount: amount, Status: domain.EscrowStatusDisputed})
+		}},
+		{name: "open dispute", setup: func(s *erasureStore) {
+			s.disputes = append(s.disputes, &domain.Dispute{ID: 1, PayerWalletID: walletID(1), MerchantWalletID: walletID(2), Amount: amount, Status: domain.DisputeStatusOpen})
+		}},
+		{name: "dispute under review", setup: func(s *erasureStore) {
+			s.disputes = append(s.disputes, &domain.Dispute{ID: 1, PayerWalletID: walletID(2), MerchantWalletID: walletID(1), Amount: amount, Status: domain.DisputeStatusUnderReview})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newErasureStore(t)
+			tt.setup(store)
+
+			if err := eraseUser(store, testPassword); !errors.Is(err, ErrErasureBlocked) {
+				t.Fatalf("EraseUser() error = %v, want %v", err, ErrErasureBlocked)
+			}
+
+			if user := store.users[1]; user.ErasedAt != nil || user.Email != "user@example.com" || store.erasedData != 0 {
+				t.Errorf("user = %+v with %d tables erased, want untouched", user, store.erasedData)
+			}
+		})
+	}
+}
+
+func TestEraseUserIgnoresSettledActivity(t *testing.T) {
+	store := newErasureStore(t)
+	amount := decimal.RequireFromString("10.00")
+	store.transactions[1] = &domain.Transaction{ID: 1, SenderWalletID: walletID(1), ReceiverWalletID: walletID(2), Amount: amount, Status: domain.TransactionStatusCompleted}
+	store.holds[1] = &domain.Hold{ID: 1, WalletID: walletID(1), PayeeWalletID: walletID(2), Amount: amount, Status: domain.HoldStatusCaptured}
+	store.escrows = append(store.escrows, &domain.Escrow{ID: 1, PayerWalletID: walletID(1), PayeeWalletID: walletID(2), Amount: amount, Status: domain.EscrowStatusReleased})
+	store.disputes = append(store.disputes, &domain.Dispute{ID: 1, PayerWalletID: walletID(1), MerchantWalletID: walletID(2), Amount: amount, Status: domain.DisputeStatusPayerWon})
+	store.paymentRequests[1] = &domain.PaymentRequest{ID: 1, RequesterUserID: 2, PayerUserID: 1, Amount: amount, Status: domain.PaymentRequestStatusPending}
+	store.endpoints = []*domain.WebhookEndpoint{{ID: 1, UserID: 1, Status: domain.WebhookEndpointStatusActive}}
+
+	if err := eraseUser(store, testPassword); err != nil {
+		t.Fatalf("EraseUser() error = %v", err)
+	}
+
+	user := store.users[1]
+	if user.ErasedAt == nil || user.Email != "erased-1@erased.invalid" || user.Name != "" {
+		t.Errorf("user = %+v, want pseudonymised", user)
+	}
+
+	if store.paymentRequests[1].Status != domain.PaymentRequestStatusCancelled {
+		t.Errorf("payment request status = %s, want %s", store.paymentRequests[1].Status, domain.PaymentRequestStatusCancelled)
+	}
+
+	if store.endpoints[0].Status != domain.WebhookEndpointStatusDisabled {
+		t.Errorf("webhook endpoint status = %s, want %s", store.endpoints[0].Status, domain.WebhookEndpointStatusDisabled)
+	}
+
+	// The wallet and its history stay as financial records.
+	if _, ok := store.wallets[walletID(1)]; !ok || store.transactions[1] == nil {
+		t.Error("wallet or transactions were removed")
+	}
+
+	if last := store.outbox[len(store.outbox)-1]; last.Topic != tasks.TopicUserErased {
+		t.Errorf("last event = %s, want %s", last.Topic, tasks.TopicUserErased)
+	}
+
+	if err := eraseUser(store, testPassword); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("second EraseUser() error = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestEraseUserWrongPassword(t *testing.T) {
+	store := newErasureStore(t)
+
+	if err := eraseUser(store, "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("EraseUser() error = %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	if store.users[1].ErasedAt != nil {
+		t.Error("user was erased")
+	}
+
+	if store.failures != 1 {
+		t.Errorf("recorded %d failed logins, want 1", store.failures)
+	}
+}
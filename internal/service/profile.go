@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrProfileModified     = errors.New("profile was modified by another request")
+	ErrInvalidTimezone     = errors.New("timezone must be an IANA time zone name")
+	ErrSameEmail           = errors.New("new email is the current email")
+	ErrEmailChangeNotFound = errors.New("no pending email change")
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+// UpdateProfile applies update to the user's profile. When ifUnmodifiedSince
+// is set the update only goes through if the profile's updated_at still
+// matches it, otherwise ErrProfileModified is returned.
+func (s *userService) UpdateProfile(ctx context.Context, userID int64, update domain.ProfileUpdate, ifUnmodifiedSince *time.Time) (*domain.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		user.Name = strings.TrimSpace(*update.Name)
+	}
+
+	if update.Phone != nil {
+		user.Phone = *update.Phone
+	}
+
+	if update.Timezone != nil {
+		if *update.Timezone != "" {
+			if _, err := time.LoadLocation(*update.Timezone); err != nil {
+				return nil, ErrInvalidTimezone
+			}
+		}
+		user.Timezone = *update.Timezone
+	}
+
+	updated, err := s.store.UpdateUserProfile(ctx, user, ifUnmodifiedSince)
+	if err != nil {
+		return nil, err
+	}
+
+	if !updated {
+		return nil, ErrProfileModified
+	}
+
+	return s.GetProfile(ctx, userID)
+}
+
+// RequestEmailChange starts changing the user's email to newEmail, replacing
+// any change already pending. The switch happens once both addresses have
+// confirmed it with ConfirmEmailChange. The password is required so that a
+// stolen session cannot take over the account; failures count as failed
+// logins.
+func (s *userService) RequestEmailChange(ctx context.Context, userID int64, newEmail, password string, client domain.ClientInfo) (*domain.EmailChange, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	accountSubject := loginSubject(user.Email)
+
+	if err := checkLoginLock(ctx, s.store, now, accountSubject, client.IP); err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			return recordLoginFailure(ctx, q, now, user, accountSubject, client)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidCredentials
+	}
+
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, ErrSameEmail
+	}
+
+	existing, err := s.store.GetByEmail(ctx, newEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		return nil, ErrUserAlreadyExists
+	}
+
+	change := &domain.EmailChange{
+		UserID:    userID,
+		OldEmail:  user.Email,
+		NewEmail:  newEmail,
+		Status:    domain.EmailChangeStatusPending,
+		ExpiresAt: now.Add(emailChangeTTL).Truncate(time.Second),
+	}
+
+	var currentToken, newToken string
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		pending, err := q.GetPendingEmailChangeForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if pending != nil {
+			pending.Status = domain.EmailChangeStatusCancelled
+			if err := q.UpdateEmailChange(ctx, pending); err != nil {
+				return err
+			}
+		}
+
+		if err := q.CreateEmailChange(ctx, change); err != nil {
+			return err
+		}
+
+		currentToken, err = issueUserToken(ctx, q, user, domain.UserTokenPurposeEmailChangeCurrent, emailChangeTTL)
+		if err != nil {
+			return err
+		}
+
+		newToken, err = issueUserToken(ctx, q, &domain.User{ID: userID, Email: newEmail}, domain.UserTokenPurposeEmailChangeNew, emailChangeTTL)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.Send(ctx, domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Confirm your email change",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email of your wallet account to %s. To approve the change, open this link:\n\n%s\n\n"+
+			"The change also has to be confirmed from the new address. If you did not ask for this, change your password right away.\n",
+			user.Name, newEmail, s.link("/confirm-email-change", currentToken)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.Send(ctx, domain.EmailMessage{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm that this is the new email address of your wallet account by opening this link:\n\n%s\n\n"+
+			"The change also has to be approved from your current address. The link expires in 24 hours.\n",
+			user.Name, s.link("/confirm-email-change", newToken)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetPendingEmailChange(ctx, userID)
+}
+
+// ConfirmEmailChange records a confirmation from either address of the
+// pending change and switches the user's email once both have confirmed. The
+// new address then counts as verified, and password reset links sent to the
+// old one stop working.
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) (*domain.EmailChange, error) {
+	var change *domain.EmailChange
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		userToken, err := redeemUserToken(ctx, q, token, domain.UserTokenPurposeEmailChangeCurrent, domain.UserTokenPurposeEmailChangeNew)
+		if err != nil {
+			return err
+		}
+
+		change, err = q.GetPendingEmailChangeForUpdate(ctx, userToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		if change == nil || !change.ExpiresAt.After(time.Now()) {
+			return ErrInvalidUserToken
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+		switch {
+		case userToken.Purpose == domain.UserTokenPurposeEmailChangeCurrent && userToken.Email == change.OldEmail:
+			change.OldConfirmedAt = &now
+		case userToken.Purpose == domain.UserTokenPurposeEmailChangeNew && userToken.Email == change.NewEmail:
+			change.NewConfirmedAt = &now
+		default:
+			return ErrInvalidUserToken
+		}
+
+		if change.OldConfirmedAt != nil && change.NewConfirmedAt != nil {
+			existing, err := q.GetByEmail(ctx, change.NewEmail)
+			if err != nil {
+				return err
+			}
+
+			if existing != nil && existing.ID != change.UserID {
+				return ErrUserAlreadyExists
+			}
+
+			if err := q.UpdateUserEmail(ctx, change.UserID, change.NewEmail); err != nil {
+				return err
+			}
+
+			if err := q.InvalidateUserTokens(ctx, change.UserID, domain.UserTokenPurposePasswordReset); err != nil {
+				return err
+			}
+
+			change.Status = domain.EmailChangeStatusCompleted
+		}
+
+		return q.UpdateEmailChange(ctx, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+// GetEmailChange returns the user's pending, unexpired email change.
+func (s *userService) GetEmailChange(ctx context.Context, userID int64) (*domain.EmailChange, error) {
+	change, err := s.store.GetPendingEmailChange(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if change == nil || !change.ExpiresAt.After(time.Now()) {
+		return nil, ErrEmailChangeNotFound
+	}
+
+	return change, nil
+}
+
+// CancelEmailChange cancels the user's pending email change and voids its
+// confirmation links.
+func (s *userService) CancelEmailChange(ctx context.Context, userID int64) error {
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		change, err := q.GetPendingEmailChangeForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if change == nil {
+			return ErrEmailChangeNotFound
+		}
+
+		change.Status = domain.EmailChangeStatusCancelled
+		if err := q.UpdateEmailChange(ctx, change); err != nil {
+			return err
+		}
+
+		if err := q.InvalidateUserTokens(ctx, userID, domain.UserTokenPurposeEmailChangeCurrent); err != nil {
+			return err
+		}
+
+		return q.InvalidateUserTokens(ctx, userID, domain.UserTokenPurposeEmailChangeNew)
+	})
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+)
+
+// profileStore adds conditional profile updates to loginStore.
+type profileStore struct {
+	*loginStore
+}
+
+// GetByID returns a copy, so that the service's edits only reach the store
+// through UpdateUserProfile.
+func (s profileStore) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	user, _ := s.loginStore.GetByID(ctx, id)
+	copied := *user
+	return &copied, nil
+}
+
+func (s profileStore) UpdateUserProfile(ctx context.Context, user *domain.User, ifUnmodifiedSince *time.Time) (bool, error) {
+	stored, _ := s.loginStore.GetByID(ctx, user.ID)
+	if ifUnmodifiedSince != nil && !ifUnmodifiedSince.Equal(stored.UpdatedAt) {
+		return false, nil
+	}
+
+	updated := *user
+	updated.UpdatedAt = stored.UpdatedAt.Add(time.Microsecond)
+	*stored = updated
+	return true, nil
+}
+
+func TestUpdateProfileDetectsConflicts(t *testing.T) {
+	store := profileStore{newLoginStore(t)}
+	svc := NewUserService(txStore{store}, "test-secret", nil, "")
+	ctx := context.Background()
+	name := func(s string) domain.ProfileUpdate { return domain.ProfileUpdate{Name: &s} }
+
+	read, err := svc.GetProfile(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := read.UpdatedAt
+
+	// Two clients edit the profile they both read; the second loses.
+	first, err := svc.UpdateProfile(ctx, 1, name("Ada King"), &seen)
+	if err != nil {
+		t.Fatalf("first UpdateProfile() error = %v", err)
+	}
+
+	if first.Name != "Ada King" || !first.UpdatedAt.After(seen) {
+		t.Errorf("first UpdateProfile() = %+v, want the new name and a later updated_at", first)
+	}
+
+	if _, err := svc.UpdateProfile(ctx, 1, name("Ada Lovelace"), &seen); !errors.Is(err, ErrProfileModified) {
+		t.Errorf("second UpdateProfile() error = %v, want %v", err, ErrProfileModified)
+	}
+
+	if got, _ := svc.GetProfile(ctx, 1); got.Name != "Ada King" {
+		t.Errorf("name = %q, want the first update kept", got.Name)
+	}
+
+	// Without a precondition the update always applies.
+	if _, err := svc.UpdateProfile(ctx, 1, name("Ada Lovelace"), nil); err != nil {
+		t.Errorf("unconditional UpdateProfile() error = %v", err)
+	}
+}
+
+func TestUpdateProfileRejectsUnknownTimezone(t *testing.T) {
+	svc := NewUserService(txStore{profileStore{newLoginStore(t)}}, "test-secret", nil, "")
+	timezone := "Mars/Olympus_Mons"
+
+	if _, err := svc.UpdateProfile(context.Background(), 1, domain.ProfileUpdate{Timezone: &timezone}, nil); !errors.Is(err, ErrInvalidTimezone) {
+		t.Errorf("UpdateProfile() error = %v, want %v", err, ErrInvalidTimezone)
+	}
+}
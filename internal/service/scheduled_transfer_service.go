@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrScheduledTransferNotFound = errors.New("scheduled transfer not found")
+	ErrInvalidSchedule           = errors.New("invalid schedule")
+	ErrInvalidScheduleState      = errors.New("scheduled transfer cannot be changed in its current state")
+)
+
+const (
+	// maxScheduledTransferFailures is the number of consecutive failed runs
+	// after which a schedule is marked FAILED and stops running.
+	maxScheduledTransferFailures = 3
+	// maxRecordedSkippedRuns caps how many missed occurrences are written to
+	// the run history when the worker catches up after downtime.
+	maxRecordedSkippedRuns      = 10
+	scheduledTransferBatchSize  = 100
+	scheduledTransferRunHistory = 20
+)
+
+type scheduledTransferService struct {
+	store repository.Store
+}
+
+func NewScheduledTransferService(store repository.Store) domain.ScheduledTransferService {
+	return &scheduledTransferService{
+		store: store,
+	}
+}
+
+// CreateScheduledTransfer validates and stores st. For ONCE schedules
+// st.NextRunAt is the exact run time; for recurring schedules it is optional and
+// marks the earliest time the first occurrence may fall on.
+func (s *scheduledTransferService) CreateScheduledTransfer(ctx context.Context, st *domain.ScheduledTransfer) (*domain.ScheduledTransfer, error) {
+	if !st.Amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	if st.SenderUserID == st.ReceiverUserID {
+		return nil, ErrSelfTransfer
+	}
+
+	now := time.Now().UTC()
+	firstRun, err := firstScheduledRun(st, now)
+	if err != nil {
+		return nil, err
+	}
+
+	receiverWallet, err := s.store.GetByUserID(ctx, st.ReceiverUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if receiverWallet == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	st.NextRunAt = &firstRun
+	st.Status = domain.ScheduledTransferStatusActive
+
+	if err := s.store.CreateScheduledTransfer(ctx, st); err != nil {
+		return nil, err
+	}
+
+	return s.store.GetScheduledTransferByID(ctx, st.ID)
+}
+
+func (s *scheduledTransferService) ListScheduledTransfers(ctx context.Context, userID int64) ([]*domain.ScheduledTransfer, error) {
+	return s.store.ListScheduledTransfersBySender(ctx, userID)
+}
+
+func (s *scheduledTransferService) GetScheduledTransfer(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, []*domain.ScheduledTransferRun, error) {
+	st, err := s.store.GetScheduledTransferByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if st == nil || st.SenderUserID != userID {
+		return nil, nil, ErrScheduledTransferNotFound
+	}
+
+	runs, err := s.store.ListScheduledTransferRuns(ctx, id, scheduledTransferRunHistory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return st, runs, nil
+}
+
+func (s *scheduledTransferService) PauseScheduledTransfer(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, error) {
+	return s.transition(ctx, userID, id, func(st *domain.ScheduledTransfer) error {
+		if st.Status != domain.ScheduledTransferStatusActive {
+			return ErrInvalidScheduleState
+		}
+
+		st.Status = domain.ScheduledTransferStatusPaused
+		return nil
+	})
+}
+
+// ResumeScheduledTransfer reactivates a paused schedule. Recurring schedules
+// continue from the next occurrence after now, so occurrences that fell inside
+// the pause are not run. A paused ONCE transfer whose time has passed runs on
+// the next scheduler tick.
+func (s *scheduledTransferService) ResumeScheduledTransfer(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, error) {
+	return s.transition(ctx, userID, id, func(st *domain.ScheduledTransfer) error {
+		if st.Status != domain.ScheduledTransferStatusPaused {
+			return ErrInvalidScheduleState
+		}
+
+		if st.ScheduleType != domain.ScheduleTypeOnce {
+			next, err := nextScheduledRun(st, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			st.NextRunAt = &next
+		}
+
+		st.Status = domain.ScheduledTransferStatusActive
+		st.FailureCount = 0
+		return nil
+	})
+}
+
+func (s *scheduledTransferService) CancelScheduledTransfer(ctx context.Context, userID, id int64) (*domain.ScheduledTransfer, error) {
+	return s.transition(ctx, userID, id, func(st *domain.ScheduledTransfer) error {
+		if st.Status != domain.ScheduledTransferStatusActive && st.Status != domain.ScheduledTransferStatusPaused {
+			return ErrInvalidScheduleState
+		}
+
+		st.Status = domain.ScheduledTransferStatusCancelled
+		st.NextRunAt = nil
+		return nil
+	})
+}
+
+func (s *scheduledTransferService) transition(ctx context.Context, userID, id int64, apply func(*domain.ScheduledTransfer) error) (*domain.ScheduledTransfer, error) {
+	var updated *domain.ScheduledTransfer
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		st, err := q.GetScheduledTransferForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if st == nil || st.SenderUserID != userID {
+			return ErrScheduledTransferNotFound
+		}
+
+		if err := apply(st); err != nil {
+			return err
+		}
+
+		if err := q.UpdateScheduledTransfer(ctx, st); err != nil {
+			return err
+		}
+
+		updated = st
+		return nil
+	})
+
+	return updated, err
+}
+
+// ProcessDueScheduledTransfers materialises every active schedule whose
+// NextRunAt is at or before now into a PENDING transaction and returns the
+// number of schedules processed. The run stays PENDING until the transaction
+// settles; see settleScheduledTransferRun.
+func (s *scheduledTransferService) ProcessDueScheduledTransfers(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListDueScheduledTransferIDs(ctx, now, scheduledTransferBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, id := range ids {
+		if err := s.processScheduledTransfer(ctx, id, now); err != nil {
+			log.Printf("Error processing scheduled transfer %d: %v", id, err)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// processScheduledTransfer runs the latest due occurrence of a schedule. Older
+// occurrences that were missed (for example while the worker was down) are
+// recorded as SKIPPED rather than executed, so a late worker never sends
+// several payments at once.
+func (s *scheduledTransferService) processScheduledTransfer(ctx context.Context, id int64, now time.Time) error {
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		st, err := q.GetScheduledTransferForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if st == nil || st.Status != domain.ScheduledTransferStatusActive || st.NextRunAt == nil || st.NextRunAt.After(now) {
+			return nil
+		}
+
+		due := st.NextRunAt.UTC()
+		for skipped := 0; ; skipped++ {
+			next, err := nextScheduledRun(st, due)
+			if err != nil || next.IsZero() || next.After(now) {
+				break
+			}
+
+			if skipped < maxRecordedSkippedRuns {
+				err := q.CreateScheduledTransferRun(ctx, &domain.ScheduledTransferRun{
+					ScheduledTransferID: st.ID,
+					ScheduledFor:        due,
+					Status:              domain.ScheduledTransferRunStatusSkipped,
+					Error:               "missed run superseded by a later occurrence",
+				})
+				if err != nil {
+					return err
+				}
+			}
+			due = next
+		}
+
+		run := &domain.ScheduledTransferRun{
+			ScheduledTransferID: st.ID,
+			ScheduledFor:        due,
+		}
+
+		tx, err := createTransfer(ctx, q, st.SenderUserID, st.ReceiverUserID, st.Amount, st.Memo)
+		switch {
+		case err == nil:
+			run.TransactionID = &tx.ID
+			run.Status = domain.ScheduledTransferRunStatusPending
+		case isTransferRejection(err):
+			run.Status = domain.ScheduledTransferRunStatusFailed
+			run.Error = err.Error()
+		default:
+			return err
+		}
+
+		if err := q.CreateScheduledTransferRun(ctx, run); err != nil {
+			return err
+		}
+
+		st.LastRunAt = &due
+		next, err := nextScheduledRun(st, due)
+		if err != nil {
+			return err
+		}
+
+		if next.IsZero() {
+			st.Status = domain.ScheduledTransferStatusCompleted
+			st.NextRunAt = nil
+		} else {
+			st.NextRunAt = &next
+		}
+
+		if run.Status == domain.ScheduledTransferRunStatusFailed {
+			recordScheduledRunFailure(st, run.Error)
+		}
+
+		return q.UpdateScheduledTransfer(ctx, st)
+	})
+}
+
+// settleScheduledTransferRun records the outcome of tx on the scheduled
+// transfer run that created it, if any. A transfer that failed at settlement,
+// for example for lack of funds, counts towards the schedule's failures just
+// like one rejected when it was created.
+func settleScheduledTransferRun(ctx context.Context, q *repository.Queries, tx *domain.Transaction, reason string) error {
+	run, err := q.GetScheduledTransferRunByTransactionForUpdate(ctx, tx.ID)
+	if err != nil {
+		return err
+	}
+
+	if run == nil || run.Status != domain.ScheduledTransferRunStatusPending {
+		return nil
+	}
+
+	st, err := q.GetScheduledTransferForUpdate(ctx, run.ScheduledTransferID)
+	if err != nil {
+		return err
+	}
+
+	if st == nil {
+		return ErrScheduledTransferNotFound
+	}
+
+	if tx.Status == domain.TransactionStatusCompleted {
+		run.Status = domain.ScheduledTransferRunStatusSucceeded
+		st.FailureCount = 0
+		st.LastError = ""
+	} else {
+		run.Status = domain.ScheduledTransferRunStatusFailed
+		run.Error = truncate(reason, 255)
+		recordScheduledRunFailure(st, run.Error)
+	}
+
+	if err := q.UpdateScheduledTransferRun(ctx, run); err != nil {
+		return err
+	}
+
+	return q.UpdateScheduledTransfer(ctx, st)
+}
+
+// recordScheduledRunFailure counts a failed run against st. The schedule is
+// marked FAILED after maxScheduledTransferFailures failures in a row, or when
+// the failed run was its last one.
+func recordScheduledRunFailure(st *domain.ScheduledTransfer, reason string) {
+	st.FailureCount++
+	st.LastError = truncate(reason, 255)
+
+	switch {
+	case st.Status == domain.ScheduledTransferStatusCompleted,
+		st.Status == domain.ScheduledTransferStatusActive && st.FailureCount >= maxScheduledTransferFailures:
+		st.Status = domain.ScheduledTransferStatusFailed
+		st.NextRunAt = nil
+	}
+}
+
+// isTransferRejection reports whether err is a business-rule rejection of the
+// transfer, as opposed to an infrastructure error that should be retried.
+func isTransferRejection(err error) bool {
+	return errors.Is(err, ErrWalletNotFound) ||
+		errors.Is(err, ErrInvalidAmount) ||
+		errors.Is(err, ErrSelfTransfer) ||
+		errors.Is(err, ErrFeeExceedsAmount)
+}
+
+// firstScheduledRun returns the first occurrence of a new schedule.
+func firstScheduledRun(st *domain.ScheduledTransfer, now time.Time) (time.Time, error) {
+	switch st.ScheduleType {
+	case domain.ScheduleTypeOnce:
+		if st.NextRunAt == nil || !st.NextRunAt.After(now) {
+			return time.Time{}, fmt.Errorf("%w: run_at must be in the future", ErrInvalidSchedule)
+		}
+		return st.NextRunAt.UTC(), nil
+	case domain.ScheduleTypeCron, domain.ScheduleTypeMonthly:
+		after := now
+		if st.NextRunAt != nil && st.NextRunAt.After(now) {
+			// Step back by a second so a start time that is itself an
+			// occurrence is included.
+			after = st.NextRunAt.UTC().Add(-time.Second)
+		}
+		return nextScheduledRun(st, after)
+	default:
+		return time.Time{}, fmt.Errorf("%w: unknown schedule type %q", ErrInvalidSchedule, st.ScheduleType)
+	}
+}
+
+// nextScheduledRun returns the first occurrence strictly after the given time,
+// or the zero time when the schedule has no further occurrences.
+func nextScheduledRun(st *domain.ScheduledTransfer, after time.Time) (time.Time, error) {
+	switch st.ScheduleType {
+	case domain.ScheduleTypeOnce:
+		return time.Time{}, nil
+	case domain.ScheduleTypeCron:
+		schedule, err := cron.ParseStandard(st.CronExpr)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+		return schedule.Next(after.UTC()), nil
+	case domain.ScheduleTypeMonthly:
+		if st.DayOfMonth < 1 || st.DayOfMonth > 31 {
+			return time.Time{}, fmt.Errorf("%w: day_of_month must be between 1 and 31", ErrInvalidSchedule)
+		}
+		return nextMonthlyRun(st.DayOfMonth, after.UTC()), nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: unknown schedule type %q", ErrInvalidSchedule, st.ScheduleType)
+	}
+}
+
+// nextMonthlyRun returns midnight UTC on the given day of the month, clamped to
+// the last day of shorter months, strictly after the given time.
+func nextMonthlyRun(day int, after time.Time) time.Time {
+	year, month, _ := after.Date()
+	for {
+		lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		candidate := time.Date(year, month, min(day, lastDay), 0, 0, 0, 0, time.UTC)
+		if candidate.After(after) {
+			return candidate
+		}
+		month++
+		if month > time.December {
+			month = time.January
+			year++
+		}
+	}
+}
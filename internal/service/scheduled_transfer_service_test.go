@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func date(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestNextScheduledRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		st    domain.ScheduledTransfer
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "monthly later this month",
+			st:    domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeMonthly, DayOfMonth: 15},
+			after: date(2025, time.March, 3, 12),
+			want:  date(2025, time.March, 15, 0),
+		},
+		{
+			name:  "monthly strictly after an occurrence",
+			st:    domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeMonthly, DayOfMonth: 15},
+			after: date(2025, time.March, 15, 0),
+			want:  date(2025, time.April, 15, 0),
+		},
+		{
+			name:  "monthly clamped to the end of February",
+			st:    domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeMonthly, DayOfMonth: 31},
+			after: date(2025, time.January, 31, 0),
+			want:  date(2025, time.February, 28, 0),
+		},
+		{
+			name:  "monthly clamped in a leap year",
+			st:    domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeMonthly, DayOfMonth: 30},
+			after: date(2024, time.January, 30, 0),
+			want:  date(2024, time.February, 29, 0),
+		},
+		{
+			name:  "monthly across the year end",
+			st:    domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeMonthly, DayOfMonth: 1},
+			after: date(2025, time.December, 1, 9),
+			want:  date(2026, time.January, 1, 0),
+		},
+		{
+			name:  "cron weekly",
+			st:    domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeCron, CronExpr: "0 9 * * 1"},
+			after: date(2025, time.June, 2, 9),
+			want:  date(2025, time.June, 9, 9),
+		},
+		{
+			name:  "once has no further runs",
+			st:    domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeOnce},
+			after: date(2025, time.June, 2, 9),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextScheduledRun(&tt.st, tt.after)
+			if err != nil {
+				t.Fatalf("nextScheduledRun() error = %v", err)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("nextScheduledRun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextScheduledRunRejectsInvalidSchedules(t *testing.T) {
+	for _, st := range []domain.ScheduledTransfer{
+		{ScheduleType: domain.ScheduleTypeMonthly, DayOfMonth: 0},
+		{ScheduleType: domain.ScheduleTypeMonthly, DayOfMonth: 32},
+		{ScheduleType: domain.ScheduleTypeCron, CronExpr: "every day"},
+		{ScheduleType: "YEARLY"},
+	} {
+		if _, err := nextScheduledRun(&st, date(2025, time.June, 2, 9)); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("nextScheduledRun(%+v) error = %v, want %v", st, err, ErrInvalidSchedule)
+		}
+	}
+}
+
+func TestFirstScheduledRun(t *testing.T) {
+	now := date(2025, time.June, 2, 9)
+
+	past := now.Add(-time.Minute)
+	once := domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeOnce, NextRunAt: &past}
+	if _, err := firstScheduledRun(&once, now); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("firstScheduledRun() of a past ONCE error = %v, want %v", err, ErrInvalidSchedule)
+	}
+
+	// A start time that is itself an occurrence is the first run.
+	start := date(2025, time.July, 1, 0)
+	monthly := domain.ScheduledTransfer{ScheduleType: domain.ScheduleTypeMonthly, DayOfMonth: 1, NextRunAt: &start}
+	got, err := firstScheduledRun(&monthly, now)
+	if err != nil || !got.Equal(start) {
+		t.Errorf("firstScheduledRun() = %v, %v, want %v", got, err, start)
+	}
+}
+
+// addSchedule adds an active schedule of 10.00 from user 1 to user 2, next
+// due at nextRunAt.
+func (s *ledgerStore) addSchedule(scheduleType domain.ScheduleType, nextRunAt time.Time) *domain.ScheduledTransfer {
+	st := &domain.ScheduledTransfer{
+		ID:             int64(len(s.scheduledTransfers) + 1),
+		SenderUserID:   1,
+		ReceiverUserID: 2,
+		Amount:         decimal.RequireFromString("10.00"),
+		Memo:           "Rent",
+		ScheduleType:   scheduleType,
+		DayOfMonth:     1,
+		NextRunAt:      &nextRunAt,
+		Status:         domain.ScheduledTransferStatusActive,
+	}
+	s.scheduledTransfers[st.ID] = st
+
+	return st
+}
+
+func TestProcessDueScheduledTransfersSkipsMissedRuns(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	store.addSchedule(domain.ScheduleTypeMonthly, date(2025, time.March, 1, 0))
+
+	now := date(2025, time.May, 20, 0)
+	if _, err := NewScheduledTransferService(store).ProcessDueScheduledTransfers(context.Background(), now); err != nil {
+		t.Fatalf("ProcessDueScheduledTransfers() error = %v", err)
+	}
+
+	var statuses []domain.ScheduledTransferRunStatus
+	for _, run := range store.scheduledRuns {
+		statuses = append(statuses, run.Status)
+	}
+
+	want := []domain.ScheduledTransferRunStatus{
+		domain.ScheduledTransferRunStatusSkipped,
+		domain.ScheduledTransferRunStatusSkipped,
+		domain.ScheduledTransferRunStatusPending,
+	}
+	if len(statuses) != len(want) || statuses[0] != want[0] || statuses[1] != want[1] || statuses[2] != want[2] {
+		t.Fatalf("run statuses = %v, want %v", statuses, want)
+	}
+
+	if got := store.scheduledRuns[2].ScheduledFor; !got.Equal(date(2025, time.May, 1, 0)) {
+		t.Errorf("run scheduled for %v, want the latest due occurrence", got)
+	}
+
+	if len(store.transactions) != 1 {
+		t.Fatalf("transactions = %d, want 1", len(store.transactions))
+	}
+
+	if tx := store.transactions[1]; tx.Memo != "Rent" {
+		t.Errorf("transaction memo = %q, want %q", tx.Memo, "Rent")
+	}
+
+	if next := store.scheduledTransfers[1].NextRunAt; next == nil || !next.Equal(date(2025, time.June, 1, 0)) {
+		t.Errorf("next run = %v, want 2025-06-01", next)
+	}
+
+	store.settleTransfers(t)
+
+	if run := store.scheduledRuns[2]; run.Status != domain.ScheduledTransferRunStatusSucceeded {
+		t.Errorf("run status after settlement = %s, want SUCCEEDED", run.Status)
+	}
+
+	store.assertBalance(t, 1, "90.00", "0")
+	store.assertBalance(t, 2, "10.00", "0")
+}
+
+func TestScheduledTransferFailsAfterRepeatedSettlementFailures(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "5.00")
+	store.addUser(2, "0")
+	store.addSchedule(domain.ScheduleTypeMonthly, date(2025, time.March, 1, 0))
+
+	svc := NewScheduledTransferService(store)
+
+	// Each month's transfer is created, then fails to settle for lack of
+	// funds.
+	for month := time.March; month < time.March+maxScheduledTransferFailures; month++ {
+		if _, err := svc.ProcessDueScheduledTransfers(context.Background(), date(2025, month, 1, 1)); err != nil {
+			t.Fatalf("ProcessDueScheduledTransfers() error = %v", err)
+		}
+
+		store.settleTransfers(t)
+
+		run := store.scheduledRuns[len(store.scheduledRuns)-1]
+		if run.Status != domain.ScheduledTransferRunStatusFailed || run.Error != ErrInsufficientFunds.Error() {
+			t.Errorf("run for %s = %s %q, want FAILED for insufficient funds", month, run.Status, run.Error)
+		}
+	}
+
+	st := store.scheduledTransfers[1]
+	if st.Status != domain.ScheduledTransferStatusFailed || st.NextRunAt != nil {
+		t.Errorf("schedule = %s next %v, want FAILED with no next run", st.Status, st.NextRunAt)
+	}
+
+	if st.FailureCount != maxScheduledTransferFailures || st.LastError != ErrInsufficientFunds.Error() {
+		t.Errorf("schedule failures = %d %q, want %d for insufficient funds", st.FailureCount, st.LastError, maxScheduledTransferFailures)
+	}
+
+	store.assertBalance(t, 1, "5.00", "0")
+}
+
+func TestScheduledTransferSettlementResetsFailures(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	st := store.addSchedule(domain.ScheduleTypeMonthly, date(2025, time.March, 1, 0))
+	st.FailureCount = 2
+	st.LastError = ErrInsufficientFunds.Error()
+
+	if _, err := NewScheduledTransferService(store).ProcessDueScheduledTransfers(context.Background(), date(2025, time.March, 1, 1)); err != nil {
+		t.Fatalf("ProcessDueScheduledTransfers() error = %v", err)
+	}
+
+	// Creating the transfer alone does not count as success.
+	if got := store.scheduledTransfers[1].FailureCount; got != 2 {
+		t.Errorf("failure count before settlement = %d, want 2", got)
+	}
+
+	store.settleTransfers(t)
+
+	if got := store.scheduledTransfers[1]; got.FailureCount != 0 || got.LastError != "" || got.Status != domain.ScheduledTransferStatusActive {
+		t.Errorf("schedule after settlement = %s, %d failures %q, want ACTIVE with none", got.Status, got.FailureCount, got.LastError)
+	}
+}
+
+func TestOnceScheduledTransferFailsWhenItsRunFails(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "0")
+	store.addUser(2, "0")
+	store.addSchedule(domain.ScheduleTypeOnce, date(2025, time.March, 1, 0))
+
+	if _, err := NewScheduledTransferService(store).ProcessDueScheduledTransfers(context.Background(), date(2025, time.March, 1, 1)); err != nil {
+		t.Fatalf("ProcessDueScheduledTransfers() error = %v", err)
+	}
+
+	if got := store.scheduledTransfers[1].Status; got != domain.ScheduledTransferStatusCompleted {
+		t.Errorf("schedule status once run = %s, want COMPLETED", got)
+	}
+
+	store.settleTransfers(t)
+
+	if got := store.scheduledTransfers[1]; got.Status != domain.ScheduledTransferStatusFailed || got.FailureCount != 1 {
+		t.Errorf("schedule after failed settlement = %s with %d failures, want FAILED with 1", got.Status, got.FailureCount)
+	}
+}
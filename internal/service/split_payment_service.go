@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrSplitPaymentNotFound = errors.New("split payment not found")
+	ErrInvalidSplitPayment  = errors.New("split payment is not valid")
+)
+
+const (
+	minSplitRecipients = 2
+	maxSplitRecipients = 50
+)
+
+var hundredPercent = decimal.NewFromInt(100)
+
+type splitPaymentService struct {
+	store repository.Store
+}
+
+func NewSplitPaymentService(store repository.Store) domain.SplitPaymentService {
+	return &splitPaymentService{
+		store: store,
+	}
+}
+
+// CreateSplitPayment records a PENDING split payment with one PENDING leg per
+// recipient and queues it for settlement. Recipients are given either all by
+// amount, in which case amount may be zero and defaults to their sum, or all
+// by percentage of amount. Each leg's fee is fixed here, as for a transfer.
+func (s *splitPaymentService) CreateSplitPayment(ctx context.Context, senderUserID int64, amount decimal.Decimal, recipients []domain.SplitRecipient, description string) (*domain.SplitPayment, error) {
+	shares, total, err := splitShares(amount, recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	var created *domain.SplitPayment
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		senderWallet, err := q.GetByUserID(ctx, senderUserID)
+		if err != nil {
+			return err
+		}
+
+		if senderWallet == nil {
+			return ErrWalletNotFound
+		}
+
+		legs := make([]*domain.Transaction, 0, len(recipients))
+		for i, recipient := range recipients {
+			_, receiverWallet, err := transferWallets(ctx, q, senderUserID, recipient.UserID, shares[i])
+			if err != nil {
+				return fmt.Errorf("recipient %d: %w", recipient.UserID, err)
+			}
+
+			if receiverWallet.Currency != senderWallet.Currency {
+				return fmt.Errorf("recipient %d: %w", recipient.UserID, ErrCurrencyMismatch)
+			}
+
+			quote, err := quoteTransferFee(ctx, q, recipient.UserID, receiverWallet, shares[i])
+			if err != nil {
+				return fmt.Errorf("recipient %d: %w", recipient.UserID, err)
+			}
+
+			legs = append(legs, &domain.Transaction{
+				SenderWalletID:   senderWallet.ID,
+				ReceiverWalletID: receiverWallet.ID,
+				Amount:           shares[i],
+				Fee:              quote.Fee,
+				FeeWalletID:      quote.FeeWalletID,
+				Status:           domain.TransactionStatusPending,
+			})
+		}
+
+		payment := &domain.SplitPayment{
+			SenderWalletID: senderWallet.ID,
+			Amount:         total,
+			Currency:       senderWallet.Currency,
+			Description:    description,
+			Status:         domain.SplitPaymentStatusPending,
+		}
+		if err := q.CreateSplitPayment(ctx, payment); err != nil {
+			return err
+		}
+
+		// The legs are settled by the split payment's task, not one
+		// transfer task each, so that they succeed or fail together.
+		for _, leg := range legs {
+			leg.SplitPaymentID = &payment.ID
+			if err := q.CreateTransaction(ctx, leg); err != nil {
+				return err
+			}
+		}
+		payment.Legs = legs
+
+		if err := publishEvent(ctx, q, tasks.TaskTypeProcessSplitPayment, tasks.ProcessSplitPaymentPayload{SplitPaymentID: payment.ID}); err != nil {
+			return err
+		}
+
+		created = payment
+		return publishSplitPaymentEvent(ctx, q, tasks.TopicSplitPaymentCreated, payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSplitPayment(ctx, senderUserID, created.ID)
+}
+
+// splitShares validates the recipients and returns the amount each of them
+// receives, in order, and the payment's total.
+func splitShares(amount decimal.Decimal, recipients []domain.SplitRecipient) ([]decimal.Decimal, decimal.Decimal, error) {
+	invalid := func(format string, args ...any) ([]decimal.Decimal, decimal.Decimal, error) {
+		return nil, decimal.Zero, fmt.Errorf("%w: %s", ErrInvalidSplitPayment, fmt.Sprintf(format, args...))
+	}
+
+	if len(recipients) < minSplitRecipients || len(recipients) > maxSplitRecipients {
+		return invalid("between %d and %d recipients are required", minSplitRecipients, maxSplitRecipients)
+	}
+
+	if amount.IsNegative() || !amount.Equal(amount.Round(2)) {
+		return invalid("amount must be positive with at most 2 decimal places")
+	}
+
+	byPercentage := recipients[0].Percentage.IsPositive()
+	seen := make(map[int64]bool, len(recipients))
+
+	for _, recipient := range recipients {
+		if seen[recipient.UserID] {
+			return invalid("recipient %d is listed more than once", recipient.UserID)
+		}
+		seen[recipient.UserID] = true
+
+		switch {
+		case !recipient.Amount.IsZero() && !recipient.Percentage.IsZero():
+			return invalid("recipient %d has both an amount and a percentage", recipient.UserID)
+		case byPercentage && !recipient.Percentage.IsPositive():
+			return invalid("recipient %d has no percentage; give every recipient an amount or every recipient a percentage", recipient.UserID)
+		case !byPercentage && !recipient.Amount.IsPositive():
+			return invalid("recipient %d has no amount; give every recipient an amount or every recipient a percentage", recipient.UserID)
+		case !recipient.Amount.Equal(recipient.Amount.Round(2)):
+			return invalid("recipient %d's amount has more than 2 decimal places", recipient.UserID)
+		}
+	}
+
+	shares := make([]decimal.Decimal, len(recipients))
+
+	if !byPercentage {
+		total := decimal.Zero
+		for i, recipient := range recipients {
+			shares[i] = recipient.Amount
+			total = total.Add(recipient.Amount)
+		}
+
+		if !amount.IsZero() && !amount.Equal(total) {
+			return invalid("recipients' amounts add up to %s, not %s", total, amount)
+		}
+
+		return shares, total, nil
+	}
+
+	if !amount.IsPositive() {
+		return invalid("amount is required when recipients are given percentages")
+	}
+
+	percent := decimal.Zero
+	for _, recipient := range recipients {
+		percent = percent.Add(recipient.Percentage)
+	}
+
+	if !percent.Equal(hundredPercent) {
+		return invalid("recipients' percentages add up to %s, not 100", percent)
+	}
+
+	// Each share is rounded down to the cent and the cents left over go to
+	// the recipients whose shares lost the most to rounding, so the shares
+	// always add up to amount.
+	remainders := make([]decimal.Decimal, len(recipients))
+	allocated := decimal.Zero
+	for i, recipient := range recipients {
+		exact := amount.Mul(recipient.Percentage).Div(hundredPercent)
+		shares[i] = exact.RoundDown(2)
+		remainders[i] = exact.Sub(shares[i])
+		allocated = allocated.Add(shares[i])
+	}
+
+	order := make([]int, len(recipients))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].GreaterThan(remainders[order[b]])
+	})
+
+	cent := decimal.New(1, -2)
+	for i := 0; allocated.LessThan(amount); i++ {
+		shares[order[i]] = shares[order[i]].Add(cent)
+		allocated = allocated.Add(cent)
+	}
+
+	for i, share := range shares {
+		if !share.IsPositive() {
+			return invalid("recipient %d's share of %s rounds down to nothing", recipients[i].UserID, amount)
+		}
+	}
+
+	return shares, amount, nil
+}
+
+func (s *splitPaymentService) ListSplitPayments(ctx context.Context, userID int64) ([]*domain.SplitPayment, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	payments, err := s.store.ListSplitPaymentsBySender(ctx, wallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		if payment.Legs, err = s.store.ListTransactionsBySplitPayment(ctx, payment.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return payments, nil
+}
+
+// GetSplitPayment returns a split payment the user sent, with its legs.
+func (s *splitPaymentService) GetSplitPayment(ctx context.Context, userID, id int64) (*domain.SplitPayment, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.store.GetSplitPaymentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment == nil || wallet == nil || payment.SenderWalletID != wallet.ID {
+		return nil, ErrSplitPaymentNotFound
+	}
+
+	if payment.Legs, err = s.store.ListTransactionsBySplitPayment(ctx, payment.ID); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// ProcessSplitPayment settles a PENDING split payment. Every wallet involved is
+// locked and checked first, then either all legs complete or all legs fail.
+// It is idempotent: split payments that are already settled are left
+// untouched.
+func (s *splitPaymentService) ProcessSplitPayment(ctx context.Context, id int64) error {
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		payment, err := q.GetSplitPaymentForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if payment == nil {
+			return ErrSplitPaymentNotFound
+		}
+
+		if payment.Status != domain.SplitPaymentStatusPending {
+			return nil
+		}
+
+		legs, err := q.ListTransactionsBySplitPayment(ctx, payment.ID)
+		if err != nil {
+			return err
+		}
+
+		walletIDs := []int64{payment.SenderWalletID}
+		for _, leg := range legs {
+			walletIDs = append(walletIDs, leg.ReceiverWalletID)
+		}
+
+		wallets, err := lockWallets(ctx, q, walletIDs...)
+		if err != nil {
+			return err
+		}
+
+		sender := wallets[payment.SenderWalletID]
+
+		reason := ""
+		for _, leg := range legs {
+			if wallets[leg.ReceiverWalletID].Currency != sender.Currency {
+				reason = ErrCurrencyMismatch.Error()
+			}
+		}
+
+		if reason == "" && sender.AvailableBalance.LessThan(payment.Amount) {
+			reason = ErrInsufficientFunds.Error()
+		}
+
+		settle, topic := completeTransfer, tasks.TopicSplitPaymentCompleted
+		payment.Status = domain.SplitPaymentStatusCompleted
+		if reason != "" {
+			settle = func(ctx context.Context, q *repository.Queries, tx *domain.Transaction) error {
+				return failTransfer(ctx, q, tx, reason)
+			}
+			topic = tasks.TopicSplitPaymentFailed
+			payment.Status = domain.SplitPaymentStatusFailed
+			payment.FailureReason = reason
+		}
+
+		for _, leg := range legs {
+			if leg.Status != domain.TransactionStatusPending {
+				continue
+			}
+
+			if err := settle(ctx, q, leg); err != nil {
+				return err
+			}
+		}
+
+		if err := q.UpdateSplitPayment(ctx, payment); err != nil {
+			return err
+		}
+
+		payment.Legs = legs
+		return publishSplitPaymentEvent(ctx, q, topic, payment)
+	})
+}
+
+// lockWallets locks the given wallets in ID order, like lockWalletPair, and
+// returns them by ID. IDs may repeat.
+func lockWallets(ctx context.Context, q *repository.Queries, ids ...int64) (map[int64]*domain.Wallet, error) {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	wallets := make(map[int64]*domain.Wallet, len(sorted))
+	for _, id := range sorted {
+		if _, ok := wallets[id]; ok {
+			continue
+		}
+
+		wallet, err := q.GetWalletForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if wallet == nil {
+			return nil, ErrWalletNotFound
+		}
+
+		wallets[id] = wallet
+	}
+
+	return wallets, nil
+}
+
+func publishSplitPaymentEvent(ctx context.Context, q *repository.Queries, topic string, payment *domain.SplitPayment) error {
+	return publishEvent(ctx, q, topic, tasks.SplitPaymentEventPayload{
+		SplitPaymentID: payment.ID,
+		SenderWalletID: payment.SenderWalletID,
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		LegCount:       len(payment.Legs),
+		Status:         string(payment.Status),
+		Reason:         payment.FailureReason,
+	})
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// splitPaymentStore adds split payments to ledgerStore.
+type splitPaymentStore struct {
+	*ledgerStore
+	payments []*domain.SplitPayment
+}
+
+func (s *splitPaymentStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+func (s *splitPaymentStore) CreateSplitPayment(ctx context.Context, payment *domain.SplitPayment) error {
+	payment.ID = int64(len(s.payments) + 1)
+	copied := *payment
+	s.payments = append(s.payments, &copied)
+	return nil
+}
+
+func (s *splitPaymentStore) GetSplitPaymentByID(ctx context.Context, id int64) (*domain.SplitPayment, error) {
+	copied := *s.payments[id-1]
+	return &copied, nil
+}
+
+func (s *splitPaymentStore) GetSplitPaymentForUpdate(ctx context.Context, id int64) (*domain.SplitPayment, error) {
+	return s.GetSplitPaymentByID(ctx, id)
+}
+
+func (s *splitPaymentStore) UpdateSplitPayment(ctx context.Context, payment *domain.SplitPayment) error {
+	copied := *payment
+	s.payments[payment.ID-1] = &copied
+	return nil
+}
+
+func (s *splitPaymentStore) ListTransactionsBySplitPayment(ctx context.Context, splitPaymentID int64) ([]*domain.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var legs []*domain.Transaction
+	for id := int64(1); id <= int64(len(s.transactions)); id++ {
+		if tx := s.transactions[id]; tx.SplitPaymentID != nil && *tx.SplitPaymentID == splitPaymentID {
+			copied := *tx
+			legs = append(legs, &copied)
+		}
+	}
+
+	return legs, nil
+}
+
+func newSplitPaymentStore() *splitPaymentStore {
+	store := &splitPaymentStore{ledgerStore: newLedgerStore()}
+	store.addUser(1, "100.00")
+	store.addUser(2, "0")
+	store.addUser(3, "0")
+	store.addUser(4, "0")
+	return store
+}
+
+func byAmount(amounts ...string) []domain.SplitRecipient {
+	recipients := make([]domain.SplitRecipient, len(amounts))
+	for i, amount := range amounts {
+		recipients[i] = domain.SplitRecipient{UserID: int64(i + 2), Amount: dec(amount)}
+	}
+
+	return recipients
+}
+
+func byPercentage(percentages ...string) []domain.SplitRecipient {
+	recipients := make([]domain.SplitRecipient, len(percentages))
+	for i, percentage := range percentages {
+		recipients[i] = domain.SplitRecipient{UserID: int64(i + 2), Percentage: dec(percentage)}
+	}
+
+	return recipients
+}
+
+func TestSplitSharesRemainderCents(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     string
+		recipients []domain.SplitRecipient
+		want       []string
+	}{
+		{
+			name:       "even split",
+			amount:     "90.00",
+			recipients: byPercentage("50", "25", "25"),
+			want:       []string{"45.00", "22.50", "22.50"},
+		},
+		{
+			name:       "cent goes to the largest remainder",
+			amount:     "10.00",
+			recipients: byPercentage("33.33", "33.33", "33.34"),
+			want:       []string{"3.33", "3.33", "3.34"},
+		},
+		{
+			name:       "two cents left over",
+			amount:     "0.05",
+			recipients: byPercentage("33.33", "33.33", "33.34"),
+			want:       []string{"0.02", "0.01", "0.02"},
+		},
+		{
+			name:       "ties go to the earlier recipient",
+			amount:     "0.05",
+			recipients: byPercentage("50", "50"),
+			want:       []string{"0.03", "0.02"},
+		},
+		{
+			name:       "amounts default the total",
+			amount:     "0",
+			recipients: byAmount("12.34", "0.66"),
+			want:       []string{"12.34", "0.66"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, total, err := splitShares(dec(tt.amount), tt.recipients)
+			if err != nil {
+				t.Fatalf("splitShares() error = %v", err)
+			}
+
+			sum := decimal.Zero
+			for i, share := range shares {
+				if !share.Equal(dec(tt.want[i])) {
+					t.Errorf("share %d = %s, want %s", i, share, tt.want[i])
+				}
+				sum = sum.Add(share)
+			}
+
+			if !sum.Equal(total) {
+				t.Errorf("shares add up to %s, want the total %s", sum, total)
+			}
+		})
+	}
+}
+
+func TestSplitSharesRejectsInvalidSplits(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     string
+		recipients []domain.SplitRecipient
+	}{
+		{"one recipient", "10.00", byAmount("10.00")},
+		{"amounts do not add up", "10.00", byAmount("4.00", "5.00")},
+		{"percentages do not add up", "10.00", byPercentage("50", "49.99")},
+		{"share rounds to nothing", "0.01", byPercentage("50", "50")},
+		{"no amount for percentages", "0", byPercentage("50", "50")},
+		{"sub-cent amount", "10.005", byPercentage("50", "50")},
+		{"mixed amount and percentage", "10.00", []domain.SplitRecipient{
+			{UserID: 2, Amount: dec("5.00")},
+			{UserID: 3, Percentage: dec("50")},
+		}},
+		{"duplicate recipient", "0", []domain.SplitRecipient{
+			{UserID: 2, Amount: dec("5.00")},
+			{UserID: 2, Amount: dec("5.00")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := splitShares(dec(tt.amount), tt.recipients); !errors.Is(err, ErrInvalidSplitPayment) {
+				t.Errorf("splitShares() error = %v, want %v", err, ErrInvalidSplitPayment)
+			}
+		})
+	}
+}
+
+func TestSplitPaymentCompletes(t *testing.T) {
+	store := newSplitPaymentStore()
+	svc := NewSplitPaymentService(store)
+	ctx := context.Background()
+
+	payment, err := svc.CreateSplitPayment(ctx, 1, dec("100.00"), byPercentage("33.33", "33.33", "33.34"), "dinner")
+	if err != nil {
+		t.Fatalf("CreateSplitPayment() error = %v", err)
+	}
+
+	// Nothing moves until the payment's task runs, and redelivery of the
+	// task does not pay anyone twice.
+	store.assertBalance(t, 1, "100.00", "0")
+
+	for i := 0; i < 2; i++ {
+		if err := svc.ProcessSplitPayment(ctx, payment.ID); err != nil {
+			t.Fatalf("ProcessSplitPayment() error = %v", err)
+		}
+	}
+
+	got, err := svc.GetSplitPayment(ctx, 1, payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status != domain.SplitPaymentStatusCompleted {
+		t.Errorf("status = %s, want %s", got.Status, domain.SplitPaymentStatusCompleted)
+	}
+
+	for _, leg := range got.Legs {
+		if leg.Status != domain.TransactionStatusCompleted {
+			t.Errorf("leg %d status = %s, want %s", leg.ID, leg.Status, domain.TransactionStatusCompleted)
+		}
+	}
+
+	store.assertBalance(t, 1, "0", "0")
+	store.assertBalance(t, 2, "33.33", "0")
+	store.assertBalance(t, 3, "33.33", "0")
+	store.assertBalance(t, 4, "33.34", "0")
+}
+
+func TestSplitPaymentIsAllOrNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(store *splitPaymentStore)
+		reason string
+	}{
+		{
+			name:   "sender can no longer cover the total",
+			change: func(store *splitPaymentStore) { store.wallets[walletID(1)].HeldBalance = dec("10.01") },
+			reason: ErrInsufficientFunds.Error(),
+		},
+		{
+			name:   "one recipient changed currency",
+			change: func(store *splitPaymentStore) { store.wallets[walletID(4)].Currency = "EUR" },
+			reason: ErrCurrencyMismatch.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newSplitPaymentStore()
+			svc := NewSplitPaymentService(store)
+			ctx := context.Background()
+
+			payment, err := svc.CreateSplitPayment(ctx, 1, decimal.Zero, byAmount("30.00", "30.00", "30.00"), "")
+			if err != nil {
+				t.Fatalf("CreateSplitPayment() error = %v", err)
+			}
+
+			tt.change(store)
+
+			if err := svc.ProcessSplitPayment(ctx, payment.ID); err != nil {
+				t.Fatalf("ProcessSplitPayment() error = %v", err)
+			}
+
+			got, err := svc.GetSplitPayment(ctx, 1, payment.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Status != domain.SplitPaymentStatusFailed || got.FailureReason != tt.reason {
+				t.Errorf("payment = %s %q, want %s %q", got.Status, got.FailureReason, domain.SplitPaymentStatusFailed, tt.reason)
+			}
+
+			// Recipients who could have been paid are not.
+			for _, leg := range got.Legs {
+				if leg.Status != domain.TransactionStatusFailed {
+					t.Errorf("leg %d status = %s, want %s", leg.ID, leg.Status, domain.TransactionStatusFailed)
+				}
+			}
+
+			if len(store.ledger) != 0 {
+				t.Errorf("%d ledger entries, want none", len(store.ledger))
+			}
+
+			store.assertBalance(t, 2, "0", "0")
+			store.assertBalance(t, 3, "0", "0")
+			store.assertBalance(t, 4, "0", "0")
+		})
+	}
+}
+
+func TestCreateSplitPaymentRejectsUnknownRecipient(t *testing.T) {
+	store := newSplitPaymentStore()
+	svc := NewSplitPaymentService(store)
+
+	recipients := append(byAmount("10.00", "10.00"), domain.SplitRecipient{UserID: 9, Amount: dec("10.00")})
+	if _, err := svc.CreateSplitPayment(context.Background(), 1, decimal.Zero, recipients, ""); !errors.Is(err, ErrWalletNotFound) {
+		t.Fatalf("CreateSplitPayment() error = %v, want %v", err, ErrWalletNotFound)
+	}
+
+	if len(store.payments) != 0 {
+		t.Errorf("%d split payments stored, want none", len(store.payments))
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+// txStore runs ExecTx callbacks directly against store, whose methods serve
+// both the store and the Queries passed to the callback. Test fakes embed a
+// nil repository.Store and override only the methods a test needs.
+type txStore struct {
+	repository.Store
+}
+
+func (s txStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	return fn(queriesFor(s.Store))
+}
+
+// queriesFor returns Queries whose repositories are all backed by store.
+func queriesFor(store repository.Store) *repository.Queries {
+	q := &repository.Queries{}
+
+	v := reflect.ValueOf(q).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		v.Field(i).Set(reflect.ValueOf(store))
+	}
+
+	return q
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+// Stream event types sent to clients.
+const (
+	StreamEventTransferStatus = "transfer.status"
+	StreamEventWalletBalance  = "wallet.balance"
+)
+
+type streamService struct {
+	store  repository.Store
+	broker domain.EventBroker
+}
+
+func NewStreamService(store repository.Store, broker domain.EventBroker) domain.StreamService {
+	return &streamService{
+		store:  store,
+		broker: broker,
+	}
+}
+
+type transferStatusEvent struct {
+	TransactionID int64           `json:"transaction_id"`
+	WalletID      int64           `json:"wallet_id"`
+	Direction     string          `json:"direction"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	Status        string          `json:"status"`
+	Reason        string          `json:"reason,omitempty"`
+}
+
+// PublishEvent turns an outbox event into stream events for the owners of the
+// wallets it touched. Event IDs are "<outbox id>-<n>", so they increase across
+// events and are stable if the same outbox event is published again.
+func (s *streamService) PublishEvent(ctx context.Context, eventID int64, eventType string, data []byte) error {
+	switch eventType {
+	case tasks.TopicTransferCompleted, tasks.TopicTransferFailed:
+		var payload tasks.TransferEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		completed := eventType == tasks.TopicTransferCompleted
+		for i, walletID := range []int64{payload.SenderWalletID, payload.ReceiverWalletID} {
+			// The receiver is credited the amount less the fee.
+			direction, amount := "debit", payload.Amount
+			if walletID == payload.ReceiverWalletID {
+				direction, amount = "credit", payload.Amount.Sub(payload.Fee)
+			}
+
+			// A failed transfer never moved the receiver's funds.
+			if !completed && direction == "credit" {
+				continue
+			}
+
+			status := transferStatusEvent{
+				TransactionID: payload.TransactionID,
+				WalletID:      walletID,
+				Direction:     direction,
+				Amount:        amount,
+				Fee:           payload.Fee,
+				Status:        payload.Status,
+				Reason:        payload.Reason,
+			}
+			if err := s.publishToWalletOwner(ctx, walletID, eventID, i*2, StreamEventTransferStatus, status, completed); err != nil {
+				return err
+			}
+		}
+	case tasks.TopicHoldAuthorized, tasks.TopicHoldCaptured, tasks.TopicHoldVoided, tasks.TopicHoldExpired:
+		var payload tasks.HoldEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		// Holds change the payer's available balance; a capture also credits
+		// the payee.
+		if err := s.publishBalance(ctx, payload.WalletID, eventID, 0); err != nil {
+			return err
+		}
+
+		if eventType == tasks.TopicHoldCaptured {
+			if err := s.publishBalance(ctx, payload.PayeeWalletID, eventID, 1); err != nil {
+				return err
+			}
+		}
+	case tasks.TopicDepositSucceeded:
+		var payload tasks.DepositEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		if err := s.publishBalance(ctx, payload.WalletID, eventID, 0); err != nil {
+			return err
+		}
+	case tasks.TopicWithdrawalCreated, tasks.TopicWithdrawalCompleted, tasks.TopicWithdrawalFailed, tasks.TopicWithdrawalCancelled:
+		var payload tasks.WithdrawalEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		// Withdrawals hold their amount until they settle.
+		if err := s.publishBalance(ctx, payload.WalletID, eventID, 0); err != nil {
+			return err
+		}
+	case tasks.TopicPayoutBatchCreated, tasks.TopicPayoutBatchCompleted:
+		var payload tasks.PayoutBatchEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		// A batch holds its total when it is created; by the time it
+		// completes, what failed items held has been released.
+		if err := s.publishBalance(ctx, payload.WalletID, eventID, 0); err != nil {
+			return err
+		}
+	case tasks.TopicEscrowFunded, tasks.TopicEscrowReleased, tasks.TopicEscrowRefunded:
+		var payload tasks.EscrowEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		// Funding and refunds change the payer's balance; a release
+		// credits the payee.
+		walletID := payload.WalletID
+		if eventType == tasks.TopicEscrowReleased {
+			walletID = payload.PayeeWalletID
+		}
+
+		if err := s.publishBalance(ctx, walletID, eventID, 0); err != nil {
+			return err
+		}
+	case tasks.TopicDisputeOpened, tasks.TopicDisputeResolved, tasks.TopicDisputeWithdrawn:
+		var payload tasks.DisputeEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		// Opening a dispute debits the merchant and credits the payer on
+		// hold; resolving it releases the hold and may move the amount
+		// back.
+		for i, walletID := range []int64{payload.PayerWalletID, payload.MerchantWalletID} {
+			if err := s.publishBalance(ctx, walletID, eventID, i); err != nil {
+				return err
+			}
+		}
+	case tasks.TopicVoucherRedeemed:
+		var payload tasks.VoucherRedeemedEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return err
+		}
+
+		if err := s.publishBalance(ctx, payload.WalletID, eventID, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publishToWalletOwner sends event to the owner of walletID as part number
+// part, followed by a balance update as part+1 when withBalance is set.
+func (s *streamService) publishToWalletOwner(ctx context.Context, walletID, eventID int64, part int, eventType string, event any, withBalance bool) error {
+	wallet, err := s.store.GetWalletByID(ctx, walletID)
+	if err != nil || wallet == nil {
+		return err
+	}
+
+	if err := s.publish(ctx, wallet.UserID, eventID, part, eventType, event); err != nil {
+		return err
+	}
+
+	if !withBalance {
+		return nil
+	}
+
+	return s.publish(ctx, wallet.UserID, eventID, part+1, StreamEventWalletBalance, wallet)
+}
+
+func (s *streamService) publishBalance(ctx context.Context, walletID, eventID int64, part int) error {
+	wallet, err := s.store.GetWalletByID(ctx, walletID)
+	if err != nil || wallet == nil {
+		return err
+	}
+
+	return s.publish(ctx, wallet.UserID, eventID, part, StreamEventWalletBalance, wallet)
+}
+
+func (s *streamService) publish(ctx context.Context, userID, eventID int64, part int, eventType string, event any) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.broker.Publish(ctx, userID, domain.StreamEvent{
+		ID:   fmt.Sprintf("%d-%d", eventID, part),
+		Type: eventType,
+		Data: data,
+	})
+}
+
+func (s *streamService) Subscribe(ctx context.Context, userID int64, lastEventID string) (<-chan domain.StreamEvent, error) {
+	return s.broker.Subscribe(ctx, userID, lastEventID)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/pubsub"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+// streamedEvents returns the events in a user's backlog.
+func streamedEvents(t *testing.T, broker domain.EventBroker, userID int64) []domain.StreamEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := broker.Subscribe(ctx, userID, "")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	var got []domain.StreamEvent
+	for {
+		select {
+		case event := <-events:
+			got = append(got, event)
+		case <-time.After(50 * time.Millisecond):
+			return got
+		}
+	}
+}
+
+func transferEvent(t *testing.T, status string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(tasks.TransferEventPayload{
+		TransactionID:    9,
+		SenderWalletID:   walletID(1),
+		ReceiverWalletID: walletID(2),
+		Amount:           decimal.RequireFromString("10.00"),
+		Fee:              decimal.RequireFromString("0.50"),
+		Status:           status,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestPublishCompletedTransfer(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "90.00")
+	store.addUser(2, "9.50")
+	broker := pubsub.NewMemoryBroker()
+	svc := NewStreamService(store, broker)
+
+	if err := svc.PublishEvent(context.Background(), 42, tasks.TopicTransferCompleted, transferEvent(t, "COMPLETED")); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+
+	tests := []struct {
+		userID    int64
+		firstID   string
+		direction string
+		amount    string
+	}{
+		{userID: 1, firstID: "42-0", direction: "debit", amount: "10"},
+		{userID: 2, firstID: "42-2", direction: "credit", amount: "9.5"},
+	}
+
+	for _, tt := range tests {
+		events := streamedEvents(t, broker, tt.userID)
+		if len(events) != 2 || events[0].Type != StreamEventTransferStatus || events[1].Type != StreamEventWalletBalance {
+			t.Fatalf("user %d events = %+v, want a transfer status then a balance", tt.userID, events)
+		}
+
+		if events[0].ID != tt.firstID {
+			t.Errorf("user %d first event ID = %s, want %s", tt.userID, events[0].ID, tt.firstID)
+		}
+
+		var status transferStatusEvent
+		if err := json.Unmarshal(events[0].Data, &status); err != nil {
+			t.Fatal(err)
+		}
+
+		if status.Direction != tt.direction || status.Amount.String() != tt.amount || status.TransactionID != 9 {
+			t.Errorf("user %d transfer event = %+v, want %s of %s", tt.userID, status, tt.direction, tt.amount)
+		}
+	}
+}
+
+func TestPublishFailedTransferOnlyNotifiesSender(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "5.00")
+	store.addUser(2, "0")
+	broker := pubsub.NewMemoryBroker()
+	svc := NewStreamService(store, broker)
+
+	if err := svc.PublishEvent(context.Background(), 42, tasks.TopicTransferFailed, transferEvent(t, "FAILED")); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+
+	// The sender's balance did not change, so only the status is sent.
+	if events := streamedEvents(t, broker, 1); len(events) != 1 || events[0].Type != StreamEventTransferStatus {
+		t.Errorf("sender events = %+v, want one transfer status", events)
+	}
+
+	if events := streamedEvents(t, broker, 2); len(events) != 0 {
+		t.Errorf("receiver events = %+v, want none", events)
+	}
+}
+
+func TestPublishEventIgnoresOtherTopics(t *testing.T) {
+	store := newLedgerStore()
+	store.addUser(1, "5.00")
+	broker := pubsub.NewMemoryBroker()
+
+	if err := NewStreamService(store, broker).PublishEvent(context.Background(), 1, tasks.TopicPaymentRequestCreated, []byte(`{}`)); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+
+	if events := streamedEvents(t, broker, 1); len(events) != 0 {
+		t.Errorf("events = %+v, want none", events)
+	}
+}
@@ -2,7 +2,7 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 
 	"github.com/amankp-zop/wallet/internal/domain"
 	"github.com/amankp-zop/wallet/internal/repository"
@@ -10,6 +10,14 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+var (
+	ErrInvalidAmount       = errors.New("amount must be greater than zero")
+	ErrSelfTransfer        = errors.New("cannot transfer to your own wallet")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrInsufficientFunds   = errors.New("insufficient available balance")
+	ErrCurrencyMismatch    = errors.New("wallet currencies do not match")
+)
+
 type transactionService struct {
 	store repository.Store
 }
@@ -20,52 +28,275 @@ func NewTransactionService(store repository.Store) domain.TransactionService {
 	}
 }
 
-func (s *transactionService) CreateTransfer(ctx context.Context, senderUserID, receiverUserID int64, amount decimal.Decimal) (*domain.Transaction, error){
+func (s *transactionService) CreateTransfer(ctx context.Context, senderUserID, receiverUserID int64, amount decimal.Decimal) (*domain.Transaction, error) {
 	var createdTx *domain.Transaction
 
 	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
-		senderWallet, err := s.store.GetByUserID(ctx, senderUserID);
+		tx, err := createTransfer(ctx, q, senderUserID, receiverUserID, amount, "")
 		if err != nil {
 			return err
 		}
 
-		receiverWallet, err := s.store.GetByUserID(ctx, receiverUserID);
+		createdTx = tx
+		return nil
+	})
+
+	return createdTx, err
+}
+
+// createTransfer records a PENDING transaction and its outbox event using q, so
+// that callers already inside a database transaction can create transfers
+// atomically with their own writes. The transfer's fee is fixed here.
+func createTransfer(ctx context.Context, q *repository.Queries, senderUserID, receiverUserID int64, amount decimal.Decimal, memo string) (*domain.Transaction, error) {
+	senderWallet, receiverWallet, err := transferWallets(ctx, q, senderUserID, receiverUserID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := quoteTransferFee(ctx, q, receiverUserID, receiverWallet, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &domain.Transaction{
+		SenderWalletID:   senderWallet.ID,
+		ReceiverWalletID: receiverWallet.ID,
+		Amount:           amount,
+		Fee:              quote.Fee,
+		Memo:             memo,
+		FeeWalletID:      quote.FeeWalletID,
+		Status:           domain.TransactionStatusPending,
+	}
+
+	err = q.CreateTransaction(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := tasks.ProcessTransferPayload{
+		TransactionID: tx.ID,
+	}
+	if err := publishEvent(ctx, q, tasks.TaskTypeProcessTransfer, payload); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// transferWallets checks that senderUserID may send amount to receiverUserID
+// and returns both wallets.
+func transferWallets(ctx context.Context, q *repository.Queries, senderUserID, receiverUserID int64, amount decimal.Decimal) (*domain.Wallet, *domain.Wallet, error) {
+	if !amount.IsPositive() {
+		return nil, nil, ErrInvalidAmount
+	}
+
+	if senderUserID == receiverUserID {
+		return nil, nil, ErrSelfTransfer
+	}
+
+	senderWallet, err := q.GetByUserID(ctx, senderUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	receiverWallet, err := q.GetByUserID(ctx, receiverUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if senderWallet == nil || receiverWallet == nil {
+		return nil, nil, ErrWalletNotFound
+	}
+
+	// Erased users can no longer log in to spend what they receive.
+	receiver, err := q.GetByID(ctx, receiverUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if receiver == nil || receiver.ErasedAt != nil {
+		return nil, nil, ErrWalletNotFound
+	}
+
+	return senderWallet, receiverWallet, nil
+}
+
+// ProcessTransfer settles a PENDING transaction. It is idempotent: transactions
+// that are already settled are left untouched.
+func (s *transactionService) ProcessTransfer(ctx context.Context, transactionID int64) error {
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		tx, err := q.GetTransactionForUpdate(ctx, transactionID)
 		if err != nil {
 			return err
 		}
 
-		tx := &domain.Transaction{
-			SenderWalletID: senderWallet.ID,
-			ReceiverWalletID: receiverWallet.ID,
-			Amount: amount,
-			Status: domain.TransactionStatusPending,
+		if tx == nil {
+			return ErrTransactionNotFound
 		}
 
-		err = s.store.CreateTransaction(ctx, tx)
+		if tx.Status != domain.TransactionStatusPending {
+			return nil
+		}
+
+		sender, receiver, err := lockWalletPair(ctx, q, tx.SenderWalletID, tx.ReceiverWalletID)
 		if err != nil {
 			return err
 		}
 
-		payload := tasks.ProcessTransferPayload{
-			TransactionID: tx.ID,
+		switch {
+		case sender.Currency != receiver.Currency:
+			return failTransfer(ctx, q, tx, ErrCurrencyMismatch.Error())
+		case sender.AvailableBalance.LessThan(tx.Amount):
+			return failTransfer(ctx, q, tx, ErrInsufficientFunds.Error())
+		}
+
+		return completeTransfer(ctx, q, tx)
+	})
+}
+
+// ListHistory returns the payments into and out of the user's wallet, newest
+// first. A split payment the user sent is one entry holding all of its legs.
+func (s *transactionService) ListHistory(ctx context.Context, userID int64) ([]*domain.HistoryEntry, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	transactions, err := s.store.ListTransactionsByWallet(ctx, wallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*domain.HistoryEntry
+	payments := make(map[int64]*domain.SplitPayment)
+
+	for _, tx := range transactions {
+		if tx.SplitPaymentID == nil || tx.SenderWalletID != wallet.ID {
+			entries = append(entries, &domain.HistoryEntry{Type: domain.HistoryEntryTypeTransfer, Transaction: tx})
+			continue
+		}
+
+		if payment, ok := payments[*tx.SplitPaymentID]; ok {
+			// Transactions are listed newest first; legs are kept in order.
+			payment.Legs = append([]*domain.Transaction{tx}, payment.Legs...)
+			continue
 		}
-		payloadBytes, err:= json.Marshal(payload);
+
+		payment, err := s.store.GetSplitPaymentByID(ctx, *tx.SplitPaymentID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		outboxEvent := &domain.Outbox{
-			Topic: tasks.TaskTypeProcessTransfer,
-			Payload: payloadBytes,
+		if payment == nil {
+			return nil, ErrSplitPaymentNotFound
+		}
+
+		payment.Legs = []*domain.Transaction{tx}
+		payments[payment.ID] = payment
+		entries = append(entries, &domain.HistoryEntry{Type: domain.HistoryEntryTypeSplitPayment, SplitPayment: payment})
+	}
+
+	return entries, nil
+}
+
+// lockWalletPair locks both wallets in ID order so that concurrent transfers in
+// opposite directions cannot deadlock.
+func lockWalletPair(ctx context.Context, q *repository.Queries, firstID, secondID int64) (*domain.Wallet, *domain.Wallet, error) {
+	lowID, highID := firstID, secondID
+	if lowID > highID {
+		lowID, highID = highID, lowID
+	}
+
+	low, err := q.GetWalletForUpdate(ctx, lowID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	high, err := q.GetWalletForUpdate(ctx, highID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if low == nil || high == nil {
+		return nil, nil, ErrWalletNotFound
+	}
+
+	if lowID == firstID {
+		return low, high, nil
+	}
+
+	return high, low, nil
+}
+
+// completeTransfer posts tx to the sender, the receiver and the fee wallet,
+// records each leg in the ledger and marks tx COMPLETED, completing any
+// checkout session it pays and scheduled transfer run that created it. The caller must hold locks on the sender and
+// receiver wallets and have checked the sender's funds.
+func completeTransfer(ctx context.Context, q *repository.Queries, tx *domain.Transaction) error {
+	legs := []domain.LedgerEntry{
+		{WalletID: tx.SenderWalletID, Type: domain.LedgerEntryTypePrincipal, Amount: tx.Amount.Neg()},
+		{WalletID: tx.ReceiverWalletID, Type: domain.LedgerEntryTypePrincipal, Amount: tx.Amount.Sub(tx.Fee)},
+	}
+	if tx.Fee.IsPositive() && tx.FeeWalletID != nil {
+		legs = append(legs, domain.LedgerEntry{WalletID: *tx.FeeWalletID, Type: domain.LedgerEntryTypeFee, Amount: tx.Fee})
+	}
+
+	for _, leg := range legs {
+		if err := q.AdjustWalletBalance(ctx, leg.WalletID, leg.Amount); err != nil {
+			return err
 		}
 
-		if err := q.CreateOutbox(ctx, outboxEvent); err!=nil{
+		leg.TransactionID = &tx.ID
+		if err := q.CreateLedgerEntry(ctx, &leg); err != nil {
 			return err
 		}
+	}
 
-		createdTx = tx
-		return nil
-	})
+	tx.Status = domain.TransactionStatusCompleted
+	if err := q.UpdateTransactionStatus(ctx, tx.ID, tx.Status); err != nil {
+		return err
+	}
 
-	return createdTx, err
-}
\ No newline at end of file
+	if err := publishTransferEvent(ctx, q, tasks.TopicTransferCompleted, tx, ""); err != nil {
+		return err
+	}
+
+	if err := settleScheduledTransferRun(ctx, q, tx, ""); err != nil {
+		return err
+	}
+
+	return settleCheckoutSession(ctx, q, tx, "")
+}
+
+func failTransfer(ctx context.Context, q *repository.Queries, tx *domain.Transaction, reason string) error {
+	tx.Status = domain.TransactionStatusFailed
+	if err := q.UpdateTransactionStatus(ctx, tx.ID, tx.Status); err != nil {
+		return err
+	}
+
+	if err := publishTransferEvent(ctx, q, tasks.TopicTransferFailed, tx, reason); err != nil {
+		return err
+	}
+
+	if err := settleScheduledTransferRun(ctx, q, tx, reason); err != nil {
+		return err
+	}
+
+	return settleCheckoutSession(ctx, q, tx, reason)
+}
+
+func publishTransferEvent(ctx context.Context, q *repository.Queries, topic string, tx *domain.Transaction, reason string) error {
+	return publishEvent(ctx, q, topic, tasks.TransferEventPayload{
+		TransactionID:    tx.ID,
+		SenderWalletID:   tx.SenderWalletID,
+		ReceiverWalletID: tx.ReceiverWalletID,
+		Amount:           tx.Amount,
+		Fee:              tx.Fee,
+		Status:           string(tx.Status),
+		Reason:           reason,
+	})
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/auth"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidTwoFactorToken   = errors.New("invalid or expired two-factor token")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor enrolment has not been started")
+)
+
+const (
+	totpIssuer = "Wallet"
+	// twoFactorTokenTTL is how long a user has to enter their code after the
+	// password step of a login.
+	twoFactorTokenTTL  = 5 * time.Minute
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// CompleteTwoFactorLogin finishes a login that returned TwoFactorRequired.
+// code is a current TOTP code or an unused recovery code; a wrong code counts
+// as a failed login.
+func (s *userService) CompleteTwoFactorLogin(ctx context.Context, twoFactorToken, code string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	claims, err := auth.Parse(s.jwtSecret, twoFactorToken)
+	if err != nil || claims.Type != auth.TypeTwoFactor {
+		return nil, ErrInvalidTwoFactorToken
+	}
+
+	user, err := s.store.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || !user.TwoFactorEnabled {
+		return nil, ErrInvalidTwoFactorToken
+	}
+
+	now := time.Now().UTC()
+	accountSubject := loginSubject(user.Email)
+
+	if err := checkLoginLock(ctx, s.store, now, accountSubject, client.IP); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code, now, accountSubject, client); err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, user, accountSubject, client)
+}
+
+// EnrollTOTP generates a new secret for the user. It only takes effect once
+// ConfirmTOTP proves the authenticator app produces matching codes.
+func (s *userService) EnrollTOTP(ctx context.Context, userID int64) (*domain.TOTPEnrollment, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UpdateUserTOTP(ctx, userID, secret, false); err != nil {
+		return nil, err
+	}
+
+	return &domain.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication if code matches the enrolled
+// secret and returns the user's recovery codes. Only their hashes are stored,
+// so they cannot be shown again.
+func (s *userService) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		if err := q.UpdateUserTOTP(ctx, userID, user.TOTPSecret, true); err != nil {
+			return err
+		}
+
+		if _, err := q.AdvanceUserTOTPStep(ctx, userID, step); err != nil {
+			return err
+		}
+
+		return q.ReplaceRecoveryCodes(ctx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off and discards the secret and
+// recovery codes. code is a TOTP or recovery code.
+func (s *userService) DisableTOTP(ctx context.Context, userID int64, code string, client domain.ClientInfo) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	now := time.Now().UTC()
+	accountSubject := loginSubject(user.Email)
+
+	if err := checkLoginLock(ctx, s.store, now, accountSubject, client.IP); err != nil {
+		return err
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code, now, accountSubject, client); err != nil {
+		return err
+	}
+
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		if err := q.UpdateUserTOTP(ctx, userID, "", false); err != nil {
+			return err
+		}
+
+		return q.DeleteRecoveryCodes(ctx, userID)
+	})
+}
+
+// StepUp re-authenticates a logged-in user and returns an access token
+// carrying a step-up time, which sensitive operations require to be recent.
+// Users with two-factor authentication prove themselves with code, others
+// with their password. Failures count as failed logins.
+func (s *userService) StepUp(ctx context.Context, userID int64, code, password string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	accountSubject := loginSubject(user.Email)
+
+	if err := checkLoginLock(ctx, s.store, now, accountSubject, client.IP); err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		if err := s.verifySecondFactor(ctx, user, code, now, accountSubject, client); err != nil {
+			return nil, err
+		}
+	} else if err := s.verifyPassword(ctx, user, password, now, accountSubject, client); err != nil {
+		return nil, err
+	}
+
+	token, err := auth.Sign(s.jwtSecret, auth.Claims{UserID: user.ID, Type: auth.TypeAccess, StepUpAt: now}, s.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginResult{Token: token}, nil
+}
+
+// VerifyPassword checks the caller's password before a sensitive change that
+// is not otherwise re-authenticated. A wrong password counts as a failed
+// login.
+func (s *userService) VerifyPassword(ctx context.Context, userID int64, password string, client domain.ClientInfo) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	accountSubject := loginSubject(user.Email)
+
+	if err := checkLoginLock(ctx, s.store, now, accountSubject, client.IP); err != nil {
+		return err
+	}
+
+	return s.verifyPassword(ctx, user, password, now, accountSubject, client)
+}
+
+// verifyPassword returns ErrInvalidCredentials, after recording a failed
+// login, unless password is the user's.
+func (s *userService) verifyPassword(ctx context.Context, user *domain.User, password string, now time.Time, accountSubject string, client domain.ClientInfo) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			return recordLoginFailure(ctx, q, now, user, accountSubject, client)
+		})
+		if err != nil {
+			return err
+		}
+
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// verifySecondFactor accepts a TOTP code for a step not used before or an
+// unused recovery code, which it consumes. Anything else is recorded as a
+// failed login and returns ErrInvalidTwoFactorCode.
+func (s *userService) verifySecondFactor(ctx context.Context, user *domain.User, code string, now time.Time, accountSubject string, client domain.ClientInfo) error {
+	code = strings.TrimSpace(code)
+
+	var ok bool
+	var err error
+	if step, valid := totp.Validate(user.TOTPSecret, code, now); valid && user.TOTPSecret != "" {
+		ok, err = s.store.AdvanceUserTOTPStep(ctx, user.ID, step)
+	} else if code != "" {
+		ok, err = s.store.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+	}
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return nil
+	}
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		return recordLoginFailure(ctx, q, now, user, accountSubject, client)
+	})
+	if err != nil {
+		return err
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+// newRecoveryCode returns a random code formatted as two dash-separated
+// groups for readability.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeLength*5/8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := recoveryCodeEncoding.EncodeToString(b)
+	return code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:], nil
+}
+
+// hashRecoveryCode normalises case and separators before hashing so codes can
+// be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalised := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalised))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/totp"
+)
+
+type twoFactorStore struct {
+	repository.Store
+	lastStep      int64
+	loginFailures int
+}
+
+// AdvanceUserTOTPStep mirrors the repository's conditional update, which
+// only moves the step forward.
+func (s *twoFactorStore) AdvanceUserTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	if step <= s.lastStep {
+		return false, nil
+	}
+
+	s.lastStep = step
+	return true, nil
+}
+
+func (s *twoFactorStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	return false, nil
+}
+
+func (s *twoFactorStore) CreateLoginEvent(ctx context.Context, event *domain.LoginEvent) error {
+	s.loginFailures++
+	return nil
+}
+
+func (s *twoFactorStore) GetLoginThrottleForUpdate(ctx context.Context, scope domain.LoginThrottleScope, subject string) (*domain.LoginThrottle, error) {
+	return nil, nil
+}
+
+func (s *twoFactorStore) UpsertLoginThrottle(ctx context.Context, throttle *domain.LoginThrottle) error {
+	return nil
+}
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestVerifySecondFactorRejectsReusedCode(t *testing.T) {
+	store := &twoFactorStore{}
+	svc := &userService{store: txStore{store}}
+	user := &domain.User{ID: 1, Email: "user@example.com", TOTPSecret: testTOTPSecret, TwoFactorEnabled: true}
+	client := domain.ClientInfo{IP: "203.0.113.1"}
+	now := time.Unix(1111111111, 0)
+
+	code, err := totp.Code(testTOTPSecret, totp.Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.verifySecondFactor(context.Background(), user, code, now, "subject", client); err != nil {
+		t.Fatalf("first use: verifySecondFactor() error = %v", err)
+	}
+
+	err = svc.verifySecondFactor(context.Background(), user, code, now.Add(time.Second), "subject", client)
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("reuse: verifySecondFactor() error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	if store.loginFailures != 1 {
+		t.Errorf("login failures recorded = %d, want 1", store.loginFailures)
+	}
+}
+
+func TestVerifySecondFactorRejectsEarlierStep(t *testing.T) {
+	store := &twoFactorStore{}
+	svc := &userService{store: txStore{store}}
+	user := &domain.User{ID: 1, Email: "user@example.com", TOTPSecret: testTOTPSecret, TwoFactorEnabled: true}
+	now := time.Unix(1111111111, 0)
+
+	current, err := totp.Code(testTOTPSecret, totp.Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := totp.Code(testTOTPSecret, totp.Step(now)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.verifySecondFactor(context.Background(), user, current, now, "subject", domain.ClientInfo{}); err != nil {
+		t.Fatalf("verifySecondFactor() error = %v", err)
+	}
+
+	// The previous step is inside the drift window but older than the code
+	// already accepted.
+	err = svc.verifySecondFactor(context.Background(), user, previous, now, "subject", domain.ClientInfo{})
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("verifySecondFactor() error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+}
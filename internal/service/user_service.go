@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
+	"strings"
 	"time"
 
+	"github.com/amankp-zop/wallet/internal/auth"
 	"github.com/amankp-zop/wallet/internal/domain"
 	"github.com/amankp-zop/wallet/internal/repository"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/shopspring/decimal"
 
 	"golang.org/x/crypto/bcrypt"
@@ -21,13 +23,19 @@ type userService struct {
 	store     repository.Store
 	jwtSecret string
 	tokenTTL  time.Duration
+	mailer    domain.Mailer
+	appURL    string
 }
 
-func NewUserService(store repository.Store, jwtsecret string) domain.UserService {
+// NewUserService returns the user service. appURL is the base URL of the
+// links in the emails it sends.
+func NewUserService(store repository.Store, jwtsecret string, mailer domain.Mailer, appURL string) domain.UserService {
 	return &userService{
 		store:     store,
 		jwtSecret: jwtsecret,
 		tokenTTL:  24 * time.Hour,
+		mailer:    mailer,
+		appURL:    strings.TrimSuffix(appURL, "/"),
 	}
 }
 
@@ -77,37 +85,79 @@ func (s *userService) Signup(ctx context.Context, name, email, password string)
 		return nil, err
 	}
 
+	// The account works without a verified email, so a failed send only
+	// means the user has to ask for another link.
+	if err := s.sendEmailVerification(ctx, user); err != nil {
+		log.Printf("Error sending verification email to user %d: %v", user.ID, err)
+	}
+
 	return user, nil
 }
 
-func (s *userService) Login(ctx context.Context, email, password string) (string, error){
+// Login verifies the credentials and returns a signed JWT, or a two-factor
+// token to pass to CompleteTwoFactorLogin if the user has two-factor
+// authentication enabled. Unknown emails and wrong passwords both return
+// ErrInvalidCredentials after a bcrypt comparison, so callers cannot tell them
+// apart by response or timing. Failures count towards lockouts of the account
+// and of client.IP.
+func (s *userService) Login(ctx context.Context, email, password string, client domain.ClientInfo) (*domain.LoginResult, error){
+	now := time.Now().UTC()
+	accountSubject := loginSubject(email)
+
+	if err := checkLoginLock(ctx, s.store, now, accountSubject, client.IP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.store.GetByEmail(ctx, email)
 	if err!=nil{
-		return "", err
+		return nil, err
 	}
 
-	if user == nil{
-		return "", ErrUserNotFound
+	hash := dummyPasswordHash()
+	if user != nil {
+		hash = []byte(user.Password)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password),[]byte(password))
-	if err != nil{
-		return "", ErrInvalidCredentials
+	err = bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if err != nil || user == nil{
+		err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+			return recordLoginFailure(ctx, q, now, user, accountSubject, client)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidCredentials
 	}
 
-	claims := jwt.MapClaims{
-		"sub": user.ID,
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(s.tokenTTL).Unix(),
+	// The login only counts as successful once the second factor is in.
+	if user.TwoFactorEnabled {
+		token, err := auth.Sign(s.jwtSecret, auth.Claims{UserID: user.ID, Type: auth.TypeTwoFactor}, twoFactorTokenTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		return &domain.LoginResult{TwoFactorRequired: true, TwoFactorToken: token}, nil
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(s.jwtSecret))
-	if err != nil{
-		return "", err
+	return s.completeLogin(ctx, user, accountSubject, client)
+}
+
+// completeLogin records a successful login and issues the access token.
+func (s *userService) completeLogin(ctx context.Context, user *domain.User, accountSubject string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		return recordLoginSuccess(ctx, q, user, accountSubject, client)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.Sign(s.jwtSecret, auth.Claims{UserID: user.ID, Type: auth.TypeAccess}, s.tokenTTL)
+	if err != nil {
+		return nil, err
 	}
 
-	return signedToken, nil
+	return &domain.LoginResult{Token: token}, nil
 }
 
 func (s *userService) GetProfile(ctx context.Context, userID int64) (*domain.User, error){
@@ -121,4 +171,9 @@ func (s *userService) GetProfile(ctx context.Context, userID int64) (*domain.Use
 	}
 
 	return user, nil
-}
\ No newline at end of file
+}
+
+// LoginHistory returns the user's most recent login attempts, newest first.
+func (s *userService) LoginHistory(ctx context.Context, userID int64) ([]*domain.LoginEvent, error) {
+	return s.store.ListLoginEventsByUser(ctx, userID, loginHistoryLimit)
+}
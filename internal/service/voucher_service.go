@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/amankp-zop/wallet/internal/voucher"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrVoucherBatchNotFound   = errors.New("voucher batch not found")
+	ErrInvalidVoucherBatch    = errors.New("voucher batch is not valid")
+	ErrPromoWalletNotFound    = errors.New("no promo wallet is configured for the currency")
+	ErrVoucherNotFound        = errors.New("voucher code is not valid")
+	ErrVoucherInactive        = errors.New("voucher has expired or been withdrawn")
+	ErrVoucherUsedUp          = errors.New("voucher has been fully redeemed")
+	ErrVoucherAlreadyRedeemed = errors.New("you have already redeemed this voucher")
+	ErrVoucherLimitReached    = errors.New("you have redeemed as many vouchers of this promotion as allowed")
+	ErrPromoFundsExhausted    = errors.New("promotion has run out of funds")
+)
+
+const (
+	MaxVoucherCodesPerBatch = 10000
+	maxVoucherRedemptions   = 1000000
+)
+
+type voucherService struct {
+	store repository.Store
+}
+
+func NewVoucherService(store repository.Store) domain.VoucherService {
+	return &voucherService{
+		store: store,
+	}
+}
+
+// CreateVoucherBatch generates codeCount codes worth amount each, redeemable
+// until expiresAt from the promo wallet of currency. The codes are only
+// returned here; afterwards just their hashes are kept. The promo wallet is
+// not debited until codes are redeemed, so it must be kept funded.
+func (s *voucherService) CreateVoucherBatch(ctx context.Context, adminUserID int64, name string, amount decimal.Decimal, currency string, expiresAt time.Time, maxRedemptions, perUserLimit, codeCount int) (*domain.VoucherBatch, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidVoucherBatch, fmt.Sprintf(format, args...))
+	}
+
+	switch {
+	case !amount.IsPositive() || !amount.Equal(amount.Round(2)):
+		return nil, invalid("amount must be positive with at most 2 decimal places")
+	case !expiresAt.After(time.Now()):
+		return nil, invalid("expiry must be in the future")
+	case codeCount < 1 || codeCount > MaxVoucherCodesPerBatch:
+		return nil, invalid("between 1 and %d codes can be generated", MaxVoucherCodesPerBatch)
+	case maxRedemptions < 1 || maxRedemptions > maxVoucherRedemptions:
+		return nil, invalid("each code must allow between 1 and %d redemptions", maxVoucherRedemptions)
+	case perUserLimit < 1:
+		return nil, invalid("per-user limit must be at least 1")
+	}
+
+	codes := make([]string, codeCount)
+	for i := range codes {
+		code, err := voucher.NewCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+
+	var created *domain.VoucherBatch
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		promoWalletID, ok, err := q.GetPromoWalletID(ctx, currency)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return ErrPromoWalletNotFound
+		}
+
+		batch := &domain.VoucherBatch{
+			Name:            name,
+			Amount:          amount,
+			Currency:        currency,
+			PromoWalletID:   promoWalletID,
+			ExpiresAt:       expiresAt.UTC().Truncate(time.Second),
+			MaxRedemptions:  maxRedemptions,
+			PerUserLimit:    perUserLimit,
+			CodeCount:       codeCount,
+			RedeemedAmount:  decimal.Zero,
+			Status:          domain.VoucherBatchStatusActive,
+			CreatedByUserID: adminUserID,
+		}
+		if err := q.CreateVoucherBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		for _, code := range codes {
+			normalized, err := voucher.Normalize(code)
+			if err != nil {
+				return err
+			}
+
+			if err := q.CreateVoucher(ctx, &domain.Voucher{
+				BatchID:  batch.ID,
+				CodeHash: voucher.Hash(normalized),
+				CodeHint: voucher.Hint(normalized),
+			}); err != nil {
+				return err
+			}
+		}
+
+		created = batch
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.GetVoucherBatch(ctx, created.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch.Codes = codes
+	return batch, nil
+}
+
+func (s *voucherService) ListVoucherBatches(ctx context.Context) ([]*domain.VoucherBatch, error) {
+	return s.store.ListVoucherBatches(ctx)
+}
+
+func (s *voucherService) GetVoucherBatch(ctx context.Context, id int64) (*domain.VoucherBatch, error) {
+	batch, err := s.store.GetVoucherBatchByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if batch == nil {
+		return nil, ErrVoucherBatchNotFound
+	}
+
+	return batch, nil
+}
+
+// DisableVoucherBatch stops the batch's codes from being redeemed. Credit
+// already given is kept. Disabling a disabled batch does nothing.
+func (s *voucherService) DisableVoucherBatch(ctx context.Context, id int64) (*domain.VoucherBatch, error) {
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		batch, err := q.GetVoucherBatchForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if batch == nil {
+			return ErrVoucherBatchNotFound
+		}
+
+		batch.Status = domain.VoucherBatchStatusDisabled
+		return q.UpdateVoucherBatch(ctx, batch)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetVoucherBatch(ctx, id)
+}
+
+// GetVoucherBatchReport returns the batch's redemption totals with a
+// breakdown per day.
+func (s *voucherService) GetVoucherBatchReport(ctx context.Context, id int64) (*domain.VoucherBatchReport, error) {
+	batch, err := s.GetVoucherBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.VoucherBatchReport{Batch: batch}
+
+	if report.CodesRedeemed, report.UniqueRedeemers, err = s.store.GetVoucherBatchRedeemerCounts(ctx, batch.ID); err != nil {
+		return nil, err
+	}
+
+	if report.Days, err = s.store.ListVoucherRedemptionDays(ctx, batch.ID); err != nil {
+		return nil, err
+	}
+	report.Days = emptyIfNil(report.Days)
+
+	return report, nil
+}
+
+// RedeemVoucher credits the user's wallet with the voucher's full amount from
+// the promo wallet; redemptions are not charged a fee. The voucher and its
+// batch are locked for the whole redemption, so concurrent attempts cannot
+// take a code past its redemption limit, a user past the batch's per-user
+// limit or the promo wallet below zero; a unique key on the voucher and user
+// backs this up.
+func (s *voucherService) RedeemVoucher(ctx context.Context, userID int64, code string) (*domain.VoucherRedemption, error) {
+	normalized, err := voucher.Normalize(code)
+	if err != nil {
+		return nil, ErrVoucherNotFound
+	}
+
+	var redemption *domain.VoucherRedemption
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		v, err := q.GetVoucherByCodeHashForUpdate(ctx, voucher.Hash(normalized))
+		if err != nil {
+			return err
+		}
+
+		if v == nil {
+			return ErrVoucherNotFound
+		}
+
+		batch, err := q.GetVoucherBatchForUpdate(ctx, v.BatchID)
+		if err != nil {
+			return err
+		}
+
+		if batch == nil {
+			return ErrVoucherNotFound
+		}
+
+		if batch.Status != domain.VoucherBatchStatusActive || !time.Now().Before(batch.ExpiresAt) {
+			return ErrVoucherInactive
+		}
+
+		wallet, err := q.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case wallet == nil:
+			return ErrWalletNotFound
+		case wallet.ID == batch.PromoWalletID:
+			return ErrSelfTransfer
+		case wallet.Currency != batch.Currency:
+			return ErrCurrencyMismatch
+		}
+
+		existing, err := q.GetVoucherRedemption(ctx, v.ID, userID)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return ErrVoucherAlreadyRedeemed
+		}
+
+		if v.RedemptionCount >= batch.MaxRedemptions {
+			return ErrVoucherUsedUp
+		}
+
+		redeemed, err := q.CountVoucherRedemptionsByUser(ctx, batch.ID, userID)
+		if err != nil {
+			return err
+		}
+
+		if redeemed >= batch.PerUserLimit {
+			return ErrVoucherLimitReached
+		}
+
+		wallets, err := lockWallets(ctx, q, batch.PromoWalletID, wallet.ID)
+		if err != nil {
+			return err
+		}
+
+		if wallets[batch.PromoWalletID].AvailableBalance.LessThan(batch.Amount) {
+			return ErrPromoFundsExhausted
+		}
+
+		redemption = &domain.VoucherRedemption{
+			VoucherID: v.ID,
+			BatchID:   batch.ID,
+			UserID:    userID,
+			WalletID:  wallet.ID,
+			Amount:    batch.Amount,
+			Currency:  batch.Currency,
+		}
+		if err := q.CreateVoucherRedemption(ctx, redemption); err != nil {
+			return err
+		}
+
+		legs := []domain.LedgerEntry{
+			{WalletID: batch.PromoWalletID, Type: domain.LedgerEntryTypeVoucher, Amount: batch.Amount.Neg()},
+			{WalletID: wallet.ID, Type: domain.LedgerEntryTypeVoucher, Amount: batch.Amount},
+		}
+
+		for _, leg := range legs {
+			if err := q.AdjustWalletBalance(ctx, leg.WalletID, leg.Amount); err != nil {
+				return err
+			}
+
+			leg.VoucherRedemptionID = &redemption.ID
+			if err := q.CreateLedgerEntry(ctx, &leg); err != nil {
+				return err
+			}
+		}
+
+		v.RedemptionCount++
+		if err := q.UpdateVoucher(ctx, v); err != nil {
+			return err
+		}
+
+		batch.RedemptionCount++
+		batch.RedeemedAmount = batch.RedeemedAmount.Add(batch.Amount)
+		if err := q.UpdateVoucherBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		return publishEvent(ctx, q, tasks.TopicVoucherRedeemed, tasks.VoucherRedeemedEventPayload{
+			RedemptionID: redemption.ID,
+			VoucherID:    v.ID,
+			BatchID:      batch.ID,
+			UserID:       userID,
+			WalletID:     wallet.ID,
+			Amount:       batch.Amount,
+			Currency:     batch.Currency,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetVoucherRedemption(ctx, redemption.VoucherID, userID)
+}
+
+func (s *voucherService) ListVoucherRedemptions(ctx context.Context, userID int64) ([]*domain.VoucherRedemption, error) {
+	return s.store.ListVoucherRedemptionsByUser(ctx, userID)
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// promoUserID owns the promo wallet in voucherStore.
+const promoUserID = 97
+
+// voucherStore adds voucher batches, their codes and redemptions to
+// ledgerStore.
+type voucherStore struct {
+	*ledgerStore
+	batches     []*domain.VoucherBatch
+	vouchers    []*domain.Voucher
+	redemptions []*domain.VoucherRedemption
+}
+
+func (s *voucherStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+func (s *voucherStore) GetPromoWalletID(ctx context.Context, currency string) (int64, bool, error) {
+	return walletID(promoUserID), currency == "USD", nil
+}
+
+func (s *voucherStore) CreateVoucherBatch(ctx context.Context, batch *domain.VoucherBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch.ID = int64(len(s.batches) + 1)
+	copied := *batch
+	s.batches = append(s.batches, &copied)
+	return nil
+}
+
+func (s *voucherStore) GetVoucherBatchByID(ctx context.Context, id int64) (*domain.VoucherBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *s.batches[id-1]
+	return &copied, nil
+}
+
+func (s *voucherStore) GetVoucherBatchForUpdate(ctx context.Context, id int64) (*domain.VoucherBatch, error) {
+	return s.GetVoucherBatchByID(ctx, id)
+}
+
+func (s *voucherStore) UpdateVoucherBatch(ctx context.Context, batch *domain.VoucherBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *batch
+	s.batches[batch.ID-1] = &copied
+	return nil
+}
+
+func (s *voucherStore) CreateVoucher(ctx context.Context, v *domain.Voucher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v.ID = int64(len(s.vouchers) + 1)
+	copied := *v
+	s.vouchers = append(s.vouchers, &copied)
+	return nil
+}
+
+func (s *voucherStore) GetVoucherByCodeHashForUpdate(ctx context.Context, codeHash string) (*domain.Voucher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range s.vouchers {
+		if v.CodeHash == codeHash {
+			copied := *v
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *voucherStore) UpdateVoucher(ctx context.Context, v *domain.Voucher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *v
+	s.vouchers[v.ID-1] = &copied
+	return nil
+}
+
+func (s *voucherStore) CreateVoucherRedemption(ctx context.Context, redemption *domain.VoucherRedemption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	redemption.ID = int64(len(s.redemptions) + 1)
+	copied := *redemption
+	s.redemptions = append(s.redemptions, &copied)
+	return nil
+}
+
+func (s *voucherStore) GetVoucherRedemption(ctx context.Context, voucherID, userID int64) (*domain.VoucherRedemption, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, redemption := range s.redemptions {
+		if redemption.VoucherID == voucherID && redemption.UserID == userID {
+			copied := *redemption
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *voucherStore) CountVoucherRedemptionsByUser(ctx context.Context, batchID, userID int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, redemption := range s.redemptions {
+		if redemption.BatchID == batchID && redemption.UserID == userID {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// newVoucherService funds the promo wallet with 1000.00 and gives users 1 to
+// 20 an empty wallet.
+func newVoucherService() (*voucherStore, domain.VoucherService) {
+	store := &voucherStore{ledgerStore: newLedgerStore()}
+	store.addUser(promoUserID, "1000.00")
+	for userID := int64(1); userID <= 20; userID++ {
+		store.addUser(userID, "0")
+	}
+
+	return store, NewVoucherService(store)
+}
+
+// createVoucherBatch creates a batch of codeCount 5.00 codes for a week.
+func createVoucherBatch(t *testing.T, svc domain.VoucherService, maxRedemptions, perUserLimit, codeCount int) *domain.VoucherBatch {
+	t.Helper()
+
+	batch, err := svc.CreateVoucherBatch(context.Background(), 50, "launch", dec("5.00"), "USD", time.Now().Add(7*24*time.Hour), maxRedemptions, perUserLimit, codeCount)
+	if err != nil {
+		t.Fatalf("CreateVoucherBatch() error = %v", err)
+	}
+
+	return batch
+}
+
+func TestRedeemVoucherPerCodeLimit(t *testing.T) {
+	store, svc := newVoucherService()
+	ctx := context.Background()
+	batch := createVoucherBatch(t, svc, 2, 1, 1)
+	code := batch.Codes[0]
+
+	for _, userID := range []int64{1, 2} {
+		if _, err := svc.RedeemVoucher(ctx, userID, code); err != nil {
+			t.Fatalf("RedeemVoucher() by user %d error = %v", userID, err)
+		}
+	}
+
+	if _, err := svc.RedeemVoucher(ctx, 3, code); !errors.Is(err, ErrVoucherUsedUp) {
+		t.Errorf("third RedeemVoucher() error = %v, want %v", err, ErrVoucherUsedUp)
+	}
+
+	// Each user redeems a code once, however many uses it has left.
+	if _, err := svc.RedeemVoucher(ctx, 1, code); !errors.Is(err, ErrVoucherAlreadyRedeemed) {
+		t.Errorf("repeat RedeemVoucher() error = %v, want %v", err, ErrVoucherAlreadyRedeemed)
+	}
+
+	store.assertBalance(t, 1, "5.00", "0")
+	store.assertBalance(t, 2, "5.00", "0")
+	store.assertBalance(t, 3, "0", "0")
+	store.assertBalance(t, promoUserID, "990.00", "0")
+
+	if got := store.batches[0]; got.RedemptionCount != 2 || !got.RedeemedAmount.Equal(dec("10.00")) {
+		t.Errorf("batch = %d redemptions of %s, want 2 of 10.00", got.RedemptionCount, got.RedeemedAmount)
+	}
+}
+
+func TestRedeemVoucherPerUserLimit(t *testing.T) {
+	store, svc := newVoucherService()
+	ctx := context.Background()
+	batch := createVoucherBatch(t, svc, 10, 2, 3)
+
+	for _, code := range batch.Codes[:2] {
+		if _, err := svc.RedeemVoucher(ctx, 1, code); err != nil {
+			t.Fatalf("RedeemVoucher() error = %v", err)
+		}
+	}
+
+	if _, err := svc.RedeemVoucher(ctx, 1, batch.Codes[2]); !errors.Is(err, ErrVoucherLimitReached) {
+		t.Errorf("RedeemVoucher() past the per-user limit error = %v, want %v", err, ErrVoucherLimitReached)
+	}
+
+	// The limit is per user; the same code still works for someone else.
+	if _, err := svc.RedeemVoucher(ctx, 2, batch.Codes[2]); err != nil {
+		t.Errorf("RedeemVoucher() by another user error = %v", err)
+	}
+
+	store.assertBalance(t, 1, "10.00", "0")
+	store.assertBalance(t, 2, "5.00", "0")
+}
+
+func TestRedeemVoucherRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(store *voucherStore)
+		code   func(batch *domain.VoucherBatch) string
+		want   error
+	}{
+		{
+			name: "unknown code",
+			code: func(batch *domain.VoucherBatch) string { return "AAAA-AAAA-AAAA" },
+			want: ErrVoucherNotFound,
+		},
+		{
+			name:   "disabled batch",
+			change: func(store *voucherStore) { store.batches[0].Status = domain.VoucherBatchStatusDisabled },
+			want:   ErrVoucherInactive,
+		},
+		{
+			name:   "expired batch",
+			change: func(store *voucherStore) { store.batches[0].ExpiresAt = time.Now().Add(-time.Minute) },
+			want:   ErrVoucherInactive,
+		},
+		{
+			name:   "promo wallet runs dry",
+			change: func(store *voucherStore) { store.wallets[walletID(promoUserID)].Balance = dec("4.99") },
+			want:   ErrPromoFundsExhausted,
+		},
+		{
+			name:   "wallet in another currency",
+			change: func(store *voucherStore) { store.wallets[walletID(1)].Currency = "EUR" },
+			want:   ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, svc := newVoucherService()
+			batch := createVoucherBatch(t, svc, 10, 1, 1)
+
+			code := batch.Codes[0]
+			if tt.code != nil {
+				code = tt.code(batch)
+			}
+
+			if tt.change != nil {
+				tt.change(store)
+			}
+
+			if _, err := svc.RedeemVoucher(context.Background(), 1, code); !errors.Is(err, tt.want) {
+				t.Fatalf("RedeemVoucher() error = %v, want %v", err, tt.want)
+			}
+
+			if len(store.redemptions) != 0 || len(store.ledger) != 0 {
+				t.Errorf("%d redemptions and %d ledger entries, want none", len(store.redemptions), len(store.ledger))
+			}
+		})
+	}
+}
+
+// redeemConcurrently has each user redeem code at the same time and returns
+// how many succeeded and the errors of the rest.
+func redeemConcurrently(svc domain.VoucherService, code string, userIDs []int64) (int, []error) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		errs      []error
+	)
+
+	start := make(chan struct{})
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			<-start
+
+			_, err := svc.RedeemVoucher(context.Background(), userID, code)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			succeeded++
+		}(userID)
+	}
+
+	close(start)
+	wg.Wait()
+
+	return succeeded, errs
+}
+
+func TestRedeemVoucherConcurrentlyBySameUser(t *testing.T) {
+	store, svc := newVoucherService()
+	batch := createVoucherBatch(t, svc, 10, 5, 1)
+
+	userIDs := make([]int64, 10)
+	for i := range userIDs {
+		userIDs[i] = 1
+	}
+
+	succeeded, errs := redeemConcurrently(svc, batch.Codes[0], userIDs)
+	if succeeded != 1 {
+		t.Errorf("%d redemptions succeeded, want 1", succeeded)
+	}
+
+	for _, err := range errs {
+		if !errors.Is(err, ErrVoucherAlreadyRedeemed) {
+			t.Errorf("RedeemVoucher() error = %v, want %v", err, ErrVoucherAlreadyRedeemed)
+		}
+	}
+
+	store.assertBalance(t, 1, "5.00", "0")
+	store.assertBalance(t, promoUserID, "995.00", "0")
+}
+
+func TestRedeemVoucherConcurrentlyPastItsLimit(t *testing.T) {
+	store, svc := newVoucherService()
+	batch := createVoucherBatch(t, svc, 5, 1, 1)
+
+	userIDs := make([]int64, 20)
+	for i := range userIDs {
+		userIDs[i] = int64(i + 1)
+	}
+
+	succeeded, errs := redeemConcurrently(svc, batch.Codes[0], userIDs)
+	if succeeded != 5 {
+		t.Errorf("%d redemptions succeeded, want 5", succeeded)
+	}
+
+	for _, err := range errs {
+		if !errors.Is(err, ErrVoucherUsedUp) {
+			t.Errorf("RedeemVoucher() error = %v, want %v", err, ErrVoucherUsedUp)
+		}
+	}
+
+	credited := decimal.Zero
+	for userID := int64(1); userID <= 20; userID++ {
+		balance, _ := store.balances(userID)
+		credited = credited.Add(balance)
+	}
+
+	if !credited.Equal(dec("25.00")) {
+		t.Errorf("users were credited %s in total, want 25.00", credited)
+	}
+
+	store.assertBalance(t, promoUserID, "975.00", "0")
+
+	if got := store.vouchers[0].RedemptionCount; got != 5 {
+		t.Errorf("voucher redemption count = %d, want 5", got)
+	}
+}
@@ -0,0 +1,469 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/amankp-zop/wallet/internal/webhook"
+)
+
+var (
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrWebhookURLNotAllowed    = errors.New("webhook url must resolve to a public address")
+	ErrWebhookEndpointDisabled = errors.New("webhook endpoint is disabled")
+)
+
+const (
+	webhookTimeout         = 10 * time.Second
+	webhookDeliveryHistory = 50
+	maxWebhookResponseBody = 1024
+
+	// webhookDisableAfterFailures is how many deliveries in a row must fail
+	// before their endpoint is disabled.
+	webhookDisableAfterFailures = 5
+)
+
+type webhookService struct {
+	store  repository.Store
+	client *http.Client
+}
+
+// NewWebhookService returns the webhook service. Its client only connects to
+// public addresses and ignores proxy settings, which would otherwise be
+// connected to in place of the endpoint.
+func NewWebhookService(store repository.Store) domain.WebhookService {
+	return &webhookService{
+		store: store,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			Transport: &http.Transport{
+				DialContext:         webhook.NewDialer(webhookTimeout).DialContext,
+				TLSHandshakeTimeout: webhookTimeout,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// webhookEvent is the JSON body sent to endpoints.
+type webhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// CreateEndpoint registers an endpoint and returns it with its signing secret,
+// which is not returned again by the read methods. The URL's host must only
+// resolve to public addresses; deliveries check again when they connect.
+func (s *webhookService) CreateEndpoint(ctx context.Context, userID int64, rawURL string, eventTypes []string, description string) (*domain.WebhookEndpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	if err := webhook.CheckHost(ctx, u.Hostname()); err != nil {
+		return nil, ErrWebhookURLNotAllowed
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	endpoint := &domain.WebhookEndpoint{
+		UserID:      userID,
+		URL:         u.String(),
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		Description: description,
+		Status:      domain.WebhookEndpointStatusActive,
+	}
+
+	if err := s.store.CreateWebhookEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	created, err := s.store.GetWebhookEndpointByID(ctx, endpoint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *webhookService) ListEndpoints(ctx context.Context, userID int64) ([]*domain.WebhookEndpoint, error) {
+	endpoints, err := s.store.ListWebhookEndpointsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, endpoint := range endpoints {
+		endpoint.Secret = ""
+	}
+
+	return endpoints, nil
+}
+
+func (s *webhookService) GetEndpoint(ctx context.Context, userID, endpointID int64) (*domain.WebhookEndpoint, error) {
+	endpoint, err := s.ownedEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+func (s *webhookService) DisableEndpoint(ctx context.Context, userID, endpointID int64) error {
+	if _, err := s.ownedEndpoint(ctx, userID, endpointID); err != nil {
+		return err
+	}
+
+	return s.store.UpdateWebhookEndpointStatus(ctx, endpointID, domain.WebhookEndpointStatusDisabled)
+}
+
+// EnableEndpoint turns a disabled endpoint back on, whether it was disabled by
+// its owner or after repeated failed deliveries. The URL's host is checked
+// again, as it may have been changed to point inside the network since the
+// endpoint was registered.
+func (s *webhookService) EnableEndpoint(ctx context.Context, userID, endpointID int64) (*domain.WebhookEndpoint, error) {
+	endpoint, err := s.ownedEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	if err := webhook.CheckHost(ctx, u.Hostname()); err != nil {
+		return nil, ErrWebhookURLNotAllowed
+	}
+
+	if endpoint.Status != domain.WebhookEndpointStatusActive {
+		if err := s.store.UpdateWebhookEndpointStatus(ctx, endpointID, domain.WebhookEndpointStatusActive); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetEndpoint(ctx, userID, endpointID)
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, userID, endpointID int64) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.ownedEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+
+	return s.store.ListWebhookDeliveriesByEndpoint(ctx, endpointID, webhookDeliveryHistory)
+}
+
+// GetDelivery returns a delivery together with its attempt log.
+func (s *webhookService) GetDelivery(ctx context.Context, userID, endpointID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	delivery, err := s.ownedDelivery(ctx, userID, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.AttemptLog, err = s.store.ListWebhookDeliveryAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// Redeliver queues another delivery of the same event body, whatever the
+// delivery's current status.
+func (s *webhookService) Redeliver(ctx context.Context, userID, endpointID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	endpoint, err := s.ownedEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.Status != domain.WebhookEndpointStatusActive {
+		return nil, ErrWebhookEndpointDisabled
+	}
+
+	delivery, err := s.ownedDelivery(ctx, userID, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		delivery.Status = domain.WebhookDeliveryStatusPending
+		if err := q.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			return err
+		}
+
+		return publishEvent(ctx, q, tasks.TaskTypeDeliverWebhook, tasks.DeliverWebhookPayload{DeliveryID: delivery.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (s *webhookService) ownedEndpoint(ctx context.Context, userID, endpointID int64) (*domain.WebhookEndpoint, error) {
+	endpoint, err := s.store.GetWebhookEndpointByID(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint == nil || endpoint.UserID != userID {
+		return nil, ErrWebhookEndpointNotFound
+	}
+
+	return endpoint, nil
+}
+
+func (s *webhookService) ownedDelivery(ctx context.Context, userID, endpointID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	if _, err := s.ownedEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.store.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery == nil || delivery.EndpointID != endpointID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	return delivery, nil
+}
+
+// DispatchEvent creates a delivery for every active endpoint, owned by a user
+// the event concerns, whose filter matches eventType. It is idempotent per
+// event and endpoint, and returns the number of deliveries created.
+func (s *webhookService) DispatchEvent(ctx context.Context, eventID int64, eventType string, data []byte, occurredAt time.Time) (int, error) {
+	userIDs, err := resolveEventUserIDs(ctx, s.store, data)
+	if err != nil {
+		return 0, err
+	}
+
+	endpoints, err := s.store.ListActiveWebhookEndpointsByUsers(ctx, userIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(webhookEvent{
+		ID:        "evt_" + strconv.FormatInt(eventID, 10),
+		Type:      eventType,
+		CreatedAt: occurredAt,
+		Data:      data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	err = s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		for _, endpoint := range endpoints {
+			if !matchesEventType(endpoint.EventTypes, eventType) {
+				continue
+			}
+
+			delivery := &domain.WebhookDelivery{
+				EndpointID: endpoint.ID,
+				EventID:    eventID,
+				EventType:  eventType,
+				Payload:    body,
+				Status:     domain.WebhookDeliveryStatusPending,
+			}
+
+			inserted, err := q.CreateWebhookDelivery(ctx, delivery)
+			if err != nil {
+				return err
+			}
+
+			if !inserted {
+				continue
+			}
+
+			err = publishEvent(ctx, q, tasks.TaskTypeDeliverWebhook, tasks.DeliverWebhookPayload{DeliveryID: delivery.ID})
+			if err != nil {
+				return err
+			}
+			created++
+		}
+
+		return nil
+	})
+
+	return created, err
+}
+
+// DeliverWebhook sends one signed attempt of a delivery and records it. A
+// non-2xx response or transport error is returned so asynq retries with
+// backoff; when finalAttempt is set the delivery is marked FAILED instead of
+// staying PENDING, and an endpoint whose last deliveries have all failed is
+// disabled.
+func (s *webhookService) DeliverWebhook(ctx context.Context, deliveryID int64, finalAttempt bool) error {
+	delivery, err := s.store.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	if delivery == nil || delivery.Status == domain.WebhookDeliveryStatusSucceeded {
+		return nil
+	}
+
+	endpoint, err := s.store.GetWebhookEndpointByID(ctx, delivery.EndpointID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	if endpoint == nil || endpoint.Status != domain.WebhookEndpointStatusActive {
+		delivery.Status = domain.WebhookDeliveryStatusFailed
+		return s.store.UpdateWebhookDelivery(ctx, delivery)
+	}
+
+	attempt := s.send(ctx, endpoint, delivery, now)
+	if err := s.store.CreateWebhookDeliveryAttempt(ctx, attempt); err != nil {
+		return err
+	}
+
+	var sendErr error
+	switch {
+	case attempt.ResponseStatus >= 200 && attempt.ResponseStatus < 300:
+		delivery.Status = domain.WebhookDeliveryStatusSucceeded
+	case attempt.Error != "":
+		sendErr = errors.New(attempt.Error)
+	default:
+		sendErr = fmt.Errorf("webhook endpoint responded with status %d", attempt.ResponseStatus)
+	}
+
+	if sendErr != nil && finalAttempt {
+		delivery.Status = domain.WebhookDeliveryStatusFailed
+	}
+
+	if err := s.store.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return err
+	}
+
+	if delivery.Status == domain.WebhookDeliveryStatusFailed {
+		if err := s.disableFailingEndpoint(ctx, endpoint); err != nil {
+			return err
+		}
+	}
+
+	return sendErr
+}
+
+// disableFailingEndpoint disables endpoint once its last
+// webhookDisableAfterFailures finished deliveries have all failed. Deliveries
+// still being retried are not counted either way, and neither are failures
+// from before the endpoint was last enabled.
+func (s *webhookService) disableFailingEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	deliveries, err := s.store.ListWebhookDeliveriesByEndpoint(ctx, endpoint.ID, webhookDeliveryHistory)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, delivery := range deliveries {
+		switch delivery.Status {
+		case domain.WebhookDeliveryStatusSucceeded:
+			return nil
+		case domain.WebhookDeliveryStatusFailed:
+			if delivery.UpdatedAt.Before(endpoint.UpdatedAt) {
+				return nil
+			}
+			failed++
+		}
+
+		if failed >= webhookDisableAfterFailures {
+			endpoint.Status = domain.WebhookEndpointStatusDisabled
+			return s.store.UpdateWebhookEndpointStatus(ctx, endpoint.ID, endpoint.Status)
+		}
+	}
+
+	return nil
+}
+
+func (s *webhookService) send(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery, now time.Time) *domain.WebhookDeliveryAttempt {
+	attempt := &domain.WebhookDeliveryAttempt{DeliveryID: delivery.ID}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wallet-webhooks/1.0")
+	req.Header.Set(webhook.EventTypeHeader, delivery.EventType)
+	req.Header.Set(webhook.DeliveryIDHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(endpoint.Secret, now, delivery.Payload))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	attempt.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = truncate(err.Error(), maxWebhookResponseBody)
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	attempt.ResponseStatus = resp.StatusCode
+	attempt.ResponseBody = string(body)
+
+	return attempt
+}
+
+// matchesEventType reports whether eventType passes an endpoint's filter.
+func matchesEventType(filters []string, eventType string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	for _, filter := range filters {
+		if filter == "*" || filter == eventType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(filter, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n]
+}
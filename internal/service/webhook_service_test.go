@@ -0,0 +1,532 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/amankp-zop/wallet/internal/webhook"
+)
+
+type webhookStore struct {
+	repository.Store
+	endpoints  map[int64]*domain.WebhookEndpoint
+	deliveries map[int64]*domain.WebhookDelivery
+	attempts   []*domain.WebhookDeliveryAttempt
+	outbox     []*domain.Outbox
+}
+
+func newWebhookStore(endpoint *domain.WebhookEndpoint, deliveries ...*domain.WebhookDelivery) *webhookStore {
+	s := &webhookStore{
+		endpoints:  map[int64]*domain.WebhookEndpoint{endpoint.ID: endpoint},
+		deliveries: make(map[int64]*domain.WebhookDelivery),
+	}
+	for _, delivery := range deliveries {
+		s.deliveries[delivery.ID] = delivery
+	}
+
+	return s
+}
+
+func (s *webhookStore) CreateWebhookEndpoint(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	endpoint.ID = int64(len(s.endpoints) + 1)
+	copied := *endpoint
+	s.endpoints[endpoint.ID] = &copied
+	return nil
+}
+
+func (s *webhookStore) GetWebhookEndpointByID(ctx context.Context, id int64) (*domain.WebhookEndpoint, error) {
+	endpoint, ok := s.endpoints[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *endpoint
+	return &copied, nil
+}
+
+func (s *webhookStore) UpdateWebhookEndpointStatus(ctx context.Context, id int64, status domain.WebhookEndpointStatus) error {
+	s.endpoints[id].Status = status
+	s.endpoints[id].UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *webhookStore) GetWebhookDeliveryByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *delivery
+	return &copied, nil
+}
+
+func (s *webhookStore) ListWebhookDeliveriesByEndpoint(ctx context.Context, endpointID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.EndpointID == endpointID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	return deliveries[:min(limit, len(deliveries))], nil
+}
+
+func (s *webhookStore) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	copied := *delivery
+	copied.UpdatedAt = time.Now()
+	s.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (s *webhookStore) CreateWebhookDeliveryAttempt(ctx context.Context, attempt *domain.WebhookDeliveryAttempt) error {
+	attempt.ID = int64(len(s.attempts) + 1)
+	s.attempts = append(s.attempts, attempt)
+	return nil
+}
+
+func (s *webhookStore) CreateOutbox(ctx context.Context, event *domain.Outbox) error {
+	event.ID = int64(len(s.outbox) + 1)
+	s.outbox = append(s.outbox, event)
+	return nil
+}
+
+// receivedWebhook is a request captured by a test receiver.
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a receiver that answers every request with status and
+// body and sends what it received on the returned channel.
+func newReceiver(t *testing.T, status int, body string) (*httptest.Server, <-chan receivedWebhook) {
+	t.Helper()
+
+	received := make(chan receivedWebhook, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		received <- receivedWebhook{header: r.Header.Clone(), body: payload}
+
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, received
+}
+
+// newTestWebhookService returns a webhook service whose client may connect
+// to the test receivers on the loopback interface.
+func newTestWebhookService(store repository.Store) domain.WebhookService {
+	svc := NewWebhookService(txStore{store}).(*webhookService)
+	svc.client = &http.Client{Timeout: webhookTimeout}
+	return svc
+}
+
+func testEndpoint(url string) *domain.WebhookEndpoint {
+	return &domain.WebhookEndpoint{
+		ID:     1,
+		UserID: 7,
+		URL:    url,
+		Secret: "whsec_test",
+		Status: domain.WebhookEndpointStatusActive,
+	}
+}
+
+func testDelivery(id int64, status domain.WebhookDeliveryStatus) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:         id,
+		EndpointID: 1,
+		EventID:    100 + id,
+		EventType:  tasks.TopicTransferCompleted,
+		Payload:    []byte(`{"id":"evt_` + strconv.FormatInt(100+id, 10) + `","type":"transfer:completed"}`),
+		Status:     status,
+	}
+}
+
+func TestDeliverWebhookSignsRequest(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusOK, "ok")
+	delivery := testDelivery(1, domain.WebhookDeliveryStatusPending)
+	store := newWebhookStore(testEndpoint(srv.URL), delivery)
+	svc := newTestWebhookService(store)
+
+	if err := svc.DeliverWebhook(context.Background(), delivery.ID, false); err != nil {
+		t.Fatalf("DeliverWebhook() error = %v", err)
+	}
+
+	got := <-received
+	if string(got.body) != string(delivery.Payload) {
+		t.Errorf("body = %s, want %s", got.body, delivery.Payload)
+	}
+
+	if h := got.header.Get(webhook.EventTypeHeader); h != delivery.EventType {
+		t.Errorf("%s = %q, want %q", webhook.EventTypeHeader, h, delivery.EventType)
+	}
+
+	if h := got.header.Get(webhook.DeliveryIDHeader); h != "1" {
+		t.Errorf("%s = %q, want %q", webhook.DeliveryIDHeader, h, "1")
+	}
+
+	timestamp := got.header.Get(webhook.TimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(unix, 0)).Abs() > time.Minute {
+		t.Errorf("%s = %q, want the current unix time", webhook.TimestampHeader, timestamp)
+	}
+
+	signature := got.header.Get(webhook.SignatureHeader)
+	if err := webhook.Verify("whsec_test", signature, timestamp, got.body, webhook.DefaultTolerance, time.Now()); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	if err := webhook.Verify("whsec_other", signature, timestamp, got.body, webhook.DefaultTolerance, time.Now()); !errors.Is(err, webhook.ErrInvalidSignature) {
+		t.Errorf("Verify() with another secret error = %v, want %v", err, webhook.ErrInvalidSignature)
+	}
+
+	stored := store.deliveries[delivery.ID]
+	if stored.Status != domain.WebhookDeliveryStatusSucceeded || stored.Attempts != 1 || stored.LastAttemptAt == nil {
+		t.Errorf("delivery = %+v, want SUCCEEDED after 1 attempt", stored)
+	}
+
+	if len(store.attempts) != 1 {
+		t.Fatalf("attempts logged = %d, want 1", len(store.attempts))
+	}
+
+	if a := store.attempts[0]; a.DeliveryID != delivery.ID || a.ResponseStatus != http.StatusOK || a.ResponseBody != "ok" || a.Error != "" {
+		t.Errorf("attempt = %+v, want 200 \"ok\"", a)
+	}
+}
+
+func TestDeliverWebhookRetriesUntilFinalAttempt(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusServiceUnavailable, "try later")
+	delivery := testDelivery(1, domain.WebhookDeliveryStatusPending)
+	store := newWebhookStore(testEndpoint(srv.URL), delivery)
+	svc := newTestWebhookService(store)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		final := attempt == 3
+		if err := svc.DeliverWebhook(context.Background(), delivery.ID, final); err == nil {
+			t.Fatalf("attempt %d: DeliverWebhook() error = nil, want an error so the task is retried", attempt)
+		}
+		<-received
+
+		want := domain.WebhookDeliveryStatusPending
+		if final {
+			want = domain.WebhookDeliveryStatusFailed
+		}
+
+		if got := store.deliveries[delivery.ID]; got.Status != want || got.Attempts != attempt {
+			t.Errorf("attempt %d: delivery = %s after %d attempts, want %s", attempt, got.Status, got.Attempts, want)
+		}
+	}
+
+	if len(store.attempts) != 3 {
+		t.Fatalf("attempts logged = %d, want 3", len(store.attempts))
+	}
+
+	for _, a := range store.attempts {
+		if a.ResponseStatus != http.StatusServiceUnavailable || a.ResponseBody != "try later" {
+			t.Errorf("attempt = %+v, want 503 \"try later\"", a)
+		}
+	}
+}
+
+func TestDeliverWebhookRecordsTransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	delivery := testDelivery(1, domain.WebhookDeliveryStatusPending)
+	store := newWebhookStore(testEndpoint(url), delivery)
+	svc := newTestWebhookService(store)
+
+	if err := svc.DeliverWebhook(context.Background(), delivery.ID, false); err == nil {
+		t.Fatal("DeliverWebhook() error = nil, want a transport error")
+	}
+
+	if len(store.attempts) != 1 {
+		t.Fatalf("attempts logged = %d, want 1", len(store.attempts))
+	}
+
+	if a := store.attempts[0]; a.Error == "" || a.ResponseStatus != 0 {
+		t.Errorf("attempt = %+v, want the transport error recorded", a)
+	}
+}
+
+func TestDeliverWebhookSkipsSucceededDelivery(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusOK, "")
+	delivery := testDelivery(1, domain.WebhookDeliveryStatusSucceeded)
+	store := newWebhookStore(testEndpoint(srv.URL), delivery)
+	svc := newTestWebhookService(store)
+
+	if err := svc.DeliverWebhook(context.Background(), delivery.ID, false); err != nil {
+		t.Fatalf("DeliverWebhook() error = %v", err)
+	}
+
+	if len(received) != 0 || len(store.attempts) != 0 {
+		t.Error("succeeded delivery was sent again")
+	}
+}
+
+func TestDeliverWebhookDisablesFailingEndpoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		earlier      []domain.WebhookDeliveryStatus
+		wantDisabled bool
+	}{
+		{
+			name:         "five consecutive failures",
+			earlier:      []domain.WebhookDeliveryStatus{"FAILED", "FAILED", "FAILED", "FAILED"},
+			wantDisabled: true,
+		},
+		{
+			name:    "fewer than five failures",
+			earlier: []domain.WebhookDeliveryStatus{"FAILED", "FAILED", "FAILED"},
+		},
+		{
+			name:    "success among the failures",
+			earlier: []domain.WebhookDeliveryStatus{"FAILED", "FAILED", "SUCCEEDED", "FAILED", "FAILED"},
+		},
+		{
+			name:         "pending deliveries are not counted",
+			earlier:      []domain.WebhookDeliveryStatus{"FAILED", "FAILED", "PENDING", "FAILED", "FAILED"},
+			wantDisabled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newReceiver(t, http.StatusInternalServerError, "")
+
+			// Earlier deliveries have lower IDs, so the last listed is the
+			// oldest.
+			var deliveries []*domain.WebhookDelivery
+			for i, status := range tt.earlier {
+				deliveries = append(deliveries, testDelivery(int64(len(tt.earlier)-i), status))
+			}
+			current := testDelivery(int64(len(tt.earlier)+1), domain.WebhookDeliveryStatusPending)
+			store := newWebhookStore(testEndpoint(srv.URL), append(deliveries, current)...)
+			svc := newTestWebhookService(store)
+
+			if err := svc.DeliverWebhook(context.Background(), current.ID, true); err == nil {
+				t.Fatal("DeliverWebhook() error = nil, want the 500 response")
+			}
+
+			want := domain.WebhookEndpointStatusActive
+			if tt.wantDisabled {
+				want = domain.WebhookEndpointStatusDisabled
+			}
+
+			if got := store.endpoints[1].Status; got != want {
+				t.Errorf("endpoint status = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestDeliverWebhookToDisabledEndpoint(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusOK, "")
+	endpoint := testEndpoint(srv.URL)
+	endpoint.Status = domain.WebhookEndpointStatusDisabled
+	delivery := testDelivery(1, domain.WebhookDeliveryStatusPending)
+	store := newWebhookStore(endpoint, delivery)
+	svc := newTestWebhookService(store)
+
+	if err := svc.DeliverWebhook(context.Background(), delivery.ID, false); err != nil {
+		t.Fatalf("DeliverWebhook() error = %v", err)
+	}
+
+	if len(received) != 0 {
+		t.Error("delivery was sent to a disabled endpoint")
+	}
+
+	if got := store.deliveries[delivery.ID].Status; got != domain.WebhookDeliveryStatusFailed {
+		t.Errorf("delivery status = %s, want %s", got, domain.WebhookDeliveryStatusFailed)
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusOK, "")
+	delivery := testDelivery(1, domain.WebhookDeliveryStatusFailed)
+	store := newWebhookStore(testEndpoint(srv.URL), delivery)
+	svc := newTestWebhookService(store)
+
+	if _, err := svc.Redeliver(context.Background(), 8, 1, delivery.ID); !errors.Is(err, ErrWebhookEndpointNotFound) {
+		t.Errorf("Redeliver() by another user error = %v, want %v", err, ErrWebhookEndpointNotFound)
+	}
+
+	got, err := svc.Redeliver(context.Background(), 7, 1, delivery.ID)
+	if err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+
+	if got.Status != domain.WebhookDeliveryStatusPending || store.deliveries[delivery.ID].Status != domain.WebhookDeliveryStatusPending {
+		t.Errorf("delivery status = %s, want %s", got.Status, domain.WebhookDeliveryStatusPending)
+	}
+
+	if len(store.outbox) != 1 || store.outbox[0].Topic != tasks.TaskTypeDeliverWebhook {
+		t.Fatalf("outbox = %+v, want one %s event", store.outbox, tasks.TaskTypeDeliverWebhook)
+	}
+
+	var payload tasks.DeliverWebhookPayload
+	if err := json.Unmarshal(store.outbox[0].Payload, &payload); err != nil || payload.DeliveryID != delivery.ID {
+		t.Errorf("outbox payload = %s, want delivery %d", store.outbox[0].Payload, delivery.ID)
+	}
+
+	// The worker picks the queued task up and sends the delivery again.
+	if err := svc.DeliverWebhook(context.Background(), payload.DeliveryID, false); err != nil {
+		t.Fatalf("DeliverWebhook() error = %v", err)
+	}
+	<-received
+
+	if got := store.deliveries[delivery.ID]; got.Status != domain.WebhookDeliveryStatusSucceeded {
+		t.Errorf("delivery status after redelivery = %s, want %s", got.Status, domain.WebhookDeliveryStatusSucceeded)
+	}
+}
+
+func TestRedeliverDisabledEndpoint(t *testing.T) {
+	endpoint := testEndpoint("http://127.0.0.1/hook")
+	endpoint.Status = domain.WebhookEndpointStatusDisabled
+	delivery := testDelivery(1, domain.WebhookDeliveryStatusFailed)
+	store := newWebhookStore(endpoint, delivery)
+	svc := newTestWebhookService(store)
+
+	if _, err := svc.Redeliver(context.Background(), 7, 1, delivery.ID); !errors.Is(err, ErrWebhookEndpointDisabled) {
+		t.Errorf("Redeliver() error = %v, want %v", err, ErrWebhookEndpointDisabled)
+	}
+
+	if len(store.outbox) != 0 {
+		t.Error("redelivery to a disabled endpoint was queued")
+	}
+}
+
+func TestEnableEndpoint(t *testing.T) {
+	endpoint := testEndpoint("https://93.184.215.14/hooks")
+	endpoint.Status = domain.WebhookEndpointStatusDisabled
+
+	// The endpoint was disabled after these deliveries failed.
+	var deliveries []*domain.WebhookDelivery
+	for id := int64(1); id <= webhookDisableAfterFailures; id++ {
+		deliveries = append(deliveries, testDelivery(id, domain.WebhookDeliveryStatusFailed))
+	}
+	store := newWebhookStore(endpoint, deliveries...)
+	svc := newTestWebhookService(store)
+
+	if _, err := svc.EnableEndpoint(context.Background(), 8, 1); !errors.Is(err, ErrWebhookEndpointNotFound) {
+		t.Errorf("EnableEndpoint() by another user error = %v, want %v", err, ErrWebhookEndpointNotFound)
+	}
+
+	got, err := svc.EnableEndpoint(context.Background(), 7, 1)
+	if err != nil {
+		t.Fatalf("EnableEndpoint() error = %v", err)
+	}
+
+	if got.Status != domain.WebhookEndpointStatusActive || got.Secret != "" {
+		t.Errorf("endpoint = %+v, want active without its secret", got)
+	}
+
+	// One more failure does not disable it again: the failures from before
+	// it was enabled are not counted.
+	srv, _ := newReceiver(t, http.StatusInternalServerError, "")
+	store.endpoints[1].URL = srv.URL
+	current := testDelivery(webhookDisableAfterFailures+1, domain.WebhookDeliveryStatusPending)
+	store.deliveries[current.ID] = current
+
+	if err := svc.DeliverWebhook(context.Background(), current.ID, true); err == nil {
+		t.Fatal("DeliverWebhook() error = nil, want the 500 response")
+	}
+
+	if got := store.endpoints[1].Status; got != domain.WebhookEndpointStatusActive {
+		t.Errorf("endpoint status = %s, want %s", got, domain.WebhookEndpointStatusActive)
+	}
+
+	if _, err := svc.Redeliver(context.Background(), 7, 1, 1); err != nil {
+		t.Errorf("Redeliver() after enabling error = %v", err)
+	}
+}
+
+func TestEnableEndpointRejectsInternalAddress(t *testing.T) {
+	endpoint := testEndpoint("http://127.0.0.1/hooks")
+	endpoint.Status = domain.WebhookEndpointStatusDisabled
+	store := newWebhookStore(endpoint)
+	svc := newTestWebhookService(store)
+
+	if _, err := svc.EnableEndpoint(context.Background(), 7, 1); !errors.Is(err, ErrWebhookURLNotAllowed) {
+		t.Errorf("EnableEndpoint() error = %v, want %v", err, ErrWebhookURLNotAllowed)
+	}
+
+	if got := store.endpoints[1].Status; got != domain.WebhookEndpointStatusDisabled {
+		t.Errorf("endpoint status = %s, want %s", got, domain.WebhookEndpointStatusDisabled)
+	}
+}
+
+func TestCreateEndpointRejectsInternalAddresses(t *testing.T) {
+	for _, rawURL := range []string{
+		"http://localhost:8080/hooks",
+		"http://127.0.0.1/hooks",
+		"http://[::1]/hooks",
+		"http://10.0.0.5/hooks",
+		"https://192.168.1.10/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hooks",
+		"http://[::ffff:127.0.0.1]/hooks",
+	} {
+		store := newWebhookStore(testEndpoint("https://example.com/hooks"))
+		svc := NewWebhookService(txStore{store})
+
+		if _, err := svc.CreateEndpoint(context.Background(), 7, rawURL, nil, ""); !errors.Is(err, ErrWebhookURLNotAllowed) {
+			t.Errorf("CreateEndpoint(%q) error = %v, want %v", rawURL, err, ErrWebhookURLNotAllowed)
+		}
+
+		if len(store.endpoints) != 1 {
+			t.Errorf("CreateEndpoint(%q) stored the endpoint", rawURL)
+		}
+	}
+}
+
+func TestCreateEndpointAcceptsPublicAddress(t *testing.T) {
+	store := newWebhookStore(testEndpoint("https://example.com/hooks"))
+	svc := NewWebhookService(txStore{store})
+
+	endpoint, err := svc.CreateEndpoint(context.Background(), 7, "https://93.184.215.14/hooks", []string{"transfer.*"}, "")
+	if err != nil {
+		t.Fatalf("CreateEndpoint() error = %v", err)
+	}
+
+	if endpoint.Secret == "" || endpoint.Status != domain.WebhookEndpointStatusActive {
+		t.Errorf("endpoint = %+v, want an active endpoint with its secret", endpoint)
+	}
+}
+
+// TestDeliverWebhookRefusesInternalAddress covers an endpoint whose host
+// resolved to a public address at registration and points inside the
+// network by the time a delivery is sent.
+func TestDeliverWebhookRefusesInternalAddress(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusOK, "ok")
+	delivery := testDelivery(1, domain.WebhookDeliveryStatusPending)
+	store := newWebhookStore(testEndpoint(srv.URL), delivery)
+	svc := NewWebhookService(txStore{store})
+
+	if err := svc.DeliverWebhook(context.Background(), delivery.ID, false); err == nil {
+		t.Fatal("DeliverWebhook() error = nil, want the connection refused")
+	}
+
+	if len(received) != 0 {
+		t.Error("delivery reached a loopback address")
+	}
+
+	if len(store.attempts) != 1 || !strings.Contains(store.attempts[0].Error, webhook.ErrForbiddenAddress.Error()) {
+		t.Errorf("attempts = %+v, want one refused for its address", store.attempts)
+	}
+}
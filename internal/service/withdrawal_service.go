@@ -0,0 +1,483 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/bankaccount"
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrBeneficiaryNotFound  = errors.New("beneficiary not found")
+	ErrInvalidBankAccount   = errors.New("bank account details are not valid")
+	ErrWithdrawalNotFound   = errors.New("withdrawal not found")
+	ErrWithdrawalNotPending = errors.New("withdrawal is already being paid out")
+)
+
+const (
+	withdrawalBatchSize = 100
+	// withdrawalPollInterval is how long to wait before asking the payout
+	// provider about a payout in flight.
+	withdrawalPollInterval = time.Minute
+	// withdrawalMaxRetryDelay caps the backoff after provider errors.
+	withdrawalMaxRetryDelay = time.Hour
+)
+
+type withdrawalService struct {
+	store    repository.Store
+	provider domain.PayoutProvider
+}
+
+func NewWithdrawalService(store repository.Store, provider domain.PayoutProvider) domain.WithdrawalService {
+	return &withdrawalService{
+		store:    store,
+		provider: provider,
+	}
+}
+
+// CreateBeneficiary validates and saves a bank account the user can withdraw
+// to. The account must be in the currency of the user's wallet.
+func (s *withdrawalService) CreateBeneficiary(ctx context.Context, userID int64, params domain.BankBeneficiaryParams) (*domain.BankBeneficiary, error) {
+	wallet, err := s.store.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	if params.Currency != wallet.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	holderName := strings.TrimSpace(params.AccountHolderName)
+	if holderName == "" {
+		return nil, fmt.Errorf("%w: account holder name is required", ErrInvalidBankAccount)
+	}
+
+	beneficiary := &domain.BankBeneficiary{
+		UserID:            userID,
+		Nickname:          strings.TrimSpace(params.Nickname),
+		AccountHolderName: holderName,
+		Currency:          params.Currency,
+		AccountType:       params.AccountType,
+	}
+
+	switch params.AccountType {
+	case domain.BankAccountTypeIBAN:
+		iban, country, err := bankaccount.ValidateIBAN(params.AccountNumber)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBankAccount, err)
+		}
+
+		if params.Country != "" && params.Country != country {
+			return nil, fmt.Errorf("%w: country does not match the iban", ErrInvalidBankAccount)
+		}
+
+		beneficiary.AccountNumber = iban
+		beneficiary.Country = country
+
+		if params.RoutingCode != "" {
+			if beneficiary.RoutingCode, err = bankaccount.ValidateBIC(params.RoutingCode); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidBankAccount, err)
+			}
+		}
+	case domain.BankAccountTypeAccountNumber:
+		if params.Country == "" {
+			return nil, fmt.Errorf("%w: country is required", ErrInvalidBankAccount)
+		}
+
+		accountNumber, routingCode, err := bankaccount.ValidateAccount(params.Country, params.AccountNumber, params.RoutingCode)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBankAccount, err)
+		}
+
+		beneficiary.AccountNumber = accountNumber
+		beneficiary.RoutingCode = routingCode
+		beneficiary.Country = params.Country
+	default:
+		return nil, fmt.Errorf("%w: unknown account type %q", ErrInvalidBankAccount, params.AccountType)
+	}
+
+	beneficiary.AccountLast4 = beneficiary.AccountNumber[len(beneficiary.AccountNumber)-4:]
+
+	if err := s.store.CreateBankBeneficiary(ctx, beneficiary); err != nil {
+		return nil, err
+	}
+
+	return s.store.GetBankBeneficiaryByID(ctx, beneficiary.ID)
+}
+
+func (s *withdrawalService) ListBeneficiaries(ctx context.Context, userID int64) ([]*domain.BankBeneficiary, error) {
+	return s.store.ListBankBeneficiariesByUser(ctx, userID)
+}
+
+func (s *withdrawalService) GetBeneficiary(ctx context.Context, userID, id int64) (*domain.BankBeneficiary, error) {
+	beneficiary, err := s.store.GetBankBeneficiaryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if beneficiary == nil || beneficiary.UserID != userID || beneficiary.DeletedAt != nil {
+		return nil, ErrBeneficiaryNotFound
+	}
+
+	return beneficiary, nil
+}
+
+// DeleteBeneficiary removes the beneficiary from the user's list. Withdrawals
+// already made to it are still paid out.
+func (s *withdrawalService) DeleteBeneficiary(ctx context.Context, userID, id int64) error {
+	beneficiary, err := s.GetBeneficiary(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.store.DeleteBankBeneficiary(ctx, beneficiary.ID, time.Now().UTC())
+}
+
+// CreateWithdrawal holds amount on the user's wallet and queues the payout to
+// the beneficiary. The worker submits it to the payout provider.
+func (s *withdrawalService) CreateWithdrawal(ctx context.Context, userID, beneficiaryID int64, amount decimal.Decimal) (*domain.Withdrawal, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	var created *domain.Withdrawal
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		beneficiary, err := q.GetBankBeneficiaryByID(ctx, beneficiaryID)
+		if err != nil {
+			return err
+		}
+
+		if beneficiary == nil || beneficiary.UserID != userID || beneficiary.DeletedAt != nil {
+			return ErrBeneficiaryNotFound
+		}
+
+		wallet, err := q.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if wallet == nil {
+			return ErrWalletNotFound
+		}
+
+		wallet, err = q.GetWalletForUpdate(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+
+		if wallet.Currency != beneficiary.Currency {
+			return ErrCurrencyMismatch
+		}
+
+		if wallet.AvailableBalance.LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+
+		if err := q.AdjustWalletHeldBalance(ctx, wallet.ID, amount); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		withdrawal := &domain.Withdrawal{
+			UserID:        userID,
+			WalletID:      wallet.ID,
+			BeneficiaryID: beneficiary.ID,
+			Amount:        amount,
+			Currency:      wallet.Currency,
+			Provider:      s.provider.Name(),
+			Status:        domain.WithdrawalStatusPending,
+			NextAttemptAt: &now,
+		}
+
+		if err := q.CreateWithdrawal(ctx, withdrawal); err != nil {
+			return err
+		}
+
+		created = withdrawal
+
+		if err := publishWithdrawalEvent(ctx, q, tasks.TopicWithdrawalCreated, withdrawal); err != nil {
+			return err
+		}
+
+		return publishEvent(ctx, q, tasks.TaskTypeProcessWithdrawal, tasks.ProcessWithdrawalPayload{WithdrawalID: withdrawal.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetWithdrawalByID(ctx, created.ID)
+}
+
+func (s *withdrawalService) ListWithdrawals(ctx context.Context, userID int64) ([]*domain.Withdrawal, error) {
+	return s.store.ListWithdrawalsByUser(ctx, userID)
+}
+
+func (s *withdrawalService) GetWithdrawal(ctx context.Context, userID, id int64) (*domain.Withdrawal, error) {
+	withdrawal, err := s.store.GetWithdrawalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if withdrawal == nil || withdrawal.UserID != userID {
+		return nil, ErrWithdrawalNotFound
+	}
+
+	return withdrawal, nil
+}
+
+// CancelWithdrawal releases the held amount of a withdrawal the worker has
+// not yet submitted to the payout provider.
+func (s *withdrawalService) CancelWithdrawal(ctx context.Context, userID, id int64) (*domain.Withdrawal, error) {
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		withdrawal, err := q.GetWithdrawalForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if withdrawal == nil || withdrawal.UserID != userID {
+			return ErrWithdrawalNotFound
+		}
+
+		if withdrawal.Status != domain.WithdrawalStatusPending {
+			return ErrWithdrawalNotPending
+		}
+
+		return releaseWithdrawal(ctx, q, withdrawal, domain.WithdrawalStatusCancelled, "", tasks.TopicWithdrawalCancelled)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetWithdrawalByID(ctx, id)
+}
+
+// ProcessWithdrawal advances the withdrawal one step through its state
+// machine. A PENDING withdrawal is moved to PROCESSING, after which it can no
+// longer be cancelled, and submitted to the payout provider; a PROCESSING one
+// is submitted again if no submission got through, or else checked on. The
+// provider's answer completes or fails the withdrawal, or schedules the next
+// check. Settled withdrawals are left untouched, so the task is idempotent.
+func (s *withdrawalService) ProcessWithdrawal(ctx context.Context, id int64) error {
+	var withdrawal *domain.Withdrawal
+
+	err := s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		var err error
+		withdrawal, err = q.GetWithdrawalForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if withdrawal == nil {
+			return ErrWithdrawalNotFound
+		}
+
+		if withdrawal.Status != domain.WithdrawalStatusPending {
+			return nil
+		}
+
+		// Keep the periodic sweep away while this attempt is in flight.
+		next := time.Now().UTC().Add(withdrawalPollInterval)
+		withdrawal.Status = domain.WithdrawalStatusProcessing
+		withdrawal.NextAttemptAt = &next
+		if err := q.UpdateWithdrawal(ctx, withdrawal); err != nil {
+			return err
+		}
+
+		return publishWithdrawalEvent(ctx, q, tasks.TopicWithdrawalProcessing, withdrawal)
+	})
+	if err != nil {
+		return err
+	}
+
+	if withdrawal.Status != domain.WithdrawalStatusProcessing {
+		return nil
+	}
+
+	if withdrawal.Provider != s.provider.Name() {
+		return fmt.Errorf("withdrawal %d is paid out by %q, not %q", withdrawal.ID, withdrawal.Provider, s.provider.Name())
+	}
+
+	var result *domain.PayoutResult
+	if withdrawal.ProviderReference == nil {
+		beneficiary, err := s.store.GetBankBeneficiaryByID(ctx, withdrawal.BeneficiaryID)
+		if err != nil {
+			return err
+		}
+
+		if beneficiary == nil {
+			return ErrBeneficiaryNotFound
+		}
+
+		result, err = s.provider.SubmitPayout(ctx, withdrawal, beneficiary)
+		if err != nil {
+			return s.retryWithdrawal(ctx, id, err)
+		}
+	} else {
+		result, err = s.provider.GetPayout(ctx, *withdrawal.ProviderReference)
+		if err != nil {
+			return s.retryWithdrawal(ctx, id, err)
+		}
+	}
+
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		withdrawal, err := q.GetWithdrawalForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if withdrawal == nil || withdrawal.Status != domain.WithdrawalStatusProcessing {
+			return nil
+		}
+
+		if withdrawal.ProviderReference == nil && result.Reference != "" {
+			withdrawal.ProviderReference = &result.Reference
+		}
+
+		switch result.Status {
+		case domain.PayoutStatusSucceeded:
+			return completeWithdrawal(ctx, q, withdrawal)
+		case domain.PayoutStatusFailed:
+			reason := result.FailureReason
+			if reason == "" {
+				reason = "payout failed"
+			}
+			return releaseWithdrawal(ctx, q, withdrawal, domain.WithdrawalStatusFailed, truncate(reason, 255), tasks.TopicWithdrawalFailed)
+		default:
+			next := time.Now().UTC().Add(withdrawalPollInterval)
+			withdrawal.Attempts = 0
+			withdrawal.NextAttemptAt = &next
+			return q.UpdateWithdrawal(ctx, withdrawal)
+		}
+	})
+}
+
+// retryWithdrawal schedules the next attempt after the payout provider could
+// not be reached, backing off exponentially. The outcome of the failed call
+// is unknown, so the withdrawal stays PROCESSING and keeps its hold.
+func (s *withdrawalService) retryWithdrawal(ctx context.Context, id int64, providerErr error) error {
+	log.Printf("Error calling payout provider for withdrawal %d: %v", id, providerErr)
+
+	return s.store.ExecTx(ctx, func(q *repository.Queries) error {
+		withdrawal, err := q.GetWithdrawalForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if withdrawal == nil || withdrawal.Status != domain.WithdrawalStatusProcessing {
+			return nil
+		}
+
+		delay := min(withdrawalPollInterval<<min(withdrawal.Attempts, 10), withdrawalMaxRetryDelay)
+		next := time.Now().UTC().Add(delay)
+		withdrawal.Attempts++
+		withdrawal.NextAttemptAt = &next
+		return q.UpdateWithdrawal(ctx, withdrawal)
+	})
+}
+
+// ProcessDueWithdrawals advances withdrawals whose next attempt is due: those
+// whose process task was lost and those waiting on the payout provider. It
+// returns how many were processed without error.
+func (s *withdrawalService) ProcessDueWithdrawals(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.store.ListDueWithdrawalIDs(ctx, now, withdrawalBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, id := range ids {
+		if err := s.ProcessWithdrawal(ctx, id); err != nil {
+			log.Printf("Error processing withdrawal %d: %v", id, err)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// completeWithdrawal debits the held amount from the wallet, records the
+// debit in the ledger and marks the withdrawal COMPLETED.
+func completeWithdrawal(ctx context.Context, q *repository.Queries, withdrawal *domain.Withdrawal) error {
+	if _, err := q.GetWalletForUpdate(ctx, withdrawal.WalletID); err != nil {
+		return err
+	}
+
+	if err := q.AdjustWalletHeldBalance(ctx, withdrawal.WalletID, withdrawal.Amount.Neg()); err != nil {
+		return err
+	}
+
+	if err := q.AdjustWalletBalance(ctx, withdrawal.WalletID, withdrawal.Amount.Neg()); err != nil {
+		return err
+	}
+
+	err := q.CreateLedgerEntry(ctx, &domain.LedgerEntry{
+		WithdrawalID: &withdrawal.ID,
+		WalletID:     withdrawal.WalletID,
+		Type:         domain.LedgerEntryTypeWithdrawal,
+		Amount:       withdrawal.Amount.Neg(),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	withdrawal.Status = domain.WithdrawalStatusCompleted
+	withdrawal.NextAttemptAt = nil
+	withdrawal.CompletedAt = &now
+	if err := q.UpdateWithdrawal(ctx, withdrawal); err != nil {
+		return err
+	}
+
+	return publishWithdrawalEvent(ctx, q, tasks.TopicWithdrawalCompleted, withdrawal)
+}
+
+// releaseWithdrawal releases the held amount and moves the withdrawal to the
+// final status, FAILED or CANCELLED.
+func releaseWithdrawal(ctx context.Context, q *repository.Queries, withdrawal *domain.Withdrawal, status domain.WithdrawalStatus, reason, topic string) error {
+	if _, err := q.GetWalletForUpdate(ctx, withdrawal.WalletID); err != nil {
+		return err
+	}
+
+	if err := q.AdjustWalletHeldBalance(ctx, withdrawal.WalletID, withdrawal.Amount.Neg()); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	withdrawal.Status = status
+	withdrawal.FailureReason = reason
+	withdrawal.NextAttemptAt = nil
+	withdrawal.CompletedAt = &now
+	if err := q.UpdateWithdrawal(ctx, withdrawal); err != nil {
+		return err
+	}
+
+	return publishWithdrawalEvent(ctx, q, topic, withdrawal)
+}
+
+func publishWithdrawalEvent(ctx context.Context, q *repository.Queries, topic string, withdrawal *domain.Withdrawal) error {
+	return publishEvent(ctx, q, topic, tasks.WithdrawalEventPayload{
+		WithdrawalID:      withdrawal.ID,
+		UserID:            withdrawal.UserID,
+		WalletID:          withdrawal.WalletID,
+		BeneficiaryID:     withdrawal.BeneficiaryID,
+		Amount:            withdrawal.Amount,
+		Currency:          withdrawal.Currency,
+		Status:            string(withdrawal.Status),
+		ProviderReference: withdrawal.ProviderReference,
+		Reason:            withdrawal.FailureReason,
+	})
+}
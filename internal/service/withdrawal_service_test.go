@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/repository"
+)
+
+// withdrawalStore adds beneficiaries and withdrawals to ledgerStore.
+type withdrawalStore struct {
+	*ledgerStore
+	beneficiaries []*domain.BankBeneficiary
+	withdrawals   []*domain.Withdrawal
+}
+
+func (s *withdrawalStore) ExecTx(ctx context.Context, fn func(*repository.Queries) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(queriesFor(s))
+}
+
+func (s *withdrawalStore) GetBankBeneficiaryByID(ctx context.Context, id int64) (*domain.BankBeneficiary, error) {
+	copied := *s.beneficiaries[id-1]
+	return &copied, nil
+}
+
+func (s *withdrawalStore) CreateWithdrawal(ctx context.Context, withdrawal *domain.Withdrawal) error {
+	withdrawal.ID = int64(len(s.withdrawals) + 1)
+	copied := *withdrawal
+	s.withdrawals = append(s.withdrawals, &copied)
+	return nil
+}
+
+func (s *withdrawalStore) GetWithdrawalByID(ctx context.Context, id int64) (*domain.Withdrawal, error) {
+	copied := *s.withdrawals[id-1]
+	return &copied, nil
+}
+
+func (s *withdrawalStore) GetWithdrawalForUpdate(ctx context.Context, id int64) (*domain.Withdrawal, error) {
+	return s.GetWithdrawalByID(ctx, id)
+}
+
+func (s *withdrawalStore) UpdateWithdrawal(ctx context.Context, withdrawal *domain.Withdrawal) error {
+	copied := *withdrawal
+	s.withdrawals[withdrawal.ID-1] = &copied
+	return nil
+}
+
+// scriptedProvider answers each call with the next of its results, or fails
+// with err while err is set.
+type scriptedProvider struct {
+	results []domain.PayoutStatus
+	err     error
+	calls   int
+}
+
+func (p *scriptedProvider) Name() string {
+	return "scripted"
+}
+
+func (p *scriptedProvider) next() (*domain.PayoutResult, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	status := p.results[0]
+	p.results = p.results[1:]
+
+	result := &domain.PayoutResult{Reference: "po_1", Status: status}
+	if status == domain.PayoutStatusFailed {
+		result.FailureReason = "beneficiary account is closed"
+	}
+
+	return result, nil
+}
+
+func (p *scriptedProvider) SubmitPayout(ctx context.Context, withdrawal *domain.Withdrawal, beneficiary *domain.BankBeneficiary) (*domain.PayoutResult, error) {
+	return p.next()
+}
+
+func (p *scriptedProvider) GetPayout(ctx context.Context, reference string) (*domain.PayoutResult, error) {
+	return p.next()
+}
+
+func newWithdrawalService(t *testing.T, provider *scriptedProvider) (*withdrawalStore, domain.WithdrawalService, *domain.Withdrawal) {
+	t.Helper()
+
+	store := &withdrawalStore{ledgerStore: newLedgerStore()}
+	store.addUser(1, "100.00")
+	store.beneficiaries = []*domain.BankBeneficiary{{ID: 1, UserID: 1, Currency: "USD", AccountNumber: "12345678"}}
+	svc := NewWithdrawalService(store, provider)
+
+	withdrawal, err := svc.CreateWithdrawal(context.Background(), 1, 1, dec("30.00"))
+	if err != nil {
+		t.Fatalf("CreateWithdrawal() error = %v", err)
+	}
+
+	return store, svc, withdrawal
+}
+
+func (s *withdrawalStore) assertWithdrawal(t *testing.T, status domain.WithdrawalStatus) *domain.Withdrawal {
+	t.Helper()
+
+	withdrawal := s.withdrawals[0]
+	if withdrawal.Status != status {
+		t.Fatalf("withdrawal status = %s, want %s", withdrawal.Status, status)
+	}
+
+	return withdrawal
+}
+
+func TestWithdrawalCompletes(t *testing.T) {
+	provider := &scriptedProvider{results: []domain.PayoutStatus{domain.PayoutStatusPending, domain.PayoutStatusSucceeded}}
+	store, svc, withdrawal := newWithdrawalService(t, provider)
+	ctx := context.Background()
+
+	store.assertWithdrawal(t, domain.WithdrawalStatusPending)
+	store.assertBalance(t, 1, "100.00", "30.00")
+
+	// The provider accepts the payout; it is in flight and can no longer
+	// be cancelled.
+	if err := svc.ProcessWithdrawal(ctx, withdrawal.ID); err != nil {
+		t.Fatalf("ProcessWithdrawal() error = %v", err)
+	}
+
+	processing := store.assertWithdrawal(t, domain.WithdrawalStatusProcessing)
+	if processing.ProviderReference == nil || *processing.ProviderReference != "po_1" {
+		t.Errorf("provider reference = %v, want po_1", processing.ProviderReference)
+	}
+
+	if _, err := svc.CancelWithdrawal(ctx, 1, withdrawal.ID); !errors.Is(err, ErrWithdrawalNotPending) {
+		t.Errorf("CancelWithdrawal() while processing error = %v, want %v", err, ErrWithdrawalNotPending)
+	}
+
+	if err := svc.ProcessWithdrawal(ctx, withdrawal.ID); err != nil {
+		t.Fatalf("ProcessWithdrawal() error = %v", err)
+	}
+
+	completed := store.assertWithdrawal(t, domain.WithdrawalStatusCompleted)
+	if completed.CompletedAt == nil || completed.NextAttemptAt != nil {
+		t.Errorf("withdrawal = %+v, want completed with nothing scheduled", completed)
+	}
+
+	store.assertBalance(t, 1, "70.00", "0")
+
+	if len(store.ledger) != 1 || !store.ledger[0].Amount.Equal(dec("-30.00")) {
+		t.Errorf("ledger = %+v, want one -30.00 withdrawal entry", store.ledger)
+	}
+
+	// Settled withdrawals are never sent to the provider again.
+	if err := svc.ProcessWithdrawal(ctx, withdrawal.ID); err != nil {
+		t.Fatalf("ProcessWithdrawal() of a completed withdrawal error = %v", err)
+	}
+
+	if provider.calls != 2 {
+		t.Errorf("provider called %d times, want 2", provider.calls)
+	}
+}
+
+func TestWithdrawalFails(t *testing.T) {
+	provider := &scriptedProvider{results: []domain.PayoutStatus{domain.PayoutStatusFailed}}
+	store, svc, withdrawal := newWithdrawalService(t, provider)
+
+	if err := svc.ProcessWithdrawal(context.Background(), withdrawal.ID); err != nil {
+		t.Fatalf("ProcessWithdrawal() error = %v", err)
+	}
+
+	failed := store.assertWithdrawal(t, domain.WithdrawalStatusFailed)
+	if failed.FailureReason != "beneficiary account is closed" {
+		t.Errorf("failure reason = %q, want the provider's", failed.FailureReason)
+	}
+
+	store.assertBalance(t, 1, "100.00", "0")
+}
+
+func TestCancelPendingWithdrawal(t *testing.T) {
+	provider := &scriptedProvider{}
+	store, svc, withdrawal := newWithdrawalService(t, provider)
+	ctx := context.Background()
+
+	if _, err := svc.CancelWithdrawal(ctx, 2, withdrawal.ID); !errors.Is(err, ErrWithdrawalNotFound) {
+		t.Errorf("CancelWithdrawal() by another user error = %v, want %v", err, ErrWithdrawalNotFound)
+	}
+
+	if _, err := svc.CancelWithdrawal(ctx, 1, withdrawal.ID); err != nil {
+		t.Fatalf("CancelWithdrawal() error = %v", err)
+	}
+
+	store.assertWithdrawal(t, domain.WithdrawalStatusCancelled)
+	store.assertBalance(t, 1, "100.00", "0")
+
+	if err := svc.ProcessWithdrawal(ctx, withdrawal.ID); err != nil || provider.calls != 0 {
+		t.Errorf("ProcessWithdrawal() of a cancelled withdrawal = %v with %d provider calls, want a no-op", err, provider.calls)
+	}
+
+	if _, err := svc.CancelWithdrawal(ctx, 1, withdrawal.ID); !errors.Is(err, ErrWithdrawalNotPending) {
+		t.Errorf("second CancelWithdrawal() error = %v, want %v", err, ErrWithdrawalNotPending)
+	}
+}
+
+func TestCreateWithdrawalNeedsAvailableFunds(t *testing.T) {
+	store, svc, _ := newWithdrawalService(t, &scriptedProvider{})
+
+	// 30.00 of the 100.00 is already held by the first withdrawal.
+	if _, err := svc.CreateWithdrawal(context.Background(), 1, 1, dec("70.01")); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("CreateWithdrawal() error = %v, want %v", err, ErrInsufficientFunds)
+	}
+
+	if len(store.withdrawals) != 1 {
+		t.Errorf("%d withdrawals stored, want 1", len(store.withdrawals))
+	}
+
+	store.assertBalance(t, 1, "100.00", "30.00")
+}
+
+func TestWithdrawalRetryBackoff(t *testing.T) {
+	provider := &scriptedProvider{err: errors.New("connection refused")}
+	store, svc, withdrawal := newWithdrawalService(t, provider)
+	ctx := context.Background()
+
+	// Each failed call doubles the wait, from a minute up to an hour.
+	for attempt, want := range []time.Duration{
+		time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour,
+	} {
+		before := time.Now().UTC()
+		if err := svc.ProcessWithdrawal(ctx, withdrawal.ID); err != nil {
+			t.Fatalf("ProcessWithdrawal() error = %v", err)
+		}
+
+		retrying := store.assertWithdrawal(t, domain.WithdrawalStatusProcessing)
+		if retrying.Attempts != attempt+1 {
+			t.Errorf("attempts = %d, want %d", retrying.Attempts, attempt+1)
+		}
+
+		if delay := retrying.NextAttemptAt.Sub(before); delay < want || delay > want+time.Second {
+			t.Errorf("attempt %d retries after %s, want %s", attempt+1, delay, want)
+		}
+	}
+
+	// The outcome of the failed calls is unknown, so the hold stays.
+	store.assertBalance(t, 1, "100.00", "30.00")
+
+	// Once the provider answers, the backoff resets to the poll interval.
+	provider.err = nil
+	provider.results = []domain.PayoutStatus{domain.PayoutStatusPending}
+	before := time.Now().UTC()
+	if err := svc.ProcessWithdrawal(ctx, withdrawal.ID); err != nil {
+		t.Fatalf("ProcessWithdrawal() error = %v", err)
+	}
+
+	polling := store.assertWithdrawal(t, domain.WithdrawalStatusProcessing)
+	if polling.Attempts != 0 || polling.NextAttemptAt.Sub(before) > withdrawalPollInterval+time.Second {
+		t.Errorf("withdrawal = %+v, want the next check within the poll interval", polling)
+	}
+}
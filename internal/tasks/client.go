@@ -1,9 +1,15 @@
 package tasks
 
-import "github.com/hibiken/asynq"
+import (
+	"errors"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/hibiken/asynq"
+)
 
 type TaskProducer interface {
 	ProduceProcessTransferTask(transactionID int64) error
+	ProduceOutboxTask(event *domain.Outbox) error
 }
 
 type RedisTaskProducer struct {
@@ -25,4 +31,20 @@ func (p *RedisTaskProducer) ProduceProcessTransferTask(transactinID int64) error
 	
 	_,err = p.client.Enqueue(task)
 	return err
-}
\ No newline at end of file
+}
+
+// ProduceOutboxTask enqueues the task for an outbox row. Each row maps to a
+// fixed task ID, so relaying the same row twice enqueues it only once.
+func (p *RedisTaskProducer) ProduceOutboxTask(event *domain.Outbox) error {
+	task, err := NewOutboxTask(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.client.Enqueue(task)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+
+	return err
+}
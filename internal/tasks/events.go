@@ -0,0 +1,234 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Outbox topics for payment request state changes.
+const (
+	TopicPaymentRequestCreated   = "payment_request:created"
+	TopicPaymentRequestAccepted  = "payment_request:accepted"
+	TopicPaymentRequestDeclined  = "payment_request:declined"
+	TopicPaymentRequestCancelled = "payment_request:cancelled"
+	TopicPaymentRequestExpired   = "payment_request:expired"
+)
+
+type PaymentRequestEventPayload struct {
+	PaymentRequestID int64  `json:"payment_request_id"`
+	RequesterUserID  int64  `json:"requester_user_id"`
+	PayerUserID      int64  `json:"payer_user_id"`
+	Status           string `json:"status"`
+	TransactionID    *int64 `json:"transaction_id,omitempty"`
+}
+
+// Outbox topics emitted when the worker settles a transfer.
+const (
+	TopicTransferCompleted = "transfer:completed"
+	TopicTransferFailed    = "transfer:failed"
+)
+
+type TransferEventPayload struct {
+	TransactionID    int64           `json:"transaction_id"`
+	SenderWalletID   int64           `json:"sender_wallet_id"`
+	ReceiverWalletID int64           `json:"receiver_wallet_id"`
+	Amount           decimal.Decimal `json:"amount"`
+	Fee              decimal.Decimal `json:"fee"`
+	Status           string          `json:"status"`
+	Reason           string          `json:"reason,omitempty"`
+}
+
+// Outbox topics for hold state changes.
+const (
+	TopicHoldAuthorized = "hold:authorized"
+	TopicHoldCaptured   = "hold:captured"
+	TopicHoldVoided     = "hold:voided"
+	TopicHoldExpired    = "hold:expired"
+)
+
+type HoldEventPayload struct {
+	HoldID         int64           `json:"hold_id"`
+	WalletID       int64           `json:"wallet_id"`
+	PayeeWalletID  int64           `json:"payee_wallet_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	CapturedAmount decimal.Decimal `json:"captured_amount"`
+	Status         string          `json:"status"`
+	TransactionID  *int64          `json:"transaction_id,omitempty"`
+}
+
+// TopicUserNewDeviceLogin is emitted when a user logs in successfully from a
+// device they have not used before.
+const TopicUserNewDeviceLogin = "user:new_device_login"
+
+type UserLoginEventPayload struct {
+	UserID       int64  `json:"user_id"`
+	LoginEventID int64  `json:"login_event_id"`
+	IP           string `json:"ip"`
+	UserAgent    string `json:"user_agent"`
+}
+
+// TopicUserErased is emitted once a user's personal data has been
+// pseudonymised, so that downstream systems can erase their copies.
+const TopicUserErased = "user:erased"
+
+type UserErasedEventPayload struct {
+	UserID   int64     `json:"user_id"`
+	ErasedAt time.Time `json:"erased_at"`
+}
+
+// Outbox topics for checkout session state changes.
+const (
+	TopicCheckoutSessionCreated   = "checkout_session:created"
+	TopicCheckoutSessionConfirmed = "checkout_session:confirmed"
+	TopicCheckoutSessionCompleted = "checkout_session:completed"
+	TopicCheckoutSessionFailed    = "checkout_session:failed"
+	TopicCheckoutSessionCancelled = "checkout_session:cancelled"
+	TopicCheckoutSessionExpired   = "checkout_session:expired"
+)
+
+type CheckoutSessionEventPayload struct {
+	CheckoutSessionID int64           `json:"checkout_session_id"`
+	MerchantID        int64           `json:"merchant_id"`
+	MerchantUserID    int64           `json:"merchant_user_id"`
+	Reference         string          `json:"reference"`
+	Amount            decimal.Decimal `json:"amount"`
+	Currency          string          `json:"currency"`
+	Status            string          `json:"status"`
+	PayerUserID       *int64          `json:"payer_user_id,omitempty"`
+	TransactionID     *int64          `json:"transaction_id,omitempty"`
+	Reason            string          `json:"reason,omitempty"`
+}
+
+// Outbox topics for deposit state changes.
+const (
+	TopicDepositCreated   = "deposit:created"
+	TopicDepositSucceeded = "deposit:succeeded"
+	TopicDepositFailed    = "deposit:failed"
+)
+
+type DepositEventPayload struct {
+	DepositID int64           `json:"deposit_id"`
+	UserID    int64           `json:"user_id"`
+	WalletID  int64           `json:"wallet_id"`
+	Amount    decimal.Decimal `json:"amount"`
+	Currency  string          `json:"currency"`
+	Gateway   string          `json:"gateway"`
+	Status    string          `json:"status"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// Outbox topics for withdrawal state changes.
+const (
+	TopicWithdrawalCreated    = "withdrawal:created"
+	TopicWithdrawalProcessing = "withdrawal:processing"
+	TopicWithdrawalCompleted  = "withdrawal:completed"
+	TopicWithdrawalFailed     = "withdrawal:failed"
+	TopicWithdrawalCancelled  = "withdrawal:cancelled"
+)
+
+type WithdrawalEventPayload struct {
+	WithdrawalID      int64           `json:"withdrawal_id"`
+	UserID            int64           `json:"user_id"`
+	WalletID          int64           `json:"wallet_id"`
+	BeneficiaryID     int64           `json:"beneficiary_id"`
+	Amount            decimal.Decimal `json:"amount"`
+	Currency          string          `json:"currency"`
+	Status            string          `json:"status"`
+	ProviderReference *string         `json:"provider_reference,omitempty"`
+	Reason            string          `json:"reason,omitempty"`
+}
+
+// Outbox topics for payout batch state changes.
+const (
+	TopicPayoutBatchCreated   = "payout_batch:created"
+	TopicPayoutBatchCompleted = "payout_batch:completed"
+)
+
+type PayoutBatchEventPayload struct {
+	BatchID        int64           `json:"batch_id"`
+	UserID         int64           `json:"user_id"`
+	WalletID       int64           `json:"wallet_id"`
+	TotalAmount    decimal.Decimal `json:"total_amount"`
+	Currency       string          `json:"currency"`
+	ItemCount      int             `json:"item_count"`
+	SucceededCount int             `json:"succeeded_count"`
+	FailedCount    int             `json:"failed_count"`
+	Status         string          `json:"status"`
+}
+
+// Outbox topics for split payment state changes.
+const (
+	TopicSplitPaymentCreated   = "split_payment:created"
+	TopicSplitPaymentCompleted = "split_payment:completed"
+	TopicSplitPaymentFailed    = "split_payment:failed"
+)
+
+type SplitPaymentEventPayload struct {
+	SplitPaymentID int64           `json:"split_payment_id"`
+	SenderWalletID int64           `json:"sender_wallet_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	Currency       string          `json:"currency"`
+	LegCount       int             `json:"leg_count"`
+	Status         string          `json:"status"`
+	Reason         string          `json:"reason,omitempty"`
+}
+
+// Outbox topics for escrow state changes.
+const (
+	TopicEscrowFunded   = "escrow:funded"
+	TopicEscrowDisputed = "escrow:disputed"
+	TopicEscrowReleased = "escrow:released"
+	TopicEscrowRefunded = "escrow:refunded"
+)
+
+type EscrowEventPayload struct {
+	EscrowID        int64           `json:"escrow_id"`
+	WalletID        int64           `json:"wallet_id"`
+	PayeeWalletID   int64           `json:"payee_wallet_id"`
+	ArbiterUserID   *int64          `json:"arbiter_user_id,omitempty"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        string          `json:"currency"`
+	Status          string          `json:"status"`
+	SettledByUserID *int64          `json:"settled_by_user_id,omitempty"`
+	Reason          string          `json:"reason,omitempty"`
+}
+
+// Outbox topics for dispute state changes. A dispute is resolved when the
+// merchant accepts it, an admin decides it or the merchant's response window
+// closes; Status tells who won.
+const (
+	TopicDisputeOpened            = "dispute:opened"
+	TopicDisputeEvidenceSubmitted = "dispute:evidence_submitted"
+	TopicDisputeResponded         = "dispute:responded"
+	TopicDisputeResolved          = "dispute:resolved"
+	TopicDisputeWithdrawn         = "dispute:withdrawn"
+)
+
+type DisputeEventPayload struct {
+	DisputeID        int64           `json:"dispute_id"`
+	TransactionID    int64           `json:"transaction_id"`
+	PayerWalletID    int64           `json:"payer_wallet_id"`
+	MerchantWalletID int64           `json:"merchant_wallet_id"`
+	Amount           decimal.Decimal `json:"amount"`
+	Currency         string          `json:"currency"`
+	Reason           string          `json:"reason"`
+	Status           string          `json:"status"`
+	EvidenceID       *int64          `json:"evidence_id,omitempty"`
+	Party            string          `json:"party,omitempty"`
+	ResolvedByUserID *int64          `json:"resolved_by_user_id,omitempty"`
+	ResolutionNote   string          `json:"resolution_note,omitempty"`
+}
+
+// TopicVoucherRedeemed is published when a user redeems a voucher.
+const TopicVoucherRedeemed = "voucher:redeemed"
+
+type VoucherRedeemedEventPayload struct {
+	RedemptionID int64           `json:"redemption_id"`
+	VoucherID    int64           `json:"voucher_id"`
+	BatchID      int64           `json:"batch_id"`
+	UserID       int64           `json:"user_id"`
+	WalletID     int64           `json:"wallet_id"`
+	Amount       decimal.Decimal `json:"amount"`
+	Currency     string          `json:"currency"`
+}
@@ -2,7 +2,10 @@ package tasks
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/amankp-zop/wallet/internal/domain"
 	"github.com/hibiken/asynq"
 )
 
@@ -22,4 +25,166 @@ func NewProcessTransferTask(transcationID int64) (*asynq.Task, error){
 	}
 
 	return asynq.NewTask(TaskTypeProcessTransfer, payload), nil
-}
\ No newline at end of file
+}
+
+// TaskTypeProcessWithdrawal advances a withdrawal through its payout. It is
+// enqueued through the outbox when the withdrawal is created.
+const TaskTypeProcessWithdrawal = "withdrawal:process"
+
+type ProcessWithdrawalPayload struct {
+	WithdrawalID int64 `json:"withdrawal_id"`
+}
+
+// TaskTypeProcessPayoutBatchItem pays one item of a payout batch. It is
+// enqueued through the outbox for every item when the batch is created.
+const TaskTypeProcessPayoutBatchItem = "payout_batch:process_item"
+
+type ProcessPayoutBatchItemPayload struct {
+	ItemID int64 `json:"item_id"`
+}
+
+// TaskTypeProcessSplitPayment settles every leg of a split payment. It is
+// enqueued through the outbox when the split payment is created.
+const TaskTypeProcessSplitPayment = "split_payment:process"
+
+type ProcessSplitPaymentPayload struct {
+	SplitPaymentID int64 `json:"split_payment_id"`
+}
+
+// TaskTypeDispatchScheduledTransfers is enqueued periodically by the worker's
+// scheduler and carries no payload.
+const TaskTypeDispatchScheduledTransfers = "scheduled_transfer:dispatch"
+
+func NewDispatchScheduledTransfersTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeDispatchScheduledTransfers, nil)
+}
+
+// TaskTypeExpirePaymentRequests is enqueued periodically by the worker's
+// scheduler and carries no payload.
+const TaskTypeExpirePaymentRequests = "payment_request:expire"
+
+func NewExpirePaymentRequestsTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeExpirePaymentRequests, nil)
+}
+
+// TaskTypeExpireHolds is enqueued periodically by the worker's scheduler and
+// carries no payload.
+const TaskTypeExpireHolds = "hold:expire"
+
+func NewExpireHoldsTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeExpireHolds, nil)
+}
+
+// TaskTypeRelayOutbox is enqueued periodically by the worker's scheduler and
+// carries no payload.
+const TaskTypeRelayOutbox = "outbox:relay"
+
+func NewRelayOutboxTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeRelayOutbox, nil)
+}
+
+// TaskTypePublishEvent wraps every outbox topic that is not itself a task type,
+// so that domain events reach their subscribers through a single handler.
+const TaskTypePublishEvent = "event:publish"
+
+type PublishEventPayload struct {
+	EventID    int64           `json:"event_id"`
+	Type       string          `json:"type"`
+	Data       json.RawMessage `json:"data"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// TaskTypeBuildDataExport builds the archive of a requested data export. It
+// is enqueued through the outbox when the export is requested.
+const TaskTypeBuildDataExport = "data_export:build"
+
+type BuildDataExportPayload struct {
+	DataExportID int64 `json:"data_export_id"`
+}
+
+// TaskTypeExpireDataExports is enqueued periodically by the worker's
+// scheduler and carries no payload.
+const TaskTypeExpireDataExports = "data_export:expire"
+
+func NewExpireDataExportsTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeExpireDataExports, nil)
+}
+
+// TaskTypeExpireCheckoutSessions is enqueued periodically by the worker's
+// scheduler and carries no payload.
+const TaskTypeExpireCheckoutSessions = "checkout_session:expire"
+
+func NewExpireCheckoutSessionsTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeExpireCheckoutSessions, nil)
+}
+
+// TaskTypeExpireDeposits is enqueued periodically by the worker's scheduler
+// and carries no payload.
+const TaskTypeExpireDeposits = "deposit:expire"
+
+func NewExpireDepositsTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeExpireDeposits, nil)
+}
+
+// TaskTypeProcessDueWithdrawals is enqueued periodically by the worker's
+// scheduler and carries no payload. It submits and checks on withdrawals
+// whose next attempt is due.
+const TaskTypeProcessDueWithdrawals = "withdrawal:process_due"
+
+func NewProcessDueWithdrawalsTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeProcessDueWithdrawals, nil)
+}
+
+// TaskTypeReleaseDueEscrows is enqueued periodically by the worker's scheduler
+// and carries no payload.
+const TaskTypeReleaseDueEscrows = "escrow:release_due"
+
+func NewReleaseDueEscrowsTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeReleaseDueEscrows, nil)
+}
+
+// TaskTypeResolveOverdueDisputes is enqueued periodically by the worker's
+// scheduler and carries no payload. It resolves disputes whose merchant did
+// not respond in time.
+const TaskTypeResolveOverdueDisputes = "dispute:resolve_overdue"
+
+func NewResolveOverdueDisputesTask() *asynq.Task {
+	return asynq.NewTask(TaskTypeResolveOverdueDisputes, nil)
+}
+
+const TaskTypeDeliverWebhook = "webhook:deliver"
+
+// WebhookDeliveryMaxRetry bounds how often a webhook delivery is retried with
+// exponential backoff before it is marked FAILED.
+const WebhookDeliveryMaxRetry = 8
+
+type DeliverWebhookPayload struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// NewOutboxTask converts an outbox row into the task that publishes it. Topics
+// that name a task type are enqueued as that task; any other topic is a
+// domain event and is wrapped in a TaskTypePublishEvent task.
+func NewOutboxTask(event *domain.Outbox) (*asynq.Task, error) {
+	taskID := asynq.TaskID(fmt.Sprintf("outbox:%d", event.ID))
+
+	switch event.Topic {
+	case TaskTypeProcessTransfer, TaskTypeBuildDataExport, TaskTypeProcessWithdrawal, TaskTypeProcessPayoutBatchItem,
+		TaskTypeProcessSplitPayment:
+		return asynq.NewTask(event.Topic, event.Payload, taskID), nil
+	case TaskTypeDeliverWebhook:
+		return asynq.NewTask(event.Topic, event.Payload, taskID, asynq.MaxRetry(WebhookDeliveryMaxRetry)), nil
+	}
+
+	payload, err := json.Marshal(PublishEventPayload{
+		EventID:    event.ID,
+		Type:       event.Topic,
+		Data:       event.Payload,
+		OccurredAt: event.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(TaskTypePublishEvent, payload, taskID), nil
+}
@@ -0,0 +1,80 @@
+// Package voucher generates and normalizes voucher codes.
+package voucher
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Codes are 16 characters from an alphabet without the easily confused 0, 1,
+// I, L, O and U, printed in groups of four: XXXX-XXXX-XXXX-XXXX. That is
+// about 78 random bits, so codes cannot be guessed even at high request
+// rates.
+const (
+	alphabet  = "23456789ABCDEFGHJKMNPQRSTVWXYZ"
+	codeLen   = 16
+	groupLen  = 4
+	HintLen   = 4
+	separator = '-'
+)
+
+var ErrInvalidCode = errors.New("voucher code is not valid")
+
+// NewCode returns a new random code in its printed form.
+func NewCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(alphabet)))
+
+	for i := 0; i < codeLen; i++ {
+		if i > 0 && i%groupLen == 0 {
+			b.WriteByte(separator)
+		}
+
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(alphabet[n.Int64()])
+	}
+
+	return b.String(), nil
+}
+
+// Normalize returns code in upper case without separators or spaces, or
+// ErrInvalidCode if it cannot be a code.
+func Normalize(code string) (string, error) {
+	var b strings.Builder
+
+	for _, r := range strings.ToUpper(code) {
+		switch {
+		case r == separator || r == ' ':
+			continue
+		case !strings.ContainsRune(alphabet, r):
+			return "", ErrInvalidCode
+		}
+		b.WriteRune(r)
+	}
+
+	if b.Len() != codeLen {
+		return "", ErrInvalidCode
+	}
+
+	return b.String(), nil
+}
+
+// Hash returns the hex SHA-256 of a normalized code, which is what is
+// stored. Codes are random enough that a slow hash adds nothing.
+func Hash(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// Hint returns the last characters of a normalized code, to tell codes apart
+// without revealing them.
+func Hint(normalized string) string {
+	return normalized[len(normalized)-HintLen:]
+}
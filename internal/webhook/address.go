@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for endpoints that are, or resolve to, an
+// address webhooks must not be sent to.
+var ErrForbiddenAddress = errors.New("webhook endpoint must resolve to a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not covered by
+// netip's IsPrivate but is just as internal.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicAddress reports whether webhooks may be sent to addr. Loopback,
+// private, link-local (including the 169.254.169.254 metadata service),
+// unspecified and multicast addresses are refused.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// CheckHost resolves host and returns ErrForbiddenAddress unless every
+// address it resolves to is public.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenAddress, err)
+	}
+
+	for _, addr := range addrs {
+		if !IsPublicAddress(addr) {
+			return ErrForbiddenAddress
+		}
+	}
+
+	return nil
+}
+
+// NewDialer returns a dialer that refuses to connect to addresses that are
+// not public. CheckHost at registration is not enough on its own: the
+// endpoint's DNS records can be changed to point inside the network later.
+func NewDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			if !IsPublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.0.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"100.64.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublicAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublicAddress(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	if err := CheckHost(context.Background(), "93.184.215.14"); err != nil {
+		t.Errorf("CheckHost(public) error = %v", err)
+	}
+
+	for _, host := range []string{"localhost", "127.0.0.1", "169.254.169.254"} {
+		if err := CheckHost(context.Background(), host); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("CheckHost(%q) error = %v, want %v", host, err, ErrForbiddenAddress)
+		}
+	}
+}
+
+func TestDialerRefusesInternalAddress(t *testing.T) {
+	_, err := NewDialer(0).DialContext(context.Background(), "tcp", "127.0.0.1:9")
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("DialContext() error = %v, want %v", err, ErrForbiddenAddress)
+	}
+}
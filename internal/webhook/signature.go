@@ -0,0 +1,86 @@
+// Package webhook implements the signature scheme used for outgoing webhook
+// deliveries, and the address checks that keep deliveries out of internal
+// networks. Receivers can use Verify to authenticate a request.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader  = "X-Wallet-Signature"
+	TimestampHeader  = "X-Wallet-Timestamp"
+	EventTypeHeader  = "X-Wallet-Event"
+	DeliveryIDHeader = "X-Wallet-Delivery"
+
+	// DefaultTolerance is the maximum age of a delivery a receiver should
+	// accept, limiting how long a captured request can be replayed.
+	DefaultTolerance = 5 * time.Minute
+
+	signatureVersion = "v1"
+	secretPrefix     = "whsec_"
+)
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrInvalidTimestamp = errors.New("webhook timestamp is missing or outside the tolerance")
+)
+
+// NewSecret returns a random signing secret for a new endpoint.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value for body sent at timestamp. The MAC
+// covers "<unix timestamp>.<body>", so the timestamp cannot be altered without
+// invalidating the signature.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signatureVersion + "=" + hex.EncodeToString(mac(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+// Verify checks the signature and timestamp headers of a delivery received at
+// now.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	age := now.Sub(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrInvalidTimestamp
+	}
+
+	version, digest, ok := strings.Cut(signature, "=")
+	if !ok || version != signatureVersion {
+		return ErrInvalidSignature
+	}
+
+	got, err := hex.DecodeString(digest)
+	if err != nil || !hmac.Equal(got, mac(secret, timestamp, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+
+	return h.Sum(nil)
+}
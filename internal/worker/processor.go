@@ -0,0 +1,306 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/domain"
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/hibiken/asynq"
+)
+
+// Services are the application services the worker's task handlers call into.
+type Services struct {
+	Transactions       domain.TransactionService
+	ScheduledTransfers domain.ScheduledTransferService
+	PaymentRequests    domain.PaymentRequestService
+	Holds              domain.HoldService
+	Outbox             domain.OutboxService
+	Webhooks           domain.WebhookService
+	Stream             domain.StreamService
+	Privacy            domain.PrivacyService
+	Checkout           domain.CheckoutService
+	Deposits           domain.DepositService
+	Withdrawals        domain.WithdrawalService
+	PayoutBatches      domain.PayoutBatchService
+	SplitPayments      domain.SplitPaymentService
+	Escrows            domain.EscrowService
+	Disputes           domain.DisputeService
+}
+
+// TaskProcessor handles the asynq tasks consumed by the worker binary.
+type TaskProcessor struct {
+	services Services
+}
+
+func NewTaskProcessor(services Services) *TaskProcessor {
+	return &TaskProcessor{
+		services: services,
+	}
+}
+
+// Register wires every task handler into mux.
+func (p *TaskProcessor) Register(mux *asynq.ServeMux) {
+	mux.HandleFunc(tasks.TaskTypeRelayOutbox, p.HandleRelayOutbox)
+	mux.HandleFunc(tasks.TaskTypePublishEvent, p.HandlePublishEvent)
+	mux.HandleFunc(tasks.TaskTypeProcessTransfer, p.HandleProcessTransfer)
+	mux.HandleFunc(tasks.TaskTypeDeliverWebhook, p.HandleDeliverWebhook)
+	mux.HandleFunc(tasks.TaskTypeDispatchScheduledTransfers, p.HandleDispatchScheduledTransfers)
+	mux.HandleFunc(tasks.TaskTypeExpirePaymentRequests, p.HandleExpirePaymentRequests)
+	mux.HandleFunc(tasks.TaskTypeExpireHolds, p.HandleExpireHolds)
+	mux.HandleFunc(tasks.TaskTypeBuildDataExport, p.HandleBuildDataExport)
+	mux.HandleFunc(tasks.TaskTypeExpireDataExports, p.HandleExpireDataExports)
+	mux.HandleFunc(tasks.TaskTypeExpireCheckoutSessions, p.HandleExpireCheckoutSessions)
+	mux.HandleFunc(tasks.TaskTypeExpireDeposits, p.HandleExpireDeposits)
+	mux.HandleFunc(tasks.TaskTypeProcessWithdrawal, p.HandleProcessWithdrawal)
+	mux.HandleFunc(tasks.TaskTypeProcessDueWithdrawals, p.HandleProcessDueWithdrawals)
+	mux.HandleFunc(tasks.TaskTypeProcessPayoutBatchItem, p.HandleProcessPayoutBatchItem)
+	mux.HandleFunc(tasks.TaskTypeProcessSplitPayment, p.HandleProcessSplitPayment)
+	mux.HandleFunc(tasks.TaskTypeReleaseDueEscrows, p.HandleReleaseDueEscrows)
+	mux.HandleFunc(tasks.TaskTypeResolveOverdueDisputes, p.HandleResolveOverdueDisputes)
+}
+
+// RegisterPeriodicTasks schedules the recurring maintenance tasks. Each task is
+// unique for its interval so a slow run is not stacked up behind itself.
+func RegisterPeriodicTasks(scheduler *asynq.Scheduler) error {
+	periodic := []struct {
+		every time.Duration
+		task  *asynq.Task
+	}{
+		{5 * time.Second, tasks.NewRelayOutboxTask()},
+		{time.Minute, tasks.NewDispatchScheduledTransfersTask()},
+		{5 * time.Minute, tasks.NewExpirePaymentRequestsTask()},
+		{time.Minute, tasks.NewExpireHoldsTask()},
+		{time.Hour, tasks.NewExpireDataExportsTask()},
+		{time.Minute, tasks.NewExpireCheckoutSessionsTask()},
+		{5 * time.Minute, tasks.NewExpireDepositsTask()},
+		{time.Minute, tasks.NewProcessDueWithdrawalsTask()},
+		{time.Minute, tasks.NewReleaseDueEscrowsTask()},
+		{5 * time.Minute, tasks.NewResolveOverdueDisputesTask()},
+	}
+
+	for _, p := range periodic {
+		if _, err := scheduler.Register("@every "+p.every.String(), p.task, asynq.Unique(p.every)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RetryDelay backs webhook deliveries off exponentially from 30 seconds up to
+// six hours, with jitter, and uses asynq's default for every other task.
+func RetryDelay(n int, err error, t *asynq.Task) time.Duration {
+	if t.Type() != tasks.TaskTypeDeliverWebhook {
+		return asynq.DefaultRetryDelayFunc(n, err, t)
+	}
+
+	delay := 30 * time.Second << min(n, 10)
+	delay = min(delay, 6*time.Hour)
+
+	return delay + rand.N(delay/10+1)
+}
+
+func (p *TaskProcessor) HandleRelayOutbox(ctx context.Context, t *asynq.Task) error {
+	_, err := p.services.Outbox.RelayOutbox(ctx)
+
+	return err
+}
+
+func (p *TaskProcessor) HandlePublishEvent(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.PublishEventPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid %s payload: %v: %w", t.Type(), err, asynq.SkipRetry)
+	}
+
+	// Both consumers are idempotent per event, so a retry after a partial
+	// failure is safe.
+	if err := p.services.Stream.PublishEvent(ctx, payload.EventID, payload.Type, payload.Data); err != nil {
+		return err
+	}
+
+	_, err := p.services.Webhooks.DispatchEvent(ctx, payload.EventID, payload.Type, payload.Data, payload.OccurredAt)
+
+	return err
+}
+
+func (p *TaskProcessor) HandleProcessTransfer(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.ProcessTransferPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid %s payload: %v: %w", t.Type(), err, asynq.SkipRetry)
+	}
+
+	return p.services.Transactions.ProcessTransfer(ctx, payload.TransactionID)
+}
+
+func (p *TaskProcessor) HandleDeliverWebhook(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.DeliverWebhookPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid %s payload: %v: %w", t.Type(), err, asynq.SkipRetry)
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+
+	return p.services.Webhooks.DeliverWebhook(ctx, payload.DeliveryID, retried >= maxRetry)
+}
+
+func (p *TaskProcessor) HandleDispatchScheduledTransfers(ctx context.Context, t *asynq.Task) error {
+	processed, err := p.services.ScheduledTransfers.ProcessDueScheduledTransfers(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if processed > 0 {
+		log.Printf("Dispatched %d scheduled transfers", processed)
+	}
+
+	return nil
+}
+
+func (p *TaskProcessor) HandleExpirePaymentRequests(ctx context.Context, t *asynq.Task) error {
+	expired, err := p.services.PaymentRequests.ExpirePaymentRequests(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d payment requests", expired)
+	}
+
+	return nil
+}
+
+func (p *TaskProcessor) HandleExpireHolds(ctx context.Context, t *asynq.Task) error {
+	expired, err := p.services.Holds.ExpireHolds(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 {
+		log.Printf("Released %d expired holds", expired)
+	}
+
+	return nil
+}
+
+func (p *TaskProcessor) HandleBuildDataExport(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.BuildDataExportPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid %s payload: %v: %w", t.Type(), err, asynq.SkipRetry)
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+
+	return p.services.Privacy.BuildDataExport(ctx, payload.DataExportID, retried >= maxRetry)
+}
+
+func (p *TaskProcessor) HandleExpireDataExports(ctx context.Context, t *asynq.Task) error {
+	expired, err := p.services.Privacy.ExpireDataExports(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 {
+		log.Printf("Deleted %d expired data exports", expired)
+	}
+
+	return nil
+}
+
+func (p *TaskProcessor) HandleExpireCheckoutSessions(ctx context.Context, t *asynq.Task) error {
+	expired, err := p.services.Checkout.ExpireCheckoutSessions(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d checkout sessions", expired)
+	}
+
+	return nil
+}
+
+func (p *TaskProcessor) HandleExpireDeposits(ctx context.Context, t *asynq.Task) error {
+	expired, err := p.services.Deposits.ExpireDeposits(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d deposits", expired)
+	}
+
+	return nil
+}
+
+func (p *TaskProcessor) HandleProcessWithdrawal(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.ProcessWithdrawalPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid %s payload: %v: %w", t.Type(), err, asynq.SkipRetry)
+	}
+
+	return p.services.Withdrawals.ProcessWithdrawal(ctx, payload.WithdrawalID)
+}
+
+func (p *TaskProcessor) HandleProcessDueWithdrawals(ctx context.Context, t *asynq.Task) error {
+	processed, err := p.services.Withdrawals.ProcessDueWithdrawals(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if processed > 0 {
+		log.Printf("Processed %d due withdrawals", processed)
+	}
+
+	return nil
+}
+
+func (p *TaskProcessor) HandleProcessPayoutBatchItem(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.ProcessPayoutBatchItemPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid %s payload: %v: %w", t.Type(), err, asynq.SkipRetry)
+	}
+
+	return p.services.PayoutBatches.ProcessPayoutBatchItem(ctx, payload.ItemID)
+}
+
+func (p *TaskProcessor) HandleProcessSplitPayment(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.ProcessSplitPaymentPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid %s payload: %v: %w", t.Type(), err, asynq.SkipRetry)
+	}
+
+	return p.services.SplitPayments.ProcessSplitPayment(ctx, payload.SplitPaymentID)
+}
+
+func (p *TaskProcessor) HandleReleaseDueEscrows(ctx context.Context, t *asynq.Task) error {
+	released, err := p.services.Escrows.ReleaseDueEscrows(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if released > 0 {
+		log.Printf("Released %d escrows past their deadline", released)
+	}
+
+	return nil
+}
+
+func (p *TaskProcessor) HandleResolveOverdueDisputes(ctx context.Context, t *asynq.Task) error {
+	resolved, err := p.services.Disputes.ResolveOverdueDisputes(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if resolved > 0 {
+		log.Printf("Resolved %d disputes the merchant did not respond to", resolved)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankp-zop/wallet/internal/tasks"
+	"github.com/hibiken/asynq"
+)
+
+func TestRetryDelayBacksOffWebhookDeliveries(t *testing.T) {
+	task := asynq.NewTask(tasks.TaskTypeDeliverWebhook, nil)
+	err := errors.New("webhook endpoint responded with status 503")
+
+	tests := []struct {
+		retried int
+		base    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, 6 * time.Hour},
+		{24, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			got := RetryDelay(tt.retried, err, task)
+			if got < tt.base || got > tt.base+tt.base/10 {
+				t.Errorf("RetryDelay(%d) = %v, want %v plus up to 10%% jitter", tt.retried, got, tt.base)
+			}
+		}
+	}
+}
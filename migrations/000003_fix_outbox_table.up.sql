@@ -0,0 +1,11 @@
+-- 000002 shipped with its up and down swapped, so databases that ran it have
+-- no outbox table. Create it here rather than rewriting 000002.
+CREATE TABLE IF NOT EXISTS `outbox`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `topic` VARCHAR(255) NOT NULL,
+    `payload` JSON NOT NULL,
+    `status` ENUM('UNPUBLISHED', 'PUBLISHED') NOT NULL DEFAULT 'UNPUBLISHED',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    INDEX `idx_outbox_status` (`status`)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `scheduled_transfer_runs`;
+DROP TABLE IF EXISTS `scheduled_transfers`;
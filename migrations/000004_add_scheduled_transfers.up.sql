@@ -0,0 +1,36 @@
+CREATE TABLE `scheduled_transfers`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `sender_user_id` BIGINT UNSIGNED NOT NULL,
+    `receiver_user_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `memo` VARCHAR(255) NOT NULL DEFAULT '',
+    `schedule_type` ENUM('ONCE', 'CRON', 'MONTHLY') NOT NULL,
+    `cron_expr` VARCHAR(100) NOT NULL DEFAULT '',
+    `day_of_month` TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    `next_run_at` TIMESTAMP NULL DEFAULT NULL,
+    `last_run_at` TIMESTAMP NULL DEFAULT NULL,
+    `status` ENUM('ACTIVE', 'PAUSED', 'CANCELLED', 'COMPLETED', 'FAILED') NOT NULL DEFAULT 'ACTIVE',
+    `failure_count` INT UNSIGNED NOT NULL DEFAULT 0,
+    `last_error` VARCHAR(255) NOT NULL DEFAULT '',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`sender_user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`receiver_user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE INDEX `idx_scheduled_transfers_due` ON `scheduled_transfers`(`status`, `next_run_at`);
+
+CREATE TABLE `scheduled_transfer_runs`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `scheduled_transfer_id` BIGINT UNSIGNED NOT NULL,
+    `scheduled_for` TIMESTAMP NOT NULL,
+    `transaction_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `status` ENUM('SUCCEEDED', 'FAILED', 'SKIPPED') NOT NULL,
+    `error` VARCHAR(255) NOT NULL DEFAULT '',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_scheduled_transfer_runs_occurrence` (`scheduled_transfer_id`, `scheduled_for`),
+    FOREIGN KEY (`scheduled_transfer_id`) REFERENCES `scheduled_transfers`(`id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`)
+);
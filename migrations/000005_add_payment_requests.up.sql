@@ -0,0 +1,20 @@
+CREATE TABLE `payment_requests`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `requester_user_id` BIGINT UNSIGNED NOT NULL,
+    `payer_user_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `memo` VARCHAR(255) NOT NULL DEFAULT '',
+    `status` ENUM('PENDING', 'ACCEPTED', 'DECLINED', 'CANCELLED', 'EXPIRED') NOT NULL DEFAULT 'PENDING',
+    `transaction_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`requester_user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`payer_user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`)
+);
+
+CREATE INDEX `idx_payment_requests_payer_status` ON `payment_requests`(`payer_user_id`, `status`);
+CREATE INDEX `idx_payment_requests_requester_status` ON `payment_requests`(`requester_user_id`, `status`);
+CREATE INDEX `idx_payment_requests_status_expires` ON `payment_requests`(`status`, `expires_at`);
@@ -0,0 +1,21 @@
+ALTER TABLE `wallets` ADD COLUMN `held_balance` DECIMAL(19,4) NOT NULL DEFAULT 0.0000 AFTER `balance`;
+
+CREATE TABLE `holds`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    `payee_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `captured_amount` DECIMAL(19,4) NOT NULL DEFAULT 0.0000,
+    `reference` VARCHAR(255) NOT NULL DEFAULT '',
+    `status` ENUM('AUTHORIZED', 'CAPTURED', 'VOIDED', 'EXPIRED') NOT NULL DEFAULT 'AUTHORIZED',
+    `transaction_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`payee_wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`)
+);
+
+CREATE INDEX `idx_holds_status_expires` ON `holds`(`status`, `expires_at`);
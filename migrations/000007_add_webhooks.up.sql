@@ -0,0 +1,45 @@
+ALTER TABLE `outbox` ADD COLUMN `published_at` TIMESTAMP NULL DEFAULT NULL;
+
+CREATE TABLE `webhook_endpoints`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `url` VARCHAR(2048) NOT NULL,
+    `secret` VARCHAR(255) NOT NULL,
+    `event_types` JSON NOT NULL,
+    `description` VARCHAR(255) NOT NULL DEFAULT '',
+    `status` ENUM('ACTIVE', 'DISABLED') NOT NULL DEFAULT 'ACTIVE',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_webhook_endpoints_user_status` ON `webhook_endpoints`(`user_id`, `status`);
+
+CREATE TABLE `webhook_deliveries`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `endpoint_id` BIGINT UNSIGNED NOT NULL,
+    `event_id` BIGINT UNSIGNED NOT NULL,
+    `event_type` VARCHAR(255) NOT NULL,
+    `payload` JSON NOT NULL,
+    `status` ENUM('PENDING', 'SUCCEEDED', 'FAILED') NOT NULL DEFAULT 'PENDING',
+    `attempts` INT UNSIGNED NOT NULL DEFAULT 0,
+    `last_attempt_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_webhook_deliveries_endpoint_event` (`endpoint_id`, `event_id`),
+    FOREIGN KEY (`endpoint_id`) REFERENCES `webhook_endpoints`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `webhook_delivery_attempts`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `delivery_id` BIGINT UNSIGNED NOT NULL,
+    `response_status` INT NOT NULL DEFAULT 0,
+    `response_body` VARCHAR(1024) NOT NULL DEFAULT '',
+    `error` VARCHAR(1024) NOT NULL DEFAULT '',
+    `duration_ms` INT UNSIGNED NOT NULL DEFAULT 0,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`delivery_id`) REFERENCES `webhook_deliveries`(`id`) ON DELETE CASCADE
+);
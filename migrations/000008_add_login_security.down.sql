@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `user_devices`;
+DROP TABLE IF EXISTS `login_throttles`;
+DROP TABLE IF EXISTS `login_events`;
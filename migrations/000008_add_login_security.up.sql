@@ -0,0 +1,40 @@
+CREATE TABLE `login_events`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `ip` VARCHAR(45) NOT NULL,
+    `user_agent` VARCHAR(512) NOT NULL DEFAULT '',
+    `success` BOOLEAN NOT NULL,
+    `new_device` BOOLEAN NOT NULL DEFAULT FALSE,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_login_events_user_created` ON `login_events`(`user_id`, `created_at`);
+
+-- login_throttles counts recent failed logins per account and per IP. Accounts
+-- are keyed by a hash of the submitted email so that unknown emails are
+-- throttled exactly like real ones.
+CREATE TABLE `login_throttles`(
+    `scope` ENUM('ACCOUNT', 'IP') NOT NULL,
+    `subject` VARCHAR(64) NOT NULL,
+    `failures` INT UNSIGNED NOT NULL DEFAULT 0,
+    `lockouts` INT UNSIGNED NOT NULL DEFAULT 0,
+    `locked_until` TIMESTAMP NULL DEFAULT NULL,
+    `last_failure_at` TIMESTAMP NULL DEFAULT NULL,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`scope`, `subject`)
+);
+
+CREATE TABLE `user_devices`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `fingerprint` CHAR(64) NOT NULL,
+    `user_agent` VARCHAR(512) NOT NULL DEFAULT '',
+    `last_ip` VARCHAR(45) NOT NULL,
+    `first_seen_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `last_seen_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_user_devices_user_fingerprint` (`user_id`, `fingerprint`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+-- totp_secret is set by enrolment and only takes effect once totp_enabled is
+-- confirmed. totp_last_step records the last accepted time step so that a
+-- code cannot be replayed.
+ALTER TABLE `users`
+    ADD COLUMN `totp_secret` VARCHAR(64) NULL DEFAULT NULL AFTER `password`,
+    ADD COLUMN `totp_enabled` BOOLEAN NOT NULL DEFAULT FALSE AFTER `totp_secret`,
+    ADD COLUMN `totp_last_step` BIGINT NOT NULL DEFAULT 0 AFTER `totp_enabled`;
+
+-- recovery_codes holds SHA-256 hashes of single-use recovery codes.
+CREATE TABLE `recovery_codes`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `code_hash` CHAR(64) NOT NULL,
+    `used_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_recovery_codes_user_hash` (`user_id`, `code_hash`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
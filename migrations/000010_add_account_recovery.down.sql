@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `user_tokens`;
+
+ALTER TABLE `users`
+    DROP COLUMN `sessions_valid_after`,
+    DROP COLUMN `email_verified_at`;
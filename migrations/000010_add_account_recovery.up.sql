@@ -0,0 +1,23 @@
+-- sessions_valid_after revokes every token issued before it, which is how a
+-- password change or reset signs out other sessions.
+ALTER TABLE `users`
+    ADD COLUMN `email_verified_at` TIMESTAMP NULL DEFAULT NULL AFTER `email`,
+    ADD COLUMN `sessions_valid_after` TIMESTAMP NULL DEFAULT NULL AFTER `totp_last_step`;
+
+-- user_tokens holds SHA-256 hashes of single-use tokens mailed to users. email
+-- is the address the token was sent to.
+CREATE TABLE `user_tokens`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `purpose` ENUM('EMAIL_VERIFICATION', 'PASSWORD_RESET') NOT NULL,
+    `token_hash` CHAR(64) NOT NULL,
+    `email` VARCHAR(255) NOT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    `used_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_user_tokens_hash` (`token_hash`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_user_tokens_user_purpose` ON `user_tokens`(`user_id`, `purpose`);
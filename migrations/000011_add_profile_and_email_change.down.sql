@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS `email_changes`;
+
+DELETE FROM `user_tokens` WHERE `purpose` IN ('EMAIL_CHANGE_CURRENT', 'EMAIL_CHANGE_NEW');
+
+ALTER TABLE `user_tokens`
+    MODIFY COLUMN `purpose` ENUM('EMAIL_VERIFICATION', 'PASSWORD_RESET') NOT NULL;
+
+ALTER TABLE `users`
+    DROP COLUMN `timezone`,
+    DROP COLUMN `phone`,
+    MODIFY COLUMN `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
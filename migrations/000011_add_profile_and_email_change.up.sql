@@ -0,0 +1,28 @@
+-- updated_at gets microsecond precision because it doubles as the profile's
+-- ETag for optimistic concurrency.
+ALTER TABLE `users`
+    ADD COLUMN `phone` VARCHAR(32) NOT NULL DEFAULT '' AFTER `email_verified_at`,
+    ADD COLUMN `timezone` VARCHAR(64) NOT NULL DEFAULT '' AFTER `phone`,
+    MODIFY COLUMN `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6);
+
+ALTER TABLE `user_tokens`
+    MODIFY COLUMN `purpose` ENUM('EMAIL_VERIFICATION', 'PASSWORD_RESET', 'EMAIL_CHANGE_CURRENT', 'EMAIL_CHANGE_NEW') NOT NULL;
+
+-- email_changes tracks a requested email change until both the current and
+-- the new address have confirmed it.
+CREATE TABLE `email_changes`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `old_email` VARCHAR(255) NOT NULL,
+    `new_email` VARCHAR(255) NOT NULL,
+    `status` ENUM('PENDING', 'COMPLETED', 'CANCELLED') NOT NULL DEFAULT 'PENDING',
+    `old_confirmed_at` TIMESTAMP NULL DEFAULT NULL,
+    `new_confirmed_at` TIMESTAMP NULL DEFAULT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_email_changes_user_status` ON `email_changes`(`user_id`, `status`);
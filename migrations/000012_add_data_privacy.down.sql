@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS `data_exports`;
+
+ALTER TABLE `wallets`
+    DROP FOREIGN KEY `fk_wallets_user`,
+    ADD CONSTRAINT `wallets_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `users` DROP COLUMN `erased_at`;
@@ -0,0 +1,30 @@
+-- Erased users keep their row, pseudonymised, so that their wallets and the
+-- ledger stay intact. Deleting a user that still owns a wallet is refused
+-- instead of cascading into the ledger.
+ALTER TABLE `users` ADD COLUMN `erased_at` TIMESTAMP NULL DEFAULT NULL AFTER `sessions_valid_after`;
+
+ALTER TABLE `wallets`
+    DROP FOREIGN KEY `wallets_ibfk_1`,
+    ADD CONSTRAINT `fk_wallets_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE RESTRICT;
+
+-- data_exports tracks archives of a user's data built by the worker. path is
+-- relative to the configured export directory and cleared once the file is
+-- deleted.
+CREATE TABLE `data_exports`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `format` ENUM('JSON', 'ZIP') NOT NULL,
+    `status` ENUM('PENDING', 'READY', 'FAILED', 'EXPIRED') NOT NULL DEFAULT 'PENDING',
+    `path` VARCHAR(255) NOT NULL DEFAULT '',
+    `size_bytes` BIGINT NOT NULL DEFAULT 0,
+    `error` VARCHAR(1024) NOT NULL DEFAULT '',
+    `completed_at` TIMESTAMP NULL DEFAULT NULL,
+    `expires_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_data_exports_user` ON `data_exports`(`user_id`, `id`);
+CREATE INDEX `idx_data_exports_status_expires` ON `data_exports`(`status`, `expires_at`);
@@ -0,0 +1,16 @@
+-- Run `rotatekeys -decrypt` first: encrypted values do not fit the original
+-- columns.
+ALTER TABLE `user_tokens`
+    MODIFY COLUMN `email` VARCHAR(255) NOT NULL;
+
+ALTER TABLE `email_changes`
+    MODIFY COLUMN `old_email` VARCHAR(255) NOT NULL,
+    MODIFY COLUMN `new_email` VARCHAR(255) NOT NULL;
+
+ALTER TABLE `users`
+    DROP INDEX `uq_users_email_bidx`,
+    DROP COLUMN `email_bidx`,
+    MODIFY COLUMN `name` VARCHAR(255) NOT NULL,
+    MODIFY COLUMN `email` VARCHAR(255) NOT NULL,
+    MODIFY COLUMN `phone` VARCHAR(32) NOT NULL DEFAULT '',
+    ADD UNIQUE KEY `email` (`email`);
@@ -0,0 +1,18 @@
+-- PII columns hold values encrypted by the application, which are longer
+-- than the plaintext. Email lookups and uniqueness move to email_bidx, a
+-- keyed hash of the normalised address. email_bidx stays NULL for rows
+-- written before encryption until the rotatekeys command has encrypted them.
+ALTER TABLE `users`
+    DROP INDEX `email`,
+    MODIFY COLUMN `name` VARCHAR(1024) NOT NULL,
+    MODIFY COLUMN `email` VARCHAR(1024) NOT NULL,
+    MODIFY COLUMN `phone` VARCHAR(512) NOT NULL DEFAULT '',
+    ADD COLUMN `email_bidx` CHAR(64) NULL DEFAULT NULL AFTER `email`,
+    ADD UNIQUE KEY `uq_users_email_bidx` (`email_bidx`);
+
+ALTER TABLE `email_changes`
+    MODIFY COLUMN `old_email` VARCHAR(1024) NOT NULL,
+    MODIFY COLUMN `new_email` VARCHAR(1024) NOT NULL;
+
+ALTER TABLE `user_tokens`
+    MODIFY COLUMN `email` VARCHAR(1024) NOT NULL;
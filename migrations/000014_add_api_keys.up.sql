@@ -0,0 +1,21 @@
+-- api_keys are long-lived credentials for server-to-server access. Only a
+-- SHA-256 hash of the key is stored; prefix is the public part of the key
+-- used to look it up.
+CREATE TABLE `api_keys`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `name` VARCHAR(100) NOT NULL,
+    `prefix` CHAR(12) NOT NULL,
+    `key_hash` CHAR(64) NOT NULL,
+    `scopes` JSON NOT NULL,
+    `allowed_ips` JSON NOT NULL,
+    `expires_at` TIMESTAMP NULL DEFAULT NULL,
+    `last_used_at` TIMESTAMP NULL DEFAULT NULL,
+    `revoked_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_api_keys_prefix` (`prefix`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_api_keys_user` ON `api_keys`(`user_id`, `id`);
@@ -0,0 +1,3 @@
+ALTER TABLE `api_keys`
+    DROP COLUMN `require_signature`,
+    DROP COLUMN `signing_secret`;
@@ -0,0 +1,5 @@
+-- signing_secret keys the HMAC of signed API key requests. It is encrypted by
+-- the application and empty for keys created before request signing.
+ALTER TABLE `api_keys`
+    ADD COLUMN `signing_secret` VARCHAR(512) NOT NULL DEFAULT '' AFTER `key_hash`,
+    ADD COLUMN `require_signature` BOOLEAN NOT NULL DEFAULT FALSE AFTER `allowed_ips`;
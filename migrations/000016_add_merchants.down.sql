@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `checkout_sessions`;
+DROP TABLE IF EXISTS `merchants`;
@@ -0,0 +1,52 @@
+-- A merchant is a business profile owned by a user. Checkout payments settle
+-- into settlement_wallet_id; fee_plan names the fee schedule applied to them.
+CREATE TABLE `merchants`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `business_name` VARCHAR(255) NOT NULL,
+    `legal_name` VARCHAR(255) NOT NULL DEFAULT '',
+    `website` VARCHAR(2048) NOT NULL DEFAULT '',
+    `support_email` VARCHAR(255) NOT NULL DEFAULT '',
+    `country` CHAR(2) NOT NULL DEFAULT '',
+    `settlement_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `fee_plan` VARCHAR(64) NOT NULL DEFAULT 'standard',
+    `status` ENUM('ACTIVE', 'DISABLED') NOT NULL DEFAULT 'ACTIVE',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_merchants_user` (`user_id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`settlement_wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+-- token is the unguessable identifier payers use to view and confirm a
+-- session; id is only exposed to the merchant.
+CREATE TABLE `checkout_sessions`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `merchant_id` BIGINT UNSIGNED NOT NULL,
+    `token` VARCHAR(64) NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `reference` VARCHAR(255) NOT NULL,
+    `description` VARCHAR(255) NOT NULL DEFAULT '',
+    `success_url` VARCHAR(2048) NOT NULL,
+    `cancel_url` VARCHAR(2048) NOT NULL,
+    `status` ENUM('OPEN', 'PROCESSING', 'COMPLETED', 'FAILED', 'CANCELLED', 'EXPIRED') NOT NULL DEFAULT 'OPEN',
+    `payer_user_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `transaction_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `failure_reason` VARCHAR(255) NOT NULL DEFAULT '',
+    `expires_at` TIMESTAMP NOT NULL,
+    `completed_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_checkout_sessions_token` (`token`),
+    UNIQUE KEY `uq_checkout_sessions_merchant_reference` (`merchant_id`, `reference`),
+    UNIQUE KEY `uq_checkout_sessions_transaction` (`transaction_id`),
+    FOREIGN KEY (`merchant_id`) REFERENCES `merchants`(`id`),
+    FOREIGN KEY (`payer_user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`)
+);
+
+CREATE INDEX `idx_checkout_sessions_merchant` ON `checkout_sessions`(`merchant_id`, `id`);
+CREATE INDEX `idx_checkout_sessions_status_expires` ON `checkout_sessions`(`status`, `expires_at`);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS `ledger_entries`;
+DROP TABLE IF EXISTS `revenue_wallets`;
+DROP TABLE IF EXISTS `fee_schedules`;
+ALTER TABLE `transactions`
+    DROP FOREIGN KEY `fk_transactions_fee_wallet`,
+    DROP COLUMN `fee_wallet_id`,
+    DROP COLUMN `fee`;
@@ -0,0 +1,62 @@
+-- fee is deducted from what the receiver is credited and posted to
+-- fee_wallet_id, the house revenue wallet of the transfer's currency.
+ALTER TABLE `transactions`
+    ADD COLUMN `fee` DECIMAL(19,4) NOT NULL DEFAULT 0.0000 AFTER `amount`,
+    ADD COLUMN `fee_wallet_id` BIGINT UNSIGNED NULL DEFAULT NULL AFTER `fee`,
+    ADD CONSTRAINT `fk_transactions_fee_wallet` FOREIGN KEY (`fee_wallet_id`) REFERENCES `wallets`(`id`);
+
+-- A fee schedule applies to transfers under a fee plan, or to transfers to
+-- one merchant when merchant_id is set. A schedule with a currency only
+-- applies to that currency. The most specific active schedule wins: merchant
+-- before plan, then a matching currency before any currency.
+--
+-- FLAT charges flat_amount; PERCENTAGE charges flat_amount plus percentage of
+-- the amount; TIERED does the same with the flat_amount and percentage of the
+-- first tier whose up_to is at least the amount (a tier without up_to has no
+-- upper bound). The result is then clamped to min_fee and max_fee.
+CREATE TABLE `fee_schedules`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `plan` VARCHAR(64) NULL DEFAULT NULL,
+    `merchant_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `currency` VARCHAR(3) NULL DEFAULT NULL,
+    `type` ENUM('FLAT', 'PERCENTAGE', 'TIERED') NOT NULL,
+    `flat_amount` DECIMAL(19,4) NOT NULL DEFAULT 0.0000,
+    `percentage` DECIMAL(9,6) NOT NULL DEFAULT 0.000000,
+    `tiers` JSON NULL DEFAULT NULL,
+    `min_fee` DECIMAL(19,4) NULL DEFAULT NULL,
+    `max_fee` DECIMAL(19,4) NULL DEFAULT NULL,
+    `active` BOOLEAN NOT NULL DEFAULT TRUE,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`merchant_id`) REFERENCES `merchants`(`id`),
+    CHECK (`plan` IS NOT NULL OR `merchant_id` IS NOT NULL)
+);
+
+CREATE INDEX `idx_fee_schedules_plan` ON `fee_schedules`(`plan`, `active`);
+CREATE INDEX `idx_fee_schedules_merchant` ON `fee_schedules`(`merchant_id`, `active`);
+
+-- revenue_wallets names the house wallet collecting fees in each currency.
+CREATE TABLE `revenue_wallets`(
+    `currency` VARCHAR(3) NOT NULL,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (`currency`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+-- ledger_entries records every balance change a settled transaction made,
+-- one row per leg. Amounts are signed: debits are negative.
+CREATE TABLE `ledger_entries`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `transaction_id` BIGINT UNSIGNED NOT NULL,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    `type` ENUM('PRINCIPAL', 'FEE') NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+CREATE INDEX `idx_ledger_entries_transaction` ON `ledger_entries`(`transaction_id`);
+CREATE INDEX `idx_ledger_entries_wallet` ON `ledger_entries`(`wallet_id`, `id`);
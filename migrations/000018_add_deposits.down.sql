@@ -0,0 +1,7 @@
+DELETE FROM `ledger_entries` WHERE `deposit_id` IS NOT NULL;
+ALTER TABLE `ledger_entries`
+    DROP FOREIGN KEY `fk_ledger_entries_deposit`,
+    DROP COLUMN `deposit_id`,
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE') NOT NULL,
+    MODIFY COLUMN `transaction_id` BIGINT UNSIGNED NOT NULL;
+DROP TABLE IF EXISTS `deposits`;
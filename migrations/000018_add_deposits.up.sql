@@ -0,0 +1,33 @@
+-- A deposit is money paid into a wallet through a payment gateway. The
+-- gateway identifies it by gateway_reference once the intent is created.
+CREATE TABLE `deposits`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `gateway` VARCHAR(32) NOT NULL,
+    `gateway_reference` VARCHAR(255) NULL DEFAULT NULL,
+    `payment_url` VARCHAR(2048) NOT NULL DEFAULT '',
+    `status` ENUM('PENDING', 'SUCCEEDED', 'FAILED') NOT NULL DEFAULT 'PENDING',
+    `failure_reason` VARCHAR(255) NOT NULL DEFAULT '',
+    `expires_at` TIMESTAMP NOT NULL,
+    `completed_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_deposits_gateway_reference` (`gateway`, `gateway_reference`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+CREATE INDEX `idx_deposits_user` ON `deposits`(`user_id`, `id`);
+CREATE INDEX `idx_deposits_status_expires` ON `deposits`(`status`, `expires_at`);
+
+-- Deposits credit a wallet without a transaction, so a ledger entry belongs
+-- to either a transaction or a deposit.
+ALTER TABLE `ledger_entries`
+    MODIFY COLUMN `transaction_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT') NOT NULL,
+    ADD COLUMN `deposit_id` BIGINT UNSIGNED NULL DEFAULT NULL AFTER `transaction_id`,
+    ADD CONSTRAINT `fk_ledger_entries_deposit` FOREIGN KEY (`deposit_id`) REFERENCES `deposits`(`id`);
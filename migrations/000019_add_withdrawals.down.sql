@@ -0,0 +1,7 @@
+DELETE FROM `ledger_entries` WHERE `withdrawal_id` IS NOT NULL;
+ALTER TABLE `ledger_entries`
+    DROP FOREIGN KEY `fk_ledger_entries_withdrawal`,
+    DROP COLUMN `withdrawal_id`,
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT') NOT NULL;
+DROP TABLE IF EXISTS `withdrawals`;
+DROP TABLE IF EXISTS `bank_beneficiaries`;
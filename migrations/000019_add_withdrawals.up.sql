@@ -0,0 +1,56 @@
+-- A bank beneficiary is an external account a user withdraws to. The holder
+-- name and account number are encrypted by the application; account_last4 is
+-- kept in the clear to tell accounts apart.
+CREATE TABLE `bank_beneficiaries`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `nickname` VARCHAR(100) NOT NULL DEFAULT '',
+    `account_holder_name` VARCHAR(1024) NOT NULL,
+    `country` CHAR(2) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `account_type` ENUM('IBAN', 'ACCOUNT_NUMBER') NOT NULL,
+    `account_number` VARCHAR(1024) NOT NULL,
+    `account_last4` VARCHAR(4) NOT NULL,
+    `routing_code` VARCHAR(11) NOT NULL DEFAULT '',
+    `deleted_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE INDEX `idx_bank_beneficiaries_user` ON `bank_beneficiaries`(`user_id`, `id`);
+
+-- A withdrawal holds its amount on the wallet until the payout provider
+-- reports the payout paid or failed. The worker picks up withdrawals whose
+-- next_attempt_at has passed to submit them or check on them.
+CREATE TABLE `withdrawals`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    `beneficiary_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `provider` VARCHAR(32) NOT NULL,
+    `provider_reference` VARCHAR(255) NULL DEFAULT NULL,
+    `status` ENUM('PENDING', 'PROCESSING', 'COMPLETED', 'FAILED', 'CANCELLED') NOT NULL DEFAULT 'PENDING',
+    `failure_reason` VARCHAR(255) NOT NULL DEFAULT '',
+    `attempts` INT NOT NULL DEFAULT 0,
+    `next_attempt_at` TIMESTAMP NULL DEFAULT NULL,
+    `completed_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_withdrawals_provider_reference` (`provider`, `provider_reference`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`beneficiary_id`) REFERENCES `bank_beneficiaries`(`id`)
+);
+
+CREATE INDEX `idx_withdrawals_user` ON `withdrawals`(`user_id`, `id`);
+CREATE INDEX `idx_withdrawals_status_next_attempt` ON `withdrawals`(`status`, `next_attempt_at`);
+
+ALTER TABLE `ledger_entries`
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT', 'WITHDRAWAL') NOT NULL,
+    ADD COLUMN `withdrawal_id` BIGINT UNSIGNED NULL DEFAULT NULL AFTER `deposit_id`,
+    ADD CONSTRAINT `fk_ledger_entries_withdrawal` FOREIGN KEY (`withdrawal_id`) REFERENCES `withdrawals`(`id`);
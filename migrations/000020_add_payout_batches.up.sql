@@ -0,0 +1,44 @@
+-- A payout batch pays many recipients out of one wallet. Its total is held
+-- on the wallet when the batch is accepted; reserved_amount is the part of
+-- the hold still waiting on items, which the worker settles one by one.
+CREATE TABLE `payout_batches`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `total_amount` DECIMAL(19,4) NOT NULL,
+    `reserved_amount` DECIMAL(19,4) NOT NULL,
+    `item_count` INT NOT NULL,
+    `succeeded_count` INT NOT NULL DEFAULT 0,
+    `failed_count` INT NOT NULL DEFAULT 0,
+    `status` ENUM('PROCESSING', 'COMPLETED') NOT NULL DEFAULT 'PROCESSING',
+    `completed_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+CREATE INDEX `idx_payout_batches_user` ON `payout_batches`(`user_id`, `id`);
+
+-- One row of a batch file. transaction_id is set once the item is paid.
+CREATE TABLE `payout_batch_items`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `batch_id` BIGINT UNSIGNED NOT NULL,
+    `line_number` INT NOT NULL,
+    `recipient_user_id` BIGINT UNSIGNED NOT NULL,
+    `receiver_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `reference` VARCHAR(255) NOT NULL,
+    `status` ENUM('PENDING', 'COMPLETED', 'FAILED') NOT NULL DEFAULT 'PENDING',
+    `transaction_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `failure_reason` VARCHAR(255) NOT NULL DEFAULT '',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_payout_batch_items_reference` (`batch_id`, `reference`),
+    FOREIGN KEY (`batch_id`) REFERENCES `payout_batches`(`id`),
+    FOREIGN KEY (`receiver_wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE `transactions`
+    DROP FOREIGN KEY `fk_transactions_split_payment`,
+    DROP COLUMN `split_payment_id`;
+DROP TABLE IF EXISTS `split_payments`;
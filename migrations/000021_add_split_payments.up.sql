@@ -0,0 +1,22 @@
+-- A split payment debits one wallet and credits several recipients. Each
+-- recipient's share is a transaction with split_payment_id set; the worker
+-- settles all of them together or fails all of them.
+CREATE TABLE `split_payments`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `sender_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `description` VARCHAR(255) NOT NULL DEFAULT '',
+    `status` ENUM('PENDING', 'COMPLETED', 'FAILED') NOT NULL DEFAULT 'PENDING',
+    `failure_reason` VARCHAR(255) NOT NULL DEFAULT '',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`sender_wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+CREATE INDEX `idx_split_payments_sender` ON `split_payments`(`sender_wallet_id`, `id`);
+
+ALTER TABLE `transactions`
+    ADD COLUMN `split_payment_id` BIGINT UNSIGNED NULL DEFAULT NULL AFTER `fee_wallet_id`,
+    ADD CONSTRAINT `fk_transactions_split_payment` FOREIGN KEY (`split_payment_id`) REFERENCES `split_payments`(`id`);
@@ -0,0 +1,7 @@
+DELETE FROM `ledger_entries` WHERE `escrow_id` IS NOT NULL;
+ALTER TABLE `ledger_entries`
+    DROP FOREIGN KEY `fk_ledger_entries_escrow`,
+    DROP COLUMN `escrow_id`,
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT', 'WITHDRAWAL') NOT NULL;
+DROP TABLE IF EXISTS `escrows`;
+DROP TABLE IF EXISTS `escrow_wallets`;
@@ -0,0 +1,51 @@
+-- escrow_wallets names the house wallet holding escrowed funds in each
+-- currency.
+CREATE TABLE `escrow_wallets`(
+    `currency` VARCHAR(3) NOT NULL,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (`currency`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+-- An escrow moves its amount from the payer's wallet to the escrow wallet of
+-- its currency when it is created, and out of it to the payee when it is
+-- released or back to the payer when it is refunded. The worker releases
+-- FUNDED escrows once release_after has passed; a DISPUTED escrow waits for
+-- its payer, payee or arbiter. settled_by_user_id is NULL for escrows the
+-- worker released.
+CREATE TABLE `escrows`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `payer_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `payee_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `arbiter_user_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `escrow_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `description` VARCHAR(255) NOT NULL DEFAULT '',
+    `status` ENUM('FUNDED', 'DISPUTED', 'RELEASED', 'REFUNDED') NOT NULL DEFAULT 'FUNDED',
+    `release_after` TIMESTAMP NOT NULL,
+    `disputed_by_user_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `dispute_reason` VARCHAR(1000) NOT NULL DEFAULT '',
+    `disputed_at` TIMESTAMP NULL DEFAULT NULL,
+    `settled_by_user_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `settled_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`payer_wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`payee_wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`arbiter_user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`escrow_wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`disputed_by_user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`settled_by_user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE INDEX `idx_escrows_payer` ON `escrows`(`payer_wallet_id`, `id`);
+CREATE INDEX `idx_escrows_payee` ON `escrows`(`payee_wallet_id`, `id`);
+CREATE INDEX `idx_escrows_arbiter` ON `escrows`(`arbiter_user_id`, `id`);
+CREATE INDEX `idx_escrows_status_release_after` ON `escrows`(`status`, `release_after`);
+
+ALTER TABLE `ledger_entries`
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT', 'WITHDRAWAL', 'ESCROW') NOT NULL,
+    ADD COLUMN `escrow_id` BIGINT UNSIGNED NULL DEFAULT NULL AFTER `withdrawal_id`,
+    ADD CONSTRAINT `fk_ledger_entries_escrow` FOREIGN KEY (`escrow_id`) REFERENCES `escrows`(`id`);
@@ -0,0 +1,7 @@
+DELETE FROM `ledger_entries` WHERE `dispute_id` IS NOT NULL;
+ALTER TABLE `ledger_entries`
+    DROP FOREIGN KEY `fk_ledger_entries_dispute`,
+    DROP COLUMN `dispute_id`,
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT', 'WITHDRAWAL', 'ESCROW') NOT NULL;
+DROP TABLE IF EXISTS `dispute_evidence`;
+DROP TABLE IF EXISTS `disputes`;
@@ -0,0 +1,55 @@
+-- A dispute contests a completed transaction. Opening it debits the
+-- receiver's wallet by amount and credits the sender's, where the amount is
+-- also held until the dispute is resolved. The receiver answers with evidence
+-- before respond_by; an admin then resolves it for the payer, which releases
+-- the hold, or for the merchant, which moves the amount back. The worker
+-- resolves OPEN disputes for the payer once respond_by has passed.
+CREATE TABLE `disputes`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `transaction_id` BIGINT UNSIGNED NOT NULL,
+    `payer_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `merchant_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `reason` VARCHAR(1000) NOT NULL,
+    `status` ENUM('OPEN', 'UNDER_REVIEW', 'PAYER_WON', 'MERCHANT_WON', 'WITHDRAWN') NOT NULL DEFAULT 'OPEN',
+    `respond_by` TIMESTAMP NOT NULL,
+    `responded_at` TIMESTAMP NULL DEFAULT NULL,
+    `resolution_note` VARCHAR(1000) NOT NULL DEFAULT '',
+    `resolved_by_user_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `resolved_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_disputes_transaction` (`transaction_id`),
+    FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`),
+    FOREIGN KEY (`payer_wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`merchant_wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`resolved_by_user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE INDEX `idx_disputes_payer` ON `disputes`(`payer_wallet_id`, `id`);
+CREATE INDEX `idx_disputes_merchant` ON `disputes`(`merchant_wallet_id`, `id`);
+CREATE INDEX `idx_disputes_status_respond_by` ON `disputes`(`status`, `respond_by`);
+
+-- file_refs lists references to files kept elsewhere, such as URLs or
+-- document IDs; the files themselves are not stored.
+CREATE TABLE `dispute_evidence`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `dispute_id` BIGINT UNSIGNED NOT NULL,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `party` ENUM('PAYER', 'MERCHANT') NOT NULL,
+    `text` TEXT NOT NULL,
+    `file_refs` JSON NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`dispute_id`) REFERENCES `disputes`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE INDEX `idx_dispute_evidence_dispute` ON `dispute_evidence`(`dispute_id`, `id`);
+
+ALTER TABLE `ledger_entries`
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT', 'WITHDRAWAL', 'ESCROW', 'DISPUTE') NOT NULL,
+    ADD COLUMN `dispute_id` BIGINT UNSIGNED NULL DEFAULT NULL AFTER `escrow_id`,
+    ADD CONSTRAINT `fk_ledger_entries_dispute` FOREIGN KEY (`dispute_id`) REFERENCES `disputes`(`id`);
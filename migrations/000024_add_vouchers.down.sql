@@ -0,0 +1,9 @@
+DELETE FROM `ledger_entries` WHERE `voucher_redemption_id` IS NOT NULL;
+ALTER TABLE `ledger_entries`
+    DROP FOREIGN KEY `fk_ledger_entries_voucher_redemption`,
+    DROP COLUMN `voucher_redemption_id`,
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT', 'WITHDRAWAL', 'ESCROW', 'DISPUTE') NOT NULL;
+DROP TABLE IF EXISTS `voucher_redemptions`;
+DROP TABLE IF EXISTS `vouchers`;
+DROP TABLE IF EXISTS `voucher_batches`;
+DROP TABLE IF EXISTS `promo_wallets`;
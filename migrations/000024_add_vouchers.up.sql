@@ -0,0 +1,74 @@
+-- promo_wallets names the house wallet that funds voucher redemptions in
+-- each currency.
+CREATE TABLE `promo_wallets`(
+    `currency` VARCHAR(3) NOT NULL,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (`currency`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+-- A voucher batch is a set of codes worth amount each. Every code can be
+-- redeemed by up to max_redemptions users, once each, and a user can redeem
+-- at most per_user_limit codes of the batch. redemption_count and
+-- redeemed_amount total the batch's redemptions.
+CREATE TABLE `voucher_batches`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `name` VARCHAR(255) NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `promo_wallet_id` BIGINT UNSIGNED NOT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    `max_redemptions` INT UNSIGNED NOT NULL DEFAULT 1,
+    `per_user_limit` INT UNSIGNED NOT NULL DEFAULT 1,
+    `code_count` INT UNSIGNED NOT NULL,
+    `redemption_count` INT UNSIGNED NOT NULL DEFAULT 0,
+    `redeemed_amount` DECIMAL(19,4) NOT NULL DEFAULT 0,
+    `status` ENUM('ACTIVE', 'DISABLED') NOT NULL DEFAULT 'ACTIVE',
+    `created_by_user_id` BIGINT UNSIGNED NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    FOREIGN KEY (`promo_wallet_id`) REFERENCES `wallets`(`id`),
+    FOREIGN KEY (`created_by_user_id`) REFERENCES `users`(`id`)
+);
+
+-- Codes are stored as the SHA-256 of their normalized form; code_hint is
+-- their last four characters.
+CREATE TABLE `vouchers`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `batch_id` BIGINT UNSIGNED NOT NULL,
+    `code_hash` CHAR(64) NOT NULL,
+    `code_hint` VARCHAR(4) NOT NULL,
+    `redemption_count` INT UNSIGNED NOT NULL DEFAULT 0,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_vouchers_code_hash` (`code_hash`),
+    FOREIGN KEY (`batch_id`) REFERENCES `voucher_batches`(`id`)
+);
+
+CREATE INDEX `idx_vouchers_batch` ON `vouchers`(`batch_id`, `id`);
+
+CREATE TABLE `voucher_redemptions`(
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `voucher_id` BIGINT UNSIGNED NOT NULL,
+    `batch_id` BIGINT UNSIGNED NOT NULL,
+    `user_id` BIGINT UNSIGNED NOT NULL,
+    `wallet_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(19,4) NOT NULL,
+    `currency` VARCHAR(3) NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_voucher_redemptions_voucher_user` (`voucher_id`, `user_id`),
+    FOREIGN KEY (`voucher_id`) REFERENCES `vouchers`(`id`),
+    FOREIGN KEY (`batch_id`) REFERENCES `voucher_batches`(`id`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`wallet_id`) REFERENCES `wallets`(`id`)
+);
+
+CREATE INDEX `idx_voucher_redemptions_batch_user` ON `voucher_redemptions`(`batch_id`, `user_id`);
+CREATE INDEX `idx_voucher_redemptions_user` ON `voucher_redemptions`(`user_id`, `id`);
+
+ALTER TABLE `ledger_entries`
+    MODIFY COLUMN `type` ENUM('PRINCIPAL', 'FEE', 'DEPOSIT', 'WITHDRAWAL', 'ESCROW', 'DISPUTE', 'VOUCHER') NOT NULL,
+    ADD COLUMN `voucher_redemption_id` BIGINT UNSIGNED NULL DEFAULT NULL AFTER `dispute_id`,
+    ADD CONSTRAINT `fk_ledger_entries_voucher_redemption` FOREIGN KEY (`voucher_redemption_id`) REFERENCES `voucher_redemptions`(`id`);
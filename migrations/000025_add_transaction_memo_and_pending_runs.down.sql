@@ -0,0 +1,6 @@
+UPDATE `scheduled_transfer_runs` SET `status` = 'SUCCEEDED' WHERE `status` = 'PENDING';
+
+ALTER TABLE `scheduled_transfer_runs`
+    MODIFY `status` ENUM('SUCCEEDED', 'FAILED', 'SKIPPED') NOT NULL;
+
+ALTER TABLE `transactions` DROP COLUMN `memo`;
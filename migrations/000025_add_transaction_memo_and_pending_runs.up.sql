@@ -0,0 +1,5 @@
+ALTER TABLE `transactions` ADD COLUMN `memo` VARCHAR(255) NOT NULL DEFAULT '' AFTER `fee`;
+
+-- A run that created its transaction is PENDING until the transaction settles.
+ALTER TABLE `scheduled_transfer_runs`
+    MODIFY `status` ENUM('PENDING', 'SUCCEEDED', 'FAILED', 'SKIPPED') NOT NULL;
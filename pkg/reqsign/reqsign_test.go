@@ -0,0 +1,159 @@
+package reqsign
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret = "sk_test_secret"
+	testNonce  = "0123456789abcdef-_XY"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   []byte
+		want   string
+	}{
+		{
+			name:   "empty body",
+			method: "GET",
+			path:   "/api/v1/wallets/me",
+			want:   "GET\n/api/v1/wallets/me\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n1700000000\n" + testNonce,
+		},
+		{
+			name:   "body and query",
+			method: "POST",
+			path:   "/api/v1/transactions?dry_run=true",
+			body:   []byte("abc"),
+			want:   "POST\n/api/v1/transactions?dry_run=true\nba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad\n1700000000\n" + testNonce,
+		},
+		{
+			name:   "method is upper-cased",
+			method: "delete",
+			path:   "/api/v1/webhooks/1",
+			want:   "DELETE\n/api/v1/webhooks/1\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n1700000000\n" + testNonce,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Canonical(tt.method, tt.path, tt.body, "1700000000", testNonce); got != tt.want {
+				t.Errorf("Canonical() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"amount":"10.00"}`)
+	path := "/api/v1/transactions?dry_run=true"
+	signature := Sign(testSecret, "POST", path, body, timestamp, testNonce)
+
+	at := func(d time.Duration) string { return strconv.FormatInt(now.Add(d).Unix(), 10) }
+	signedAt := func(d time.Duration) string { return Sign(testSecret, "POST", path, body, at(d), testNonce) }
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		method    string
+		path      string
+		body      string
+		timestamp string
+		nonce     string
+		want      error
+	}{
+		{name: "valid", want: nil},
+		{name: "lower-case method", method: "post", want: nil},
+		{name: "oldest allowed timestamp", timestamp: at(-DefaultMaxSkew), signature: signedAt(-DefaultMaxSkew), want: nil},
+		{name: "newest allowed timestamp", timestamp: at(DefaultMaxSkew), signature: signedAt(DefaultMaxSkew), want: nil},
+		{name: "timestamp too old", timestamp: at(-DefaultMaxSkew - time.Second), signature: signedAt(-DefaultMaxSkew - time.Second), want: ErrInvalidTimestamp},
+		{name: "timestamp too new", timestamp: at(DefaultMaxSkew + time.Second), signature: signedAt(DefaultMaxSkew + time.Second), want: ErrInvalidTimestamp},
+		{name: "malformed timestamp", timestamp: "yesterday", want: ErrInvalidTimestamp},
+		{name: "timestamp changed after signing", timestamp: at(time.Second), want: ErrInvalidSignature},
+		{name: "tampered body", body: `{"amount":"99.00"}`, want: ErrInvalidSignature},
+		{name: "tampered path", path: "/api/v1/withdrawals?dry_run=true", want: ErrInvalidSignature},
+		{name: "tampered query", path: "/api/v1/transactions?dry_run=false", want: ErrInvalidSignature},
+		{name: "query dropped", path: "/api/v1/transactions", want: ErrInvalidSignature},
+		{name: "other method", method: "PUT", want: ErrInvalidSignature},
+		{name: "other nonce", nonce: "fedcba9876543210", want: ErrInvalidSignature},
+		{name: "wrong secret", secret: "sk_other", want: ErrInvalidSignature},
+		{name: "unknown version", signature: "v2=" + strings.TrimPrefix(signature, "v1="), want: ErrInvalidSignature},
+		{name: "not hex", signature: "v1=zz", want: ErrInvalidSignature},
+		{name: "short nonce", nonce: "tooshort", want: ErrInvalidNonce},
+		{name: "nonce with invalid characters", nonce: "0123456789abcdef!", want: ErrInvalidNonce},
+		{name: "missing signature", signature: "-", want: ErrMissingSignature},
+		{name: "missing nonce", nonce: "-", want: ErrMissingSignature},
+	}
+
+	or := func(value, fallback string) string {
+		switch value {
+		case "":
+			return fallback
+		case "-":
+			return ""
+		}
+		return value
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(or(tt.secret, testSecret), or(tt.signature, signature), or(tt.method, "POST"), or(tt.path, path),
+				[]byte(or(tt.body, string(body))), or(tt.timestamp, timestamp), or(tt.nonce, testNonce), DefaultMaxSkew, now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	now := time.Now()
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/api/v1/transactions?dry_run=true", strings.NewReader(`{"amount":"1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SignRequest(req, testSecret, now); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+
+	// The body is still readable after signing.
+	body, err := io.ReadAll(req.Body)
+	if err != nil || string(body) != `{"amount":"1"}` {
+		t.Fatalf("body after signing = %q, %v", body, err)
+	}
+
+	err = Verify(testSecret, req.Header.Get(SignatureHeader), req.Method, "/api/v1/transactions?dry_run=true", body,
+		req.Header.Get(TimestampHeader), req.Header.Get(NonceHeader), DefaultMaxSkew, now)
+	if err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestNewNonce(t *testing.T) {
+	a, err := NewNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ValidNonce(a) || !ValidNonce(b) || a == b {
+		t.Errorf("NewNonce() = %q, %q, want two different valid nonces", a, b)
+	}
+}
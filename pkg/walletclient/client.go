@@ -0,0 +1,156 @@
+// Package walletclient is a minimal client for calling the wallet API from a
+// backend with an API key. When the client has the key's signing secret,
+// every request is signed with the reqsign scheme, which keys created with
+// require_signature need and which transfers above the step-up threshold
+// need.
+//
+//	c := walletclient.New("https://wallet.example.com", apiKey, signingSecret)
+//	var tx map[string]any
+//	err := c.Do(ctx, http.MethodPost, "/transfers", map[string]any{
+//		"receiver_user_id": 42,
+//		"amount":           "1500.00",
+//	}, &tx)
+//
+// Upload and Download send and fetch bodies other than JSON, such as payout
+// batch files and their results.
+package walletclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amankp-zop/wallet/pkg/reqsign"
+)
+
+// maxErrorBody bounds how much of an error response is kept in an Error.
+const maxErrorBody = 4096
+
+// Client sends JSON requests authenticated with an API key. Requests are
+// signed when SigningSecret is set.
+type Client struct {
+	BaseURL       string
+	APIKey        string
+	SigningSecret string
+	HTTPClient    *http.Client
+
+	// Now returns the time used for signatures; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// New returns a client for the API at baseURL. signingSecret may be empty to
+// send unsigned requests.
+func New(baseURL, apiKey, signingSecret string) *Client {
+	return &Client{
+		BaseURL:       strings.TrimSuffix(baseURL, "/"),
+		APIKey:        apiKey,
+		SigningSecret: signingSecret,
+		HTTPClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Error is a non-2xx response. The API returns errors as plain text.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wallet api: %d %s", e.StatusCode, e.Message)
+}
+
+// Do sends in, if not nil, as the JSON body of a request to path and decodes
+// the JSON response into out, if not nil. Non-2xx responses are returned as
+// *Error.
+func (c *Client) Do(ctx context.Context, method, path string, in, out any) error {
+	var body []byte
+	contentType := ""
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body, contentType = b, "application/json"
+	}
+
+	return c.Upload(ctx, method, path, contentType, body, out)
+}
+
+// Upload sends body, if not nil, as a request to path with the given content
+// type and decodes the JSON response into out, if not nil. Non-2xx responses
+// are returned as *Error.
+func (c *Client) Upload(ctx context.Context, method, path, contentType string, body []byte, out any) error {
+	resp, err := c.send(ctx, method, path, contentType, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Download copies the body of a GET of path to w. accept is sent as the
+// Accept header. Non-2xx responses are returned as *Error.
+func (c *Client) Download(ctx context.Context, path, accept string, w io.Writer) error {
+	resp, err := c.send(ctx, http.MethodGet, path, "", nil, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// send makes an authenticated request and returns the response if it is a
+// 2xx; the caller closes its body.
+func (c *Client) send(ctx context.Context, method, path, contentType string, body []byte, accept string) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("Accept", accept)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if c.SigningSecret != "" {
+		now := time.Now
+		if c.Now != nil {
+			now = c.Now
+		}
+
+		if err := reqsign.SignRequest(req, c.SigningSecret, now()); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+
+	return resp, nil
+}